@@ -103,13 +103,12 @@ func (agent *Agent) handleConn(conn *ssh.ServerConn, chans <-chan ssh.NewChannel
 			return
 		}
 
-		for req := range requests {
-			if req.Type != "exec" {
-				log.Errorf("rejecting non-exec channel request (type=%s)\n", req.Type)
-				req.Reply(false, nil)
-				continue
-			}
-
+		// watch for signal requests from the SHIELD core (i.e. when
+		// a task is canceled or overruns its deadline), and relay them
+		// to the running command.  if the core hangs up on us, that
+		// counts as a cancellation too.
+		execs, signals := DemuxRequests(requests)
+		for req := range execs {
 			req.Reply(true, nil)
 
 			command, err := ParseCommandFromSSHRequest(req)
@@ -144,7 +143,13 @@ func (agent *Agent) handleConn(conn *ssh.ServerConn, chans <-chan ssh.NewChannel
 				close(done)
 			}(channel, output, done)
 
-			err = agent.Execute(command, output)
+			// imports of uploaded data read it from the SSH channel,
+			// which the SHIELD core closes when it has sent it all.
+			var input io.Reader
+			if command.Op == "import" {
+				input = channel
+			}
+			err = agent.ExecuteWithInput(command, input, output, signals)
 			<-done
 			var rc int
 			if exitErr, ok := err.(*exec.ExitError); ok {
@@ -182,7 +187,7 @@ func (agent *Agent) handleConn(conn *ssh.ServerConn, chans <-chan ssh.NewChannel
 						}
 						channel.SendRequest("exit-signal", false, ssh.Marshal(&sigMsg))
 						channel.Close()
-						continue
+						break
 					}
 				}
 			} else if err != nil {
@@ -196,6 +201,7 @@ func (agent *Agent) handleConn(conn *ssh.ServerConn, chans <-chan ssh.NewChannel
 			log.Infof("Task completed with rc=%d", rc)
 			channel.SendRequest("exit-status", false, encodeExitCode(rc))
 			channel.Close()
+			break
 		}
 	}
 }
@@ -217,6 +223,69 @@ var SIGSTRING = map[syscall.Signal]string{
 	syscall.SIGUSR2: "USR2",
 }
 
+// the reverse of SIGSTRING, for relaying signal requests
+// from the SHIELD core to running commands.
+var STRINGSIG = map[string]syscall.Signal{}
+
+func init() {
+	for sig, s := range SIGSTRING {
+		STRINGSIG[s] = sig
+	}
+}
+
+// DemuxRequests reads every request sent across an SSH session
+// channel, so that there is only ever one reader.  Exec requests are
+// handed back on the first channel (unanswered, and one at a time),
+// and the names of signals to relay to the running command on the
+// second.  Anything else (i.e. exit-status requests from a confused
+// peer) is refused.
+//
+// When the peer goes away, both channels are closed.
+func DemuxRequests(requests <-chan *ssh.Request) (<-chan *ssh.Request, <-chan string) {
+	execs := make(chan *ssh.Request, 1)
+	signals := make(chan string, 4)
+
+	go func() {
+		for req := range requests {
+			switch req.Type {
+			case "exec":
+				// never block on a second exec; signals
+				// for the first have to keep flowing.
+				select {
+				case execs <- req:
+				default:
+					log.Errorf("rejecting exec request; a command is already running\n")
+					req.Reply(false, nil)
+				}
+
+			case "signal":
+				var msg struct {
+					Signal string
+				}
+				if err := ssh.Unmarshal(req.Payload, &msg); err != nil {
+					log.Errorf("ignoring malformed signal request: %s\n", err)
+					req.Reply(false, nil)
+					continue
+				}
+				req.Reply(true, nil)
+
+				select {
+				case signals <- msg.Signal:
+				default:
+				}
+
+			default:
+				log.Errorf("rejecting non-exec channel request (type=%s)\n", req.Type)
+				req.Reply(false, nil)
+			}
+		}
+		close(execs)
+		close(signals)
+	}()
+
+	return execs, signals
+}
+
 func encodeExitCode(rc int) []byte {
 	b := make([]byte, 4)
 	binary.BigEndian.PutUint32(b, uint32(rc))
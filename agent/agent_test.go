@@ -7,6 +7,7 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
 
 	. "github.com/shieldproject/shield/agent"
 )
@@ -214,6 +215,44 @@ var _ = Describe("Agent", func() {
 			Ω(err.Error()).Should(MatchRegexp(`missing required 'restore_key'`))
 		})
 
+		It("errors for a hold payload missing required 'restore_key' field", func() {
+			_, err := ParseCommand([]byte(`
+				{
+					"task_uuid"      : "d9b66d82-b016-4e4a-8d7a-800ef9699112",
+					"operation"      : "hold",
+					"store_plugin"   : "plugin",
+					"store_endpoint" : "endpoint"
+				}
+			`))
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(MatchRegexp(`missing required 'restore_key'`))
+		})
+
+		It("errors for a download payload missing required 'restore_key' field", func() {
+			_, err := ParseCommand([]byte(`
+				{
+					"task_uuid"      : "d9b66d82-b016-4e4a-8d7a-800ef9699112",
+					"operation"      : "download",
+					"store_plugin"   : "plugin",
+					"store_endpoint" : "endpoint"
+				}
+			`))
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(MatchRegexp(`missing required 'restore_key'`))
+		})
+
+		It("errors for an import payload missing required 'store_plugin' field", func() {
+			_, err := ParseCommand([]byte(`
+				{
+					"task_uuid"      : "d9b66d82-b016-4e4a-8d7a-800ef9699112",
+					"operation"      : "import",
+					"store_endpoint" : "endpoint"
+				}
+			`))
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(MatchRegexp(`missing required 'store_plugin'`))
+		})
+
 		It("errors for a payload with unsupported 'operation' field", func() {
 			_, err := ParseCommand([]byte(`
 				{
@@ -525,6 +564,51 @@ var _ = Describe("Agent", func() {
 		})
 	})
 
+	Describe("Session Requests", func() {
+		request := func(typ string, payload []byte) *ssh.Request {
+			return &ssh.Request{Type: typ, Payload: payload}
+		}
+
+		It("separates signals from exec requests, with a single reader", func() {
+			in := make(chan *ssh.Request, 4)
+			in <- request("signal", ssh.Marshal(&struct{ Signal string }{"TERM"}))
+			in <- request("exit-status", ssh.Marshal(&struct{ Status uint32 }{0}))
+			in <- request("exec", ssh.Marshal(&struct{ Command string }{"{}"}))
+			in <- request("signal", ssh.Marshal(&struct{ Signal string }{"KILL"}))
+			close(in)
+
+			execs, signals := DemuxRequests(in)
+
+			var got []string
+			for sig := range signals {
+				got = append(got, sig)
+			}
+			Ω(got).Should(Equal([]string{"TERM", "KILL"}))
+
+			req, ok := <-execs
+			Ω(ok).Should(BeTrue())
+			Ω(req.Type).Should(Equal("exec"))
+
+			_, ok = <-execs
+			Ω(ok).Should(BeFalse())
+		})
+
+		It("refuses a second exec request, without holding up signals", func() {
+			in := make(chan *ssh.Request, 4)
+			in <- request("exec", ssh.Marshal(&struct{ Command string }{"{}"}))
+			in <- request("exec", ssh.Marshal(&struct{ Command string }{"{}"}))
+			in <- request("signal", ssh.Marshal(&struct{ Signal string }{"INT"}))
+			close(in)
+
+			execs, signals := DemuxRequests(in)
+			Eventually(signals).Should(Receive(Equal("INT")))
+			Eventually(signals).Should(BeClosed())
+
+			Ω(execs).Should(Receive())
+			Ω(execs).ShouldNot(Receive())
+		})
+	})
+
 	Describe("SSH Server", func() {
 		Endpoint := "127.0.0.1:9122"
 		var ag *Agent
@@ -11,6 +11,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"syscall"
 
 	"github.com/jhunt/go-log"
 	"golang.org/x/crypto/ssh"
@@ -73,7 +74,7 @@ func ParseCommand(b []byte) (*Command, error) {
 			return nil, fmt.Errorf("missing required 'restore_key' value in payload (for restore operation)")
 		}
 
-	case "purge":
+	case "purge", "hold", "release", "download":
 		if cmd.StorePlugin == "" {
 			return nil, fmt.Errorf("missing required 'store_plugin' value in payload")
 		}
@@ -81,7 +82,15 @@ func ParseCommand(b []byte) (*Command, error) {
 			return nil, fmt.Errorf("missing required 'store_endpoint' value in payload")
 		}
 		if cmd.RestoreKey == "" {
-			return nil, fmt.Errorf("missing required 'restore_key' value in payload (for purge operation)")
+			return nil, fmt.Errorf("missing required 'restore_key' value in payload (for %s operation)", cmd.Op)
+		}
+
+	case "import":
+		if cmd.StorePlugin == "" {
+			return nil, fmt.Errorf("missing required 'store_plugin' value in payload")
+		}
+		if cmd.StoreEndpoint == "" {
+			return nil, fmt.Errorf("missing required 'store_endpoint' value in payload")
 		}
 
 	case "test-store":
@@ -126,6 +135,26 @@ func (c *Command) Details() string {
 		return fmt.Sprintf("purge of [%s] from store '%s'",
 			c.RestoreKey, c.StorePlugin)
 
+	case "hold":
+		return fmt.Sprintf("legal hold of [%s] in store '%s'",
+			c.RestoreKey, c.StorePlugin)
+
+	case "release":
+		return fmt.Sprintf("release of legal hold on [%s] in store '%s'",
+			c.RestoreKey, c.StorePlugin)
+
+	case "download":
+		return fmt.Sprintf("download of [%s] from store '%s'",
+			c.RestoreKey, c.StorePlugin)
+
+	case "import":
+		if c.RestoreKey != "" {
+			return fmt.Sprintf("import of [%s] into store '%s' with task_uuid '%s'",
+				c.RestoreKey, c.StorePlugin, c.TaskUUID)
+		}
+		return fmt.Sprintf("import of uploaded data into store '%s' with task_uuid '%s'",
+			c.StorePlugin, c.TaskUUID)
+
 	default:
 		return fmt.Sprintf("%s op", c.Op)
 	}
@@ -147,7 +176,22 @@ func appendEndpointVariables(env []string, prefix, raw string) []string {
 }
 
 func (agent *Agent) Execute(c *Command, out chan string) error {
+	return agent.ExecuteWithSignals(c, out, nil)
+}
+
+func (agent *Agent) ExecuteWithSignals(c *Command, out chan string, signals <-chan string) error {
+	return agent.ExecuteWithInput(c, nil, out, signals)
+}
+
+// ExecuteWithInput runs a command, like ExecuteWithSignals, feeding it
+// the given input on its standard input (for imports, which upload the
+// data to be stored.)  If in is nil, the command gets no input at all.
+func (agent *Agent) ExecuteWithInput(c *Command, in io.Reader, out chan string, signals <-chan string) error {
 	cmd := exec.Command("shield-pipe")
+	cmd.Stdin = in
+	/* run shield-pipe (and the plugins it spawns) in its own
+	   process group, so that we can signal the whole pipeline. */
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 
 	log.Infof("Executing %s via shield-pipe", c.Details())
 	cmd.Env = []string{
@@ -212,6 +256,34 @@ func (agent *Agent) Execute(c *Command, out chan string) error {
 		return err
 	}
 
+	finished := make(chan bool)
+	defer close(finished)
+	if signals != nil {
+		go func() {
+			for {
+				select {
+				case <-finished:
+					return
+
+				case name, ok := <-signals:
+					if !ok {
+						log.Infof("SHIELD core disconnected; terminating %s", c.Details())
+						syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+						return
+					}
+
+					sig, known := STRINGSIG[name]
+					if !known {
+						log.Errorf("ignoring unrecognized signal SIG%s for %s", name, c.Details())
+						continue
+					}
+					log.Infof("relaying SIG%s to %s", name, c.Details())
+					syscall.Kill(-cmd.Process.Pid, sig)
+				}
+			}
+		}()
+	}
+
 	wg.Wait()
 	close(out)
 
@@ -0,0 +1,96 @@
+package shield
+
+import (
+	"fmt"
+	"strings"
+
+	qs "github.com/jhunt/go-querytron"
+	"github.com/pborman/uuid"
+)
+
+type Approval struct {
+	UUID        string `json:"uuid"`
+	TenantUUID  string `json:"tenant_uuid"`
+	Operation   string `json:"operation"`
+	ArchiveUUID string `json:"archive_uuid,omitempty"`
+	TargetUUID  string `json:"target_uuid,omitempty"`
+	Summary     string `json:"summary"`
+
+	RequestedBy string `json:"requested_by"`
+	RequestedAt int64  `json:"requested_at"`
+	ExpiresAt   int64  `json:"expires_at"`
+
+	Status    string `json:"status"`
+	DecidedBy string `json:"decided_by,omitempty"`
+	DecidedAt int64  `json:"decided_at,omitempty"`
+	TaskUUID  string `json:"task_uuid,omitempty"`
+}
+
+// ApprovalPending is returned (as an error) by restores, archive purges,
+// and target deletions, in tenants that require a second person to
+// approve those, to say that a request for approval has been opened,
+// and that nothing has been done yet.
+type ApprovalPending struct {
+	Message  string   `json:"ok"`
+	Approval Approval `json:"approval"`
+}
+
+func (p ApprovalPending) Error() string {
+	return p.Message
+}
+
+type ApprovalFilter struct {
+	Status string `qs:"status"`
+	Limit  *int   `qs:"limit"`
+}
+
+func (c *Client) ListApprovals(parent *Tenant, filter *ApprovalFilter) ([]*Approval, error) {
+	if filter == nil {
+		filter = &ApprovalFilter{}
+	}
+	u := qs.Generate(filter).Encode()
+
+	var out []*Approval
+	return out, c.get(fmt.Sprintf("/v2/tenants/%s/approvals?%s", parent.UUID, u), &out)
+}
+
+func (c *Client) GetApproval(parent *Tenant, uuid string) (*Approval, error) {
+	var out *Approval
+	return out, c.get(fmt.Sprintf("/v2/tenants/%s/approvals/%s", parent.UUID, uuid), &out)
+}
+
+func (c *Client) FindApproval(parent *Tenant, q string) (*Approval, error) {
+	if uuid.Parse(q) != nil {
+		return c.GetApproval(parent, q)
+	}
+
+	l, err := c.ListApprovals(parent, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]*Approval, 0)
+	for _, a := range l {
+		if strings.HasPrefix(a.UUID, q) {
+			found = append(found, a)
+		}
+	}
+
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no matching approval request found")
+	}
+	if len(found) > 1 {
+		return nil, fmt.Errorf("multiple matching approval requests found")
+	}
+	return found[0], nil
+}
+
+func (c *Client) Approve(parent *Tenant, a *Approval) (*Approval, error) {
+	var out *Approval
+	return out, c.post(fmt.Sprintf("/v2/tenants/%s/approvals/%s/approve", parent.UUID, a.UUID), nil, &out)
+}
+
+func (c *Client) Reject(parent *Tenant, a *Approval) (*Approval, error) {
+	var out *Approval
+	return out, c.post(fmt.Sprintf("/v2/tenants/%s/approvals/%s/reject", parent.UUID, a.UUID), nil, &out)
+}
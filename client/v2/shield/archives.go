@@ -1,7 +1,12 @@
 package shield
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 
 	qs "github.com/jhunt/go-querytron"
 	"github.com/pborman/uuid"
@@ -19,6 +24,20 @@ type Archive struct {
 	Compression    string `json:"compression"`
 	EncryptionType string `json:"encryption_type"`
 	Size           int64  `json:"size"`
+
+	TakenAt   int64 `json:"taken_at"`
+	ExpiresAt int64 `json:"expires_at"`
+
+	PurgeReason string `json:"purge_reason"`
+	PurgeAfter  int64  `json:"purge_after"`
+
+	Held       bool   `json:"held"`
+	HoldReason string `json:"hold_reason"`
+	HeldBy     string `json:"held_by"`
+	HeldAt     int64  `json:"held_at"`
+	HoldUntil  int64  `json:"hold_until"`
+
+	Labels map[string]string `json:"labels"`
 }
 
 type ArchiveFilter struct {
@@ -27,9 +46,12 @@ type ArchiveFilter struct {
 	Target string `qs:"target"`
 	Store  string `qs:"store"`
 	Status string `qs:"status"`
+	Search string `qs:"search"`
 	//Before string `qs:"before"`
 	//After string `qs:"after"`
 	Limit *int `qs:"limit"`
+
+	Labels []string /* name=value, as many as you like */
 }
 
 func fixupArchiveResponse(p *Archive) {
@@ -39,7 +61,11 @@ func fixupArchiveRequest(p *Archive) {
 }
 
 func (c *Client) ListArchives(parent *Tenant, filter *ArchiveFilter) ([]*Archive, error) {
-	u := qs.Generate(filter).Encode()
+	q := qs.Generate(filter)
+	for _, label := range filter.Labels {
+		q.Add("label", label)
+	}
+	u := q.Encode()
 	var out []*Archive
 	if err := c.get(fmt.Sprintf("/v2/tenants/%s/archives?%s", parent.UUID, u), &out); err != nil {
 		return nil, err
@@ -107,6 +133,21 @@ func (c *Client) DeleteArchive(parent *Tenant, in *Archive) (Response, error) {
 	return out, c.delete(fmt.Sprintf("/v2/tenants/%s/archives/%s", parent.UUID, in.UUID), &out)
 }
 
+func (c *Client) UndeleteArchive(parent *Tenant, a *Archive, expiresAt int64) (*Archive, error) {
+	var out *Archive
+	in := struct {
+		ExpiresAt int64 `json:"expires_at,omitempty"`
+	}{
+		ExpiresAt: expiresAt,
+	}
+
+	if err := c.post(fmt.Sprintf("/v2/tenants/%s/archives/%s/undelete", parent.UUID, a.UUID), in, &out); err != nil {
+		return nil, err
+	}
+	fixupArchiveResponse(out)
+	return out, nil
+}
+
 func (c *Client) RestoreArchive(parent *Tenant, a *Archive, t *Target) (*Task, error) {
 	var out Task
 	var filter struct {
@@ -120,3 +161,171 @@ func (c *Client) RestoreArchive(parent *Tenant, a *Archive, t *Target) (*Task, e
 	return &out, c.post(fmt.Sprintf("/v2/tenants/%s/archives/%s/restore",
 		parent.UUID, a.UUID), filter, &out)
 }
+
+func (c *Client) RestorePoint(parent *Tenant, t *Target, asOf int64) (*Archive, error) {
+	var out *Archive
+	if err := c.get(fmt.Sprintf("/v2/tenants/%s/targets/%s/restore?as_of=%d", parent.UUID, t.UUID, asOf), &out); err != nil {
+		return nil, err
+	}
+	fixupArchiveResponse(out)
+	return out, nil
+}
+
+func (c *Client) RestoreAsOf(parent *Tenant, t *Target, asOf int64, a *Archive) (*Task, error) {
+	var out Task
+	in := struct {
+		AsOf    int64  `json:"as_of"`
+		Archive string `json:"archive,omitempty"`
+	}{
+		AsOf: asOf,
+	}
+
+	if a != nil {
+		in.Archive = a.UUID
+	}
+
+	return &out, c.post(fmt.Sprintf("/v2/tenants/%s/targets/%s/restore",
+		parent.UUID, t.UUID), in, &out)
+}
+
+// DownloadArchive retrieves the (decrypted) contents of an archive,
+// optionally decompressing them as well.  Along with the contents, it
+// returns the UUID of the download task, which can be checked once the
+// contents have been read, to make sure that all of them were sent.
+// The caller must close the returned reader.
+func (c *Client) DownloadArchive(parent *Tenant, a *Archive, decompress bool) (io.ReadCloser, string, error) {
+	path := fmt.Sprintf("/v2/tenants/%s/archives/%s/download", parent.UUID, a.UUID)
+	if decompress {
+		path += "?decompress=t"
+	}
+
+	req, err := http.NewRequest("GET", path, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	res, err := c.curl(req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if res.StatusCode != 200 {
+		defer res.Body.Close()
+
+		var e Error
+		b, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			return nil, "", err
+		}
+		if err := json.Unmarshal(b, &e); err != nil {
+			return nil, "", err
+		}
+		return nil, "", e
+	}
+
+	return res.Body, res.Header.Get("X-Shield-Task"), nil
+}
+
+// ArchiveImport describes a backup, made outside of SHIELD, to import
+// as an archive of a target system.
+type ArchiveImport struct {
+	Target      string `json:"target"`
+	Store       string `json:"store"`
+	Key         string `json:"key,omitempty"`
+	Compression string `json:"compression,omitempty"`
+	TakenAt     int64  `json:"taken_at,omitempty"`
+	ExpiresAt   int64  `json:"expires_at"`
+	Notes       string `json:"notes,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	Encrypt     bool   `json:"encrypt"`
+}
+
+// ImportArchive imports a backup that is already in cloud storage, at
+// in.Key, either registering it as it is (unencrypted), or storing an
+// encrypted copy of it, if in.Encrypt is set.
+func (c *Client) ImportArchive(parent *Tenant, in *ArchiveImport) (*Archive, error) {
+	var out *Archive
+	if err := c.post(fmt.Sprintf("/v2/tenants/%s/archives/import", parent.UUID), in, &out); err != nil {
+		return nil, err
+	}
+	fixupArchiveResponse(out)
+	return out, nil
+}
+
+// UploadArchive imports a backup by uploading it, from data, into the
+// cloud storage system in.Store, encrypting it along the way if
+// in.Encrypt is set.  in.Key is ignored.
+func (c *Client) UploadArchive(parent *Tenant, in *ArchiveImport, data io.Reader) (*Archive, error) {
+	q := url.Values{}
+	q.Set("target", in.Target)
+	q.Set("store", in.Store)
+	q.Set("expires_at", fmt.Sprintf("%d", in.ExpiresAt))
+	if in.Compression != "" {
+		q.Set("compression", in.Compression)
+	}
+	if in.TakenAt != 0 {
+		q.Set("taken_at", fmt.Sprintf("%d", in.TakenAt))
+	}
+	if in.Notes != "" {
+		q.Set("notes", in.Notes)
+	}
+	if !in.Encrypt {
+		q.Set("encrypt", "f")
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("/v2/tenants/%s/archives/upload?%s", parent.UUID, q.Encode()), data)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	var out *Archive
+	if err := c.request(req, &out); err != nil {
+		return nil, err
+	}
+	fixupArchiveResponse(out)
+	return out, nil
+}
+
+func (c *Client) LabelArchive(parent *Tenant, a *Archive, labels map[string]string) (*Archive, error) {
+	var out *Archive
+	in := struct {
+		Labels map[string]string `json:"labels"`
+	}{
+		Labels: labels,
+	}
+
+	if err := c.put(fmt.Sprintf("/v2/tenants/%s/archives/%s/labels", parent.UUID, a.UUID), in, &out); err != nil {
+		return nil, err
+	}
+	fixupArchiveResponse(out)
+	return out, nil
+}
+
+func (c *Client) UnlabelArchive(parent *Tenant, a *Archive, name string) (Response, error) {
+	var out Response
+	return out, c.delete(fmt.Sprintf("/v2/tenants/%s/archives/%s/labels/%s", parent.UUID, a.UUID, name), &out)
+}
+
+func (c *Client) HoldArchive(parent *Tenant, a *Archive, reason string, until int64) (*Archive, error) {
+	var out *Archive
+	in := struct {
+		Reason string `json:"reason"`
+		Until  int64  `json:"until,omitempty"`
+	}{
+		Reason: reason,
+		Until:  until,
+	}
+
+	if err := c.post(fmt.Sprintf("/v2/tenants/%s/archives/%s/hold", parent.UUID, a.UUID), in, &out); err != nil {
+		return nil, err
+	}
+	fixupArchiveResponse(out)
+	return out, nil
+}
+
+func (c *Client) ReleaseArchive(parent *Tenant, a *Archive) (Response, error) {
+	var out Response
+	return out, c.delete(fmt.Sprintf("/v2/tenants/%s/archives/%s/hold", parent.UUID, a.UUID), &out)
+}
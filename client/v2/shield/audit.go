@@ -0,0 +1,112 @@
+package shield
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	qs "github.com/jhunt/go-querytron"
+)
+
+type AuditEntry struct {
+	Seq         int64           `json:"seq"`
+	UUID        string          `json:"uuid"`
+	At          int64           `json:"at"`
+	Actor       string          `json:"actor"`
+	ActorUUID   string          `json:"actor_uuid"`
+	SessionUUID string          `json:"session_uuid"`
+	TokenUUID   string          `json:"token_uuid"`
+	IP          string          `json:"ip"`
+	Method      string          `json:"method"`
+	Route       string          `json:"route"`
+	Path        string          `json:"path"`
+	Status      int             `json:"status"`
+	TenantUUID  string          `json:"tenant_uuid"`
+	ObjectType  string          `json:"object_type"`
+	ObjectUUID  string          `json:"object_uuid"`
+	Changes     json.RawMessage `json:"changes"`
+	PrevHash    string          `json:"prev_hash"`
+	Hash        string          `json:"hash"`
+}
+
+type AuditFilter struct {
+	Actor  string `qs:"actor"`
+	Tenant string `qs:"tenant"`
+	Type   string `qs:"type"`
+	Object string `qs:"object"`
+	Method string `qs:"method"`
+	Since  int64  `qs:"since"`
+	Until  int64  `qs:"until"`
+	Before int64  `qs:"before"`
+	Limit  *int   `qs:"limit"`
+}
+
+type AuditVerification struct {
+	OK       bool   `json:"ok"`
+	Entries  int64  `json:"entries"`
+	Head     string `json:"head"`
+	BrokenAt int64  `json:"broken_at"`
+	Problem  string `json:"problem"`
+}
+
+func (c *Client) ListAuditLog(filter *AuditFilter) ([]*AuditEntry, error) {
+	var out []*AuditEntry
+	return out, c.get(fmt.Sprintf("/v2/audit?%s", qs.Generate(filter).Encode()), &out)
+}
+
+// ListTenantAuditLog retrieves the audit log entries for a single
+// tenant, which (unlike ListAuditLog) does not require a system role.
+func (c *Client) ListTenantAuditLog(parent *Tenant, filter *AuditFilter) ([]*AuditEntry, error) {
+	f := AuditFilter{}
+	if filter != nil {
+		f = *filter
+	}
+	f.Tenant = ""
+
+	var out []*AuditEntry
+	return out, c.get(fmt.Sprintf("/v2/tenants/%s/audit?%s", parent.UUID, qs.Generate(&f).Encode()), &out)
+}
+
+// ExportAuditLog streams the audit log entries that match the filter
+// (ignoring its Before and Limit), oldest first, as JSON lines.  The
+// caller is responsible for closing the returned reader.
+func (c *Client) ExportAuditLog(filter *AuditFilter) (io.ReadCloser, error) {
+	f := AuditFilter{}
+	if filter != nil {
+		f = *filter
+	}
+	f.Before, f.Limit = 0, nil
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("/v2/audit/export?%s", qs.Generate(&f).Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.curl(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != 200 {
+		defer res.Body.Close()
+
+		var e Error
+		b, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &e); err != nil {
+			return nil, err
+		}
+		return nil, e
+	}
+
+	return res.Body, nil
+}
+
+func (c *Client) VerifyAuditLog() (*AuditVerification, error) {
+	var out AuditVerification
+	return &out, c.get("/v2/audit/verify", &out)
+}
@@ -11,6 +11,8 @@ type AuthMethod interface {
 type LocalAuth struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Provider string `json:"provider,omitempty"`
+	Code     string `json:"code,omitempty"`
 }
 
 func (auth *LocalAuth) Authenticate(c *Client) (bool, error) {
@@ -48,6 +50,20 @@ func (c *Client) Authenticate(auth AuthMethod) error {
 	return nil
 }
 
+// IsMFARequired returns true if err is the SHIELD Core telling us
+// that the account needs a multi-factor authentication code (set
+// LocalAuth.Code and try again.)
+func IsMFARequired(err error) bool {
+	if e, ok := err.(Error); ok {
+		for _, field := range e.Missing {
+			if field == "code" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (c *Client) Logout() error {
 	return c.get("/v2/auth/logout", nil)
 }
@@ -62,16 +78,23 @@ type AuthID struct {
 		SysRole string `json:"sysrole"`
 	} `json:"user"`
 
+	MFA *struct {
+		Enabled  bool `json:"enabled"`
+		Required bool `json:"required"`
+	} `json:"mfa,omitempty"`
+
 	Tenants []struct {
-		UUID string `json:"uuid"`
-		Name string `json:"name"`
-		Role string `json:"role"`
+		UUID        string   `json:"uuid"`
+		Name        string   `json:"name"`
+		Role        string   `json:"role"`
+		Permissions []string `json:"permissions"`
 	} `json:"tenants"`
 
 	Tenant struct {
-		UUID string `json:"uuid"`
-		Name string `json:"name"`
-		Role string `json:"role"`
+		UUID        string   `json:"uuid"`
+		Name        string   `json:"name"`
+		Role        string   `json:"role"`
+		Permissions []string `json:"permissions"`
 	} `json:"tenant"`
 
 	Is struct {
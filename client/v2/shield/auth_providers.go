@@ -12,6 +12,7 @@ type AuthProvider struct {
 	WebEntry string `json:"web_entry"`
 	CLIEntry string `json:"cli_entry"`
 	Redirect string `json:"redirect"`
+	Password bool   `json:"password,omitempty"`
 
 	Properties map[string]interface{} `json:"properties,omitempty"`
 }
@@ -10,6 +10,12 @@ type AuthToken struct {
 	Session   string `json:"session"`
 	CreatedAt int64  `json:"created_at"`
 	LastSeen  int64  `json:"last_seen"`
+
+	Tenant    string   `json:"tenant,omitempty"`
+	Role      string   `json:"role,omitempty"`
+	Ops       []string `json:"ops,omitempty"`
+	Allow     []string `json:"allow,omitempty"`
+	ExpiresAt int64    `json:"expires_at,omitempty"`
 }
 
 func (c *Client) ListAuthTokens() ([]*AuthToken, error) {
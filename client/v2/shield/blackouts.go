@@ -0,0 +1,175 @@
+package shield
+
+import (
+	"fmt"
+
+	qs "github.com/jhunt/go-querytron"
+	"github.com/pborman/uuid"
+)
+
+type Blackout struct {
+	UUID       string `json:"uuid,omitempty"`
+	TenantUUID string `json:"tenant_uuid,omitempty"`
+	TargetUUID string `json:"target_uuid,omitempty"`
+	Name       string `json:"name"`
+	Summary    string `json:"summary"`
+
+	Schedule string `json:"schedule"`
+	Duration int    `json:"duration"`
+	StartsAt int64  `json:"starts_at"`
+	EndsAt   int64  `json:"ends_at"`
+	CatchUp  bool   `json:"catch_up"`
+}
+
+type BlackoutFilter struct {
+	UUID   string `qs:"uuid"`
+	Fuzzy  bool   `qs:"exact:f:t"`
+	Name   string `qs:"name"`
+	Target string `qs:"target"`
+}
+
+func blackoutRequest(in *Blackout) interface{} {
+	return struct {
+		Name     string `json:"name"`
+		Summary  string `json:"summary"`
+		Target   string `json:"target"`
+		Schedule string `json:"schedule"`
+		Duration int    `json:"duration"`
+		StartsAt int64  `json:"starts_at"`
+		EndsAt   int64  `json:"ends_at"`
+		CatchUp  bool   `json:"catch_up"`
+	}{
+		Name:     in.Name,
+		Summary:  in.Summary,
+		Target:   in.TargetUUID,
+		Schedule: in.Schedule,
+		Duration: in.Duration,
+		StartsAt: in.StartsAt,
+		EndsAt:   in.EndsAt,
+		CatchUp:  in.CatchUp,
+	}
+}
+
+func (c *Client) ListBlackouts(parent *Tenant, filter *BlackoutFilter) ([]*Blackout, error) {
+	u := qs.Generate(filter).Encode()
+	var out []*Blackout
+	if err := c.get(fmt.Sprintf("/v2/tenants/%s/blackouts?%s", parent.UUID, u), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) GetBlackout(parent *Tenant, uuid string) (*Blackout, error) {
+	var out *Blackout
+	if err := c.get(fmt.Sprintf("/v2/tenants/%s/blackouts/%s", parent.UUID, uuid), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) FindBlackout(parent *Tenant, q string, fuzzy bool) (*Blackout, error) {
+	if uuid.Parse(q) != nil {
+		return c.GetBlackout(parent, q)
+	}
+
+	l, err := c.ListBlackouts(parent, &BlackoutFilter{
+		UUID:  q,
+		Name:  q,
+		Fuzzy: fuzzy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(l) == 0 {
+		return nil, fmt.Errorf("no matching blackout found")
+	}
+	if len(l) > 1 {
+		return nil, fmt.Errorf("multiple matching blackouts found")
+	}
+
+	return c.GetBlackout(parent, l[0].UUID)
+}
+
+func (c *Client) CreateBlackout(parent *Tenant, in *Blackout) (*Blackout, error) {
+	var out *Blackout
+	if err := c.post(fmt.Sprintf("/v2/tenants/%s/blackouts", parent.UUID), blackoutRequest(in), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) UpdateBlackout(parent *Tenant, in *Blackout) (*Blackout, error) {
+	var out *Blackout
+	if err := c.put(fmt.Sprintf("/v2/tenants/%s/blackouts/%s", parent.UUID, in.UUID), blackoutRequest(in), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) DeleteBlackout(parent *Tenant, in *Blackout) (Response, error) {
+	var out Response
+	return out, c.delete(fmt.Sprintf("/v2/tenants/%s/blackouts/%s", parent.UUID, in.UUID), &out)
+}
+
+func (c *Client) ListGlobalBlackouts(filter *BlackoutFilter) ([]*Blackout, error) {
+	u := qs.Generate(filter).Encode()
+	var out []*Blackout
+	if err := c.get(fmt.Sprintf("/v2/global/blackouts?%s", u), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) GetGlobalBlackout(uuid string) (*Blackout, error) {
+	var out *Blackout
+	if err := c.get(fmt.Sprintf("/v2/global/blackouts/%s", uuid), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) FindGlobalBlackout(q string, fuzzy bool) (*Blackout, error) {
+	if uuid.Parse(q) != nil {
+		return c.GetGlobalBlackout(q)
+	}
+
+	l, err := c.ListGlobalBlackouts(&BlackoutFilter{
+		UUID:  q,
+		Name:  q,
+		Fuzzy: fuzzy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(l) == 0 {
+		return nil, fmt.Errorf("no matching blackout found")
+	}
+	if len(l) > 1 {
+		return nil, fmt.Errorf("multiple matching blackouts found")
+	}
+
+	return c.GetGlobalBlackout(l[0].UUID)
+}
+
+func (c *Client) CreateGlobalBlackout(in *Blackout) (*Blackout, error) {
+	var out *Blackout
+	if err := c.post("/v2/global/blackouts", blackoutRequest(in), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) UpdateGlobalBlackout(in *Blackout) (*Blackout, error) {
+	var out *Blackout
+	if err := c.put(fmt.Sprintf("/v2/global/blackouts/%s", in.UUID), blackoutRequest(in), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) DeleteGlobalBlackout(in *Blackout) (Response, error) {
+	var out Response
+	return out, c.delete(fmt.Sprintf("/v2/global/blackouts/%s", in.UUID), &out)
+}
@@ -0,0 +1,83 @@
+package shield
+
+import (
+	"fmt"
+
+	qs "github.com/jhunt/go-querytron"
+	"github.com/pborman/uuid"
+)
+
+type Calendar struct {
+	UUID    string   `json:"uuid,omitempty"`
+	Name    string   `json:"name"`
+	Summary string   `json:"summary"`
+	Dates   []string `json:"dates"`
+}
+
+type CalendarFilter struct {
+	UUID  string `qs:"uuid"`
+	Fuzzy bool   `qs:"exact:f:t"`
+	Name  string `qs:"name"`
+}
+
+func (c *Client) ListCalendars(filter *CalendarFilter) ([]*Calendar, error) {
+	u := qs.Generate(filter).Encode()
+	var out []*Calendar
+	if err := c.get(fmt.Sprintf("/v2/global/calendars?%s", u), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) GetCalendar(uuid string) (*Calendar, error) {
+	var out *Calendar
+	if err := c.get(fmt.Sprintf("/v2/global/calendars/%s", uuid), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) FindCalendar(q string, fuzzy bool) (*Calendar, error) {
+	if uuid.Parse(q) != nil {
+		return c.GetCalendar(q)
+	}
+
+	l, err := c.ListCalendars(&CalendarFilter{
+		UUID:  q,
+		Name:  q,
+		Fuzzy: fuzzy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(l) == 0 {
+		return nil, fmt.Errorf("no matching calendar found")
+	}
+	if len(l) > 1 {
+		return nil, fmt.Errorf("multiple matching calendars found")
+	}
+
+	return c.GetCalendar(l[0].UUID)
+}
+
+func (c *Client) CreateCalendar(in *Calendar) (*Calendar, error) {
+	var out *Calendar
+	if err := c.post("/v2/global/calendars", in, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) UpdateCalendar(in *Calendar) (*Calendar, error) {
+	var out *Calendar
+	if err := c.put(fmt.Sprintf("/v2/global/calendars/%s", in.UUID), in, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) DeleteCalendar(in *Calendar) (Response, error) {
+	var out Response
+	return out, c.delete(fmt.Sprintf("/v2/global/calendars/%s", in.UUID), &out)
+}
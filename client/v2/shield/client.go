@@ -127,6 +127,18 @@ func (c *Client) request(req *http.Request, out interface{}) error {
 		return nil
 	}
 
+	if res.StatusCode == 202 {
+		var p ApprovalPending
+		b, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(b, &p); err != nil {
+			return err
+		}
+		return p
+	}
+
 	if res.StatusCode == 200 {
 		if session := res.Header.Get("X-Shield-Session"); session != "" {
 			c.Session = session
@@ -2,6 +2,7 @@ package shield
 
 import (
 	"fmt"
+	"net/url"
 
 	qs "github.com/jhunt/go-querytron"
 	"github.com/pborman/uuid"
@@ -22,6 +23,14 @@ type Job struct {
 	FixedKey   bool   `json:"fixed_key"`
 	Retries    int    `json:"retries"`
 
+	MaxRuntime  int    `json:"max_runtime"`
+	WindowStart string `json:"window_start"`
+	WindowEnd   string `json:"window_end"`
+	FinishBy    string `json:"finish_by"`
+
+	Jitter       int  `json:"jitter"`
+	HashedJitter bool `json:"hashed_jitter"`
+
 	TargetUUID string `json:"-"`
 	Target     struct {
 		UUID   string `json:"uuid"`
@@ -123,6 +132,14 @@ func (c *Client) CreateJob(parent *Tenant, job *Job) (*Job, error) {
 		Target   string `json:"target"`
 		FixedKey bool   `json:"fixed_key"`
 		Retries  int    `json:"retries"`
+
+		MaxRuntime  int    `json:"max_runtime"`
+		WindowStart string `json:"window_start"`
+		WindowEnd   string `json:"window_end"`
+		FinishBy    string `json:"finish_by"`
+
+		Jitter       int  `json:"jitter"`
+		HashedJitter bool `json:"hashed_jitter"`
 	}{
 		Name:     job.Name,
 		Summary:  job.Summary,
@@ -133,6 +150,14 @@ func (c *Client) CreateJob(parent *Tenant, job *Job) (*Job, error) {
 		Store:    job.StoreUUID,
 		FixedKey: job.FixedKey,
 		Retries:  job.Retries,
+
+		MaxRuntime:  job.MaxRuntime,
+		WindowStart: job.WindowStart,
+		WindowEnd:   job.WindowEnd,
+		FinishBy:    job.FinishBy,
+
+		Jitter:       job.Jitter,
+		HashedJitter: job.HashedJitter,
 	}
 	if err := c.post(fmt.Sprintf("/v2/tenants/%s/jobs", parent.UUID), in, &out); err != nil {
 		return nil, err
@@ -151,6 +176,14 @@ func (c *Client) UpdateJob(parent *Tenant, job *Job) (*Job, error) {
 		Target   string `json:"target,omitempty"`
 		FixedKey bool   `json:"fixed_key"`
 		Retries  int    `json:"retries"`
+
+		MaxRuntime  int    `json:"max_runtime"`
+		WindowStart string `json:"window_start"`
+		WindowEnd   string `json:"window_end"`
+		FinishBy    string `json:"finish_by"`
+
+		Jitter       int  `json:"jitter"`
+		HashedJitter bool `json:"hashed_jitter"`
 	}{
 		Name:     job.Name,
 		Summary:  job.Summary,
@@ -160,6 +193,14 @@ func (c *Client) UpdateJob(parent *Tenant, job *Job) (*Job, error) {
 		Store:    job.StoreUUID,
 		FixedKey: job.FixedKey,
 		Retries:  job.Retries,
+
+		MaxRuntime:  job.MaxRuntime,
+		WindowStart: job.WindowStart,
+		WindowEnd:   job.WindowEnd,
+		FinishBy:    job.FinishBy,
+
+		Jitter:       job.Jitter,
+		HashedJitter: job.HashedJitter,
 	}
 	if err := c.put(fmt.Sprintf("/v2/tenants/%s/jobs/%s", parent.UUID, job.UUID), in, nil); err != nil {
 		return nil, err
@@ -187,6 +228,29 @@ func (c *Client) RunJob(parent *Tenant, job *Job) (Response, error) {
 	return out, c.post(fmt.Sprintf("/v2/tenants/%s/jobs/%s/run", parent.UUID, job.UUID), nil, &out)
 }
 
+type RetentionSimulation struct {
+	KeepDays int `json:"keep_days"`
+	KeepN    int `json:"keep_n"`
+
+	Keep       []*Archive `json:"keep"`
+	Purge      []*Archive `json:"purge"`
+	KeepBytes  int64      `json:"keep_bytes"`
+	PurgeBytes int64      `json:"purge_bytes"`
+}
+
+func (c *Client) SimulateRetention(parent *Tenant, job *Job, retain string) (*RetentionSimulation, error) {
+	u := url.Values{}
+	if retain != "" {
+		u.Set("retain", retain)
+	}
+
+	var out *RetentionSimulation
+	if err := c.get(fmt.Sprintf("/v2/tenants/%s/jobs/%s/retention?%s", parent.UUID, job.UUID, u.Encode()), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (j Job) Status() string {
 	if j.Paused {
 		return "paused"
@@ -200,3 +264,30 @@ func (j Job) Status() string {
 		return j.LastStatus
 	}
 }
+
+func (j Job) Spread() string {
+	if j.Jitter <= 0 {
+		return "none"
+	}
+	if j.HashedJitter {
+		return fmt.Sprintf("fixed offset within %dm", j.Jitter)
+	}
+	return fmt.Sprintf("random delay of up to %dm", j.Jitter)
+}
+
+func (j Job) Window() string {
+	var s string
+	if j.WindowStart != "" {
+		s = fmt.Sprintf("start between %s and %s", j.WindowStart, j.WindowEnd)
+	}
+	if j.FinishBy != "" {
+		if s != "" {
+			s += ", "
+		}
+		s += fmt.Sprintf("finish by %s", j.FinishBy)
+	}
+	if s == "" {
+		return "anytime"
+	}
+	return s
+}
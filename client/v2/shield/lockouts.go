@@ -0,0 +1,29 @@
+package shield
+
+import (
+	"fmt"
+	"net/url"
+)
+
+type LoginLockout struct {
+	Kind         string `json:"kind"`
+	Name         string `json:"name"`
+	Failures     int    `json:"failures"`
+	LastFailedAt int64  `json:"last_failed_at"`
+	LockedUntil  int64  `json:"locked_until"`
+}
+
+func (c *Client) ListLoginLockouts() ([]*LoginLockout, error) {
+	var out []*LoginLockout
+	return out, c.get("/v2/auth/lockouts", &out)
+}
+
+func (c *Client) ClearLoginLockout(kind, name string) (Response, error) {
+	var r Response
+	return r, c.delete(fmt.Sprintf("/v2/auth/lockouts/%s/%s", url.PathEscape(kind), url.PathEscape(name)), &r)
+}
+
+func (c *Client) UnlockUser(user *User) (Response, error) {
+	var r Response
+	return r, c.post(fmt.Sprintf("/v2/auth/local/users/%s/unlock", user.UUID), nil, &r)
+}
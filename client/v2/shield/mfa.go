@@ -0,0 +1,71 @@
+package shield
+
+import (
+	"fmt"
+)
+
+type MFAStatus struct {
+	Enabled       bool `json:"enabled"`
+	Required      bool `json:"required"`
+	RecoveryCodes int  `json:"recovery_codes"`
+}
+
+type MFAEnrolment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+	QR     string `json:"qr"`
+}
+
+func (c *Client) MFAStatus() (*MFAStatus, error) {
+	var out *MFAStatus
+	return out, c.get("/v2/auth/mfa", &out)
+}
+
+func (c *Client) StartMFA(password string) (*MFAEnrolment, error) {
+	var out *MFAEnrolment
+	in := struct {
+		Password string `json:"password"`
+	}{
+		Password: password,
+	}
+	return out, c.post("/v2/auth/mfa", in, &out)
+}
+
+func (c *Client) ConfirmMFA(code string) ([]string, error) {
+	var out struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	in := struct {
+		Code string `json:"code"`
+	}{
+		Code: code,
+	}
+	return out.RecoveryCodes, c.post("/v2/auth/mfa/verify", in, &out)
+}
+
+func (c *Client) RegenerateRecoveryCodes(code string) ([]string, error) {
+	var out struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	in := struct {
+		Code string `json:"code"`
+	}{
+		Code: code,
+	}
+	return out.RecoveryCodes, c.post("/v2/auth/mfa/recovery-codes", in, &out)
+}
+
+func (c *Client) DisableMFA(code string) (Response, error) {
+	var r Response
+	in := struct {
+		Code string `json:"code"`
+	}{
+		Code: code,
+	}
+	return r, c.post("/v2/auth/mfa/disable", in, &r)
+}
+
+func (c *Client) ResetUserMFA(user *User) (Response, error) {
+	var r Response
+	return r, c.delete(fmt.Sprintf("/v2/auth/local/users/%s/mfa", user.UUID), &r)
+}
@@ -4,4 +4,5 @@ type Response struct {
 	OK string `json:"ok"`
 
 	TaskUUID string `json:"task_uuid,omitempty"`
+	Warning  string `json:"warning,omitempty"`
 }
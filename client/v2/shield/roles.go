@@ -0,0 +1,52 @@
+package shield
+
+import (
+	"fmt"
+)
+
+type Role struct {
+	Name        string   `json:"name"`
+	Summary     string   `json:"summary"`
+	Permissions []string `json:"permissions"`
+	Builtin     bool     `json:"builtin,omitempty"`
+}
+
+func (c *Client) ListRoles(parent *Tenant) ([]*Role, error) {
+	var out []*Role
+	return out, c.get(fmt.Sprintf("/v2/tenants/%s/roles", parent.UUID), &out)
+}
+
+func (c *Client) FindRole(parent *Tenant, name string) (*Role, error) {
+	l, err := c.ListRoles(parent)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, role := range l {
+		if role.Name == name {
+			return role, nil
+		}
+	}
+	return nil, fmt.Errorf("no role named '%s' found", name)
+}
+
+func (c *Client) CreateRole(parent *Tenant, role *Role) (*Role, error) {
+	var out *Role
+	if err := c.post(fmt.Sprintf("/v2/tenants/%s/roles", parent.UUID), role, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) UpdateRole(parent *Tenant, role *Role) (*Role, error) {
+	var out *Role
+	if err := c.put(fmt.Sprintf("/v2/tenants/%s/roles/%s", parent.UUID, role.Name), role, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) DeleteRole(parent *Tenant, role *Role) (Response, error) {
+	var out Response
+	return out, c.delete(fmt.Sprintf("/v2/tenants/%s/roles/%s", parent.UUID, role.Name), &out)
+}
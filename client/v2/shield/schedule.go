@@ -0,0 +1,45 @@
+package shield
+
+import (
+	"fmt"
+)
+
+type ScheduledRun struct {
+	At         int64  `json:"at"`
+	Duration   int64  `json:"duration"`
+	Jitter     int    `json:"jitter"`
+	Blackout   string `json:"blackout"`
+	TenantUUID string `json:"tenant_uuid"`
+	Agent      string `json:"agent"`
+
+	Job struct {
+		UUID string `json:"uuid"`
+		Name string `json:"name"`
+	} `json:"job"`
+
+	Target struct {
+		UUID string `json:"uuid"`
+		Name string `json:"name"`
+	} `json:"target"`
+
+	Store struct {
+		UUID string `json:"uuid"`
+		Name string `json:"name"`
+	} `json:"store"`
+}
+
+func (c *Client) Schedule(parent *Tenant, from, to int64) ([]*ScheduledRun, error) {
+	var out []*ScheduledRun
+	if err := c.get(fmt.Sprintf("/v2/tenants/%s/schedule?from=%d&to=%d", parent.UUID, from, to), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) GlobalSchedule(from, to int64) ([]*ScheduledRun, error) {
+	var out []*ScheduledRun
+	if err := c.get(fmt.Sprintf("/v2/global/schedule?from=%d&to=%d", from, to), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
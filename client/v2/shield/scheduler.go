@@ -4,6 +4,8 @@ type BacklogStatus struct {
 	Priority int    `json:"priority"`
 	Position int    `json:"position"`
 	TaskUUID string `json:"task_uuid"`
+	Reason   string `json:"reason,omitempty"`
+	Wait     int    `json:"wait"`
 
 	Op    string `json:"op"`
 	Agent string `json:"agent"`
@@ -71,9 +73,24 @@ type WorkerStatus struct {
 	} `json:"archive,omitempty"`
 }
 
+type TenantSchedulerStatus struct {
+	Tenant struct {
+		UUID string `json:"uuid"`
+		Name string `json:"name"`
+	} `json:"tenant"`
+
+	Share       int `json:"share"`
+	Queued      int `json:"queued"`
+	Running     int `json:"running"`
+	LongestWait int `json:"longest_wait"`
+	AverageWait int `json:"average_wait"`
+	LastWait    int `json:"last_wait"`
+}
+
 type SchedulerStatus struct {
-	Backlog []BacklogStatus `json:"backlog"`
-	Workers []WorkerStatus  `json:"workers"`
+	Backlog []BacklogStatus         `json:"backlog"`
+	Workers []WorkerStatus          `json:"workers"`
+	Tenants []TenantSchedulerStatus `json:"tenants"`
 }
 
 func (c *Client) SchedulerStatus() (*SchedulerStatus, error) {
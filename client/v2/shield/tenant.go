@@ -9,8 +9,28 @@ import (
 )
 
 type Tenant struct {
-	UUID string `json:"uuid,omitempty"`
-	Name string `json:"name"`
+	UUID  string `json:"uuid,omitempty"`
+	Name  string `json:"name"`
+	Share int    `json:"share,omitempty"`
+
+	Spread int `json:"spread"`
+
+	RequireApproval bool `json:"require_approval"`
+	ApprovalTimeout int  `json:"approval_timeout,omitempty"`
+
+	QuotaStorage  int64 `json:"quota_storage"`
+	QuotaArchives int   `json:"quota_archives"`
+	QuotaJobs     int   `json:"quota_jobs"`
+	QuotaTargets  int   `json:"quota_targets"`
+	QuotaTasks    int   `json:"quota_tasks"`
+	EnforceQuotas bool  `json:"enforce_quotas"`
+
+	Quotas []struct {
+		Resource string `json:"resource"`
+		Limit    int64  `json:"limit"`
+		Used     int64  `json:"used"`
+		Reached  bool   `json:"reached"`
+	} `json:"quotas,omitempty"`
 
 	Members []struct {
 		UUID    string `json:"uuid,omitempty"`
@@ -50,3 +50,21 @@ func (c *Client) CheckTimespec(spec string) (bool, string, error) {
 
 	return true, out.OK, nil
 }
+
+type TimespecPreview struct {
+	Timespec string  `json:"ok"`
+	Next     []int64 `json:"next"`
+}
+
+func (c *Client) PreviewTimespec(spec string) (*TimespecPreview, error) {
+	var out *TimespecPreview
+	in := struct {
+		Timespec string `json:"timespec"`
+	}{
+		Timespec: spec,
+	}
+	if err := c.post("/v2/ui/check/timespec", in, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
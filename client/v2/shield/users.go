@@ -13,6 +13,10 @@ type User struct {
 	Account  string `json:"account"`
 	SysRole  string `json:"sysrole"`
 	Password string `json:"password,omitempty"`
+	MFA      bool   `json:"mfa,omitempty"`
+
+	FailedLogins int   `json:"failed_logins,omitempty"`
+	LockedUntil  int64 `json:"locked_until,omitempty"`
 
 	Tenants []struct {
 		UUID string `json:"uuid"`
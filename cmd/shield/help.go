@@ -120,6 +120,56 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("\n")
 
+	/* }}} */
+	case "approval": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} approval --tenant @Y{TENANT} @Y{UUID}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Display the details of a single request for approval.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  See @G{shield} @Y{approvals} for details on requests for approval.\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "approvals": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} approvals --tenant @Y{TENANT} [--all] [--limit @Y{N}]\n")
+		fmt.Printf("\n")
+		fmt.Printf("  List requests for approval in a SHIELD Tenant.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Tenants can require that a second person approve every restore,\n")
+		fmt.Printf("  archive purge, and target deletion (see @G{shield} @Y{update-tenant}).\n")
+		fmt.Printf("  In those tenants, running @G{shield restore}, @G{shield restore-archive},\n")
+		fmt.Printf("  @G{shield purge-archive}, or @G{shield delete-target} only opens a\n")
+		fmt.Printf("  request for approval.  Nothing happens until someone else approves\n")
+		fmt.Printf("  the request, with @G{shield approve}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Requests that no one approves (or rejects) in time expire.  Every\n")
+		fmt.Printf("  request, and what became of it, is recorded in the audit log.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  By default, only requests that are still pending are listed.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Options:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  -a, --all      List all requests, including those that have been\n")
+		fmt.Printf("                 approved, rejected, or that have expired.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  -l, --limit    Only show this many requests.\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "approve": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} approve --tenant @Y{TENANT} @Y{UUID}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Approve a request, carrying it out.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  You cannot approve your own requests, and you must be able to do\n")
+		fmt.Printf("  what you are approving yourself: to approve a restore, you must be\n")
+		fmt.Printf("  able to restore archives; to approve an archive purge, you must be\n")
+		fmt.Printf("  able to purge archives; to approve a target deletion, you must be\n")
+		fmt.Printf("  able to manage targets.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  See @G{shield} @Y{approvals} for details on requests for approval.\n")
+		fmt.Printf("\n")
+
 	/* }}} */
 	case "archive": /* {{{ */
 		fmt.Printf("USAGE: @G{shield} archive --tenant @Y{TENANT} @Y{NAME-OR-UUID}\n")
@@ -157,8 +207,83 @@ func ShowHelp(command string) {
 		fmt.Printf("      --store    Show archives housed in the given cloud storage system,\n")
 		fmt.Printf("                 specified either by name or UUID.\n")
 		fmt.Printf("\n")
+		fmt.Printf("      --label    Only show archives with the given label, specified as\n")
+		fmt.Printf("                 @Y{name=value}.  Can be given more than once, in which\n")
+		fmt.Printf("                 case archives must have all of the given labels.\n")
+		fmt.Printf("\n")
+		fmt.Printf("      --search   Only show archives whose notes or labels contain all of\n")
+		fmt.Printf("                 the given words (or words starting with them).  Case\n")
+		fmt.Printf("                 does not matter.\n")
+		fmt.Printf("\n")
 		fmt.Printf("  -l, --limit    Only show the given number of archives.\n")
 		fmt.Printf("\n")
+		fmt.Printf("@B{Examples:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  # Production backups from right before the migration\n")
+		fmt.Printf("  @W{shield archives} \\\n")
+		fmt.Printf("    @Y{--label} env=prod \\\n")
+		fmt.Printf("    @Y{--search} \"before migration\"\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "audit": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} audit [OPTIONS]\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Show the audit log of changes made via the SHIELD API.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Every request made to SHIELD that could change something (creating,\n")
+		fmt.Printf("  updating, or deleting things, running jobs, restoring, logging in,\n")
+		fmt.Printf("  and so on) is recorded in the audit log, whether it succeeded or not:\n")
+		fmt.Printf("  who made it, from where, what it was made against, and what changed.\n")
+		fmt.Printf("  Passwords, secrets, keys, and plugin configuration are noted as\n")
+		fmt.Printf("  having changed, but their values are never recorded.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  The most recent entries are listed first.  Use @Y{--json} to see the\n")
+		fmt.Printf("  changes recorded for each entry.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @Y{NOTE:} This command can only be used by SHIELD site managers,\n")
+		fmt.Printf("        except with @Y{--tenant} (and without @Y{--export}), which\n")
+		fmt.Printf("        only requires the @C{audit} permission on that tenant.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  See also @G{shield verify-audit}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Options:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  -l, --limit     Only show this many entries (the default is 50).\n")
+		fmt.Printf("\n")
+		fmt.Printf("      --actor     Only show requests made by the given account, as\n")
+		fmt.Printf("                  @W{account@backend} (i.e. @W{jhunt@local}), or by UUID.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  -t, --tenant    Only show requests made against the given tenant.\n")
+		fmt.Printf("\n")
+		fmt.Printf("      --type      Only show requests that acted upon the given type\n")
+		fmt.Printf("                  of thing, i.e. @W{job}, @W{target}, @W{archive}, or @W{user}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("      --object    Only show requests that acted upon the thing with\n")
+		fmt.Printf("                  the given UUID.\n")
+		fmt.Printf("\n")
+		fmt.Printf("      --method    Only show requests of the given HTTP method, i.e.\n")
+		fmt.Printf("                  @W{POST}, @W{PUT}, @W{PATCH}, or @W{DELETE}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("      --since     Only show requests made at or after the given time,\n")
+		fmt.Printf("                  i.e. @W{\"2020-06-30 14:00\"} or @W{2020-06-30}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("      --until     Only show requests made before the given time.\n")
+		fmt.Printf("\n")
+		fmt.Printf("      --export    Instead of listing the entries, print all of the\n")
+		fmt.Printf("                  matching entries (ignoring @Y{--limit}), oldest first,\n")
+		fmt.Printf("                  as JSON lines, one entry per line.  Each entry has\n")
+		fmt.Printf("                  the hash of the one before it, so that the export\n")
+		fmt.Printf("                  can be checked independently.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Examples:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  # What did jhunt change yesterday?\n")
+		fmt.Printf("  @W{shield audit} @Y{--actor} @C{jhunt@local} \\\n")
+		fmt.Printf("     @Y{--since} @C{2020-06-29} @Y{--until} @C{2020-06-30}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  # Keep a copy of everything done to the acme tenant.\n")
+		fmt.Printf("  @W{shield audit} @Y{--tenant} @C{acme} @Y{--export} > acme-audit.jsonl\n")
 		fmt.Printf("\n")
 
 	/* }}} */
@@ -173,7 +298,8 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("  If you are authenticated to a SHIELD Core, you can run this\n")
 		fmt.Printf("  command to view the metadata, including names and creation / last\n")
-		fmt.Printf("  used timestamps, of your issued authentication tokens.\n")
+		fmt.Printf("  used timestamps, of your issued authentication tokens, as well as\n")
+		fmt.Printf("  when they expire, and what they are limited to.\n")
 		fmt.Printf("\n")
 		fmt.Printf("\n")
 
@@ -198,6 +324,73 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("\n")
 
+	/* }}} */
+	case "blackout": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} blackout --tenant @Y{TENANT} @Y{NAME-OR-UUID}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Show a single Blackout Window.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Blackouts are windows of time during which SHIELD will not run\n")
+		fmt.Printf("  scheduled backup jobs.  They can recur on a timespec schedule,\n")
+		fmt.Printf("  lasting a fixed amount of time, or cover a one-off, explicit\n")
+		fmt.Printf("  range of time, i.e. a data center move or a change freeze.\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "blackouts": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} blackouts --tenant @Y{TENANT} [OPTIONS]\n")
+		fmt.Printf("\n")
+		fmt.Printf("  List Blackout Windows.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Blackouts are windows of time during which SHIELD will not run\n")
+		fmt.Printf("  scheduled backup jobs.  They can recur on a timespec schedule,\n")
+		fmt.Printf("  lasting a fixed amount of time, or cover a one-off, explicit\n")
+		fmt.Printf("  range of time, i.e. a data center move or a change freeze.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Options:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  By default, all of the tenant's blackouts will be displayed.\n")
+		fmt.Printf("  You may filter the results with the following command-line flags.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --target        Only show blackouts for the given target data\n")
+		fmt.Printf("                  system (given by name or UUID).\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Global blackouts, which apply to every tenant, are listed by\n")
+		fmt.Printf("  @C{shield global-blackouts}.\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "calendar": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} calendar @Y{NAME-OR-UUID}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Show the dates in a single Exclusion Calendar.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Exclusion calendars are named lists of dates (i.e. public\n")
+		fmt.Printf("  holidays, or month-end close) that backup job schedules can\n")
+		fmt.Printf("  skip, with an @C{except} clause, like this:\n")
+		fmt.Printf("\n")
+		fmt.Printf("    @C{daily 2am except dates in the us-holidays calendar}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @Y{NOTE:} Calendars are shared by every tenant, and you must be\n")
+		fmt.Printf("  a site engineer to manage them.\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "calendars": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} calendars [OPTIONS]\n")
+		fmt.Printf("\n")
+		fmt.Printf("  List Exclusion Calendars.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Exclusion calendars are named lists of dates (i.e. public\n")
+		fmt.Printf("  holidays, or month-end close) that backup job schedules can\n")
+		fmt.Printf("  skip, with an @C{except} clause, like this:\n")
+		fmt.Printf("\n")
+		fmt.Printf("    @C{daily 2am except dates in the us-holidays calendar}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @Y{NOTE:} Calendars are shared by every tenant, and you must be\n")
+		fmt.Printf("  a site engineer to manage them.\n")
+		fmt.Printf("\n")
+
 	/* }}} */
 	case "cancel": /* {{{ */
 		fmt.Printf("USAGE: @G{shield} cancel --tenant @Y{TENANT} @Y{NAME-OR-UUID}\n")
@@ -211,6 +404,20 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("\n")
 
+	/* }}} */
+	case "clear-lockout": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} clear-lockout account @Y{ACCOUNT@BACKEND}\n")
+		fmt.Printf("       @G{shield} clear-lockout ip @Y{ADDRESS}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Lift a login lockout on an account or IP address.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Lifting a lockout also forgets about all of the failed login\n")
+		fmt.Printf("  attempts that led up to it.  Use @G{shield lockouts} to find out\n")
+		fmt.Printf("  which accounts and IP addresses are currently locked out.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @Y{NOTE:} This command can only be used by SHIELD site managers.\n")
+		fmt.Printf("\n")
+
 	/* }}} */
 	case "cores": /* {{{ */
 		fmt.Printf("USAGE: @G{shield} cores\n")
@@ -225,7 +432,7 @@ func ShowHelp(command string) {
 
 	/* }}} */
 	case "create-auth-token": /* {{{ */
-		fmt.Printf("USAGE: @G{shield} create-auth-token\n")
+		fmt.Printf("USAGE: @G{shield} create-auth-token @Y{TOKEN-NAME} [OPTIONS]\n")
 		fmt.Printf("\n")
 		fmt.Printf("  Issue a new authentication token, for the current user.\n")
 		fmt.Printf("\n")
@@ -233,9 +440,184 @@ func ShowHelp(command string) {
 		fmt.Printf("  used in scripts and other automatons to represent the issuing\n")
 		fmt.Printf("  account, and all of their privileges within the system.\n")
 		fmt.Printf("\n")
+		fmt.Printf("  Tokens can also be limited, so that they only carry some of those\n")
+		fmt.Printf("  privileges: to a single tenant, with at most a given role, for only\n")
+		fmt.Printf("  some operations, from only some network addresses, and only until\n")
+		fmt.Printf("  a given time.  A CI pipeline that only needs to run one tenant's\n")
+		fmt.Printf("  backup jobs and watch the tasks might get a token like this:\n")
+		fmt.Printf("\n")
+		fmt.Printf("    shield create-auth-token ci --for-tenant acme --role operator \\\n")
+		fmt.Printf("      --op jobs --op run-job --op tasks --allow 10.8.0.0/16 \\\n")
+		fmt.Printf("      --expires 2021-01-01\n")
+		fmt.Printf("\n")
+		fmt.Printf("  (The @W{jobs} operation lets the CLI find jobs by name.)\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Limited tokens cannot be used to issue or revoke other tokens, or\n")
+		fmt.Printf("  to change your password.\n")
+		fmt.Printf("\n")
 		fmt.Printf("  This command contacts your currently targeted SHIELD Core, and\n")
 		fmt.Printf("  asks it to issue a new token for authentication.\n")
 		fmt.Printf("\n")
+		fmt.Printf("@B{Options:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --for-tenant     The name or UUID of the one tenant (of yours)\n")
+		fmt.Printf("                   that the token can be used for.  Tokens limited\n")
+		fmt.Printf("                   to a tenant carry none of your system privileges.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --role           The most that the token can do, in the tenants\n")
+		fmt.Printf("                   it can be used for: one of @Y{admin}, @Y{engineer}\n")
+		fmt.Printf("                   or @Y{operator}.  The token never gets more than\n")
+		fmt.Printf("                   your own role in a tenant.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --op             An operation that the token can be used for; can\n")
+		fmt.Printf("                   be given more than once.  Valid operations are:\n")
+		fmt.Printf("\n")
+		fmt.Printf("                     health     systems    targets    stores\n")
+		fmt.Printf("                     jobs       run-job    pause-job  tasks\n")
+		fmt.Printf("                     archives   restore    download   import\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --allow          An IP address, or CIDR range (i.e. 10.0.0.0/8)\n")
+		fmt.Printf("                   that the token can be used from; can be given\n")
+		fmt.Printf("                   more than once.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --expires        When the token stops working, either as\n")
+		fmt.Printf("                   \"YYYY-MM-DD HH:MM\" or \"YYYY-MM-DD\" (local time),\n")
+		fmt.Printf("                   or formatted per the SHIELD_DATE_FORMAT environment\n")
+		fmt.Printf("                   variable, if it is set.\n")
+		fmt.Printf("\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "create-blackout": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} create-blackout --tenant @Y{TENANT} [OPTIONS]\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Configure a new Blackout Window.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Blackouts are windows of time during which SHIELD will not run\n")
+		fmt.Printf("  scheduled backup jobs.  They can recur on a timespec schedule,\n")
+		fmt.Printf("  lasting a fixed amount of time, or cover a one-off, explicit\n")
+		fmt.Printf("  range of time, i.e. a data center move or a change freeze.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Options:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  -n, --name      A name for your blackout.\n")
+		fmt.Printf("                  This field is @W{required}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  -s, --summary   An optional, long-form description for the blackout.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --target        The name or UUID of a target data system; if given,\n")
+		fmt.Printf("                  the blackout only applies to jobs for that system.\n")
+		fmt.Printf("                  Otherwise, it applies to every job in the tenant.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --schedule      A @W{timespec} schedule description, saying when\n")
+		fmt.Printf("                  each occurrence of a recurring blackout starts,\n")
+		fmt.Printf("                  i.e. @C{daily 1am} or @C{sundays at 22:00}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --duration      How long each occurrence of a recurring blackout\n")
+		fmt.Printf("                  lasts, i.e. @C{90m} or @C{48h}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --from          The start and end of a one-off blackout, i.e.\n")
+		fmt.Printf("  --until         @C{\"2026-12-24 00:00:00+0000\"}.  These cannot be\n")
+		fmt.Printf("                  combined with @Y{--schedule}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --catch-up      Once the blackout is over, run a single backup\n")
+		fmt.Printf("                  for each job that missed a run during it.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Examples:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  # No backups during the nightly batch run\n")
+		fmt.Printf("  @W{shield create-blackout}            \\\n")
+		fmt.Printf("      @Y{--name}      \"Nightly Batch\"   \\\n")
+		fmt.Printf("      @Y{--schedule}  \"daily 1am\"       \\\n")
+		fmt.Printf("      @Y{--duration}  2h\n")
+		fmt.Printf("\n")
+		fmt.Printf("  # Leave the database alone during its upgrade\n")
+		fmt.Printf("  @W{shield create-blackout}                     \\\n")
+		fmt.Printf("      @Y{--name}    \"DB Upgrade\"                 \\\n")
+		fmt.Printf("      @Y{--target}  UAADB                        \\\n")
+		fmt.Printf("      @Y{--from}    \"2026-11-01 02:00:00+0000\"   \\\n")
+		fmt.Printf("      @Y{--until}   \"2026-11-01 06:00:00+0000\"   \\\n")
+		fmt.Printf("      @Y{--catch-up}\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "create-calendar": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} create-calendar [OPTIONS]\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Configure a new Exclusion Calendar.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Exclusion calendars are named lists of dates (i.e. public\n")
+		fmt.Printf("  holidays, or month-end close) that backup job schedules can\n")
+		fmt.Printf("  skip, with an @C{except} clause, like this:\n")
+		fmt.Printf("\n")
+		fmt.Printf("    @C{daily 2am except dates in the us-holidays calendar}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @Y{NOTE:} Calendars are shared by every tenant, and you must be\n")
+		fmt.Printf("  a site engineer to manage them.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Options:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  -n, --name      A name for your calendar.  Since schedules refer\n")
+		fmt.Printf("                  to calendars by name, it may only contain letters,\n")
+		fmt.Printf("                  numbers, dots, dashes and underscores.\n")
+		fmt.Printf("                  This field is @W{required}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  -s, --summary   An optional, long-form description for the calendar.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --date          A date to exclude, in YYYY-MM-DD format.  Can be\n")
+		fmt.Printf("                  given more than once.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Example:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @W{shield create-calendar}                \\\n")
+		fmt.Printf("      @Y{--name}     us-holidays            \\\n")
+		fmt.Printf("      @Y{--summary}  \"US Federal Holidays\"  \\\n")
+		fmt.Printf("      @Y{--date}     2026-11-26             \\\n")
+		fmt.Printf("      @Y{--date}     2026-12-25\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "create-global-blackout": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} create-global-blackout [OPTIONS]\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Configure a new Global Blackout Window.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Blackouts are windows of time during which SHIELD will not run\n")
+		fmt.Printf("  scheduled backup jobs.  They can recur on a timespec schedule,\n")
+		fmt.Printf("  lasting a fixed amount of time, or cover a one-off, explicit\n")
+		fmt.Printf("  range of time, i.e. a data center move or a change freeze.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @Y{NOTE:} Global blackouts apply to every job, in every tenant,\n")
+		fmt.Printf("  and you must be a site engineer to manage them.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Options:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  -n, --name      A name for your blackout.\n")
+		fmt.Printf("                  This field is @W{required}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  -s, --summary   An optional, long-form description for the blackout.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --schedule      A @W{timespec} schedule description, saying when\n")
+		fmt.Printf("                  each occurrence of a recurring blackout starts,\n")
+		fmt.Printf("                  i.e. @C{daily 1am} or @C{sundays at 22:00}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --duration      How long each occurrence of a recurring blackout\n")
+		fmt.Printf("                  lasts, i.e. @C{90m} or @C{48h}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --from          The start and end of a one-off blackout, i.e.\n")
+		fmt.Printf("  --until         @C{\"2026-12-24 00:00:00+0000\"}.  These cannot be\n")
+		fmt.Printf("                  combined with @Y{--schedule}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --catch-up      Once the blackout is over, run a single backup\n")
+		fmt.Printf("                  for each job that missed a run during it.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Example:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  # Month-end close runs from the 28th, for four days\n")
+		fmt.Printf("  @W{shield create-global-blackout}                  \\\n")
+		fmt.Printf("      @Y{--name}      \"Month-End Close\"              \\\n")
+		fmt.Printf("      @Y{--schedule}  \"monthly at 18:00 on 28th\"     \\\n")
+		fmt.Printf("      @Y{--duration}  96h\n")
 		fmt.Printf("\n")
 
 	/* }}} */
@@ -346,6 +728,24 @@ func ShowHelp(command string) {
 		fmt.Printf("                  Backups of SHIELD itself should use this option\n")
 		fmt.Printf("                  to enable recovery in a disaster scenario\n")
 		fmt.Printf("\n")
+		fmt.Printf("  --max-runtime   How long a single backup may run before SHIELD\n")
+		fmt.Printf("                  terminates it, i.e. @C{90m} or @C{4h}.  Backups\n")
+		fmt.Printf("                  that are cut short are marked as @M{overrun}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --window-start  Only start backups between these two times of\n")
+		fmt.Printf("  --window-end    day, i.e. @C{22:00} and @C{4am}.  Backups that come\n")
+		fmt.Printf("                  due outside of the window wait for it to open.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --finish-by     A time of day by which backups must be finished,\n")
+		fmt.Printf("                  i.e. @C{6:30am}.  Must fall outside of the window.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --jitter        Delay each scheduled run by up to this long, i.e.\n")
+		fmt.Printf("                  @C{10m}, so that jobs on the same schedule don't all\n")
+		fmt.Printf("                  start at once.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --hashed-jitter Use the same delay for every run, derived from the\n")
+		fmt.Printf("                  job's UUID, instead of a random one.\n")
+		fmt.Printf("\n")
 		fmt.Printf("  In @Y{--batch} mode, the name or UUID specified on the command-line\n")
 		fmt.Printf("  must be \"unique enough\" for shield to determine what you meant.\n")
 		fmt.Printf("  In interactive mode, you will be asked to narrow your search\n")
@@ -373,9 +773,63 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("    @C{every 2h from 0:15}  Run at 0:15, 2:15, 4:15, etc...\n")
 		fmt.Printf("\n")
+		fmt.Printf("    @C{every 30 minutes between 8am and 6pm on weekdays}\n")
+		fmt.Printf("                          Runs at 8:00, 8:30, 9:00, etc. up to 18:00,\n")
+		fmt.Printf("                          Monday through Friday only.\n")
+		fmt.Printf("\n")
 		fmt.Printf("    @C{sundays at 16:32}    Runs weekly, on Sundays, at 4:32 in the afternoon.\n")
 		fmt.Printf("\n")
 		fmt.Printf("\n")
+		fmt.Printf("    @C{weekdays at 1am}     Runs Monday through Friday, at 1:00 in the morning.\n")
+		fmt.Printf("\n")
+		fmt.Printf("    @C{last friday at 11pm} Runs monthly, on the last Friday of the month.\n")
+		fmt.Printf("\n")
+		fmt.Printf("    @C{daily 2am except dates in the us-holidays calendar}\n")
+		fmt.Printf("                          Runs every day at 2:00 in the morning, except\n")
+		fmt.Printf("                          on the dates in the @W{us-holidays} calendar.\n")
+		fmt.Printf("                          (See @G{shield calendars}.)\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "create-role": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} create-role --tenant @Y{TENANT} [OPTIONS] @Y{NAME}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Define a custom role for a SHIELD Tenant.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Custom roles let you give tenant members exactly the permissions\n")
+		fmt.Printf("  they need, and no more, where none of the built-in @M{admin},\n")
+		fmt.Printf("  @M{engineer}, or @M{operator} roles fit.  Once defined, a custom\n")
+		fmt.Printf("  role can be given to tenant members with @G{shield invite}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Run @G{shield roles} for the list of permissions.  Most roles will\n")
+		fmt.Printf("  want the @C{view} permission, without which very little else in\n")
+		fmt.Printf("  the tenant can be found.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @Y{NOTE:} This command is only available to @R{SHIELD Site Managers},\n")
+		fmt.Printf("        and to those who can manage the members of the tenant.  You\n")
+		fmt.Printf("        cannot define a role with permissions you do not hold yourself.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Options:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  -s, --summary      An optional, long-form description for the role.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  -p, --permission   A permission to grant to the role.  Can be\n")
+		fmt.Printf("                     given more than once.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Examples:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  # A role that can restore, but not change jobs (or anything else)\n")
+		fmt.Printf("  @W{shield create-role} @Y{--tenant} acme restorer \\\n")
+		fmt.Printf("      @Y{--summary}     \"Restores, and nothing else\" \\\n")
+		fmt.Printf("      @Y{--permission}  view \\\n")
+		fmt.Printf("      @Y{--permission}  restore\n")
+		fmt.Printf("\n")
+		fmt.Printf("  # A role that can see everything, except for credentials\n")
+		fmt.Printf("  @W{shield create-role} @Y{--tenant} acme auditor \\\n")
+		fmt.Printf("      @Y{--summary}     \"Read-only access, without credentials\" \\\n")
+		fmt.Printf("      @Y{--permission}  view \\\n")
+		fmt.Printf("      @Y{--permission}  audit\n")
+		fmt.Printf("\n")
 
 	/* }}} */
 	case "create-store": /* {{{ */
@@ -503,7 +957,11 @@ func ShowHelp(command string) {
 
 	/* }}} */
 	case "create-tenant": /* {{{ */
-		fmt.Printf("USAGE: @G{shield} create-tenant [--name @Y{NAME}]\n")
+		fmt.Printf("USAGE: @G{shield} create-tenant [--name @Y{NAME}] [--share @Y{N}] [--spread @Y{WINDOW}]\n")
+		fmt.Printf("                             [--require-approval] [--approval-timeout @Y{TIMEOUT}]\n")
+		fmt.Printf("                             [--storage-quota @Y{SIZE}] [--archive-quota @Y{N}]\n")
+		fmt.Printf("                             [--job-quota @Y{N}] [--target-quota @Y{N}]\n")
+		fmt.Printf("                             [--task-quota @Y{N}] [--enforce-quotas]\n")
 		fmt.Printf("\n")
 		fmt.Printf("  Create a new SHIELD Tenant.\n")
 		fmt.Printf("\n")
@@ -516,6 +974,46 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("  --name         The name to assign this new tenant.\n")
 		fmt.Printf("\n")
+		fmt.Printf("  --share        The tenant's share of the SHIELD Core scheduler, relative\n")
+		fmt.Printf("                 to other tenants.  A tenant with a share of 3 will have\n")
+		fmt.Printf("                 three tasks dispatched for every one task of a tenant\n")
+		fmt.Printf("                 with a share of 1, when both have tasks waiting.\n")
+		fmt.Printf("                 Defaults to 1.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --spread       Spread the tenant's jobs evenly across this window,\n")
+		fmt.Printf("                 i.e. @C{30m}.  Jobs on the same schedule each get a\n")
+		fmt.Printf("                 fixed, evenly-spaced offset into the window, in place\n")
+		fmt.Printf("                 of any per-job jitter.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --require-approval\n")
+		fmt.Printf("                 Require a second person to approve every restore,\n")
+		fmt.Printf("                 archive purge, and target deletion in this tenant.\n")
+		fmt.Printf("                 See @G{shield} @Y{approvals} for details.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --approval-timeout\n")
+		fmt.Printf("                 How long requests for approval stay open before they\n")
+		fmt.Printf("                 expire, i.e. @C{4h}.  Defaults to @C{24h}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --storage-quota\n")
+		fmt.Printf("                 How much cloud storage the tenant's backup archives\n")
+		fmt.Printf("                 can use, i.e. @C{500G}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --archive-quota\n")
+		fmt.Printf("                 How many backup archives the tenant can keep.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --job-quota    How many backup jobs the tenant can define.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --target-quota How many data systems the tenant can define.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --task-quota   How many of the tenant's tasks can run at once; any\n")
+		fmt.Printf("                 more wait their turn.\n")
+		fmt.Printf("\n")
+		fmt.Printf("                 Quotas default to @C{0}, which means \"unlimited\".\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --enforce-quotas\n")
+		fmt.Printf("                 Refuse to run backups (or create jobs and data systems)\n")
+		fmt.Printf("                 once the tenant has reached its quota.  Otherwise, going\n")
+		fmt.Printf("                 over quota only draws a warning.\n")
 		fmt.Printf("\n")
 
 	/* }}} */
@@ -615,6 +1113,52 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("\n")
 
+	/* }}} */
+	case "delete-blackout": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} delete-blackout --tenant @Y{TENANT} @Y{NAME-OR-UUID}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Delete a Blackout Window.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Blackouts are windows of time during which SHIELD will not run\n")
+		fmt.Printf("  scheduled backup jobs.  They can recur on a timespec schedule,\n")
+		fmt.Printf("  lasting a fixed amount of time, or cover a one-off, explicit\n")
+		fmt.Printf("  range of time, i.e. a data center move or a change freeze.\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "delete-calendar": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} delete-calendar @Y{NAME-OR-UUID}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Delete an Exclusion Calendar.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Exclusion calendars are named lists of dates (i.e. public\n")
+		fmt.Printf("  holidays, or month-end close) that backup job schedules can\n")
+		fmt.Printf("  skip, with an @C{except} clause, like this:\n")
+		fmt.Printf("\n")
+		fmt.Printf("    @C{daily 2am except dates in the us-holidays calendar}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @Y{NOTE:} Calendars are shared by every tenant, and you must be\n")
+		fmt.Printf("  a site engineer to manage them.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Calendars that are still named in the schedule of a backup job\n")
+		fmt.Printf("  cannot be deleted.\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "delete-global-blackout": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} delete-global-blackout @Y{NAME-OR-UUID}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Delete a Global Blackout Window.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Blackouts are windows of time during which SHIELD will not run\n")
+		fmt.Printf("  scheduled backup jobs.  They can recur on a timespec schedule,\n")
+		fmt.Printf("  lasting a fixed amount of time, or cover a one-off, explicit\n")
+		fmt.Printf("  range of time, i.e. a data center move or a change freeze.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @Y{NOTE:} Global blackouts apply to every job, in every tenant,\n")
+		fmt.Printf("  and you must be a site engineer to manage them.\n")
+		fmt.Printf("\n")
+
 	/* }}} */
 	case "delete-global-store": /* {{{ */
 		fmt.Printf("USAGE: @G{shield} delete-global-store @Y{NAME-OR-UUID}\n")
@@ -653,6 +1197,21 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("\n")
 
+	/* }}} */
+	case "delete-role": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} delete-role --tenant @Y{TENANT} @Y{NAME}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Remove a custom role from a SHIELD Tenant.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Roles that are still held by members of the tenant cannot be\n")
+		fmt.Printf("  deleted; give those members some other role (with @G{shield invite})\n")
+		fmt.Printf("  or banish them first.  The built-in @M{admin}, @M{engineer}, and\n")
+		fmt.Printf("  @M{operator} roles cannot be deleted.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @Y{NOTE:} This command is only available to @R{SHIELD Site Managers},\n")
+		fmt.Printf("        and to those who can manage the members of the tenant.\n")
+		fmt.Printf("\n")
+
 	/* }}} */
 	case "delete-session": /* {{{ */
 		fmt.Printf("USAGE: @G{shield} delete-session @Y{UUID}\n")
@@ -711,6 +1270,10 @@ func ShowHelp(command string) {
 		fmt.Printf("  systems; if the system is being referenced by any backup job\n")
 		fmt.Printf("  configuration, you will be unable to delete it.\n")
 		fmt.Printf("\n")
+		fmt.Printf("  If the tenant requires a second person to approve target deletions,\n")
+		fmt.Printf("  this only opens a request for approval; nothing happens until\n")
+		fmt.Printf("  someone else runs @G{shield approve}.  See @G{shield} @Y{approvals}\n")
+		fmt.Printf("  for details.\n")
 		fmt.Printf("\n")
 
 	/* }}} */
@@ -751,6 +1314,91 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("\n")
 
+	/* }}} */
+	case "disable-mfa": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} disable-mfa [--code @Y{CODE}]\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Turn off multi-factor authentication.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Your authenticator secret and any remaining recovery codes will be\n")
+		fmt.Printf("  forgotten, and you will only need your password to log in.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Options:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("      --code ...        A code from your authenticator app, or one of\n")
+		fmt.Printf("                        your recovery codes.  If not given, you will\n")
+		fmt.Printf("                        be prompted for it.\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "download-archive": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} download-archive --tenant @Y{TENANT} --output @Y{FILE} [OPTIONS] @Y{UUID}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Download the Contents of a Backup Archive.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  SHIELD retrieves the archive from its cloud storage system, through\n")
+		fmt.Printf("  the SHIELD agent that stored it, and decrypts it, so that you can\n")
+		fmt.Printf("  inspect it, or restore it by hand, without access to the vault.\n")
+		fmt.Printf("  Downloads are recorded as tasks, and show up in @G{shield tasks}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  By default, the archive is left compressed, the way it was stored.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  This requires the tenant @Y{engineer} role (or better).\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Options:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  -o, --output    (required) Where to write the archive contents.\n")
+		fmt.Printf("                  Use \"-\" to write them to standard output.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --decompress    Decompress the archive before sending it, so that\n")
+		fmt.Printf("                  what you download is the raw output of the target\n")
+		fmt.Printf("                  plugin.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Examples:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  # Grab a copy of last night's backup, for inspection\n")
+		fmt.Printf("  @W{shield download-archive} \\\n")
+		fmt.Printf("    @Y{d5a80d64-72bd-423a-8411-61b65dbb4188} \\\n")
+		fmt.Printf("    @Y{--decompress} @Y{--output} backup.tar\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "enable-mfa": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} enable-mfa [--password @Y{PASSWORD}] [--code @Y{CODE}]\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Set up multi-factor authentication, with an authenticator app.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  This command asks for your current password, and then displays a\n")
+		fmt.Printf("  QR code (and the secret it encodes) for you to scan with your\n")
+		fmt.Printf("  authenticator app.  Once you enter the code the app gives you,\n")
+		fmt.Printf("  multi-factor authentication is enabled, and you will need a code\n")
+		fmt.Printf("  from the app every time you log in.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  You will also be given a set of @W{recovery codes}.  Each of these\n")
+		fmt.Printf("  can be used, once, in place of a code from your app, in case you\n")
+		fmt.Printf("  lose your phone.  Keep them somewhere safe.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  If your SHIELD site managers have required multi-factor\n")
+		fmt.Printf("  authentication for your system role, you will not be able to\n")
+		fmt.Printf("  exercise that role until you have enabled it.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @Y{Note:} Multi-factor authentication is only available for local\n")
+		fmt.Printf("  SHIELD users; accounts from 3rd-party authentication providers\n")
+		fmt.Printf("  rely on those providers for their second factor.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Options:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  -p, --password ...    Your current password.  If not given, you\n")
+		fmt.Printf("                        will be prompted for it.\n")
+		fmt.Printf("\n")
+		fmt.Printf("      --code ...        The code from your authenticator app, to\n")
+		fmt.Printf("                        finish an enrolment.  In @Y{--batch} mode,\n")
+		fmt.Printf("                        running this command with just @B{--password}\n")
+		fmt.Printf("                        prints the new secret and its provisioning\n")
+		fmt.Printf("                        URI as JSON; enrolment can then be finished\n")
+		fmt.Printf("                        by running it again, with just @B{--code}.\n")
+		fmt.Printf("\n")
+
 	/* }}} */
 	case "events": /* {{{ */
 		fmt.Printf("USAGE: @G{shield} events [--skip @Y{EVENT-or-QUEUE} [--skip ...]]\n")
@@ -815,6 +1463,36 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("\n")
 
+	/* }}} */
+	case "global-blackout": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} global-blackout @Y{NAME-OR-UUID}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Show a single Global Blackout Window.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Blackouts are windows of time during which SHIELD will not run\n")
+		fmt.Printf("  scheduled backup jobs.  They can recur on a timespec schedule,\n")
+		fmt.Printf("  lasting a fixed amount of time, or cover a one-off, explicit\n")
+		fmt.Printf("  range of time, i.e. a data center move or a change freeze.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @Y{NOTE:} Global blackouts apply to every job, in every tenant,\n")
+		fmt.Printf("  and you must be a site engineer to manage them.\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "global-blackouts": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} global-blackouts [OPTIONS]\n")
+		fmt.Printf("\n")
+		fmt.Printf("  List Global Blackout Windows.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Blackouts are windows of time during which SHIELD will not run\n")
+		fmt.Printf("  scheduled backup jobs.  They can recur on a timespec schedule,\n")
+		fmt.Printf("  lasting a fixed amount of time, or cover a one-off, explicit\n")
+		fmt.Printf("  range of time, i.e. a data center move or a change freeze.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @Y{NOTE:} Global blackouts apply to every job, in every tenant,\n")
+		fmt.Printf("  and you must be a site engineer to manage them.\n")
+		fmt.Printf("\n")
+
 	/* }}} */
 	case "global-store": /* {{{ */
 		fmt.Printf("USAGE: @G{shield} global-store @Y{NAME-OR-UUID}\n")
@@ -919,10 +1597,45 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("  Hide a single SHIELD Agent.\n")
 		fmt.Printf("\n")
-		fmt.Printf("  Agents perform all backup / restore / purge / test operations on\n")
-		fmt.Printf("  behalf of the SHIELD Core.  Hidden SHIELD Agents cannot be used\n")
-		fmt.Printf("  by tenants when configuring data and storage systems.\n")
+		fmt.Printf("  Agents perform all backup / restore / purge / test operations on\n")
+		fmt.Printf("  behalf of the SHIELD Core.  Hidden SHIELD Agents cannot be used\n")
+		fmt.Printf("  by tenants when configuring data and storage systems.\n")
+		fmt.Printf("\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "hold-archive": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} hold-archive --tenant @Y{TENANT} --reason @Y{REASON} [OPTIONS] @Y{UUID}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Place a Backup Archive under Legal Hold.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Archives under legal hold are never expired or purged, regardless\n")
+		fmt.Printf("  of their retention policy; nor can they be purged by hand.  Where\n")
+		fmt.Printf("  the cloud storage system supports it, SHIELD also asks the storage\n")
+		fmt.Printf("  provider to hold the archive (i.e. a Google Cloud Storage temporary\n")
+		fmt.Printf("  hold), so that it cannot be deleted outside of SHIELD either.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Holds can only be released by a SHIELD site administrator, via\n")
+		fmt.Printf("  @G{shield release-archive}, unless they were placed with a release\n")
+		fmt.Printf("  date (see --until), in which case they lapse on their own.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Options:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --reason        A (human-readable) reason for holding this archive,\n")
+		fmt.Printf("                  like a case or ticket number.  This is required.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --until         When the hold should lapse, formatted per the\n")
+		fmt.Printf("                  SHIELD_DATE_FORMAT environment variable (by default,\n")
+		fmt.Printf("                  \"YYYY-MM-DD HH:MM:SS-ZZZZ\").  If not given, the hold\n")
+		fmt.Printf("                  stays in place until it is explicitly released.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Examples:}\n")
 		fmt.Printf("\n")
+		fmt.Printf("  # Hold an archive for the duration of an audit\n")
+		fmt.Printf("  @W{shield hold-archive} \\\n")
+		fmt.Printf("    @Y{d5a80d64-72bd-423a-8411-61b65dbb4188} \\\n")
+		fmt.Printf("    @Y{--reason} \"2019 SOX audit\" \\\n")
+		fmt.Printf("    @Y{--until} \"2020-06-30 00:00:00-0400\"\n")
 		fmt.Printf("\n")
 
 	/* }}} */
@@ -970,6 +1683,76 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("\n")
 
+	/* }}} */
+	case "import-archive": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} import-archive --tenant @Y{TENANT} --target @Y{TARGET} --store @Y{STORE} --expires @Y{TIME} (--file @Y{FILE} | --key @Y{KEY}) [OPTIONS]\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Import a Backup Made Outside of SHIELD.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  If you already have backups of a system, made by cron jobs and\n")
+		fmt.Printf("  scripts before SHIELD came along, you can import them as archives of\n")
+		fmt.Printf("  the target system, so that SHIELD can restore them, and expire them\n")
+		fmt.Printf("  according to its retention rules, like any other archive.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Backups can either be uploaded from a local file (--file), or taken\n")
+		fmt.Printf("  from wherever they already are in the cloud storage system (--key).\n")
+		fmt.Printf("  Either way, SHIELD encrypts them, storing the encrypted copy via the\n")
+		fmt.Printf("  storage system's agent, unless you ask for @Y{--unencrypted}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  With --unencrypted, an uploaded backup is stored as it is, and a\n")
+		fmt.Printf("  backup already in cloud storage is just registered where it is; no\n")
+		fmt.Printf("  data needs to move at all.  These \"legacy\" archives are restored\n")
+		fmt.Printf("  without decrypting them.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Imported archives are labeled @C{imported=yes}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  This requires the tenant @Y{engineer} role (or better).\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Options:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --target         (required) The name or UUID of the target data\n")
+		fmt.Printf("                   system that the backup was taken of.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --store          (required) The name or UUID of the cloud storage\n")
+		fmt.Printf("                   system to import into.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --expires        (required) When the imported archive should expire,\n")
+		fmt.Printf("                   either as \"YYYY-MM-DD HH:MM\" or \"YYYY-MM-DD\" (local\n")
+		fmt.Printf("                   time), or formatted per the SHIELD_DATE_FORMAT\n")
+		fmt.Printf("                   environment variable, if it is set.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  -f, --file       A local file to upload.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --key            Where the backup already is, in the cloud storage\n")
+		fmt.Printf("                   system, i.e. a path in an S3 bucket.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --compression    How the backup is already compressed; one of\n")
+		fmt.Printf("                   \"bzip2\", \"gzip\" or \"none\" (the default).  SHIELD\n")
+		fmt.Printf("                   does not compress imported backups any further.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --taken-at       When the backup was taken, in the same format as\n")
+		fmt.Printf("                   --expires.  Defaults to the modification time of the\n")
+		fmt.Printf("                   uploaded file, or the time of the import.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --notes          Notes about the archive.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --unencrypted    Import the backup without encrypting it.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Examples:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  # Upload (and encrypt) last year's database dump\n")
+		fmt.Printf("  @W{shield import-archive} \\\n")
+		fmt.Printf("    @Y{--target} prod-db @Y{--store} s3 \\\n")
+		fmt.Printf("    @Y{--file} prod-db-2019-12-31.sql.gz @Y{--compression} gzip \\\n")
+		fmt.Printf("    @Y{--taken-at} 2019-12-31 @Y{--expires} 2026-12-31\n")
+		fmt.Printf("\n")
+		fmt.Printf("  # Register a dump that is already in S3, as it is\n")
+		fmt.Printf("  @W{shield import-archive} \\\n")
+		fmt.Printf("    @Y{--target} prod-db @Y{--store} s3 \\\n")
+		fmt.Printf("    @Y{--key} dumps/prod-db-2019-06-30.sql.gz @Y{--compression} gzip \\\n")
+		fmt.Printf("    @Y{--taken-at} 2019-06-30 @Y{--expires} 2026-06-30 @Y{--unencrypted}\n")
+		fmt.Printf("\n")
+
 	/* }}} */
 	case "init": /* {{{ */
 		fmt.Printf("USAGE: @G{shield} init [--master @Y{PASSWORD}]\n")
@@ -1022,6 +1805,10 @@ func ShowHelp(command string) {
 		fmt.Printf("  For example, a @M{admin} can do everything an @M{engineer} can do,\n")
 		fmt.Printf("  and an @M{engineer} can do everything an @M{operator} can do.\n")
 		fmt.Printf("\n")
+		fmt.Printf("  Tenants can also define their own custom roles, each with its own\n")
+		fmt.Printf("  set of permissions (see @G{shield create-role}).  You cannot give\n")
+		fmt.Printf("  anyone a role with permissions that you do not hold yourself.\n")
+		fmt.Printf("\n")
 		fmt.Printf("  @Y{NOTE:} This command is only available to @R{SHIELD Site Managers},\n")
 		fmt.Printf("        and @R{SHIELD Tenant Managers}.\n")
 		fmt.Printf("\n")
@@ -1032,7 +1819,9 @@ func ShowHelp(command string) {
 		fmt.Printf("@B{Options:}\n")
 		fmt.Printf("\n")
 		fmt.Printf("  -r, --role     The role to assign the new user.  Must be one of\n")
-		fmt.Printf("                 @M{admin}, @M{engineer}, or @M{operator}.\n")
+		fmt.Printf("                 @M{admin}, @M{engineer}, or @M{operator}, or the name of\n")
+		fmt.Printf("                 one of the tenant's custom roles (see @G{shield roles}).\n")
+		fmt.Printf("                 Defaults to @M{operator}.\n")
 		fmt.Printf("\n")
 		fmt.Printf("\n")
 
@@ -1088,6 +1877,30 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("\n")
 
+	/* }}} */
+	case "label-archive": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} label-archive --tenant @Y{TENANT} @Y{UUID} @Y{NAME=VALUE} ...\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Label a Backup Archive.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Labels are name=value pairs that make archives easier to find, via\n")
+		fmt.Printf("  @G{shield archives --label}.  Every archive that a backup job makes\n")
+		fmt.Printf("  is labeled automatically, with the name of the job (@M{job}), the\n")
+		fmt.Printf("  target system (@M{target}), its plugin (@M{plugin}) and the cloud\n")
+		fmt.Printf("  storage system (@M{store}).\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Setting a label that the archive already has changes its value;\n")
+		fmt.Printf("  all other labels are left alone.  Label names may only contain\n")
+		fmt.Printf("  letters, numbers, dots, dashes and underscores.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Examples:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  # This one was taken from production, for ticket 12345\n")
+		fmt.Printf("  @W{shield label-archive} \\\n")
+		fmt.Printf("    @Y{ca24f30b-87f6-4599-bf9e-818998c4b0de} \\\n")
+		fmt.Printf("    env=prod ticket=12345\n")
+		fmt.Printf("\n")
+
 	/* }}} */
 	case "lock": /* {{{ */
 		fmt.Printf("USAGE: @G{shield} lock\n")
@@ -1106,12 +1919,31 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("\n")
 
+	/* }}} */
+	case "lockouts": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} lockouts\n")
+		fmt.Printf("\n")
+		fmt.Printf("  List accounts and IP addresses locked out after failed logins.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  SHIELD keeps track of failed login attempts, both by account and\n")
+		fmt.Printf("  by the IP address they come from.  After too many failures (see\n")
+		fmt.Printf("  the @W{api.lockout.*} configuration), further attempts are refused\n")
+		fmt.Printf("  for a while; each additional failure after that doubles the time\n")
+		fmt.Printf("  until the next attempt is allowed, up to a maximum.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Accounts are listed as @W{account@backend}, i.e. @W{jhunt@local}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @Y{NOTE:} This command can only be used by SHIELD site managers.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  See also @G{shield clear-lockout}.\n")
+		fmt.Printf("\n")
+
 	/* }}} */
 	case "login": /* {{{ */
-		fmt.Printf("USAGE: @G{shield} login [--username @Y{USERNAME}] [--password @Y{PASSWORD}]\n")
+		fmt.Printf("USAGE: @G{shield} login [--username @Y{USERNAME}] [--password @Y{PASSWORD}] [--code @Y{CODE}]\n")
 		fmt.Printf("       @G{shield} login --token @Y{AUTH-TOKEN}\n")
 		fmt.Printf("       @G{shield} login --providers\n")
-		fmt.Printf("       @G{shield} login --via @Y{PROVIDER-ID}\n")
+		fmt.Printf("       @G{shield} login --via @Y{PROVIDER-ID} [--username @Y{USERNAME}] [--password @Y{PASSWORD}]\n")
 		fmt.Printf("\n")
 		fmt.Printf("  Authenticate to a SHIELD Core.\n")
 		fmt.Printf("\n")
@@ -1123,6 +1955,10 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("  -u, --username ...    Who to log in as. (@W{$SHIELD_CORE_USERNAME})\n")
 		fmt.Printf("  -p, --password ...    Secret password.  (@W{$SHIELD_CORE_PASSWORD})\n")
+		fmt.Printf("      --code ...        Multi-factor authentication code, from your\n")
+		fmt.Printf("                        authenticator app (or a recovery code.)  If\n")
+		fmt.Printf("                        your account needs one and you don't give\n")
+		fmt.Printf("                        it, you will be prompted for it.\n")
 		fmt.Printf("\n")
 		fmt.Printf("@B{Token Authentication:}\n")
 		fmt.Printf("\n")
@@ -1137,6 +1973,10 @@ func ShowHelp(command string) {
 		fmt.Printf("      --via ...         Which 3rd-party authentication provider to\n")
 		fmt.Printf("                        use for logging in.\n")
 		fmt.Printf("\n")
+		fmt.Printf("                        Directory-backed providers (i.e. LDAP) take a\n")
+		fmt.Printf("                        username and password instead of sending you\n")
+		fmt.Printf("                        to a web browser; @B{--username} and @B{--password}\n")
+		fmt.Printf("                        can be given along with @B{--via} for those.\n")
 		fmt.Printf("\n")
 
 	/* }}} */
@@ -1147,6 +1987,40 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("\n")
 
+	/* }}} */
+	case "mfa": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} mfa\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Show your multi-factor authentication status.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Local SHIELD users can protect their accounts with a second\n")
+		fmt.Printf("  factor: a six-digit code from an authenticator app on their phone\n")
+		fmt.Printf("  (any TOTP app will do), in addition to their password.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  This command shows whether or not you have multi-factor\n")
+		fmt.Printf("  authentication enabled, how many unused recovery codes you have\n")
+		fmt.Printf("  left, and whether or not your system role requires it.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  See also @G{shield enable-mfa} and @G{shield disable-mfa}.\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "mfa-recovery-codes": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} mfa-recovery-codes [--code @Y{CODE}]\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Issue a new set of multi-factor recovery codes.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Recovery codes can each be used once, in place of a code from your\n")
+		fmt.Printf("  authenticator app.  This command throws away all of your existing\n")
+		fmt.Printf("  recovery codes (used or not), and prints out a new set.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Options:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("      --code ...        A code from your authenticator app, or one of\n")
+		fmt.Printf("                        your recovery codes.  If not given, you will\n")
+		fmt.Printf("                        be prompted for it.\n")
+		fmt.Printf("\n")
+
 	/* }}} */
 	case "op pry": /* {{{ */
 		fmt.Printf("USAGE: @G{shield} op pry @Y{/path/to/vault.crypt}\n")
@@ -1205,6 +2079,16 @@ func ShowHelp(command string) {
 		fmt.Printf("  This command shows what tasks the scheduler threads inside of a\n")
 		fmt.Printf("  SHIELD Core are currently executing.\n")
 		fmt.Printf("\n")
+		fmt.Printf("  Tasks in the backlog that cannot yet be dispatched, either because\n")
+		fmt.Printf("  all of the scheduler threads are busy, or because a per-agent,\n")
+		fmt.Printf("  per-store or per-tenant concurrency limit has been reached, will\n")
+		fmt.Printf("  show why they are waiting.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Within each priority, the scheduler divides its threads between\n")
+		fmt.Printf("  tenants according to their scheduler share.  The @M{Tenant Fair Share}\n")
+		fmt.Printf("  table shows, for each tenant with work queued or running, how many\n")
+		fmt.Printf("  tasks are waiting, and how long they have been waiting for.\n")
+		fmt.Printf("\n")
 		fmt.Printf("\n")
 
 	/* }}} */
@@ -1219,11 +2103,19 @@ func ShowHelp(command string) {
 		fmt.Printf("  is then placed in cloud storage, awaiting either expiry and purgation,\n")
 		fmt.Printf("  or restoration to a data system.\n")
 		fmt.Printf("\n")
-		fmt.Printf("  When you purge a backup archive, it will be remmoved from its cloud\n")
-		fmt.Printf("  storage system, and marked as purged in the SHIELD database; no one\n")
-		fmt.Printf("  will be able to restore the data in the archvie.\n")
+		fmt.Printf("  When you purge a backup archive, it is first moved to a recycle bin,\n")
+		fmt.Printf("  and marked as @M{pending-purge}.  Until its grace period is over, you\n")
+		fmt.Printf("  can get it back with @G{shield undelete-archive}.  After that, it will\n")
+		fmt.Printf("  be removed from its cloud storage system, and marked as purged in the\n")
+		fmt.Printf("  SHIELD database; no one will be able to restore the data in the\n")
+		fmt.Printf("  archive.\n")
 		fmt.Printf("\n")
-		fmt.Printf("  @R{This is a dangerous operation that cannot be undone.}\n")
+		fmt.Printf("  @R{Once the grace period is over, this cannot be undone.}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  If the tenant requires a second person to approve archive purges,\n")
+		fmt.Printf("  this only opens a request for approval; nothing happens until\n")
+		fmt.Printf("  someone else runs @G{shield approve}.  See @G{shield} @Y{approvals}\n")
+		fmt.Printf("  for details.\n")
 		fmt.Printf("\n")
 		fmt.Printf("@B{Options:}\n")
 		fmt.Printf("\n")
@@ -1240,6 +2132,18 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("\n")
 
+	/* }}} */
+	case "reject": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} reject --tenant @Y{TENANT} @Y{UUID}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Reject a request, or withdraw one of your own.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Rejecting a request takes the same permissions as approving it; see\n")
+		fmt.Printf("  @G{shield} @Y{approve}.  Anyone can withdraw their own requests.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  See @G{shield} @Y{approvals} for details on requests for approval.\n")
+		fmt.Printf("\n")
+
 	/* }}} */
 	case "rekey": /* {{{ */
 		fmt.Printf("USAGE: @G{shield} rekey [--old-master @Y{PASSWORD}]\n")
@@ -1275,6 +2179,80 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("\n")
 
+	/* }}} */
+	case "release-archive": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} release-archive --tenant @Y{TENANT} @Y{UUID}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Release a Legal Hold on a Backup Archive.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Once released, the archive is subject to its retention policy\n")
+		fmt.Printf("  again; if it has already outlived it, it will be expired (and then\n")
+		fmt.Printf("  purged) the next time SHIELD checks archive expiries.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Only SHIELD site administrators can release legal holds.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Examples:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  # The audit is over\n")
+		fmt.Printf("  @W{shield release-archive} \\\n")
+		fmt.Printf("    @Y{d5a80d64-72bd-423a-8411-61b65dbb4188}\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "reset-mfa": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} reset-mfa @Y{NAME-OR-UUID}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Turn off multi-factor authentication for a local SHIELD User.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  If a user loses their authenticator app, and all of their recovery\n")
+		fmt.Printf("  codes, they will be unable to log in.  This command turns off\n")
+		fmt.Printf("  multi-factor authentication for their account, so that they can\n")
+		fmt.Printf("  log in with just their password, and enrol a new authenticator.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @Y{NOTE:} This command can only be used by SHIELD site managers.\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "restore": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} restore --tenant @Y{TENANT} --target @Y{TARGET} --as-of @Y{TIME}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Restore a Target System to a Point in Time.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Rather than picking through @G{shield archives} by hand, you can ask\n")
+		fmt.Printf("  SHIELD to restore a target system to the way it was at a given time.\n")
+		fmt.Printf("  SHIELD picks the newest valid archive of that target that was taken\n")
+		fmt.Printf("  at or before then, shows it to you, and asks for confirmation before\n")
+		fmt.Printf("  scheduling the restore.  Every archive is a complete backup, so this\n")
+		fmt.Printf("  single archive is all that gets restored.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @R{NOTE: Restoring data may cause an outage} in the target data system\n")
+		fmt.Printf("  as the data is replayed.  See @G{shield help restore-archive}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  If the tenant requires a second person to approve restores, this\n")
+		fmt.Printf("  only opens a request for approval; nothing happens until someone\n")
+		fmt.Printf("  else runs @G{shield approve}.  See @G{shield} @Y{approvals} for\n")
+		fmt.Printf("  details.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Options:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --target         (required) The name or UUID of the target data\n")
+		fmt.Printf("                   system to restore.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --as-of          (required) The point in time to restore to, either\n")
+		fmt.Printf("                   as \"YYYY-MM-DD HH:MM\" or \"YYYY-MM-DD\" (local time),\n")
+		fmt.Printf("                   or formatted per the SHIELD_DATE_FORMAT environment\n")
+		fmt.Printf("                   variable, if it is set.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  -y, --yes        Do not ask for confirmation.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Examples:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  # Put the database back the way it was last Tuesday afternoon\n")
+		fmt.Printf("  @W{shield restore} \\\n")
+		fmt.Printf("    @Y{--target} prod-db \\\n")
+		fmt.Printf("    @Y{--as-of} \"2020-06-30 14:00\"\n")
+		fmt.Printf("\n")
+
 	/* }}} */
 	case "restore-archive": /* {{{ */
 		fmt.Printf("USAGE: @G{shield} restore-archive --tenant @Y{TENANT} [OPTIONS] @Y{UUID}\n")
@@ -1296,6 +2274,11 @@ func ShowHelp(command string) {
 		fmt.Printf("  disconnect end users, block new connections, prohibit writes to the data\n")
 		fmt.Printf("  store, etc.  Please consult your plugin documentation.\n")
 		fmt.Printf("\n")
+		fmt.Printf("  If the tenant requires a second person to approve restores, this\n")
+		fmt.Printf("  only opens a request for approval; nothing happens until someone\n")
+		fmt.Printf("  else runs @G{shield approve}.  See @G{shield} @Y{approvals} for\n")
+		fmt.Printf("  details.\n")
+		fmt.Printf("\n")
 		fmt.Printf("@B{Options:}\n")
 		fmt.Printf("\n")
 		fmt.Printf("  --to, --target   The name or UUID of an alternate target data system\n")
@@ -1320,6 +2303,40 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("\n")
 
+	/* }}} */
+	case "roles": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} roles --tenant @Y{TENANT}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  List the roles that members of a SHIELD Tenant can hold.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Every tenant has the three built-in roles, @M{admin}, @M{engineer},\n")
+		fmt.Printf("  and @M{operator}, along with any custom roles that have been\n")
+		fmt.Printf("  defined for it (see @G{shield create-role}).  Each role is\n")
+		fmt.Printf("  nothing more than a named set of permissions:\n")
+		fmt.Printf("\n")
+		fmt.Printf("    @C{view}              See the tenant, and its systems, stores, jobs,\n")
+		fmt.Printf("                      blackouts, tasks, and archives.\n")
+		fmt.Printf("    @C{credentials}       See the credentials (passwords, keys, etc.)\n")
+		fmt.Printf("                      in target and store configurations.\n")
+		fmt.Printf("    @C{manage-targets}    Create, reconfigure, and delete target data systems.\n")
+		fmt.Printf("    @C{manage-stores}     Create, reconfigure, and delete cloud storage systems.\n")
+		fmt.Printf("    @C{manage-jobs}       Create, reconfigure, and delete backup jobs.\n")
+		fmt.Printf("    @C{manage-blackouts}  Create, reconfigure, and delete blackout windows.\n")
+		fmt.Printf("    @C{run-job}           Run backup jobs on demand.\n")
+		fmt.Printf("    @C{pause-job}         Pause and unpause backup jobs.\n")
+		fmt.Printf("    @C{cancel-task}       Cancel running tasks.\n")
+		fmt.Printf("    @C{annotate-archive}  Annotate and label backup archives.\n")
+		fmt.Printf("    @C{delete-archive}    Purge backup archives, and bring them back out\n")
+		fmt.Printf("                      of the recycle bin.\n")
+		fmt.Printf("    @C{hold-archive}      Place backup archives under legal hold.\n")
+		fmt.Printf("    @C{restore}           Restore backup archives.\n")
+		fmt.Printf("    @C{download}          Download the contents of backup archives.\n")
+		fmt.Printf("    @C{import}            Import backups made outside of SHIELD.\n")
+		fmt.Printf("    @C{audit}             See the audit log of changes made to the tenant.\n")
+		fmt.Printf("    @C{manage-members}    Invite and banish tenant members, and manage\n")
+		fmt.Printf("                      custom roles.\n")
+		fmt.Printf("\n")
+
 	/* }}} */
 	case "run-job": /* {{{ */
 		fmt.Printf("USAGE: @G{shield} run-job --tenant @Y{TENANT} @Y{NAME-OR-UUID}\n")
@@ -1333,8 +2350,45 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("  When you run a job via @C{shield run-job}, SHIELD will temporarily\n")
 		fmt.Printf("  ignore the schedule and schedule an immediate, ad hoc execution\n")
-		fmt.Printf("  of the job, whether or not it was paused\n")
+		fmt.Printf("  of the job, whether or not it was paused.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  If the tenant has reached its storage or archive quota, and\n")
+		fmt.Printf("  enforces its quotas, the job will not be run.\n")
+		fmt.Printf("\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "schedule": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} schedule --tenant @Y{TENANT} [OPTIONS]\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Preview Upcoming Backup Runs.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Works out when each of the tenant's backup jobs will next run,\n")
+		fmt.Printf("  taking into account offsets, exclusions and blackouts, along\n")
+		fmt.Printf("  with how long each run is expected to take, based on its most\n")
+		fmt.Printf("  recent successful backups.\n")
 		fmt.Printf("\n")
+		fmt.Printf("  Runs that overlap one another on the same SHIELD agent, or\n")
+		fmt.Printf("  against the same cloud storage system, are listed separately,\n")
+		fmt.Printf("  after the full schedule.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Options:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  By default, the next 24 hours are shown.  You may pick a\n")
+		fmt.Printf("  different stretch of time (up to 31 days) with the following\n")
+		fmt.Printf("  command-line flags.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --from          When to start the preview, in the format\n")
+		fmt.Printf("                  set by $SHIELD_DATE_FORMAT.  Defaults to now.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --until         When to end the preview, in the same format.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --for           How long the preview should cover, instead\n")
+		fmt.Printf("                  of an explicit --until, i.e. @C{12h} or @C{168h}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --global        Preview the schedules of every tenant's jobs,\n")
+		fmt.Printf("                  instead of just one tenant.  Requires SHIELD\n")
+		fmt.Printf("                  engineer rights, and no --tenant.\n")
 		fmt.Printf("\n")
 
 	/* }}} */
@@ -1408,6 +2462,32 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("\n")
 
+	/* }}} */
+	case "simulate-retention": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} simulate-retention --tenant @Y{TENANT} [OPTIONS] @Y{JOB}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Preview the Effects of a Retention Change.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Works out which of the job's valid backup archives would be kept,\n")
+		fmt.Printf("  and which would be expired and purged, were the job to keep its\n")
+		fmt.Printf("  archives for a different number of days, along with how much\n")
+		fmt.Printf("  cloud storage would be freed up.  Nothing is actually changed.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Each archive's expiry is fixed when it is taken, so changing the\n")
+		fmt.Printf("  retention of a job (with @C{shield update-job --retain}) only affects\n")
+		fmt.Printf("  archives taken from then on.  This simulation shows what would\n")
+		fmt.Printf("  happen if the proposed retention applied to existing archives, too.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Options:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --retain        The proposed retention period, in days (i.e. @C{14d})\n")
+		fmt.Printf("                  or weeks (i.e. @C{4w}).  Defaults to the job's current\n")
+		fmt.Printf("                  retention period.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --all           Also list the archives that would be kept, along\n")
+		fmt.Printf("                  with when each would expire.\n")
+		fmt.Printf("\n")
+
 	/* }}} */
 	case "status": /* {{{ */
 		fmt.Printf("USAGE: @G{shield} status\n")
@@ -1569,6 +2649,10 @@ func ShowHelp(command string) {
 		fmt.Printf("                   @M{failed}     The task has finished, but there\n")
 		fmt.Printf("                              was an unrecoverable error.\n")
 		fmt.Printf("\n")
+		fmt.Printf("                   @M{overrun}    The task ran past its maximum run\n")
+		fmt.Printf("                              time or backup window, and was\n")
+		fmt.Printf("                              terminated by SHIELD.\n")
+		fmt.Printf("\n")
 		fmt.Printf("                   @M{done}       The task finished succesfully.\n")
 		fmt.Printf("\n")
 		fmt.Printf("                 Additionally, you can use the special status @M{all}\n")
@@ -1585,6 +2669,12 @@ func ShowHelp(command string) {
 		fmt.Printf("                   @M{purge}             Purge a single backup archive\n")
 		fmt.Printf("                                     from cloud storage.\n")
 		fmt.Printf("\n")
+		fmt.Printf("                   @M{hold}              Place a legal hold on a single\n")
+		fmt.Printf("                                     backup archive in cloud storage.\n")
+		fmt.Printf("\n")
+		fmt.Printf("                   @M{release}           Lift a legal hold from a single\n")
+		fmt.Printf("                                     backup archive in cloud storage.\n")
+		fmt.Printf("\n")
 		fmt.Printf("                   @M{test-store}        Test a cloud storage system for\n")
 		fmt.Printf("                                     viability (a small store+retrieve).\n")
 		fmt.Printf("\n")
@@ -1615,6 +2705,9 @@ func ShowHelp(command string) {
 		fmt.Printf("  Each SHIELD Core defines one or more tenants, each with their own\n")
 		fmt.Printf("  set of cloud storage configurations, data systems, and backup jobs.\n")
 		fmt.Printf("\n")
+		fmt.Printf("  The tenant's quotas are listed alongside how much of each\n")
+		fmt.Printf("  resource it is using right now.\n")
+		fmt.Printf("\n")
 		fmt.Printf("  @Y{NOTE:} This command is only available to @R{SHIELD Site Managers}.\n")
 		fmt.Printf("\n")
 		fmt.Printf("@B{Options:}\n")
@@ -1646,8 +2739,58 @@ func ShowHelp(command string) {
 		fmt.Printf("  When you schedule backup jobs in SHIELD, you use a language\n")
 		fmt.Printf("  called \"timespec\" to indicate when and how often you want the\n")
 		fmt.Printf("  job to execute.  This command parses a timespec (server-side)\n")
-		fmt.Printf("  and re-assembles it into canonical form.\n")
+		fmt.Printf("  and re-assembles it into canonical form, and then shows you\n")
+		fmt.Printf("  the next 10 times it would run, skipping over any days that\n")
+		fmt.Printf("  its @C{except} clause (and exclusion calendars) rule out.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Example:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @W{shield timespec} \"daily 2am except sundays and dates in the us-holidays calendar\"\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "undelete-archive": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} undelete-archive --tenant @Y{TENANT} [OPTIONS] @Y{UUID}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Undelete a Backup Archive.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Backup archives that are purged (by hand, or because they have\n")
+		fmt.Printf("  outlived their retention) are first moved to a recycle bin, and\n")
+		fmt.Printf("  marked as @M{pending-purge}.  They stay there for a grace period\n")
+		fmt.Printf("  (see @G{shield archive} for when it ends), after which they are\n")
+		fmt.Printf("  removed from cloud storage for good.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Until then, you can bring them back with this command.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Options:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --expires       When the undeleted archive should expire, formatted\n")
+		fmt.Printf("                  per the SHIELD_DATE_FORMAT environment variable (by\n")
+		fmt.Printf("                  default, \"YYYY-MM-DD HH:MM:SS-ZZZZ\").  This is\n")
+		fmt.Printf("                  required for archives that have already outlived\n")
+		fmt.Printf("                  their original retention.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Examples:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  # Oops, didn't mean to purge that one...\n")
+		fmt.Printf("  @W{shield undelete-archive} \\\n")
+		fmt.Printf("    @Y{d5a80d64-72bd-423a-8411-61b65dbb4188}\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "unlabel-archive": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} unlabel-archive --tenant @Y{TENANT} @Y{UUID} @Y{NAME} ...\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Remove Labels from a Backup Archive.\n")
 		fmt.Printf("\n")
+		fmt.Printf("  See @G{shield label-archive} for more on labels.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Examples:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  # Nobody cares about that ticket anymore\n")
+		fmt.Printf("  @W{shield unlabel-archive} \\\n")
+		fmt.Printf("    @Y{ca24f30b-87f6-4599-bf9e-818998c4b0de} \\\n")
+		fmt.Printf("    ticket\n")
 		fmt.Printf("\n")
 
 	/* }}} */
@@ -1675,6 +2818,22 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("\n")
 
+	/* }}} */
+	case "unlock-user": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} unlock-user @Y{NAME-OR-UUID}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Lift a login lockout on a local SHIELD User.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  After too many failed login attempts, SHIELD refuses to let anyone\n")
+		fmt.Printf("  log in as a user for a while.  This command lifts that lockout\n")
+		fmt.Printf("  immediately, and forgets about the failed attempts.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Note that logins from an IP address can be locked out as well;\n")
+		fmt.Printf("  see @G{shield lockouts} and @G{shield clear-lockout}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @Y{NOTE:} This command can only be used by SHIELD site managers.\n")
+		fmt.Printf("\n")
+
 	/* }}} */
 	case "unpause-job": /* {{{ */
 		fmt.Printf("USAGE: @G{shield} unpause-job --tenant @Y{TENANT} @Y{NAME-OR-UUID}\n")
@@ -1695,6 +2854,128 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("\n")
 
+	/* }}} */
+	case "update-blackout": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} update-blackout --tenant @Y{TENANT} [OPTIONS] @Y{NAME-OR-UUID}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Reconfigure a Blackout Window.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Blackouts are windows of time during which SHIELD will not run\n")
+		fmt.Printf("  scheduled backup jobs.  They can recur on a timespec schedule,\n")
+		fmt.Printf("  lasting a fixed amount of time, or cover a one-off, explicit\n")
+		fmt.Printf("  range of time, i.e. a data center move or a change freeze.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Options:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  -n, --name      A new name for your blackout.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  -s, --summary   An optional, long-form description for the blackout.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --target        The name or UUID of a target data system to limit\n")
+		fmt.Printf("                  the blackout to.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --no-target     Apply the blackout to every job in the tenant.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --schedule      A @W{timespec} schedule description, saying when\n")
+		fmt.Printf("                  each occurrence of a recurring blackout starts,\n")
+		fmt.Printf("                  i.e. @C{daily 1am} or @C{sundays at 22:00}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --duration      How long each occurrence of a recurring blackout\n")
+		fmt.Printf("                  lasts, i.e. @C{90m} or @C{48h}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --from          The start and end of a one-off blackout, i.e.\n")
+		fmt.Printf("  --until         @C{\"2026-12-24 00:00:00+0000\"}.  These cannot be\n")
+		fmt.Printf("                  combined with @Y{--schedule}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --catch-up      Whether or not to run a single, catch-up backup\n")
+		fmt.Printf("  --no-catch-up   for each job that missed a run during the blackout.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Switching a blackout from recurring to one-off (or vice versa)\n")
+		fmt.Printf("  clears out the old schedule (or range of time).\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "update-calendar": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} update-calendar [OPTIONS] @Y{NAME-OR-UUID}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Add or remove dates from an Exclusion Calendar.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Exclusion calendars are named lists of dates (i.e. public\n")
+		fmt.Printf("  holidays, or month-end close) that backup job schedules can\n")
+		fmt.Printf("  skip, with an @C{except} clause, like this:\n")
+		fmt.Printf("\n")
+		fmt.Printf("    @C{daily 2am except dates in the us-holidays calendar}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @Y{NOTE:} Calendars are shared by every tenant, and you must be\n")
+		fmt.Printf("  a site engineer to manage them.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Backup jobs that skip the dates in the calendar are rescheduled\n")
+		fmt.Printf("  as soon as its dates change.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Options:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  -n, --name      A new name for the calendar.  Calendars that are\n")
+		fmt.Printf("                  still named in the schedule of a backup job cannot\n")
+		fmt.Printf("                  be renamed.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  -s, --summary   An optional, long-form description for the calendar.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --add-date      A date to exclude, in YYYY-MM-DD format.  Can be\n")
+		fmt.Printf("                  given more than once.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --remove-date   A date to no longer exclude.  Can be given more\n")
+		fmt.Printf("                  than once.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --clear-dates   Remove all of the existing dates from the calendar,\n")
+		fmt.Printf("                  before adding any new ones from @Y{--add-date}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Example:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  # roll the holiday calendar over to next year\n")
+		fmt.Printf("  @W{shield update-calendar} us-holidays  \\\n")
+		fmt.Printf("      @Y{--clear-dates}                    \\\n")
+		fmt.Printf("      @Y{--add-date}  2027-01-01           \\\n")
+		fmt.Printf("      @Y{--add-date}  2027-01-18\n")
+		fmt.Printf("\n")
+
+	/* }}} */
+	case "update-global-blackout": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} update-global-blackout [OPTIONS] @Y{NAME-OR-UUID}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Reconfigure a Global Blackout Window.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Blackouts are windows of time during which SHIELD will not run\n")
+		fmt.Printf("  scheduled backup jobs.  They can recur on a timespec schedule,\n")
+		fmt.Printf("  lasting a fixed amount of time, or cover a one-off, explicit\n")
+		fmt.Printf("  range of time, i.e. a data center move or a change freeze.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @Y{NOTE:} Global blackouts apply to every job, in every tenant,\n")
+		fmt.Printf("  and you must be a site engineer to manage them.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Options:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  -n, --name      A new name for your blackout.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  -s, --summary   An optional, long-form description for the blackout.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --schedule      A @W{timespec} schedule description, saying when\n")
+		fmt.Printf("                  each occurrence of a recurring blackout starts,\n")
+		fmt.Printf("                  i.e. @C{daily 1am} or @C{sundays at 22:00}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --duration      How long each occurrence of a recurring blackout\n")
+		fmt.Printf("                  lasts, i.e. @C{90m} or @C{48h}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --from          The start and end of a one-off blackout, i.e.\n")
+		fmt.Printf("  --until         @C{\"2026-12-24 00:00:00+0000\"}.  These cannot be\n")
+		fmt.Printf("                  combined with @Y{--schedule}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --catch-up      Whether or not to run a single, catch-up backup\n")
+		fmt.Printf("  --no-catch-up   for each job that missed a run during the blackout.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Switching a blackout from recurring to one-off (or vice versa)\n")
+		fmt.Printf("  clears out the old schedule (or range of time).\n")
+		fmt.Printf("\n")
+
 	/* }}} */
 	case "update-global-store": /* {{{ */
 		fmt.Printf("USAGE: @G{shield} update-global-store [OPTIONS] @Y{NAME-OR-UUID}\n")
@@ -1795,6 +3076,26 @@ func ShowHelp(command string) {
 		fmt.Printf("                  Backups of SHIELD itself should use this option\n")
 		fmt.Printf("                  to enable recovery in a disaster scenario\n")
 		fmt.Printf("\n")
+		fmt.Printf("  --max-runtime   How long a single backup may run before SHIELD\n")
+		fmt.Printf("                  terminates it, i.e. @C{90m} or @C{4h}.  Backups\n")
+		fmt.Printf("                  that are cut short are marked as @M{overrun}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --window-start  Only start backups between these two times of\n")
+		fmt.Printf("  --window-end    day, i.e. @C{22:00} and @C{4am}.  Backups that come\n")
+		fmt.Printf("                  due outside of the window wait for it to open.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --finish-by     A time of day by which backups must be finished,\n")
+		fmt.Printf("                  i.e. @C{6:30am}.  Must fall outside of the window.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --no-window     Remove the backup window (and finish-by time)\n")
+		fmt.Printf("                  from the job, so that it can run at any time.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --jitter        Delay each scheduled run by up to this long, i.e.\n")
+		fmt.Printf("                  @C{10m}.  Use @C{0} to run exactly on schedule.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --hashed-jitter     Use the same delay for every run, derived\n")
+		fmt.Printf("  --no-hashed-jitter  from the job's UUID, or a random one.\n")
+		fmt.Printf("\n")
 		fmt.Printf("  To pause/unpause a job, please use \"pause-job\" or \"unpause-job\".\n")
 		fmt.Printf("\n")
 		fmt.Printf("  In @Y{--batch} mode, the name or UUID specified on the command-line\n")
@@ -1817,6 +3118,37 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("\n")
 
+	/* }}} */
+	case "update-role": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} update-role --tenant @Y{TENANT} [OPTIONS] @Y{NAME}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Grant or revoke the permissions of a custom role.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Changes take effect immediately, for every tenant member who holds\n")
+		fmt.Printf("  the role.  The built-in @M{admin}, @M{engineer}, and @M{operator}\n")
+		fmt.Printf("  roles cannot be changed.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @Y{NOTE:} This command is only available to @R{SHIELD Site Managers},\n")
+		fmt.Printf("        and to those who can manage the members of the tenant.  You\n")
+		fmt.Printf("        cannot grant permissions you do not hold yourself.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Options:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  -s, --summary   A new long-form description for the role.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --grant         A permission to grant to the role.  Can be given\n")
+		fmt.Printf("                  more than once.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --revoke        A permission to revoke from the role.  Can be given\n")
+		fmt.Printf("                  more than once.\n")
+		fmt.Printf("\n")
+		fmt.Printf("@B{Example:}\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @W{shield update-role} @Y{--tenant} acme restorer \\\n")
+		fmt.Printf("      @Y{--grant}   download \\\n")
+		fmt.Printf("      @Y{--revoke}  restore\n")
+		fmt.Printf("\n")
+
 	/* }}} */
 	case "update-store": /* {{{ */
 		fmt.Printf("USAGE: @G{shield} update-store --tenant @Y{TENANT} [OPTIONS] @Y{NAME-OR-UUID}\n")
@@ -1944,7 +3276,12 @@ func ShowHelp(command string) {
 
 	/* }}} */
 	case "update-tenant": /* {{{ */
-		fmt.Printf("USAGE: @G{shield} update-tenant [--name @Y{NAME}] @Y{NAME-OR-UUID}\n")
+		fmt.Printf("USAGE: @G{shield} update-tenant [--name @Y{NAME}] [--share @Y{N}] [--spread @Y{WINDOW}]\n")
+		fmt.Printf("                             [--[no-]require-approval] [--approval-timeout @Y{TIMEOUT}]\n")
+		fmt.Printf("                             [--storage-quota @Y{SIZE}] [--archive-quota @Y{N}]\n")
+		fmt.Printf("                             [--job-quota @Y{N}] [--target-quota @Y{N}]\n")
+		fmt.Printf("                             [--task-quota @Y{N}] [--[no-]enforce-quotas]\n")
+		fmt.Printf("                             @Y{NAME-OR-UUID}\n")
 		fmt.Printf("\n")
 		fmt.Printf("  Update an existing SHIELD Tenant.\n")
 		fmt.Printf("\n")
@@ -1957,6 +3294,26 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("  --name         The name to assign this tenant, effectively renaming it.\n")
 		fmt.Printf("\n")
+		fmt.Printf("  --share        The tenant's share of the SHIELD Core scheduler, relative\n")
+		fmt.Printf("                 to other tenants.  See @G{shield} @Y{create-tenant} for details.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --spread       Spread the tenant's jobs evenly across this window.\n")
+		fmt.Printf("  --no-spread    See @G{shield} @Y{create-tenant} for details.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --require-approval     Require (or stop requiring) a second person to\n")
+		fmt.Printf("  --no-require-approval  approve restores, archive purges, and target\n")
+		fmt.Printf("                         deletions.  See @G{shield} @Y{create-tenant}.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --approval-timeout     How long requests for approval stay open.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --storage-quota        Change the tenant's quotas; @C{0} means\n")
+		fmt.Printf("  --archive-quota        \"unlimited\".  See @G{shield} @Y{create-tenant}\n")
+		fmt.Printf("  --job-quota            for details.\n")
+		fmt.Printf("  --target-quota\n")
+		fmt.Printf("  --task-quota\n")
+		fmt.Printf("\n")
+		fmt.Printf("  --enforce-quotas       Refuse (or stop refusing) to run backups once\n")
+		fmt.Printf("  --no-enforce-quotas    the tenant has reached its quota.\n")
 		fmt.Printf("\n")
 
 	/* }}} */
@@ -2054,6 +3411,28 @@ func ShowHelp(command string) {
 		fmt.Printf("\n")
 		fmt.Printf("\n")
 
+	/* }}} */
+	case "verify-audit": /* {{{ */
+		fmt.Printf("USAGE: @G{shield} verify-audit\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Check the audit log for signs of tampering.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  Each entry in the audit log carries a hash of its own contents and\n")
+		fmt.Printf("  of the entry before it.  This command walks the whole log, from the\n")
+		fmt.Printf("  first entry to the last, checking that every entry follows on from\n")
+		fmt.Printf("  the one before it, and still matches its hash.  If an entry has been\n")
+		fmt.Printf("  altered or removed, it reports the first entry that doesn't check\n")
+		fmt.Printf("  out, and exits non-zero.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  The hash of the last entry is printed as well; keeping a copy of it\n")
+		fmt.Printf("  somewhere safe makes it possible to tell later on whether entries\n")
+		fmt.Printf("  have been removed from the end of the log.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  @Y{NOTE:} This command can only be used by SHIELD site managers.\n")
+		fmt.Printf("\n")
+		fmt.Printf("  See also @G{shield audit}.\n")
+		fmt.Printf("\n")
+
 	/* }}} */
 
 	default:
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"io"
 	"io/ioutil"
 	"os"
 	"regexp"
@@ -82,6 +83,7 @@ var opts struct {
 
 		Username string `cli:"-u, --username, --user" env:"SHIELD_CORE_USERNAME"`
 		Password string `cli:"-p, --password, --pass" env:"SHIELD_CORE_PASSWORD"`
+		Code     string `cli:"--code"`
 
 		Token string `cli:"-a, --auth-token, --token" env:"SHIELD_CORE_TOKEN"`
 
@@ -90,6 +92,18 @@ var opts struct {
 	Logout struct{} `cli:"logout"`
 	ID     struct{} `cli:"id"`
 
+	MFA       struct{} `cli:"mfa"`
+	EnableMFA struct {
+		Password string `cli:"-p, --password, --pass"`
+		Code     string `cli:"--code"`
+	} `cli:"enable-mfa"`
+	DisableMFA struct {
+		Code string `cli:"--code"`
+	} `cli:"disable-mfa"`
+	MFARecoveryCodes struct {
+		Code string `cli:"--code"`
+	} `cli:"mfa-recovery-codes"`
+
 	/* }}} */
 	/* LOCKING / INIT {{{ */
 	Init struct {
@@ -108,7 +122,13 @@ var opts struct {
 	/* }}} */
 	/* AUTH TOKENS {{{ */
 	AuthTokens      struct{} `cli:"auth-tokens"`
-	CreateAuthToken struct{} `cli:"create-auth-token"`
+	CreateAuthToken struct {
+		ForTenant string   `cli:"--for-tenant"`
+		Role      string   `cli:"--role"`
+		Ops       []string `cli:"--op"`
+		Allow     []string `cli:"--allow"`
+		Expires   string   `cli:"--expires"`
+	} `cli:"create-auth-token"`
 	RevokeAuthToken struct{} `cli:"revoke-auth-token"`
 
 	/* }}} */
@@ -118,10 +138,33 @@ var opts struct {
 		Members bool `cli:"--members"`
 	} `cli:"tenant"`
 	CreateTenant struct {
-		Name string `cli:"-n, --name"`
+		Name            string `cli:"-n, --name"`
+		Share           int    `cli:"--share"`
+		Spread          string `cli:"--spread"`
+		RequireApproval bool   `cli:"--require-approval"`
+		ApprovalTimeout string `cli:"--approval-timeout"`
+		StorageQuota    string `cli:"--storage-quota"`
+		ArchiveQuota    int    `cli:"--archive-quota"`
+		JobQuota        int    `cli:"--job-quota"`
+		TargetQuota     int    `cli:"--target-quota"`
+		TaskQuota       int    `cli:"--task-quota"`
+		EnforceQuotas   bool   `cli:"--enforce-quotas"`
 	} `cli:"create-tenant"`
 	UpdateTenant struct {
-		Name string `cli:"-n, --name"`
+		Name              string `cli:"-n, --name"`
+		Share             int    `cli:"--share"`
+		Spread            string `cli:"--spread"`
+		NoSpread          bool   `cli:"--no-spread"`
+		RequireApproval   bool   `cli:"--require-approval"`
+		NoRequireApproval bool   `cli:"--no-require-approval"`
+		ApprovalTimeout   string `cli:"--approval-timeout"`
+		StorageQuota      string `cli:"--storage-quota"`
+		ArchiveQuota      string `cli:"--archive-quota"`
+		JobQuota          string `cli:"--job-quota"`
+		TargetQuota       string `cli:"--target-quota"`
+		TaskQuota         string `cli:"--task-quota"`
+		EnforceQuotas     bool   `cli:"--enforce-quotas"`
+		NoEnforceQuotas   bool   `cli:"--no-enforce-quotas"`
 	} `cli:"update-tenant"`
 	DeleteTenant struct {
 		Recursive bool `cli:"-r, --recursive"`
@@ -135,6 +178,30 @@ var opts struct {
 	} `cli:"invite"`
 	/* FIXME: delete-tenant */
 
+	/* }}} */
+	/* ROLES {{{ */
+	Roles      struct{} `cli:"roles"`
+	DeleteRole struct{} `cli:"delete-role"`
+	CreateRole struct {
+		Summary     string   `cli:"-s, --summary"`
+		Permissions []string `cli:"-p, --permission"`
+	} `cli:"create-role"`
+	UpdateRole struct {
+		Summary string   `cli:"-s, --summary"`
+		Grant   []string `cli:"--grant"`
+		Revoke  []string `cli:"--revoke"`
+	} `cli:"update-role"`
+
+	/* }}} */
+	/* APPROVALS {{{ */
+	Approvals struct {
+		All   bool `cli:"-a, --all"`
+		Limit int  `cli:"-l, --limit"`
+	} `cli:"approvals"`
+	Approval struct{} `cli:"approval"`
+	Approve  struct{} `cli:"approve"`
+	Reject   struct{} `cli:"reject"`
+
 	/* }}} */
 	/* TARGETS {{{ */
 	Targets struct {
@@ -230,6 +297,12 @@ var opts struct {
 		Paused   bool   `cli:"--paused"`
 		Unpaused bool   `cli:"--unpaused"`
 	} `cli:"jobs"`
+	Schedule struct {
+		Global bool   `cli:"--global"`
+		From   string `cli:"--from"`
+		Until  string `cli:"--until"`
+		For    string `cli:"--for"`
+	} `cli:"schedule"`
 	Job        struct{} `cli:"job"`
 	DeleteJob  struct{} `cli:"delete-job"`
 	PauseJob   struct{} `cli:"pause-job"`
@@ -245,6 +318,14 @@ var opts struct {
 		Paused   bool   `cli:"--paused"`
 		FixedKey bool   `cli:"--fixed-key"`
 		Retries  int    `cli:"--retries"`
+
+		MaxRuntime  string `cli:"--max-runtime"`
+		WindowStart string `cli:"--window-start"`
+		WindowEnd   string `cli:"--window-end"`
+		FinishBy    string `cli:"--finish-by"`
+
+		Jitter       string `cli:"--jitter"`
+		HashedJitter bool   `cli:"--hashed-jitter"`
 	} `cli:"create-job"`
 	UpdateJob struct {
 		Name       string `cli:"-n, --name"`
@@ -256,25 +337,142 @@ var opts struct {
 		FixedKey   bool   `cli:"--fixed-key"`
 		NoFixedKey bool   `cli:"--no-fixed-key"`
 		Retries    int    `cli:"--retries"`
+
+		MaxRuntime  string `cli:"--max-runtime"`
+		WindowStart string `cli:"--window-start"`
+		WindowEnd   string `cli:"--window-end"`
+		FinishBy    string `cli:"--finish-by"`
+		NoWindow    bool   `cli:"--no-window"`
+
+		Jitter         string `cli:"--jitter"`
+		HashedJitter   bool   `cli:"--hashed-jitter"`
+		NoHashedJitter bool   `cli:"--no-hashed-jitter"`
 	} `cli:"update-job"`
+	SimulateRetention struct {
+		Retain string `cli:"--retain"`
+		All    bool   `cli:"--all"`
+	} `cli:"simulate-retention"`
+
+	/* }}} */
+	/* BLACKOUTS {{{ */
+	Blackouts struct {
+		Target string `cli:"--target"`
+	} `cli:"blackouts"`
+	Blackout       struct{} `cli:"blackout"`
+	DeleteBlackout struct{} `cli:"delete-blackout"`
+	CreateBlackout struct {
+		Name     string `cli:"-n, --name"`
+		Summary  string `cli:"-s, --summary"`
+		Target   string `cli:"--target"`
+		Schedule string `cli:"--schedule"`
+		Duration string `cli:"--duration"`
+		From     string `cli:"--from"`
+		Until    string `cli:"--until"`
+		CatchUp  bool   `cli:"--catch-up"`
+	} `cli:"create-blackout"`
+	UpdateBlackout struct {
+		Name      string `cli:"-n, --name"`
+		Summary   string `cli:"-s, --summary"`
+		Target    string `cli:"--target"`
+		NoTarget  bool   `cli:"--no-target"`
+		Schedule  string `cli:"--schedule"`
+		Duration  string `cli:"--duration"`
+		From      string `cli:"--from"`
+		Until     string `cli:"--until"`
+		CatchUp   bool   `cli:"--catch-up"`
+		NoCatchUp bool   `cli:"--no-catch-up"`
+	} `cli:"update-blackout"`
+
+	GlobalBlackouts struct {
+	} `cli:"global-blackouts"`
+	GlobalBlackout       struct{} `cli:"global-blackout"`
+	DeleteGlobalBlackout struct{} `cli:"delete-global-blackout"`
+	CreateGlobalBlackout struct {
+		Name     string `cli:"-n, --name"`
+		Summary  string `cli:"-s, --summary"`
+		Schedule string `cli:"--schedule"`
+		Duration string `cli:"--duration"`
+		From     string `cli:"--from"`
+		Until    string `cli:"--until"`
+		CatchUp  bool   `cli:"--catch-up"`
+	} `cli:"create-global-blackout"`
+	UpdateGlobalBlackout struct {
+		Name      string `cli:"-n, --name"`
+		Summary   string `cli:"-s, --summary"`
+		Schedule  string `cli:"--schedule"`
+		Duration  string `cli:"--duration"`
+		From      string `cli:"--from"`
+		Until     string `cli:"--until"`
+		CatchUp   bool   `cli:"--catch-up"`
+		NoCatchUp bool   `cli:"--no-catch-up"`
+	} `cli:"update-global-blackout"`
+
+	/* }}} */
+	/* CALENDARS {{{ */
+	Calendars      struct{} `cli:"calendars"`
+	Calendar       struct{} `cli:"calendar"`
+	DeleteCalendar struct{} `cli:"delete-calendar"`
+	CreateCalendar struct {
+		Name    string   `cli:"-n, --name"`
+		Summary string   `cli:"-s, --summary"`
+		Dates   []string `cli:"--date"`
+	} `cli:"create-calendar"`
+	UpdateCalendar struct {
+		Name        string   `cli:"-n, --name"`
+		Summary     string   `cli:"-s, --summary"`
+		AddDates    []string `cli:"--add-date"`
+		RemoveDates []string `cli:"--remove-date"`
+		ClearDates  bool     `cli:"--clear-dates"`
+	} `cli:"update-calendar"`
 
 	/* }}} */
 	/* ARCHIVES {{{ */
 	Archives struct {
-		Target string `cli:"--target"`
-		Store  string `cli:"--store"`
-		Limit  int    `cli:"-l, --limit"`
+		Target string   `cli:"--target"`
+		Store  string   `cli:"--store"`
+		Labels []string `cli:"--label"`
+		Search string   `cli:"--search"`
+		Limit  int      `cli:"-l, --limit"`
 	} `cli:"archives"`
 	Archive        struct{} `cli:"archive"`
 	RestoreArchive struct {
 		Target string `cli:"--target, --to"`
 	} `cli:"restore-archive"`
+	Restore struct {
+		Target string `cli:"--target"`
+		AsOf   string `cli:"--as-of"`
+	} `cli:"restore"`
 	PurgeArchive struct {
 		Reason string `cli:"--reason"`
 	} `cli:"purge-archive"`
 	AnnotateArchive struct {
 		Notes string `cli:"--notes"`
 	} `cli:"annotate-archive"`
+	LabelArchive    struct{} `cli:"label-archive"`
+	UnlabelArchive  struct{} `cli:"unlabel-archive"`
+	UndeleteArchive struct {
+		Expires string `cli:"--expires"`
+	} `cli:"undelete-archive"`
+	HoldArchive struct {
+		Reason string `cli:"--reason"`
+		Until  string `cli:"--until"`
+	} `cli:"hold-archive"`
+	ReleaseArchive  struct{} `cli:"release-archive"`
+	DownloadArchive struct {
+		Output     string `cli:"-o, --output"`
+		Decompress bool   `cli:"--decompress"`
+	} `cli:"download-archive"`
+	ImportArchive struct {
+		Target      string `cli:"--target"`
+		Store       string `cli:"--store"`
+		File        string `cli:"-f, --file"`
+		Key         string `cli:"--key"`
+		Compression string `cli:"--compression"`
+		TakenAt     string `cli:"--taken-at"`
+		Expires     string `cli:"--expires"`
+		Notes       string `cli:"--notes"`
+		Unencrypted bool   `cli:"--unencrypted"`
+	} `cli:"import-archive"`
 
 	/* }}} */
 	/* TASKS {{{ */
@@ -299,6 +497,8 @@ var opts struct {
 	} `cli:"users"`
 	User       struct{} `cli:"user"`
 	DeleteUser struct{} `cli:"delete-user"`
+	ResetMFA   struct{} `cli:"reset-mfa"`
+	UnlockUser struct{} `cli:"unlock-user"`
 	Passwd     struct{} `cli:"passwd"`
 	CreateUser struct {
 		Name     string `cli:"-n, --name"`
@@ -320,6 +520,21 @@ var opts struct {
 	} `cli:"sessions"`
 	Session       struct{} `cli:"session"`
 	DeleteSession struct{} `cli:"delete-session"`
+	Lockouts      struct{} `cli:"lockouts"`
+	ClearLockout  struct{} `cli:"clear-lockout"`
+	/* }}} */
+	/* AUDIT {{{ */
+	Audit struct {
+		Limit  int    `cli:"-l, --limit"`
+		Actor  string `cli:"--actor"`
+		Type   string `cli:"--type"`
+		Object string `cli:"--object"`
+		Method string `cli:"--method"`
+		Since  string `cli:"--since"`
+		Until  string `cli:"--until"`
+		Export bool   `cli:"--export"`
+	} `cli:"audit"`
+	VerifyAudit struct{} `cli:"verify-audit"`
 	/* }}} */
 	/* AGENTS {{{ */
 	Agents struct {
@@ -533,6 +748,11 @@ func main() {
 			printc("  id                       Display information about the current session.\n")
 			printc("  passwd                   Change your password.\n")
 			blank()
+			printc("  mfa                      Show your multi-factor authentication status.\n")
+			printc("  enable-mfa               Set up multi-factor authentication, with an authenticator app.\n")
+			printc("  disable-mfa              Turn off multi-factor authentication.\n")
+			printc("  mfa-recovery-codes       Issue a new set of multi-factor recovery codes.\n")
+			blank()
 			printc("  auth-tokens              List your personal authentication tokens.\n")
 			printc("  create-auth-token        Issue a new personal authentication token.\n")
 			printc("  revoke-auth-token        Revoke an issued authentication token\n")
@@ -550,15 +770,35 @@ func main() {
 			printc("  update-global-store      Reconfigure a shared cloud storage system.\n")
 			printc("  delete-global-store      Decomission an unused shared cloud storage system.\n")
 			blank()
+			printc("  global-blackouts         List blackout windows that apply to all tenants.\n")
+			printc("  global-blackout          Display details for a single global blackout window.\n")
+			printc("  create-global-blackout   Configure a new global blackout window.\n")
+			printc("  update-global-blackout   Reconfigure a global blackout window.\n")
+			printc("  delete-global-blackout   Remove a global blackout window.\n")
+			blank()
+			printc("  calendars                List exclusion calendars, for skipping holidays and the like.\n")
+			printc("  calendar                 Display the dates in a single exclusion calendar.\n")
+			printc("  create-calendar          Configure a new exclusion calendar.\n")
+			printc("  update-calendar          Add or remove dates from an exclusion calendar.\n")
+			printc("  delete-calendar          Remove an unused exclusion calendar.\n")
+			blank()
 			printc("  users                    List all of the local user accounts.\n")
 			printc("  user                     Display the details for a single local user account.\n")
 			printc("  create-user              Create a new local user account.\n")
 			printc("  update-user              Modify the account settings of a local user.\n")
 			printc("  delete-user              Delete a local user account.\n")
+			printc("  reset-mfa                Turn off multi-factor authentication for a local user.\n")
+			printc("  unlock-user              Lift a login lockout on a local user account.\n")
 			blank()
 			printc("  sessions                 List all authenticated sessions.\n")
 			printc("  session                  Display the details of a single session.\n")
 			printc("  delete-session           Revoke (forcibly de-authenticate) a session.\n")
+			blank()
+			printc("  lockouts                 List accounts and IP addresses locked out after failed logins.\n")
+			printc("  clear-lockout            Lift a login lockout on an account or IP address.\n")
+			blank()
+			printc("  audit                    Show (or export) the audit log of changes made via the API.\n")
+			printc("  verify-audit             Check the audit log for signs of tampering.\n")
 		}
 		if show("tenant", "tenants") {
 			header("Tenant Management")
@@ -570,6 +810,11 @@ func main() {
 			blank()
 			printc("  invite                   Invite a local user to a SHIELD Tenant.\n")
 			printc("  banish                   Remove a local user from a SHIELD Tenant.\n")
+			blank()
+			printc("  roles                    List the roles that members of a SHIELD Tenant can hold.\n")
+			printc("  create-role              Define a custom role, with its own set of permissions.\n")
+			printc("  update-role              Grant or revoke the permissions of a custom role.\n")
+			printc("  delete-role              Remove an unused custom role.\n")
 		}
 		if show("target", "targets") {
 			header("Target Data Systems")
@@ -598,14 +843,32 @@ func main() {
 			printc("  pause-job                Pause a backup job, so that it doesn't get scheduled.\n")
 			printc("  unpause-job              Unpause a backup job, so that it gets scheduled.\n")
 			printc("  run-job                  Schedule an ad hoc run of a backup job.\n")
+			printc("  simulate-retention       See which archives a different retention period would expire.\n")
+			printc("  schedule                 Preview when backup jobs will run, and which runs overlap.\n")
+		}
+		if show("blackout", "blackouts") {
+			header("Scheduling Blackouts")
+			printc("  blackouts                List blackout windows, during which jobs are not scheduled.\n")
+			printc("  blackout                 Display the details for a single blackout window.\n")
+			printc("  create-blackout          Configure a new blackout window.\n")
+			printc("  update-blackout          Reconfigure a blackout window.\n")
+			printc("  delete-blackout          Remove a blackout window.\n")
 		}
 		if show("archive", "archives", "backup", "backups") {
 			header("Backup Data Archives")
 			printc("  archives                 List all backup archives (valid or otherwise).\n")
 			printc("  archive                  Display the details for a single backup archive.\n")
 			printc("  restore-archive          Restore a backup archive to its original target system, or a new one.\n")
+			printc("  restore                  Restore a target system to the way it was at a point in time.\n")
 			printc("  purge-archive            Remove a backup archive from its cloud storage, and mark it invalid.\n")
 			printc("  annotate-archive         Add notes about this archive, for the benefit of other operators.\n")
+			printc("  label-archive            Set labels on a backup archive, to make it easier to find.\n")
+			printc("  unlabel-archive          Remove labels from a backup archive.\n")
+			printc("  undelete-archive         Bring a purged backup archive back out of the recycle bin.\n")
+			printc("  hold-archive             Place a backup archive under legal hold, so that it is never purged.\n")
+			printc("  release-archive          Release a legal hold on a backup archive.\n")
+			printc("  download-archive         Download the (decrypted) contents of a backup archive.\n")
+			printc("  import-archive           Import a backup made outside of SHIELD as a backup archive.\n")
 		}
 		if show("task", "tasks") {
 			header("Task Management")
@@ -613,6 +876,13 @@ func main() {
 			printc("  task                     Display the details for a single task.\n")
 			printc("  cancel                   Cancel a running task.\n")
 		}
+		if show("approval", "approvals") {
+			header("Approvals")
+			printc("  approvals                List requests for approval of restores, purges, and target deletions.\n")
+			printc("  approval                 Display the details of a single request for approval.\n")
+			printc("  approve                  Approve someone else's request, carrying it out.\n")
+			printc("  reject                   Reject a request (or withdraw your own).\n")
+		}
 		blank()
 		blank()
 
@@ -905,36 +1175,55 @@ tenants:
 			})
 			bail(err)
 
+		} else if opts.Login.Via != "" {
+			provider, err := c.AuthProviderAnonymous(opts.Login.Via)
+			bail(err)
+
+			if provider.Password {
+				if opts.Login.Username == "" {
+					if opts.Batch {
+						bail(fmt.Errorf("Unable to login interactively under `--batch` mode"))
+					}
+					opts.Login.Username = prompt(fmt.Sprintf("@C{%s Username:} ", provider.Name))
+				}
+				if opts.Login.Password == "" {
+					opts.Login.Password = secureprompt(fmt.Sprintf("@Y{%s Password:} ", provider.Name))
+				}
+				err := localAuth(c, &shield.LocalAuth{
+					Username: opts.Login.Username,
+					Password: opts.Login.Password,
+					Provider: provider.Identifier,
+				})
+				bail(err)
+
+			} else {
+				fmt.Printf("Visit the following URL in your favorite web browser:\n\n")
+				fmt.Printf("  @B{%s%s}\n\n", c.URL, provider.CLIEntry)
+				fmt.Printf("Then, enter the token you get, below.\n\n")
+
+				err = c.Authenticate(&shield.TokenAuth{
+					Token: prompt("@Y{Token:} "),
+				})
+				bail(err)
+			}
+
 		} else if opts.Login.Username != "" {
 			if opts.Login.Password == "" {
 				opts.Login.Password = secureprompt("@Y{SHIELD Password:} ")
 			}
-			err := c.Authenticate(&shield.LocalAuth{
+			err := localAuth(c, &shield.LocalAuth{
 				Username: opts.Login.Username,
 				Password: opts.Login.Password,
 			})
 			bail(err)
 
-		} else if opts.Login.Via != "" {
-			provider, err := c.AuthProviderAnonymous(opts.Login.Via)
-			bail(err)
-
-			fmt.Printf("Visit the following URL in your favorite web browser:\n\n")
-			fmt.Printf("  @B{%s%s}\n\n", c.URL, provider.CLIEntry)
-			fmt.Printf("Then, enter the token you get, below.\n\n")
-
-			err = c.Authenticate(&shield.TokenAuth{
-				Token: prompt("@Y{Token:} "),
-			})
-			bail(err)
-
 		} else if opts.Batch {
 			bail(fmt.Errorf("Unable to login interactively under `--batch` mode"))
 
 		} else {
 			opts.Login.Username = prompt("@C{SHIELD Username:} ")
 			opts.Login.Password = secureprompt("@Y{SHIELD Password:} ")
-			err := c.Authenticate(&shield.LocalAuth{
+			err := localAuth(c, &shield.LocalAuth{
 				Username: opts.Login.Username,
 				Password: opts.Login.Password,
 			})
@@ -1107,15 +1396,21 @@ tenants:
 		if len(args) < 1 {
 			fail(2, "Usage: shield %s \"a schedule string\"\n", command)
 		}
-		ok, spec, err := c.CheckTimespec(strings.Join(args, " "))
+		preview, err := c.PreviewTimespec(strings.Join(args, " "))
 		if err != nil {
-			bail(fmt.Errorf("Failed to check timespec: %s\n", err))
+			bail(fmt.Errorf("Invalid timespec: %s", err))
 		}
-		if !ok {
-			fail(1, "Invalid timespec\n")
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(preview))
+			return
 		}
 
-		fmt.Printf("%s\n", spec)
+		fmt.Printf("%s\n", preview.Timespec)
+		fmt.Printf("\n@C{Next %d runs:}\n", len(preview.Next))
+		for _, t := range preview.Next {
+			fmt.Printf("  %s\n", strftime(t))
+		}
 		return
 
 	/* }}} */
@@ -1329,7 +1624,7 @@ tenants:
 		fmt.Printf("\n\n")
 		fmt.Printf("@M{Task Backlog}\n\n")
 		if len(ps.Backlog) > 0 {
-			tbl = table.NewTable("Priority", "#", "Op", "Task", "System", "Store", "Job", "Archive", "Agent")
+			tbl = table.NewTable("Priority", "#", "Op", "Task", "System", "Store", "Job", "Archive", "Agent", "Waiting On")
 			for _, t := range ps.Backlog {
 				op := oops
 				if t.Op != "" {
@@ -1365,7 +1660,32 @@ tenants:
 				if t.Agent != "" {
 					agent = fmt.Sprintf("@Y{%s}", t.Agent)
 				}
-				tbl.Row(t, t.Priority, t.Position, op, task, system, store, job, archive, agent)
+
+				reason := none
+				if t.Reason != "" {
+					reason = fmt.Sprintf("@C{%s}", t.Reason)
+				}
+				tbl.Row(t, t.Priority, t.Position, op, task, system, store, job, archive, agent, reason)
+			}
+			tbl.Output(os.Stdout)
+
+		} else {
+			fmt.Printf("  none\n")
+		}
+
+		fmt.Printf("\n\n")
+		fmt.Printf("@M{Tenant Fair Share}\n\n")
+		if len(ps.Tenants) > 0 {
+			tbl = table.NewTable("Tenant", "Share", "Queued", "Running", "Longest Wait", "Average Wait", "Last Wait")
+			for _, t := range ps.Tenants {
+				tenant := fmt.Sprintf("@W{%s}", t.Tenant.Name)
+				if t.Tenant.UUID != "" {
+					tenant = fmt.Sprintf("@W{%s}\n(%s)", t.Tenant.Name, uuid8(t.Tenant.UUID))
+				}
+				tbl.Row(t, tenant, t.Share, t.Queued, t.Running,
+					fmt.Sprintf("%ds", t.LongestWait),
+					fmt.Sprintf("%ds", t.AverageWait),
+					fmt.Sprintf("%ds", t.LastWait))
 			}
 			tbl.Output(os.Stdout)
 
@@ -1375,6 +1695,99 @@ tenants:
 
 		fmt.Printf("\n\n")
 
+	/* }}} */
+	case "mfa": /* {{{ */
+		status, err := c.MFAStatus()
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(status))
+			break
+		}
+
+		r := tui.NewReport()
+		if status.Enabled {
+			r.Add("MFA", "@G{enabled}")
+			r.Add("Recovery Codes", fmt.Sprintf("%d remaining", status.RecoveryCodes))
+		} else if status.Required {
+			r.Add("MFA", "@R{disabled (but required for your system role)}")
+		} else {
+			r.Add("MFA", "@Y{disabled}")
+		}
+		r.Output(os.Stdout)
+
+	/* }}} */
+	case "enable-mfa": /* {{{ */
+		/* with --code, we are finishing an enrolment that
+		   was started by a previous (--batch) run */
+		if opts.EnableMFA.Code == "" {
+			if opts.EnableMFA.Password == "" {
+				if opts.Batch {
+					bail(fmt.Errorf("Unable to prompt for your password under `--batch` mode"))
+				}
+				opts.EnableMFA.Password = secureprompt("@Y{SHIELD Password:} ")
+			}
+
+			enrol, err := c.StartMFA(opts.EnableMFA.Password)
+			bail(err)
+
+			if opts.Batch {
+				fmt.Printf("%s\n", asJSON(enrol))
+				break
+			}
+
+			fmt.Printf("Scan this QR code with your authenticator app:\n\n")
+			fmt.Printf("%s\n", qrcode(enrol.URI))
+			fmt.Printf("or, add it by hand, using the secret @C{%s}\n\n", enrol.Secret)
+			opts.EnableMFA.Code = prompt("@Y{Code from your authenticator:} ")
+		}
+
+		codes, err := c.ConfirmMFA(opts.EnableMFA.Code)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(codes))
+			break
+		}
+		fmt.Printf("@G{multi-factor authentication enabled.}\n\n")
+		printRecoveryCodes(codes)
+
+	/* }}} */
+	case "disable-mfa": /* {{{ */
+		if opts.DisableMFA.Code == "" {
+			if opts.Batch {
+				bail(fmt.Errorf("Unable to prompt for your multi-factor code under `--batch` mode"))
+			}
+			opts.DisableMFA.Code = prompt("@Y{Code from your authenticator (or a recovery code):} ")
+		}
+
+		r, err := c.DisableMFA(opts.DisableMFA.Code)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(r))
+			break
+		}
+		fmt.Printf("%s\n", r.OK)
+
+	/* }}} */
+	case "mfa-recovery-codes": /* {{{ */
+		if opts.MFARecoveryCodes.Code == "" {
+			if opts.Batch {
+				bail(fmt.Errorf("Unable to prompt for your multi-factor code under `--batch` mode"))
+			}
+			opts.MFARecoveryCodes.Code = prompt("@Y{Code from your authenticator (or a recovery code):} ")
+		}
+
+		codes, err := c.RegenerateRecoveryCodes(opts.MFARecoveryCodes.Code)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(codes))
+			break
+		}
+		printRecoveryCodes(codes)
+
 	/* }}} */
 
 	case "auth-tokens": /* {{{ */
@@ -1386,9 +1799,17 @@ tenants:
 			break
 		}
 
-		tbl := table.NewTable("Name", "Created at", "Last seen")
+		tenants := make(map[string]string)
+		if l, err := c.GetMyTenants(); err == nil {
+			for _, tenant := range l {
+				tenants[tenant.UUID] = tenant.Name
+			}
+		}
+
+		tbl := table.NewTable("Name", "Created at", "Last seen", "Expires", "Scope")
 		for _, token := range tokens {
-			tbl.Row(token, token.Name, strftime(token.CreatedAt), strftimenil(token.LastSeen, "(never)"))
+			tbl.Row(token, token.Name, strftime(token.CreatedAt), strftimenil(token.LastSeen, "(never)"),
+				strftimenil(token.ExpiresAt, "(never)"), tokenScope(token, tenants))
 		}
 		tbl.Output(os.Stdout)
 
@@ -1398,7 +1819,22 @@ tenants:
 			fail(2, "Usage: shield %s TOKEN-NAME\n", command)
 		}
 
-		t, err := c.CreateAuthToken(&shield.AuthToken{Name: args[0]})
+		t := &shield.AuthToken{
+			Name:  args[0],
+			Role:  opts.CreateAuthToken.Role,
+			Ops:   opts.CreateAuthToken.Ops,
+			Allow: opts.CreateAuthToken.Allow,
+		}
+		if opts.CreateAuthToken.ForTenant != "" {
+			tenant, err := c.FindMyTenant(opts.CreateAuthToken.ForTenant, true)
+			bail(err)
+			t.Tenant = tenant.UUID
+		}
+		if opts.CreateAuthToken.Expires != "" {
+			t.ExpiresAt = parseAsOf(opts.CreateAuthToken.Expires)
+		}
+
+		t, err := c.CreateAuthToken(t)
 		bail(err)
 
 		if opts.JSON {
@@ -1473,6 +1909,9 @@ tenants:
 
 		tenant, err := c.FindTenant(args[0], !opts.Exact)
 		bail(err)
+		/* only the full tenant record has members and quota usage */
+		tenant, err = c.GetTenant(tenant.UUID)
+		bail(err)
 
 		if opts.JSON {
 			fmt.Printf("%s\n", asJSON(tenant))
@@ -1482,8 +1921,31 @@ tenants:
 		r := tui.NewReport()
 		r.Add("UUID", tenant.UUID)
 		r.Add("Name", tenant.Name)
+		r.Add("Scheduler Share", fmt.Sprintf("%d", tenant.Share))
+		r.Add("Spread Window", spreadWindow(tenant.Spread))
+		r.Add("Approvals", approvalPolicy(tenant))
+		r.Add("Quotas", quotaPolicy(tenant))
 		r.Output(os.Stdout)
 
+		if len(tenant.Quotas) > 0 {
+			fmt.Printf("\n")
+			t := table.NewTable("Resource", "Used", "Quota", "Status")
+			for _, q := range tenant.Quotas {
+				used, limit := fmt.Sprintf("%d", q.Used), fmt.Sprintf("%d", q.Limit)
+				if q.Resource == "storage" {
+					used, limit = formatBytes(q.Used), formatBytes(q.Limit)
+				}
+				status := "@G{ok}"
+				if q.Limit == 0 {
+					limit = "(unlimited)"
+				} else if q.Reached {
+					status = "@R{reached}"
+				}
+				t.Row(q, q.Resource, used, limit, status)
+			}
+			t.Output(os.Stdout)
+		}
+
 		if opts.ShowTenant.Members {
 			fmt.Printf("\n")
 			t := table.NewTable("UUID", "Name", "Account", "Role")
@@ -1501,8 +1963,26 @@ tenants:
 			}
 		}
 
+		spread, err := parseRuntime(opts.CreateTenant.Spread)
+		bail(err)
+		timeout, err := parseRuntime(opts.CreateTenant.ApprovalTimeout)
+		bail(err)
+		storage, err := parseBytes(opts.CreateTenant.StorageQuota)
+		bail(err)
+
 		t, err := c.CreateTenant(&shield.Tenant{
-			Name: opts.CreateTenant.Name,
+			Name:            opts.CreateTenant.Name,
+			Share:           opts.CreateTenant.Share,
+			Spread:          spread,
+			RequireApproval: opts.CreateTenant.RequireApproval,
+			ApprovalTimeout: timeout,
+
+			QuotaStorage:  storage,
+			QuotaArchives: opts.CreateTenant.ArchiveQuota,
+			QuotaJobs:     opts.CreateTenant.JobQuota,
+			QuotaTargets:  opts.CreateTenant.TargetQuota,
+			QuotaTasks:    opts.CreateTenant.TaskQuota,
+			EnforceQuotas: opts.CreateTenant.EnforceQuotas,
 		})
 		bail(err)
 
@@ -1514,6 +1994,10 @@ tenants:
 		r := tui.NewReport()
 		r.Add("UUID", t.UUID)
 		r.Add("Name", t.Name)
+		r.Add("Scheduler Share", fmt.Sprintf("%d", t.Share))
+		r.Add("Spread Window", spreadWindow(t.Spread))
+		r.Add("Approvals", approvalPolicy(t))
+		r.Add("Quotas", quotaPolicy(t))
 		r.Output(os.Stdout)
 
 	/* }}} */
@@ -1527,6 +2011,56 @@ tenants:
 		if opts.UpdateTenant.Name != "" {
 			t.Name = opts.UpdateTenant.Name
 		}
+		if opts.UpdateTenant.Share != 0 {
+			t.Share = opts.UpdateTenant.Share
+		}
+		if opts.UpdateTenant.NoSpread {
+			t.Spread = 0
+		}
+		if opts.UpdateTenant.Spread != "" {
+			t.Spread, err = parseRuntime(opts.UpdateTenant.Spread)
+			bail(err)
+		}
+		required(!(opts.UpdateTenant.RequireApproval && opts.UpdateTenant.NoRequireApproval),
+			"The --require-approval and --no-require-approval options are mutually exclusive.")
+		if opts.UpdateTenant.RequireApproval {
+			t.RequireApproval = true
+		}
+		if opts.UpdateTenant.NoRequireApproval {
+			t.RequireApproval = false
+		}
+		if opts.UpdateTenant.ApprovalTimeout != "" {
+			t.ApprovalTimeout, err = parseRuntime(opts.UpdateTenant.ApprovalTimeout)
+			bail(err)
+		}
+		if opts.UpdateTenant.StorageQuota != "" {
+			t.QuotaStorage, err = parseBytes(opts.UpdateTenant.StorageQuota)
+			bail(err)
+		}
+		if opts.UpdateTenant.ArchiveQuota != "" {
+			t.QuotaArchives, err = parseQuota(opts.UpdateTenant.ArchiveQuota)
+			bail(err)
+		}
+		if opts.UpdateTenant.JobQuota != "" {
+			t.QuotaJobs, err = parseQuota(opts.UpdateTenant.JobQuota)
+			bail(err)
+		}
+		if opts.UpdateTenant.TargetQuota != "" {
+			t.QuotaTargets, err = parseQuota(opts.UpdateTenant.TargetQuota)
+			bail(err)
+		}
+		if opts.UpdateTenant.TaskQuota != "" {
+			t.QuotaTasks, err = parseQuota(opts.UpdateTenant.TaskQuota)
+			bail(err)
+		}
+		required(!(opts.UpdateTenant.EnforceQuotas && opts.UpdateTenant.NoEnforceQuotas),
+			"The --enforce-quotas and --no-enforce-quotas options are mutually exclusive.")
+		if opts.UpdateTenant.EnforceQuotas {
+			t.EnforceQuotas = true
+		}
+		if opts.UpdateTenant.NoEnforceQuotas {
+			t.EnforceQuotas = false
+		}
 
 		_, err = c.UpdateTenant(t)
 		bail(err)
@@ -1539,6 +2073,10 @@ tenants:
 		r := tui.NewReport()
 		r.Add("UUID", t.UUID)
 		r.Add("Name", t.Name)
+		r.Add("Scheduler Share", fmt.Sprintf("%d", t.Share))
+		r.Add("Spread Window", spreadWindow(t.Spread))
+		r.Add("Approvals", approvalPolicy(t))
+		r.Add("Quotas", quotaPolicy(t))
 		r.Output(os.Stdout)
 
 	/* }}} */
@@ -1574,12 +2112,9 @@ tenants:
 		tenant, err := c.FindTenant(opts.Tenant, true)
 		bail(err)
 
-		switch opts.Invite.Role {
-		case "":
+		/* custom roles are checked by the SHIELD Core */
+		if opts.Invite.Role == "" {
 			opts.Invite.Role = "operator"
-		case "operator", "engineer", "admin":
-		default:
-			bail(fmt.Errorf("Invalid --role value '%s' (must be one of operator, engineer, or admin)", opts.Invite.Role))
 		}
 
 		users := make([]*shield.User, len(args))
@@ -1626,141 +2161,342 @@ tenants:
 
 		/* }}} */
 
-	case "targets": /* {{{ */
+	case "roles": /* {{{ */
 		required(opts.Tenant != "", "Missing required --tenant option.")
-		required(!(opts.Targets.Used && opts.Targets.Unused),
-			"The --used and --unused options are mutually exclusive.")
-		required(len(args) <= 1, "Too many arguments.")
-
-		filter := &shield.TargetFilter{
-			Plugin: opts.Targets.WithPlugin,
-			Fuzzy:  !opts.Exact,
-		}
-		if len(args) == 1 {
-			filter.Name = args[0]
-			filter.UUID = args[0]
-		}
-		if opts.Targets.Used || opts.Targets.Unused {
-			x := opts.Targets.Used
-			filter.Used = &x
-		}
+		required(len(args) == 0, "Too many arguments.")
 
 		tenant, err := c.FindMyTenant(opts.Tenant, true)
 		bail(err)
 
-		targets, err := c.ListTargets(tenant, filter)
+		roles, err := c.ListRoles(tenant)
 		bail(err)
 
 		if opts.JSON {
-			fmt.Printf("%s\n", asJSON(targets))
+			fmt.Printf("%s\n", asJSON(roles))
 			break
 		}
 
-		tbl := table.NewTable("UUID", "Name", "Summary", "Plugin", "SHIELD Agent")
-		for _, target := range targets {
-			tbl.Row(target, uuid8full(target.UUID, opts.Long), target.Name, wrap(target.Summary, 35), target.Plugin, target.Agent)
+		tbl := table.NewTable("Name", "Summary", "Permissions")
+		for _, role := range roles {
+			name := role.Name
+			if role.Builtin {
+				name = fmt.Sprintf("%s @C{(built-in)}", role.Name)
+			}
+			tbl.Row(role, name, wrap(role.Summary, 35), strings.Join(role.Permissions, "\n"))
 		}
 		tbl.Output(os.Stdout)
 
 	/* }}} */
-	case "target": /* {{{ */
+	case "create-role": /* {{{ */
+		required(opts.Tenant != "", "Missing required --tenant option.")
 		if len(args) != 1 {
-			fail(2, "Usage: shield %s NAME-or-UUID\n", command)
+			fail(2, "Usage: shield %s [OPTIONS] -p PERMISSION [-p ...] NAME\n", command)
 		}
 
-		required(opts.Tenant != "", "Missing required --tenant option.")
 		tenant, err := c.FindMyTenant(opts.Tenant, true)
 		bail(err)
 
-		t, err := c.FindTarget(tenant, args[0], !opts.Exact)
+		role, err := c.CreateRole(tenant, &shield.Role{
+			Name:        args[0],
+			Summary:     opts.CreateRole.Summary,
+			Permissions: opts.CreateRole.Permissions,
+		})
 		bail(err)
 
 		if opts.JSON {
-			fmt.Printf("%s\n", asJSON(t))
+			fmt.Printf("%s\n", asJSON(role))
 			break
 		}
 
 		r := tui.NewReport()
-		r.Add("UUID", t.UUID)
-		r.Add("Name", t.Name)
-		r.Add("Summary", wrap(t.Summary, 35))
-		r.Add("Compression", t.Compression)
-		r.Add("SHIELD Agent", t.Agent)
-		r.Add("Backup Plugin", t.Plugin)
-		r.Break()
-		r.Add("Configuration", asJSON(t.Config))
+		r.Add("Name", role.Name)
+		r.Add("Summary", role.Summary)
+		r.Add("Permissions", strings.Join(role.Permissions, "\n"))
 		r.Output(os.Stdout)
 
 	/* }}} */
-	case "create-target": /* {{{ */
+	case "update-role": /* {{{ */
 		required(opts.Tenant != "", "Missing required --tenant option.")
+		if len(args) != 1 {
+			fail(2, "Usage: shield %s [OPTIONS] NAME\n", command)
+		}
+
 		tenant, err := c.FindMyTenant(opts.Tenant, true)
 		bail(err)
 
-		conf, err := dataConfig(opts.CreateTarget.Data)
+		role, err := c.FindRole(tenant, args[0])
 		bail(err)
 
-		if !opts.Batch {
-			if opts.CreateTarget.Name == "" {
-				opts.CreateTarget.Name = prompt("@C{Target Name}: ")
-			}
-			if opts.CreateTarget.Summary == "" {
-				opts.CreateTarget.Summary = prompt("@C{Description}: ")
-			}
-			if opts.CreateTarget.Agent == "" {
-				opts.CreateTarget.Agent = prompt("@C{SHIELD Agent (IP:port)}: ")
-			}
-			if opts.CreateTarget.Plugin == "" {
-				opts.CreateTarget.Plugin = prompt("@C{Backup Plugin}: ")
-			}
-		}
-
-		if opts.CreateTarget.Compression == "" {
-			opts.CreateTarget.Compression = "bzip2"
+		if opts.UpdateRole.Summary != "" {
+			role.Summary = opts.UpdateRole.Summary
 		}
+		role.Permissions = updateRolePermissions(role.Permissions, opts.UpdateRole.Grant, opts.UpdateRole.Revoke)
 
-		t, err := c.CreateTarget(tenant, &shield.Target{
-			Name:        opts.CreateTarget.Name,
-			Summary:     opts.CreateTarget.Summary,
-			Agent:       opts.CreateTarget.Agent,
-			Plugin:      opts.CreateTarget.Plugin,
-			Compression: opts.CreateTarget.Compression,
-			Config:      conf,
-		})
+		role, err = c.UpdateRole(tenant, role)
 		bail(err)
 
 		if opts.JSON {
-			fmt.Printf("%s\n", asJSON(t))
+			fmt.Printf("%s\n", asJSON(role))
 			break
 		}
 
 		r := tui.NewReport()
-		r.Add("UUID", t.UUID)
-		r.Add("Name", t.Name)
-		r.Add("Summary", t.Summary)
-		r.Add("Compression", t.Compression)
-		r.Add("SHIELD Agent", t.Agent)
-		r.Add("Backup Plugin", t.Plugin)
+		r.Add("Name", role.Name)
+		r.Add("Summary", role.Summary)
+		r.Add("Permissions", strings.Join(role.Permissions, "\n"))
 		r.Output(os.Stdout)
 
 	/* }}} */
-	case "update-target": /* {{{ */
+	case "delete-role": /* {{{ */
+		required(opts.Tenant != "", "Missing required --tenant option.")
 		if len(args) != 1 {
-			fail(2, "Usage: shield %s -t TENANT [OPTIONS] NAME-or-UUID\n", command)
+			fail(2, "Usage: shield %s [OPTIONS] NAME\n", command)
 		}
-		required(opts.Tenant != "", "Missing required --tenant option.")
+
 		tenant, err := c.FindMyTenant(opts.Tenant, true)
 		bail(err)
 
-		t, err := c.FindTarget(tenant, args[0], true)
+		role, err := c.FindRole(tenant, args[0])
 		bail(err)
 
-		conf, err := dataConfig(opts.UpdateTarget.Data)
+		if !confirm(opts.Yes, "Delete role @Y{%s} from tenant @Y{%s}?", role.Name, tenant.Name) {
+			break
+		}
+		r, err := c.DeleteRole(tenant, role)
 		bail(err)
 
-		if opts.UpdateTarget.Name != "" {
-			t.Name = opts.UpdateTarget.Name
-		}
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(r))
+			break
+		}
+		fmt.Printf("%s\n", r.OK)
+
+	/* }}} */
+	case "approvals": /* {{{ */
+		required(opts.Tenant != "", "Missing required --tenant option.")
+		required(len(args) == 0, "Too many arguments.")
+
+		tenant, err := c.FindMyTenant(opts.Tenant, true)
+		bail(err)
+
+		filter := &shield.ApprovalFilter{Status: "pending"}
+		if opts.Approvals.All {
+			filter.Status = ""
+		}
+		if opts.Approvals.Limit > 0 {
+			filter.Limit = &opts.Approvals.Limit
+		}
+
+		approvals, err := c.ListApprovals(tenant, filter)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(approvals))
+			break
+		}
+
+		tbl := table.NewTable("UUID", "Requested", "By", "Summary", "Status", "Expires")
+		for _, a := range approvals {
+			tbl.Row(a, uuid8full(a.UUID, opts.Long), strftime(a.RequestedAt), a.RequestedBy,
+				wrap(a.Summary, 45), a.Status, strftime(a.ExpiresAt))
+		}
+		tbl.Output(os.Stdout)
+
+	/* }}} */
+	case "approval": /* {{{ */
+		required(opts.Tenant != "", "Missing required --tenant option.")
+		if len(args) != 1 {
+			fail(2, "Usage: shield %s [OPTIONS] UUID\n", command)
+		}
+
+		tenant, err := c.FindMyTenant(opts.Tenant, true)
+		bail(err)
+
+		a, err := c.FindApproval(tenant, args[0])
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(a))
+			break
+		}
+
+		showApproval(a)
+
+	/* }}} */
+	case "approve", "reject": /* {{{ */
+		required(opts.Tenant != "", "Missing required --tenant option.")
+		if len(args) != 1 {
+			fail(2, "Usage: shield %s [OPTIONS] UUID\n", command)
+		}
+
+		tenant, err := c.FindMyTenant(opts.Tenant, true)
+		bail(err)
+
+		a, err := c.FindApproval(tenant, args[0])
+		bail(err)
+
+		if !opts.JSON {
+			showApproval(a)
+			fmt.Printf("\n")
+		}
+		if !confirm(opts.Yes, "%s this request?", strings.Title(command)) {
+			break
+		}
+
+		if command == "approve" {
+			a, err = c.Approve(tenant, a)
+		} else {
+			a, err = c.Reject(tenant, a)
+		}
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(a))
+			break
+		}
+		if a.TaskUUID != "" {
+			fmt.Printf("Request approved; task @C{%s}\n", a.TaskUUID)
+		} else {
+			fmt.Printf("Request %s\n", a.Status)
+		}
+
+	/* }}} */
+
+	case "targets": /* {{{ */
+		required(opts.Tenant != "", "Missing required --tenant option.")
+		required(!(opts.Targets.Used && opts.Targets.Unused),
+			"The --used and --unused options are mutually exclusive.")
+		required(len(args) <= 1, "Too many arguments.")
+
+		filter := &shield.TargetFilter{
+			Plugin: opts.Targets.WithPlugin,
+			Fuzzy:  !opts.Exact,
+		}
+		if len(args) == 1 {
+			filter.Name = args[0]
+			filter.UUID = args[0]
+		}
+		if opts.Targets.Used || opts.Targets.Unused {
+			x := opts.Targets.Used
+			filter.Used = &x
+		}
+
+		tenant, err := c.FindMyTenant(opts.Tenant, true)
+		bail(err)
+
+		targets, err := c.ListTargets(tenant, filter)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(targets))
+			break
+		}
+
+		tbl := table.NewTable("UUID", "Name", "Summary", "Plugin", "SHIELD Agent")
+		for _, target := range targets {
+			tbl.Row(target, uuid8full(target.UUID, opts.Long), target.Name, wrap(target.Summary, 35), target.Plugin, target.Agent)
+		}
+		tbl.Output(os.Stdout)
+
+	/* }}} */
+	case "target": /* {{{ */
+		if len(args) != 1 {
+			fail(2, "Usage: shield %s NAME-or-UUID\n", command)
+		}
+
+		required(opts.Tenant != "", "Missing required --tenant option.")
+		tenant, err := c.FindMyTenant(opts.Tenant, true)
+		bail(err)
+
+		t, err := c.FindTarget(tenant, args[0], !opts.Exact)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(t))
+			break
+		}
+
+		r := tui.NewReport()
+		r.Add("UUID", t.UUID)
+		r.Add("Name", t.Name)
+		r.Add("Summary", wrap(t.Summary, 35))
+		r.Add("Compression", t.Compression)
+		r.Add("SHIELD Agent", t.Agent)
+		r.Add("Backup Plugin", t.Plugin)
+		r.Break()
+		r.Add("Configuration", asJSON(t.Config))
+		r.Output(os.Stdout)
+
+	/* }}} */
+	case "create-target": /* {{{ */
+		required(opts.Tenant != "", "Missing required --tenant option.")
+		tenant, err := c.FindMyTenant(opts.Tenant, true)
+		bail(err)
+
+		conf, err := dataConfig(opts.CreateTarget.Data)
+		bail(err)
+
+		if !opts.Batch {
+			if opts.CreateTarget.Name == "" {
+				opts.CreateTarget.Name = prompt("@C{Target Name}: ")
+			}
+			if opts.CreateTarget.Summary == "" {
+				opts.CreateTarget.Summary = prompt("@C{Description}: ")
+			}
+			if opts.CreateTarget.Agent == "" {
+				opts.CreateTarget.Agent = prompt("@C{SHIELD Agent (IP:port)}: ")
+			}
+			if opts.CreateTarget.Plugin == "" {
+				opts.CreateTarget.Plugin = prompt("@C{Backup Plugin}: ")
+			}
+		}
+
+		if opts.CreateTarget.Compression == "" {
+			opts.CreateTarget.Compression = "bzip2"
+		}
+
+		t, err := c.CreateTarget(tenant, &shield.Target{
+			Name:        opts.CreateTarget.Name,
+			Summary:     opts.CreateTarget.Summary,
+			Agent:       opts.CreateTarget.Agent,
+			Plugin:      opts.CreateTarget.Plugin,
+			Compression: opts.CreateTarget.Compression,
+			Config:      conf,
+		})
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(t))
+			break
+		}
+
+		r := tui.NewReport()
+		r.Add("UUID", t.UUID)
+		r.Add("Name", t.Name)
+		r.Add("Summary", t.Summary)
+		r.Add("Compression", t.Compression)
+		r.Add("SHIELD Agent", t.Agent)
+		r.Add("Backup Plugin", t.Plugin)
+		r.Output(os.Stdout)
+
+	/* }}} */
+	case "update-target": /* {{{ */
+		if len(args) != 1 {
+			fail(2, "Usage: shield %s -t TENANT [OPTIONS] NAME-or-UUID\n", command)
+		}
+		required(opts.Tenant != "", "Missing required --tenant option.")
+		tenant, err := c.FindMyTenant(opts.Tenant, true)
+		bail(err)
+
+		t, err := c.FindTarget(tenant, args[0], true)
+		bail(err)
+
+		conf, err := dataConfig(opts.UpdateTarget.Data)
+		bail(err)
+
+		if opts.UpdateTarget.Name != "" {
+			t.Name = opts.UpdateTarget.Name
+		}
 		if opts.UpdateTarget.Summary != "" {
 			t.Summary = opts.UpdateTarget.Summary
 		}
@@ -1820,6 +2556,9 @@ tenants:
 			break
 		}
 		r, err := c.DeleteTarget(tenant, t)
+		if awaitingApproval(err, opts.JSON) {
+			break
+		}
 		bail(err)
 
 		if opts.JSON {
@@ -2370,6 +3109,13 @@ tenants:
 		r.Add("Schedule", job.Schedule)
 		r.Add("Keep", fmt.Sprintf("%d days (%d archives)", job.KeepDays, job.KeepN))
 		r.Add("Retries", fmt.Sprintf("%d tries", job.Retries))
+		r.Add("Backup Window", job.Window())
+		r.Add("Jitter", job.Spread())
+		if job.MaxRuntime > 0 {
+			r.Add("Max Run Time", (time.Duration(job.MaxRuntime) * time.Minute).String())
+		} else {
+			r.Add("Max Run Time", "(unlimited)")
+		}
 		r.Break()
 
 		r.Add("Data System", job.Target.Name)
@@ -2458,6 +3204,11 @@ tenants:
 			}
 		}
 
+		maxRuntime, err := parseRuntime(opts.CreateJob.MaxRuntime)
+		bail(err)
+		jitter, err := parseRuntime(opts.CreateJob.Jitter)
+		bail(err)
+
 		job, err := c.CreateJob(tenant, &shield.Job{
 			Name:       opts.CreateJob.Name,
 			Summary:    opts.CreateJob.Summary,
@@ -2468,6 +3219,14 @@ tenants:
 			Retries:    opts.CreateJob.Retries,
 			Paused:     opts.CreateJob.Paused,
 			FixedKey:   opts.CreateJob.FixedKey,
+
+			MaxRuntime:  maxRuntime,
+			WindowStart: opts.CreateJob.WindowStart,
+			WindowEnd:   opts.CreateJob.WindowEnd,
+			FinishBy:    opts.CreateJob.FinishBy,
+
+			Jitter:       jitter,
+			HashedJitter: opts.CreateJob.HashedJitter,
 		})
 		bail(err)
 
@@ -2532,6 +3291,35 @@ tenants:
 			job.FixedKey = false
 		}
 
+		if opts.UpdateJob.MaxRuntime != "" {
+			job.MaxRuntime, err = parseRuntime(opts.UpdateJob.MaxRuntime)
+			bail(err)
+		}
+		if opts.UpdateJob.NoWindow {
+			job.WindowStart = ""
+			job.WindowEnd = ""
+			job.FinishBy = ""
+		}
+		if opts.UpdateJob.WindowStart != "" {
+			job.WindowStart = opts.UpdateJob.WindowStart
+		}
+		if opts.UpdateJob.WindowEnd != "" {
+			job.WindowEnd = opts.UpdateJob.WindowEnd
+		}
+		if opts.UpdateJob.FinishBy != "" {
+			job.FinishBy = opts.UpdateJob.FinishBy
+		}
+		if opts.UpdateJob.Jitter != "" {
+			job.Jitter, err = parseRuntime(opts.UpdateJob.Jitter)
+			bail(err)
+		}
+		if opts.UpdateJob.HashedJitter {
+			job.HashedJitter = true
+		}
+		if opts.UpdateJob.NoHashedJitter {
+			job.HashedJitter = false
+		}
+
 		_, err = c.UpdateJob(tenant, job)
 		bail(err)
 
@@ -2624,6 +3412,55 @@ tenants:
 		}
 		fmt.Printf("%s\n", r.OK)
 
+	/* }}} */
+	case "simulate-retention": /* {{{ */
+		if len(args) != 1 {
+			fail(2, "Usage: shield %s -t TENANT --retain DAYS NAME-or-UUID\n", command)
+		}
+
+		required(opts.Tenant != "", "Missing required --tenant option.")
+
+		tenant, err := c.FindMyTenant(opts.Tenant, true)
+		bail(err)
+
+		job, err := c.FindJob(tenant, args[0], !opts.Exact)
+		bail(err)
+
+		sim, err := c.SimulateRetention(tenant, job, opts.SimulateRetention.Retain)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(sim))
+			break
+		}
+
+		r := tui.NewReport()
+		r.Add("Job", job.Name)
+		r.Add("Current Retention", fmt.Sprintf("%d days", job.KeepDays))
+		r.Add("Proposed Retention", fmt.Sprintf("%d days (~%d archives)", sim.KeepDays, sim.KeepN))
+		r.Break()
+		r.Add("Kept", fmt.Sprintf("%d archives (%s)", len(sim.Keep), formatBytes(sim.KeepBytes)))
+		r.Add("Purged", fmt.Sprintf("%d archives (%s)", len(sim.Purge), formatBytes(sim.PurgeBytes)))
+		r.Output(os.Stdout)
+
+		if len(sim.Purge) > 0 {
+			fmt.Printf("\n@R{Archives that would be purged:}\n")
+			tbl := table.NewTable("UUID", "Taken At", "Size")
+			for _, archive := range sim.Purge {
+				tbl.Row(archive, uuid8full(archive.UUID, opts.Long), strftime(archive.TakenAt), formatBytes(archive.Size))
+			}
+			tbl.Output(os.Stdout)
+		}
+
+		if opts.SimulateRetention.All && len(sim.Keep) > 0 {
+			fmt.Printf("\n@G{Archives that would be kept:}\n")
+			tbl := table.NewTable("UUID", "Taken At", "Expires", "Size")
+			for _, archive := range sim.Keep {
+				tbl.Row(archive, uuid8full(archive.UUID, opts.Long), strftime(archive.TakenAt), strftime(archive.ExpiresAt), formatBytes(archive.Size))
+			}
+			tbl.Output(os.Stdout)
+		}
+
 	/* }}} */
 	case "run-job": /* {{{ */
 		if len(args) != 1 {
@@ -2649,56 +3486,892 @@ tenants:
 			break
 		}
 		fmt.Printf("%s\n", r.OK)
+		if r.Warning != "" {
+			fmt.Printf("@Y{WARNING: %s}\n", r.Warning)
+		}
 
 	/* }}} */
 
-	case "archives": /* {{{ */
-		required(opts.Tenant != "", "Missing required --tenant option.")
-		required(len(args) <= 1, "Too many arguments.")
+	case "schedule": /* {{{ */
+		required(len(args) == 0, "Too many arguments.")
+
+		from := time.Now().Unix()
+		if opts.Schedule.From != "" {
+			from = strptime(opts.Schedule.From)
+		}
+		until := from + 86400
+		if opts.Schedule.Until != "" {
+			until = strptime(opts.Schedule.Until)
+		} else if opts.Schedule.For != "" {
+			n, err := parseRuntime(opts.Schedule.For)
+			bail(err)
+			until = from + int64(n)*60
+		}
+
+		var runs []*shield.ScheduledRun
+		if opts.Schedule.Global {
+			runs, err = c.GlobalSchedule(from, until)
+			bail(err)
+
+		} else {
+			required(opts.Tenant != "", "Missing required --tenant option.")
+			tenant, err := c.FindMyTenant(opts.Tenant, true)
+			bail(err)
+
+			runs, err = c.Schedule(tenant, from, until)
+			bail(err)
+		}
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(runs))
+			break
+		}
+
+		tbl := table.NewTable("Starts", "Job", "Target", "Store", "Agent", "Expected", "Notes")
+		for _, run := range runs {
+			tbl.Row(run, strftime(run.At), run.Job.Name, run.Target.Name, run.Store.Name, run.Agent, expectedRuntime(run.Duration), runNotes(run))
+		}
+		tbl.Output(os.Stdout)
+
+		for _, by := range []string{"agent", "store"} {
+			overlaps := overlappingRuns(runs, by)
+			if len(overlaps) == 0 {
+				continue
+			}
+
+			fmt.Printf("\n@Y{Overlapping runs, by %s:}\n", by)
+			tbl := table.NewTable(strings.Title(by), "From", "Until", "Jobs")
+			for _, o := range overlaps {
+				jobs := []string{}
+				for _, run := range o.Runs {
+					jobs = append(jobs, run.Job.Name)
+				}
+				tbl.Row(o, o.On, strftime(o.From), strftime(o.Until), strings.Join(jobs, ", "))
+			}
+			tbl.Output(os.Stdout)
+		}
+
+	/* }}} */
+	case "blackouts": /* {{{ */
+		required(opts.Tenant != "", "Missing required --tenant option.")
+		required(len(args) <= 1, "Too many arguments.")
+
+		tenant, err := c.FindMyTenant(opts.Tenant, true)
+		bail(err)
+
+		filter := &shield.BlackoutFilter{
+			Fuzzy: !opts.Exact,
+		}
+		if opts.Blackouts.Target != "" {
+			target, err := c.FindTarget(tenant, opts.Blackouts.Target, !opts.Exact)
+			bail(err)
+			filter.Target = target.UUID
+		}
+		if len(args) == 1 {
+			filter.Name = args[0]
+			filter.UUID = args[0]
+		}
+
+		blackouts, err := c.ListBlackouts(tenant, filter)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(blackouts))
+			break
+		}
+
+		tbl := table.NewTable("UUID", "Name", "Summary", "Target", "When", "Catch Up?")
+		for _, blackout := range blackouts {
+			target := "(all)"
+			if blackout.TargetUUID != "" {
+				target = uuid8full(blackout.TargetUUID, opts.Long)
+				if t, err := c.GetTarget(tenant, blackout.TargetUUID); err == nil && t != nil {
+					target = t.Name
+				}
+			}
+			tbl.Row(blackout, uuid8full(blackout.UUID, opts.Long), blackout.Name, wrap(blackout.Summary, 35), target, blackoutWhen(blackout), blackout.CatchUp)
+		}
+		tbl.Output(os.Stdout)
+
+	/* }}} */
+	case "blackout": /* {{{ */
+		if len(args) != 1 {
+			fail(2, "Usage: shield %s NAME-or-UUID\n", command)
+		}
+
+		required(opts.Tenant != "", "Missing required --tenant option.")
+		tenant, err := c.FindMyTenant(opts.Tenant, true)
+		bail(err)
+
+		blackout, err := c.FindBlackout(tenant, args[0], !opts.Exact)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(blackout))
+			break
+		}
+
+		target := "(all)"
+		if blackout.TargetUUID != "" {
+			t, err := c.GetTarget(tenant, blackout.TargetUUID)
+			bail(err)
+			target = t.Name
+		}
+
+		r := tui.NewReport()
+		r.Add("UUID", blackout.UUID)
+		r.Add("Name", blackout.Name)
+		r.Add("Summary", blackout.Summary)
+		r.Break()
+		r.Add("Data System", target)
+		r.Add("When", blackoutWhen(blackout))
+		r.Add("Catch Up?", strconv.FormatBool(blackout.CatchUp))
+		r.Output(os.Stdout)
+
+	/* }}} */
+	case "create-blackout": /* {{{ */
+		required(opts.Tenant != "", "Missing required --tenant option.")
+
+		tenant, err := c.FindMyTenant(opts.Tenant, true)
+		bail(err)
+
+		if !opts.Batch {
+			if opts.CreateBlackout.Name == "" {
+				opts.CreateBlackout.Name = prompt("@C{Blackout Name}: ")
+			}
+			if opts.CreateBlackout.Summary == "" {
+				opts.CreateBlackout.Summary = prompt("@C{Description}: ")
+			}
+			if opts.CreateBlackout.Schedule == "" && opts.CreateBlackout.From == "" {
+				opts.CreateBlackout.Schedule = prompt("@C{Schedule (leave blank for a one-off blackout)}: ")
+			}
+			if opts.CreateBlackout.Schedule != "" && opts.CreateBlackout.Duration == "" {
+				opts.CreateBlackout.Duration = prompt("@C{Duration}: ")
+			}
+			if opts.CreateBlackout.Schedule == "" && opts.CreateBlackout.From == "" {
+				opts.CreateBlackout.From = prompt("@C{From}: ")
+			}
+			if opts.CreateBlackout.Schedule == "" && opts.CreateBlackout.Until == "" {
+				opts.CreateBlackout.Until = prompt("@C{Until}: ")
+			}
+		}
+
+		blackout := &shield.Blackout{
+			Name:     opts.CreateBlackout.Name,
+			Summary:  opts.CreateBlackout.Summary,
+			Schedule: opts.CreateBlackout.Schedule,
+			CatchUp:  opts.CreateBlackout.CatchUp,
+		}
+		if opts.CreateBlackout.Target != "" {
+			target, err := c.FindTarget(tenant, opts.CreateBlackout.Target, !opts.Exact)
+			bail(err)
+			blackout.TargetUUID = target.UUID
+		}
+		blackout.Duration, err = parseRuntime(opts.CreateBlackout.Duration)
+		bail(err)
+		if opts.CreateBlackout.From != "" {
+			blackout.StartsAt = strptime(opts.CreateBlackout.From)
+		}
+		if opts.CreateBlackout.Until != "" {
+			blackout.EndsAt = strptime(opts.CreateBlackout.Until)
+		}
+
+		blackout, err = c.CreateBlackout(tenant, blackout)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(blackout))
+			break
+		}
+
+		r := tui.NewReport()
+		r.Add("UUID", blackout.UUID)
+		r.Add("Name", blackout.Name)
+		r.Add("Summary", blackout.Summary)
+		r.Add("When", blackoutWhen(blackout))
+		r.Output(os.Stdout)
+
+	/* }}} */
+	case "update-blackout": /* {{{ */
+		if len(args) != 1 {
+			fail(2, "Usage: shield %s -t TENANT [OPTIONS] NAME-or-UUID\n", command)
+		}
+		required(opts.Tenant != "", "Missing required --tenant option.")
+		required(!(opts.UpdateBlackout.Target != "" && opts.UpdateBlackout.NoTarget),
+			"The --target and --no-target options are mutually exclusive.")
+		required(!(opts.UpdateBlackout.CatchUp && opts.UpdateBlackout.NoCatchUp),
+			"The --catch-up and --no-catch-up options are mutually exclusive.")
+
+		tenant, err := c.FindMyTenant(opts.Tenant, true)
+		bail(err)
+
+		blackout, err := c.FindBlackout(tenant, args[0], !opts.Exact)
+		bail(err)
+
+		if opts.UpdateBlackout.Name != "" {
+			blackout.Name = opts.UpdateBlackout.Name
+		}
+		if opts.UpdateBlackout.Summary != "" {
+			blackout.Summary = opts.UpdateBlackout.Summary
+		}
+		if opts.UpdateBlackout.NoTarget {
+			blackout.TargetUUID = ""
+		}
+		if opts.UpdateBlackout.Target != "" {
+			target, err := c.FindTarget(tenant, opts.UpdateBlackout.Target, !opts.Exact)
+			bail(err)
+			blackout.TargetUUID = target.UUID
+		}
+		updateBlackoutTiming(blackout, opts.UpdateBlackout.Schedule, opts.UpdateBlackout.Duration,
+			opts.UpdateBlackout.From, opts.UpdateBlackout.Until)
+		if opts.UpdateBlackout.CatchUp {
+			blackout.CatchUp = true
+		}
+		if opts.UpdateBlackout.NoCatchUp {
+			blackout.CatchUp = false
+		}
+
+		blackout, err = c.UpdateBlackout(tenant, blackout)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(blackout))
+			break
+		}
+
+		r := tui.NewReport()
+		r.Add("UUID", blackout.UUID)
+		r.Add("Name", blackout.Name)
+		r.Add("Summary", blackout.Summary)
+		r.Add("When", blackoutWhen(blackout))
+		r.Output(os.Stdout)
+
+	/* }}} */
+	case "delete-blackout": /* {{{ */
+		if len(args) != 1 {
+			fail(2, "Usage: shield %s -t TENANT [OPTIONS] NAME-or-UUID\n", command)
+		}
+
+		required(opts.Tenant != "", "Missing required --tenant option.")
+
+		tenant, err := c.FindMyTenant(opts.Tenant, true)
+		bail(err)
+
+		blackout, err := c.FindBlackout(tenant, args[0], true)
+		bail(err)
+
+		if !confirm(opts.Yes, "Delete blackout @Y{%s} in tenant @Y{%s}?", blackout.Name, tenant.Name) {
+			break
+		}
+		r, err := c.DeleteBlackout(tenant, blackout)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(r))
+			break
+		}
+		fmt.Printf("%s\n", r.OK)
+
+	/* }}} */
+	case "global-blackouts": /* {{{ */
+		required(len(args) <= 1, "Too many arguments.")
+
+		filter := &shield.BlackoutFilter{
+			Fuzzy: !opts.Exact,
+		}
+		if len(args) == 1 {
+			filter.Name = args[0]
+			filter.UUID = args[0]
+		}
+
+		blackouts, err := c.ListGlobalBlackouts(filter)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(blackouts))
+			break
+		}
+
+		tbl := table.NewTable("UUID", "Name", "Summary", "When", "Catch Up?")
+		for _, blackout := range blackouts {
+			tbl.Row(blackout, uuid8full(blackout.UUID, opts.Long), blackout.Name, wrap(blackout.Summary, 35), blackoutWhen(blackout), blackout.CatchUp)
+		}
+		tbl.Output(os.Stdout)
+
+	/* }}} */
+	case "global-blackout": /* {{{ */
+		if len(args) != 1 {
+			fail(2, "Usage: shield %s NAME-or-UUID\n", command)
+		}
+
+		blackout, err := c.FindGlobalBlackout(args[0], !opts.Exact)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(blackout))
+			break
+		}
+
+		r := tui.NewReport()
+		r.Add("UUID", blackout.UUID)
+		r.Add("Name", blackout.Name)
+		r.Add("Summary", blackout.Summary)
+		r.Break()
+		r.Add("When", blackoutWhen(blackout))
+		r.Add("Catch Up?", strconv.FormatBool(blackout.CatchUp))
+		r.Output(os.Stdout)
+
+	/* }}} */
+	case "create-global-blackout": /* {{{ */
+		if !opts.Batch {
+			if opts.CreateGlobalBlackout.Name == "" {
+				opts.CreateGlobalBlackout.Name = prompt("@C{Blackout Name}: ")
+			}
+			if opts.CreateGlobalBlackout.Summary == "" {
+				opts.CreateGlobalBlackout.Summary = prompt("@C{Description}: ")
+			}
+			if opts.CreateGlobalBlackout.Schedule == "" && opts.CreateGlobalBlackout.From == "" {
+				opts.CreateGlobalBlackout.Schedule = prompt("@C{Schedule (leave blank for a one-off blackout)}: ")
+			}
+			if opts.CreateGlobalBlackout.Schedule != "" && opts.CreateGlobalBlackout.Duration == "" {
+				opts.CreateGlobalBlackout.Duration = prompt("@C{Duration}: ")
+			}
+			if opts.CreateGlobalBlackout.Schedule == "" && opts.CreateGlobalBlackout.From == "" {
+				opts.CreateGlobalBlackout.From = prompt("@C{From}: ")
+			}
+			if opts.CreateGlobalBlackout.Schedule == "" && opts.CreateGlobalBlackout.Until == "" {
+				opts.CreateGlobalBlackout.Until = prompt("@C{Until}: ")
+			}
+		}
+
+		blackout := &shield.Blackout{
+			Name:     opts.CreateGlobalBlackout.Name,
+			Summary:  opts.CreateGlobalBlackout.Summary,
+			Schedule: opts.CreateGlobalBlackout.Schedule,
+			CatchUp:  opts.CreateGlobalBlackout.CatchUp,
+		}
+		var err error
+		blackout.Duration, err = parseRuntime(opts.CreateGlobalBlackout.Duration)
+		bail(err)
+		if opts.CreateGlobalBlackout.From != "" {
+			blackout.StartsAt = strptime(opts.CreateGlobalBlackout.From)
+		}
+		if opts.CreateGlobalBlackout.Until != "" {
+			blackout.EndsAt = strptime(opts.CreateGlobalBlackout.Until)
+		}
+
+		blackout, err = c.CreateGlobalBlackout(blackout)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(blackout))
+			break
+		}
+
+		r := tui.NewReport()
+		r.Add("UUID", blackout.UUID)
+		r.Add("Name", blackout.Name)
+		r.Add("Summary", blackout.Summary)
+		r.Add("When", blackoutWhen(blackout))
+		r.Output(os.Stdout)
+
+	/* }}} */
+	case "update-global-blackout": /* {{{ */
+		if len(args) != 1 {
+			fail(2, "Usage: shield %s [OPTIONS] NAME-or-UUID\n", command)
+		}
+		required(!(opts.UpdateGlobalBlackout.CatchUp && opts.UpdateGlobalBlackout.NoCatchUp),
+			"The --catch-up and --no-catch-up options are mutually exclusive.")
+
+		blackout, err := c.FindGlobalBlackout(args[0], !opts.Exact)
+		bail(err)
+
+		if opts.UpdateGlobalBlackout.Name != "" {
+			blackout.Name = opts.UpdateGlobalBlackout.Name
+		}
+		if opts.UpdateGlobalBlackout.Summary != "" {
+			blackout.Summary = opts.UpdateGlobalBlackout.Summary
+		}
+		updateBlackoutTiming(blackout, opts.UpdateGlobalBlackout.Schedule, opts.UpdateGlobalBlackout.Duration,
+			opts.UpdateGlobalBlackout.From, opts.UpdateGlobalBlackout.Until)
+		if opts.UpdateGlobalBlackout.CatchUp {
+			blackout.CatchUp = true
+		}
+		if opts.UpdateGlobalBlackout.NoCatchUp {
+			blackout.CatchUp = false
+		}
+
+		blackout, err = c.UpdateGlobalBlackout(blackout)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(blackout))
+			break
+		}
+
+		r := tui.NewReport()
+		r.Add("UUID", blackout.UUID)
+		r.Add("Name", blackout.Name)
+		r.Add("Summary", blackout.Summary)
+		r.Add("When", blackoutWhen(blackout))
+		r.Output(os.Stdout)
+
+	/* }}} */
+	case "delete-global-blackout": /* {{{ */
+		if len(args) != 1 {
+			fail(2, "Usage: shield %s [OPTIONS] NAME-or-UUID\n", command)
+		}
+
+		blackout, err := c.FindGlobalBlackout(args[0], true)
+		bail(err)
+
+		if !confirm(opts.Yes, "Delete @R{global} blackout @Y{%s}?", blackout.Name) {
+			break
+		}
+		r, err := c.DeleteGlobalBlackout(blackout)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(r))
+			break
+		}
+		fmt.Printf("%s\n", r.OK)
+
+	/* }}} */
+
+	case "calendars": /* {{{ */
+		required(len(args) <= 1, "Too many arguments.")
+
+		filter := &shield.CalendarFilter{
+			Fuzzy: !opts.Exact,
+		}
+		if len(args) == 1 {
+			filter.Name = args[0]
+			filter.UUID = args[0]
+		}
+
+		calendars, err := c.ListCalendars(filter)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(calendars))
+			break
+		}
+
+		tbl := table.NewTable("UUID", "Name", "Summary", "Dates", "Next Date")
+		for _, calendar := range calendars {
+			tbl.Row(calendar, uuid8full(calendar.UUID, opts.Long), calendar.Name, wrap(calendar.Summary, 35), len(calendar.Dates), nextCalendarDate(calendar))
+		}
+		tbl.Output(os.Stdout)
+
+	/* }}} */
+	case "calendar": /* {{{ */
+		if len(args) != 1 {
+			fail(2, "Usage: shield %s NAME-or-UUID\n", command)
+		}
+
+		calendar, err := c.FindCalendar(args[0], !opts.Exact)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(calendar))
+			break
+		}
+
+		r := tui.NewReport()
+		r.Add("UUID", calendar.UUID)
+		r.Add("Name", calendar.Name)
+		r.Add("Summary", calendar.Summary)
+		r.Break()
+		r.Add("Dates", strings.Join(calendar.Dates, "\n"))
+		r.Output(os.Stdout)
+
+	/* }}} */
+	case "create-calendar": /* {{{ */
+		if !opts.Batch {
+			if opts.CreateCalendar.Name == "" {
+				opts.CreateCalendar.Name = prompt("@C{Calendar Name}: ")
+			}
+			if opts.CreateCalendar.Summary == "" {
+				opts.CreateCalendar.Summary = prompt("@C{Description}: ")
+			}
+		}
+
+		calendar, err := c.CreateCalendar(&shield.Calendar{
+			Name:    opts.CreateCalendar.Name,
+			Summary: opts.CreateCalendar.Summary,
+			Dates:   opts.CreateCalendar.Dates,
+		})
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(calendar))
+			break
+		}
+
+		r := tui.NewReport()
+		r.Add("UUID", calendar.UUID)
+		r.Add("Name", calendar.Name)
+		r.Add("Summary", calendar.Summary)
+		r.Add("Dates", strings.Join(calendar.Dates, "\n"))
+		r.Output(os.Stdout)
+
+	/* }}} */
+	case "update-calendar": /* {{{ */
+		if len(args) != 1 {
+			fail(2, "Usage: shield %s [OPTIONS] NAME-or-UUID\n", command)
+		}
+
+		calendar, err := c.FindCalendar(args[0], !opts.Exact)
+		bail(err)
+
+		if opts.UpdateCalendar.Name != "" {
+			calendar.Name = opts.UpdateCalendar.Name
+		}
+		if opts.UpdateCalendar.Summary != "" {
+			calendar.Summary = opts.UpdateCalendar.Summary
+		}
+		calendar.Dates = updateCalendarDates(calendar.Dates, opts.UpdateCalendar.ClearDates,
+			opts.UpdateCalendar.AddDates, opts.UpdateCalendar.RemoveDates)
+
+		calendar, err = c.UpdateCalendar(calendar)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(calendar))
+			break
+		}
+
+		r := tui.NewReport()
+		r.Add("UUID", calendar.UUID)
+		r.Add("Name", calendar.Name)
+		r.Add("Summary", calendar.Summary)
+		r.Add("Dates", strings.Join(calendar.Dates, "\n"))
+		r.Output(os.Stdout)
+
+	/* }}} */
+	case "delete-calendar": /* {{{ */
+		if len(args) != 1 {
+			fail(2, "Usage: shield %s [OPTIONS] NAME-or-UUID\n", command)
+		}
+
+		calendar, err := c.FindCalendar(args[0], true)
+		bail(err)
+
+		if !confirm(opts.Yes, "Delete exclusion calendar @Y{%s}?", calendar.Name) {
+			break
+		}
+		r, err := c.DeleteCalendar(calendar)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(r))
+			break
+		}
+		fmt.Printf("%s\n", r.OK)
+
+	/* }}} */
+
+	case "archives": /* {{{ */
+		required(opts.Tenant != "", "Missing required --tenant option.")
+		required(len(args) <= 1, "Too many arguments.")
+
+		tenant, err := c.FindMyTenant(opts.Tenant, true)
+		bail(err)
+
+		if opts.Archives.Limit == 0 {
+			opts.Archives.Limit = 1000 /* sane upper limit */
+		}
+		if opts.Archives.Target != "" {
+			t, err := c.FindTarget(tenant, opts.Archives.Target, !opts.Exact)
+			bail(err)
+			opts.Archives.Target = t.UUID
+		}
+		if opts.Archives.Store != "" {
+			s, err := c.FindStore(tenant, opts.Archives.Store, !opts.Exact)
+			bail(err)
+			opts.Archives.Store = s.UUID
+		}
+
+		for _, label := range opts.Archives.Labels {
+			required(strings.Contains(label, "="), "Labels must be given as --label name=value.")
+		}
+
+		filter := &shield.ArchiveFilter{
+			Target: opts.Archives.Target,
+			Store:  opts.Archives.Store,
+			Labels: opts.Archives.Labels,
+			Search: opts.Archives.Search,
+			Limit:  &opts.Archives.Limit,
+			Fuzzy:  !opts.Exact,
+		}
+		if len(args) == 1 {
+			filter.UUID = args[0]
+		}
+
+		archives, err := c.ListArchives(tenant, filter)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(archives))
+			break
+		}
+
+		tbl := table.NewTable("UUID", "Key", "Size", "Status", "Compression", "Encryption")
+		for _, archive := range archives {
+			tbl.Row(archive, uuid8full(archive.UUID, opts.Long), archive.Key, archive.Status, archive.Compression, archive.EncryptionType, formatBytes(archive.Size))
+		}
+		tbl.Output(os.Stdout)
+
+	/* }}} */
+	case "archive": /* {{{ */
+		if len(args) != 1 {
+			fail(2, "Usage: shield %s NAME-or-UUID\n", command)
+		}
+
+		required(opts.Tenant != "", "Missing required --tenant option.")
+		tenant, err := c.FindMyTenant(opts.Tenant, true)
+		bail(err)
 
+		archive, err := c.FindArchive(tenant, args[0], !opts.Exact)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(archive))
+			break
+		}
+
+		r := tui.NewReport()
+		r.Add("UUID", archive.UUID)
+		r.Add("Key", archive.Key)
+		r.Add("Status", archive.Status)
+		r.Add("Size", formatBytes(archive.Size))
+		r.Add("Compression", archive.Compression)
+		r.Add("Encryption", archive.EncryptionType)
+		r.Add("Notes", archive.Notes)
+		r.Add("Labels", formatLabels(archive.Labels))
+		if archive.Status == "pending-purge" {
+			r.Break()
+			r.Add("Purge Reason", archive.PurgeReason)
+			r.Add("Purge After", strftime(archive.PurgeAfter))
+		}
+		if archive.Held {
+			r.Break()
+			r.Add("Legal Hold", archive.HoldReason)
+			r.Add("Held By", archive.HeldBy)
+			r.Add("Held At", strftime(archive.HeldAt))
+			r.Add("Hold Until", strftimenil(archive.HoldUntil, "(until released)"))
+		}
+		r.Output(os.Stdout)
+
+	/* }}} */
+	case "restore-archive": /* {{{ */
+		if len(args) != 1 {
+			fail(2, "Usage: shield %s NAME-or-UUID\n", command)
+		}
+
+		required(opts.Tenant != "", "Missing required --tenant option.")
+		tenant, err := c.FindMyTenant(opts.Tenant, true)
+		bail(err)
+
+		archive, err := c.FindArchive(tenant, args[0], !opts.Exact)
+		bail(err)
+
+		var target *shield.Target
+		if id := opts.RestoreArchive.Target; id != "" {
+			target, err = c.FindTarget(tenant, id, !opts.Exact)
+			bail(err)
+		}
+
+		task, err := c.RestoreArchive(tenant, archive, target)
+		if awaitingApproval(err, opts.JSON) {
+			break
+		}
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(task))
+			break
+		}
+
+		fmt.Printf("Scheduled restore; task @C{%s}\n", task.UUID)
+
+	/* }}} */
+	case "restore": /* {{{ */
+		required(len(args) == 0, "Too many arguments.")
+		required(opts.Restore.Target != "", "Missing required --target option.")
+		required(opts.Restore.AsOf != "", "Missing required --as-of option.")
+		required(opts.Tenant != "", "Missing required --tenant option.")
+
+		tenant, err := c.FindMyTenant(opts.Tenant, true)
+		bail(err)
+
+		target, err := c.FindTarget(tenant, opts.Restore.Target, !opts.Exact)
+		bail(err)
+
+		asOf := parseAsOf(opts.Restore.AsOf)
+		archive, err := c.RestorePoint(tenant, target, asOf)
+		bail(err)
+
+		if !opts.JSON {
+			fmt.Printf("As of @C{%s}, target @C{%s} was last backed up by archive:\n\n", strftime(asOf), target.Name)
+
+			r := tui.NewReport()
+			r.Add("UUID", archive.UUID)
+			r.Add("Taken at", strftime(archive.TakenAt))
+			r.Add("Key", archive.Key)
+			r.Add("Size", formatBytes(archive.Size))
+			r.Add("Notes", archive.Notes)
+			r.Add("Labels", formatLabels(archive.Labels))
+			r.Output(os.Stdout)
+			fmt.Printf("\n")
+		}
+
+		if !confirm(opts.Yes, "Restore this archive to target @Y{%s}?", target.Name) {
+			break
+		}
+
+		task, err := c.RestoreAsOf(tenant, target, asOf, archive)
+		if awaitingApproval(err, opts.JSON) {
+			break
+		}
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(task))
+			break
+		}
+
+		fmt.Printf("Scheduled restore; task @C{%s}\n", task.UUID)
+
+	/* }}} */
+	case "purge-archive": /* {{{ */
+		if len(args) != 1 {
+			fail(2, "Usage: shield %s NAME-or-UUID\n", command)
+		}
+
+		required(opts.Tenant != "", "Missing required --tenant option.")
+		tenant, err := c.FindMyTenant(opts.Tenant, true)
+		bail(err)
+
+		archive, err := c.FindArchive(tenant, args[0], !opts.Exact)
+		bail(err)
+
+		if opts.PurgeArchive.Reason != "" {
+			archive.Notes = opts.PurgeArchive.Reason
+			_, err = c.UpdateArchive(tenant, archive)
+			if err != nil && !opts.JSON {
+				fmt.Fprintf(os.Stderr, "@Y{WARNING: Unable to update archive with reason for purge}: %s", err)
+			}
+		}
+
+		rs, err := c.DeleteArchive(tenant, archive)
+		if awaitingApproval(err, opts.JSON) {
+			break
+		}
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(rs))
+			break
+		}
+
+		fmt.Printf("%s\n", rs.OK)
+
+	/* }}} */
+	case "annotate-archive": /* {{{ */
+		if len(args) != 1 {
+			fail(2, "Usage: shield %s NAME-or-UUID --notes ...\n", command)
+		}
+
+		required(opts.AnnotateArchive.Notes != "", "Missing required --notes option.")
+		required(opts.Tenant != "", "Missing required --tenant option.")
 		tenant, err := c.FindMyTenant(opts.Tenant, true)
 		bail(err)
 
-		if opts.Archives.Limit == 0 {
-			opts.Archives.Limit = 1000 /* sane upper limit */
+		archive, err := c.FindArchive(tenant, args[0], !opts.Exact)
+		bail(err)
+
+		archive.Notes = opts.AnnotateArchive.Notes
+		archive, err = c.UpdateArchive(tenant, archive)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(archive))
+			break
 		}
-		if opts.Archives.Target != "" {
-			t, err := c.FindTarget(tenant, opts.Archives.Target, !opts.Exact)
-			bail(err)
-			opts.Archives.Target = t.UUID
+
+		r := tui.NewReport()
+		r.Add("UUID", archive.UUID)
+		r.Add("Key", archive.Key)
+		r.Add("Compression", archive.Compression)
+		r.Add("Status", archive.Status)
+		r.Add("Notes", archive.Notes)
+		r.Output(os.Stdout)
+
+	/* }}} */
+	case "label-archive": /* {{{ */
+		if len(args) < 2 {
+			fail(2, "Usage: shield %s NAME-or-UUID name=value [name=value ...]\n", command)
 		}
-		if opts.Archives.Store != "" {
-			s, err := c.FindStore(tenant, opts.Archives.Store, !opts.Exact)
-			bail(err)
-			opts.Archives.Store = s.UUID
+
+		labels := make(map[string]string)
+		for _, label := range args[1:] {
+			kv := strings.SplitN(label, "=", 2)
+			required(len(kv) == 2, "Labels must be given as name=value.")
+			labels[kv[0]] = kv[1]
 		}
 
-		filter := &shield.ArchiveFilter{
-			Target: opts.Archives.Target,
-			Store:  opts.Archives.Store,
-			Limit:  &opts.Archives.Limit,
-			Fuzzy:  !opts.Exact,
+		required(opts.Tenant != "", "Missing required --tenant option.")
+		tenant, err := c.FindMyTenant(opts.Tenant, true)
+		bail(err)
+
+		archive, err := c.FindArchive(tenant, args[0], !opts.Exact)
+		bail(err)
+
+		archive, err = c.LabelArchive(tenant, archive, labels)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(archive))
+			break
 		}
-		if len(args) == 1 {
-			filter.UUID = args[0]
+
+		r := tui.NewReport()
+		r.Add("UUID", archive.UUID)
+		r.Add("Key", archive.Key)
+		r.Add("Status", archive.Status)
+		r.Add("Labels", formatLabels(archive.Labels))
+		r.Output(os.Stdout)
+
+	/* }}} */
+	case "unlabel-archive": /* {{{ */
+		if len(args) < 2 {
+			fail(2, "Usage: shield %s NAME-or-UUID name [name ...]\n", command)
 		}
 
-		archives, err := c.ListArchives(tenant, filter)
+		required(opts.Tenant != "", "Missing required --tenant option.")
+		tenant, err := c.FindMyTenant(opts.Tenant, true)
+		bail(err)
+
+		archive, err := c.FindArchive(tenant, args[0], !opts.Exact)
 		bail(err)
 
+		var rs shield.Response
+		for _, name := range args[1:] {
+			rs, err = c.UnlabelArchive(tenant, archive, name)
+			bail(err)
+		}
+
 		if opts.JSON {
-			fmt.Printf("%s\n", asJSON(archives))
+			fmt.Printf("%s\n", asJSON(rs))
 			break
 		}
 
-		tbl := table.NewTable("UUID", "Key", "Size", "Status", "Compression", "Encryption")
-		for _, archive := range archives {
-			tbl.Row(archive, uuid8full(archive.UUID, opts.Long), archive.Key, archive.Status, archive.Compression, archive.EncryptionType, formatBytes(archive.Size))
-		}
-		tbl.Output(os.Stdout)
+		fmt.Printf("%s\n", rs.OK)
 
 	/* }}} */
-	case "archive": /* {{{ */
+	case "undelete-archive": /* {{{ */
 		if len(args) != 1 {
 			fail(2, "Usage: shield %s NAME-or-UUID\n", command)
 		}
@@ -2710,6 +4383,14 @@ tenants:
 		archive, err := c.FindArchive(tenant, args[0], !opts.Exact)
 		bail(err)
 
+		var expires int64
+		if opts.UndeleteArchive.Expires != "" {
+			expires = strptime(opts.UndeleteArchive.Expires)
+		}
+
+		archive, err = c.UndeleteArchive(tenant, archive, expires)
+		bail(err)
+
 		if opts.JSON {
 			fmt.Printf("%s\n", asJSON(archive))
 			break
@@ -2719,18 +4400,16 @@ tenants:
 		r.Add("UUID", archive.UUID)
 		r.Add("Key", archive.Key)
 		r.Add("Status", archive.Status)
-		r.Add("Size", formatBytes(archive.Size))
-		r.Add("Compression", archive.Compression)
-		r.Add("Encryption", archive.EncryptionType)
-		r.Add("Notes", archive.Notes)
+		r.Add("Expires", strftime(archive.ExpiresAt))
 		r.Output(os.Stdout)
 
 	/* }}} */
-	case "restore-archive": /* {{{ */
+	case "hold-archive": /* {{{ */
 		if len(args) != 1 {
-			fail(2, "Usage: shield %s NAME-or-UUID\n", command)
+			fail(2, "Usage: shield %s NAME-or-UUID --reason ...\n", command)
 		}
 
+		required(opts.HoldArchive.Reason != "", "Missing required --reason option.")
 		required(opts.Tenant != "", "Missing required --tenant option.")
 		tenant, err := c.FindMyTenant(opts.Tenant, true)
 		bail(err)
@@ -2738,24 +4417,31 @@ tenants:
 		archive, err := c.FindArchive(tenant, args[0], !opts.Exact)
 		bail(err)
 
-		var target *shield.Target
-		if id := opts.RestoreArchive.Target; id != "" {
-			target, err = c.FindTarget(tenant, id, !opts.Exact)
-			bail(err)
+		var until int64
+		if opts.HoldArchive.Until != "" {
+			until = strptime(opts.HoldArchive.Until)
 		}
 
-		task, err := c.RestoreArchive(tenant, archive, target)
+		archive, err = c.HoldArchive(tenant, archive, opts.HoldArchive.Reason, until)
 		bail(err)
 
 		if opts.JSON {
-			fmt.Printf("%s\n", asJSON(task))
+			fmt.Printf("%s\n", asJSON(archive))
 			break
 		}
 
-		fmt.Printf("Scheduled restore; task @C{%s}\n", task.UUID)
+		r := tui.NewReport()
+		r.Add("UUID", archive.UUID)
+		r.Add("Key", archive.Key)
+		r.Add("Status", archive.Status)
+		r.Add("Legal Hold", archive.HoldReason)
+		r.Add("Held By", archive.HeldBy)
+		r.Add("Held At", strftime(archive.HeldAt))
+		r.Add("Hold Until", strftimenil(archive.HoldUntil, "(until released)"))
+		r.Output(os.Stdout)
 
 	/* }}} */
-	case "purge-archive": /* {{{ */
+	case "release-archive": /* {{{ */
 		if len(args) != 1 {
 			fail(2, "Usage: shield %s NAME-or-UUID\n", command)
 		}
@@ -2767,15 +4453,10 @@ tenants:
 		archive, err := c.FindArchive(tenant, args[0], !opts.Exact)
 		bail(err)
 
-		if opts.PurgeArchive.Reason != "" {
-			archive.Notes = opts.PurgeArchive.Reason
-			_, err = c.UpdateArchive(tenant, archive)
-			if err != nil && !opts.JSON {
-				fmt.Fprintf(os.Stderr, "@Y{WARNING: Unable to update archive with reason for purge}: %s", err)
-			}
+		if !confirm(opts.Yes, "Release the legal hold on archive @Y{%s}?", archive.UUID) {
+			break
 		}
-
-		rs, err := c.DeleteArchive(tenant, archive)
+		rs, err := c.ReleaseArchive(tenant, archive)
 		bail(err)
 
 		if opts.JSON {
@@ -2786,12 +4467,13 @@ tenants:
 		fmt.Printf("%s\n", rs.OK)
 
 	/* }}} */
-	case "annotate-archive": /* {{{ */
+
+	case "download-archive": /* {{{ */
 		if len(args) != 1 {
-			fail(2, "Usage: shield %s NAME-or-UUID --notes ...\n", command)
+			fail(2, "Usage: shield %s NAME-or-UUID --output FILE\n", command)
 		}
+		required(opts.DownloadArchive.Output != "", "Missing required --output option.")
 
-		required(opts.AnnotateArchive.Notes != "", "Missing required --notes option.")
 		required(opts.Tenant != "", "Missing required --tenant option.")
 		tenant, err := c.FindMyTenant(opts.Tenant, true)
 		bail(err)
@@ -2799,25 +4481,113 @@ tenants:
 		archive, err := c.FindArchive(tenant, args[0], !opts.Exact)
 		bail(err)
 
-		archive.Notes = opts.AnnotateArchive.Notes
-		archive, err = c.UpdateArchive(tenant, archive)
+		in, id, err := c.DownloadArchive(tenant, archive, opts.DownloadArchive.Decompress)
+		bail(err)
+		defer in.Close()
+
+		out := os.Stdout
+		if opts.DownloadArchive.Output != "-" {
+			out, err = os.OpenFile(opts.DownloadArchive.Output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+			bail(err)
+			defer out.Close()
+		}
+
+		n, err := io.Copy(out, in)
+		bail(err)
+
+		/* the download task only finishes once the last
+		   of the archive has been sent (or not) our way. */
+		task, err := c.GetTask(tenant, id)
+		bail(err)
+		if task.Status != "done" {
+			fail(1, "@R{Download of archive %s failed} after %d bytes; see @C{shield task %s} for details.\n", archive.UUID, n, id)
+		}
+
+		if opts.JSON {
+			if out != os.Stdout {
+				fmt.Printf("%s\n", asJSON(task))
+			}
+			break
+		}
+
+		fmt.Fprintf(os.Stderr, "Downloaded %d bytes of archive @C{%s}; task @C{%s}\n", n, archive.UUID, id)
+
+	/* }}} */
+
+	case "import-archive": /* {{{ */
+		required(len(args) == 0, "Too many arguments.")
+		required(opts.ImportArchive.Target != "", "Missing required --target option.")
+		required(opts.ImportArchive.Store != "", "Missing required --store option.")
+		required(opts.ImportArchive.Expires != "", "Missing required --expires option.")
+		required(opts.ImportArchive.File != "" || opts.ImportArchive.Key != "",
+			"Either --file or --key is required.")
+		required(opts.ImportArchive.File == "" || opts.ImportArchive.Key == "",
+			"The --file and --key options are mutually exclusive.")
+
+		required(opts.Tenant != "", "Missing required --tenant option.")
+		tenant, err := c.FindMyTenant(opts.Tenant, true)
+		bail(err)
+
+		target, err := c.FindTarget(tenant, opts.ImportArchive.Target, !opts.Exact)
+		bail(err)
+
+		store, err := c.FindUsableStore(tenant, opts.ImportArchive.Store, !opts.Exact)
 		bail(err)
 
+		in := &shield.ArchiveImport{
+			Target:      target.UUID,
+			Store:       store.UUID,
+			Key:         opts.ImportArchive.Key,
+			Compression: opts.ImportArchive.Compression,
+			ExpiresAt:   parseAsOf(opts.ImportArchive.Expires),
+			Notes:       opts.ImportArchive.Notes,
+			Encrypt:     !opts.ImportArchive.Unencrypted,
+		}
+		if opts.ImportArchive.TakenAt != "" {
+			in.TakenAt = parseAsOf(opts.ImportArchive.TakenAt)
+		}
+
+		var archive *shield.Archive
+		if opts.ImportArchive.File != "" {
+			f, err := os.Open(opts.ImportArchive.File)
+			bail(err)
+			defer f.Close()
+
+			if in.TakenAt == 0 {
+				if st, err := f.Stat(); err == nil {
+					in.TakenAt = st.ModTime().Unix()
+				}
+			}
+
+			archive, err = c.UploadArchive(tenant, in, f)
+			bail(err)
+
+		} else {
+			archive, err = c.ImportArchive(tenant, in)
+			bail(err)
+		}
+
 		if opts.JSON {
 			fmt.Printf("%s\n", asJSON(archive))
 			break
 		}
 
+		encryption := archive.EncryptionType
+		if encryption == "none" {
+			encryption = "(unencrypted legacy archive)"
+		}
+
 		r := tui.NewReport()
 		r.Add("UUID", archive.UUID)
 		r.Add("Key", archive.Key)
-		r.Add("Compression", archive.Compression)
 		r.Add("Status", archive.Status)
-		r.Add("Notes", archive.Notes)
+		r.Add("Taken at", strftime(archive.TakenAt))
+		r.Add("Expires", strftime(archive.ExpiresAt))
+		r.Add("Compression", archive.Compression)
+		r.Add("Encryption", encryption)
 		r.Output(os.Stdout)
 
 	/* }}} */
-
 	case "tasks": /* {{{ */
 		required(!(opts.Tasks.Active && opts.Tasks.Inactive),
 			"The --active and --inactive options are mutually exclusive.")
@@ -2834,7 +4604,7 @@ tenants:
 			/* not specified; which is ok... */
 		case "all":
 			opts.Tasks.All = true
-		case "pending", "scheduled", "running", "canceled", "failed", "done":
+		case "pending", "scheduled", "running", "canceled", "failed", "overrun", "done":
 			/* good enough to pass validation... */
 		default:
 			fail(3, "Invalid --status value of '%s'\n(must be one of all, pending, running,\n cnaceled, failed, or done).", opts.Tasks.Status)
@@ -3068,9 +4838,13 @@ tenants:
 			break
 		}
 
-		tbl := table.NewTable("UUID", "Name", "Account", "System Role")
+		tbl := table.NewTable("UUID", "Name", "Account", "System Role", "MFA", "Locked Until")
 		for _, user := range users {
-			tbl.Row(user, uuid8full(user.UUID, opts.Long), user.Name, user.Account, user.SysRole)
+			mfa := "no"
+			if user.MFA {
+				mfa = "yes"
+			}
+			tbl.Row(user, uuid8full(user.UUID, opts.Long), user.Name, user.Account, user.SysRole, mfa, strftimenil(user.LockedUntil, ""))
 		}
 		tbl.Output(os.Stdout)
 
@@ -3093,6 +4867,15 @@ tenants:
 		r.Add("Name", user.Name)
 		r.Add("Account", user.Account)
 		r.Add("System Role", user.SysRole)
+		if user.MFA {
+			r.Add("MFA", "enabled")
+		} else {
+			r.Add("MFA", "disabled")
+		}
+		r.Add("Failed Logins", fmt.Sprintf("%d", user.FailedLogins))
+		if user.LockedUntil != 0 {
+			r.Add("Locked Until", fmt.Sprintf("@R{%s}", strftime(user.LockedUntil)))
+		}
 		r.Output(os.Stdout)
 
 	/* }}} */
@@ -3193,6 +4976,45 @@ tenants:
 		}
 		fmt.Printf("%s\n", r.OK)
 
+	/* }}} */
+	case "unlock-user": /* {{{ */
+		if len(args) != 1 {
+			fail(2, "Usage: shield %s NAME-or-UUID\n", command)
+		}
+
+		user, err := c.FindUser(args[0], !opts.Exact)
+		bail(err)
+
+		r, err := c.UnlockUser(user)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(r))
+			break
+		}
+		fmt.Printf("%s\n", r.OK)
+
+	/* }}} */
+	case "reset-mfa": /* {{{ */
+		if len(args) != 1 {
+			fail(2, "Usage: shield %s NAME-or-UUID\n", command)
+		}
+
+		user, err := c.FindUser(args[0], !opts.Exact)
+		bail(err)
+
+		if !confirm(opts.Yes, "Turn off multi-factor authentication for @Y{%s}@local?", user.Account) {
+			break
+		}
+		r, err := c.ResetUserMFA(user)
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(r))
+			break
+		}
+		fmt.Printf("%s\n", r.OK)
+
 	/* }}} */
 	case "passwd": /* {{{ */
 		if opts.Batch {
@@ -3295,6 +5117,121 @@ tenants:
 		}
 		fmt.Printf("%s\n", r.OK)
 
+	/* }}} */
+	case "lockouts": /* {{{ */
+		required(len(args) == 0, "Too many arguments.")
+
+		lockouts, err := c.ListLoginLockouts()
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(lockouts))
+			break
+		}
+
+		tbl := table.NewTable("Type", "Name", "Failures", "Last Failure", "Locked Until")
+		for _, lock := range lockouts {
+			tbl.Row(lock, lock.Kind, lock.Name, lock.Failures, strftime(lock.LastFailedAt), strftime(lock.LockedUntil))
+		}
+		tbl.Output(os.Stdout)
+
+	/* }}} */
+	case "clear-lockout": /* {{{ */
+		if len(args) != 2 || (args[0] != "account" && args[0] != "ip") {
+			fail(2, "Usage: shield %s (account ACCOUNT@BACKEND | ip ADDRESS)\n", command)
+		}
+
+		r, err := c.ClearLoginLockout(args[0], args[1])
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(r))
+			break
+		}
+		fmt.Printf("%s\n", r.OK)
+
+	/* }}} */
+
+	case "audit": /* {{{ */
+		required(len(args) == 0, "Too many arguments.")
+
+		filter := &shield.AuditFilter{
+			Actor:  opts.Audit.Actor,
+			Type:   opts.Audit.Type,
+			Object: opts.Audit.Object,
+			Method: opts.Audit.Method,
+		}
+		if opts.Audit.Limit > 0 {
+			filter.Limit = &opts.Audit.Limit
+		}
+		if opts.Audit.Since != "" {
+			filter.Since = parseAsOf(opts.Audit.Since)
+		}
+		if opts.Audit.Until != "" {
+			filter.Until = parseAsOf(opts.Audit.Until)
+		}
+		var tenant *shield.Tenant
+		if opts.Tenant != "" {
+			t, err := c.FindMyTenant(opts.Tenant, true)
+			bail(err)
+			tenant = t
+			filter.Tenant = tenant.UUID
+		}
+
+		if opts.Audit.Export {
+			in, err := c.ExportAuditLog(filter)
+			bail(err)
+			_, err = io.Copy(os.Stdout, in)
+			in.Close()
+			bail(err)
+			break
+		}
+
+		var (
+			entries []*shield.AuditEntry
+			err     error
+		)
+		if tenant != nil {
+			entries, err = c.ListTenantAuditLog(tenant, filter)
+		} else {
+			entries, err = c.ListAuditLog(filter)
+		}
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(entries))
+			break
+		}
+
+		tbl := table.NewTable("#", "When", "Actor", "IP Address", "Request", "Status", "Object")
+		for _, e := range entries {
+			object := e.ObjectType
+			if e.ObjectUUID != "" {
+				object += " " + uuid8full(e.ObjectUUID, opts.Long)
+			}
+			tbl.Row(e, e.Seq, strftime(e.At), e.Actor, e.IP, e.Method+" "+e.Path, e.Status, object)
+		}
+		tbl.Output(os.Stdout)
+
+	/* }}} */
+	case "verify-audit": /* {{{ */
+		required(len(args) == 0, "Too many arguments.")
+
+		v, err := c.VerifyAuditLog()
+		bail(err)
+
+		if opts.JSON {
+			fmt.Printf("%s\n", asJSON(v))
+		} else if v.OK {
+			fmt.Printf("@G{audit log is intact}: %d entries, ending in %s\n", v.Entries, v.Head)
+		} else {
+			fmt.Printf("@R{audit log has been tampered with} at entry #%d: %s\n", v.BrokenAt, v.Problem)
+			fmt.Printf("(the %d entries before that are intact, ending in %s)\n", v.Entries, v.Head)
+		}
+		if !v.OK {
+			os.Exit(1)
+		}
+
 	/* }}} */
 
 	case "agents": /* {{{ */
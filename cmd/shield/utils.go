@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"os"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -14,6 +15,10 @@ import (
 	fmt "github.com/jhunt/go-ansi"
 	"github.com/mattn/go-isatty"
 	"golang.org/x/crypto/ssh/terminal"
+	"rsc.io/qr"
+
+	"github.com/shieldproject/shield/client/v2/shield"
+	"github.com/shieldproject/shield/tui"
 )
 
 func fail(rc int, m string, args ...interface{}) {
@@ -81,6 +86,68 @@ func secureprompt(label string, args ...interface{}) string {
 	return string(b)
 }
 
+// localAuth authenticates with a username and password, asking for
+// a multi-factor authentication code (unless we already have one from
+// --code) if the SHIELD Core wants one.
+func localAuth(c *shield.Client, auth *shield.LocalAuth) error {
+	auth.Code = opts.Login.Code
+	err := c.Authenticate(auth)
+	if err == nil || auth.Code != "" || !shield.IsMFARequired(err) {
+		return err
+	}
+
+	if opts.Batch {
+		return fmt.Errorf("Unable to prompt for a multi-factor authentication code under `--batch` mode (try --code)")
+	}
+	auth.Code = prompt("@Y{Authenticator Code:} ")
+	return c.Authenticate(auth)
+}
+
+// qrcode renders a QR code for the terminal, two modules to a line,
+// using Unicode half-blocks, with the quiet zone that scanners need.
+// Dark-on-light terminals are the exception, so "black" modules are
+// drawn as blanks, and the light ones as blocks.
+func qrcode(s string) string {
+	code, err := qr.Encode(s, qr.L)
+	if err != nil {
+		return ""
+	}
+
+	const quiet = 2
+	black := func(x, y int) bool {
+		return code.Black(x-quiet, y-quiet)
+	}
+
+	var b strings.Builder
+	size := code.Size + 2*quiet
+	for y := 0; y < size; y += 2 {
+		for x := 0; x < size; x++ {
+			top, bottom := black(x, y), black(x, y+1)
+			switch {
+			case top && bottom:
+				b.WriteString(" ")
+			case top:
+				b.WriteString("▄")
+			case bottom:
+				b.WriteString("▀")
+			default:
+				b.WriteString("█")
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func printRecoveryCodes(codes []string) {
+	fmt.Printf("Your recovery codes are:\n\n")
+	for _, code := range codes {
+		fmt.Printf("  @C{%s}\n", code)
+	}
+	fmt.Printf("\nEach of these can be used once, in place of a code from your\n")
+	fmt.Printf("authenticator.  Keep them somewhere safe; they will not be shown again.\n")
+}
+
 func asJSON(x interface{}) string {
 	var raw []byte
 	if s, ok := x.(string); ok {
@@ -136,6 +203,20 @@ func strptime(t string) int64 {
 	return u.Unix()
 }
 
+// parseAsOf understands points in time given either in the format
+// of SHIELD_DATE_FORMAT (like strptime), or as a local date with an
+// optional time of day, i.e. "2020-06-30 14:00" or "2020-06-30".
+func parseAsOf(t string) int64 {
+	if os.Getenv("SHIELD_DATE_FORMAT") == "" {
+		for _, f := range []string{"2006-01-02 15:04", "2006-01-02 15:04:05", "2006-01-02"} {
+			if u, err := time.ParseInLocation(f, t, time.Local); err == nil {
+				return u.Unix()
+			}
+		}
+	}
+	return strptime(t)
+}
+
 func strftimenil(t int64, ifnil string) string {
 	if t == 0 {
 		return ifnil
@@ -194,6 +275,66 @@ func formatBytes(in int64) string {
 	return fmt.Sprintf("%0.1fT", float64(in)/1024.0/1024.0/1024.0/1024.0)
 }
 
+func formatLabels(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	l := make([]string, len(names))
+	for i, name := range names {
+		l[i] = fmt.Sprintf("%s=%s", name, labels[name])
+	}
+	return strings.Join(l, "\n")
+}
+
+// tokenScope describes what an auth token is limited to, one limit
+// per line, naming its tenant if it is one of the given tenants.
+func tokenScope(t *shield.AuthToken, tenants map[string]string) string {
+	l := []string{}
+	if t.Tenant != "" || t.Role != "" {
+		role := t.Role
+		if role == "" {
+			role = "any role"
+		}
+		tenant := "any tenant"
+		if t.Tenant != "" {
+			tenant = uuid8(t.Tenant)
+			if name, ok := tenants[t.Tenant]; ok {
+				tenant = name
+			}
+		}
+		l = append(l, fmt.Sprintf("%s on %s", role, tenant))
+	}
+	if len(t.Ops) > 0 {
+		l = append(l, "ops: "+strings.Join(t.Ops, ", "))
+	}
+	if len(t.Allow) > 0 {
+		l = append(l, "from: "+strings.Join(t.Allow, ", "))
+	}
+
+	if len(l) == 0 {
+		return "(unlimited)"
+	}
+	return strings.Join(l, "\n")
+}
+
+func parseRuntime(in string) (int, error) {
+	if in == "" {
+		return 0, nil
+	}
+	if n, err := strconv.Atoi(in); err == nil && n >= 0 {
+		return n, nil
+	}
+
+	d, err := time.ParseDuration(in)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("Invalid run time '%s' (try something like '90m' or '4h')", in)
+	}
+	return int(d.Minutes()), nil
+}
+
 func uuid8(s string) string {
 	if len(s) < 8 {
 		return s
@@ -267,3 +408,221 @@ func wrap(s string, lim uint) string {
 
 	return buf.String()
 }
+
+func blackoutWhen(b *shield.Blackout) string {
+	if b.Schedule != "" {
+		return fmt.Sprintf("%s, for %s", b.Schedule, time.Duration(b.Duration)*time.Minute)
+	}
+	return fmt.Sprintf("from %s until %s", strftime(b.StartsAt), strftime(b.EndsAt))
+}
+
+func updateBlackoutTiming(b *shield.Blackout, schedule, duration, from, until string) {
+	/* switching between recurring and one-off blackouts
+	   clears out the settings of the other kind. */
+	if schedule != "" {
+		b.Schedule = schedule
+		b.StartsAt = 0
+		b.EndsAt = 0
+	}
+	if duration != "" {
+		n, err := parseRuntime(duration)
+		bail(err)
+		b.Duration = n
+	}
+	if from != "" || until != "" {
+		b.Schedule = ""
+		b.Duration = 0
+	}
+	if from != "" {
+		b.StartsAt = strptime(from)
+	}
+	if until != "" {
+		b.EndsAt = strptime(until)
+	}
+}
+
+func spreadWindow(minutes int) string {
+	if minutes <= 0 {
+		return "(none)"
+	}
+	return (time.Duration(minutes) * time.Minute).String()
+}
+
+func approvalPolicy(t *shield.Tenant) string {
+	if !t.RequireApproval {
+		return "not required"
+	}
+	return fmt.Sprintf("required (requests expire after %s)", time.Duration(t.ApprovalTimeout)*time.Minute)
+}
+
+func quotaPolicy(t *shield.Tenant) string {
+	if t.EnforceQuotas {
+		return "enforced"
+	}
+	return "advisory (going over quota only draws a warning)"
+}
+
+func parseQuota(in string) (int, error) {
+	n, err := strconv.Atoi(in)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("Invalid quota '%s' (must be a number; 0 means unlimited)", in)
+	}
+	return n, nil
+}
+
+// awaitingApproval checks if an operation was put on hold until a second
+// person approves it, and if so, says as much.
+func awaitingApproval(err error, asjson bool) bool {
+	p, ok := err.(shield.ApprovalPending)
+	if !ok {
+		return false
+	}
+
+	if asjson {
+		fmt.Printf("%s\n", asJSON(p))
+		return true
+	}
+	fmt.Printf("@Y{%s}\n", p.Message)
+	fmt.Printf("Ask another member of the tenant to run `shield approve %s`\n", p.Approval.UUID)
+	fmt.Printf("before @C{%s}.\n", strftime(p.Approval.ExpiresAt))
+	return true
+}
+
+func showApproval(a *shield.Approval) {
+	r := tui.NewReport()
+	r.Add("UUID", a.UUID)
+	r.Add("Operation", a.Operation)
+	r.Add("Summary", a.Summary)
+	r.Add("Requested By", a.RequestedBy)
+	r.Add("Requested At", strftime(a.RequestedAt))
+	r.Add("Expires At", strftime(a.ExpiresAt))
+	r.Add("Status", a.Status)
+	if a.DecidedBy != "" {
+		r.Add("Decided By", a.DecidedBy)
+		r.Add("Decided At", strftimenil(a.DecidedAt, ""))
+	}
+	if a.TaskUUID != "" {
+		r.Add("Task", a.TaskUUID)
+	}
+	r.Output(os.Stdout)
+}
+
+func nextCalendarDate(c *shield.Calendar) string {
+	today := time.Now().Format("2006-01-02")
+	for _, date := range c.Dates {
+		if date >= today {
+			return date
+		}
+	}
+	return "(none)"
+}
+
+func updateCalendarDates(dates []string, clear bool, add, remove []string) []string {
+	if clear {
+		dates = []string{}
+	}
+
+	gone := make(map[string]bool)
+	for _, date := range remove {
+		gone[date] = true
+	}
+
+	l := []string{}
+	for _, date := range append(dates, add...) {
+		if !gone[date] {
+			l = append(l, date)
+		}
+	}
+	return l
+}
+
+func updateRolePermissions(perms, grant, revoke []string) []string {
+	revoked := make(map[string]bool)
+	for _, perm := range revoke {
+		revoked[perm] = true
+	}
+
+	l := []string{}
+	for _, perm := range append(perms, grant...) {
+		if !revoked[perm] {
+			l = append(l, perm)
+		}
+	}
+	return l
+}
+
+func expectedRuntime(seconds int64) string {
+	if seconds <= 0 {
+		return "(unknown)"
+	}
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+func runNotes(run *shield.ScheduledRun) string {
+	notes := []string{}
+	if run.Blackout != "" {
+		notes = append(notes, fmt.Sprintf("skipped (blackout %s)", run.Blackout))
+	}
+	if run.Jitter > 0 {
+		notes = append(notes, fmt.Sprintf("up to %s late (jitter)", time.Duration(run.Jitter)*time.Minute))
+	}
+	return strings.Join(notes, "; ")
+}
+
+type overlap struct {
+	On    string
+	From  int64
+	Until int64
+	Runs  []*shield.ScheduledRun
+}
+
+func overlappingRuns(runs []*shield.ScheduledRun, by string) []overlap {
+	on := func(run *shield.ScheduledRun) string {
+		if by == "store" {
+			return run.Store.Name
+		}
+		return run.Agent
+	}
+
+	/* runs come back from the SHIELD core in order, so a
+	   single sweep per agent (or store) will find them all;
+	   runs with no history are assumed to take a minute. */
+	current := make(map[string]*overlap)
+	l := []overlap{}
+	flush := func(o *overlap) {
+		if o != nil && len(o.Runs) > 1 {
+			l = append(l, *o)
+		}
+	}
+	for _, run := range runs {
+		if run.Blackout != "" {
+			continue
+		}
+		end := run.At + run.Duration
+		if run.Duration <= 0 {
+			end = run.At + 60
+		}
+
+		key := on(run)
+		if o, ok := current[key]; ok && run.At < o.Until {
+			o.Runs = append(o.Runs, run)
+			if end > o.Until {
+				o.Until = end
+			}
+			continue
+		}
+		flush(current[key])
+		current[key] = &overlap{On: key, From: run.At, Until: end, Runs: []*shield.ScheduledRun{run}}
+	}
+	for _, o := range current {
+		flush(o)
+	}
+
+	sort.Slice(l, func(i, j int) bool {
+		if l[i].From != l[j].From {
+			return l[i].From < l[j].From
+		}
+		return l[i].On < l[j].On
+	})
+	return l
+}
@@ -37,7 +37,7 @@ func (c *Core) authAPI() *route.Router {
 
 			session, err := c.db.CreateSession(&db.Session{
 				UserUUID:  user.UUID,
-				IP:        r.RemoteIP(),
+				IP:        c.clientIP(r),
 				UserAgent: r.UserAgent(),
 			})
 			if err != nil {
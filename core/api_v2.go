@@ -1,8 +1,10 @@
 package core
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -12,9 +14,11 @@ import (
 	"time"
 
 	"github.com/jhunt/go-log"
+	"rsc.io/qr"
 
 	"github.com/shieldproject/shield/core/vault"
 	"github.com/shieldproject/shield/db"
+	"github.com/shieldproject/shield/lib/totp"
 	"github.com/shieldproject/shield/route"
 	"github.com/shieldproject/shield/timespec"
 	"github.com/shieldproject/shield/util"
@@ -90,13 +94,18 @@ type v2LocalUser struct {
 	Name    string `json:"name"`
 	Account string `json:"account"`
 	SysRole string `json:"sysrole"`
+	MFA     bool   `json:"mfa"`
+
+	FailedLogins int   `json:"failed_logins"`
+	LockedUntil  int64 `json:"locked_until,omitempty"`
 
 	Tenants []v2LocalTenant `json:"tenants"`
 }
 
 func (c *Core) v2API() *route.Router {
 	r := &route.Router{
-		Debug: c.Config.Debug,
+		Debug:  c.Config.Debug,
+		Around: c.audited,
 	}
 
 	r.Dispatch("GET /v2/info", func(r *route.Request) { // {{{
@@ -127,7 +136,7 @@ func (c *Core) v2API() *route.Router {
 		}
 		out.SHIELD = c.info
 
-		if user, err := c.db.GetUserForSession(r.SessionID()); err != nil {
+		if user, session, err := c.authenticate(r); err != nil {
 			r.Fail(route.Oops(err, "Unable to retrieve user information"))
 			return
 
@@ -157,6 +166,9 @@ func (c *Core) v2API() *route.Router {
 
 			out.Tenants = make(map[string]Bearing)
 			for _, m := range memberships {
+				if session.Scope.Tenant != "" && session.Scope.Tenant != m.TenantUUID {
+					continue
+				}
 				b, err := c.BearingFor(m)
 				if err != nil {
 					r.Fail(route.Oops(err, "Unable to retrieve user membership information"))
@@ -208,6 +220,8 @@ func (c *Core) v2API() *route.Router {
 			Priority int    `json:"priority"`
 			Position int    `json:"position"`
 			TaskUUID string `json:"task_uuid"`
+			Reason   string `json:"reason,omitempty"`
+			Wait     int    `json:"wait"`
 
 			Op    string `json:"op"`
 			Agent string `json:"agent"`
@@ -236,15 +250,27 @@ func (c *Core) v2API() *route.Router {
 			Archive *archive `json:"archive,omitempty"`
 		}
 
+		type tenantStatus struct {
+			Tenant      named `json:"tenant"`
+			Share       int   `json:"share"`
+			Queued      int   `json:"queued"`
+			Running     int   `json:"running"`
+			LongestWait int   `json:"longest_wait"`
+			AverageWait int   `json:"average_wait"`
+			LastWait    int   `json:"last_wait"`
+		}
+
 		type status struct {
 			Backlog []backlogStatus `json:"backlog"`
 			Workers []workerStatus  `json:"workers"`
+			Tenants []tenantStatus  `json:"tenants"`
 		}
 
 		ps := c.scheduler.Status()
 		out := status{
 			Backlog: make([]backlogStatus, len(ps.Backlog)),
 			Workers: make([]workerStatus, len(ps.Workers)),
+			Tenants: make([]tenantStatus, len(ps.Tenants)),
 		}
 
 		tenants := make(map[string]*db.Tenant)
@@ -257,6 +283,8 @@ func (c *Core) v2API() *route.Router {
 			out.Backlog[i].Priority = x.Priority + 1
 			out.Backlog[i].Position = x.Position
 			out.Backlog[i].TaskUUID = x.TaskUUID
+			out.Backlog[i].Reason = x.Reason
+			out.Backlog[i].Wait = x.Wait
 
 			if task, err := c.db.GetTask(x.TaskUUID); err == nil && task != nil {
 				out.Backlog[i].Op = task.Op
@@ -457,6 +485,33 @@ func (c *Core) v2API() *route.Router {
 			}
 		}
 
+		for i, x := range ps.Tenants {
+			out.Tenants[i].Share = x.Share
+			out.Tenants[i].Queued = x.Queued
+			out.Tenants[i].Running = x.Running
+			out.Tenants[i].LongestWait = x.LongestWait
+			out.Tenants[i].AverageWait = x.AverageWait
+			out.Tenants[i].LastWait = x.LastWait
+
+			/* global and internal tasks aren't charged to any tenant */
+			out.Tenants[i].Tenant.Name = "SYSTEM"
+			if x.TenantUUID != "" {
+				t, found := tenants[x.TenantUUID]
+				if !found {
+					var err error
+					t, err = c.db.GetTenant(x.TenantUUID)
+					if t != nil && err == nil {
+						tenants[t.UUID] = t
+						found = true
+					}
+				}
+				if found {
+					out.Tenants[i].Tenant.UUID = t.UUID
+					out.Tenants[i].Tenant.Name = t.Name
+				}
+			}
+		}
+
 		r.OK(out)
 	})
 	// }}}
@@ -475,11 +530,12 @@ func (c *Core) v2API() *route.Router {
 			return
 		}
 
-		user, err := c.AuthenticatedUser(r)
+		user, session, err := c.authenticate(r)
 		if err != nil {
 			r.Fail(route.Oops(err, "Unable to configure your SHIELD events stream"))
 			return
 		}
+		scope := session.Scope
 
 		queues := []string{
 			"user:" + user.UUID,
@@ -492,10 +548,14 @@ func (c *Core) v2API() *route.Router {
 			return
 		}
 		for _, membership := range memberships {
-			queues = append(queues, "tenant:"+membership.TenantUUID)
+			if scope.Tenant == "" || scope.Tenant == membership.TenantUUID {
+				queues = append(queues, "tenant:"+membership.TenantUUID)
+			}
 		}
 
-		if user.SysRole != "" {
+		/* tokens limited to a tenant (or a role) are limited
+		   to tenant roles, and so only see tenant events */
+		if user.SysRole != "" && !c.mfaWithheld(user) && scope.Tenant == "" && scope.Role == "" {
 			queues = append(queues, "admins")
 		}
 
@@ -639,6 +699,8 @@ func (c *Core) v2API() *route.Router {
 			return
 		}
 
+		/* stop the task in its tracks, if it's already running */
+		c.scheduler.Cancel(task.UUID)
 		if err := c.db.CancelTask(task.UUID, time.Now()); err != nil {
 			r.Fail(route.Oops(err, "Unable to cancel task"))
 			return
@@ -649,7 +711,7 @@ func (c *Core) v2API() *route.Router {
 	// }}}
 
 	r.Dispatch("GET /v2/tenants/:uuid/health", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
 			return
 		}
 		health, err := c.checkTenantHealth(r.Args[1])
@@ -792,6 +854,8 @@ func (c *Core) v2API() *route.Router {
 	// }}}
 
 	r.Dispatch("POST /v2/ui/users", func(r *route.Request) { // {{{
+		r.Unaudited()
+
 		var in struct {
 			Search string `json:"search"`
 		}
@@ -815,6 +879,8 @@ func (c *Core) v2API() *route.Router {
 	})
 	// }}}
 	r.Dispatch("POST /v2/ui/check/timespec", func(r *route.Request) { // {{{
+		r.Unaudited()
+
 		var in struct {
 			Timespec string `json:"timespec"`
 		}
@@ -827,8 +893,28 @@ func (c *Core) v2API() *route.Router {
 			r.Fail(route.Bad(err, fmt.Sprintf("%s", err)))
 			return
 		}
+		if err := c.db.ResolveExclusions(spec); err != nil {
+			r.Fail(route.Bad(err, fmt.Sprintf("%s", err)))
+			return
+		}
+
+		/* preview the next few runs, so that operators can
+		   see what their schedule (and its exclusions) mean */
+		var out struct {
+			OK   string  `json:"ok"`
+			Next []int64 `json:"next"`
+		}
+		out.OK = spec.String()
+		out.Next = make([]int64, 0, 10)
+		for t := time.Now(); len(out.Next) < 10; {
+			if t, err = spec.Next(t); err != nil {
+				r.Fail(route.Bad(err, fmt.Sprintf("%s", err)))
+				return
+			}
+			out.Next = append(out.Next, t.Unix())
+		}
 
-		r.Success("%s", spec)
+		r.OK(out)
 	})
 	// }}}
 
@@ -882,6 +968,12 @@ func (c *Core) v2API() *route.Router {
 
 		users := make([]v2LocalUser, len(l))
 		for i, user := range l {
+			lock, err := c.db.GetLoginLockout(db.AccountLockout, db.AccountLockoutName(user.Account, "local"))
+			if err != nil {
+				r.Fail(route.Oops(err, "Unable to retrieve local users information"))
+				return
+			}
+
 			memberships, err := c.db.GetMembershipsForUser(user.UUID)
 			if err != nil {
 				log.Errorf("failed to retrieve tenant memberships for user %s@%s (uuid %s): %s",
@@ -895,8 +987,15 @@ func (c *Core) v2API() *route.Router {
 				Name:    user.Name,
 				Account: user.Account,
 				SysRole: user.SysRole,
+				MFA:     user.MFAEnabled,
 				Tenants: make([]v2LocalTenant, len(memberships)),
 			}
+			if lock != nil {
+				users[i].FailedLogins = lock.Failures
+				if lock.Locked() {
+					users[i].LockedUntil = lock.LockedUntil
+				}
+			}
 			for j, membership := range memberships {
 				users[i].Tenants[j].UUID = membership.TenantUUID
 				users[i].Tenants[j].Name = membership.TenantName
@@ -931,13 +1030,26 @@ func (c *Core) v2API() *route.Router {
 			return
 		}
 
+		lock, err := c.db.GetLoginLockout(db.AccountLockout, db.AccountLockoutName(user.Account, "local"))
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve local user information"))
+			return
+		}
+
 		local_user := v2LocalUser{
 			UUID:    user.UUID,
 			Name:    user.Name,
 			Account: user.Account,
 			SysRole: user.SysRole,
+			MFA:     user.MFAEnabled,
 			Tenants: make([]v2LocalTenant, len(memberships)),
 		}
+		if lock != nil {
+			local_user.FailedLogins = lock.Failures
+			if lock.Locked() {
+				local_user.LockedUntil = lock.LockedUntil
+			}
+		}
 
 		for j, membership := range memberships {
 			local_user.Tenants[j].UUID = membership.TenantUUID
@@ -1005,6 +1117,7 @@ func (c *Core) v2API() *route.Router {
 			r.Fail(route.Oops(err, "Unable to create local user '%s'", in.Account))
 			return
 		}
+		r.Audit("user", u.UUID, nil, auditedUser{*u, true})
 		r.OK(u)
 	})
 	// }}}
@@ -1031,6 +1144,7 @@ func (c *Core) v2API() *route.Router {
 			r.Fail(route.NotFound(nil, "No such local user"))
 			return
 		}
+		before := auditedUser{*user, false}
 		if in.Name != "" {
 			user.Name = in.Name
 		}
@@ -1057,6 +1171,7 @@ func (c *Core) v2API() *route.Router {
 			r.Fail(route.Oops(err, "Unable to update local user '%s'", user.Account))
 			return
 		}
+		r.Audit("user", user.UUID, before, auditedUser{*user, in.Password != ""})
 
 		r.Success("Updated")
 	})
@@ -1081,10 +1196,91 @@ func (c *Core) v2API() *route.Router {
 			r.Fail(route.Oops(err, "Unable to delete local user '%s' (%s)", r.Args[1], user.Account))
 			return
 		}
+		r.Audit("user", user.UUID, user, nil)
 		r.Success("Successfully deleted local user")
 	})
 	// }}}
 
+	r.Dispatch("POST /v2/auth/local/users/:uuid/unlock", func(r *route.Request) { // {{{
+		if c.IsNotSystemManager(r) {
+			return
+		}
+
+		user, err := c.db.GetUserByID(r.Args[1])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve local user information"))
+			return
+		}
+		if user == nil || user.Backend != "local" {
+			r.Fail(route.NotFound(nil, "Local User '%s' not found", r.Args[1]))
+			return
+		}
+
+		if err := c.db.UnlockLogin(db.AccountLockout, db.AccountLockoutName(user.Account, "local")); err != nil {
+			r.Fail(route.Oops(err, "Unable to unlock local user '%s' (%s)", r.Args[1], user.Account))
+			return
+		}
+		r.Success("Unlocked local user")
+	})
+	// }}}
+	r.Dispatch("DELETE /v2/auth/local/users/:uuid/mfa", func(r *route.Request) { // {{{
+		if c.IsNotSystemManager(r) {
+			return
+		}
+
+		user, err := c.db.GetUserByID(r.Args[1])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve local user information"))
+			return
+		}
+		if user == nil || user.Backend != "local" {
+			r.Fail(route.NotFound(nil, "Local User '%s' not found", r.Args[1]))
+			return
+		}
+
+		before := *user
+		if err := c.db.DisableMFA(user); err != nil {
+			r.Fail(route.Oops(err, "Unable to reset multi-factor authentication for local user '%s' (%s)", r.Args[1], user.Account))
+			return
+		}
+		r.Audit("user", user.UUID, before, user)
+		r.Success("Reset multi-factor authentication for local user")
+	})
+	// }}}
+
+	r.Dispatch("GET /v2/auth/lockouts", func(r *route.Request) { // {{{
+		if c.IsNotSystemManager(r) {
+			return
+		}
+
+		l, err := c.db.GetLoginLockouts()
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve login lockouts"))
+			return
+		}
+
+		r.OK(l)
+	})
+	// }}}
+	r.Dispatch("DELETE /v2/auth/lockouts/:kind/:name", func(r *route.Request) { // {{{
+		if c.IsNotSystemManager(r) {
+			return
+		}
+
+		if r.Args[1] != db.AccountLockout && r.Args[1] != db.IPLockout {
+			r.Fail(route.Bad(nil, "Invalid lockout type '%s' (must be either 'account' or 'ip')", r.Args[1]))
+			return
+		}
+
+		r.Audit("lockout", r.Args[1]+" "+r.Args[2], nil, nil)
+		if err := c.db.UnlockLogin(r.Args[1], r.Args[2]); err != nil {
+			r.Fail(route.Oops(err, "Unable to lift lockout on %s '%s'", r.Args[1], r.Args[2]))
+			return
+		}
+		r.Success("Lockout lifted")
+	})
+	// }}}
+
 	r.Dispatch("GET /v2/auth/tokens", func(r *route.Request) { // {{{
 		if c.IsNotAuthenticated(r) {
 			return
@@ -1107,13 +1303,15 @@ func (c *Core) v2API() *route.Router {
 	})
 	// }}}
 	r.Dispatch("POST /v2/auth/tokens", func(r *route.Request) { // {{{
-		if c.IsNotAuthenticated(r) {
+		if c.IsNotAuthenticated(r) || c.IsScopedToken(r) {
 			return
 		}
 		user, _ := c.AuthenticatedUser(r)
 
 		var in struct {
 			Name string `json:"name"`
+
+			db.TokenScope
 		}
 		if !r.Payload(&in) {
 			return
@@ -1122,6 +1320,52 @@ func (c *Core) v2API() *route.Router {
 			return
 		}
 
+		if err := in.TokenScope.Validate(); err != nil {
+			r.Fail(route.Bad(err, "Invalid token scope: %s", err))
+			return
+		}
+		for _, op := range in.Ops {
+			if _, ok := TokenOps[op]; !ok {
+				r.Fail(route.Bad(nil, "Invalid token scope: unrecognized operation '%s' (valid operations are %s)",
+					op, strings.Join(TokenOpNames(), ", ")))
+				return
+			}
+		}
+		if in.ExpiresAt != 0 && in.ExpiresAt <= time.Now().Unix() {
+			r.Fail(route.Bad(nil, "Invalid token scope: the expiry of a token must be in the future"))
+			return
+		}
+		if in.Tenant != "" {
+			memberships, err := c.db.GetMembershipsForUser(user.UUID)
+			if err != nil {
+				r.Fail(route.Oops(err, "Unable to generate new token"))
+				return
+			}
+
+			member := false
+			for _, m := range memberships {
+				if m.TenantUUID == in.Tenant {
+					member = true
+					break
+				}
+			}
+			if !member {
+				r.Fail(route.Bad(nil, "Invalid token scope: you are not a member of tenant '%s'", in.Tenant))
+				return
+			}
+		}
+		if in.Role != "" {
+			role, err := c.tenantRole(in.Tenant, in.Role)
+			if err != nil {
+				r.Fail(route.Oops(err, "Unable to generate new token"))
+				return
+			}
+			if role == nil {
+				r.Fail(route.Bad(nil, "Invalid token scope: '%s' is not a built-in role, or one of the custom roles of that tenant", in.Role))
+				return
+			}
+		}
+
 		existing, err := c.db.GetAllAuthTokens(&db.AuthTokenFilter{
 			Name: in.Name,
 			User: user,
@@ -1135,17 +1379,22 @@ func (c *Core) v2API() *route.Router {
 			return
 		}
 
-		token, id, err := c.db.GenerateAuthToken(in.Name, user)
+		token, id, err := c.db.GenerateAuthToken(in.Name, user, in.TokenScope)
 		if id == "" || err != nil {
 			r.Fail(route.Oops(err, "Unable to generate new token"))
 			return
 		}
 
+		/* the token's session is its secret; keep it out of the audit log */
+		audited := *token
+		audited.Session = ""
+		r.Audit("token", token.UUID, nil, audited)
+
 		r.OK(token)
 	})
 	// }}}
 	r.Dispatch("DELETE /v2/auth/tokens/:token", func(r *route.Request) { // {{{
-		if c.IsNotAuthenticated(r) {
+		if c.IsNotAuthenticated(r) || c.IsScopedToken(r) {
 			return
 		}
 
@@ -1241,8 +1490,71 @@ func (c *Core) v2API() *route.Router {
 	})
 	// }}}
 
+	r.Dispatch("GET /v2/audit", func(r *route.Request) { // {{{
+		if c.IsNotSystemManager(r) {
+			return
+		}
+
+		limit, err := strconv.Atoi(r.Param("limit", "50"))
+		if err != nil || limit < 0 {
+			r.Fail(route.Bad(err, "Invalid limit parameter given"))
+			return
+		}
+
+		filter := auditFilter(r)
+		if filter == nil {
+			return
+		}
+		filter.Limit = limit
+
+		l, err := c.db.GetAuditLog(filter)
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve the audit log"))
+			return
+		}
+
+		r.OK(l)
+	})
+	// }}}
+	r.Dispatch("GET /v2/audit/export", func(r *route.Request) { // {{{
+		if c.IsNotSystemManager(r) {
+			return
+		}
+
+		filter := auditFilter(r)
+		if filter == nil {
+			return
+		}
+
+		out := r.Stream("application/x-ndjson", map[string]string{
+			"Content-Disposition": fmt.Sprintf("attachment; filename=\"shield-audit-%s.jsonl\"", time.Now().Format("20060102-150405")),
+		})
+		if err := c.db.ExportAuditLog(filter, out); err != nil {
+			/* too late to fail the request; the headers are long gone */
+			log.Errorf("unable to export the audit log: %s", err)
+		}
+	})
+	// }}}
+	r.Dispatch("GET /v2/audit/verify", func(r *route.Request) { // {{{
+		if c.IsNotSystemManager(r) {
+			return
+		}
+
+		v, err := c.db.VerifyAuditLog()
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to verify the audit log"))
+			return
+		}
+		if !v.OK {
+			log.Errorf("audit log verification failed at entry #%d: %s", v.BrokenAt, v.Problem)
+		}
+
+		r.OK(v)
+	})
+	// }}}
+
 	r.Dispatch("GET /v2/tenants/:uuid/systems", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
 			return
 		}
 
@@ -1277,7 +1589,7 @@ func (c *Core) v2API() *route.Router {
 	})
 	// }}}
 	r.Dispatch("GET /v2/tenants/:uuid/systems/:uuid", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
 			return
 		}
 
@@ -1397,7 +1709,7 @@ func (c *Core) v2API() *route.Router {
 	})
 	// }}}
 	r.Dispatch("GET /v2/tenants/:uuid/systems/:uuid/config", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
 			return
 		}
 
@@ -1422,7 +1734,8 @@ func (c *Core) v2API() *route.Router {
 	})
 	// }}}
 	r.Dispatch("POST /v2/tenants/:uuid/systems", func(r *route.Request) { // {{{
-		if c.IsNotTenantEngineer(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermManageTargets) ||
+			c.IsNotPermitted(r, r.Args[1], PermManageJobs) {
 			return
 		}
 
@@ -1469,6 +1782,10 @@ func (c *Core) v2API() *route.Router {
 			r.Fail(route.Oops(err, "Invalid or malformed SHIELD Job Schedule '%s'", in.Job.Schedule))
 			return
 		}
+		if err := c.db.ResolveExclusions(sched); err != nil {
+			r.Fail(route.Bad(err, "Invalid SHIELD Job Schedule '%s': %s", in.Job.Schedule, err))
+			return
+		}
 
 		if in.Job.KeepDays < 0 {
 			r.Fail(route.Oops(nil, "Invalid or malformed SHIELD Job Archive Retention Period '%dd'", in.Job.KeepDays))
@@ -1484,6 +1801,14 @@ func (c *Core) v2API() *route.Router {
 		}
 		in.Job.KeepN = sched.KeepN(in.Job.KeepDays)
 
+		quotas := []string{db.QuotaJobs}
+		if in.Target.UUID == "" {
+			quotas = append(quotas, db.QuotaTargets)
+		}
+		if _, refused := c.checkQuota(r, r.Args[1], quotas...); refused {
+			return
+		}
+
 		if in.Target.Compression == "" {
 			in.Target.Compression = DefaultCompressionType
 		}
@@ -1570,12 +1895,18 @@ func (c *Core) v2API() *route.Router {
 			r.Fail(route.Oops(err, "Unable to create new job"))
 			return
 		}
+		r.Audit("job", job.UUID, nil, job)
+
+		/* make room for the new job in the tenant's spread */
+		if err := c.db.RespreadJobs(job.TenantUUID, time.Now()); err != nil {
+			log.Errorf("error re-scheduling jobs for tenant [%s]: %s", job.TenantUUID, err)
+		}
 
 		r.OK(target)
 	})
 	// }}}
 	r.Dispatch("PATCH /v2/tenants/:uuid/systems/:uuid", func(r *route.Request) { // {{{
-		if c.IsNotTenantEngineer(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermManageTargets) {
 			return
 		}
 
@@ -1642,7 +1973,7 @@ func (c *Core) v2API() *route.Router {
 	})
 	// }}}
 	r.Dispatch("DELETE /v2/tenants/:uuid/systems/:uuid", func(r *route.Request) { // {{{
-		if c.IsNotTenantEngineer(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermManageTargets) {
 			return
 		}
 
@@ -1752,6 +2083,7 @@ func (c *Core) v2API() *route.Router {
 			r.Fail(route.Oops(err, "Unable to delete agent"))
 			return
 		}
+		r.Audit("agent", agent.UUID, agent, nil)
 
 		r.Success("deleted agent %s (at %s)", agent.Name, agent.Address)
 	})
@@ -1783,7 +2115,18 @@ func (c *Core) v2API() *route.Router {
 			return
 		}
 
-		err := c.db.PreRegisterAgent(peer, in.Name, in.Port)
+		/* agents pre-register themselves every so often;
+		   only audit the ones that are new, or have moved */
+		known, err := c.db.GetAllAgents(&db.AgentFilter{Name: in.Name})
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to pre-register agent %s at %s:%d", in.Name, peer, in.Port))
+			return
+		}
+		if len(known) > 0 && known[0].Address == fmt.Sprintf("%s:%d", peer, in.Port) {
+			r.Unaudited()
+		}
+
+		err = c.db.PreRegisterAgent(peer, in.Name, in.Port)
 		if err != nil {
 			r.Fail(route.Oops(err, "Unable to pre-register agent %s at %s:%d", in.Name, peer, in.Port))
 			return
@@ -1806,11 +2149,13 @@ func (c *Core) v2API() *route.Router {
 			return
 		}
 
+		before := *agent
 		agent.Hidden = (r.Args[2] == "hide")
 		if err := c.db.UpdateAgent(agent); err != nil {
 			r.Fail(route.Oops(err, "Unable to set agent visibility"))
 			return
 		}
+		r.Audit("agent", agent.UUID, before, agent)
 
 		if agent.Hidden {
 			r.Success("Agent is now visible only to SHIELD site engineers")
@@ -1885,6 +2230,12 @@ func (c *Core) v2API() *route.Router {
 			return
 		}
 
+		tenant.Quotas, err = c.db.GetTenantQuotas(tenant)
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve tenant quota information"))
+			return
+		}
+
 		r.OK(tenant)
 	})
 	// }}}
@@ -1894,8 +2245,19 @@ func (c *Core) v2API() *route.Router {
 		}
 
 		var in struct {
-			UUID string `json:"uuid"`
-			Name string `json:"name"`
+			UUID            string `json:"uuid"`
+			Name            string `json:"name"`
+			Share           int    `json:"share"`
+			Spread          int    `json:"spread"`
+			RequireApproval bool   `json:"require_approval"`
+			ApprovalTimeout int    `json:"approval_timeout"`
+
+			QuotaStorage  int64 `json:"quota_storage"`
+			QuotaArchives int   `json:"quota_archives"`
+			QuotaJobs     int   `json:"quota_jobs"`
+			QuotaTargets  int   `json:"quota_targets"`
+			QuotaTasks    int   `json:"quota_tasks"`
+			EnforceQuotas bool  `json:"enforce_quotas"`
 
 			Users []struct {
 				UUID    string `json:"uuid"`
@@ -1916,9 +2278,37 @@ func (c *Core) v2API() *route.Router {
 			return
 		}
 
+		if in.Share < 0 {
+			r.Fail(route.Bad(nil, "tenant scheduler share must be a positive number (or 0, for the default share of 1)"))
+			return
+		}
+		if in.Spread < 0 {
+			r.Fail(route.Bad(nil, "tenant spread window must be a positive number of minutes"))
+			return
+		}
+		if in.ApprovalTimeout < 0 {
+			r.Fail(route.Bad(nil, "tenant approval timeout must be a positive number of minutes"))
+			return
+		}
+		if in.QuotaStorage < 0 || in.QuotaArchives < 0 || in.QuotaJobs < 0 || in.QuotaTargets < 0 || in.QuotaTasks < 0 {
+			r.Fail(route.Bad(nil, "tenant quotas cannot be negative (use 0 for unlimited)"))
+			return
+		}
+
 		t, err := c.db.CreateTenant(&db.Tenant{
-			UUID: in.UUID,
-			Name: in.Name,
+			UUID:            in.UUID,
+			Name:            in.Name,
+			Share:           in.Share,
+			Spread:          in.Spread,
+			RequireApproval: in.RequireApproval,
+			ApprovalTimeout: in.ApprovalTimeout,
+
+			QuotaStorage:  in.QuotaStorage,
+			QuotaArchives: in.QuotaArchives,
+			QuotaJobs:     in.QuotaJobs,
+			QuotaTargets:  in.QuotaTargets,
+			QuotaTasks:    in.QuotaTasks,
+			EnforceQuotas: in.EnforceQuotas,
 		})
 		if t == nil || err != nil {
 			r.Fail(route.Oops(err, "Unable to create new tenant '%s'", in.Name))
@@ -1928,17 +2318,12 @@ func (c *Core) v2API() *route.Router {
 		for _, u := range in.Users {
 			user, err := c.db.GetUserByID(u.UUID)
 			if err != nil {
-				r.Fail(route.Oops(err, "Unrecognized user account '%s'", user))
+				r.Fail(route.Oops(err, "Unrecognized user account '%s'", u.UUID))
 				return
 			}
 
 			if user == nil {
-				r.Fail(route.Oops(err, "Unrecognized user account '%s'", user))
-				return
-			}
-
-			if user.Backend != "local" {
-				r.Fail(route.Oops(nil, "Unable to invite '%s@%s' to tenant '%s' - only local users can be invited.", user.Account, user.Backend, t.Name))
+				r.Fail(route.Oops(err, "Unrecognized user account '%s'", u.UUID))
 				return
 			}
 
@@ -1948,6 +2333,8 @@ func (c *Core) v2API() *route.Router {
 				return
 			}
 		}
+		r.Audit("tenant", t.UUID, nil, t)
+		c.RefreshTenantScheduling()
 
 		r.OK(t)
 	})
@@ -1979,19 +2366,31 @@ func (c *Core) v2API() *route.Router {
 		}
 
 		for _, u := range in.Users {
-			user, err := c.db.GetUserByID(u.UUID)
+			role, err := c.tenantRole(tenant.UUID, u.Role)
 			if err != nil {
-				r.Fail(route.Oops(err, "Unrecognized user account '%s'", user))
+				r.Fail(route.Oops(err, "Unable to update tenant memberships information"))
+				return
+			}
+			if role == nil {
+				r.Fail(route.Bad(nil, "Invalid role '%s' (not a built-in role, or one of the custom roles of tenant '%s')", u.Role, tenant.Name))
 				return
 			}
+			if c.cannotGrant(r, tenant.UUID, role.Permissions) {
+				return
+			}
+		}
 
-			if user == nil {
-				r.Fail(route.Oops(err, "Unrecognized user account '%s'", user))
+		before := c.auditedMembers(tenant.UUID)
+		defer func() { r.Audit("tenant", tenant.UUID, before, c.auditedMembers(tenant.UUID)) }()
+		for _, u := range in.Users {
+			user, err := c.db.GetUserByID(u.UUID)
+			if err != nil {
+				r.Fail(route.Oops(err, "Unrecognized user account '%s'", u.UUID))
 				return
 			}
 
-			if user.Backend != "local" {
-				r.Fail(route.Oops(nil, "Unable to invite '%s@%s' to tenant '%s' - only local users can be invited.", user.Account, user.Backend, tenant.Name))
+			if user == nil {
+				r.Fail(route.Oops(err, "Unrecognized user account '%s'", u.UUID))
 				return
 			}
 
@@ -2030,20 +2429,17 @@ func (c *Core) v2API() *route.Router {
 			return
 		}
 
+		before := c.auditedMembers(tenant.UUID)
+		defer func() { r.Audit("tenant", tenant.UUID, before, c.auditedMembers(tenant.UUID)) }()
 		for _, u := range in.Users {
 			user, err := c.db.GetUserByID(u.UUID)
 			if err != nil {
-				r.Fail(route.Oops(err, "Unrecognized user account '%s'", user))
+				r.Fail(route.Oops(err, "Unrecognized user account '%s'", u.UUID))
 				return
 			}
 
 			if user == nil {
-				r.Fail(route.Oops(err, "Unrecognized user account '%s'", user))
-				return
-			}
-
-			if user.Backend != "local" {
-				r.Fail(route.Oops(nil, "Unable to banish '%s@%s' from tenant '%s' - only local users can be banished.", user.Account, user.Backend, tenant.Name))
+				r.Fail(route.Oops(err, "Unrecognized user account '%s'", u.UUID))
 				return
 			}
 
@@ -2057,70 +2453,469 @@ func (c *Core) v2API() *route.Router {
 		r.Success("Banishments served.")
 	})
 	// }}}
-	r.Dispatch("GET /v2/tenants/:uuid", func(r *route.Request) { // {{{
-		if c.IsNotSystemManager(r) {
-			return
-		}
-
-		tenant, err := c.db.GetTenant(r.Args[1])
-		if err != nil {
-			r.Fail(route.Oops(err, "Unable to retrieve tenant information"))
-			return
-		}
-		if tenant == nil {
-			r.Fail(route.NotFound(nil, "No such tenant"))
+	r.Dispatch("GET /v2/tenants/:uuid/roles", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
 			return
 		}
 
-		tenant.Members, err = c.db.GetUsersForTenant(tenant.UUID)
+		roles, err := c.tenantRoles(r.Args[1])
 		if err != nil {
-			r.Fail(route.Oops(err, "Unable to retrieve tenant memberships information"))
+			r.Fail(route.Oops(err, "Unable to retrieve tenant roles information"))
 			return
 		}
 
-		r.OK(tenant)
+		r.OK(roles)
 	})
 	// }}}
-	r.Dispatch("PATCH /v2/tenants/:uuid", func(r *route.Request) { // {{{
-		if c.IsNotSystemManager(r) {
+	r.Dispatch("POST /v2/tenants/:uuid/roles", func(r *route.Request) { // {{{
+		if !c.CanManageTenants(r, r.Args[1]) || !c.hasTenant(true, r, r.Args[1]) {
 			return
 		}
 
-		var in struct {
-			Name string `json:"name"`
-		}
+		var in db.Role
 		if !r.Payload(&in) {
 			return
 		}
-
 		if r.Missing("name", in.Name) {
 			return
 		}
 
-		tenant, err := c.db.GetTenant(r.Args[1])
-		if err != nil {
-			r.Fail(route.Oops(err, "Unable to retrieve tenant information"))
+		if builtinTenantRole(in.Name) != nil {
+			r.Fail(route.Bad(nil, "Role name '%s' is reserved for the built-in role", in.Name))
 			return
 		}
-		if tenant == nil {
-			r.Fail(route.NotFound(err, "No such tenant"))
+		if err := validPermissions(in.Permissions); err != nil {
+			r.Fail(route.Bad(err, "Invalid role: %s (valid permissions are %s)", err, strings.Join(TenantPermissionNames(), ", ")))
 			return
 		}
-
-		if in.Name != "" {
-			tenant.Name = in.Name
+		if c.cannotGrant(r, r.Args[1], in.Permissions) {
+			return
 		}
 
-		t, err := c.db.UpdateTenant(tenant)
-		if err != nil {
-			r.Fail(route.Oops(err, "Unable to update tenant '%s'", in.Name))
+		role, err := c.db.CreateRole(&db.Role{
+			TenantUUID:  r.Args[1],
+			Name:        in.Name,
+			Summary:     in.Summary,
+			Permissions: in.Permissions,
+		})
+		if role == nil || err != nil {
+			r.Fail(route.Oops(err, "Unable to create role '%s'", in.Name))
 			return
 		}
-		r.OK(t)
+		r.Audit("role", role.Name, nil, role)
+
+		r.OK(role)
 	})
 	// }}}
-	r.Dispatch("DELETE /v2/tenants/:uuid", func(r *route.Request) { // {{{
-		if c.IsNotSystemManager(r) {
+	r.Dispatch("PUT /v2/tenants/:uuid/roles/:name", func(r *route.Request) { // {{{
+		if !c.CanManageTenants(r, r.Args[1]) {
+			return
+		}
+
+		role, err := c.db.GetRole(r.Args[1], r.Args[2])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve role information"))
+			return
+		}
+		if role == nil {
+			if builtinTenantRole(r.Args[2]) != nil {
+				r.Fail(route.Bad(nil, "Built-in roles cannot be changed"))
+				return
+			}
+			r.Fail(route.NotFound(nil, "No such role"))
+			return
+		}
+		before := *role
+
+		var in struct {
+			Summary     *string  `json:"summary"`
+			Permissions []string `json:"permissions"`
+		}
+		if !r.Payload(&in) {
+			return
+		}
+
+		if in.Summary != nil {
+			role.Summary = *in.Summary
+		}
+		if in.Permissions != nil {
+			if err := validPermissions(in.Permissions); err != nil {
+				r.Fail(route.Bad(err, "Invalid role: %s (valid permissions are %s)", err, strings.Join(TenantPermissionNames(), ", ")))
+				return
+			}
+			if c.cannotGrant(r, r.Args[1], in.Permissions) {
+				return
+			}
+			role.Permissions = in.Permissions
+		}
+
+		if err := c.db.UpdateRole(role); err != nil {
+			r.Fail(route.Oops(err, "Unable to update role '%s'", role.Name))
+			return
+		}
+		r.Audit("role", role.Name, before, role)
+
+		r.OK(role)
+	})
+	// }}}
+	r.Dispatch("DELETE /v2/tenants/:uuid/roles/:name", func(r *route.Request) { // {{{
+		if !c.CanManageTenants(r, r.Args[1]) {
+			return
+		}
+
+		role, err := c.db.GetRole(r.Args[1], r.Args[2])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve role information"))
+			return
+		}
+		if role == nil {
+			if builtinTenantRole(r.Args[2]) != nil {
+				r.Fail(route.Bad(nil, "Built-in roles cannot be deleted"))
+				return
+			}
+			r.Fail(route.NotFound(nil, "No such role"))
+			return
+		}
+
+		if err := c.db.DeleteRole(role.TenantUUID, role.Name); err != nil {
+			r.Fail(route.Bad(err, "Unable to delete role '%s': %s", role.Name, err))
+			return
+		}
+		r.Audit("role", role.Name, role, nil)
+
+		r.Success("Role deleted successfully")
+	})
+	// }}}
+	r.Dispatch("GET /v2/tenants/:uuid/audit", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermAudit) {
+			return
+		}
+
+		limit, err := strconv.Atoi(r.Param("limit", "50"))
+		if err != nil || limit < 0 {
+			r.Fail(route.Bad(err, "Invalid limit parameter given"))
+			return
+		}
+
+		filter := auditFilter(r)
+		if filter == nil {
+			return
+		}
+		filter.ForTenant = r.Args[1]
+		filter.Limit = limit
+
+		l, err := c.db.GetAuditLog(filter)
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve the audit log"))
+			return
+		}
+
+		r.OK(l)
+	})
+	// }}}
+	r.Dispatch("GET /v2/tenants/:uuid/approvals", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
+			return
+		}
+
+		limit, err := strconv.Atoi(r.Param("limit", "0"))
+		if err != nil || limit < 0 {
+			r.Fail(route.Bad(err, "Invalid limit parameter given"))
+			return
+		}
+
+		l, err := c.db.GetAllApprovals(&db.ApprovalFilter{
+			ForTenant: r.Args[1],
+			ForStatus: r.Param("status", ""),
+			Limit:     limit,
+		})
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve approval requests information"))
+			return
+		}
+
+		r.OK(l)
+	})
+	// }}}
+	r.Dispatch("GET /v2/tenants/:uuid/approvals/:uuid", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
+			return
+		}
+
+		a, err := c.db.GetApproval(r.Args[2])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve approval request information"))
+			return
+		}
+		if a == nil || a.TenantUUID != r.Args[1] {
+			r.Fail(route.NotFound(nil, "No such approval request"))
+			return
+		}
+
+		r.OK(a)
+	})
+	// }}}
+	r.Dispatch("POST /v2/tenants/:uuid/approvals/:uuid/approve", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
+			return
+		}
+
+		a, err := c.db.GetApproval(r.Args[2])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve approval request information"))
+			return
+		}
+		if a == nil || a.TenantUUID != r.Args[1] {
+			r.Fail(route.NotFound(nil, "No such approval request"))
+			return
+		}
+
+		/* approvers must be able to do what they are approving */
+		if c.IsNotPermitted(r, r.Args[1], approvalPermissions[a.Operation]) {
+			return
+		}
+
+		user, _ := c.AuthenticatedUser(r)
+		if user.UUID == a.RequesterUUID {
+			r.Fail(route.Forbidden(nil, "You cannot approve your own request"))
+			return
+		}
+
+		before := *a
+		ok, err := c.db.DecideApproval(a.UUID, "approved", fmt.Sprintf("%s@%s", user.Account, user.Backend))
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to approve request"))
+			return
+		}
+		if !ok {
+			if a.Status == "pending" {
+				a.Status = "expired"
+			}
+			r.Fail(route.Bad(nil, "This request can no longer be approved (it is %s)", a.Status))
+			return
+		}
+
+		task, problem := c.carryOut(a)
+		taskUUID := ""
+		if task != nil {
+			taskUUID = task.UUID
+		}
+		if err := c.db.FinishApproval(a.UUID, taskUUID, problem != nil); err != nil {
+			log.Errorf("unable to record the outcome of approval request %s: %s", a.UUID, err)
+		}
+
+		a, err = c.db.GetApproval(a.UUID)
+		if err != nil || a == nil {
+			r.Fail(route.Oops(err, "Unable to retrieve approval request information"))
+			return
+		}
+		r.Audit("approval", a.UUID, before, a)
+
+		if problem != nil {
+			r.Fail(route.Bad(problem, "The request was approved, but could not be carried out: %s", problem))
+			return
+		}
+		r.OK(a)
+	})
+	// }}}
+	r.Dispatch("POST /v2/tenants/:uuid/approvals/:uuid/reject", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
+			return
+		}
+
+		a, err := c.db.GetApproval(r.Args[2])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve approval request information"))
+			return
+		}
+		if a == nil || a.TenantUUID != r.Args[1] {
+			r.Fail(route.NotFound(nil, "No such approval request"))
+			return
+		}
+
+		/* requesters can always withdraw their own requests */
+		user, _ := c.AuthenticatedUser(r)
+		if user == nil || user.UUID != a.RequesterUUID {
+			if c.IsNotPermitted(r, r.Args[1], approvalPermissions[a.Operation]) {
+				return
+			}
+			user, _ = c.AuthenticatedUser(r)
+		}
+
+		before := *a
+		ok, err := c.db.DecideApproval(a.UUID, "rejected", fmt.Sprintf("%s@%s", user.Account, user.Backend))
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to reject request"))
+			return
+		}
+		if !ok {
+			if a.Status == "pending" {
+				a.Status = "expired"
+			}
+			r.Fail(route.Bad(nil, "This request can no longer be rejected (it is %s)", a.Status))
+			return
+		}
+
+		a, err = c.db.GetApproval(a.UUID)
+		if err != nil || a == nil {
+			r.Fail(route.Oops(err, "Unable to retrieve approval request information"))
+			return
+		}
+		r.Audit("approval", a.UUID, before, a)
+
+		r.OK(a)
+	})
+	// }}}
+	r.Dispatch("GET /v2/tenants/:uuid", func(r *route.Request) { // {{{
+		if c.IsNotSystemManager(r) {
+			return
+		}
+
+		tenant, err := c.db.GetTenant(r.Args[1])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve tenant information"))
+			return
+		}
+		if tenant == nil {
+			r.Fail(route.NotFound(nil, "No such tenant"))
+			return
+		}
+
+		tenant.Members, err = c.db.GetUsersForTenant(tenant.UUID)
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve tenant memberships information"))
+			return
+		}
+
+		tenant.Quotas, err = c.db.GetTenantQuotas(tenant)
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve tenant quota information"))
+			return
+		}
+
+		r.OK(tenant)
+	})
+	// }}}
+	r.Dispatch("PATCH /v2/tenants/:uuid", func(r *route.Request) { // {{{
+		if c.IsNotSystemManager(r) {
+			return
+		}
+
+		var in struct {
+			Name            string `json:"name"`
+			Share           int    `json:"share"`
+			Spread          *int   `json:"spread"`
+			RequireApproval *bool  `json:"require_approval"`
+			ApprovalTimeout int    `json:"approval_timeout"`
+
+			QuotaStorage  *int64 `json:"quota_storage"`
+			QuotaArchives *int   `json:"quota_archives"`
+			QuotaJobs     *int   `json:"quota_jobs"`
+			QuotaTargets  *int   `json:"quota_targets"`
+			QuotaTasks    *int   `json:"quota_tasks"`
+			EnforceQuotas *bool  `json:"enforce_quotas"`
+		}
+		if !r.Payload(&in) {
+			return
+		}
+
+		if r.Missing("name", in.Name) {
+			return
+		}
+
+		if in.Share < 0 {
+			r.Fail(route.Bad(nil, "tenant scheduler share must be a positive number (or 0, to leave it as it is)"))
+			return
+		}
+		if in.Spread != nil && *in.Spread < 0 {
+			r.Fail(route.Bad(nil, "tenant spread window must be a positive number of minutes"))
+			return
+		}
+		if in.ApprovalTimeout < 0 {
+			r.Fail(route.Bad(nil, "tenant approval timeout must be a positive number of minutes"))
+			return
+		}
+		for _, n := range []*int{in.QuotaArchives, in.QuotaJobs, in.QuotaTargets, in.QuotaTasks} {
+			if n != nil && *n < 0 {
+				r.Fail(route.Bad(nil, "tenant quotas cannot be negative (use 0 for unlimited)"))
+				return
+			}
+		}
+		if in.QuotaStorage != nil && *in.QuotaStorage < 0 {
+			r.Fail(route.Bad(nil, "tenant quotas cannot be negative (use 0 for unlimited)"))
+			return
+		}
+
+		tenant, err := c.db.GetTenant(r.Args[1])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve tenant information"))
+			return
+		}
+		if tenant == nil {
+			r.Fail(route.NotFound(err, "No such tenant"))
+			return
+		}
+		before := *tenant
+
+		if in.Name != "" {
+			tenant.Name = in.Name
+		}
+		if in.Share > 0 {
+			tenant.Share = in.Share
+		}
+		respread := in.Spread != nil && *in.Spread != tenant.Spread
+		if in.Spread != nil {
+			tenant.Spread = *in.Spread
+		}
+		if in.RequireApproval != nil {
+			tenant.RequireApproval = *in.RequireApproval
+		}
+		if in.ApprovalTimeout > 0 {
+			tenant.ApprovalTimeout = in.ApprovalTimeout
+		}
+		if in.QuotaStorage != nil {
+			tenant.QuotaStorage = *in.QuotaStorage
+		}
+		if in.QuotaArchives != nil {
+			tenant.QuotaArchives = *in.QuotaArchives
+		}
+		if in.QuotaJobs != nil {
+			tenant.QuotaJobs = *in.QuotaJobs
+		}
+		if in.QuotaTargets != nil {
+			tenant.QuotaTargets = *in.QuotaTargets
+		}
+		if in.QuotaTasks != nil {
+			tenant.QuotaTasks = *in.QuotaTasks
+		}
+		if in.EnforceQuotas != nil {
+			tenant.EnforceQuotas = *in.EnforceQuotas
+		}
+
+		t, err := c.db.UpdateTenant(tenant)
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to update tenant '%s'", in.Name))
+			return
+		}
+		r.Audit("tenant", tenant.UUID, before, t)
+		c.RefreshTenantScheduling()
+
+		if respread {
+			/* move the tenant's jobs into (or out of) their new slots */
+			jobs, err := c.db.GetAllJobs(&db.JobFilter{ForTenant: tenant.UUID})
+			if err != nil {
+				r.Fail(route.Oops(err, "Unable to reschedule tenant jobs"))
+				return
+			}
+			if err := c.db.ScheduleJobs(jobs, time.Now()); err != nil {
+				log.Errorf("error re-scheduling jobs for tenant %s [%s]: %s", tenant.Name, tenant.UUID, err)
+			}
+		}
+		r.OK(t)
+	})
+	// }}}
+	r.Dispatch("DELETE /v2/tenants/:uuid", func(r *route.Request) { // {{{
+		if c.IsNotSystemManager(r) {
 			return
 		}
 
@@ -2139,13 +2934,15 @@ func (c *Core) v2API() *route.Router {
 			r.Fail(route.Oops(err, "Unable to delete tenant '%s' (%s)", r.Args[1], tenant.Name))
 			return
 		}
+		c.RefreshTenantScheduling()
+		r.Audit("tenant", tenant.UUID, tenant, nil)
 
 		r.Success("Successfully deleted tenant '%s' (%s)", r.Args[1], tenant.Name)
 	})
 	// }}}
 
 	r.Dispatch("GET /v2/tenants/:uuid/agents", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
 			return
 		}
 
@@ -2161,7 +2958,7 @@ func (c *Core) v2API() *route.Router {
 	})
 	// }}}
 	r.Dispatch("GET /v2/tenants/:uuid/agents/:uuid", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
 			return
 		}
 
@@ -2194,7 +2991,7 @@ func (c *Core) v2API() *route.Router {
 	// }}}
 
 	r.Dispatch("GET /v2/tenants/:uuid/targets", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
 			return
 		}
 
@@ -2220,7 +3017,7 @@ func (c *Core) v2API() *route.Router {
 	})
 	// }}}
 	r.Dispatch("POST /v2/tenants/:uuid/targets", func(r *route.Request) { // {{{
-		if c.IsNotTenantEngineer(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermManageTargets) {
 			return
 		}
 
@@ -2281,6 +3078,10 @@ func (c *Core) v2API() *route.Router {
 			return
 		}
 
+		if _, refused := c.checkQuota(r, r.Args[1], db.QuotaTargets); refused {
+			return
+		}
+
 		target, err := c.db.CreateTarget(&db.Target{
 			TenantUUID:  r.Args[1],
 			Name:        in.Name,
@@ -2295,12 +3096,13 @@ func (c *Core) v2API() *route.Router {
 			r.Fail(route.Oops(err, "Unable to create new data target"))
 			return
 		}
+		r.Audit("target", target.UUID, nil, target)
 
 		r.OK(target)
 	})
 	// }}}
 	r.Dispatch("GET /v2/tenants/:uuid/targets/:uuid", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
 			return
 		}
 
@@ -2319,7 +3121,7 @@ func (c *Core) v2API() *route.Router {
 	})
 	// }}}
 	r.Dispatch("PUT /v2/tenants/:uuid/targets/:uuid", func(r *route.Request) { // {{{
-		if c.IsNotTenantEngineer(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermManageTargets) {
 			return
 		}
 
@@ -2355,6 +3157,7 @@ func (c *Core) v2API() *route.Router {
 			in.Endpoint = string(b)
 		}
 
+		before := *target
 		if in.Name != "" {
 			target.Name = in.Name
 		}
@@ -2382,12 +3185,13 @@ func (c *Core) v2API() *route.Router {
 			r.Fail(route.Oops(err, "Unable to update target"))
 			return
 		}
+		r.Audit("target", target.UUID, before, target)
 
 		r.Success("Updated target successfully")
 	})
 	// }}}
 	r.Dispatch("DELETE /v2/tenants/:uuid/targets/:uuid", func(r *route.Request) { // {{{
-		if c.IsNotTenantEngineer(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermManageTargets) {
 			return
 		}
 
@@ -2402,22 +3206,135 @@ func (c *Core) v2API() *route.Router {
 			return
 		}
 
-		deleted, err := c.db.DeleteTarget(target.UUID)
-		if err != nil {
-			r.Fail(route.Oops(err, "Unable to delete target"))
-			return
+		if c.awaitApproval(r, &db.Approval{
+			TenantUUID: r.Args[1],
+			Operation:  db.ApproveDeleteTarget,
+			TargetUUID: target.UUID,
+			Summary:    fmt.Sprintf("Delete target %s", target.Name),
+		}) {
+			return
+		}
+
+		deleted, err := c.db.DeleteTarget(target.UUID)
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to delete target"))
+			return
 		}
 		if !deleted {
 			r.Fail(route.Forbidden(nil, "The target cannot be deleted at this time"))
 			return
 		}
+		r.Audit("target", target.UUID, target, nil)
 
 		r.Success("Target deleted successfully")
 	})
 	// }}}
+	r.Dispatch("GET /v2/tenants/:uuid/targets/:uuid/restore", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermRestore) {
+			return
+		}
+
+		asOf, err := strconv.ParseInt(r.Param("as_of", ""), 10, 64)
+		if err != nil || asOf <= 0 {
+			r.Fail(route.Bad(err, "Invalid or missing as_of parameter given"))
+			return
+		}
+
+		target, err := c.db.GetTarget(r.Args[2])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve target information"))
+			return
+		}
+
+		if target == nil || target.TenantUUID != r.Args[1] {
+			r.Fail(route.NotFound(nil, "No such target"))
+			return
+		}
+
+		archive, err := c.db.GetArchiveAsOf(target.UUID, time.Unix(asOf, 0))
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve backup archive information"))
+			return
+		}
+		if archive == nil {
+			r.Fail(route.NotFound(nil, "No valid backup archive of this target was taken at or before the given time"))
+			return
+		}
+
+		r.OK(archive)
+	})
+	// }}}
+	r.Dispatch("POST /v2/tenants/:uuid/targets/:uuid/restore", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermRestore) {
+			return
+		}
+
+		var in struct {
+			AsOf    int64  `json:"as_of"`
+			Archive string `json:"archive"`
+		}
+		if !r.Payload(&in) {
+			return
+		}
+
+		if in.AsOf <= 0 {
+			r.Fail(route.Bad(nil, "Invalid or missing as_of value given"))
+			return
+		}
+
+		target, err := c.db.GetTarget(r.Args[2])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve target information"))
+			return
+		}
+
+		if target == nil || target.TenantUUID != r.Args[1] {
+			r.Fail(route.NotFound(nil, "No such target"))
+			return
+		}
+
+		archive, err := c.db.GetArchiveAsOf(target.UUID, time.Unix(in.AsOf, 0))
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve backup archive information"))
+			return
+		}
+		if archive == nil {
+			r.Fail(route.NotFound(nil, "No valid backup archive of this target was taken at or before the given time"))
+			return
+		}
+
+		/* callers who previewed the restore can make sure
+		   that what they saw is still what they will get */
+		if in.Archive != "" && in.Archive != archive.UUID {
+			r.Fail(route.Bad(nil, "The backup archive to restore has changed since it was previewed (it is now %s)", archive.UUID))
+			return
+		}
+
+		if c.awaitApproval(r, &db.Approval{
+			TenantUUID:  r.Args[1],
+			Operation:   db.ApproveRestore,
+			ArchiveUUID: archive.UUID,
+			TargetUUID:  target.UUID,
+			Summary:     fmt.Sprintf("Restore target %s, as of %s", target.Name, time.Unix(archive.TakenAt, 0).Format(time.RFC3339)),
+		}) {
+			return
+		}
+
+		user, _ := c.AuthenticatedUser(r)
+		task, err := c.db.CreateRestoreTask(fmt.Sprintf("%s@%s", user.Account, user.Backend), archive, target)
+		if task == nil || err != nil {
+			r.Fail(route.Oops(err, "Unable to schedule a restore task"))
+			return
+		}
+		if !c.CanSeeCredentials(r, r.Args[1]) {
+			c.db.RedactTaskLog(task)
+		}
+		r.OK(task)
+	})
+	// }}}
 
 	r.Dispatch("GET /v2/tenants/:uuid/stores", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
 			return
 		}
 
@@ -2443,7 +3360,7 @@ func (c *Core) v2API() *route.Router {
 	})
 	// }}}
 	r.Dispatch("GET /v2/tenants/:uuid/stores/:uuid", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
 			return
 		}
 
@@ -2462,7 +3379,7 @@ func (c *Core) v2API() *route.Router {
 	})
 	// }}}""
 	r.Dispatch("GET /v2/tenants/:uuid/stores/:uuid/config", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
 			return
 		}
 
@@ -2487,7 +3404,7 @@ func (c *Core) v2API() *route.Router {
 	})
 	// }}}""
 	r.Dispatch("POST /v2/tenants/:uuid/stores", func(r *route.Request) { // {{{
-		if c.IsNotTenantEngineer(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermManageStores) {
 			return
 		}
 
@@ -2534,6 +3451,7 @@ func (c *Core) v2API() *route.Router {
 			r.Fail(route.Oops(err, "Unable to create new storage system"))
 			return
 		}
+		r.Audit("store", store.UUID, nil, store)
 
 		if _, err := c.db.CreateTestStoreTask("system", store); err != nil {
 			log.Errorf("failed to schedule storage test task (non-critical) for %s (%s): %s",
@@ -2544,7 +3462,7 @@ func (c *Core) v2API() *route.Router {
 	})
 	// }}}
 	r.Dispatch("PUT /v2/tenants/:uuid/stores/:uuid", func(r *route.Request) { // {{{
-		if c.IsNotTenantEngineer(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermManageStores) {
 			return
 		}
 
@@ -2572,6 +3490,7 @@ func (c *Core) v2API() *route.Router {
 			return
 		}
 
+		before := *store
 		if in.Name != "" {
 			store.Name = in.Name
 		}
@@ -2602,6 +3521,7 @@ func (c *Core) v2API() *route.Router {
 			r.Fail(route.Oops(err, "Unable to retrieve storage system information"))
 			return
 		}
+		r.Audit("store", store.UUID, before, store)
 
 		if _, err := c.db.CreateTestStoreTask("system", store); err != nil {
 			log.Errorf("failed to schedule storage test task (non-critical) for %s (%s): %s",
@@ -2612,7 +3532,7 @@ func (c *Core) v2API() *route.Router {
 	})
 	// }}}
 	r.Dispatch("DELETE /v2/tenants/:uuid/stores/:uuid", func(r *route.Request) { // {{{
-		if c.IsNotTenantEngineer(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermManageStores) {
 			return
 		}
 
@@ -2635,13 +3555,14 @@ func (c *Core) v2API() *route.Router {
 			r.Fail(route.Bad(nil, "The storage system cannot be deleted at this time"))
 			return
 		}
+		r.Audit("store", store.UUID, store, nil)
 
 		r.Success("Storage system deleted successfully")
 	})
 	// }}}
 
 	r.Dispatch("GET /v2/tenants/:uuid/jobs", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
 			return
 		}
 
@@ -2668,7 +3589,7 @@ func (c *Core) v2API() *route.Router {
 	})
 	// }}}
 	r.Dispatch("POST /v2/tenants/:uuid/jobs", func(r *route.Request) { // {{{
-		if c.IsNotTenantEngineer(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermManageJobs) {
 			return
 		}
 
@@ -2682,6 +3603,14 @@ func (c *Core) v2API() *route.Router {
 			Retain   string `json:"retain"`
 			FixedKey bool   `json:"fixed_key"`
 			Retries  int    `json:"retries"`
+
+			MaxRuntime  int    `json:"max_runtime"`
+			WindowStart string `json:"window_start"`
+			WindowEnd   string `json:"window_end"`
+			FinishBy    string `json:"finish_by"`
+
+			Jitter       int  `json:"jitter"`
+			HashedJitter bool `json:"hashed_jitter"`
 		}
 		if !r.Payload(&in) {
 			return
@@ -2691,11 +3620,28 @@ func (c *Core) v2API() *route.Router {
 			return
 		}
 
+		if in.Jitter < 0 {
+			r.Fail(route.Bad(nil, "Invalid SHIELD Job Jitter '%d' (must be a positive number of minutes)", in.Jitter))
+			return
+		}
+		if in.MaxRuntime < 0 {
+			r.Fail(route.Bad(nil, "Invalid SHIELD Job Maximum Run Time '%d' (must be a positive number of minutes)", in.MaxRuntime))
+			return
+		}
+		if _, err := timespec.ParseWindow(in.WindowStart, in.WindowEnd, in.FinishBy); err != nil {
+			r.Fail(route.Bad(err, "Invalid SHIELD Job Backup Window: %s", err))
+			return
+		}
+
 		sched, err := timespec.Parse(in.Schedule)
 		if err != nil {
 			r.Fail(route.Oops(err, "Invalid or malformed SHIELD Job Schedule '%s'", in.Schedule))
 			return
 		}
+		if err := c.db.ResolveExclusions(sched); err != nil {
+			r.Fail(route.Bad(err, "Invalid SHIELD Job Schedule '%s': %s", in.Schedule, err))
+			return
+		}
 
 		keepdays := util.ParseRetain(in.Retain)
 		if keepdays < 0 {
@@ -2714,6 +3660,10 @@ func (c *Core) v2API() *route.Router {
 
 		//retries := util.ParseRetain(in.Retries)
 
+		if _, refused := c.checkQuota(r, r.Args[1], db.QuotaJobs); refused {
+			return
+		}
+
 		job, err := c.db.CreateJob(&db.Job{
 			TenantUUID: r.Args[1],
 			Name:       in.Name,
@@ -2726,17 +3676,31 @@ func (c *Core) v2API() *route.Router {
 			TargetUUID: in.Target,
 			FixedKey:   in.FixedKey,
 			Retries:    in.Retries,
+
+			MaxRuntime:  in.MaxRuntime,
+			WindowStart: in.WindowStart,
+			WindowEnd:   in.WindowEnd,
+			FinishBy:    in.FinishBy,
+
+			Jitter:       in.Jitter,
+			HashedJitter: in.HashedJitter,
 		})
 		if job == nil || err != nil {
 			r.Fail(route.Oops(err, "Unable to create new job"))
 			return
 		}
+		r.Audit("job", job.UUID, nil, job)
+
+		/* make room for the new job in the tenant's spread */
+		if err := c.db.RespreadJobs(job.TenantUUID, time.Now()); err != nil {
+			log.Errorf("error re-scheduling jobs for tenant [%s]: %s", job.TenantUUID, err)
+		}
 
 		r.OK(job)
 	})
 	// }}}
 	r.Dispatch("GET /v2/tenants/:uuid/jobs/:uuid", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
 			return
 		}
 
@@ -2755,7 +3719,7 @@ func (c *Core) v2API() *route.Router {
 	})
 	// }}}
 	r.Dispatch("PUT /v2/tenants/:uuid/jobs/:uuid", func(r *route.Request) { // {{{
-		if c.IsNotTenantEngineer(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermManageJobs) {
 			return
 		}
 
@@ -2769,6 +3733,14 @@ func (c *Core) v2API() *route.Router {
 			TargetUUID string `json:"target"`
 			FixedKey   *bool  `json:"fixed_key"`
 			Retries    int    `json:"retries"`
+
+			MaxRuntime  *int    `json:"max_runtime"`
+			WindowStart *string `json:"window_start"`
+			WindowEnd   *string `json:"window_end"`
+			FinishBy    *string `json:"finish_by"`
+
+			Jitter       *int  `json:"jitter"`
+			HashedJitter *bool `json:"hashed_jitter"`
 		}
 		if !r.Payload(&in) {
 			return
@@ -2784,6 +3756,7 @@ func (c *Core) v2API() *route.Router {
 			return
 		}
 
+		before := *job
 		if in.Name != "" {
 			job.Name = in.Name
 		}
@@ -2791,10 +3764,15 @@ func (c *Core) v2API() *route.Router {
 			job.Summary = in.Summary
 		}
 		if in.Schedule != "" {
-			if _, err := timespec.Parse(in.Schedule); err != nil {
+			sched, err := timespec.Parse(in.Schedule)
+			if err != nil {
 				r.Fail(route.Oops(err, "Invalid or malformed SHIELD Job Schedule '%s'", in.Schedule))
 				return
 			}
+			if err := c.db.ResolveExclusions(sched); err != nil {
+				r.Fail(route.Bad(err, "Invalid SHIELD Job Schedule '%s': %s", in.Schedule, err))
+				return
+			}
 			job.Schedule = in.Schedule
 		}
 		if in.Retain != "" {
@@ -2814,7 +3792,7 @@ func (c *Core) v2API() *route.Router {
 
 			job.KeepDays = keepdays
 			job.KeepN = -1
-			if sched, err := timespec.Parse(job.Schedule); err == nil {
+			if sched, err := timespec.Parse(job.Schedule); err == nil && c.db.ResolveExclusions(sched) == nil {
 				job.KeepN = sched.KeepN(job.KeepDays)
 			}
 		}
@@ -2833,24 +3811,57 @@ func (c *Core) v2API() *route.Router {
 		if in.Retries >= 0 {
 			job.Retries = in.Retries
 		}
+		if in.MaxRuntime != nil {
+			if *in.MaxRuntime < 0 {
+				r.Fail(route.Bad(nil, "Invalid SHIELD Job Maximum Run Time '%d' (must be a positive number of minutes)", *in.MaxRuntime))
+				return
+			}
+			job.MaxRuntime = *in.MaxRuntime
+		}
+		if in.WindowStart != nil {
+			job.WindowStart = *in.WindowStart
+		}
+		if in.WindowEnd != nil {
+			job.WindowEnd = *in.WindowEnd
+		}
+		if in.FinishBy != nil {
+			job.FinishBy = *in.FinishBy
+		}
+		if _, err := job.Window(); err != nil {
+			r.Fail(route.Bad(err, "Invalid SHIELD Job Backup Window: %s", err))
+			return
+		}
+		if in.Jitter != nil {
+			if *in.Jitter < 0 {
+				r.Fail(route.Bad(nil, "Invalid SHIELD Job Jitter '%d' (must be a positive number of minutes)", *in.Jitter))
+				return
+			}
+			job.Jitter = *in.Jitter
+		}
+		if in.HashedJitter != nil {
+			job.HashedJitter = *in.HashedJitter
+		}
 		if err := c.db.UpdateJob(job); err != nil {
 			r.Fail(route.Oops(err, "Unable to update job"))
 			return
 		}
+		r.Audit("job", job.UUID, before, job)
 
 		if in.Schedule != "" {
-			if spec, err := timespec.Parse(in.Schedule); err == nil {
-				if next, err := spec.Next(time.Now()); err == nil {
-					c.db.RescheduleJob(job, next)
-				}
+			/* the job has left one set of peers, and joined another */
+			if err := c.db.RespreadJobs(job.TenantUUID, time.Now()); err != nil {
+				log.Errorf("error re-scheduling jobs for tenant [%s]: %s", job.TenantUUID, err)
 			}
 		}
+		if in.Schedule != "" || in.Jitter != nil || in.HashedJitter != nil {
+			c.db.ScheduleJob(job, time.Now())
+		}
 
 		r.Success("Updated job successfully")
 	})
 	// }}}
 	r.Dispatch("DELETE /v2/tenants/:uuid/jobs/:uuid", func(r *route.Request) { // {{{
-		if c.IsNotTenantEngineer(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermManageJobs) {
 			return
 		}
 
@@ -2874,12 +3885,18 @@ func (c *Core) v2API() *route.Router {
 			r.Fail(route.Forbidden(nil, "The job cannot be deleted at this time"))
 			return
 		}
+		r.Audit("job", job.UUID, job, nil)
+
+		/* close up the gap the job leaves in the tenant's spread */
+		if err := c.db.RespreadJobs(job.TenantUUID, time.Now()); err != nil {
+			log.Errorf("error re-scheduling jobs for tenant [%s]: %s", job.TenantUUID, err)
+		}
 
 		r.Success("Job deleted successfully")
 	})
 	// }}}
 	r.Dispatch("POST /v2/tenants/:uuid/jobs/:uuid/run", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermRunJob) {
 			return
 		}
 
@@ -2894,25 +3911,76 @@ func (c *Core) v2API() *route.Router {
 			return
 		}
 
+		warning, refused := c.checkQuota(r, job.TenantUUID, db.QuotaStorage, db.QuotaArchives)
+		if refused {
+			return
+		}
+
 		user, _ := c.AuthenticatedUser(r)
 		task, err := c.db.CreateBackupTask(fmt.Sprintf("%s@%s", user.Account, user.Backend), job)
 		if task == nil || err != nil {
 			r.Fail(route.Oops(err, "Unable to schedule ad hoc backup job run"))
 			return
 		}
+		if warning != "" {
+			c.db.UpdateTaskLog(task.UUID, fmt.Sprintf("WARNING: %s\n", warning))
+		}
 
 		var out struct {
 			OK       string `json:"ok"`
 			TaskUUID string `json:"task_uuid"`
+			Warning  string `json:"warning,omitempty"`
 		}
 
 		out.OK = "Scheduled ad hoc backup job run"
 		out.TaskUUID = task.UUID
+		out.Warning = warning
 		r.OK(out)
 	})
 	// }}}
+	r.Dispatch("GET /v2/tenants/:uuid/jobs/:uuid/retention", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
+			return
+		}
+
+		job, err := c.db.GetJob(r.Args[2])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve job information"))
+			return
+		}
+
+		if job == nil || job.TenantUUID != r.Args[1] {
+			r.Fail(route.NotFound(nil, "No such job"))
+			return
+		}
+
+		keepdays := job.KeepDays
+		if retain := r.Param("retain", ""); retain != "" {
+			keepdays = util.ParseRetain(retain)
+			if keepdays < 0 {
+				r.Fail(route.Bad(nil, "Invalid or malformed SHIELD Job Archive Retention Period '%s'", retain))
+				return
+			}
+			if keepdays < c.Config.Limit.Retention.Min {
+				r.Fail(route.Bad(nil, "SHIELD Job Archive Retention Period '%s' is too short, archives must be kept for a minimum of %d days", retain, c.Config.Limit.Retention.Min))
+				return
+			}
+			if keepdays > c.Config.Limit.Retention.Max {
+				r.Fail(route.Bad(nil, "SHIELD Job Archive Retention Period '%s' is too long, archives may be kept for a maximum of %d days", retain, c.Config.Limit.Retention.Max))
+				return
+			}
+		}
+
+		sim, err := c.db.SimulateRetention(job, keepdays, time.Now())
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to simulate archive retention"))
+			return
+		}
+		r.OK(sim)
+	})
+	// }}}
 	r.Dispatch("POST /v2/tenants/:uuid/jobs/:uuid/pause", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermPauseJob) {
 			return
 		}
 
@@ -2931,11 +3999,14 @@ func (c *Core) v2API() *route.Router {
 			r.Fail(route.Oops(err, "Unable to pause job"))
 			return
 		}
+		after := *job
+		after.Paused = true
+		r.Audit("job", job.UUID, job, after)
 		r.Success("Paused job successfully")
 	})
 	// }}}
 	r.Dispatch("POST /v2/tenants/:uuid/jobs/:uuid/unpause", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermPauseJob) {
 			return
 		}
 
@@ -2954,110 +4025,333 @@ func (c *Core) v2API() *route.Router {
 			r.Fail(route.Oops(err, "Unable to unpause job"))
 			return
 		}
+		after := *job
+		after.Paused = false
+		r.Audit("job", job.UUID, job, after)
 		r.Success("Unpaused job successfully")
 	})
 	// }}}
 
-	r.Dispatch("GET /v2/tenants/:uuid/tasks", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+	r.Dispatch("GET /v2/tenants/:uuid/blackouts", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
 			return
 		}
 
-		limit, err := strconv.Atoi(r.Param("limit", "30"))
-		if err != nil || limit < 0 || limit > 30 {
-			r.Fail(route.Bad(err, "Invalid limit parameter given"))
+		blackouts, err := c.db.GetAllBlackouts(&db.BlackoutFilter{
+			UUID:       r.Param("uuid", ""),
+			ForTenant:  r.Args[1],
+			ForTarget:  r.Param("target", ""),
+			SearchName: r.Param("name", ""),
+			ExactMatch: r.ParamIs("exact", "t"),
+		})
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve blackout information"))
 			return
 		}
 
-		// check to see if we're offseting task requests
-		paginationDate, err := strconv.ParseInt(r.Param("before", "0"), 10, 64)
-		if err != nil || paginationDate < 0 {
-			r.Fail(route.Bad(err, "Invalid before parameter given"))
+		r.OK(blackouts)
+	})
+	// }}}
+	r.Dispatch("POST /v2/tenants/:uuid/blackouts", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermManageBlackouts) {
 			return
 		}
 
-		tasks, err := c.db.GetAllTasks(
-			&db.TaskFilter{
-				UUID:          r.Param("uuid", ""),
-				ExactMatch:    r.ParamIs("exact", "t"),
-				SkipActive:    r.ParamIs("active", "f"),
-				SkipInactive:  r.ParamIs("active", "t"),
-				ForStatus:     r.Param("status", ""),
-				ForTarget:     r.Param("target", ""),
-				ForStore:      r.Param("store", ""),
-				ForOp:         r.Param("type", ""),
-				ForTenant:     r.Args[1],
-				Limit:         limit,
-				Before:        paginationDate,
-				StartedAfter:  r.ParamDuration("started_after"),
-				StoppedAfter:  r.ParamDuration("stopped_after"),
-				StartedBefore: r.ParamDuration("started_before"),
-				StoppedBefore: r.ParamDuration("stopped_before"),
-			},
-		)
-		if err != nil {
-			r.Fail(route.Oops(err, "Unable to retrieve task information"))
+		var in struct {
+			Name     string `json:"name"`
+			Summary  string `json:"summary"`
+			Target   string `json:"target"`
+			Schedule string `json:"schedule"`
+			Duration int    `json:"duration"`
+			StartsAt int64  `json:"starts_at"`
+			EndsAt   int64  `json:"ends_at"`
+			CatchUp  bool   `json:"catch_up"`
+		}
+		if !r.Payload(&in) {
 			return
 		}
 
-		if !c.CanSeeCredentials(r, r.Args[1]) {
-			c.db.RedactAllTaskLogs(tasks)
-		}
-		r.OK(tasks)
-	})
-	// }}}
-	r.Dispatch("GET /v2/tenants/:uuid/tasks/:uuid", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+		if r.Missing("name", in.Name) {
 			return
 		}
 
-		task, err := c.db.GetTask(r.Args[2])
-		if err != nil {
-			r.Fail(route.Oops(err, "Unable to retrieve task information"))
-			return
+		if in.Target != "" {
+			target, err := c.db.GetTarget(in.Target)
+			if err != nil {
+				r.Fail(route.Oops(err, "Unable to retrieve target information"))
+				return
+			}
+			if target == nil || target.TenantUUID != r.Args[1] {
+				r.Fail(route.NotFound(nil, "No such target"))
+				return
+			}
 		}
-		if task == nil || task.TenantUUID != r.Args[1] {
-			r.Fail(route.NotFound(err, "No such task"))
+
+		blackout := &db.Blackout{
+			TenantUUID: r.Args[1],
+			TargetUUID: in.Target,
+			Name:       in.Name,
+			Summary:    in.Summary,
+			Schedule:   in.Schedule,
+			Duration:   in.Duration,
+			StartsAt:   in.StartsAt,
+			EndsAt:     in.EndsAt,
+			CatchUp:    in.CatchUp,
+		}
+		if err := blackout.Validate(); err != nil {
+			r.Fail(route.Bad(err, "Invalid blackout: %s", err))
 			return
 		}
-		if !c.CanSeeCredentials(r, r.Args[1]) {
-			c.db.RedactTaskLog(task)
+
+		blackout, err := c.db.CreateBlackout(blackout)
+		if blackout == nil || err != nil {
+			r.Fail(route.Oops(err, "Unable to create new blackout"))
+			return
 		}
-		r.OK(task)
+		r.Audit("blackout", blackout.UUID, nil, blackout)
+
+		r.OK(blackout)
 	})
 	// }}}
-	r.Dispatch("DELETE /v2/tenants/:uuid/tasks/:uuid", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+	r.Dispatch("GET /v2/tenants/:uuid/blackouts/:uuid", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
 			return
 		}
 
-		task, err := c.db.GetTask(r.Args[2])
+		blackout, err := c.db.GetBlackout(r.Args[2])
 		if err != nil {
-			r.Fail(route.Oops(err, "Unable to retrieve task information"))
-			return
-		}
-		if task == nil || task.TenantUUID != r.Args[1] {
-			r.Fail(route.NotFound(err, "No such task"))
+			r.Fail(route.Oops(err, "Unable to retrieve blackout information"))
 			return
 		}
-
-		if err := c.db.CancelTask(task.UUID, time.Now()); err != nil {
-			r.Fail(route.Oops(err, "Unable to cancel task"))
+		if blackout == nil || blackout.TenantUUID != r.Args[1] {
+			r.Fail(route.NotFound(nil, "No such blackout"))
 			return
 		}
 
-		r.Success("Canceled task successfully")
+		r.OK(blackout)
 	})
 	// }}}
+	r.Dispatch("PUT /v2/tenants/:uuid/blackouts/:uuid", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermManageBlackouts) {
+			return
+		}
 
-	r.Dispatch("GET /v2/tenants/:uuid/archives", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+		var in struct {
+			Name     string  `json:"name"`
+			Summary  *string `json:"summary"`
+			Target   *string `json:"target"`
+			Schedule *string `json:"schedule"`
+			Duration *int    `json:"duration"`
+			StartsAt *int64  `json:"starts_at"`
+			EndsAt   *int64  `json:"ends_at"`
+			CatchUp  *bool   `json:"catch_up"`
+		}
+		if !r.Payload(&in) {
 			return
 		}
 
-		limit, err := strconv.Atoi(r.Param("limit", "0"))
-		if err != nil || limit < 0 {
-			r.Fail(route.Bad(err, "Invalid limit parameter given"))
+		blackout, err := c.db.GetBlackout(r.Args[2])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve blackout information"))
+			return
+		}
+		if blackout == nil || blackout.TenantUUID != r.Args[1] {
+			r.Fail(route.NotFound(nil, "No such blackout"))
+			return
+		}
+
+		before := *blackout
+		if in.Name != "" {
+			blackout.Name = in.Name
+		}
+		if in.Summary != nil {
+			blackout.Summary = *in.Summary
+		}
+		if in.Target != nil {
+			if *in.Target != "" {
+				target, err := c.db.GetTarget(*in.Target)
+				if err != nil {
+					r.Fail(route.Oops(err, "Unable to retrieve target information"))
+					return
+				}
+				if target == nil || target.TenantUUID != r.Args[1] {
+					r.Fail(route.NotFound(nil, "No such target"))
+					return
+				}
+			}
+			blackout.TargetUUID = *in.Target
+		}
+
+		if in.Schedule != nil {
+			blackout.Schedule = *in.Schedule
+		}
+		if in.Duration != nil {
+			blackout.Duration = *in.Duration
+		}
+		if in.StartsAt != nil {
+			blackout.StartsAt = *in.StartsAt
+		}
+		if in.EndsAt != nil {
+			blackout.EndsAt = *in.EndsAt
+		}
+		if in.CatchUp != nil {
+			blackout.CatchUp = *in.CatchUp
+		}
+		if err := blackout.Validate(); err != nil {
+			r.Fail(route.Bad(err, "Invalid blackout: %s", err))
+			return
+		}
+
+		if err := c.db.UpdateBlackout(blackout); err != nil {
+			r.Fail(route.Oops(err, "Unable to update blackout"))
+			return
+		}
+		r.Audit("blackout", blackout.UUID, before, blackout)
+
+		r.OK(blackout)
+	})
+	// }}}
+	r.Dispatch("DELETE /v2/tenants/:uuid/blackouts/:uuid", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermManageBlackouts) {
+			return
+		}
+
+		blackout, err := c.db.GetBlackout(r.Args[2])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve blackout information"))
+			return
+		}
+		if blackout == nil || blackout.TenantUUID != r.Args[1] {
+			r.Fail(route.NotFound(nil, "No such blackout"))
+			return
+		}
+
+		if _, err := c.db.DeleteBlackout(blackout.UUID); err != nil {
+			r.Fail(route.Oops(err, "Unable to delete blackout"))
+			return
+		}
+		r.Audit("blackout", blackout.UUID, blackout, nil)
+
+		r.Success("Blackout deleted successfully")
+	})
+	// }}}
+
+	r.Dispatch("GET /v2/tenants/:uuid/schedule", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
+			return
+		}
+
+		jobs, err := c.db.GetAllJobs(&db.JobFilter{ForTenant: r.Args[1]})
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve schedule information"))
+			return
+		}
+		c.v2schedule(r, jobs)
+	})
+	// }}}
+
+	r.Dispatch("GET /v2/tenants/:uuid/tasks", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
+			return
+		}
+
+		limit, err := strconv.Atoi(r.Param("limit", "30"))
+		if err != nil || limit < 0 || limit > 30 {
+			r.Fail(route.Bad(err, "Invalid limit parameter given"))
+			return
+		}
+
+		// check to see if we're offseting task requests
+		paginationDate, err := strconv.ParseInt(r.Param("before", "0"), 10, 64)
+		if err != nil || paginationDate < 0 {
+			r.Fail(route.Bad(err, "Invalid before parameter given"))
+			return
+		}
+
+		tasks, err := c.db.GetAllTasks(
+			&db.TaskFilter{
+				UUID:          r.Param("uuid", ""),
+				ExactMatch:    r.ParamIs("exact", "t"),
+				SkipActive:    r.ParamIs("active", "f"),
+				SkipInactive:  r.ParamIs("active", "t"),
+				ForStatus:     r.Param("status", ""),
+				ForTarget:     r.Param("target", ""),
+				ForStore:      r.Param("store", ""),
+				ForOp:         r.Param("type", ""),
+				ForTenant:     r.Args[1],
+				Limit:         limit,
+				Before:        paginationDate,
+				StartedAfter:  r.ParamDuration("started_after"),
+				StoppedAfter:  r.ParamDuration("stopped_after"),
+				StartedBefore: r.ParamDuration("started_before"),
+				StoppedBefore: r.ParamDuration("stopped_before"),
+			},
+		)
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve task information"))
+			return
+		}
+
+		if !c.CanSeeCredentials(r, r.Args[1]) {
+			c.db.RedactAllTaskLogs(tasks)
+		}
+		r.OK(tasks)
+	})
+	// }}}
+	r.Dispatch("GET /v2/tenants/:uuid/tasks/:uuid", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
+			return
+		}
+
+		task, err := c.db.GetTask(r.Args[2])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve task information"))
+			return
+		}
+		if task == nil || task.TenantUUID != r.Args[1] {
+			r.Fail(route.NotFound(err, "No such task"))
+			return
+		}
+		if !c.CanSeeCredentials(r, r.Args[1]) {
+			c.db.RedactTaskLog(task)
+		}
+		r.OK(task)
+	})
+	// }}}
+	r.Dispatch("DELETE /v2/tenants/:uuid/tasks/:uuid", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermCancelTask) {
+			return
+		}
+
+		task, err := c.db.GetTask(r.Args[2])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve task information"))
+			return
+		}
+		if task == nil || task.TenantUUID != r.Args[1] {
+			r.Fail(route.NotFound(err, "No such task"))
+			return
+		}
+
+		/* stop the task in its tracks, if it's already running */
+		c.scheduler.Cancel(task.UUID)
+		if err := c.db.CancelTask(task.UUID, time.Now()); err != nil {
+			r.Fail(route.Oops(err, "Unable to cancel task"))
+			return
+		}
+
+		r.Success("Canceled task successfully")
+	})
+	// }}}
+
+	r.Dispatch("GET /v2/tenants/:uuid/archives", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
+			return
+		}
+
+		limit, err := strconv.Atoi(r.Param("limit", "0"))
+		if err != nil || limit < 0 {
+			r.Fail(route.Bad(err, "Invalid limit parameter given"))
 			return
 		}
 
@@ -3066,6 +4360,16 @@ func (c *Core) v2API() *route.Router {
 			status = append(status, s)
 		}
 
+		labels := make(map[string]string)
+		for _, label := range r.Req.URL.Query()["label"] {
+			kv := strings.SplitN(label, "=", 2)
+			if len(kv) != 2 {
+				r.Fail(route.Bad(nil, "Invalid label parameter '%s' given (must be name=value)", label))
+				return
+			}
+			labels[kv[0]] = kv[1]
+		}
+
 		archives, err := c.db.GetAllArchives(
 			&db.ArchiveFilter{
 				UUID:       r.Param("uuid", ""),
@@ -3076,6 +4380,8 @@ func (c *Core) v2API() *route.Router {
 				Before:     r.ParamDate("before"),
 				After:      r.ParamDate("after"),
 				WithStatus: status,
+				WithLabels: labels,
+				Search:     r.Param("search", ""),
 				Limit:      limit,
 			},
 		)
@@ -3088,7 +4394,7 @@ func (c *Core) v2API() *route.Router {
 	})
 	// }}}
 	r.Dispatch("GET /v2/tenants/:uuid/archives/:uuid", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermView) {
 			return
 		}
 
@@ -3107,7 +4413,7 @@ func (c *Core) v2API() *route.Router {
 	})
 	// }}}
 	r.Dispatch("PUT /v2/tenants/:uuid/archives/:uuid", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermAnnotateArchive) {
 			return
 		}
 
@@ -3133,17 +4439,19 @@ func (c *Core) v2API() *route.Router {
 			return
 		}
 
+		before := *archive
 		archive.Notes = in.Notes
 		if err := c.db.UpdateArchive(archive); err != nil {
 			r.Fail(route.Oops(err, "Unable to update backup archive"))
 			return
 		}
+		c.auditArchive(r, &before)
 
 		r.OK(archive)
 	})
 	// }}}
 	r.Dispatch("DELETE /v2/tenants/:uuid/archives/:uuid", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+		if c.IsNotPermitted(r, r.Args[1], PermDeleteArchive) {
 			return
 		}
 
@@ -3160,28 +4468,40 @@ func (c *Core) v2API() *route.Router {
 
 		if archive.Status != "valid" {
 			r.Fail(route.Bad(err, "The backup archive could not be deleted at this time. Archive is already %s", archive.Status))
+			return
 		}
 
-		err = c.db.ManuallyPurgeArchive(archive.UUID)
-		if err != nil {
-			r.Fail(route.Oops(err, "Unable to delete backup archive"))
+		if archive.Held {
+			r.Fail(route.Bad(nil, "The backup archive is under legal hold, and cannot be deleted"))
+			return
+		}
+
+		if c.awaitApproval(r, &db.Approval{
+			TenantUUID:  r.Args[1],
+			Operation:   db.ApprovePurge,
+			ArchiveUUID: archive.UUID,
+			Summary:     fmt.Sprintf("Purge backup archive %s", archive.UUID),
+		}) {
 			return
 		}
-		err = c.vault.Delete(fmt.Sprintf("secret/archives/%s", archive.UUID))
+
+		err = c.db.ManuallyPurgeArchive(archive.UUID, c.PurgeAfter())
 		if err != nil {
-			log.Errorf("failed to delete encryption parameters for archive %s: %s", archive.UUID, err)
+			r.Fail(route.Oops(err, "Unable to delete backup archive"))
+			return
 		}
+		c.auditArchive(r, archive)
 
 		r.Success("Archive deleted successfully")
 	})
 	// }}}
-	r.Dispatch("POST /v2/tenants/:uuid/archives/:uuid/restore", func(r *route.Request) { // {{{
-		if c.IsNotTenantOperator(r, r.Args[1]) {
+	r.Dispatch("POST /v2/tenants/:uuid/archives/:uuid/undelete", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermDeleteArchive) {
 			return
 		}
 
 		var in struct {
-			Target string `json:"target"`
+			ExpiresAt int64 `json:"expires_at"`
 		}
 		if !r.Payload(&in) {
 			return
@@ -3192,135 +4512,892 @@ func (c *Core) v2API() *route.Router {
 			r.Fail(route.Oops(err, "Unable to retrieve backup archive information"))
 			return
 		}
+
 		if archive == nil || archive.TenantUUID != r.Args[1] {
 			r.Fail(route.NotFound(nil, "No such backup archive"))
 			return
 		}
 
-		if in.Target == "" {
-			in.Target = archive.TargetUUID
+		if archive.Status != "pending-purge" {
+			r.Fail(route.Bad(nil, "The backup archive cannot be undeleted. Archive is %s", archive.Status))
+			return
 		}
 
-		target, err := c.db.GetTarget(in.Target)
-		if err != nil {
-			r.Fail(route.Oops(err, "Unable to retrieve backup archive information"))
+		/* archives that expired would just expire again */
+		if in.ExpiresAt == 0 && archive.ExpiresAt <= time.Now().Unix() {
+			r.Fail(route.Bad(nil, "The backup archive has outlived its retention; please give it a new expiry"))
 			return
 		}
-
-		if target == nil || archive.TenantUUID != r.Args[1] {
-			r.Fail(route.NotFound(nil, "No such backup archive"))
+		if in.ExpiresAt != 0 && in.ExpiresAt <= time.Now().Unix() {
+			r.Fail(route.Bad(nil, "The new expiry of the backup archive must be in the future"))
 			return
 		}
 
-		user, _ := c.AuthenticatedUser(r)
-		task, err := c.db.CreateRestoreTask(fmt.Sprintf("%s@%s", user.Account, user.Backend), archive, target)
-		if task == nil || err != nil {
-			r.Fail(route.Oops(err, "Unable to schedule a restore task"))
+		if err := c.db.UndeleteArchive(archive.UUID, in.ExpiresAt); err != nil {
+			r.Fail(route.Oops(err, "Unable to undelete backup archive"))
 			return
 		}
-		if !c.CanSeeCredentials(r, r.Args[1]) {
-			c.db.RedactTaskLog(task)
+		c.auditArchive(r, archive)
+
+		archive, err = c.db.GetArchive(archive.UUID)
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve backup archive information"))
+			return
 		}
-		r.OK(task)
+
+		r.OK(archive)
 	})
 	// }}}
+	r.Dispatch("PUT /v2/tenants/:uuid/archives/:uuid/labels", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermAnnotateArchive) {
+			return
+		}
 
-	r.Dispatch("POST /v2/auth/login", func(r *route.Request) { // {{{
 		var in struct {
-			Username string
-			Password string
+			Labels map[string]string `json:"labels"`
 		}
 		if !r.Payload(&in) {
 			return
 		}
 
-		if r.Missing("username", in.Username, "password", in.Password) {
+		if len(in.Labels) == 0 {
+			r.Fail(route.Bad(nil, "No labels given"))
+			return
+		}
+		if err := db.ValidateLabels(in.Labels); err != nil {
+			r.Fail(route.Bad(err, "Invalid labels: %s", err))
 			return
 		}
 
-		user, err := c.db.GetUser(in.Username, "local")
+		archive, err := c.db.GetArchive(r.Args[2])
 		if err != nil {
-			r.Fail(route.Oops(err, "Unable to log you in"))
+			r.Fail(route.Oops(err, "Unable to retrieve backup archive information"))
 			return
 		}
 
-		if user == nil || !user.Authenticate(in.Password) {
-			r.Fail(route.Errorf(401, nil, "Incorrect username or password"))
+		if archive == nil || archive.TenantUUID != r.Args[1] {
+			r.Fail(route.NotFound(nil, "No such backup archive"))
 			return
 		}
 
-		session, err := c.db.CreateSession(&db.Session{
-			UserUUID:  user.UUID,
-			IP:        r.RemoteIP(),
-			UserAgent: r.UserAgent(),
-		})
+		if err := c.db.LabelArchive(archive.UUID, in.Labels); err != nil {
+			r.Fail(route.Oops(err, "Unable to label backup archive"))
+			return
+		}
+		c.auditArchive(r, archive)
+
+		archive, err = c.db.GetArchive(archive.UUID)
 		if err != nil {
-			r.Fail(route.Oops(err, "Unable to log you in"))
+			r.Fail(route.Oops(err, "Unable to retrieve backup archive information"))
 			return
 		}
-		if session == nil {
-			r.Fail(route.Oops(fmt.Errorf("no session created"), "Unable to log you in"))
+
+		r.OK(archive)
+	})
+	// }}}
+	r.Dispatch("DELETE /v2/tenants/:uuid/archives/:uuid/labels/:name", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermAnnotateArchive) {
 			return
 		}
 
-		id, err := c.checkAuth(user)
-		if err != nil || id == nil {
-			r.Fail(route.Oops(err, "Unable to log you in"))
+		archive, err := c.db.GetArchive(r.Args[2])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve backup archive information"))
+			return
 		}
 
-		r.SetSession(session.UUID)
-		r.OK(id)
-	})
-	// }}}
-	r.Dispatch("GET /v2/auth/logout", func(r *route.Request) { // {{{
-		if err := c.db.ClearSession(r.SessionID()); err != nil {
-			r.Fail(route.Oops(err, "Unable to log you out"))
+		if archive == nil || archive.TenantUUID != r.Args[1] {
+			r.Fail(route.NotFound(nil, "No such backup archive"))
 			return
 		}
 
-		r.ClearSession()
-		r.Success("Successfully logged out")
-	})
-	// }}}
-	r.Dispatch("GET /v2/auth/id", func(r *route.Request) { // {{{
-		user, _ := c.AuthenticatedUser(r)
-		if id, _ := c.checkAuth(user); id != nil {
-			r.OK(id)
+		if _, ok := archive.Labels[r.Args[3]]; !ok {
+			r.Fail(route.NotFound(nil, "No such label '%s' on backup archive", r.Args[3]))
 			return
 		}
 
-		r.OK(struct {
-			Unauthenticated bool `json:"unauthenticated"`
-		}{true})
+		if err := c.db.UnlabelArchive(archive.UUID, []string{r.Args[3]}); err != nil {
+			r.Fail(route.Oops(err, "Unable to remove label from backup archive"))
+			return
+		}
+		c.auditArchive(r, archive)
+
+		r.Success("Label removed successfully")
 	})
 	// }}}
-	r.Dispatch("POST /v2/auth/passwd", func(r *route.Request) { // {{{
-		if c.IsNotAuthenticated(r) {
+	r.Dispatch("POST /v2/tenants/:uuid/archives/:uuid/hold", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermHoldArchive) {
 			return
 		}
 
 		var in struct {
-			OldPassword string `json:"old_password"`
-			NewPassword string `json:"new_password"`
+			Reason string `json:"reason"`
+			Until  int64  `json:"until"`
+		}
+		if !r.Payload(&in) {
+			return
+		}
+
+		if r.Missing("reason", in.Reason) {
+			return
+		}
+
+		if in.Until != 0 && in.Until <= time.Now().Unix() {
+			r.Fail(route.Bad(nil, "The release date of a legal hold must be in the future"))
+			return
+		}
+
+		archive, err := c.db.GetArchive(r.Args[2])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve backup archive information"))
+			return
+		}
+
+		if archive == nil || archive.TenantUUID != r.Args[1] {
+			r.Fail(route.NotFound(nil, "No such backup archive"))
+			return
+		}
+
+		if archive.Status != "valid" {
+			r.Fail(route.Bad(nil, "The backup archive cannot be held. Archive is already %s", archive.Status))
+			return
+		}
+
+		if archive.Held {
+			r.Fail(route.Bad(nil, "The backup archive is already under legal hold"))
+			return
+		}
+
+		user, _ := c.AuthenticatedUser(r)
+		actor := fmt.Sprintf("%s@%s", user.Account, user.Backend)
+		if err := c.db.HoldArchive(archive.UUID, in.Reason, actor, in.Until); err != nil {
+			r.Fail(route.Oops(err, "Unable to place backup archive under legal hold"))
+			return
+		}
+		c.auditArchive(r, archive)
+
+		if _, err := c.db.CreateHoldTask(actor, archive); err != nil {
+			log.Errorf("failed to schedule legal hold of archive %s in cloud storage: %s", archive.UUID, err)
+		}
+
+		archive, err = c.db.GetArchive(archive.UUID)
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve backup archive information"))
+			return
+		}
+
+		r.OK(archive)
+	})
+	// }}}
+	r.Dispatch("DELETE /v2/tenants/:uuid/archives/:uuid/hold", func(r *route.Request) { // {{{
+		if c.IsNotSystemAdmin(r) {
+			return
+		}
+
+		archive, err := c.db.GetArchive(r.Args[2])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve backup archive information"))
+			return
+		}
+
+		if archive == nil || archive.TenantUUID != r.Args[1] {
+			r.Fail(route.NotFound(nil, "No such backup archive"))
+			return
+		}
+
+		if !archive.Held {
+			r.Fail(route.Bad(nil, "The backup archive is not under legal hold"))
+			return
+		}
+
+		if err := c.db.ReleaseArchive(archive.UUID); err != nil {
+			r.Fail(route.Oops(err, "Unable to release legal hold on backup archive"))
+			return
+		}
+		c.auditArchive(r, archive)
+
+		user, _ := c.AuthenticatedUser(r)
+		if _, err := c.db.CreateReleaseTask(fmt.Sprintf("%s@%s", user.Account, user.Backend), archive); err != nil {
+			log.Errorf("failed to schedule release of legal hold on archive %s in cloud storage: %s", archive.UUID, err)
+		}
+
+		r.Success("Legal hold released")
+	})
+	// }}}
+	r.Dispatch("POST /v2/tenants/:uuid/archives/:uuid/restore", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermRestore) {
+			return
+		}
+
+		var in struct {
+			Target string `json:"target"`
+		}
+		if !r.Payload(&in) {
+			return
+		}
+
+		archive, err := c.db.GetArchive(r.Args[2])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve backup archive information"))
+			return
+		}
+		if archive == nil || archive.TenantUUID != r.Args[1] {
+			r.Fail(route.NotFound(nil, "No such backup archive"))
+			return
+		}
+
+		if in.Target == "" {
+			in.Target = archive.TargetUUID
+		}
+
+		target, err := c.db.GetTarget(in.Target)
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve backup archive information"))
+			return
+		}
+
+		if target == nil || archive.TenantUUID != r.Args[1] {
+			r.Fail(route.NotFound(nil, "No such backup archive"))
+			return
+		}
+
+		if c.awaitApproval(r, &db.Approval{
+			TenantUUID:  r.Args[1],
+			Operation:   db.ApproveRestore,
+			ArchiveUUID: archive.UUID,
+			TargetUUID:  target.UUID,
+			Summary:     fmt.Sprintf("Restore backup archive %s to target %s", archive.UUID, target.Name),
+		}) {
+			return
+		}
+
+		user, _ := c.AuthenticatedUser(r)
+		task, err := c.db.CreateRestoreTask(fmt.Sprintf("%s@%s", user.Account, user.Backend), archive, target)
+		if task == nil || err != nil {
+			r.Fail(route.Oops(err, "Unable to schedule a restore task"))
+			return
+		}
+		if !c.CanSeeCredentials(r, r.Args[1]) {
+			c.db.RedactTaskLog(task)
+		}
+		r.OK(task)
+	})
+	// }}}
+
+	r.Dispatch("GET /v2/tenants/:uuid/archives/:uuid/download", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermDownload) {
+			return
+		}
+
+		archive, err := c.db.GetArchive(r.Args[2])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve backup archive information"))
+			return
+		}
+		if archive == nil || archive.TenantUUID != r.Args[1] {
+			r.Fail(route.NotFound(nil, "No such backup archive"))
+			return
+		}
+		if archive.Status != "valid" {
+			r.Fail(route.Bad(nil, "The backup archive cannot be downloaded. Archive is %s", archive.Status))
+			return
+		}
+
+		encryption, err := c.ArchiveEncryption(archive)
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve encryption parameters for backup archive"))
+			return
+		}
+
+		/* an archive is only ever as compressed as it was when
+		   it was taken; asking to decompress it is optional. */
+		compression, filename := "none", archive.UUID
+		switch archive.Compression {
+		case "bzip2":
+			filename += ".bz2"
+		case "gzip":
+			filename += ".gz"
+		}
+		if r.ParamIs("decompress", "t") {
+			compression, filename = archive.Compression, archive.UUID
+		}
+
+		user, _ := c.AuthenticatedUser(r)
+		actor := fmt.Sprintf("%s@%s", user.Account, user.Backend)
+		task, err := c.db.CreateDownloadTask(actor, archive, compression)
+		if task == nil || err != nil {
+			r.Fail(route.Oops(err, "Unable to download backup archive"))
+			return
+		}
+		log.Infof("%s: %s is downloading archive %s (tenant %s)", task.UUID, actor, archive.UUID, archive.TenantUUID)
+
+		fabric, err := c.FabricFor(task)
+		if err != nil {
+			c.TaskErrored(task, "unable to find a fabric to facilitate execution of this task\n")
+			r.Fail(route.Oops(err, "Unable to download backup archive"))
+			return
+		}
+
+		open := func() io.Writer {
+			return r.Stream("application/octet-stream", map[string]string{
+				"Content-Disposition": fmt.Sprintf("attachment; filename=\"%s\"", filename),
+				"X-Shield-Task":       task.UUID,
+			})
+		}
+		n, err := c.StreamDownload(task, fabric.Download(task, encryption), r.Req.Context().Done(), open)
+		if err != nil {
+			c.TaskErrored(task, "%s\n", err)
+			if !r.Done() {
+				r.Fail(route.Oops(err, "Unable to download backup archive"))
+			}
+			return
+		}
+
+		log.Infof("%s: %s downloaded archive %s (%d bytes)", task.UUID, actor, archive.UUID, n)
+		c.db.CompleteTask(task.UUID, time.Now())
+		if !r.Done() {
+			open() /* the archive was empty */
+		}
+	})
+	// }}}
+
+	r.Dispatch("POST /v2/tenants/:uuid/archives/import", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermImport) {
+			return
+		}
+
+		var in struct {
+			Target      string `json:"target"`
+			Store       string `json:"store"`
+			Key         string `json:"key"`
+			Compression string `json:"compression"`
+			TakenAt     int64  `json:"taken_at"`
+			ExpiresAt   int64  `json:"expires_at"`
+			Notes       string `json:"notes"`
+			Size        int64  `json:"size"`
+			Encrypt     bool   `json:"encrypt"`
+		}
+		if !r.Payload(&in) {
+			return
+		}
+
+		if r.Missing("target", in.Target, "store", in.Store, "key", in.Key) {
+			return
+		}
+
+		if in.Compression == "" {
+			in.Compression = "none"
+		}
+		if !ValidCompressionType(in.Compression) {
+			r.Fail(route.Bad(nil, "Invalid compression type '%s'", in.Compression))
+			return
+		}
+		if in.TakenAt == 0 {
+			in.TakenAt = time.Now().Unix()
+		}
+		if in.ExpiresAt <= time.Now().Unix() {
+			r.Fail(route.Bad(nil, "The expiry of an imported backup archive must be in the future"))
+			return
+		}
+
+		target, err := c.db.GetTarget(in.Target)
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve target information"))
+			return
+		}
+		if target == nil || target.TenantUUID != r.Args[1] {
+			r.Fail(route.NotFound(nil, "No such target"))
+			return
+		}
+
+		store, err := c.db.GetStore(in.Store)
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve cloud storage information"))
+			return
+		}
+		if store == nil || (!store.Global && store.TenantUUID != r.Args[1]) {
+			r.Fail(route.NotFound(nil, "No such store"))
+			return
+		}
+
+		if used, err := c.db.StoreKeyInUse(store.UUID, in.Key); err != nil {
+			r.Fail(route.Oops(err, "Unable to import backup archive"))
+			return
+		} else if used {
+			r.Fail(route.Bad(nil, "That store key is already in use by another archive"))
+			return
+		}
+
+		user, _ := c.AuthenticatedUser(r)
+		archive, task, err := c.ImportArchive(fmt.Sprintf("%s@%s", user.Account, user.Backend), ArchiveImport{
+			Target:      target,
+			Store:       store,
+			Key:         in.Key,
+			Compression: in.Compression,
+			TakenAt:     in.TakenAt,
+			ExpiresAt:   in.ExpiresAt,
+			Notes:       in.Notes,
+			Size:        in.Size,
+			Encrypt:     in.Encrypt,
+		}, nil, r.Req.Context().Done())
+		if err != nil {
+			if task != nil {
+				r.Fail(route.Oops(err, "Unable to import backup archive; see task %s for details", task.UUID))
+			} else {
+				r.Fail(route.Oops(err, "Unable to import backup archive"))
+			}
+			return
+		}
+
+		r.OK(archive)
+	})
+	// }}}
+	r.Dispatch("POST /v2/tenants/:uuid/archives/upload", func(r *route.Request) { // {{{
+		if c.IsNotPermitted(r, r.Args[1], PermImport) {
+			return
+		}
+
+		/* the request body is the backup itself, so everything
+		   else we need to know comes in the query string. */
+		var in ArchiveImport
+		var err error
+
+		if r.Missing("target", r.Param("target", ""), "store", r.Param("store", ""), "expires_at", r.Param("expires_at", "")) {
+			return
+		}
+
+		in.Compression = r.Param("compression", "none")
+		if !ValidCompressionType(in.Compression) {
+			r.Fail(route.Bad(nil, "Invalid compression type '%s'", in.Compression))
+			return
+		}
+
+		in.TakenAt = time.Now().Unix()
+		if v := r.Param("taken_at", ""); v != "" {
+			if in.TakenAt, err = strconv.ParseInt(v, 10, 64); err != nil {
+				r.Fail(route.Bad(err, "Invalid taken_at timestamp '%s'", v))
+				return
+			}
+		}
+		v := r.Param("expires_at", "")
+		if in.ExpiresAt, err = strconv.ParseInt(v, 10, 64); err != nil {
+			r.Fail(route.Bad(err, "Invalid expires_at timestamp '%s'", v))
+			return
+		}
+		if in.ExpiresAt <= time.Now().Unix() {
+			r.Fail(route.Bad(nil, "The expiry of an imported backup archive must be in the future"))
+			return
+		}
+
+		in.Notes = r.Param("notes", "")
+		in.Encrypt = !r.ParamIs("encrypt", "f")
+
+		in.Target, err = c.db.GetTarget(r.Param("target", ""))
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve target information"))
+			return
+		}
+		if in.Target == nil || in.Target.TenantUUID != r.Args[1] {
+			r.Fail(route.NotFound(nil, "No such target"))
+			return
+		}
+
+		in.Store, err = c.db.GetStore(r.Param("store", ""))
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve cloud storage information"))
+			return
+		}
+		if in.Store == nil || (!in.Store.Global && in.Store.TenantUUID != r.Args[1]) {
+			r.Fail(route.NotFound(nil, "No such store"))
+			return
+		}
+
+		user, _ := c.AuthenticatedUser(r)
+		archive, task, err := c.ImportArchive(fmt.Sprintf("%s@%s", user.Account, user.Backend),
+			in, r.Req.Body, r.Req.Context().Done())
+		if err != nil {
+			if task != nil {
+				r.Fail(route.Oops(err, "Unable to import backup archive; see task %s for details", task.UUID))
+			} else {
+				r.Fail(route.Oops(err, "Unable to import backup archive"))
+			}
+			return
+		}
+
+		r.OK(archive)
+	})
+	// }}}
+
+	r.Dispatch("POST /v2/auth/login", func(r *route.Request) { // {{{
+		var in struct {
+			Username string
+			Password string
+			Provider string
+			Code     string
+		}
+		if !r.Payload(&in) {
+			return
+		}
+
+		if r.Missing("username", in.Username, "password", in.Password) {
+			return
+		}
+
+		backend := in.Provider
+		if backend == "" {
+			backend = "local"
+		}
+		account := db.AccountLockoutName(in.Username, backend)
+		r.AuditAs(account)
+		if c.lockedOut(r, account) {
+			return
+		}
+
+		var user *db.User
+		if in.Provider == "" || in.Provider == "local" {
+			u, err := c.db.GetUser(in.Username, "local")
+			if err != nil {
+				r.Fail(route.Oops(err, "Unable to log you in"))
+				return
+			}
+			if u != nil && u.Authenticate(in.Password) {
+				user = u
+			}
+
+		} else {
+			provider, ok := c.providers[in.Provider].(PasswordAuthProvider)
+			if !ok {
+				r.Fail(route.Bad(nil, "No such authentication provider '%s' (or it does not support logging in with a password)", in.Provider))
+				return
+			}
+
+			u, err := provider.Authenticate(in.Username, in.Password)
+			if err != nil {
+				r.Fail(route.Oops(err, "Unable to log you in"))
+				return
+			}
+			user = u
+		}
+
+		if user == nil {
+			c.loginFailed(r, account)
+			r.Fail(route.Errorf(401, nil, "Incorrect username or password"))
+			return
+		}
+
+		if user.MFAEnabled {
+			/* clients know to ask for the second factor,
+			   and try again, when `code' is missing */
+			if in.Code == "" {
+				e := route.Errorf(401, nil, "Multi-factor authentication code required")
+				e.Missing = []string{"code"}
+				r.Fail(e)
+				return
+			}
+
+			ok, err := c.db.CheckMFA(user, in.Code)
+			if err != nil {
+				r.Fail(route.Oops(err, "Unable to log you in"))
+				return
+			}
+			if !ok {
+				c.loginFailed(r, account)
+				r.Fail(route.Errorf(401, nil, "Incorrect multi-factor authentication code"))
+				return
+			}
+		}
+
+		if err := c.db.ClearLoginFailures(db.AccountLockout, account); err != nil {
+			log.Errorf("unable to clear failed logins for %s: %s", account, err)
+		}
+
+		session, err := c.db.CreateSession(&db.Session{
+			UserUUID:  user.UUID,
+			IP:        c.clientIP(r),
+			UserAgent: r.UserAgent(),
+		})
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to log you in"))
+			return
+		}
+		if session == nil {
+			r.Fail(route.Oops(fmt.Errorf("no session created"), "Unable to log you in"))
+			return
+		}
+
+		id, err := c.checkAuth(user)
+		if err != nil || id == nil {
+			r.Fail(route.Oops(err, "Unable to log you in"))
+		}
+
+		r.Audit("session", session.UUID, nil, nil)
+		r.SetSession(session.UUID)
+		r.OK(id)
+	})
+	// }}}
+	r.Dispatch("GET /v2/auth/logout", func(r *route.Request) { // {{{
+		r.Audit("session", r.SessionID(), nil, nil)
+		if err := c.db.ClearSession(r.SessionID()); err != nil {
+			r.Fail(route.Oops(err, "Unable to log you out"))
+			return
+		}
+
+		r.ClearSession()
+		r.Success("Successfully logged out")
+	})
+	// }}}
+	r.Dispatch("GET /v2/auth/id", func(r *route.Request) { // {{{
+		user, session, _ := c.authenticate(r)
+		if id, _ := c.checkAuth(user); id != nil {
+			role, err := c.tokenRole(session.Scope)
+			if err != nil {
+				r.Fail(route.Oops(err, "Unable to retrieve your authentication details"))
+				return
+			}
+			id.limitTo(session.Scope, role)
+			r.OK(id)
+			return
+		}
+
+		r.OK(struct {
+			Unauthenticated bool `json:"unauthenticated"`
+		}{true})
+	})
+	// }}}
+	r.Dispatch("POST /v2/auth/passwd", func(r *route.Request) { // {{{
+		if c.IsNotAuthenticated(r) || c.IsScopedToken(r) {
+			return
+		}
+
+		var in struct {
+			OldPassword string `json:"old_password"`
+			NewPassword string `json:"new_password"`
+		}
+
+		if !r.Payload(&in) {
+			return
+		}
+
+		user, _ := c.AuthenticatedUser(r)
+		if !user.Authenticate(in.OldPassword) {
+			r.Fail(route.Forbidden(nil, "Incorrect password"))
+			return
+		}
+
+		r.Audit("user", user.UUID, auditedUser{*user, false}, auditedUser{*user, true})
+		user.SetPassword(in.NewPassword)
+		if err := c.db.UpdateUser(user); err != nil {
+			r.Fail(route.Oops(err, "Unable to change your password"))
+			return
+		}
+
+		r.Success("Password changed successfully")
+	})
+	// }}}
+	r.Dispatch("GET /v2/auth/mfa", func(r *route.Request) { // {{{
+		if c.IsNotAuthenticated(r) || c.IsScopedToken(r) {
+			return
+		}
+
+		user, _ := c.AuthenticatedUser(r)
+		if !user.IsLocal() {
+			r.Fail(route.Bad(nil, "Multi-factor authentication is only available for local SHIELD accounts"))
+			return
+		}
+
+		n, err := c.db.CountRecoveryCodes(user)
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve multi-factor authentication status"))
+			return
+		}
+
+		r.OK(struct {
+			Enabled       bool `json:"enabled"`
+			Required      bool `json:"required"`
+			RecoveryCodes int  `json:"recovery_codes"`
+		}{
+			Enabled:       user.MFAEnabled,
+			Required:      c.mfaRequired(user),
+			RecoveryCodes: n,
+		})
+	})
+	// }}}
+	r.Dispatch("POST /v2/auth/mfa", func(r *route.Request) { // {{{
+		if c.IsNotAuthenticated(r) || c.IsScopedToken(r) {
+			return
+		}
+
+		var in struct {
+			Password string `json:"password"`
+		}
+		if !r.Payload(&in) {
+			return
+		}
+		if r.Missing("password", in.Password) {
+			return
+		}
+
+		user, _ := c.AuthenticatedUser(r)
+		if !user.IsLocal() {
+			r.Fail(route.Bad(nil, "Multi-factor authentication is only available for local SHIELD accounts"))
+			return
+		}
+		if !user.Authenticate(in.Password) {
+			r.Fail(route.Forbidden(nil, "Incorrect password"))
+			return
+		}
+		if user.MFAEnabled {
+			r.Fail(route.Bad(nil, "Multi-factor authentication is already enabled; disable it first, to enrol a new authenticator"))
+			return
+		}
+
+		secret, err := totp.NewSecret()
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to start multi-factor authentication enrolment"))
+			return
+		}
+		uri := totp.URI(c.Config.API.MFA.Issuer, user.Account, secret)
+		code, err := qr.Encode(uri, qr.M)
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to start multi-factor authentication enrolment"))
+			return
+		}
+
+		if err := c.db.StartMFA(user, secret); err != nil {
+			r.Fail(route.Oops(err, "Unable to start multi-factor authentication enrolment"))
+			return
+		}
+		r.Audit("user", user.UUID, nil, nil)
+
+		r.OK(struct {
+			Secret string `json:"secret"`
+			URI    string `json:"uri"`
+			QR     string `json:"qr"`
+		}{
+			Secret: secret,
+			URI:    uri,
+			QR:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(code.PNG()),
+		})
+	})
+	// }}}
+	r.Dispatch("POST /v2/auth/mfa/verify", func(r *route.Request) { // {{{
+		if c.IsNotAuthenticated(r) || c.IsScopedToken(r) {
+			return
+		}
+
+		var in struct {
+			Code string `json:"code"`
+		}
+		if !r.Payload(&in) {
+			return
+		}
+		if r.Missing("code", in.Code) {
+			return
+		}
+
+		user, _ := c.AuthenticatedUser(r)
+		if user.MFAEnabled {
+			r.Fail(route.Bad(nil, "Multi-factor authentication is already enabled"))
+			return
+		}
+
+		before := *user
+		codes, ok, err := c.db.ConfirmMFA(user, in.Code)
+		if err != nil {
+			r.Fail(route.Bad(err, "Unable to enable multi-factor authentication (has enrolment been started?)"))
+			return
+		}
+		if !ok {
+			r.Fail(route.Forbidden(nil, "Incorrect multi-factor authentication code"))
+			return
+		}
+		r.Audit("user", user.UUID, before, user)
+
+		r.OK(struct {
+			RecoveryCodes []string `json:"recovery_codes"`
+		}{codes})
+	})
+	// }}}
+	r.Dispatch("POST /v2/auth/mfa/recovery-codes", func(r *route.Request) { // {{{
+		if c.IsNotAuthenticated(r) || c.IsScopedToken(r) {
+			return
+		}
+
+		var in struct {
+			Code string `json:"code"`
+		}
+		if !r.Payload(&in) {
+			return
+		}
+		if r.Missing("code", in.Code) {
+			return
+		}
+
+		user, _ := c.AuthenticatedUser(r)
+		if !user.MFAEnabled {
+			r.Fail(route.Bad(nil, "Multi-factor authentication is not enabled"))
+			return
+		}
+
+		ok, err := c.db.CheckMFA(user, in.Code)
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to generate new recovery codes"))
+			return
+		}
+		if !ok {
+			r.Fail(route.Forbidden(nil, "Incorrect multi-factor authentication code"))
+			return
+		}
+
+		codes, err := c.db.RegenerateRecoveryCodes(user)
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to generate new recovery codes"))
+			return
+		}
+		r.Audit("user", user.UUID, nil, nil)
+
+		r.OK(struct {
+			RecoveryCodes []string `json:"recovery_codes"`
+		}{codes})
+	})
+	// }}}
+	r.Dispatch("POST /v2/auth/mfa/disable", func(r *route.Request) { // {{{
+		if c.IsNotAuthenticated(r) || c.IsScopedToken(r) {
+			return
+		}
+
+		var in struct {
+			Code string `json:"code"`
 		}
-
 		if !r.Payload(&in) {
 			return
 		}
 
 		user, _ := c.AuthenticatedUser(r)
-		if !user.Authenticate(in.OldPassword) {
-			r.Fail(route.Forbidden(nil, "Incorrect password"))
-			return
+		if user.MFAEnabled {
+			if r.Missing("code", in.Code) {
+				return
+			}
+
+			ok, err := c.db.CheckMFA(user, in.Code)
+			if err != nil {
+				r.Fail(route.Oops(err, "Unable to disable multi-factor authentication"))
+				return
+			}
+			if !ok {
+				r.Fail(route.Forbidden(nil, "Incorrect multi-factor authentication code"))
+				return
+			}
 		}
 
-		user.SetPassword(in.NewPassword)
-		if err := c.db.UpdateUser(user); err != nil {
-			r.Fail(route.Oops(err, "Unable to change your password"))
+		before := *user
+		if err := c.db.DisableMFA(user); err != nil {
+			r.Fail(route.Oops(err, "Unable to disable multi-factor authentication"))
 			return
 		}
+		r.Audit("user", user.UUID, before, user)
 
-		r.Success("Password changed successfully")
+		r.Success("Multi-factor authentication disabled")
 	})
 	// }}}
 	r.Dispatch("PATCH /v2/auth/user/settings", func(r *route.Request) { // {{{
@@ -3338,6 +5415,7 @@ func (c *Core) v2API() *route.Router {
 			return
 		}
 
+		before := *user
 		if in.DefaultTenant != "" {
 			user.DefaultTenant = in.DefaultTenant
 		}
@@ -3345,6 +5423,7 @@ func (c *Core) v2API() *route.Router {
 			r.Fail(route.Oops(err, "Unable to save settings"))
 			return
 		}
+		r.Audit("user", user.UUID, before, user)
 
 		r.Success("Settings saved")
 	})
@@ -3456,6 +5535,7 @@ func (c *Core) v2API() *route.Router {
 			r.Fail(route.Oops(err, "Unable to create new storage system"))
 			return
 		}
+		r.Audit("store", store.UUID, nil, store)
 
 		r.OK(store)
 	})
@@ -3489,6 +5569,7 @@ func (c *Core) v2API() *route.Router {
 			return
 		}
 
+		before := *store
 		if in.Name != "" {
 			store.Name = in.Name
 		}
@@ -3518,6 +5599,7 @@ func (c *Core) v2API() *route.Router {
 			r.Fail(route.Oops(err, "Unable to retrieve storage system information"))
 			return
 		}
+		r.Audit("store", store.UUID, before, store)
 
 		r.OK(store)
 	})
@@ -3546,11 +5628,380 @@ func (c *Core) v2API() *route.Router {
 			r.Fail(route.Bad(nil, "The storage system cannot be deleted at this time"))
 			return
 		}
+		r.Audit("store", store.UUID, store, nil)
 
 		r.Success("Storage system deleted successfully")
 	})
 	// }}}
 
+	r.Dispatch("GET /v2/global/blackouts", func(r *route.Request) { // {{{
+		if c.IsNotAuthenticated(r) {
+			return
+		}
+
+		blackouts, err := c.db.GetAllBlackouts(&db.BlackoutFilter{
+			UUID:       r.Param("uuid", ""),
+			ForTenant:  db.GlobalTenantUUID,
+			SearchName: r.Param("name", ""),
+			ExactMatch: r.ParamIs("exact", "t"),
+		})
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve blackout information"))
+			return
+		}
+
+		r.OK(blackouts)
+	})
+	// }}}
+	r.Dispatch("POST /v2/global/blackouts", func(r *route.Request) { // {{{
+		if c.IsNotSystemEngineer(r) {
+			return
+		}
+
+		var in struct {
+			Name     string `json:"name"`
+			Summary  string `json:"summary"`
+			Target   string `json:"target"`
+			Schedule string `json:"schedule"`
+			Duration int    `json:"duration"`
+			StartsAt int64  `json:"starts_at"`
+			EndsAt   int64  `json:"ends_at"`
+			CatchUp  bool   `json:"catch_up"`
+		}
+		if !r.Payload(&in) {
+			return
+		}
+
+		if r.Missing("name", in.Name) {
+			return
+		}
+
+		if in.Target != "" {
+			r.Fail(route.Bad(nil, "Global blackouts cannot be tied to a single target"))
+			return
+		}
+
+		blackout := &db.Blackout{
+			TenantUUID: db.GlobalTenantUUID,
+			TargetUUID: in.Target,
+			Name:       in.Name,
+			Summary:    in.Summary,
+			Schedule:   in.Schedule,
+			Duration:   in.Duration,
+			StartsAt:   in.StartsAt,
+			EndsAt:     in.EndsAt,
+			CatchUp:    in.CatchUp,
+		}
+		if err := blackout.Validate(); err != nil {
+			r.Fail(route.Bad(err, "Invalid blackout: %s", err))
+			return
+		}
+
+		blackout, err := c.db.CreateBlackout(blackout)
+		if blackout == nil || err != nil {
+			r.Fail(route.Oops(err, "Unable to create new blackout"))
+			return
+		}
+		r.Audit("blackout", blackout.UUID, nil, blackout)
+
+		r.OK(blackout)
+	})
+	// }}}
+	r.Dispatch("GET /v2/global/blackouts/:uuid", func(r *route.Request) { // {{{
+		if c.IsNotAuthenticated(r) {
+			return
+		}
+
+		blackout, err := c.db.GetBlackout(r.Args[1])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve blackout information"))
+			return
+		}
+		if blackout == nil || blackout.TenantUUID != db.GlobalTenantUUID {
+			r.Fail(route.NotFound(nil, "No such blackout"))
+			return
+		}
+
+		r.OK(blackout)
+	})
+	// }}}
+	r.Dispatch("PUT /v2/global/blackouts/:uuid", func(r *route.Request) { // {{{
+		if c.IsNotSystemEngineer(r) {
+			return
+		}
+
+		var in struct {
+			Name     string  `json:"name"`
+			Summary  *string `json:"summary"`
+			Schedule *string `json:"schedule"`
+			Duration *int    `json:"duration"`
+			StartsAt *int64  `json:"starts_at"`
+			EndsAt   *int64  `json:"ends_at"`
+			CatchUp  *bool   `json:"catch_up"`
+		}
+		if !r.Payload(&in) {
+			return
+		}
+
+		blackout, err := c.db.GetBlackout(r.Args[1])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve blackout information"))
+			return
+		}
+		if blackout == nil || blackout.TenantUUID != db.GlobalTenantUUID {
+			r.Fail(route.NotFound(nil, "No such blackout"))
+			return
+		}
+
+		before := *blackout
+		if in.Name != "" {
+			blackout.Name = in.Name
+		}
+		if in.Summary != nil {
+			blackout.Summary = *in.Summary
+		}
+		if in.Schedule != nil {
+			blackout.Schedule = *in.Schedule
+		}
+		if in.Duration != nil {
+			blackout.Duration = *in.Duration
+		}
+		if in.StartsAt != nil {
+			blackout.StartsAt = *in.StartsAt
+		}
+		if in.EndsAt != nil {
+			blackout.EndsAt = *in.EndsAt
+		}
+		if in.CatchUp != nil {
+			blackout.CatchUp = *in.CatchUp
+		}
+		if err := blackout.Validate(); err != nil {
+			r.Fail(route.Bad(err, "Invalid blackout: %s", err))
+			return
+		}
+
+		if err := c.db.UpdateBlackout(blackout); err != nil {
+			r.Fail(route.Oops(err, "Unable to update blackout"))
+			return
+		}
+		r.Audit("blackout", blackout.UUID, before, blackout)
+
+		r.OK(blackout)
+	})
+	// }}}
+	r.Dispatch("DELETE /v2/global/blackouts/:uuid", func(r *route.Request) { // {{{
+		if c.IsNotSystemEngineer(r) {
+			return
+		}
+
+		blackout, err := c.db.GetBlackout(r.Args[1])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve blackout information"))
+			return
+		}
+		if blackout == nil || blackout.TenantUUID != db.GlobalTenantUUID {
+			r.Fail(route.NotFound(nil, "No such blackout"))
+			return
+		}
+
+		if _, err := c.db.DeleteBlackout(blackout.UUID); err != nil {
+			r.Fail(route.Oops(err, "Unable to delete blackout"))
+			return
+		}
+		r.Audit("blackout", blackout.UUID, blackout, nil)
+
+		r.Success("Blackout deleted successfully")
+	})
+	// }}}
+
+	r.Dispatch("GET /v2/global/calendars", func(r *route.Request) { // {{{
+		if c.IsNotAuthenticated(r) {
+			return
+		}
+
+		calendars, err := c.db.GetAllCalendars(&db.CalendarFilter{
+			UUID:       r.Param("uuid", ""),
+			SearchName: r.Param("name", ""),
+			ExactMatch: r.ParamIs("exact", "t"),
+		})
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve calendar information"))
+			return
+		}
+
+		r.OK(calendars)
+	})
+	// }}}
+	r.Dispatch("POST /v2/global/calendars", func(r *route.Request) { // {{{
+		if c.IsNotSystemEngineer(r) {
+			return
+		}
+
+		var in struct {
+			Name    string   `json:"name"`
+			Summary string   `json:"summary"`
+			Dates   []string `json:"dates"`
+		}
+		if !r.Payload(&in) {
+			return
+		}
+
+		if r.Missing("name", in.Name) {
+			return
+		}
+
+		calendar := &db.Calendar{
+			Name:    in.Name,
+			Summary: in.Summary,
+			Dates:   in.Dates,
+		}
+		if err := calendar.Validate(); err != nil {
+			r.Fail(route.Bad(err, "Invalid calendar: %s", err))
+			return
+		}
+
+		calendar, err := c.db.CreateCalendar(calendar)
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to create new calendar"))
+			return
+		}
+		r.Audit("calendar", calendar.UUID, nil, calendar)
+
+		r.OK(calendar)
+	})
+	// }}}
+	r.Dispatch("GET /v2/global/calendars/:uuid", func(r *route.Request) { // {{{
+		if c.IsNotAuthenticated(r) {
+			return
+		}
+
+		calendar, err := c.db.GetCalendar(r.Args[1])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve calendar information"))
+			return
+		}
+		if calendar == nil {
+			r.Fail(route.NotFound(nil, "No such calendar"))
+			return
+		}
+
+		r.OK(calendar)
+	})
+	// }}}
+	r.Dispatch("PUT /v2/global/calendars/:uuid", func(r *route.Request) { // {{{
+		if c.IsNotSystemEngineer(r) {
+			return
+		}
+
+		var in struct {
+			Name    string    `json:"name"`
+			Summary *string   `json:"summary"`
+			Dates   *[]string `json:"dates"`
+		}
+		if !r.Payload(&in) {
+			return
+		}
+
+		calendar, err := c.db.GetCalendar(r.Args[1])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve calendar information"))
+			return
+		}
+		if calendar == nil {
+			r.Fail(route.NotFound(nil, "No such calendar"))
+			return
+		}
+
+		jobs, err := c.db.JobsExcludingCalendar(calendar.Name)
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to update calendar"))
+			return
+		}
+
+		before := *calendar
+		if in.Name != "" && in.Name != calendar.Name {
+			/* job schedules refer to calendars by name */
+			if len(jobs) > 0 {
+				r.Fail(route.Bad(nil, "Unable to rename calendar: it is still excluded by the schedules of %d job(s)", len(jobs)))
+				return
+			}
+			calendar.Name = in.Name
+		}
+		if in.Summary != nil {
+			calendar.Summary = *in.Summary
+		}
+		if in.Dates != nil {
+			calendar.Dates = *in.Dates
+		}
+		if err := calendar.Validate(); err != nil {
+			r.Fail(route.Bad(err, "Invalid calendar: %s", err))
+			return
+		}
+
+		if err := c.db.UpdateCalendar(calendar); err != nil {
+			r.Fail(route.Oops(err, "Unable to update calendar"))
+			return
+		}
+		r.Audit("calendar", calendar.UUID, before, calendar)
+
+		/* the dates have (probably) changed; work out when
+		   each of the affected jobs should run next. */
+		if err := c.db.ScheduleJobs(jobs, time.Now()); err != nil {
+			log.Errorf("unable to reschedule jobs after calendar %s changed: %s", calendar.Name, err)
+		}
+
+		r.OK(calendar)
+	})
+	// }}}
+	r.Dispatch("DELETE /v2/global/calendars/:uuid", func(r *route.Request) { // {{{
+		if c.IsNotSystemEngineer(r) {
+			return
+		}
+
+		calendar, err := c.db.GetCalendar(r.Args[1])
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve calendar information"))
+			return
+		}
+		if calendar == nil {
+			r.Fail(route.NotFound(nil, "No such calendar"))
+			return
+		}
+
+		jobs, err := c.db.JobsExcludingCalendar(calendar.Name)
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to delete calendar"))
+			return
+		}
+		if len(jobs) > 0 {
+			r.Fail(route.Bad(nil, "Unable to delete calendar: it is still excluded by the schedules of %d job(s)", len(jobs)))
+			return
+		}
+
+		if _, err := c.db.DeleteCalendar(calendar.UUID); err != nil {
+			r.Fail(route.Oops(err, "Unable to delete calendar"))
+			return
+		}
+		r.Audit("calendar", calendar.UUID, calendar, nil)
+
+		r.Success("Calendar deleted successfully")
+	})
+	// }}}
+
+	r.Dispatch("GET /v2/global/schedule", func(r *route.Request) { // {{{
+		if c.IsNotSystemEngineer(r) {
+			return
+		}
+
+		jobs, err := c.db.GetAllJobs(nil)
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to retrieve schedule information"))
+			return
+		}
+		c.v2schedule(r, jobs)
+	})
+	// }}}
+
 	r.Dispatch("GET /v2/fixups", func(r *route.Request) { // {{{
 		if c.IsNotSystemEngineer(r) {
 			return
@@ -3735,6 +6186,7 @@ func (c *Core) v2API() *route.Router {
 				// r.Fail(route.Oops(err, "Failed to import SHIELD data"))
 				// return
 			}
+			c.RefreshTenantScheduling()
 			r.Success("imported successfully: %s    %s", r.Param("key", ""), r.Param("task", ""))
 		} else {
 			r.Fail(route.Oops(nil, "Failed to import SHIELD data"))
@@ -3776,22 +6228,44 @@ func (c *Core) v2copyTarget(dst *v2System, target *db.Target) error {
 		if err != nil {
 			return err
 		}
-		switch tspec.Interval {
-		case timespec.Minutely:
-			dst.Jobs[j].Keep.N = dst.Jobs[j].Keep.Days * 1440 / int(tspec.Cardinality)
-		case timespec.Hourly:
-			if tspec.Cardinality == 0 {
-				dst.Jobs[j].Keep.N = dst.Jobs[j].Keep.Days * 24
-			} else {
-				dst.Jobs[j].Keep.N = dst.Jobs[j].Keep.Days * 24 / int(tspec.Cardinality)
-			}
-		case timespec.Daily:
-			dst.Jobs[j].Keep.N = dst.Jobs[j].Keep.Days
-		case timespec.Weekly:
-			dst.Jobs[j].Keep.N = dst.Jobs[j].Keep.Days / 7
-		case timespec.Monthly:
-			dst.Jobs[j].Keep.N = dst.Jobs[j].Keep.Days / 30
+		if err := c.db.ResolveExclusions(tspec); err != nil {
+			return err
 		}
+		dst.Jobs[j].Keep.N = tspec.KeepN(dst.Jobs[j].Keep.Days)
 	}
 	return nil
 }
+
+// MaxScheduleRange is the longest stretch of time that a schedule
+// preview may cover.
+const MaxScheduleRange = 31 * 24 * time.Hour
+
+// v2schedule expands the schedules of the given jobs across the time
+// range requested via the `from` and `to` query string parameters
+// (both epoch timestamps), and replies with the list of runs.  The
+// range defaults to the next 24 hours, and may not be longer than
+// MaxScheduleRange.
+func (c *Core) v2schedule(r *route.Request, jobs []*db.Job) {
+	now := time.Now()
+	from, err := strconv.ParseInt(r.Param("from", fmt.Sprintf("%d", now.Unix())), 10, 64)
+	if err != nil || from < 0 {
+		r.Fail(route.Bad(err, "Invalid from parameter given"))
+		return
+	}
+	to, err := strconv.ParseInt(r.Param("to", fmt.Sprintf("%d", from+86400)), 10, 64)
+	if err != nil || to < from {
+		r.Fail(route.Bad(err, "Invalid to parameter given"))
+		return
+	}
+	if time.Duration(to-from)*time.Second > MaxScheduleRange {
+		r.Fail(route.Bad(nil, "Unable to preview schedule: time range may not exceed %d days", int(MaxScheduleRange/(24*time.Hour))))
+		return
+	}
+
+	runs, err := c.db.ExpandSchedule(jobs, time.Unix(from, 0), time.Unix(to, 0))
+	if err != nil {
+		r.Fail(route.Oops(err, "Unable to retrieve schedule information"))
+		return
+	}
+	r.OK(runs)
+}
@@ -0,0 +1,152 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jhunt/go-log"
+
+	"github.com/shieldproject/shield/db"
+	"github.com/shieldproject/shield/route"
+)
+
+// approvalPermissions maps each of the operations that a tenant can
+// require a second person to approve to the permission needed to carry
+// it out; approvers need that same permission.
+var approvalPermissions = map[string]string{
+	db.ApproveRestore:      PermRestore,
+	db.ApprovePurge:        PermDeleteArchive,
+	db.ApproveDeleteTarget: PermManageTargets,
+}
+
+// awaitApproval checks if the tenant requires a second person to
+// approve the operation about to be carried out, and if so, opens a
+// request for approval, responding to the request with that instead.
+// It returns true if the request has been dealt with, either way.
+func (c *Core) awaitApproval(r *route.Request, a *db.Approval) bool {
+	tenant, err := c.db.GetTenant(a.TenantUUID)
+	if err != nil {
+		r.Fail(route.Oops(err, "Unable to retrieve tenant information"))
+		return true
+	}
+	if tenant == nil {
+		r.Fail(route.NotFound(nil, "No such tenant"))
+		return true
+	}
+	if !tenant.RequireApproval {
+		return false
+	}
+
+	user, err := c.AuthenticatedUser(r)
+	if user == nil || err != nil {
+		r.Fail(route.Unauthorized(err, "Authorization required"))
+		return true
+	}
+	a.RequestedBy = fmt.Sprintf("%s@%s", user.Account, user.Backend)
+	a.RequesterUUID = user.UUID
+	a.RequestedAt = time.Now().Unix()
+	a.ExpiresAt = a.RequestedAt + int64(tenant.ApprovalTimeout)*60
+
+	if _, err := c.db.CreateApproval(a); err != nil {
+		r.Fail(route.Bad(err, "Unable to request approval: %s", err))
+		return true
+	}
+	r.Audit("approval", a.UUID, nil, a)
+
+	r.Accepted(struct {
+		Ok       string       `json:"ok"`
+		Approval *db.Approval `json:"approval"`
+	}{
+		Ok:       fmt.Sprintf("Awaiting approval from a second person (request %s)", a.UUID),
+		Approval: a,
+	})
+	return true
+}
+
+// carryOut performs an operation that has been approved, checking
+// (again) that it still can be, and returns the task it scheduled, if
+// it scheduled one.
+func (c *Core) carryOut(a *db.Approval) (*db.Task, error) {
+	var (
+		archive *db.Archive
+		target  *db.Target
+		err     error
+	)
+
+	if a.ArchiveUUID != "" {
+		archive, err = c.db.GetArchive(a.ArchiveUUID)
+		if err != nil {
+			return nil, err
+		}
+		if archive == nil || archive.TenantUUID != a.TenantUUID {
+			return nil, fmt.Errorf("the backup archive no longer exists")
+		}
+	}
+	if a.TargetUUID != "" {
+		target, err = c.db.GetTarget(a.TargetUUID)
+		if err != nil {
+			return nil, err
+		}
+		if target == nil || target.TenantUUID != a.TenantUUID {
+			return nil, fmt.Errorf("the target no longer exists")
+		}
+	}
+
+	switch a.Operation {
+	case db.ApproveRestore:
+		return c.db.CreateRestoreTask(a.RequestedBy, archive, target)
+
+	case db.ApprovePurge:
+		if archive.Status != "valid" {
+			return nil, fmt.Errorf("the backup archive is already %s", archive.Status)
+		}
+		if archive.Held {
+			return nil, fmt.Errorf("the backup archive is under legal hold")
+		}
+		return nil, c.db.ManuallyPurgeArchive(archive.UUID, c.PurgeAfter())
+
+	case db.ApproveDeleteTarget:
+		deleted, err := c.db.DeleteTarget(target.UUID)
+		if err != nil {
+			return nil, err
+		}
+		if !deleted {
+			return nil, fmt.Errorf("the target cannot be deleted at this time")
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unrecognized operation '%s'", a.Operation)
+}
+
+// ExpireApprovalRequests closes out any requests for approval that
+// no one has decided on in time, noting each in the audit log.
+func (c *Core) ExpireApprovalRequests() {
+	log.Infof("UPKEEP: expiring unanswered requests for approval...")
+
+	l, err := c.db.ExpireApprovals(time.Now())
+	if err != nil {
+		log.Errorf("Failed to expire requests for approval: %s", err)
+	}
+
+	for _, a := range l {
+		was := *a
+		was.Status = "pending"
+		changes, err := db.AuditDiff(was, a)
+		if err != nil {
+			log.Errorf("unable to work out what changed for the audit log (expiring approval request %s): %s", a.UUID, err)
+		}
+
+		err = c.db.AppendAudit(&db.AuditEntry{
+			Actor:      "system",
+			Method:     "EXPIRE",
+			Route:      "(approval request expiry)",
+			TenantUUID: a.TenantUUID,
+			ObjectType: "approval",
+			ObjectUUID: a.UUID,
+			Changes:    changes,
+		})
+		if err != nil {
+			log.Errorf("unable to add the expiry of approval request %s to the audit log: %s", a.UUID, err)
+		}
+	}
+}
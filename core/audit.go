@@ -0,0 +1,220 @@
+package core
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/jhunt/go-log"
+
+	"github.com/shieldproject/shield/db"
+	"github.com/shieldproject/shield/route"
+)
+
+// auditedGets lists the GET routes that change things all the same
+// (or hand out tenant data), and so are audited along with every POST,
+// PUT, PATCH, and DELETE.
+var auditedGets = []string{
+	"GET /v2/auth/logout",
+	"GET /v2/tenants/:uuid/archives/:uuid/download",
+}
+
+// audited runs the handler for an API request and, if the request was
+// one that could change anything (a POST, PUT, PATCH, or DELETE), adds
+// an entry to the audit log saying who made it, from where, what it was
+// made against, what came of it, and what (if anything) changed.
+//
+// Whatever the handler has to say about the request (via r.Audit(),
+// r.AuditAs(), or r.Unaudited()) takes precedence over what can be
+// worked out from the route and its arguments.
+func (c *Core) audited(r *route.Request, handler route.Handler) {
+	if !auditable(r) {
+		handler(r)
+		return
+	}
+
+	/* work out who is making the request before handling it,
+	   since it may well be a logout, or a user deleting their
+	   own account, or an auth token revoking itself. */
+	var (
+		user    *db.User
+		session *db.Session
+	)
+	if id := r.SessionID(); id != "" {
+		session, _ = c.db.GetSession(id)
+		if session != nil {
+			user, _ = c.db.GetUserForSession(session.UUID)
+		}
+	}
+
+	handler(r)
+
+	audit := r.Audited()
+	if audit.Skip {
+		return
+	}
+
+	e := &db.AuditEntry{
+		Actor:  audit.Actor,
+		IP:     c.clientIP(r),
+		Method: r.Req.Method,
+		Route:  r.Route,
+		Path:   r.Req.URL.Path,
+		Status: r.Status(),
+	}
+	if session != nil {
+		e.SessionUUID = session.UUID
+		e.TokenUUID = session.Token
+	}
+	if user != nil {
+		e.ActorUUID = user.UUID
+		if e.Actor == "" {
+			e.Actor = user.Account + "@" + user.Backend
+		}
+	}
+
+	e.TenantUUID, e.ObjectType, e.ObjectUUID = auditTarget(r)
+	if audit.Type != "" {
+		e.ObjectType, e.ObjectUUID = audit.Type, audit.UUID
+		if audit.Type == "tenant" {
+			e.TenantUUID = audit.UUID
+		}
+	}
+
+	changes, err := db.AuditDiff(audit.Before, audit.After)
+	if err != nil {
+		log.Errorf("unable to work out what changed for the audit log (%s): %s", r, err)
+	}
+	e.Changes = changes
+
+	if err := c.db.AppendAudit(e); err != nil {
+		log.Errorf("unable to add %s (by %s from %s) to the audit log: %s", r, e.Actor, e.IP, err)
+	}
+}
+
+// auditTarget works out which tenant a request was made against, and
+// what it acted upon, from the route it matched; the last `:uuid`
+// argument in the route names the object, and the word before that
+// (singularized) its type, so that
+//
+//	PUT /v2/tenants/:uuid/jobs/:uuid
+//
+// acts upon a "job" in the tenant given by the first argument.  Routes
+// without a `:uuid` make do with their last argument of any name.
+func auditTarget(r *route.Request) (tenant, typ, id string) {
+	pattern := r.Route
+	if i := strings.Index(pattern, " "); i >= 0 {
+		pattern = pattern[i+1:]
+	}
+
+	var (
+		n       = 1
+		uuid    bool
+		segment string
+	)
+	for _, s := range strings.Split(pattern, "/") {
+		if !strings.HasPrefix(s, ":") && !strings.HasPrefix(s, "(") {
+			segment = s
+			continue
+		}
+
+		arg := ""
+		if n < len(r.Args) {
+			arg = r.Args[n]
+		}
+		n++
+
+		if segment == "tenants" && tenant == "" {
+			tenant = arg
+		}
+		if strings.HasPrefix(s, ":") && strings.HasSuffix(segment, "s") && (s == ":uuid" || !uuid) {
+			typ, id = strings.TrimSuffix(segment, "s"), arg
+			uuid = uuid || s == ":uuid"
+		}
+		segment = ""
+	}
+	return
+}
+
+// auditedUser is a user, as recorded in the audit log, along with
+// whether or not their password was (re-)set; the password itself is
+// never recorded.
+type auditedUser struct {
+	db.User
+	Password bool `json:"password"`
+}
+
+// auditedMembers is the membership of a tenant, as recorded in the
+// audit log: the role of each account (as account@backend) in it.
+type auditedMembers struct {
+	Members map[string]string `json:"members"`
+}
+
+func (c *Core) auditedMembers(tenant string) *auditedMembers {
+	users, err := c.db.GetUsersForTenant(tenant)
+	if err != nil {
+		log.Errorf("unable to retrieve the members of tenant %s for the audit log: %s", tenant, err)
+		return nil
+	}
+
+	m := &auditedMembers{Members: make(map[string]string)}
+	for _, u := range users {
+		m.Members[u.Account+"@"+u.Backend] = u.Role
+	}
+	return m
+}
+
+// auditArchive notes, for the audit log, how an archive has changed
+// from what it was before the request was handled.
+func (c *Core) auditArchive(r *route.Request, was *db.Archive) {
+	now, err := c.db.GetArchive(was.UUID)
+	if err != nil {
+		log.Errorf("unable to retrieve archive %s for the audit log: %s", was.UUID, err)
+		return
+	}
+	r.Audit("archive", was.UUID, was, now)
+}
+
+// auditFilter builds an audit log filter out of the query parameters
+// of a request, failing the request (and returning nil) if any of them
+// are invalid.
+func auditFilter(r *route.Request) *db.AuditFilter {
+	f := &db.AuditFilter{
+		ForActor:  r.Param("actor", ""),
+		ForTenant: r.Param("tenant", ""),
+		ForType:   r.Param("type", ""),
+		ForObject: r.Param("object", ""),
+		ForMethod: r.Param("method", ""),
+	}
+
+	for _, p := range []struct {
+		name string
+		into *int64
+	}{
+		{"since", &f.Since},
+		{"until", &f.Until},
+		{"before", &f.Before},
+		{"after", &f.After},
+	} {
+		n, err := strconv.ParseInt(r.Param(p.name, "0"), 10, 64)
+		if err != nil || n < 0 {
+			r.Fail(route.Bad(err, "Invalid %s parameter given", p.name))
+			return nil
+		}
+		*p.into = n
+	}
+
+	return f
+}
+
+func auditable(r *route.Request) bool {
+	switch r.Req.Method {
+	case "POST", "PUT", "PATCH", "DELETE":
+		return true
+	}
+	for _, pat := range auditedGets {
+		if r.Route == pat {
+			return true
+		}
+	}
+	return false
+}
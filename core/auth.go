@@ -1,8 +1,8 @@
 package core
 
 import (
-	"fmt"
-	"strings"
+	"sort"
+	"time"
 
 	"github.com/jhunt/go-log"
 
@@ -10,10 +10,100 @@ import (
 	"github.com/shieldproject/shield/route"
 )
 
+// TokenOps names the operations that an auth token can be limited
+// to, each as the API routes that it takes to carry it out.  Tokens
+// limited to a set of operations can still use the routes in
+// tokenAlways, so that clients can find out who they are.
+var TokenOps = map[string][]string{
+	"health": {
+		"GET /v2/tenants/:uuid/health",
+	},
+	"systems": {
+		"GET /v2/tenants/:uuid/systems",
+		"GET /v2/tenants/:uuid/systems/:uuid",
+	},
+	"targets": {
+		"GET /v2/tenants/:uuid/targets",
+		"GET /v2/tenants/:uuid/targets/:uuid",
+	},
+	"stores": {
+		"GET /v2/tenants/:uuid/stores",
+		"GET /v2/tenants/:uuid/stores/:uuid",
+	},
+	"jobs": {
+		"GET /v2/tenants/:uuid/jobs",
+		"GET /v2/tenants/:uuid/jobs/:uuid",
+	},
+	"run-job": {
+		"POST /v2/tenants/:uuid/jobs/:uuid/run",
+	},
+	"pause-job": {
+		"POST /v2/tenants/:uuid/jobs/:uuid/pause",
+		"POST /v2/tenants/:uuid/jobs/:uuid/unpause",
+	},
+	"tasks": {
+		"GET /v2/tenants/:uuid/tasks",
+		"GET /v2/tenants/:uuid/tasks/:uuid",
+		"DELETE /v2/tenants/:uuid/tasks/:uuid",
+	},
+	"archives": {
+		"GET /v2/tenants/:uuid/archives",
+		"GET /v2/tenants/:uuid/archives/:uuid",
+	},
+	"restore": {
+		"GET /v2/tenants/:uuid/targets/:uuid/restore",
+		"POST /v2/tenants/:uuid/targets/:uuid/restore",
+		"POST /v2/tenants/:uuid/archives/:uuid/restore",
+	},
+	"download": {
+		"GET /v2/tenants/:uuid/archives/:uuid/download",
+	},
+	"import": {
+		"POST /v2/tenants/:uuid/archives/import",
+		"POST /v2/tenants/:uuid/archives/upload",
+	},
+}
+
+var tokenAlways = []string{
+	"GET /v2/auth/id",
+}
+
+// TokenOpNames lists the names of all of the operations that auth
+// tokens can be limited to, in order.
+func TokenOpNames() []string {
+	l := make([]string, 0, len(TokenOps))
+	for op := range TokenOps {
+		l = append(l, op)
+	}
+	sort.Strings(l)
+	return l
+}
+
+func tokenPermits(r *route.Request, scope db.TokenScope) bool {
+	if len(scope.Ops) == 0 {
+		return true
+	}
+
+	for _, pat := range tokenAlways {
+		if r.Matches(pat) {
+			return true
+		}
+	}
+	for _, op := range scope.Ops {
+		for _, pat := range TokenOps[op] {
+			if r.Matches(pat) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 type authTenant struct {
-	UUID string `json:"uuid"`
-	Name string `json:"name"`
-	Role string `json:"role"`
+	UUID        string   `json:"uuid"`
+	Name        string   `json:"name"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
 }
 type authUser struct {
 	UUID    string `json:"uuid"`
@@ -27,6 +117,31 @@ type authTenantGrant struct {
 	Engineer bool `json:"engineer"`
 	Operator bool `json:"operator"`
 }
+
+// tenantGrant works out which of the built-in tenant roles a set of
+// permissions amounts to, for the benefit of clients that only know
+// about those.
+func tenantGrant(perms []string) authTenantGrant {
+	has := make(map[string]bool)
+	for _, perm := range perms {
+		has[perm] = true
+	}
+	covers := func(role string) bool {
+		for _, perm := range BuiltinTenantRoles[role] {
+			if !has[perm] {
+				return false
+			}
+		}
+		return true
+	}
+
+	return authTenantGrant{
+		Admin:    covers("admin"),
+		Engineer: covers("engineer"),
+		Operator: covers("operator"),
+	}
+}
+
 type authGrants struct {
 	System struct {
 		Admin    bool `json:"admin"`
@@ -35,12 +150,17 @@ type authGrants struct {
 	} `json:"system"`
 	Tenants map[string]authTenantGrant `json:"tenant"`
 }
+type authMFA struct {
+	Enabled  bool `json:"enabled"`
+	Required bool `json:"required"`
+}
 type authResponse struct {
 	User    authUser     `json:"user"`
 	Tenants []authTenant `json:"tenants"`
 	Tenant  *authTenant  `json:"tenant,omitempty"`
 
 	Grants authGrants `json:"is"`
+	MFA    *authMFA   `json:"mfa,omitempty"`
 }
 
 func (c *Core) checkAuth(user *db.User) (*authResponse, error) {
@@ -59,7 +179,17 @@ func (c *Core) checkAuth(user *db.User) (*authResponse, error) {
 		Tenant: nil,
 	}
 
-	switch user.SysRole {
+	if user.IsLocal() {
+		answer.MFA = &authMFA{
+			Enabled:  user.MFAEnabled,
+			Required: c.mfaRequired(user),
+		}
+	}
+	if c.mfaWithheld(user) {
+		answer.User.SysRole = ""
+	}
+
+	switch answer.User.SysRole {
 	case "admin":
 		answer.Grants.System.Admin = true
 		answer.Grants.System.Manager = true
@@ -89,19 +219,17 @@ func (c *Core) checkAuth(user *db.User) (*authResponse, error) {
 			answer.Tenant = &answer.Tenants[i]
 		}
 
-		grant := authTenantGrant{}
-		switch membership.Role {
-		case "admin":
-			grant.Admin = true
-			grant.Engineer = true
-			grant.Operator = true
-		case "engineer":
-			grant.Engineer = true
-			grant.Operator = true
-		case "operator":
-			grant.Operator = true
+		role, err := c.tenantRole(membership.TenantUUID, membership.Role)
+		if err != nil {
+			log.Debugf("failed to retrieve role '%s' of user %s@%s on tenant %s: %s",
+				membership.Role, user.Account, user.Backend, membership.TenantUUID, err)
+			return nil, err
 		}
-		answer.Grants.Tenants[membership.TenantUUID] = grant
+		answer.Tenants[i].Permissions = []string{}
+		if role != nil {
+			answer.Tenants[i].Permissions = role.Permissions
+		}
+		answer.Grants.Tenants[membership.TenantUUID] = tenantGrant(answer.Tenants[i].Permissions)
 	}
 	if answer.Tenant == nil && len(answer.Tenants) > 0 {
 		answer.Tenant = &answer.Tenants[0]
@@ -118,122 +246,231 @@ func (c *Core) checkAuth(user *db.User) (*authResponse, error) {
 	return &answer, nil
 }
 
-func (c *Core) hasRole(fail bool, r *route.Request, tenant string, roles ...string) bool {
-	user, err := c.AuthenticatedUser(r)
-	if user == nil || err != nil {
-		r.Fail(route.Unauthorized(err, "Authorization required"))
-		return false
+// limitTo trims the grants in an auth response down to what an auth
+// token with the given scope can actually do, given the permissions of
+// the role it is limited to (see tokenRole).
+func (a *authResponse) limitTo(scope db.TokenScope, role []string) {
+	if scope.Tenant == "" && scope.Role == "" {
+		return
 	}
 
-	memberships, err := c.db.GetMembershipsForUser(user.UUID)
-	if err != nil {
-		err = fmt.Errorf("failed to retrieve tenant memberships for user %s@%s (uuid %s): %s",
-			user.Account, user.Backend, user.UUID, err)
-		if fail {
-			r.Fail(route.Forbidden(err, "Access denied"))
-		}
-		return false
-	}
+	a.User.SysRole = ""
+	a.Grants.System.Admin = false
+	a.Grants.System.Manager = false
+	a.Grants.System.Engineer = false
 
-	for _, role := range roles {
-		l := strings.SplitN(role, "/", 2)
-		if len(l) != 2 {
+	tenants := []authTenant{}
+	for _, t := range a.Tenants {
+		if scope.Tenant != "" && t.UUID != scope.Tenant {
+			delete(a.Grants.Tenants, t.UUID)
 			continue
 		}
 
-		if l[0] == "system" {
-			if l[1] == "*" && user.SysRole != "" {
-				return true
-			}
-			if l[1] == user.SysRole {
-				return true
+		if scope.Role != "" {
+			perms := make(map[string]bool)
+			for _, perm := range t.Permissions {
+				perms[perm] = true
 			}
-			continue
+			t.Permissions = permissionList(limitPermissions(perms, role))
+			a.Grants.Tenants[t.UUID] = tenantGrant(t.Permissions)
 		}
+		tenants = append(tenants, t)
+	}
+
+	a.Tenants = tenants
+	a.Tenant = nil
+	if len(a.Tenants) > 0 {
+		a.Tenant = &a.Tenants[0]
+	}
+}
 
-		for _, m := range memberships {
-			if m.TenantUUID == tenant {
-				if l[1] == "*" {
-					return true
-				}
-				if l[1] == m.Role {
-					return true
-				}
+// hasSystemRole checks that the authenticated user has at least one of
+// the given system roles (where "*" is any system role at all.)
+//
+// Tenant roles are a matter of permissions; see hasPermission().
+//
+// Requests made with an auth token that is limited to a tenant, or to
+// a tenant role, never have a system role.
+func (c *Core) hasSystemRole(fail bool, r *route.Request, roles ...string) bool {
+	user, session, err := c.authenticate(r)
+	if user == nil || err != nil {
+		r.Fail(route.Unauthorized(err, "Authorization required"))
+		return false
+	}
+
+	granted := false
+	if user.SysRole != "" && !c.mfaWithheld(user) {
+		for _, role := range roles {
+			if role == "*" || role == user.SysRole {
+				granted = true
 				break
 			}
 		}
 	}
+
+	scope := session.Scope
+	if granted && scope.Tenant == "" && scope.Role == "" {
+		return true
+	}
+
 	if fail {
-		r.Fail(route.Forbidden(nil, "Access denied"))
+		if granted {
+			r.Fail(route.Forbidden(nil, "Access denied (beyond the scope of this auth token)"))
+		} else {
+			r.Fail(route.Forbidden(nil, "Access denied"))
+		}
 	}
 	return false
 }
 
-func (c *Core) hasTenant(fail bool, r *route.Request, id string) bool {
-	tenant, err := c.db.GetTenant(id)
-	if err != nil || tenant == nil {
-		if fail {
-			r.Fail(route.NotFound(err, "No such tenant"))
-		}
+// mfaRequired reports whether the MFA policy (api.mfa.require)
+// requires that a user enrol in multi-factor authentication, on
+// account of their system role.  Only local users are subject to the
+// policy; everyone else authenticates somewhere else entirely.
+func (c *Core) mfaRequired(user *db.User) bool {
+	if user == nil || !user.IsLocal() || user.SysRole == "" {
 		return false
 	}
-	return true
+	for _, role := range c.Config.API.MFA.Require {
+		if role == user.SysRole {
+			return true
+		}
+	}
+	return false
 }
 
-func (c *Core) CanManageTenants(r *route.Request, tenant string) bool {
-	user, err := c.AuthenticatedUser(r)
-	if user == nil || err != nil {
-		r.Fail(route.Unauthorized(err, "Authorization required"))
-		return false
+// mfaWithheld reports whether a user's system role is being withheld
+// from them, until they enrol in multi-factor authentication.  They
+// can still log in (to enrol), and keep their tenant roles.
+func (c *Core) mfaWithheld(user *db.User) bool {
+	return c.mfaRequired(user) && !user.MFAEnabled
+}
+
+func (c *Core) lockoutPolicy(kind string) db.LockoutPolicy {
+	policy := db.LockoutPolicy{
+		Threshold: c.Config.API.Lockout.Threshold,
+		Backoff:   int64(c.Config.API.Lockout.Backoff),
+		Max:       int64(c.Config.API.Lockout.Max),
 	}
+	if kind == db.IPLockout {
+		policy.Threshold = c.Config.API.Lockout.IPThreshold
+	}
+	return policy
+}
 
-	if user.SysRole == "admin" || user.SysRole == "manager" {
-		return true
+// clientIP returns the IP address that the request came from, taking
+// X-Forwarded-For into account only when it was set by one of our
+// trusted proxies (api.trusted-proxies).
+func (c *Core) clientIP(r *route.Request) string {
+	return r.ClientIP(c.trustedProxies)
+}
+
+// lockedOut checks whether logins for an account, or from the remote
+// IP of the request, are locked out after too many failed attempts.
+// Locked out requests are failed with a 429, without the password
+// (or anything else) ever being checked.
+func (c *Core) lockedOut(r *route.Request, account string) bool {
+	for _, kind := range []string{db.IPLockout, db.AccountLockout} {
+		name := account
+		if kind == db.IPLockout {
+			name = c.clientIP(r)
+		}
+
+		lock, err := c.db.GetLoginLockout(kind, name)
+		if err != nil {
+			r.Fail(route.Oops(err, "Unable to log you in"))
+			return true
+		}
+		if lock.Locked() {
+			log.Warnf("refusing login for %s from %s: %s %s is locked out", account, c.clientIP(r), kind, name)
+			r.Fail(route.Errorf(429, nil, "Too many failed login attempts; please try again in %d seconds", lock.LockedUntil-time.Now().Unix()))
+			return true
+		}
 	}
+	return false
+}
 
-	memberships, err := c.db.GetMembershipsForUser(user.UUID)
-	if err != nil {
-		err = fmt.Errorf("failed to retrieve tenant memberships for user %s@%s (uuid %s): %s",
-			user.Account, user.Backend, user.UUID, err)
-		r.Fail(route.Forbidden(err, "Access denied"))
-		return false
+// loginFailed counts a failed login against both the account and the
+// remote IP of the request.
+func (c *Core) loginFailed(r *route.Request, account string) {
+	for _, kind := range []string{db.IPLockout, db.AccountLockout} {
+		name := account
+		if kind == db.IPLockout {
+			name = c.clientIP(r)
+		}
+
+		lock, err := c.db.RecordLoginFailure(kind, name, c.lockoutPolicy(kind))
+		if err != nil {
+			log.Errorf("unable to record failed login for %s %s: %s", kind, name, err)
+			continue
+		}
+		if lock.Locked() {
+			log.Warnf("locking out %s %s after %d failed logins, until %s", kind, name, lock.Failures, time.Unix(lock.LockedUntil, 0).Format(time.RFC3339))
+		}
 	}
+}
 
-	for _, m := range memberships {
-		if m.TenantUUID == tenant {
-			if m.Role == "admin" {
-				return true
-			}
-			break
+func (c *Core) hasTenant(fail bool, r *route.Request, id string) bool {
+	tenant, err := c.db.GetTenant(id)
+	if err != nil || tenant == nil {
+		if fail {
+			r.Fail(route.NotFound(err, "No such tenant"))
 		}
+		return false
 	}
+	return true
+}
 
-	r.Fail(route.Forbidden(nil, "Access denied"))
-	return false
+func (c *Core) CanManageTenants(r *route.Request, tenant string) bool {
+	return c.hasPermission(true, r, tenant, PermManageMembers)
 }
 
 func (c *Core) AuthenticatedUser(r *route.Request) (*db.User, error) {
+	user, _, err := c.authenticate(r)
+	return user, err
+}
+
+// authenticate looks up the session (or auth token) that the request
+// was made with, and the user it belongs to.  Auth tokens that have
+// expired, or are being used from somewhere or for something outside
+// of their scope, do not authenticate anyone.
+func (c *Core) authenticate(r *route.Request) (*db.User, *db.Session, error) {
 	session, err := c.db.GetSession(r.SessionID())
 	if err != nil {
 		log.Errorf("failed to retrieve session [%s] from database: %s", r.SessionID(), err)
-		return nil, err
+		return nil, nil, err
 	}
 	if session == nil {
 		log.Errorf("failed to retrieve session [%s] from database: (no such session)", r.SessionID())
-		return nil, err
+		return nil, nil, err
 	}
-	session.IP = r.RemoteIP()
+	session.IP = c.clientIP(r)
 	session.UserAgent = r.UserAgent()
 
 	if session.Expired(int(c.Config.API.Session.Timeout)) {
 		log.Infof("session %s expired; purging...", r.SessionID())
 		c.db.ClearSession(session.UUID)
-		return nil, nil
+		return nil, nil, nil
+	}
+	if session.Token != "" {
+		if session.Scope.Expired(time.Now()) {
+			log.Infof("auth token '%s' (session %s) expired; denying access", session.Name, session.UUID)
+			return nil, nil, nil
+		}
+		if !session.Scope.Allows(session.IP) {
+			log.Warnf("auth token '%s' (session %s) used from %s, outside of its allow list; denying access", session.Name, session.UUID, session.IP)
+			return nil, nil, nil
+		}
+		if !tokenPermits(r, session.Scope) {
+			log.Warnf("auth token '%s' (session %s) used for %s, outside of its operations; denying access", session.Name, session.UUID, r)
+			return nil, nil, nil
+		}
 	}
+
 	user, err := c.db.GetUserForSession(session.UUID)
 	if err != nil || user == nil {
 		log.Errorf("failed to retrieve user belonging to session [%s] from database: %s", session.UUID, err)
-		return user, err
+		return user, session, err
 	}
 
 	err = c.db.PokeSession(session)
@@ -241,7 +478,7 @@ func (c *Core) AuthenticatedUser(r *route.Request) (*db.User, error) {
 		log.Errorf("Failed to poke session %s with error %s", session, err.Error())
 	}
 
-	return user, nil
+	return user, session, nil
 }
 
 func (c *Core) IsNotAuthenticated(r *route.Request) bool {
@@ -252,37 +489,46 @@ func (c *Core) IsNotAuthenticated(r *route.Request) bool {
 	return false
 }
 
+// IsScopedToken fails the request if it was made with an auth token
+// that has any scope to it.  Scoped tokens cannot manage the account
+// they belong to (issue other tokens, or change its password, say)
+// lest they be used to grant themselves more than they were given.
+func (c *Core) IsScopedToken(r *route.Request) bool {
+	_, session, err := c.authenticate(r)
+	if session == nil || err != nil {
+		r.Fail(route.Unauthorized(err, "Authorization required"))
+		return true
+	}
+	if session.Token != "" && !session.Scope.Unlimited() {
+		r.Fail(route.Forbidden(nil, "Access denied (scoped auth tokens cannot manage accounts)"))
+		return true
+	}
+	return false
+}
+
 func (c *Core) IsNotSystemAdmin(r *route.Request) bool {
-	return !c.hasRole(true, r, "", "system/admin")
+	return !c.hasSystemRole(true, r, "admin")
 }
 
 func (c *Core) IsNotSystemManager(r *route.Request) bool {
-	return !c.hasRole(true, r, "", "system/manager", "system/admin")
+	return !c.hasSystemRole(true, r, "manager", "admin")
 }
 
 func (c *Core) IsNotSystemEngineer(r *route.Request) bool {
-	return !c.hasRole(true, r, "", "system/engineer", "system/manager", "system/admin")
-}
-
-func (c *Core) IsNotTenantAdmin(r *route.Request, tenant string) bool {
-	return !c.hasRole(true, r, tenant, "tenant/admin", "system/manager", "system/admin") ||
-		!c.hasTenant(true, r, tenant)
-}
-
-func (c *Core) IsNotTenantEngineer(r *route.Request, tenant string) bool {
-	return !c.hasRole(true, r, tenant, "tenant/engineer", "tenant/admin", "system/*") ||
-		!c.hasTenant(true, r, tenant)
+	return !c.hasSystemRole(true, r, "engineer", "manager", "admin")
 }
 
-func (c *Core) IsNotTenantOperator(r *route.Request, tenant string) bool {
-	return !c.hasRole(true, r, tenant, "tenant/*", "system/*") ||
+// IsNotPermitted fails the request unless the authenticated user holds
+// the given permission on a tenant (that actually exists).
+func (c *Core) IsNotPermitted(r *route.Request, tenant, perm string) bool {
+	return !c.hasPermission(true, r, tenant, perm) ||
 		!c.hasTenant(true, r, tenant)
 }
 
 func (c *Core) CanSeeCredentials(r *route.Request, tenant string) bool {
-	return c.hasRole(false, r, tenant, "tenant/engineer", "tenant/admin", "system/*") &&
+	return c.hasPermission(false, r, tenant, PermCredentials) &&
 		c.hasTenant(false, r, tenant)
 }
 func (c *Core) CanSeeGlobalCredentials(r *route.Request) bool {
-	return c.hasRole(false, r, "", "system/*")
+	return c.hasSystemRole(false, r, "*")
 }
@@ -32,6 +32,7 @@ type AuthProviderConfig struct {
 	WebEntry string `json:"web_entry"`
 	CLIEntry string `json:"cli_entry"`
 	Redirect string `json:"redirect"`
+	Password bool   `json:"password,omitempty"`
 
 	Properties map[string]interface{} `json:"properties,omitempty"`
 }
@@ -47,6 +48,15 @@ type AuthProvider interface {
 	HandleRedirect(*route.Request) *db.User
 }
 
+// A PasswordAuthProvider checks usernames and passwords itself, via
+// POST /v2/auth/login, rather than sending people off to some other
+// system to log in.  Bad credentials yield a nil user and no error.
+type PasswordAuthProvider interface {
+	AuthProvider
+
+	Authenticate(username, password string) (*db.User, error)
+}
+
 type AuthProviderBase struct {
 	Name       string
 	Identifier string
@@ -112,8 +122,11 @@ func (p *AuthProviderBase) Assign(user *db.User, tenant, role string) bool {
 
 	} else {
 		p.Infof("assigning tenant role %s on '%s' to %s", role, tenant, who)
-		if !IsValidTenantRole(role) {
-			p.Errorf("unable to assign tenant role %s on '%s' to %s: '%s' is not a valid tenant role", role, tenant, who, role)
+		if ok, err := p.core.IsValidTenantRole(tenant, role); err != nil {
+			p.Errorf("unable to assign tenant role %s on '%s' to %s: %s", role, tenant, who, err)
+			return false
+		} else if !ok {
+			p.Errorf("unable to assign tenant role %s on '%s' to %s: '%s' is neither a built-in role, nor one of the tenant's custom roles", role, tenant, who, role)
 			return false
 		}
 	}
@@ -142,8 +155,8 @@ func (p *AuthProviderBase) SaveAssignments(DB *db.DB, user *db.User) bool {
 	user.SysRole = ""
 
 	p.Infof("processing %d role assignments for %s", len(p.assignments), who)
-	p.Infof("clearing pre-existing tenant assignments for %s", who)
-	if err := DB.ClearMembershipsFor(user); err != nil {
+	p.Infof("clearing pre-existing tenant assignments for %s (leaving any granted by hand)", who)
+	if err := DB.ClearExternalMembershipsFor(user); err != nil {
 		p.Errorf("failed to clear pre-existing tenant assignments for %s: %s", who, err)
 		return false
 	}
@@ -160,11 +173,14 @@ func (p *AuthProviderBase) SaveAssignments(DB *db.DB, user *db.User) bool {
 				return false
 			}
 			p.Infof("saving assignment of tenant role %s on '%s' to %s", role, on, who)
-			err = DB.AddUserToTenant(user.UUID, tenant.UUID, role)
+			added, err := DB.AddExternalUserToTenant(user.UUID, tenant.UUID, role)
 			if err != nil {
 				p.Errorf("failed to assign tenant role %s on '%s' to %s: %s", role, on, who, err)
 				return false
 			}
+			if !added {
+				p.Infof("not assigning tenant role %s on '%s' to %s: keeping the role they were granted by hand", role, on, who)
+			}
 		}
 	}
 
@@ -0,0 +1,427 @@
+package core
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/pborman/uuid"
+
+	"github.com/shieldproject/shield/db"
+	"github.com/shieldproject/shield/route"
+	"github.com/shieldproject/shield/util"
+)
+
+type LDAPAuthProvider struct {
+	AuthProviderBase
+
+	URL           string `json:"url"`
+	Directory     string `json:"directory"`
+	StartTLS      bool   `json:"start_tls"`
+	SkipVerifyTLS bool   `json:"skip_verify_tls"`
+	CACertificate string `json:"ca_certificate"`
+	Timeout       int    `json:"timeout"`
+
+	BindDN       string `json:"bind_dn"`
+	BindPassword string `json:"bind_password"`
+	UserDN       string `json:"user_dn"`
+
+	UserSearch struct {
+		Base   string `json:"base"`
+		Filter string `json:"filter"`
+	} `json:"user_search"`
+
+	GroupSearch struct {
+		Base   string `json:"base"`
+		Filter string `json:"filter"`
+	} `json:"group_search"`
+
+	Attributes struct {
+		Account string `json:"account"`
+		Name    string `json:"name"`
+		Groups  string `json:"groups"`
+	} `json:"attributes"`
+
+	Mapping []struct {
+		Tenant string `json:"tenant"`
+		Rights []struct {
+			Group string `json:"group"`
+			Role  string `json:"role"`
+		} `json:"rights"`
+	} `json:"mapping"`
+
+	tls *tls.Config
+
+	/* how to reach the directory server; normally connect(),
+	   but the tests talk to a fake directory instead. */
+	dial func() (ldap.Client, error)
+
+	/* role assignments are accumulated on the provider itself,
+	   so only one login can be processed at a time. */
+	lock sync.Mutex
+}
+
+func (p *LDAPAuthProvider) Configure(raw map[interface{}]interface{}) error {
+	b, err := json.Marshal(util.StringifyKeys(raw))
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(b, p)
+	if err != nil {
+		return err
+	}
+
+	if p.URL == "" {
+		return fmt.Errorf("invalid configuration for LDAP Provider: missing `url' value")
+	}
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return fmt.Errorf("invalid configuration for LDAP Provider: invalid `url' value '%s': %s", p.URL, err)
+	}
+	switch u.Scheme {
+	case "ldap":
+	case "ldaps":
+		if p.StartTLS {
+			return fmt.Errorf("invalid configuration for LDAP Provider: `start_tls' cannot be used with an ldaps:// `url'")
+		}
+	default:
+		return fmt.Errorf("invalid configuration for LDAP Provider: `url' must be an ldap:// or ldaps:// URL")
+	}
+
+	if p.UserDN == "" && p.UserSearch.Base == "" {
+		return fmt.Errorf("invalid configuration for LDAP Provider: one of `user_dn' or `user_search.base' must be given")
+	}
+	if p.UserDN != "" && p.UserSearch.Base != "" {
+		return fmt.Errorf("invalid configuration for LDAP Provider: only one of `user_dn' or `user_search.base' can be given")
+	}
+	if p.UserDN != "" && !strings.Contains(p.UserDN, "{username}") {
+		return fmt.Errorf("invalid configuration for LDAP Provider: `user_dn' must contain a {username} placeholder")
+	}
+	if p.BindDN != "" && p.BindPassword == "" {
+		return fmt.Errorf("invalid configuration for LDAP Provider: missing `bind_password' value (required with `bind_dn')")
+	}
+
+	for _, m := range p.Mapping {
+		for _, right := range m.Rights {
+			if right.Group == "" {
+				continue
+			}
+			if _, err := ldap.ParseDN(right.Group); err != nil {
+				return fmt.Errorf("invalid configuration for LDAP Provider: mapping for tenant '%s' has an invalid group DN '%s': %s", m.Tenant, right.Group, err)
+			}
+		}
+	}
+
+	if p.Timeout == 0 {
+		p.Timeout = 10
+	}
+
+	/* Active Directory names its users by sAMAccountName, not uid,
+	   so it gets its own set of defaults. */
+	switch p.Directory {
+	case "", "ldap":
+		if p.UserSearch.Filter == "" {
+			p.UserSearch.Filter = "(uid={username})"
+		}
+		if p.Attributes.Account == "" {
+			p.Attributes.Account = "uid"
+		}
+		if p.Attributes.Name == "" {
+			p.Attributes.Name = "cn"
+		}
+
+	case "active-directory":
+		if p.UserSearch.Filter == "" {
+			p.UserSearch.Filter = "(sAMAccountName={username})"
+		}
+		if p.Attributes.Account == "" {
+			p.Attributes.Account = "sAMAccountName"
+		}
+		if p.Attributes.Name == "" {
+			p.Attributes.Name = "displayName"
+		}
+
+	default:
+		return fmt.Errorf("invalid configuration for LDAP Provider: `directory' must be either `ldap' or `active-directory'")
+	}
+	if p.GroupSearch.Filter == "" {
+		p.GroupSearch.Filter = "(|(member={dn})(uniqueMember={dn}))"
+	}
+	if p.Attributes.Groups == "" {
+		p.Attributes.Groups = "memberOf"
+	}
+
+	p.tls = &tls.Config{
+		ServerName:         u.Hostname(),
+		InsecureSkipVerify: p.SkipVerifyTLS,
+	}
+	if p.CACertificate != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(p.CACertificate)) {
+			return fmt.Errorf("invalid configuration for LDAP Provider: no PEM-encoded certificates found in `ca_certificate'")
+		}
+		p.tls.RootCAs = pool
+	}
+
+	p.properties = util.StringifyKeys(raw).(map[string]interface{})
+	p.dial = p.connect
+
+	return nil
+}
+
+func (p *LDAPAuthProvider) Configuration(private bool) AuthProviderConfig {
+	cfg := p.AuthProviderBase.Configuration(private)
+	cfg.Password = true
+	return cfg
+}
+
+func (p *LDAPAuthProvider) WireUpTo(c *Core) {
+	p.core = c
+}
+
+func (p *LDAPAuthProvider) ReferencedTenants() []string {
+	ll := make([]string, 0)
+	for _, m := range p.Mapping {
+		ll = append(ll, m.Tenant)
+	}
+	return ll
+}
+
+// LDAP logins don't involve any redirection; people sign in with their
+// directory username and password, right from the login page.
+func (p *LDAPAuthProvider) Initiate(r *route.Request) {
+	r.Redirect(302, "/#!/login")
+}
+
+func (p *LDAPAuthProvider) HandleRedirect(r *route.Request) *db.User {
+	p.Errorf("LDAP authentication does not involve redirection")
+	return nil
+}
+
+func (p *LDAPAuthProvider) Authenticate(username, password string) (*db.User, error) {
+	/* an empty password would make for an unauthenticated bind,
+	   which most directories happily allow, and which would let
+	   anyone log in as anyone else. */
+	if username == "" || password == "" {
+		return nil, nil
+	}
+
+	conn, err := p.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var entry *ldap.Entry
+	if p.UserDN != "" {
+		dn := strings.ReplaceAll(p.UserDN, "{username}", ldap.EscapeDN(username))
+		if err := conn.Bind(dn, password); err != nil {
+			if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+				p.Infof("invalid credentials supplied for %s", dn)
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unable to bind as %s: %s", dn, err)
+		}
+
+		entry, err = p.lookup(conn, dn)
+		if err != nil {
+			return nil, err
+		}
+
+	} else {
+		if err := p.bind(conn); err != nil {
+			return nil, err
+		}
+
+		entry, err = p.search(conn, username)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			p.Infof("no directory entry found for user '%s'", username)
+			return nil, nil
+		}
+
+		if err := conn.Bind(entry.DN, password); err != nil {
+			if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+				p.Infof("invalid credentials supplied for %s", entry.DN)
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unable to bind as %s: %s", entry.DN, err)
+		}
+
+		/* go back to being ourselves, in case the directory
+		   doesn't let users search for their own groups. */
+		if err := p.bind(conn); err != nil {
+			return nil, err
+		}
+	}
+
+	groups, err := p.groups(conn, entry, username)
+	if err != nil {
+		return nil, err
+	}
+
+	account := entry.GetEqualFoldAttributeValue(p.Attributes.Account)
+	if account == "" {
+		p.Infof("no `%s' attribute found on %s; falling back to username '%s'", p.Attributes.Account, entry.DN, username)
+		account = username
+	}
+	name := entry.GetEqualFoldAttributeValue(p.Attributes.Name)
+	if name == "" {
+		name = account
+	}
+
+	// check if the user that logged in via ldap already exists
+	if p.core.db == nil {
+		return nil, fmt.Errorf("no handle for the core database found!")
+	}
+	user, err := p.core.db.GetUser(account, p.Identifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve user %s@%s from database: %s", account, p.Identifier, err)
+	}
+	if user == nil {
+		user = &db.User{
+			UUID:    uuid.NewRandom().String(),
+			Name:    name,
+			Account: account,
+			Backend: p.Identifier,
+			SysRole: "",
+		}
+		p.core.db.CreateUser(user)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.ClearAssignments()
+Mapping:
+	for _, candidate := range p.Mapping {
+		for _, match := range candidate.Rights {
+			if match.Group != "" && !ldapMemberOf(groups, match.Group) {
+				continue
+			}
+
+			if !p.Assign(user, candidate.Tenant, match.Role) {
+				return nil, fmt.Errorf("unable to assign roles to %s@%s", account, p.Identifier)
+			}
+			continue Mapping
+		}
+	}
+	if !p.SaveAssignments(p.core.db, user) {
+		return nil, fmt.Errorf("unable to save role assignments for %s@%s", account, p.Identifier)
+	}
+
+	return user, nil
+}
+
+func (p *LDAPAuthProvider) connect() (ldap.Client, error) {
+	timeout := time.Duration(p.Timeout) * time.Second
+
+	conn, err := ldap.DialURL(p.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: timeout}),
+		ldap.DialWithTLSConfig(p.tls))
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to %s: %s", p.URL, err)
+	}
+	conn.SetTimeout(timeout)
+
+	if p.StartTLS {
+		if err := conn.StartTLS(p.tls); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("unable to negotiate StartTLS with %s: %s", p.URL, err)
+		}
+	}
+
+	return conn, nil
+}
+
+func (p *LDAPAuthProvider) bind(conn ldap.Client) error {
+	if p.BindDN == "" {
+		return nil
+	}
+	if err := conn.Bind(p.BindDN, p.BindPassword); err != nil {
+		return fmt.Errorf("unable to bind as %s: %s", p.BindDN, err)
+	}
+	return nil
+}
+
+func (p *LDAPAuthProvider) attributes() []string {
+	return []string{p.Attributes.Account, p.Attributes.Name, p.Attributes.Groups}
+}
+
+func (p *LDAPAuthProvider) lookup(conn ldap.Client, dn string) (*ldap.Entry, error) {
+	res, err := conn.Search(ldap.NewSearchRequest(dn,
+		ldap.ScopeBaseObject, ldap.NeverDerefAliases, 1, p.Timeout, false,
+		"(objectClass=*)", p.attributes(), nil))
+	if err != nil {
+		return nil, fmt.Errorf("unable to look up %s: %s", dn, err)
+	}
+	if len(res.Entries) != 1 {
+		return nil, fmt.Errorf("unable to look up %s: no such entry", dn)
+	}
+	return res.Entries[0], nil
+}
+
+func (p *LDAPAuthProvider) search(conn ldap.Client, username string) (*ldap.Entry, error) {
+	filter := strings.ReplaceAll(p.UserSearch.Filter, "{username}", ldap.EscapeFilter(username))
+	res, err := conn.Search(ldap.NewSearchRequest(p.UserSearch.Base,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, p.Timeout, false,
+		filter, p.attributes(), nil))
+	if err != nil {
+		return nil, fmt.Errorf("unable to search %s for %s: %s", p.UserSearch.Base, filter, err)
+	}
+	switch len(res.Entries) {
+	case 0:
+		return nil, nil
+	case 1:
+		return res.Entries[0], nil
+	default:
+		return nil, fmt.Errorf("search of %s for %s matched more than one entry", p.UserSearch.Base, filter)
+	}
+}
+
+// groups returns the DNs of all the groups that a user belongs to,
+// per their own entry's group attribute (i.e. memberOf), and per a
+// search for groups that list them as a member, if so configured.
+func (p *LDAPAuthProvider) groups(conn ldap.Client, entry *ldap.Entry, username string) ([]string, error) {
+	groups := entry.GetEqualFoldAttributeValues(p.Attributes.Groups)
+
+	if p.GroupSearch.Base != "" {
+		filter := strings.ReplaceAll(p.GroupSearch.Filter, "{dn}", ldap.EscapeFilter(entry.DN))
+		filter = strings.ReplaceAll(filter, "{username}", ldap.EscapeFilter(username))
+
+		res, err := conn.Search(ldap.NewSearchRequest(p.GroupSearch.Base,
+			ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, p.Timeout, false,
+			filter, []string{"dn"}, nil))
+		if err != nil {
+			return nil, fmt.Errorf("unable to search %s for %s: %s", p.GroupSearch.Base, filter, err)
+		}
+		for _, group := range res.Entries {
+			groups = append(groups, group.DN)
+		}
+	}
+
+	return groups, nil
+}
+
+func ldapMemberOf(groups []string, group string) bool {
+	want, err := ldap.ParseDN(group)
+	if err != nil {
+		return false
+	}
+	for _, g := range groups {
+		if have, err := ldap.ParseDN(g); err == nil && have.EqualFold(want) {
+			return true
+		}
+	}
+	return false
+}
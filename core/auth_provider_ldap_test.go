@@ -0,0 +1,319 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/go-ldap/ldap/v3"
+
+	// sql drivers
+	_ "github.com/mattn/go-sqlite3"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/shieldproject/shield/db"
+)
+
+// A fakeDirectory stands in for an LDAP server.  Anything that the
+// LDAP provider has no business doing (adds, deletes, etc.) panics.
+type fakeDirectory struct {
+	ldap.Client
+
+	passwords map[string]string        /* DN -> password */
+	entries   map[string]*ldap.Entry   /* DN -> entry, for base object lookups */
+	searches  map[string][]*ldap.Entry /* filter -> entries, for subtree searches */
+
+	bound    string
+	binds    []string
+	searched []string
+}
+
+func (d *fakeDirectory) Bind(dn, password string) error {
+	d.binds = append(d.binds, dn)
+	if want, ok := d.passwords[dn]; !ok || want != password {
+		return ldap.NewError(ldap.LDAPResultInvalidCredentials, fmt.Errorf("invalid credentials"))
+	}
+	d.bound = dn
+	return nil
+}
+
+func (d *fakeDirectory) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	d.searched = append(d.searched, fmt.Sprintf("%s as %s", req.Filter, d.bound))
+	if req.Scope == ldap.ScopeBaseObject {
+		if entry, ok := d.entries[req.BaseDN]; ok {
+			return &ldap.SearchResult{Entries: []*ldap.Entry{entry}}, nil
+		}
+		return nil, ldap.NewError(ldap.LDAPResultNoSuchObject, fmt.Errorf("no such object"))
+	}
+	return &ldap.SearchResult{Entries: d.searches[req.Filter]}, nil
+}
+
+func (d *fakeDirectory) Close() error {
+	return nil
+}
+
+var _ = Describe("LDAP Authentication Provider", func() {
+	var (
+		c   *Core
+		dir *fakeDirectory
+	)
+
+	const (
+		ServiceDN = "CN=shield,OU=Service Accounts,DC=example,DC=com"
+		JaneDN    = "CN=Jane Doe,OU=People,DC=example,DC=com"
+		AdminsDN  = "CN=SHIELD Admins,OU=Groups,DC=example,DC=com"
+		BackupsDN = "CN=Backup Operators,OU=Groups,DC=example,DC=com"
+	)
+
+	BeforeEach(func() {
+		d, err := db.Connect(":memory:")
+		Ω(err).ShouldNot(HaveOccurred())
+		_, err = d.Setup(0)
+		Ω(err).ShouldNot(HaveOccurred())
+		c = &Core{db: d}
+
+		dir = &fakeDirectory{
+			passwords: map[string]string{
+				ServiceDN: "s3rvice",
+				JaneDN:    "janespw",
+			},
+			entries:  map[string]*ldap.Entry{},
+			searches: map[string][]*ldap.Entry{},
+		}
+	})
+
+	provider := func(properties map[interface{}]interface{}) *LDAPAuthProvider {
+		p := &LDAPAuthProvider{
+			AuthProviderBase: AuthProviderBase{
+				Name:       "Corporate AD",
+				Identifier: "ad",
+				Type:       "ldap",
+			},
+		}
+		Ω(p.Configure(properties)).Should(Succeed())
+		p.WireUpTo(c)
+		p.dial = func() (ldap.Client, error) { return dir, nil }
+		return p
+	}
+
+	mapping := []interface{}{
+		map[interface{}]interface{}{
+			"tenant": "SYSTEM",
+			"rights": []interface{}{
+				map[interface{}]interface{}{"group": AdminsDN, "role": "admin"},
+			},
+		},
+		map[interface{}]interface{}{
+			"tenant": "acme",
+			"rights": []interface{}{
+				map[interface{}]interface{}{"group": AdminsDN, "role": "admin"},
+				map[interface{}]interface{}{"group": BackupsDN, "role": "operator"},
+			},
+		},
+	}
+
+	/* search-then-bind, against Active Directory */
+	activeDirectory := func() *LDAPAuthProvider {
+		return provider(map[interface{}]interface{}{
+			"url":           "ldaps://ad.example.com",
+			"directory":     "active-directory",
+			"bind_dn":       ServiceDN,
+			"bind_password": "s3rvice",
+			"user_search": map[interface{}]interface{}{
+				"base": "OU=People,DC=example,DC=com",
+			},
+			"mapping": mapping,
+		})
+	}
+
+	jane := func(groups ...string) *ldap.Entry {
+		return ldap.NewEntry(JaneDN, map[string][]string{
+			"sAMAccountName": {"jdoe"},
+			"displayName":    {"Jane Doe"},
+			"memberOf":       groups,
+		})
+	}
+
+	memberships := func(user *db.User) map[string]string {
+		l, err := c.db.GetMembershipsForUser(user.UUID)
+		Ω(err).ShouldNot(HaveOccurred())
+		roles := make(map[string]string)
+		for _, m := range l {
+			roles[m.TenantName] = m.Role
+		}
+		return roles
+	}
+
+	Context("with search-then-bind", func() {
+		It("binds as the service account to find the user, then as the user", func() {
+			dir.searches["(sAMAccountName=jdoe)"] = []*ldap.Entry{jane(BackupsDN)}
+
+			user, err := activeDirectory().Authenticate("jdoe", "janespw")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(user).ShouldNot(BeNil())
+			Ω(user.Account).Should(Equal("jdoe"))
+			Ω(user.Name).Should(Equal("Jane Doe"))
+			Ω(user.Backend).Should(Equal("ad"))
+
+			Ω(dir.binds).Should(Equal([]string{ServiceDN, JaneDN, ServiceDN}))
+			Ω(dir.searched).Should(Equal([]string{"(sAMAccountName=jdoe) as " + ServiceDN}))
+		})
+
+		It("escapes the username in the search filter", func() {
+			user, err := activeDirectory().Authenticate("*)(sAMAccountName=*", "janespw")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(user).Should(BeNil())
+			Ω(dir.searched).Should(Equal([]string{`(sAMAccountName=\2a\29\28sAMAccountName=\2a) as ` + ServiceDN}))
+		})
+
+		It("refuses bad passwords", func() {
+			dir.searches["(sAMAccountName=jdoe)"] = []*ldap.Entry{jane(AdminsDN)}
+
+			user, err := activeDirectory().Authenticate("jdoe", "wrong")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(user).Should(BeNil())
+		})
+
+		It("refuses unknown users", func() {
+			user, err := activeDirectory().Authenticate("nobody", "janespw")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(user).Should(BeNil())
+		})
+
+		It("never tries an empty password, which would be an unauthenticated bind", func() {
+			dir.passwords[JaneDN] = ""
+			dir.searches["(sAMAccountName=jdoe)"] = []*ldap.Entry{jane(AdminsDN)}
+
+			user, err := activeDirectory().Authenticate("jdoe", "")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(user).Should(BeNil())
+			Ω(dir.binds).Should(BeEmpty())
+		})
+
+		It("fails if the service account cannot bind", func() {
+			dir.passwords[ServiceDN] = "rotated"
+			dir.searches["(sAMAccountName=jdoe)"] = []*ldap.Entry{jane(AdminsDN)}
+
+			_, err := activeDirectory().Authenticate("jdoe", "janespw")
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Context("with direct bind", func() {
+		BeforeEach(func() {
+			dir.passwords = map[string]string{
+				"uid=jdoe,ou=People,dc=example,dc=com": "janespw",
+			}
+			dir.entries["uid=jdoe,ou=People,dc=example,dc=com"] = ldap.NewEntry("uid=jdoe,ou=People,dc=example,dc=com", map[string][]string{
+				"uid": {"jdoe"},
+				"cn":  {"Jane Doe"},
+			})
+		})
+
+		openLDAP := func() *LDAPAuthProvider {
+			return provider(map[interface{}]interface{}{
+				"url":     "ldap://ldap.example.com",
+				"user_dn": "uid={username},ou=People,dc=example,dc=com",
+				"group_search": map[interface{}]interface{}{
+					"base": "ou=Groups,dc=example,dc=com",
+				},
+				"mapping": []interface{}{
+					map[interface{}]interface{}{
+						"tenant": "acme",
+						"rights": []interface{}{
+							map[interface{}]interface{}{"group": "cn=engineers,ou=Groups,dc=example,dc=com", "role": "engineer"},
+						},
+					},
+				},
+			})
+		}
+
+		It("binds as the user, and finds their groups by searching", func() {
+			dir.searches["(|(member=uid=jdoe,ou=People,dc=example,dc=com)(uniqueMember=uid=jdoe,ou=People,dc=example,dc=com))"] = []*ldap.Entry{
+				ldap.NewEntry("cn=engineers,ou=Groups,dc=example,dc=com", nil),
+			}
+
+			user, err := openLDAP().Authenticate("jdoe", "janespw")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(user).ShouldNot(BeNil())
+			Ω(user.Account).Should(Equal("jdoe"))
+			Ω(user.Name).Should(Equal("Jane Doe"))
+			Ω(dir.binds).Should(Equal([]string{"uid=jdoe,ou=People,dc=example,dc=com"}))
+			Ω(memberships(user)).Should(Equal(map[string]string{"acme": "engineer"}))
+		})
+
+		It("refuses bad passwords", func() {
+			user, err := openLDAP().Authenticate("jdoe", "wrong")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(user).Should(BeNil())
+		})
+	})
+
+	Context("mapping groups to roles", func() {
+		It("assigns the role of the first matching group, on each tenant", func() {
+			dir.searches["(sAMAccountName=jdoe)"] = []*ldap.Entry{jane(BackupsDN, AdminsDN)}
+
+			user, err := activeDirectory().Authenticate("jdoe", "janespw")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(user.SysRole).Should(Equal("admin"))
+			Ω(memberships(user)).Should(Equal(map[string]string{"acme": "admin"}))
+		})
+
+		It("compares group DNs without regard to case or spacing", func() {
+			dir.searches["(sAMAccountName=jdoe)"] = []*ldap.Entry{jane("cn=backup operators, ou=groups, dc=example, dc=com")}
+
+			user, err := activeDirectory().Authenticate("jdoe", "janespw")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(user.SysRole).Should(Equal(""))
+			Ω(memberships(user)).Should(Equal(map[string]string{"acme": "operator"}))
+		})
+
+		It("takes away roles when the user leaves a group", func() {
+			dir.searches["(sAMAccountName=jdoe)"] = []*ldap.Entry{jane(AdminsDN)}
+			user, err := activeDirectory().Authenticate("jdoe", "janespw")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(memberships(user)).Should(Equal(map[string]string{"acme": "admin"}))
+
+			dir.searches["(sAMAccountName=jdoe)"] = []*ldap.Entry{jane()}
+			user, err = activeDirectory().Authenticate("jdoe", "janespw")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(user.SysRole).Should(Equal(""))
+			Ω(memberships(user)).Should(BeEmpty())
+		})
+
+		It("maps groups to the tenant's custom roles", func() {
+			tenant, err := c.db.CreateTenant(&db.Tenant{Name: "acme"})
+			Ω(err).ShouldNot(HaveOccurred())
+			_, err = c.db.CreateRole(&db.Role{TenantUUID: tenant.UUID, Name: "restorer", Permissions: []string{PermRestore, PermView}})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			p := activeDirectory()
+			p.Mapping[1].Rights[1].Role = "restorer"
+			dir.searches["(sAMAccountName=jdoe)"] = []*ldap.Entry{jane(BackupsDN)}
+
+			user, err := p.Authenticate("jdoe", "janespw")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(user).ShouldNot(BeNil())
+			Ω(memberships(user)).Should(Equal(map[string]string{"acme": "restorer"}))
+
+			p.Mapping[1].Rights[1].Role = "auditor"
+			_, err = p.Authenticate("jdoe", "janespw")
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	It("configures Active Directory defaults with the active-directory preset", func() {
+		p := activeDirectory()
+		Ω(p.UserSearch.Filter).Should(Equal("(sAMAccountName={username})"))
+		Ω(p.Attributes.Account).Should(Equal("sAMAccountName"))
+		Ω(p.Attributes.Name).Should(Equal("displayName"))
+		Ω(p.Attributes.Groups).Should(Equal("memberOf"))
+
+		err := (&LDAPAuthProvider{}).Configure(map[interface{}]interface{}{
+			"url":       "ldaps://ad.example.com",
+			"directory": "novell",
+			"user_dn":   "uid={username},dc=example,dc=com",
+		})
+		Ω(err).Should(HaveOccurred())
+	})
+})
@@ -0,0 +1,249 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pborman/uuid"
+
+	"github.com/shieldproject/shield/db"
+	"github.com/shieldproject/shield/lib/oidc"
+	"github.com/shieldproject/shield/route"
+	"github.com/shieldproject/shield/util"
+)
+
+// How long someone has to log into the identity provider, once they
+// have been sent there, before their login attempt is no longer good.
+const oidcLoginTimeout = 10 * time.Minute
+
+type OIDCAuthProvider struct {
+	AuthProviderBase
+
+	Issuer        string   `json:"issuer"`
+	ClientID      string   `json:"client_id"`
+	ClientSecret  string   `json:"client_secret"`
+	DeploymentURI string   `json:"deployment_uri"`
+	Scopes        []string `json:"scopes"`
+	SkipVerifyTLS bool     `json:"skip_verify_tls"`
+
+	Claims struct {
+		Name   string `json:"name"`
+		Groups string `json:"groups"`
+	} `json:"claims"`
+
+	Mapping []oidc.Mapping `json:"mapping"`
+
+	oidc *oidc.Client
+
+	/* logins in progress are held by the browser, in a cookie,
+	   sealed with this key (see oidc.Login) */
+	key []byte
+}
+
+func (p *OIDCAuthProvider) Configure(raw map[interface{}]interface{}) error {
+	b, err := json.Marshal(util.StringifyKeys(raw))
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(b, p)
+	if err != nil {
+		return err
+	}
+
+	if p.Issuer == "" {
+		return fmt.Errorf("invalid configuration for OpenID Connect Provider: missing `issuer' value")
+	}
+
+	if p.ClientID == "" {
+		return fmt.Errorf("invalid configuration for OpenID Connect Provider: missing `client_id' value")
+	}
+
+	if p.DeploymentURI == "" {
+		return fmt.Errorf("invalid configuration for OpenID Connect Provider: missing `deployment_uri' value")
+	}
+
+	for _, m := range p.Mapping {
+		if err := m.Validate(); err != nil {
+			return fmt.Errorf("invalid configuration for OpenID Connect Provider: %s", err)
+		}
+	}
+
+	if len(p.Scopes) == 0 {
+		p.Scopes = strings.Split(oidc.DefaultScopes, " ")
+	}
+	if p.Claims.Name == "" {
+		p.Claims.Name = "name"
+	}
+	if p.Claims.Groups == "" {
+		p.Claims.Groups = "groups"
+	}
+
+	p.DeploymentURI = strings.TrimSuffix(p.DeploymentURI, "/")
+	p.properties = util.StringifyKeys(raw).(map[string]interface{})
+
+	p.oidc = oidc.NewClient(p.Issuer, p.ClientID, p.ClientSecret, !p.SkipVerifyTLS)
+
+	key, err := oidc.Random(32)
+	if err != nil {
+		return err
+	}
+	p.key = []byte(key)
+
+	return nil
+}
+
+func (p *OIDCAuthProvider) WireUpTo(c *Core) {
+	p.core = c
+}
+
+func (p *OIDCAuthProvider) ReferencedTenants() []string {
+	ll := make([]string, 0)
+	for _, m := range p.Mapping {
+		ll = append(ll, m.Tenant)
+	}
+	return ll
+}
+
+func (p *OIDCAuthProvider) Initiate(r *route.Request) {
+	login, challenge, err := oidc.NewLogin(oidcLoginTimeout)
+	if err != nil {
+		p.Errorf("unable to start login: %s", err)
+		r.Redirect(302, "/fail/e500")
+		return
+	}
+
+	uri, err := p.oidc.AuthorizationURL(p.redirectURI(), strings.Join(p.Scopes, " "), login.State, login.Nonce, challenge)
+	if err != nil {
+		p.Errorf("unable to start login: %s", err)
+		r.Redirect(302, "/fail/e500")
+		return
+	}
+
+	sealed, err := login.Seal(p.key)
+	if err != nil {
+		p.Errorf("unable to start login: %s", err)
+		r.Redirect(302, "/fail/e500")
+		return
+	}
+
+	/* tie the login to this browser, so that nobody else can
+	   finish it for them (and log them in as someone else) */
+	r.SetCookie(p.cookie(), sealed, "/auth")
+	r.Redirect(302, uri)
+}
+
+func (p *OIDCAuthProvider) HandleRedirect(r *route.Request) *db.User {
+	if e := r.Param("error", ""); e != "" {
+		p.Errorf("identity provider refused the login: %s (%s)", e, r.Param("error_description", ""))
+		return nil
+	}
+
+	cookie, err := r.Req.Cookie(p.cookie())
+	if err != nil {
+		p.Errorf("no login in progress for this browser")
+		return nil
+	}
+	r.ClearCookie(p.cookie(), "/auth")
+
+	login, err := oidc.OpenLogin(cookie.Value, p.key, time.Now())
+	if err != nil {
+		p.Errorf("no usable login in progress for this browser: %s", err)
+		return nil
+	}
+	if state := r.Param("state", ""); state == "" || state != login.State {
+		p.Errorf("login state from the identity provider does not match the one for this browser")
+		return nil
+	}
+
+	code := r.Param("code", "")
+	if code == "" {
+		p.Errorf("no code parameter was supplied by the identity provider")
+		return nil
+	}
+
+	tokens, err := p.oidc.Exchange(code, p.redirectURI(), login.Verifier)
+	if err != nil {
+		p.Errorf("unable to fetch tokens: %s", err)
+		return nil
+	}
+
+	claims, err := p.oidc.VerifyIDToken(tokens.IDToken, login.Nonce)
+	if err != nil {
+		p.Errorf("unable to verify id token: %s", err)
+		return nil
+	}
+	if tokens.AccessToken != "" {
+		/* some identity providers only put the basics in the id token,
+		   but not all access tokens are good for the userinfo endpoint,
+		   so we make do with what we have if that doesn't work out. */
+		info, err := p.oidc.UserInfo(tokens.AccessToken)
+		if err != nil {
+			p.Infof("unable to retrieve user information (continuing with id token claims alone): %s", err)
+		} else if sub := info.String("sub"); sub != "" && sub != claims.String("sub") {
+			p.Errorf("userinfo subject '%s' does not match id token subject '%s'", sub, claims.String("sub"))
+			return nil
+		} else {
+			claims.Merge(info)
+		}
+	}
+
+	/* the subject is the only claim that the identity provider
+	   promises is unique, and never reassigned, for its issuer (which
+	   is pinned by configuration); everything else, usernames included,
+	   is only ever good for display. */
+	account := claims.String("sub")
+	name := claims.String(p.Claims.Name)
+	if name == "" {
+		name = claims.String("preferred_username")
+	}
+	if name == "" {
+		name = account
+	}
+
+	// check if the user that logged in via oidc already exists
+	if p.core.db == nil {
+		p.Errorf("no handle for the core database found!")
+		return nil
+	}
+	user, err := p.core.db.GetUser(account, p.Identifier)
+	if err != nil {
+		p.Errorf("failed to retrieve user %s@%s from database: %s", account, p.Identifier, err)
+		return nil
+	}
+	if user == nil {
+		user = &db.User{
+			UUID:    uuid.NewRandom().String(),
+			Name:    name,
+			Account: account,
+			Backend: p.Identifier,
+			SysRole: "",
+		}
+		p.core.db.CreateUser(user)
+	}
+	user.Name = name
+
+	p.ClearAssignments()
+	for _, m := range p.Mapping {
+		if role, ok := m.Role(claims, p.Claims.Groups); ok {
+			if !p.Assign(user, m.Tenant, role) {
+				return nil
+			}
+		}
+	}
+	if !p.SaveAssignments(p.core.db, user) {
+		return nil
+	}
+
+	return user
+}
+
+func (p *OIDCAuthProvider) cookie() string {
+	return "oidc-" + p.Identifier
+}
+
+func (p *OIDCAuthProvider) redirectURI() string {
+	return p.DeploymentURI + p.Configuration(false).Redirect
+}
@@ -15,6 +15,8 @@ type Bearing struct {
 	Agents   []*db.Agent   `json:"agents"`
 	Role     string        `json:"role"`
 
+	Permissions []string `json:"permissions"`
+
 	Grants struct {
 		Admin    bool `json:"admin"`
 		Engineer bool `json:"engineer"`
@@ -33,16 +35,18 @@ func (c *Core) BearingFor(m *db.Membership) (Bearing, error) {
 		return b, fmt.Errorf("unable to retrieve tenant [%s]: %s", m.TenantUUID, err)
 	}
 	b.Role = m.Role
-	switch b.Role {
-	case "admin":
-		b.Grants.Admin = true
-		fallthrough
-	case "engineer":
-		b.Grants.Engineer = true
-		fallthrough
-	case "operator":
-		b.Grants.Operator = true
+	role, err := c.tenantRole(b.Tenant.UUID, m.Role)
+	if err != nil {
+		return b, fmt.Errorf("unable to retrieve role '%s' for tenant [%s]: %s", m.Role, b.Tenant.UUID, err)
+	}
+	b.Permissions = []string{}
+	if role != nil {
+		b.Permissions = role.Permissions
 	}
+	grant := tenantGrant(b.Permissions)
+	b.Grants.Admin = grant.Admin
+	b.Grants.Engineer = grant.Engineer
+	b.Grants.Operator = grant.Operator
 
 	b.Archives, err = c.db.GetAllArchives(&db.ArchiveFilter{ForTenant: b.Tenant.UUID})
 	if err != nil {
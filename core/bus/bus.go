@@ -18,6 +18,8 @@ const (
 	TenantInviteEvent     = "tenant-invite"
 	TenantBanishEvent     = "tenant-banish"
 	HealthUpdateEvent     = "health-update"
+	LoginLockoutEvent     = "login-lockout"
+	LoginUnlockEvent      = "login-unlock"
 )
 
 type Event struct {
@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
 	"strings"
 	"time"
@@ -19,6 +20,7 @@ import (
 	"github.com/shieldproject/shield/core/scheduler"
 	"github.com/shieldproject/shield/core/vault"
 	"github.com/shieldproject/shield/db"
+	"github.com/shieldproject/shield/route"
 )
 
 type Core struct {
@@ -31,6 +33,9 @@ type Core struct {
 	scheduler *scheduler.Scheduler
 	metrics   *metrics.Exporter
 
+	/* proxies whose X-Forwarded-For headers we believe */
+	trustedProxies []*net.IPNet
+
 	bailout bool
 
 	info struct {
@@ -54,6 +59,12 @@ type Config struct {
 		SlowLoop duration `yaml:"slow-loop" env:"SHIELD_SCHEDULER_SLOW_LOOP"`
 		Threads  int      `yaml:"threads"   env:"SHIELD_SCHEDULER_THREADS"`
 		Timeout  int      `yaml:"timeout"   env:"SHIELD_SCHEDULER_TIMEOUT"`
+
+		Limits struct {
+			Agent  int `yaml:"agent"  env:"SHIELD_SCHEDULER_LIMITS_AGENT"`
+			Store  int `yaml:"store"  env:"SHIELD_SCHEDULER_LIMITS_STORE"`
+			Tenant int `yaml:"tenant" env:"SHIELD_SCHEDULER_LIMITS_TENANT"`
+		} `yaml:"limits"`
 	} `yaml:"scheduler"`
 
 	API struct {
@@ -69,6 +80,22 @@ type Config struct {
 			Password string `yaml:"password" env:"SHIELD_API_FAILSAFE_PASSWORD"`
 		} `yaml:"failsafe"`
 
+		MFA struct {
+			Require    []string `yaml:"require"`
+			RequireEnv string   `yaml:"-"      env:"SHIELD_API_MFA_REQUIRE"`
+			Issuer     string   `yaml:"issuer" env:"SHIELD_API_MFA_ISSUER"`
+		} `yaml:"mfa"`
+
+		Lockout struct {
+			Threshold   int      `yaml:"threshold"    env:"SHIELD_API_LOCKOUT_THRESHOLD"`
+			IPThreshold int      `yaml:"ip-threshold" env:"SHIELD_API_LOCKOUT_IP_THRESHOLD"`
+			Backoff     duration `yaml:"backoff"      env:"SHIELD_API_LOCKOUT_BACKOFF"`
+			Max         duration `yaml:"max"          env:"SHIELD_API_LOCKOUT_MAX"`
+		} `yaml:"lockout"`
+
+		TrustedProxies    []string `yaml:"trusted-proxies"`
+		TrustedProxiesEnv string   `yaml:"-" env:"SHIELD_API_TRUSTED_PROXIES"`
+
 		Websocket struct {
 			WriteTimeout duration `yaml:"write-timeout" env:"SHIELD_API_WEBSOCKET_WRITE_TIMEOUT"`
 			PingInterval duration `yaml:"ping-interval" env:"SHIELD_API_WEBSOCKET_PING_INTERVAL"`
@@ -86,6 +113,10 @@ type Config struct {
 		} `yaml:"retention"`
 	} `yaml:"limit"`
 
+	Purge struct {
+		Grace duration `yaml:"grace" env:"SHIELD_PURGE_GRACE"`
+	} `yaml:"purge"`
+
 	Metadata struct {
 		Retention struct {
 			PurgedArchives duration `yaml:"purged_archives" env:"SHIELD_METADATA_RETENTION_PURGED_ARCHIVES"`
@@ -154,6 +185,11 @@ func init() {
 	DefaultConfig.API.Failsafe.Username = "admin"
 	DefaultConfig.API.Failsafe.Password = "password"
 
+	DefaultConfig.API.Lockout.Threshold = 5
+	DefaultConfig.API.Lockout.IPThreshold = 20
+	DefaultConfig.API.Lockout.Backoff = 60
+	DefaultConfig.API.Lockout.Max = 60 * 60
+
 	DefaultConfig.API.Env = "SHIELD"
 	DefaultConfig.API.Color = "yellow"
 
@@ -163,6 +199,8 @@ func init() {
 	DefaultConfig.Limit.Retention.Min = 1
 	DefaultConfig.Limit.Retention.Max = 390
 
+	DefaultConfig.Purge.Grace = 60 * 60 * 24 * 7
+
 	DefaultConfig.Metadata.Retention.PurgedArchives = 60 * 60 * 24 * 90
 	DefaultConfig.Metadata.Retention.TaskLogs = 60 * 60 * 24 * 90
 
@@ -219,10 +257,58 @@ func Configure(file string, config Config) (*Core, error) {
 		return nil, fmt.Errorf("scheduler.threads value '%d' is invalid (must be greater than zero)", c.Config.Scheduler.Threads)
 	}
 
+	if c.Config.Scheduler.Limits.Agent < 0 {
+		return nil, fmt.Errorf("scheduler.limits.agent value '%d' is invalid (must be zero or greater)", c.Config.Scheduler.Limits.Agent)
+	}
+	if c.Config.Scheduler.Limits.Store < 0 {
+		return nil, fmt.Errorf("scheduler.limits.store value '%d' is invalid (must be zero or greater)", c.Config.Scheduler.Limits.Store)
+	}
+	if c.Config.Scheduler.Limits.Tenant < 0 {
+		return nil, fmt.Errorf("scheduler.limits.tenant value '%d' is invalid (must be zero or greater)", c.Config.Scheduler.Limits.Tenant)
+	}
+
 	if c.Config.API.Session.Timeout <= 0 {
 		return nil, fmt.Errorf("api.session.timeout of '%d' hours is invalid (must be greater than zero)", c.Config.API.Session.Timeout)
 	}
 
+	if len(c.Config.API.MFA.Require) == 0 && c.Config.API.MFA.RequireEnv != "" {
+		for _, role := range strings.Split(c.Config.API.MFA.RequireEnv, ",") {
+			c.Config.API.MFA.Require = append(c.Config.API.MFA.Require, strings.TrimSpace(role))
+		}
+	}
+	for _, role := range c.Config.API.MFA.Require {
+		if !IsValidSystemRole(role) {
+			return nil, fmt.Errorf("api.mfa.require value '%s' is invalid (must be a system role: admin, manager, or engineer)", role)
+		}
+	}
+	if c.Config.API.MFA.Issuer == "" {
+		c.Config.API.MFA.Issuer = c.Config.API.Env
+	}
+
+	if len(c.Config.API.TrustedProxies) == 0 && c.Config.API.TrustedProxiesEnv != "" {
+		for _, proxy := range strings.Split(c.Config.API.TrustedProxiesEnv, ",") {
+			c.Config.API.TrustedProxies = append(c.Config.API.TrustedProxies, strings.TrimSpace(proxy))
+		}
+	}
+	proxies, err := route.ParseTrustedProxies(c.Config.API.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("api.trusted-proxies is invalid: %s", err)
+	}
+	c.trustedProxies = proxies
+
+	if c.Config.API.Lockout.Threshold < 0 {
+		return nil, fmt.Errorf("api.lockout.threshold of '%d' is invalid (must be zero, to disable, or greater)", c.Config.API.Lockout.Threshold)
+	}
+	if c.Config.API.Lockout.IPThreshold < 0 {
+		return nil, fmt.Errorf("api.lockout.ip-threshold of '%d' is invalid (must be zero, to disable, or greater)", c.Config.API.Lockout.IPThreshold)
+	}
+	if c.Config.API.Lockout.Backoff <= 0 {
+		return nil, fmt.Errorf("api.lockout.backoff of '%d' seconds is invalid (must be greater than zero)", c.Config.API.Lockout.Backoff)
+	}
+	if c.Config.API.Lockout.Max < c.Config.API.Lockout.Backoff {
+		return nil, fmt.Errorf("api.lockout.max of '%d' seconds is invalid (must be at least api.lockout.backoff)", c.Config.API.Lockout.Max)
+	}
+
 	if c.Config.API.Websocket.PingInterval <= 0 {
 		return nil, fmt.Errorf("api.websocket.ping-interval of '%d' seconds is invalid (must be greater than zero)", c.Config.API.Websocket.PingInterval)
 	}
@@ -372,8 +458,26 @@ func Configure(file string, config Config) (*Core, error) {
 					Type:       auth.Backend,
 				},
 			}
+		case "oidc":
+			c.providers[id] = &OIDCAuthProvider{
+				/* we will provide a link back to core in c.WireUpAuthenticationProviders() */
+				AuthProviderBase: AuthProviderBase{
+					Name:       auth.Name,
+					Identifier: id,
+					Type:       auth.Backend,
+				},
+			}
+		case "ldap":
+			c.providers[id] = &LDAPAuthProvider{
+				/* we will provide a link back to core in c.WireUpAuthenticationProviders() */
+				AuthProviderBase: AuthProviderBase{
+					Name:       auth.Name,
+					Identifier: id,
+					Type:       auth.Backend,
+				},
+			}
 		default:
-			return nil, fmt.Errorf("%s authentication provider has an unrecognized `backend' of '%s'; must be one of github, uaa or okta", id, auth.Backend)
+			return nil, fmt.Errorf("%s authentication provider has an unrecognized `backend' of '%s'; must be one of github, uaa, okta, oidc or ldap", id, auth.Backend)
 		}
 
 		if err := c.providers[id].Configure(auth.Properties); err != nil {
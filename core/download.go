@@ -0,0 +1,68 @@
+package core
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/shieldproject/shield/core/scheduler"
+	"github.com/shieldproject/shield/core/vault"
+	"github.com/shieldproject/shield/db"
+)
+
+// ArchiveEncryption retrieves the parameters needed to decrypt an
+// archive from the vault.  Archives that were imported without being
+// encrypted have no such parameters; for them, the empty parameters
+// are returned, which the agents take to mean "do not decrypt".
+func (c *Core) ArchiveEncryption(archive *db.Archive) (vault.Parameters, error) {
+	if archive.Unencrypted() {
+		return vault.Parameters{}, nil
+	}
+
+	encryption, err := c.vault.Retrieve(archive.UUID)
+	if err != nil {
+		return encryption, err
+	}
+	if encryption.Type == "" {
+		return encryption, fmt.Errorf("encryption parameters for archive '%s' not found in vault", archive.UUID)
+	}
+	return encryption, nil
+}
+
+// StreamDownload runs the chore for a download task (see RunNow),
+// decoding the archive data the agent sends back and writing it out
+// as it arrives.  The open function is called to get that writer just
+// before the first of the data is written, so that anything that goes
+// wrong before then can still be reported to the requester properly.
+//
+// StreamDownload returns the number of bytes written.
+func (c *Core) StreamDownload(task *db.Task, chore scheduler.Chore, cancel <-chan struct{}, open func() io.Writer) (int64, error) {
+	var (
+		out io.Writer
+		n   int64
+	)
+
+	err := c.RunNow(task, chore, cancel, func(s string) error {
+		b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+		if err != nil {
+			return fmt.Errorf("malformed archive data received from agent: %s", err)
+		}
+		if len(b) == 0 {
+			return nil
+		}
+
+		if out == nil {
+			out = open()
+		}
+		m, err := out.Write(b)
+		n += int64(m)
+		if err != nil {
+			return fmt.Errorf("unable to send archive data: %s", err)
+		}
+		return nil
+	})
+
+	c.db.UpdateTaskLog(task.UUID, fmt.Sprintf("DOWNLOAD: sent %d bytes\n", n))
+	return n, err
+}
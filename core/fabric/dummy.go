@@ -1,6 +1,9 @@
 package fabric
 
 import (
+	"encoding/base64"
+	"io"
+	"io/ioutil"
 	"time"
 
 	"github.com/shieldproject/shield/core/scheduler"
@@ -79,6 +82,59 @@ func (f DummyFabric) Restore(task *db.Task, encryption vault.Parameters) schedul
 		})
 }
 
+func (f DummyFabric) Download(task *db.Task, encryption vault.Parameters) scheduler.Chore {
+	return scheduler.NewChore(
+		task.UUID,
+		func(chore scheduler.Chore) {
+			chore.Errorf("DUMMY> starting an archive download operation; delay is %ds", f.delay)
+			chore.Errorf("DUMMY>")
+			chore.Errorf("DUMMY>   archive key:     '%s'", task.RestoreKey)
+			chore.Errorf("DUMMY>")
+			chore.Errorf("DUMMY>   store plugin:    '%s'", task.StorePlugin)
+			chore.Errorf("DUMMY>   store endpoint:  '%s'", task.StoreEndpoint)
+			chore.Errorf("DUMMY>")
+			chore.Errorf("DUMMY>   compression:     '%s'", task.Compression)
+			chore.Errorf("DUMMY>")
+			chore.Errorf("DUMMY>   encryption type: '%s'", encryption.Type)
+			f.Sleep()
+			chore.Infof("%s", base64.StdEncoding.EncodeToString([]byte("DUMMY ARCHIVE "+task.RestoreKey+"\n")))
+			chore.Errorf("DUMMY>")
+			chore.Errorf("DUMMY> archive download operation complete.")
+			chore.UnixExit(0)
+			return
+		})
+}
+
+func (f DummyFabric) Import(task *db.Task, encryption vault.Parameters, in io.Reader) scheduler.Chore {
+	return scheduler.NewChore(
+		task.UUID,
+		func(chore scheduler.Chore) {
+			chore.Errorf("DUMMY> starting an archive import operation; delay is %ds", f.delay)
+			chore.Errorf("DUMMY>")
+			chore.Errorf("DUMMY>   archive key:     '%s'", task.RestoreKey)
+			chore.Errorf("DUMMY>")
+			chore.Errorf("DUMMY>   store plugin:    '%s'", task.StorePlugin)
+			chore.Errorf("DUMMY>   store endpoint:  '%s'", task.StoreEndpoint)
+			chore.Errorf("DUMMY>")
+			chore.Errorf("DUMMY>   compression:     '%s'", task.Compression)
+			chore.Errorf("DUMMY>")
+			chore.Errorf("DUMMY>   encryption type: '%s'", encryption.Type)
+
+			var n int64
+			if in != nil {
+				n, _ = io.Copy(ioutil.Discard, in)
+			}
+			f.Sleep()
+			chore.Errorf("DUMMY>")
+			chore.Errorf("DUMMY> archive import operation complete.")
+			chore.Infof(`{"key":"%s","archive_size":%d,"compression":"%s"}`,
+				time.Now().Format("2006/01/02/15/04/05/2006-01-02T1504.import"),
+				n, task.Compression)
+			chore.UnixExit(0)
+			return
+		})
+}
+
 func (f DummyFabric) Status(task *db.Task) scheduler.Chore {
 	return scheduler.NewChore(
 		task.UUID,
@@ -112,6 +168,42 @@ func (f DummyFabric) Purge(task *db.Task) scheduler.Chore {
 		})
 }
 
+func (f DummyFabric) Hold(task *db.Task) scheduler.Chore {
+	return scheduler.NewChore(
+		task.UUID,
+		func(chore scheduler.Chore) {
+			chore.Errorf("DUMMY> starting an archive hold operation; delay is %ds", f.delay)
+			chore.Errorf("DUMMY>")
+			chore.Errorf("DUMMY>   archive key:     '%s'", task.RestoreKey)
+			chore.Errorf("DUMMY>")
+			chore.Errorf("DUMMY>   store plugin:    '%s'", task.StorePlugin)
+			chore.Errorf("DUMMY>   store endpoint:  '%s'", task.StoreEndpoint)
+			f.Sleep()
+			chore.Errorf("DUMMY>")
+			chore.Errorf("DUMMY> archive hold operation complete.")
+			chore.UnixExit(0)
+			return
+		})
+}
+
+func (f DummyFabric) Release(task *db.Task) scheduler.Chore {
+	return scheduler.NewChore(
+		task.UUID,
+		func(chore scheduler.Chore) {
+			chore.Errorf("DUMMY> starting an archive hold release operation; delay is %ds", f.delay)
+			chore.Errorf("DUMMY>")
+			chore.Errorf("DUMMY>   archive key:     '%s'", task.RestoreKey)
+			chore.Errorf("DUMMY>")
+			chore.Errorf("DUMMY>   store plugin:    '%s'", task.StorePlugin)
+			chore.Errorf("DUMMY>   store endpoint:  '%s'", task.StoreEndpoint)
+			f.Sleep()
+			chore.Errorf("DUMMY>")
+			chore.Errorf("DUMMY> archive hold release operation complete.")
+			chore.UnixExit(0)
+			return
+		})
+}
+
 func (f DummyFabric) TestStore(task *db.Task) scheduler.Chore {
 	return scheduler.NewChore(
 		task.UUID,
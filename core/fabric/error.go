@@ -41,6 +41,14 @@ func (f ErrorFabric) Purge(task *db.Task) scheduler.Chore {
 	return f.chore(task.UUID)
 }
 
+func (f ErrorFabric) Hold(task *db.Task) scheduler.Chore {
+	return f.chore(task.UUID)
+}
+
+func (f ErrorFabric) Release(task *db.Task) scheduler.Chore {
+	return f.chore(task.UUID)
+}
+
 func (f ErrorFabric) TestStore(task *db.Task) scheduler.Chore {
 	return f.chore(task.UUID)
 }
@@ -1,6 +1,8 @@
 package fabric
 
 import (
+	"io"
+
 	"github.com/shieldproject/shield/core/scheduler"
 	"github.com/shieldproject/shield/core/vault"
 	"github.com/shieldproject/shield/db"
@@ -14,12 +16,28 @@ type Fabric interface {
 	/* restore an encrypted archive to a target. */
 	Restore(*db.Task, vault.Parameters) scheduler.Chore
 
+	/* retrieve an encrypted archive from cloud storage, decrypt
+	   it, and (optionally) decompress it, sending it back to the
+	   core as base64-encoded standard output. */
+	Download(*db.Task, vault.Parameters) scheduler.Chore
+
+	/* store an externally produced backup, read either from the
+	   given reader or (if the task has one) from an existing key
+	   in cloud storage, optionally encrypting it on the way. */
+	Import(*db.Task, vault.Parameters, io.Reader) scheduler.Chore
+
 	/* check the status of the agent. */
 	Status(*db.Task) scheduler.Chore
 
 	/* purge an from cloud storage archive. */
 	Purge(*db.Task) scheduler.Chore
 
+	/* place a legal hold on an archive in cloud storage. */
+	Hold(*db.Task) scheduler.Chore
+
+	/* lift a legal hold on an archive in cloud storage. */
+	Release(*db.Task) scheduler.Chore
+
 	/* test the viability of a storage system. */
 	TestStore(*db.Task) scheduler.Chore
 }
@@ -3,6 +3,8 @@ package fabric
 import (
 	"bufio"
 	"encoding/json"
+	"io"
+	"time"
 
 	"github.com/jhunt/go-log"
 	"golang.org/x/crypto/ssh"
@@ -20,6 +22,10 @@ func Legacy(ip string, config *ssh.ClientConfig, db *db.DB) LegacyFabric {
 	}
 }
 
+// LegacyCancelGracePeriod is how long to wait, after asking the remote
+// agent to terminate a task, before we give up and hang up on it.
+const LegacyCancelGracePeriod = 30 * time.Second
+
 type LegacyFabric struct {
 	ip  string
 	ssh *ssh.ClientConfig
@@ -94,6 +100,49 @@ func (f LegacyFabric) Restore(task *db.Task, encryption vault.Parameters) schedu
 	})
 }
 
+func (f LegacyFabric) Download(task *db.Task, encryption vault.Parameters) scheduler.Chore {
+	op := "download"
+
+	return f.Execute("archive download", task.UUID, Command{
+		Op: op,
+
+		RestoreKey:    task.RestoreKey,
+		StorePlugin:   task.StorePlugin,
+		StoreEndpoint: task.StoreEndpoint,
+
+		TaskUUID: task.UUID,
+
+		Compression: task.Compression,
+
+		EncryptType: encryption.Type,
+		EncryptKey:  encryption.Key,
+		EncryptIV:   encryption.IV,
+	})
+}
+
+func (f LegacyFabric) Import(task *db.Task, encryption vault.Parameters, in io.Reader) scheduler.Chore {
+	op := "import"
+
+	chore := f.execute("archive import", task.UUID, Command{
+		Op: op,
+
+		RestoreKey:    task.RestoreKey,
+		StorePlugin:   task.StorePlugin,
+		StoreEndpoint: task.StoreEndpoint,
+
+		TaskUUID: task.UUID,
+
+		Compression: task.Compression,
+
+		EncryptType: encryption.Type,
+		EncryptKey:  encryption.Key,
+		EncryptIV:   encryption.IV,
+	}, in)
+
+	chore.Encryption = encryption.Type
+	return chore
+}
+
 func (f LegacyFabric) Status(task *db.Task) scheduler.Chore {
 	return f.Execute("agent status", task.UUID, Command{
 		Op: "status",
@@ -110,6 +159,26 @@ func (f LegacyFabric) Purge(task *db.Task) scheduler.Chore {
 	})
 }
 
+func (f LegacyFabric) Hold(task *db.Task) scheduler.Chore {
+	return f.Execute("archive hold", task.UUID, Command{
+		Op: "hold",
+
+		RestoreKey:    task.RestoreKey,
+		StorePlugin:   task.StorePlugin,
+		StoreEndpoint: task.StoreEndpoint,
+	})
+}
+
+func (f LegacyFabric) Release(task *db.Task) scheduler.Chore {
+	return f.Execute("archive hold release", task.UUID, Command{
+		Op: "release",
+
+		RestoreKey:    task.RestoreKey,
+		StorePlugin:   task.StorePlugin,
+		StoreEndpoint: task.StoreEndpoint,
+	})
+}
+
 func (f LegacyFabric) TestStore(task *db.Task) scheduler.Chore {
 	op := "storage test"
 
@@ -122,6 +191,10 @@ func (f LegacyFabric) TestStore(task *db.Task) scheduler.Chore {
 }
 
 func (f LegacyFabric) Execute(op, id string, command Command) scheduler.Chore {
+	return f.execute(op, id, command, nil)
+}
+
+func (f LegacyFabric) execute(op, id string, command Command, stdin io.Reader) scheduler.Chore {
 	return scheduler.NewChore(
 		id,
 		func(chore scheduler.Chore) {
@@ -166,6 +239,13 @@ func (f LegacyFabric) Execute(op, id string, command Command) scheduler.Chore {
 				return
 			}
 
+			/* uploads (for imports) are sent to the agent as
+			   standard input; when we run out, the remote end
+			   sees EOF and finishes storing what it was sent. */
+			if stdin != nil {
+				sess.Stdin = stdin
+			}
+
 			/* we do this in a goroutine so that we can
 			   exec the payload in the main thread. */
 			wait := make(chan bool)
@@ -180,6 +260,12 @@ func (f LegacyFabric) Execute(op, id string, command Command) scheduler.Chore {
 					s := b.Text()
 					switch s[:2] {
 					case "O:":
+						/* downloads are the archive data itself;
+						   keep them out of the debugging logs. */
+						if command.Op == "download" {
+							chore.Stdout <- s[2:] + "\n"
+							continue
+						}
 						chore.Infof("%s", s[2:])
 					case "E:":
 						chore.Errorf("%s", s[2:])
@@ -191,7 +277,42 @@ func (f LegacyFabric) Execute(op, id string, command Command) scheduler.Chore {
 
 			/* execute the payload remotely */
 			chore.Errorf("executing %s task on remote agent.", op)
-			err = sess.Run(payload)
+			err = sess.Start(payload)
+			if err != nil {
+				chore.Errorf("ERR> unable to start remote execution: %s", err)
+				chore.UnixExit(1)
+				return
+			}
+
+			/* wait for the remote execution to finish, or for
+			   the scheduler to cancel us.  cancellation is relayed
+			   to the agent as a signal, which it passes on to the
+			   plugin process group; if the agent doesn't wind the
+			   task down in a timely fashion, we hang up on it. */
+			done := make(chan error, 1)
+			go func() {
+				done <- sess.Wait()
+			}()
+
+			select {
+			case err = <-done:
+			case <-chore.Cancel:
+				chore.Errorf("ERR> task canceled; terminating remote execution on %s...", f.ip)
+				if err := sess.Signal(ssh.SIGTERM); err != nil {
+					log.Errorf("unable to signal remote execution on %s: %s", f.ip, err)
+				}
+
+				select {
+				case err = <-done:
+				case <-time.After(LegacyCancelGracePeriod):
+					chore.Errorf("ERR> remote execution did not terminate within %s; disconnecting", LegacyCancelGracePeriod)
+					conn.Close()
+					err = <-done
+				}
+				<-wait
+				chore.UnixExit(1)
+				return
+			}
 			<-wait
 			if err != nil {
 				chore.Errorf("ERR> remote execution failed: %s", err)
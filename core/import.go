@@ -0,0 +1,135 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jhunt/go-log"
+
+	"github.com/shieldproject/shield/core/vault"
+	"github.com/shieldproject/shield/db"
+)
+
+// ArchiveImport describes a backup made outside of SHIELD (by a cron
+// job and a shell script, most likely) that is to be imported, as an
+// archive of the given target system.
+type ArchiveImport struct {
+	Target *db.Target
+	Store  *db.Store
+
+	/* where the backup is already in cloud storage, if it is; if not,
+	   it is uploaded as part of the import. */
+	Key string
+
+	/* how the backup is already compressed, if at all; imports never
+	   compress anything, but restores need to know what to undo. */
+	Compression string
+
+	TakenAt   int64
+	ExpiresAt int64
+	Notes     string
+	Size      int64
+
+	/* encrypt the backup, storing the encrypted copy alongside the
+	   original, instead of registering the original as it is. */
+	Encrypt bool
+}
+
+// ImportArchive brings an externally produced backup into SHIELD.
+//
+// Backups already in cloud storage can be registered as they are, as
+// unencrypted legacy archives; nothing needs to run for that, so no
+// task is created.  Otherwise, the backup (uploaded from data, or
+// retrieved from its store key) is run through the store's agent to be
+// stored and, if requested, encrypted along the way.  This happens
+// right away, under an import task (see RunNow), which is returned
+// along with the new archive.
+func (c *Core) ImportArchive(owner string, in ArchiveImport, data io.Reader, cancel <-chan struct{}) (*db.Archive, *db.Task, error) {
+	archive := &db.Archive{
+		TenantUUID:     in.Target.TenantUUID,
+		TargetUUID:     in.Target.UUID,
+		StoreUUID:      in.Store.UUID,
+		StoreKey:       in.Key,
+		TakenAt:        in.TakenAt,
+		ExpiresAt:      in.ExpiresAt,
+		Notes:          in.Notes,
+		Compression:    in.Compression,
+		EncryptionType: db.UnencryptedArchive,
+		Size:           in.Size,
+	}
+
+	if data == nil && !in.Encrypt {
+		log.Infof("%s is registering [%s] in store %s as a legacy (unencrypted) archive of target %s",
+			owner, in.Key, in.Store.UUID, in.Target.UUID)
+		archive, err := c.db.ImportArchive(archive)
+		return archive, nil, err
+	}
+
+	task, err := c.db.CreateImportTask(owner, in.Target, in.Store, in.Key, in.Compression)
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Infof("%s: %s is importing an archive of target %s into store %s", task.UUID, owner, in.Target.UUID, in.Store.UUID)
+
+	var encryption vault.Parameters
+	if in.Encrypt {
+		encryption, err = c.vault.NewParameters(task.ArchiveUUID, c.Config.Cipher, false)
+		if err != nil {
+			c.TaskErrored(task, "unable to generate encryption parameters:\n%s\n", err)
+			return nil, task, err
+		}
+		archive.EncryptionType = encryption.Type
+	}
+
+	fail := func(err error) (*db.Archive, *db.Task, error) {
+		c.TaskErrored(task, "%s\n", err)
+		if in.Encrypt {
+			if err := c.vault.Delete(fmt.Sprintf("secret/archives/%s", task.ArchiveUUID)); err != nil {
+				log.Errorf("%s: failed to delete encryption parameters for archive %s: %s", task.UUID, task.ArchiveUUID, err)
+			}
+		}
+		return nil, task, err
+	}
+
+	fabric, err := c.FabricFor(task)
+	if err != nil {
+		return fail(fmt.Errorf("unable to find a fabric to facilitate execution of this task: %s", err))
+	}
+
+	output := ""
+	err = c.RunNow(task, fabric.Import(task, encryption, data), cancel, func(s string) error {
+		output += s
+		return nil
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	var v struct {
+		Key  string `json:"key"`
+		Size int64  `json:"archive_size"`
+	}
+	output = strings.TrimSpace(output)
+	if err := json.Unmarshal([]byte(output), &v); err != nil {
+		return fail(fmt.Errorf("failed to unmarshal output [%s] from import operation: %s", output, err))
+	}
+	if v.Key == "" {
+		return fail(fmt.Errorf("no restore key detected in import operation output"))
+	}
+	c.db.UpdateTaskLog(task.UUID, fmt.Sprintf("IMPORT: restore key  = %s\n", v.Key))
+	c.db.UpdateTaskLog(task.UUID, fmt.Sprintf("IMPORT: archive size = %d bytes\n", v.Size))
+
+	archive.UUID = task.ArchiveUUID
+	archive.StoreKey = v.Key
+	archive.Size = v.Size
+	archive, err = c.db.ImportArchive(archive)
+	if err != nil {
+		return fail(fmt.Errorf("failed to create archive database record '%s': %s", task.ArchiveUUID, err))
+	}
+
+	c.db.CompleteTask(task.UUID, time.Now())
+	return archive, task, nil
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/jhunt/go-log"
@@ -68,9 +69,11 @@ func (c Core) Main() {
 			c.MarkIrrelevantTasks()
 			c.ScheduleAgentStatusCheckTasks(nil)
 			c.AnalyzeStorage()
+			c.UpdateQuotaMetrics()
 			c.TruncateOldTaskLogs()
 			c.DeleteOldPurgedArchives()
 			c.CleanupOrphanedObjects()
+			c.ExpireApprovalRequests()
 
 			if c.Unlocked() {
 				c.PurgeExpiredAPISessions()
@@ -94,10 +97,14 @@ func (c *Core) PrintConfiguration() {
 
 	log.Infof("CONFIG | scheduler loop:    fast=%ds slow=%ds", c.Config.Scheduler.FastLoop, c.Config.Scheduler.SlowLoop)
 	log.Infof("CONFIG | scheduler threads: %d", c.Config.Scheduler.Threads)
-	log.Infof("CONFIG | scheduler timeout: %ds", c.Config.Scheduler.Timeout)
+	log.Infof("CONFIG | scheduler timeout: %dh", c.Config.Scheduler.Timeout)
+	log.Infof("CONFIG | scheduler limits:  agent=%d store=%d tenant=%d (0 = unlimited)",
+		c.Config.Scheduler.Limits.Agent, c.Config.Scheduler.Limits.Store, c.Config.Scheduler.Limits.Tenant)
 	log.Infof("CONFIG | api bind:          '%s'", c.Config.API.Bind)
 	log.Infof("CONFIG | session timeout:   %ds", c.Config.API.Session.Timeout)
 	log.Infof("CONFIG | failsafe username: '%s'", c.Config.API.Failsafe.Username)
+	log.Infof("CONFIG | mfa required for:  [%s] (system roles)", strings.Join(c.Config.API.MFA.Require, ", "))
+	log.Infof("CONFIG | login lockout:     after %d failures (%d per ip), %ds - %ds", c.Config.API.Lockout.Threshold, c.Config.API.Lockout.IPThreshold, c.Config.API.Lockout.Backoff, c.Config.API.Lockout.Max)
 	log.Infof("CONFIG | websocket timeout: %ds", c.Config.API.Websocket.WriteTimeout)
 	log.Infof("CONFIG | websocket ping:    %ds", c.Config.API.Websocket.PingInterval)
 	log.Infof("CONFIG | mbus max clients:  %d", c.Config.Mbus.MaxSlots)
@@ -105,6 +112,7 @@ func (c *Core) PrintConfiguration() {
 	log.Infof("")
 	log.Infof("CONFIG | backup archives must be kept for at least %s", (duration)(c.Config.Limit.Retention.Min))
 	log.Infof("CONFIG | backup archives are kept for no more than %s", (duration)(c.Config.Limit.Retention.Max))
+	log.Infof("CONFIG | deleted archives can be undeleted for %s", c.Config.Purge.Grace)
 	log.Infof("CONFIG | task logs will be truncated after %s", c.Config.Metadata.Retention.TaskLogs)
 	log.Infof("CONFIG | purged archives will be deleted after %s", c.Config.Metadata.Retention.PurgedArchives)
 	log.Infof("")
@@ -170,6 +178,7 @@ func (c *Core) InitializePrometheus() error {
 		ArchiveCount:     len(archives),
 		StorageUsedCount: storageBytesUsed,
 	})
+	c.UpdateQuotaMetrics()
 
 	return nil
 }
@@ -338,7 +347,35 @@ func (c *Core) Bind() {
 func (c *Core) StartScheduler() {
 	log.Infof("INITIALIZING: starting up the scheduler...")
 
-	c.scheduler = scheduler.New(c.Config.Scheduler.Threads, c.db)
+	c.scheduler = scheduler.New(c.Config.Scheduler.Threads, scheduler.Limits{
+		Agent:  c.Config.Scheduler.Limits.Agent,
+		Store:  c.Config.Scheduler.Limits.Store,
+		Tenant: c.Config.Scheduler.Limits.Tenant,
+
+		Runtime: time.Duration(c.Config.Scheduler.Timeout) * time.Hour,
+	}, c.db, c.vault)
+	c.RefreshTenantScheduling()
+}
+
+// RefreshTenantScheduling hands each tenant's scheduler share and task
+// quota to the scheduler.  Tenants that the scheduler doesn't know about
+// get the defaults (a share of 1, and no quota), so this only needs to
+// happen at startup, and whenever tenants are updated or deleted.
+func (c *Core) RefreshTenantScheduling() {
+	tenants, err := c.db.GetAllTenants(nil)
+	if err != nil {
+		log.Errorf("unable to retrieve tenants from database, in order to update their scheduler shares and quotas: %s", err)
+		return
+	}
+
+	shares := make(map[string]int)
+	quotas := make(map[string]int)
+	for _, tenant := range tenants {
+		shares[tenant.UUID] = tenant.Share
+		quotas[tenant.UUID] = tenant.QuotaTasks
+	}
+	c.scheduler.SetShares(shares)
+	c.scheduler.SetTenantLimits(quotas)
 }
 
 func (c *Core) ConfigureMessageBus() {
@@ -381,8 +418,28 @@ func (c *Core) ScheduleBackupTasks() {
 		lookup[task.JobUUID] = task
 	}
 
+	blackouts, err := c.db.GetAllBlackouts(nil)
+	if err != nil {
+		log.Errorf("error retrieving blackouts from database: %s", err)
+		return
+	}
+
+	now := time.Now()
 	for _, job := range l {
-		if task, running := lookup[job.UUID]; running {
+		if blackout, until := c.blackedOut(blackouts, job, now); blackout != nil {
+			log.Infof("skipping next run of job %s [%s]; blackout '%s' [%s] is in effect until %s...", job.Name, job.UUID, blackout.Name, blackout.UUID, until.Format(time.RFC3339))
+			_, err := c.db.SkipBackupTask("system", job,
+				fmt.Sprintf("... skipping this run; blackout '%s' is in effect until %s ...\n", blackout.Name, until.Format(time.RFC3339)))
+			if err != nil {
+				log.Errorf("failed to insert skipped backup task record: %s", err)
+			}
+			if blackout.CatchUp {
+				if err := c.db.MissJob(job.UUID, now); err != nil {
+					log.Errorf("failed to record missed run of job %s [%s]: %s", job.Name, job.UUID, err)
+				}
+			}
+
+		} else if task, running := lookup[job.UUID]; running {
 			log.Infof("skipping next run of job %s [%s]; already running in task [%s] (status %s)...", job.Name, job.UUID, task.UUID, task.Status)
 			_, err := c.db.SkipBackupTask("system", job,
 				fmt.Sprintf("... skipping this run; task %s is still not finished ...\n", task.UUID))
@@ -390,26 +447,101 @@ func (c *Core) ScheduleBackupTasks() {
 				log.Errorf("failed to insert skipped backup task record: %s", err)
 			}
 
-		} else {
-			log.Infof("scheduling a run of job %s [%s]", job.Name, job.UUID)
-			_, err := c.db.CreateBackupTask("system", job)
+		} else if reached, enforced := c.backupQuota(job); reached != "" && enforced {
+			log.Infof("skipping next run of job %s [%s]; %s...", job.Name, job.UUID, reached)
+			_, err := c.db.SkipBackupTask("system", job,
+				fmt.Sprintf("... skipping this run; %s ...\n", reached))
 			if err != nil {
-				log.Errorf("failed to insert backup task record: %s", err)
+				log.Errorf("failed to insert skipped backup task record: %s", err)
 			}
-		}
 
-		if spec, err := timespec.Parse(job.Schedule); err != nil {
-			log.Errorf("error re-scheduling job %s [%s]: %s", job.Name, job.UUID, err)
 		} else {
-			if next, err := spec.Next(time.Now()); err != nil {
-				log.Errorf("error re-scheduling job %s [%s]: %s", job.Name, job.UUID, err)
+			log.Infof("scheduling a run of job %s [%s]", job.Name, job.UUID)
+			task, err := c.db.CreateBackupTask("system", job)
+			if err != nil {
+				log.Errorf("failed to insert backup task record: %s", err)
 			} else {
-				if err = c.db.RescheduleJob(job, next); err != nil {
-					log.Errorf("error re-scheduling job %s [%s]: %s", job.Name, job.UUID, err)
+				if reached != "" {
+					c.db.UpdateTaskLog(task.UUID, fmt.Sprintf("WARNING: %s\n", reached))
+				}
+				if job.MissedRun != 0 {
+					/* this run covers anything we missed */
+					c.db.CatchUpJob(job.UUID)
 				}
 			}
 		}
 	}
+
+	/* work out everyone's next run in one go, so that each
+	   tenant's spread only has to be looked up once. */
+	if err := c.db.ScheduleJobs(l, time.Now()); err != nil {
+		log.Errorf("error re-scheduling overdue jobs: %s", err)
+	}
+
+	c.CatchUpMissedJobs(blackouts, lookup)
+}
+
+// CatchUpMissedJobs runs a single backup for each job that missed
+// one or more scheduled runs because of a blackout that asked to be
+// caught up, once that job is no longer blacked out.
+func (c *Core) CatchUpMissedJobs(blackouts []*db.Blackout, inflight map[string]*db.Task) {
+	l, err := c.db.GetAllJobs(&db.JobFilter{
+		Missed:     true,
+		SkipPaused: true,
+	})
+	if err != nil {
+		log.Errorf("error retrieving jobs with missed runs from database: %s", err)
+		return
+	}
+
+	now := time.Now()
+	for _, job := range l {
+		if blackout, _ := c.blackedOut(blackouts, job, now); blackout != nil {
+			continue
+		}
+		if _, running := inflight[job.UUID]; running {
+			continue
+		}
+		reached, enforced := c.backupQuota(job)
+		if reached != "" && enforced {
+			log.Infof("not catching up on missed run of job %s [%s] yet; %s", job.Name, job.UUID, reached)
+			continue
+		}
+
+		log.Infof("catching up on missed run of job %s [%s] (missed at %s)", job.Name, job.UUID, time.Unix(job.MissedRun, 0).Format(time.RFC3339))
+		task, err := c.db.CreateBackupTask("system", job)
+		if err != nil {
+			log.Errorf("failed to insert backup task record: %s", err)
+			continue
+		}
+		c.db.UpdateTaskLog(task.UUID, fmt.Sprintf("catching up on a run missed at %s, during a blackout.\n", time.Unix(job.MissedRun, 0).Format(time.RFC3339)))
+		if reached != "" {
+			c.db.UpdateTaskLog(task.UUID, fmt.Sprintf("WARNING: %s\n", reached))
+		}
+		if err := c.db.CatchUpJob(job.UUID); err != nil {
+			log.Errorf("failed to clear missed run of job %s [%s]: %s", job.Name, job.UUID, err)
+		}
+	}
+}
+
+// blackedOut returns the first of the given blackouts that covers
+// the job and is in effect at the given time, along with when that
+// blackout ends, or nil if the job is free to run.
+func (c *Core) blackedOut(blackouts []*db.Blackout, job *db.Job, at time.Time) (*db.Blackout, time.Time) {
+	for _, blackout := range blackouts {
+		if !blackout.Covers(job) {
+			continue
+		}
+		active, until, err := blackout.Active(at)
+		if err != nil {
+			log.Errorf("unable to evaluate blackout '%s' [%s]: %s", blackout.Name, blackout.UUID, err)
+			continue
+		}
+		if active {
+			return blackout, until
+		}
+	}
+	return nil, time.Time{}
 }
 
 func (c *Core) ScheduleAgentStatusCheckTasks(f *db.AgentFilter) {
@@ -483,12 +615,34 @@ func (c *Core) TasksToChores() {
 				log.Infof("SCHEDULER: SKIPPING [%s] task %s, another %s task [%s] is already in-flight for target [%s]", task.Op, task.UUID, other.Op, other.UUID, task.TargetUUID)
 				continue
 			}
+			job, err := c.db.GetJob(task.JobUUID)
+			if err != nil {
+				log.Errorf("unable to retrieve job %s for [%s] task %s: %s", task.JobUUID, task.Op, task.UUID, err)
+				continue
+			}
+			var window *timespec.Window
+			var runtime time.Duration
+			if job != nil {
+				window, err = job.Window()
+				if err != nil {
+					c.TaskErrored(task, "invalid backup window for job '%s':\n%s\n", job.Name, err)
+					continue
+				}
+				if !window.Open(time.Now()) {
+					log.Debugf("SCHEDULER: DEFERRING [%s] task %s until its backup window (%s) opens at %s", task.Op, task.UUID, window, window.Opens(time.Now()))
+					continue
+				}
+				runtime = time.Duration(job.MaxRuntime) * time.Minute
+			}
 			encryption, err := c.vault.NewParameters(task.ArchiveUUID, c.Config.Cipher, task.FixedKey)
 			if err != nil {
 				c.TaskErrored(task, "unable to generate encryption parameters:\n%s\n", err)
 				continue
 			}
-			c.scheduler.Schedule(20, fabric.Backup(task, encryption))
+			chore := fabric.Backup(task, encryption).Bind(task)
+			chore.Window = window
+			chore.MaxRuntime = runtime
+			c.scheduler.Schedule(20, chore)
 			inflight[task.TargetUUID] = task
 
 		case db.RestoreOperation:
@@ -496,26 +650,37 @@ func (c *Core) TasksToChores() {
 				log.Infof("SCHEDULER: SKIPPING [%s] task %s, another %s operation is already in-flight for target [%s]", task.Op, task.UUID, op, task.TargetUUID)
 				continue
 			}
-			encryption, err := c.vault.Retrieve(task.ArchiveUUID)
+			archive, err := c.db.GetArchive(task.ArchiveUUID)
 			if err != nil {
-				c.TaskErrored(task, "unable to retrieve encryption parameters:\n%s\n", err)
+				log.Errorf("unable to retrieve archive %s for [%s] task %s: %s", task.ArchiveUUID, task.Op, task.UUID, err)
+				continue
+			}
+			if archive == nil {
+				c.TaskErrored(task, "archive '%s' not found in database\n", task.ArchiveUUID)
 				continue
 			}
-			if encryption.Type == "" {
-				c.TaskErrored(task, "unable to retrieve encryption parameters:\nencryption parameters for archive '%s' not found in vault\n", task.ArchiveUUID)
+			encryption, err := c.ArchiveEncryption(archive)
+			if err != nil {
+				c.TaskErrored(task, "unable to retrieve encryption parameters:\n%s\n", err)
 				continue
 			}
-			c.scheduler.Schedule(20, fabric.Restore(task, encryption))
+			c.scheduler.Schedule(20, fabric.Restore(task, encryption).Bind(task))
 			inflight[task.TargetUUID] = task
 
 		case db.PurgeOperation:
-			c.scheduler.Schedule(50, fabric.Purge(task))
+			c.scheduler.Schedule(50, fabric.Purge(task).Bind(task))
+
+		case db.HoldOperation:
+			c.scheduler.Schedule(50, fabric.Hold(task).Bind(task))
+
+		case db.ReleaseOperation:
+			c.scheduler.Schedule(50, fabric.Release(task).Bind(task))
 
 		case db.AgentStatusOperation:
-			c.scheduler.Schedule(30, fabric.Status(task))
+			c.scheduler.Schedule(30, fabric.Status(task).Bind(task))
 
 		case db.TestStoreOperation:
-			c.scheduler.Schedule(40, fabric.TestStore(task))
+			c.scheduler.Schedule(40, fabric.TestStore(task).Bind(task))
 		}
 
 		if err := c.db.ScheduledTask(task.UUID); err != nil {
@@ -528,6 +693,23 @@ func (c *Core) TasksToChores() {
 func (c *Core) CheckArchiveExpiries() {
 	log.Infof("UPKEEP: checking archive expiries...")
 
+	held, err := c.db.GetLapsedHolds(time.Now())
+	if err != nil {
+		log.Errorf("error retrieving archives whose legal hold has lapsed: %s", err)
+		return
+	}
+
+	for _, archive := range held {
+		log.Infof("legal hold on archive %s lapsed at %d, releasing it", archive.UUID, archive.HoldUntil)
+		if err := c.db.ReleaseArchive(archive.UUID); err != nil {
+			log.Errorf("error releasing legal hold on archive %s: %s", archive.UUID, err)
+			continue
+		}
+		if _, err := c.db.CreateReleaseTask("system", archive); err != nil {
+			log.Errorf("error scheduling release of legal hold on archive %s: %s", archive.UUID, err)
+		}
+	}
+
 	l, err := c.db.GetExpiredArchives()
 	if err != nil {
 		log.Errorf("error retrieving archives that have outlived their retention policy: %s", err)
@@ -535,23 +717,42 @@ func (c *Core) CheckArchiveExpiries() {
 	}
 
 	for _, archive := range l {
-		log.Infof("archive %s has expiration %s, marking as expired", archive.UUID, archive.ExpiresAt)
-		if err := c.db.ExpireArchive(archive.UUID); err != nil {
+		log.Infof("archive %s has expiration %d, moving it to the recycle bin", archive.UUID, archive.ExpiresAt)
+		if err := c.db.RecycleArchive(archive.UUID, "expired", c.PurgeAfter()); err != nil {
 			log.Errorf("error marking archive %s as expired: %s", archive.UUID, err)
 			continue
 		}
-
-		log.Infof("deleting encryption parameters for archive %s", archive.UUID)
-		if err := c.vault.Delete(fmt.Sprintf("secret/archives/%s", archive.UUID)); err != nil {
-			log.Errorf("failed to delete encryption parameters for archive %s: %s", archive.UUID, err)
-		}
 	}
 }
 
+// PurgeAfter works out when an archive deleted (or expired) now
+// should leave the recycle bin, and be purged from cloud storage.
+func (c *Core) PurgeAfter() time.Time {
+	return time.Now().Add(time.Duration(c.Config.Purge.Grace) * time.Second)
+}
+
 func (c *Core) SchedulePurgeTasks() {
+	log.Infof("UPKEEP: emptying the recycle bin of archives past their grace period...")
+
+	l, err := c.db.GetArchivesPastGrace(time.Now())
+	if err != nil {
+		log.Errorf("error retrieving archives past their grace period: %s", err)
+		return
+	}
+
+	for _, archive := range l {
+		log.Infof("grace period for archive %s ended at %d; it can no longer be undeleted", archive.UUID, archive.PurgeAfter)
+		/* the encryption parameters are kept until the purge task
+		   succeeds; until then, the archive is still out there. */
+		if err := c.db.EndPurgeGrace(archive.UUID); err != nil {
+			log.Errorf("error ending grace period for archive %s: %s", archive.UUID, err)
+			continue
+		}
+	}
+
 	log.Infof("UPKEEP: schedule purge tasks for all expired archives...")
 
-	l, err := c.db.GetArchivesNeedingPurge()
+	l, err = c.db.GetArchivesNeedingPurge()
 	if err != nil {
 		log.Errorf("error retrieving archives to purge: %s", err)
 		return
@@ -721,7 +922,7 @@ func (c *Core) AnalyzeStorage() {
 				chore.Errorf("      total storage used: %d bytes", tenant.StorageUsed)
 				chore.Errorf("      # archives present: %d", tenant.ArchiveCount)
 
-				_, err = c.db.UpdateTenant(tenant)
+				err = c.db.UpdateTenantStats(tenant)
 				if err != nil {
 					chore.Errorf("      ERROR: failed to update tenant '%s':", tenant.UUID)
 					chore.Errorf("      ERROR: %s", err)
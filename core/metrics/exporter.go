@@ -33,6 +33,21 @@ type Exporter struct {
 	tasksGauge            prometheus.Gauge
 	archivesGauge         prometheus.Gauge
 	storageUsedBytesGauge prometheus.Gauge
+
+	quotaUsedGauge    *prometheus.GaugeVec
+	quotaLimitGauge   *prometheus.GaugeVec
+	quotaReachedGauge *prometheus.GaugeVec
+}
+
+// A TenantQuota is how much of a single resource a single tenant is
+// using, against its quota (where a Limit of zero means "unlimited").
+type TenantQuota struct {
+	TenantUUID string
+	Tenant     string
+	Resource   string
+	Used       int64
+	Limit      int64
+	Reached    bool
 }
 
 const (
@@ -44,6 +59,9 @@ const (
 	tasksTotal        = "tasks_total"
 	archivesTotal     = "archives_total"
 	storageBytesTotal = "storage_used_bytes"
+	tenantQuotaUsed   = "tenant_quota_used"
+	tenantQuotaLimit  = "tenant_quota_limit"
+	tenantQuotaReach  = "tenant_quota_reached"
 	/* TODO
 	targetHealthStatus = "target_health_status"
 	storeHealthStatus  = "storeHealthStatus"
@@ -124,6 +142,28 @@ func New(endpoint *Exporter) *Exporter {
 			Help:      "How much storage has been used, in bytes.",
 		})
 
+	quotaLabels := []string{"tenant_uuid", "tenant", "resource"}
+	endpoint.quotaUsedGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: endpoint.Namespace,
+			Name:      tenantQuotaUsed,
+			Help:      "How much of each quota-limited resource each tenant is using (storage in bytes)",
+		}, quotaLabels)
+
+	endpoint.quotaLimitGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: endpoint.Namespace,
+			Name:      tenantQuotaLimit,
+			Help:      "Each tenant's quota for each resource (0 = unlimited)",
+		}, quotaLabels)
+
+	endpoint.quotaReachedGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: endpoint.Namespace,
+			Name:      tenantQuotaReach,
+			Help:      "Whether (1) or not (0) each tenant has reached its quota for each resource",
+		}, quotaLabels)
+
 	prometheus.MustRegister(
 		endpoint.tenantsGauge,
 		endpoint.agentsGauge,
@@ -133,6 +173,9 @@ func New(endpoint *Exporter) *Exporter {
 		endpoint.tasksGauge,
 		endpoint.archivesGauge,
 		endpoint.storageUsedBytesGauge,
+		endpoint.quotaUsedGauge,
+		endpoint.quotaLimitGauge,
+		endpoint.quotaReachedGauge,
 	)
 
 	endpoint.tenantsGauge.Set(float64(endpoint.TenantCount))
@@ -160,6 +203,25 @@ func (e *Exporter) Handler() http.Handler {
 	}
 }
 
+// SetTenantQuotas replaces the per-tenant quota gauges wholesale, so
+// that tenants that have since been deleted drop out of the metrics.
+func (e *Exporter) SetTenantQuotas(l []TenantQuota) {
+	e.quotaUsedGauge.Reset()
+	e.quotaLimitGauge.Reset()
+	e.quotaReachedGauge.Reset()
+
+	for _, q := range l {
+		labels := prometheus.Labels{"tenant_uuid": q.TenantUUID, "tenant": q.Tenant, "resource": q.Resource}
+		e.quotaUsedGauge.With(labels).Set(float64(q.Used))
+		e.quotaLimitGauge.With(labels).Set(float64(q.Limit))
+		if q.Reached {
+			e.quotaReachedGauge.With(labels).Set(1)
+		} else {
+			e.quotaReachedGauge.With(labels).Set(0)
+		}
+	}
+}
+
 func (e *Exporter) createObjectCount(typ string, raw interface{}) {
 	data := raw.(map[string]interface{})
 	switch typ {
@@ -0,0 +1,320 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/shieldproject/shield/db"
+	"github.com/shieldproject/shield/route"
+)
+
+// Tenant permissions name the things that members of a tenant can be
+// allowed to do to it, and to everything in it.  Roles, built-in or
+// custom, are nothing more than named sets of these permissions.
+const (
+	PermView            = "view"
+	PermCredentials     = "credentials"
+	PermManageTargets   = "manage-targets"
+	PermManageStores    = "manage-stores"
+	PermManageJobs      = "manage-jobs"
+	PermManageBlackouts = "manage-blackouts"
+	PermRunJob          = "run-job"
+	PermPauseJob        = "pause-job"
+	PermCancelTask      = "cancel-task"
+	PermAnnotateArchive = "annotate-archive"
+	PermDeleteArchive   = "delete-archive"
+	PermHoldArchive     = "hold-archive"
+	PermRestore         = "restore"
+	PermDownload        = "download"
+	PermImport          = "import"
+	PermAudit           = "audit"
+	PermManageMembers   = "manage-members"
+)
+
+// TenantPermissions describes each of the tenant permissions.
+var TenantPermissions = map[string]string{
+	PermView:            "See the tenant, and its systems, stores, jobs, blackouts, tasks, and archives.",
+	PermCredentials:     "See the credentials (passwords, keys, etc.) in target and store configurations.",
+	PermManageTargets:   "Create, reconfigure, and delete target data systems.",
+	PermManageStores:    "Create, reconfigure, and delete cloud storage systems.",
+	PermManageJobs:      "Create, reconfigure, and delete backup jobs.",
+	PermManageBlackouts: "Create, reconfigure, and delete blackout windows.",
+	PermRunJob:          "Run backup jobs on demand.",
+	PermPauseJob:        "Pause and unpause backup jobs.",
+	PermCancelTask:      "Cancel running tasks.",
+	PermAnnotateArchive: "Annotate and label backup archives.",
+	PermDeleteArchive:   "Purge backup archives, and bring them back out of the recycle bin.",
+	PermHoldArchive:     "Place backup archives under legal hold.",
+	PermRestore:         "Restore backup archives.",
+	PermDownload:        "Download the contents of backup archives.",
+	PermImport:          "Import backups made outside of SHIELD as backup archives.",
+	PermAudit:           "See the audit log of changes made to the tenant.",
+	PermManageMembers:   "Invite and banish tenant members, and manage custom roles.",
+}
+
+// TenantPermissionNames lists the names of all of the tenant
+// permissions, in order.
+func TenantPermissionNames() []string {
+	l := make([]string, 0, len(TenantPermissions))
+	for perm := range TenantPermissions {
+		l = append(l, perm)
+	}
+	sort.Strings(l)
+	return l
+}
+
+// BuiltinTenantRoles lists the permissions held by each of the built-in
+// tenant roles; each role can do everything the role below it can.
+var BuiltinTenantRoles = map[string][]string{
+	"operator": {
+		PermAnnotateArchive,
+		PermCancelTask,
+		PermDeleteArchive,
+		PermPauseJob,
+		PermRestore,
+		PermRunJob,
+		PermView,
+	},
+	"engineer": {
+		PermAnnotateArchive,
+		PermCancelTask,
+		PermCredentials,
+		PermDeleteArchive,
+		PermDownload,
+		PermHoldArchive,
+		PermImport,
+		PermManageBlackouts,
+		PermManageJobs,
+		PermManageStores,
+		PermManageTargets,
+		PermPauseJob,
+		PermRestore,
+		PermRunJob,
+		PermView,
+	},
+	"admin": {
+		PermAnnotateArchive,
+		PermAudit,
+		PermCancelTask,
+		PermCredentials,
+		PermDeleteArchive,
+		PermDownload,
+		PermHoldArchive,
+		PermImport,
+		PermManageBlackouts,
+		PermManageJobs,
+		PermManageMembers,
+		PermManageStores,
+		PermManageTargets,
+		PermPauseJob,
+		PermRestore,
+		PermRunJob,
+		PermView,
+	},
+}
+
+// builtinTenantRole returns one of the built-in tenant roles as a
+// Role, or nil if there is no such built-in role.
+func builtinTenantRole(name string) *db.Role {
+	perms, ok := BuiltinTenantRoles[name]
+	if !ok {
+		return nil
+	}
+	return &db.Role{
+		Name:        name,
+		Summary:     fmt.Sprintf("The built-in %s role.", name),
+		Permissions: perms,
+		Builtin:     true,
+	}
+}
+
+// tenantRole looks up a role by name, as seen from a tenant: either
+// one of the built-in roles, or one of the tenant's own custom roles.
+// Unknown roles are returned as nil (without error).
+func (c *Core) tenantRole(tenant, name string) (*db.Role, error) {
+	if role := builtinTenantRole(name); role != nil {
+		return role, nil
+	}
+	return c.db.GetRole(tenant, name)
+}
+
+// tenantRoles lists all of the roles that members of a tenant can be
+// assigned, built-in roles first.
+func (c *Core) tenantRoles(tenant string) ([]*db.Role, error) {
+	l := []*db.Role{
+		builtinTenantRole("admin"),
+		builtinTenantRole("engineer"),
+		builtinTenantRole("operator"),
+	}
+
+	custom, err := c.db.GetRolesForTenant(tenant)
+	if err != nil {
+		return nil, err
+	}
+	return append(l, custom...), nil
+}
+
+// validPermissions checks that each of the given permissions names a
+// tenant permission.
+func validPermissions(perms []string) error {
+	for _, perm := range perms {
+		if _, ok := TenantPermissions[perm]; !ok {
+			return fmt.Errorf("unrecognized permission '%s'", perm)
+		}
+	}
+	return nil
+}
+
+// permissions works out what a user can do to a tenant: whatever their
+// role in that tenant allows, and (unless it is being withheld) what
+// their system role allows on every tenant.  Site managers and admins
+// can do anything a tenant admin can; site engineers anything a tenant
+// engineer can.
+//
+// Auth tokens limited to a tenant can do nothing to any other tenant,
+// and those limited to a role can do no more than that role allows.
+func (c *Core) permissions(user *db.User, scope db.TokenScope, tenant string) (map[string]bool, error) {
+	perms := make(map[string]bool)
+	if scope.Tenant != "" && scope.Tenant != tenant {
+		return perms, nil
+	}
+
+	grant := func(l []string) {
+		for _, perm := range l {
+			perms[perm] = true
+		}
+	}
+
+	if !c.mfaWithheld(user) {
+		switch user.SysRole {
+		case "admin", "manager":
+			grant(BuiltinTenantRoles["admin"])
+		case "engineer":
+			grant(BuiltinTenantRoles["engineer"])
+		}
+	}
+
+	memberships, err := c.db.GetMembershipsForUser(user.UUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve tenant memberships for user %s@%s (uuid %s): %s",
+			user.Account, user.Backend, user.UUID, err)
+	}
+	for _, m := range memberships {
+		if m.TenantUUID != tenant {
+			continue
+		}
+
+		role, err := c.tenantRole(tenant, m.Role)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve role '%s' of user %s@%s on tenant %s: %s",
+				m.Role, user.Account, user.Backend, tenant, err)
+		}
+		if role != nil {
+			grant(role.Permissions)
+		}
+		break
+	}
+
+	if scope.Role != "" {
+		limit, err := c.tokenRole(scope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve role '%s' of auth token for user %s@%s: %s",
+				scope.Role, user.Account, user.Backend, err)
+		}
+		return limitPermissions(perms, limit), nil
+	}
+	return perms, nil
+}
+
+// tokenRole returns the permissions of the role that an auth token is
+// limited to.  Custom roles are looked up on the token's tenant; one
+// that has since been deleted grants nothing at all.
+func (c *Core) tokenRole(scope db.TokenScope) ([]string, error) {
+	if scope.Role == "" {
+		return nil, nil
+	}
+	role, err := c.tenantRole(scope.Tenant, scope.Role)
+	if err != nil || role == nil {
+		return []string{}, err
+	}
+	return role.Permissions, nil
+}
+
+// limitPermissions returns only those of the given permissions that
+// are also in the list of permissions to limit them to.
+func limitPermissions(perms map[string]bool, to []string) map[string]bool {
+	limited := make(map[string]bool)
+	for _, perm := range to {
+		if perms[perm] {
+			limited[perm] = true
+		}
+	}
+	return limited
+}
+
+// hasPermission checks that the authenticated user holds a permission
+// on a tenant, failing the request (if asked to) when they don't.
+func (c *Core) hasPermission(fail bool, r *route.Request, tenant, perm string) bool {
+	user, session, err := c.authenticate(r)
+	if user == nil || err != nil {
+		r.Fail(route.Unauthorized(err, "Authorization required"))
+		return false
+	}
+
+	perms, err := c.permissions(user, session.Scope, tenant)
+	if err != nil {
+		if fail {
+			r.Fail(route.Forbidden(err, "Access denied"))
+		}
+		return false
+	}
+	if perms[perm] {
+		return true
+	}
+
+	if fail {
+		if session.Token != "" && (session.Scope.Tenant != "" || session.Scope.Role != "") {
+			if all, err := c.permissions(user, db.TokenScope{}, tenant); err == nil && all[perm] {
+				r.Fail(route.Forbidden(nil, "Access denied (beyond the scope of this auth token)"))
+				return false
+			}
+		}
+		r.Fail(route.Forbidden(nil, "Access denied"))
+	}
+	return false
+}
+
+// cannotGrant checks that the authenticated user holds every one of
+// the given permissions on a tenant, failing the request if not, so
+// that those who can manage members and roles cannot use that to hand
+// out (to themselves, or to anyone else) more than they have.
+func (c *Core) cannotGrant(r *route.Request, tenant string, perms []string) bool {
+	user, session, err := c.authenticate(r)
+	if user == nil || err != nil {
+		r.Fail(route.Unauthorized(err, "Authorization required"))
+		return true
+	}
+
+	have, err := c.permissions(user, session.Scope, tenant)
+	if err != nil {
+		r.Fail(route.Forbidden(err, "Access denied"))
+		return true
+	}
+	for _, perm := range perms {
+		if !have[perm] {
+			r.Fail(route.Forbidden(nil, "Access denied (you cannot grant the '%s' permission, since you do not hold it yourself)", perm))
+			return true
+		}
+	}
+	return false
+}
+
+// permissionList turns a set of permissions into a sorted list.
+func permissionList(perms map[string]bool) []string {
+	l := make([]string, 0, len(perms))
+	for perm := range perms {
+		l = append(l, perm)
+	}
+	sort.Strings(l)
+	return l
+}
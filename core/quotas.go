@@ -0,0 +1,118 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jhunt/go-log"
+
+	"github.com/shieldproject/shield/core/metrics"
+	"github.com/shieldproject/shield/db"
+	"github.com/shieldproject/shield/route"
+)
+
+// quotaReached describes which of the given resources the tenant has
+// used up its quota for, or returns "" if it still has room.
+func (c *Core) quotaReached(tenant *db.Tenant, resources ...string) (string, error) {
+	l, err := c.db.GetTenantQuotas(tenant)
+	if err != nil {
+		return "", err
+	}
+
+	var reached []string
+	for _, q := range l {
+		if !q.Reached {
+			continue
+		}
+		for _, resource := range resources {
+			if q.Resource == resource {
+				reached = append(reached, q.String())
+			}
+		}
+	}
+	if len(reached) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("tenant '%s' has reached its quota for %s", tenant.Name, strings.Join(reached, ", ")), nil
+}
+
+// checkQuota checks the tenant's quotas on the given resources before
+// something is created on its behalf.  If the tenant enforces its
+// quotas, and any of them have been reached, the request is refused,
+// and checkQuota returns true.  Otherwise, it returns a warning about
+// any quotas that have been reached, which is also logged.
+func (c *Core) checkQuota(r *route.Request, id string, resources ...string) (string, bool) {
+	tenant, err := c.db.GetTenant(id)
+	if err != nil {
+		r.Fail(route.Oops(err, "Unable to retrieve tenant information"))
+		return "", true
+	}
+	if tenant == nil {
+		r.Fail(route.NotFound(nil, "No such tenant"))
+		return "", true
+	}
+
+	reached, err := c.quotaReached(tenant, resources...)
+	if err != nil {
+		r.Fail(route.Oops(err, "Unable to retrieve tenant quota information"))
+		return "", true
+	}
+	if reached == "" {
+		return "", false
+	}
+
+	if tenant.EnforceQuotas {
+		r.Fail(route.Forbidden(nil, "Over quota: %s", reached))
+		return "", true
+	}
+	log.Warnf("%s (quotas are not enforced for this tenant; carrying on anyway)", reached)
+	return reached, false
+}
+
+// backupQuota checks if another backup of the given job would take
+// its tenant (further) over its storage or archive quotas, and if so,
+// describes the quotas reached, and whether or not they are enforced.
+func (c *Core) backupQuota(job *db.Job) (string, bool) {
+	tenant, err := c.db.GetTenant(job.TenantUUID)
+	if err != nil || tenant == nil {
+		log.Errorf("unable to retrieve tenant %s (for job %s [%s]), in order to check its quotas: %s", job.TenantUUID, job.Name, job.UUID, err)
+		return "", false
+	}
+
+	reached, err := c.quotaReached(tenant, db.QuotaStorage, db.QuotaArchives)
+	if err != nil {
+		log.Errorf("unable to check quotas of tenant %s (for job %s [%s]): %s", job.TenantUUID, job.Name, job.UUID, err)
+		return "", false
+	}
+	return reached, tenant.EnforceQuotas
+}
+
+// UpdateQuotaMetrics refreshes the per-tenant quota usage that we
+// export to Prometheus.
+func (c *Core) UpdateQuotaMetrics() {
+	tenants, err := c.db.GetAllTenants(nil)
+	if err != nil {
+		log.Errorf("unable to retrieve tenants from database, in order to update quota metrics: %s", err)
+		return
+	}
+
+	var l []metrics.TenantQuota
+	for _, tenant := range tenants {
+		quotas, err := c.db.GetTenantQuotas(tenant)
+		if err != nil {
+			log.Errorf("unable to retrieve quota usage of tenant %s [%s]: %s", tenant.Name, tenant.UUID, err)
+			continue
+		}
+		for _, q := range quotas {
+			l = append(l, metrics.TenantQuota{
+				TenantUUID: tenant.UUID,
+				Tenant:     tenant.Name,
+				Resource:   q.Resource,
+				Used:       q.Used,
+				Limit:      q.Limit,
+				Reached:    q.Reached,
+			})
+		}
+	}
+	c.metrics.SetTenantQuotas(l)
+}
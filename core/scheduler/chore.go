@@ -10,6 +10,7 @@ import (
 	"github.com/jhunt/go-log"
 
 	"github.com/shieldproject/shield/db"
+	"github.com/shieldproject/shield/timespec"
 )
 
 var next = 0
@@ -19,6 +20,16 @@ type Chore struct {
 	TaskUUID   string
 	Encryption string
 
+	Agent      string
+	StoreUUID  string
+	TenantUUID string
+
+	Window     *timespec.Window
+	MaxRuntime time.Duration
+
+	queued   time.Time
+	deadline time.Time
+
 	Do func(chore Chore)
 
 	Stdout chan string
@@ -78,12 +89,24 @@ func (w *Worker) Execute(chore Chore) {
 
 	var wait sync.WaitGroup
 
-	w.Reserve(chore.TaskUUID)
 	defer w.Release()
 
 	log.Infof("%s: %s executing chore for task '%s'", chore, w, chore.TaskUUID)
 	w.db.StartTask(chore.TaskUUID, time.Now())
 
+	if !chore.deadline.IsZero() {
+		log.Infof("%s: task '%s' must finish by %s", chore, chore.TaskUUID, chore.deadline.Format(time.RFC3339))
+		w.db.TaskDeadline(chore.TaskUUID, chore.deadline)
+
+		timer := time.AfterFunc(time.Until(chore.deadline), func() {
+			if w.Kill(chore.TaskUUID, db.OverrunStatus) {
+				log.Errorf("%s: task '%s' ran past its deadline of %s; terminating", chore, chore.TaskUUID, chore.deadline.Format(time.RFC3339))
+				w.db.UpdateTaskLog(chore.TaskUUID, fmt.Sprintf("\n\nTIMEOUT: task did not finish by %s; terminating it...\n", chore.deadline.Format(time.RFC3339)))
+			}
+		})
+		defer timer.Stop()
+	}
+
 	log.Debugf("%s: spinning up [stderr] goroutine to watch chore stderr and update the task log...", chore)
 	wait.Add(1)
 	go func() {
@@ -123,7 +146,7 @@ func (w *Worker) Execute(chore Chore) {
 	go func() {
 		chore.Do(chore)
 
-		if rc != 0 {
+		if rc != 0 && w.Killed() == "" {
 			job, err := w.db.GetJob(task.JobUUID)
 			if err != nil {
 				panic(fmt.Errorf("failed to retrieve job '%s' from database: %s", task.JobUUID, err))
@@ -131,7 +154,7 @@ func (w *Worker) Execute(chore Chore) {
 			retries := job.Retries
 			log.Infof("Retries: %d", chore)
 			if retries > 0 {
-				for i := 0; i < retries || rc == 0; i++ {
+				for i := 0; (i < retries || rc == 0) && w.Killed() == ""; i++ {
 					w.db.UpdateTaskLog(chore.TaskUUID, "\n\n------\n\n")
 					w.db.UpdateTaskLog(chore.TaskUUID, fmt.Sprintf("RETRY: `%d`\n", i+1))
 					chore.Do(chore)
@@ -151,6 +174,18 @@ func (w *Worker) Execute(chore Chore) {
 	wait.Wait()
 	w.db.UpdateTaskLog(chore.TaskUUID, "\n\n------\n")
 
+	switch w.Killed() {
+	case db.OverrunStatus:
+		log.Debugf("%s: marking task '%s' as OVERRUN in database", chore, chore.TaskUUID)
+		w.db.OverrunTask(chore.TaskUUID, time.Now())
+		return
+
+	case db.CanceledStatus:
+		log.Debugf("%s: marking task '%s' as CANCELED in database", chore, chore.TaskUUID)
+		w.db.CancelTask(chore.TaskUUID, time.Now())
+		return
+	}
+
 	switch task.Op {
 	case db.BackupOperation:
 		output = strings.TrimSpace(output)
@@ -222,7 +257,7 @@ func (w *Worker) Execute(chore Chore) {
 			tenant.StorageUsed += v.Size
 			tenant.ArchiveCount += 1
 			tenant.DailyIncrease += v.Size
-			_, err := w.db.UpdateTenant(tenant)
+			err := w.db.UpdateTenantStats(tenant)
 			if err != nil {
 				log.Errorf("%s: failed to update tenant in the database: %s", chore, err)
 				w.db.UpdateTaskLog(task.UUID, "WARNING: tenant usage statistics were not updated...\n")
@@ -247,6 +282,15 @@ func (w *Worker) Execute(chore Chore) {
 			panic(fmt.Errorf("%s: failed to purge the archive record from the database: %s", chore, err))
 		}
 
+		/* only now that the data is gone can its key go too */
+		if w.vault != nil {
+			log.Infof("%s: deleting encryption parameters for archive '%s'", chore, task.ArchiveUUID)
+			if err := w.vault.Delete(fmt.Sprintf("secret/archives/%s", task.ArchiveUUID)); err != nil {
+				log.Errorf("%s: failed to delete encryption parameters for archive '%s': %s", chore, task.ArchiveUUID, err)
+				w.db.UpdateTaskLog(task.UUID, "\nWARNING: encryption parameters were NOT deleted from the vault...\n")
+			}
+		}
+
 		w.db.UpdateTaskLog(task.UUID, "\nPURGE: recalculating cloud storage usage statistics...\n")
 		archive, err := w.db.GetArchive(task.ArchiveUUID)
 		if err != nil {
@@ -280,7 +324,7 @@ func (w *Worker) Execute(chore Chore) {
 			tenant.StorageUsed -= archive.Size
 			tenant.ArchiveCount -= 1
 			tenant.DailyIncrease -= archive.Size
-			_, err := w.db.UpdateTenant(tenant)
+			err := w.db.UpdateTenantStats(tenant)
 			if err != nil {
 				log.Errorf("%s: failed to update tenant in the database: %s", chore, err)
 				w.db.UpdateTaskLog(task.UUID, "WARNING: tenant usage statistics were not updated...\n")
@@ -290,6 +334,15 @@ func (w *Worker) Execute(chore Chore) {
 		}
 		w.db.UpdateTaskLog(task.UUID, "\n\n")
 
+	case db.HoldOperation, db.ReleaseOperation:
+		if rc != 0 {
+			log.Debugf("%s: FAILING task '%s' in database", chore, chore.TaskUUID)
+			w.db.UpdateTaskLog(task.UUID, "\nHOLD: unable to update the hold in cloud storage.\n")
+			w.db.UpdateTaskLog(task.UUID, "HOLD: SHIELD itself will still honor the legal hold (or its release).\n")
+			w.db.FailTask(chore.TaskUUID, time.Now())
+			return
+		}
+
 	case db.TestStoreOperation:
 		var v struct {
 			Healthy bool `json:"healthy"`
@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"time"
+)
+
+/* weighted fair queuing across tenants
+
+   Within a single priority level, chores are not dispatched in strict
+   FIFO order.  Instead, each tenant is given a virtual "start tag",
+   and the next chore to run is the oldest chore belonging to the
+   tenant with the lowest tag.  Every time a tenant has a chore
+   dispatched, its tag advances by 1/share, so a tenant with a share
+   of 3 gets three chores dispatched for every one dispatched for a
+   tenant with a share of 1, while both have work queued.
+
+   Tenants that have been idle re-enter at the current virtual time,
+   so they cannot "bank" credit while they have nothing queued.
+   Chores that aren't bound to a tenant (global and internal tasks)
+   are accounted for under the empty tenant UUID, with a share of 1. */
+
+func (s *Scheduler) SetShares(shares map[string]int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.shares = shares
+}
+
+func (s *Scheduler) share(tenant string) int {
+	if n, ok := s.shares[tenant]; ok && n > 0 {
+		return n
+	}
+	return 1
+}
+
+func (s *Scheduler) start(tenant string) float64 {
+	if tag := s.tags[tenant]; tag > s.vclock {
+		return tag
+	}
+	return s.vclock
+}
+
+// next picks the index of the next chore in the queue to dispatch,
+// or -1 if every chore in the queue is held back by a limit.
+func (s *Scheduler) next(queue []Chore, running usage) int {
+	best := -1
+	lowest := 0.0
+	seen := make(map[string]bool)
+
+	for i, chore := range queue {
+		if seen[chore.TenantUUID] {
+			continue
+		}
+		if s.limits.blocks(running, chore) != "" {
+			continue
+		}
+		seen[chore.TenantUUID] = true
+
+		if tag := s.start(chore.TenantUUID); best < 0 || tag < lowest {
+			best = i
+			lowest = tag
+		}
+	}
+
+	return best
+}
+
+// charge accounts for the dispatch of a chore against its tenant's
+// share, and remembers how long that chore waited in the backlog.
+func (s *Scheduler) charge(chore Chore, now time.Time) {
+	start := s.start(chore.TenantUUID)
+	s.vclock = start
+	s.tags[chore.TenantUUID] = start + 1.0/float64(s.share(chore.TenantUUID))
+
+	if !chore.queued.IsZero() {
+		s.waits[chore.TenantUUID] = now.Sub(chore.queued)
+	}
+}
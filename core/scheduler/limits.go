@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shieldproject/shield/db"
+)
+
+// Limits caps how many chores can be running at once against any
+// single SHIELD agent, cloud storage system, or tenant.  A limit
+// of zero (the default) means "unlimited".
+//
+// Tenants holds per-tenant caps on concurrent chores (from each
+// tenant's task quota), which apply on top of the Tenant limit.
+//
+// Runtime caps the maximum run time that individual jobs can set for
+// themselves; chores with no maximum run time are not subject to it.
+type Limits struct {
+	Agent   int
+	Store   int
+	Tenant  int
+	Tenants map[string]int
+
+	Runtime time.Duration
+}
+
+// SetTenantLimits replaces the per-tenant caps on concurrent chores.
+func (s *Scheduler) SetTenantLimits(limits map[string]int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.limits.Tenants = limits
+}
+
+type usage struct {
+	agents  map[string]int
+	stores  map[string]int
+	tenants map[string]int
+}
+
+func newUsage() usage {
+	return usage{
+		agents:  make(map[string]int),
+		stores:  make(map[string]int),
+		tenants: make(map[string]int),
+	}
+}
+
+func (u usage) add(chore Chore) {
+	if chore.Agent != "" {
+		u.agents[chore.Agent] += 1
+	}
+	if chore.StoreUUID != "" {
+		u.stores[chore.StoreUUID] += 1
+	}
+	if chore.TenantUUID != "" {
+		u.tenants[chore.TenantUUID] += 1
+	}
+}
+
+// blocks returns a human-readable explanation of why the given
+// chore cannot be dispatched right now, given what is already
+// running, or the empty string if it is free to go.
+func (l Limits) blocks(u usage, chore Chore) string {
+	if l.Agent > 0 && chore.Agent != "" && u.agents[chore.Agent] >= l.Agent {
+		return fmt.Sprintf("agent %s is already running %d task(s) (limit %d)", chore.Agent, u.agents[chore.Agent], l.Agent)
+	}
+	if l.Store > 0 && chore.StoreUUID != "" && u.stores[chore.StoreUUID] >= l.Store {
+		return fmt.Sprintf("store %s is already in use by %d task(s) (limit %d)", chore.StoreUUID, u.stores[chore.StoreUUID], l.Store)
+	}
+	if l.Tenant > 0 && chore.TenantUUID != "" && u.tenants[chore.TenantUUID] >= l.Tenant {
+		return fmt.Sprintf("tenant %s is already running %d task(s) (limit %d)", chore.TenantUUID, u.tenants[chore.TenantUUID], l.Tenant)
+	}
+	if n := l.Tenants[chore.TenantUUID]; n > 0 && chore.TenantUUID != "" && u.tenants[chore.TenantUUID] >= n {
+		return fmt.Sprintf("tenant %s is already running %d task(s) (quota %d)", chore.TenantUUID, u.tenants[chore.TenantUUID], n)
+	}
+	return ""
+}
+
+// Bind associates a chore with the agent, store, and tenant of the
+// task it is executing, so that the scheduler can enforce Limits.
+func (chore Chore) Bind(task *db.Task) Chore {
+	chore.Agent = task.Agent
+	chore.StoreUUID = task.StoreUUID
+	if task.TenantUUID != db.GlobalTenantUUID {
+		chore.TenantUUID = task.TenantUUID
+	}
+	return chore
+}
@@ -3,7 +3,11 @@ package scheduler
 import (
 	"fmt"
 	"sync"
+	"time"
 
+	"github.com/jhunt/go-log"
+
+	"github.com/shieldproject/shield/core/vault"
 	"github.com/shieldproject/shield/db"
 )
 
@@ -25,19 +29,34 @@ const MaxPriority = 100
 
 type Scheduler struct {
 	lock    sync.Mutex
+	db      *db.DB
 	workers []*Worker
 	chores  [][]Chore
+	limits  Limits
+	waiting map[string]string
+
+	shares map[string]int
+	tags   map[string]float64
+	waits  map[string]time.Duration
+	vclock float64
 }
 
-func New(workers int, db *db.DB) *Scheduler {
+func New(workers int, limits Limits, db *db.DB, vault *vault.Client) *Scheduler {
 	pool := make([]*Worker, workers)
 	for i := range pool {
-		pool[i] = NewWorker(db)
+		pool[i] = NewWorker(db, vault)
 	}
 
 	return &Scheduler{
+		db:      db,
 		workers: pool,
 		chores:  make([][]Chore, MaxPriority),
+		limits:  limits,
+		waiting: make(map[string]string),
+
+		shares: make(map[string]int),
+		tags:   make(map[string]float64),
+		waits:  make(map[string]time.Duration),
 	}
 }
 
@@ -49,31 +68,78 @@ func (s *Scheduler) Schedule(priority int, chore Chore) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
+	if chore.queued.IsZero() {
+		chore.queued = time.Now()
+	}
 	s.chores[priority-1] = append(s.chores[priority-1], chore)
 	return nil
 }
 
 func (s *Scheduler) Run() {
-	prio := 0
-
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
+	running := newUsage()
+	idle := make([]*Worker, 0, len(s.workers))
 	for _, worker := range s.workers {
-		if !worker.Available() {
+		if worker.Available() {
+			idle = append(idle, worker)
+		} else {
+			running.add(worker.chore)
+		}
+	}
+
+	/* walk the backlog in priority order, handing chores to idle
+	   workers.  within each priority, tenants are served according
+	   to their fair share (see fairness.go).  chores that would
+	   exceed one of the concurrency limits stay put (along with the
+	   reason why), but do not hold up anything queued behind them. */
+	now := time.Now()
+	s.waiting = make(map[string]string)
+	for prio := range s.chores {
+		if len(s.chores[prio]) == 0 {
 			continue
 		}
 
-		for len(s.chores[prio]) == 0 {
-			prio += 1
-			if prio == MaxPriority {
-				return
+		/* chores whose backup window has closed since they were
+		   queued go back to the database as pending; the core will
+		   hand them to us again once the window re-opens. */
+		queue, closed := deferrable(s.chores[prio], now)
+		for _, chore := range closed {
+			log.Infof("%s: backup window for task '%s' is closed (%s); deferring until %s",
+				chore, chore.TaskUUID, chore.Window, chore.Window.Opens(now).Format(time.RFC3339))
+			if s.db != nil {
+				s.db.UpdateTaskLog(chore.TaskUUID, fmt.Sprintf("outside of backup window (%s); deferring...\n", chore.Window))
+				s.db.DeferTask(chore.TaskUUID)
 			}
 		}
 
-		chore := s.chores[prio][0]
-		s.chores[prio] = s.chores[prio][1:]
+		for len(idle) > 0 {
+			i := s.next(queue, running)
+			if i < 0 {
+				break
+			}
+
+			chore := queue[i]
+			queue = append(queue[:i], queue[i+1:]...)
 
-		go worker.Execute(chore)
+			worker := idle[0]
+			idle = idle[1:]
+
+			s.charge(chore, now)
+			chore.deadline = s.deadline(chore, now)
+			worker.Reserve(chore)
+			running.add(chore)
+			go worker.Execute(chore)
+		}
+
+		for _, chore := range queue {
+			if why := s.limits.blocks(running, chore); why != "" {
+				s.waiting[chore.ID] = why
+			} else {
+				s.waiting[chore.ID] = "all scheduler threads are busy"
+			}
+		}
+		s.chores[prio] = queue
 	}
 }
@@ -1,9 +1,16 @@
 package scheduler
 
+import (
+	"sort"
+	"time"
+)
+
 type BacklogStatus struct {
 	Priority int    `json:"priority"`
 	Position int    `json:"position"`
 	TaskUUID string `json:"task_uuid"`
+	Reason   string `json:"reason,omitempty"`
+	Wait     int    `json:"wait"`
 }
 
 type WorkerStatus struct {
@@ -13,9 +20,20 @@ type WorkerStatus struct {
 	LastSeen int    `json:"last_seen"`
 }
 
+type TenantStatus struct {
+	TenantUUID  string `json:"tenant_uuid"`
+	Share       int    `json:"share"`
+	Queued      int    `json:"queued"`
+	Running     int    `json:"running"`
+	LongestWait int    `json:"longest_wait"`
+	AverageWait int    `json:"average_wait"`
+	LastWait    int    `json:"last_wait"`
+}
+
 type Status struct {
 	Backlog []BacklogStatus `json:"backlog"`
 	Workers []WorkerStatus  `json:"workers"`
+	Tenants []TenantStatus  `json:"tenants"`
 }
 
 func (s *Scheduler) Status() Status {
@@ -33,15 +51,59 @@ func (s *Scheduler) Status() Status {
 		status.Workers[i].LastSeen = w.last
 	}
 
+	now := time.Now()
+	tenants := make(map[string]*TenantStatus)
+	tenant := func(uuid string) *TenantStatus {
+		if _, ok := tenants[uuid]; !ok {
+			tenants[uuid] = &TenantStatus{
+				TenantUUID: uuid,
+				Share:      s.share(uuid),
+				LastWait:   int(s.waits[uuid].Seconds()),
+			}
+		}
+		return tenants[uuid]
+	}
+
+	for _, w := range s.workers {
+		if !w.available {
+			tenant(w.chore.TenantUUID).Running += 1
+		}
+	}
+
+	total := make(map[string]int)
 	for prio, lst := range s.chores {
 		for i, chore := range lst {
+			wait := 0
+			if !chore.queued.IsZero() {
+				wait = int(now.Sub(chore.queued).Seconds())
+			}
+
 			status.Backlog = append(status.Backlog, BacklogStatus{
 				Priority: prio,
 				Position: i,
 				TaskUUID: chore.TaskUUID,
+				Reason:   s.waiting[chore.ID],
+				Wait:     wait,
 			})
+
+			t := tenant(chore.TenantUUID)
+			t.Queued += 1
+			if wait > t.LongestWait {
+				t.LongestWait = wait
+			}
+			total[chore.TenantUUID] += wait
+		}
+	}
+
+	for uuid, t := range tenants {
+		if t.Queued > 0 {
+			t.AverageWait = total[uuid] / t.Queued
 		}
+		status.Tenants = append(status.Tenants, *t)
 	}
+	sort.Slice(status.Tenants, func(i, j int) bool {
+		return status.Tenants[i].TenantUUID < status.Tenants[j].TenantUUID
+	})
 
 	return status
 }
@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/shieldproject/shield/db"
+)
+
+// deadline works out when a chore that starts at the given time must
+// be finished by, taking into account the per-job maximum run time,
+// and the job's backup window (if it has a finish-by time).  The global
+// Runtime limit only caps per-job maximum run times; chores that have
+// no maximum run time of their own are never killed for running long.
+// The zero time means "no deadline".
+func (s *Scheduler) deadline(chore Chore, start time.Time) time.Time {
+	var by time.Time
+	sooner := func(t time.Time) {
+		if !t.IsZero() && (by.IsZero() || t.Before(by)) {
+			by = t
+		}
+	}
+
+	if chore.MaxRuntime > 0 {
+		sooner(start.Add(chore.MaxRuntime))
+		if s.limits.Runtime > 0 {
+			sooner(start.Add(s.limits.Runtime))
+		}
+	}
+	sooner(chore.Window.Deadline(start))
+	return by
+}
+
+// deferrable splits a queue of chores into those that may be started
+// right now, and those whose backup window is currently closed.
+func deferrable(queue []Chore, now time.Time) ([]Chore, []Chore) {
+	ready := make([]Chore, 0, len(queue))
+	closed := make([]Chore, 0)
+	for _, chore := range queue {
+		if chore.Window.Open(now) {
+			ready = append(ready, chore)
+		} else {
+			closed = append(closed, chore)
+		}
+	}
+	return ready, closed
+}
+
+// Cancel stops the given task, whether it is still waiting in the
+// backlog or is already running on a worker.  Running chores are asked
+// to terminate (via their Cancel channel); it is up to the fabric to
+// carry that out on the remote agent.  Cancel returns false if the
+// scheduler knows nothing about the task.
+func (s *Scheduler) Cancel(task string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for prio := range s.chores {
+		for i, chore := range s.chores[prio] {
+			if chore.TaskUUID == task {
+				s.chores[prio] = append(s.chores[prio][:i], s.chores[prio][i+1:]...)
+				delete(s.waiting, chore.ID)
+				return true
+			}
+		}
+	}
+
+	for _, worker := range s.workers {
+		if worker.Kill(task, db.CanceledStatus) {
+			return true
+		}
+	}
+	return false
+}
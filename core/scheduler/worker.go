@@ -2,9 +2,11 @@ package scheduler
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/jhunt/go-log"
 
+	"github.com/shieldproject/shield/core/vault"
 	"github.com/shieldproject/shield/db"
 )
 
@@ -14,35 +16,78 @@ type Worker struct {
 	id        int
 	available bool
 	task      string
+	chore     Chore
 	last      int
 	db        *db.DB
+	vault     *vault.Client
+
+	kill   sync.Mutex
+	killed string
 }
 
-func NewWorker(db *db.DB) *Worker {
+func NewWorker(db *db.DB, vault *vault.Client) *Worker {
 	serial += 1
 	return &Worker{
 		id:        serial,
 		available: true,
 		db:        db,
+		vault:     vault,
 	}
 }
 
-func (t Worker) String() string {
+func (t *Worker) String() string {
 	return fmt.Sprintf("worker t#%03d", t.id)
 }
 
-func (t Worker) Available() bool {
+func (t *Worker) Available() bool {
 	return t.available
 }
 
-func (t *Worker) Reserve(task string) {
+func (t *Worker) Reserve(chore Chore) {
 	log.Infof("reserving %s...", t)
+	t.kill.Lock()
+	defer t.kill.Unlock()
+
 	t.available = false
-	t.task = task
+	t.task = chore.TaskUUID
+	t.chore = chore
+	t.killed = ""
 }
 
 func (t *Worker) Release() {
 	log.Infof("releasing %s...", t)
+	t.kill.Lock()
+	defer t.kill.Unlock()
+
 	t.available = true
 	t.task = ""
+	t.chore = Chore{}
+}
+
+// Kill asks the chore currently executing the given task to stop, by
+// closing its Cancel channel, and remembers the task status that should
+// be recorded once it does.  Kill returns false if this worker is not
+// (or is no longer) running that task, or if it has already been killed.
+func (t *Worker) Kill(task, status string) bool {
+	t.kill.Lock()
+	defer t.kill.Unlock()
+
+	if t.available || t.task != task || t.killed != "" {
+		return false
+	}
+
+	log.Infof("killing %s (task '%s'); will mark it as %s...", t, task, status)
+	t.killed = status
+	if t.chore.Cancel != nil {
+		close(t.chore.Cancel)
+	}
+	return true
+}
+
+// Killed returns the status that the running task should be recorded
+// with if it was killed, or the empty string if it was not.
+func (t *Worker) Killed() string {
+	t.kill.Lock()
+	defer t.kill.Unlock()
+	return t.killed
 }
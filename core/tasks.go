@@ -3,10 +3,12 @@ package core
 import (
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jhunt/go-log"
 
+	"github.com/shieldproject/shield/core/scheduler"
 	"github.com/shieldproject/shield/db"
 )
 
@@ -25,3 +27,77 @@ func (c *Core) TaskErrored(task *db.Task, fail string, args ...interface{}) {
 		log.Errorf("  %s: !! failed to update database: %s", task.UUID, err)
 	}
 }
+
+// RunNow runs the chore for a task right away, in the calling goroutine,
+// instead of handing it to the scheduler; this is how downloads and
+// imports, which are tied to the API request that asked for them, get
+// carried out.  Each line of the chore's standard output is passed to
+// stdout as it arrives, and standard error goes to the task log.  If
+// stdout returns an error, or cancel is closed, the chore is canceled.
+//
+// The caller is responsible for completing (or failing) the task.
+func (c *Core) RunNow(task *db.Task, chore scheduler.Chore, cancel <-chan struct{}, stdout func(string) error) error {
+	var (
+		wait   sync.WaitGroup
+		once   sync.Once
+		failed error
+		rc     int
+	)
+
+	stop := func() {
+		once.Do(func() { close(chore.Cancel) })
+	}
+	finished := make(chan bool)
+	defer close(finished)
+	go func() {
+		select {
+		case <-cancel:
+			log.Infof("%s: requester went away; canceling %s task", task.UUID, task.Op)
+			stop()
+		case <-finished:
+		}
+	}()
+
+	wait.Add(1)
+	go func() {
+		for s := range chore.Stderr {
+			c.db.UpdateTaskLog(task.UUID, s)
+		}
+		wait.Done()
+	}()
+
+	wait.Add(1)
+	go func() {
+		for s := range chore.Stdout {
+			if failed != nil {
+				continue
+			}
+			if err := stdout(s); err != nil {
+				failed = err
+				stop()
+			}
+		}
+		wait.Done()
+	}()
+
+	wait.Add(1)
+	go func() {
+		rc = <-chore.Exit
+		wait.Done()
+	}()
+
+	chore.Do(chore)
+	chore.UnixExit(0) /* catch-all */
+	close(chore.Stderr)
+	close(chore.Stdout)
+	wait.Wait()
+	c.db.UpdateTaskLog(task.UUID, "\n\n------\n")
+
+	if failed != nil {
+		return failed
+	}
+	if rc != 0 {
+		return fmt.Errorf("%s task failed on the agent (exit %d)", task.Op, rc)
+	}
+	return nil
+}
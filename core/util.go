@@ -6,8 +6,25 @@ import (
 	"github.com/shieldproject/shield/db"
 )
 
-func IsValidTenantRole(role string) bool {
-	return role == "admin" || role == "engineer" || role == "operator"
+// IsValidTenantRole checks that a role can be assigned on the named
+// tenant: either it is one of the built-in roles, or it is one of that
+// tenant's custom roles.  Tenants that don't exist yet (and will be
+// created on first login) have no custom roles.
+func (c *Core) IsValidTenantRole(tenant, role string) (bool, error) {
+	if db.IsBuiltinRole(role) {
+		return true, nil
+	}
+
+	l, err := c.db.GetAllTenants(&db.TenantFilter{
+		Name:       tenant,
+		ExactMatch: true,
+	})
+	if err != nil || len(l) != 1 {
+		return false, err
+	}
+
+	custom, err := c.db.GetRole(l[0].UUID, role)
+	return custom != nil, err
 }
 
 func IsValidSystemRole(role string) bool {
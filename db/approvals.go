@@ -0,0 +1,253 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The operations that tenants can require a second person to approve.
+const (
+	ApproveRestore      = "restore"
+	ApprovePurge        = "purge"
+	ApproveDeleteTarget = "delete-target"
+)
+
+// An Approval is a request, made by one member of a tenant, to carry
+// out some destructive operation (a restore, an archive purge, or a
+// target deletion) that the tenant requires a second person to approve.
+//
+// Approvals start out "pending", and end up "approved", "rejected", or
+// (if no one decides before ExpiresAt) "expired".  An approved request
+// that could not be carried out after all is "failed".
+type Approval struct {
+	UUID        string `json:"uuid"`
+	TenantUUID  string `json:"tenant_uuid"`
+	Operation   string `json:"operation"`
+	ArchiveUUID string `json:"archive_uuid,omitempty"`
+	TargetUUID  string `json:"target_uuid,omitempty"`
+	Summary     string `json:"summary"`
+
+	RequestedBy   string `json:"requested_by"`
+	RequesterUUID string `json:"requester_uuid"`
+	RequestedAt   int64  `json:"requested_at"`
+	ExpiresAt     int64  `json:"expires_at"`
+
+	Status    string `json:"status"`
+	DecidedBy string `json:"decided_by,omitempty"`
+	DecidedAt int64  `json:"decided_at,omitempty"`
+	TaskUUID  string `json:"task_uuid,omitempty"`
+}
+
+type ApprovalFilter struct {
+	UUID       string
+	ExactMatch bool
+	ForTenant  string
+	ForStatus  string
+	Limit      int
+}
+
+func (f *ApprovalFilter) Query() (string, []interface{}) {
+	wheres := []string{"1"}
+	args := []interface{}{}
+
+	if f.UUID != "" {
+		if f.ExactMatch {
+			wheres = append(wheres, "a.uuid = ?")
+			args = append(args, f.UUID)
+		} else {
+			wheres = append(wheres, "a.uuid LIKE ? ESCAPE '/'")
+			args = append(args, PatternPrefix(f.UUID))
+		}
+	}
+	if f.ForTenant != "" {
+		wheres = append(wheres, "a.tenant_uuid = ?")
+		args = append(args, f.ForTenant)
+	}
+	if f.ForStatus != "" {
+		wheres = append(wheres, "a.status = ?")
+		args = append(args, f.ForStatus)
+	}
+
+	limit := ""
+	if f.Limit > 0 {
+		limit = " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+
+	return `
+	   SELECT a.uuid, a.tenant_uuid, a.operation, a.archive_uuid, a.target_uuid, a.summary,
+	          a.requested_by, a.requester_uuid, a.requested_at, a.expires_at,
+	          a.status, a.decided_by, a.decided_at, a.task_uuid
+	     FROM approvals a
+	    WHERE ` + strings.Join(wheres, " AND ") + `
+	 ORDER BY a.requested_at DESC, a.uuid ASC` + limit, args
+}
+
+func (db *DB) GetAllApprovals(filter *ApprovalFilter) ([]*Approval, error) {
+	if filter == nil {
+		filter = &ApprovalFilter{}
+	}
+
+	var l []*Approval
+	err := db.exclusively(func() error {
+		var err error
+		l, err = db.approvals(filter)
+		return err
+	})
+	return l, err
+}
+
+// approvals retrieves the approvals matching a filter.
+// The caller is responsible for locking the Mutex.
+func (db *DB) approvals(filter *ApprovalFilter) ([]*Approval, error) {
+	l := []*Approval{}
+	query, args := filter.Query()
+	r, err := db.query(query, args...)
+	if err != nil {
+		return l, err
+	}
+	defer r.Close()
+
+	for r.Next() {
+		a := &Approval{}
+		if err := r.Scan(&a.UUID, &a.TenantUUID, &a.Operation, &a.ArchiveUUID, &a.TargetUUID, &a.Summary,
+			&a.RequestedBy, &a.RequesterUUID, &a.RequestedAt, &a.ExpiresAt,
+			&a.Status, &a.DecidedBy, &a.DecidedAt, &a.TaskUUID); err != nil {
+			return l, err
+		}
+		l = append(l, a)
+	}
+	return l, nil
+}
+
+func (db *DB) GetApproval(id string) (*Approval, error) {
+	l, err := db.GetAllApprovals(&ApprovalFilter{UUID: id, ExactMatch: true})
+	if err != nil || len(l) == 0 {
+		return nil, err
+	}
+	return l[0], nil
+}
+
+// CreateApproval opens a new request for approval, refusing to open a
+// second request for the same operation on the same things while the
+// first is still pending.
+func (db *DB) CreateApproval(a *Approval) (*Approval, error) {
+	switch a.Operation {
+	case ApproveRestore, ApprovePurge, ApproveDeleteTarget:
+	default:
+		return nil, fmt.Errorf("unrecognized operation '%s'", a.Operation)
+	}
+
+	a.UUID = RandomID()
+	a.Status = "pending"
+	if a.RequestedAt == 0 {
+		a.RequestedAt = time.Now().Unix()
+	}
+
+	err := db.exclusively(func() error {
+		if err := db.tenantShouldExist(a.TenantUUID); err != nil {
+			return fmt.Errorf("unable to create approval request: %s", err)
+		}
+
+		if ok, err := db.exists(`
+		    SELECT uuid FROM approvals
+		     WHERE tenant_uuid  = ?
+		       AND operation    = ?
+		       AND archive_uuid = ?
+		       AND target_uuid  = ?
+		       AND status       = 'pending'
+		       AND expires_at   > ?`,
+			a.TenantUUID, a.Operation, a.ArchiveUUID, a.TargetUUID, a.RequestedAt); err != nil {
+			return err
+		} else if ok {
+			return fmt.Errorf("an identical request is already awaiting approval")
+		}
+
+		return db.exec(`
+		    INSERT INTO approvals (uuid, tenant_uuid, operation, archive_uuid, target_uuid, summary,
+		                           requested_by, requester_uuid, requested_at, expires_at, status)
+		                   VALUES (?, ?, ?, ?, ?, ?,
+		                           ?, ?, ?, ?, ?)`,
+			a.UUID, a.TenantUUID, a.Operation, a.ArchiveUUID, a.TargetUUID, a.Summary,
+			a.RequestedBy, a.RequesterUUID, a.RequestedAt, a.ExpiresAt, a.Status)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// DecideApproval approves or rejects a pending request for approval,
+// on behalf of the given account.  It returns false if the request was
+// no longer pending (or had expired), so that no request is ever
+// decided twice.
+func (db *DB) DecideApproval(id, status, by string) (bool, error) {
+	if status != "approved" && status != "rejected" {
+		return false, fmt.Errorf("invalid approval decision '%s'", status)
+	}
+
+	decided := false
+	err := db.exclusively(func() error {
+		now := time.Now().Unix()
+		ok, err := db.exists(`
+		    SELECT uuid FROM approvals
+		     WHERE uuid       = ?
+		       AND status     = 'pending'
+		       AND expires_at > ?`, id, now)
+		if err != nil || !ok {
+			return err
+		}
+
+		if err := db.exec(`
+		    UPDATE approvals
+		       SET status     = ?,
+		           decided_by = ?,
+		           decided_at = ?
+		     WHERE uuid = ?`, status, by, now, id); err != nil {
+			return err
+		}
+		decided = true
+		return nil
+	})
+	return decided, err
+}
+
+// FinishApproval records what came of carrying out an approved request:
+// the task that it scheduled (if any), or that it failed.
+func (db *DB) FinishApproval(id, task string, failed bool) error {
+	status := "approved"
+	if failed {
+		status = "failed"
+	}
+	return db.Exec(`
+	    UPDATE approvals
+	       SET status    = ?,
+	           task_uuid = ?
+	     WHERE uuid = ?`, status, task, id)
+}
+
+// ExpireApprovals marks every pending request for approval that has
+// outlived its expiry as expired, and returns them.
+func (db *DB) ExpireApprovals(now time.Time) ([]*Approval, error) {
+	expired := make([]*Approval, 0)
+	err := db.exclusively(func() error {
+		l, err := db.approvals(&ApprovalFilter{ForStatus: "pending"})
+		if err != nil {
+			return err
+		}
+
+		for _, a := range l {
+			if a.ExpiresAt > now.Unix() {
+				continue
+			}
+			if err := db.exec(`UPDATE approvals SET status = 'expired' WHERE uuid = ?`, a.UUID); err != nil {
+				return err
+			}
+			a.Status = "expired"
+			expired = append(expired, a)
+		}
+		return nil
+	})
+	return expired, err
+}
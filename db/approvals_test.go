@@ -0,0 +1,127 @@
+package db
+
+import (
+	"time"
+
+	// sql drivers
+	_ "github.com/mattn/go-sqlite3"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Approval Requests", func() {
+	var (
+		db     *DB
+		tenant *Tenant
+	)
+
+	BeforeEach(func() {
+		var err error
+		db, err = Database()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		tenant, err = db.CreateTenant(&Tenant{Name: "acme", RequireApproval: true})
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	request := func(op, archive string, expires time.Time) *Approval {
+		a, err := db.CreateApproval(&Approval{
+			TenantUUID:    tenant.UUID,
+			Operation:     op,
+			ArchiveUUID:   archive,
+			RequestedBy:   "jhunt@local",
+			RequesterUUID: "a0e5ecd5-4f5e-4ed2-8a4f-b0cd4b2b21d3",
+			ExpiresAt:     expires.Unix(),
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+		return a
+	}
+
+	It("gives tenants a default approval timeout", func() {
+		t, err := db.GetTenant(tenant.UUID)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(t.RequireApproval).Should(BeTrue())
+		Ω(t.ApprovalTimeout).Should(Equal(DefaultApprovalTimeout))
+	})
+
+	It("creates and retrieves pending requests", func() {
+		a := request(ApprovePurge, "archive-1", time.Now().Add(time.Hour))
+		Ω(a.Status).Should(Equal("pending"))
+
+		got, err := db.GetApproval(a.UUID)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(got).ShouldNot(BeNil())
+		Ω(got.Operation).Should(Equal(ApprovePurge))
+		Ω(got.ArchiveUUID).Should(Equal("archive-1"))
+		Ω(got.RequestedBy).Should(Equal("jhunt@local"))
+
+		l, err := db.GetAllApprovals(&ApprovalFilter{ForTenant: tenant.UUID, ForStatus: "pending"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(l).Should(HaveLen(1))
+	})
+
+	It("rejects unknown operations, unknown tenants, and duplicate requests", func() {
+		_, err := db.CreateApproval(&Approval{TenantUUID: tenant.UUID, Operation: "reboot"})
+		Ω(err).Should(HaveOccurred())
+
+		_, err = db.CreateApproval(&Approval{TenantUUID: "some-other-tenant", Operation: ApproveRestore})
+		Ω(err).Should(HaveOccurred())
+
+		request(ApprovePurge, "archive-1", time.Now().Add(time.Hour))
+		_, err = db.CreateApproval(&Approval{
+			TenantUUID:  tenant.UUID,
+			Operation:   ApprovePurge,
+			ArchiveUUID: "archive-1",
+			ExpiresAt:   time.Now().Add(time.Hour).Unix(),
+		})
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("only ever decides a request once", func() {
+		a := request(ApproveRestore, "archive-1", time.Now().Add(time.Hour))
+
+		ok, err := db.DecideApproval(a.UUID, "approved", "tmitchell@local")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(ok).Should(BeTrue())
+
+		ok, err = db.DecideApproval(a.UUID, "rejected", "gfranks@local")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(ok).Should(BeFalse())
+
+		Ω(db.FinishApproval(a.UUID, "task-1", false)).Should(Succeed())
+		got, err := db.GetApproval(a.UUID)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(got.Status).Should(Equal("approved"))
+		Ω(got.DecidedBy).Should(Equal("tmitchell@local"))
+		Ω(got.TaskUUID).Should(Equal("task-1"))
+	})
+
+	It("expires requests that no one decides on in time", func() {
+		old := request(ApprovePurge, "archive-1", time.Now().Add(-1*time.Minute))
+		request(ApprovePurge, "archive-2", time.Now().Add(time.Hour))
+
+		ok, err := db.DecideApproval(old.UUID, "approved", "tmitchell@local")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(ok).Should(BeFalse())
+
+		l, err := db.ExpireApprovals(time.Now())
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(l).Should(HaveLen(1))
+		Ω(l[0].UUID).Should(Equal(old.UUID))
+
+		l, err = db.GetAllApprovals(&ApprovalFilter{ForStatus: "pending"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(l).Should(HaveLen(1))
+		Ω(l[0].ArchiveUUID).Should(Equal("archive-2"))
+	})
+
+	It("deletes requests along with their tenant", func() {
+		request(ApprovePurge, "archive-1", time.Now().Add(time.Hour))
+		Ω(db.DeleteTenant(tenant, false)).Should(Succeed())
+
+		l, err := db.GetAllApprovals(nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(l).Should(BeEmpty())
+	})
+})
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/shieldproject/shield/timespec"
 )
 
 type Archive struct {
@@ -18,9 +20,17 @@ type Archive struct {
 	Notes          string `json:"notes"           mbus:"notes"`
 	Status         string `json:"status"          mbus:"status"`
 	PurgeReason    string `json:"purge_reason"    mbus:"purge_reason"`
+	PurgeAfter     int64  `json:"purge_after"     mbus:"purge_after"`
 	EncryptionType string `json:"encryption_type" mbus:"encryption_type"`
 	Compression    string `json:"compression"     mbus:"compression"`
 	Size           int64  `json:"size"            mbus:"size"`
+	Held           bool   `json:"held"            mbus:"held"`
+	HoldReason     string `json:"hold_reason"     mbus:"hold_reason"`
+	HeldBy         string `json:"held_by"         mbus:"held_by"`
+	HeldAt         int64  `json:"held_at"         mbus:"held_at"`
+	HoldUntil      int64  `json:"hold_until"      mbus:"hold_until"`
+
+	Labels map[string]string `json:"labels" mbus:"labels"`
 
 	TargetName     string `json:"target_name"`
 	TargetPlugin   string `json:"target_plugin"`
@@ -45,6 +55,11 @@ type ArchiveFilter struct {
 	WithOutStatus []string
 	ForTenant     string
 	ForStoreKey   string
+	WithOutHolds  bool
+	HoldsLapsedBy *time.Time
+	PurgeableBy   *time.Time
+	WithLabels    map[string]string
+	Search        string
 	Limit         int
 }
 
@@ -107,6 +122,28 @@ func (f *ArchiveFilter) Query() (string, []interface{}) {
 		args = append(args, f.ForStoreKey)
 	}
 
+	if f.WithOutHolds {
+		wheres = append(wheres, "a.held = 0")
+	}
+	if f.HoldsLapsedBy != nil {
+		wheres = append(wheres, "a.held = 1 AND a.hold_until > 0 AND a.hold_until <= ?")
+		args = append(args, f.HoldsLapsedBy.Unix())
+	}
+	if f.PurgeableBy != nil {
+		wheres = append(wheres, "a.purge_after <= ?")
+		args = append(args, f.PurgeableBy.Unix())
+	}
+	for _, name := range labelNames(f.WithLabels) {
+		wheres = append(wheres, "a.uuid IN (SELECT archive_uuid FROM archive_labels WHERE name = ? AND value = ?)")
+		args = append(args, name, f.WithLabels[name])
+	}
+	/* every word searched for has to start some term of the archive */
+	for _, term := range SearchTerms(f.Search) {
+		sub, subargs := termsMatching(term)
+		wheres = append(wheres, "a.uuid IN ("+sub+")")
+		args = append(args, subargs...)
+	}
+
 	limit := ""
 	if f.Limit > 0 {
 		limit = " LIMIT ?"
@@ -119,7 +156,9 @@ func (f *ArchiveFilter) Query() (string, []interface{}) {
                t.uuid, t.name, t.plugin, t.endpoint,
                s.uuid, s.name, s.plugin, s.endpoint, s.agent,
                a.status, a.purge_reason, a.job, a.encryption_type,
-               a.compression, a.tenant_uuid, a.size
+               a.compression, a.tenant_uuid, a.size,
+               a.held, a.hold_reason, a.held_by, a.held_at, a.hold_until,
+               a.purge_after
 
         FROM archives a
            LEFT  JOIN targets t   ON t.uuid = a.target_uuid
@@ -169,7 +208,9 @@ func (db *DB) GetAllArchives(filter *ArchiveFilter) ([]*Archive, error) {
 			&targetUUID, &targetName, &targetPlugin, &targetEndpoint,
 			&a.StoreUUID, &storeName, &a.StorePlugin, &a.StoreEndpoint, &a.StoreAgent,
 			&a.Status, &a.PurgeReason, &a.Job, &a.EncryptionType,
-			&a.Compression, &a.TenantUUID, &size); err != nil {
+			&a.Compression, &a.TenantUUID, &size,
+			&a.Held, &a.HoldReason, &a.HeldBy, &a.HeldAt, &a.HoldUntil,
+			&a.PurgeAfter); err != nil {
 
 			return l, err
 		}
@@ -200,8 +241,9 @@ func (db *DB) GetAllArchives(filter *ArchiveFilter) ([]*Archive, error) {
 
 		l = append(l, a)
 	}
+	r.Close()
 
-	return l, nil
+	return l, db.loadArchiveLabels(l)
 }
 
 func (db *DB) getArchive(id string) (*Archive, error) {
@@ -211,7 +253,9 @@ func (db *DB) getArchive(id string) (*Archive, error) {
                t.uuid, t.name, t.plugin, t.endpoint,
                s.uuid, s.name, s.plugin, s.endpoint, s.agent,
                a.status, a.purge_reason, a.job, a.encryption_type,
-               a.compression, a.tenant_uuid, a.size
+               a.compression, a.tenant_uuid, a.size,
+               a.held, a.hold_reason, a.held_by, a.held_at, a.hold_until,
+               a.purge_after
 
         FROM archives a
            LEFT  JOIN targets t   ON t.uuid = a.target_uuid
@@ -235,7 +279,9 @@ func (db *DB) getArchive(id string) (*Archive, error) {
 		&targetUUID, &targetName, &targetPlugin, &targetEndpoint,
 		&a.StoreUUID, &storeName, &a.StorePlugin, &a.StoreEndpoint, &a.StoreAgent,
 		&a.Status, &a.PurgeReason, &a.Job, &a.EncryptionType,
-		&a.Compression, &a.TenantUUID, &size); err != nil {
+		&a.Compression, &a.TenantUUID, &size,
+		&a.Held, &a.HoldReason, &a.HeldBy, &a.HeldAt, &a.HoldUntil,
+		&a.PurgeAfter); err != nil {
 
 		return nil, err
 	}
@@ -263,8 +309,9 @@ func (db *DB) getArchive(id string) (*Archive, error) {
 	if size != nil {
 		a.Size = *size
 	}
+	r.Close()
 
-	return a, nil
+	return a, db.loadArchiveLabels([]*Archive{a})
 }
 
 func (db *DB) GetArchive(id string) (*Archive, error) {
@@ -300,35 +347,186 @@ func (db *DB) createArchiveFromTask(task_uuid string, archive Archive) (*Archive
 }
 
 func (db *DB) UpdateArchive(update *Archive) error {
-	return db.Exec(
-		`UPDATE archives SET notes = ? WHERE uuid = ?`,
-		update.Notes, update.UUID,
-	)
+	return db.exclusively(func() error {
+		err := db.exec(
+			`UPDATE archives SET notes = ? WHERE uuid = ?`,
+			update.Notes, update.UUID,
+		)
+		if err != nil {
+			return err
+		}
+		return db.reindexArchive(update.UUID)
+	})
 }
 
 func (db *DB) AnnotateTargetArchive(target, id, notes string) error {
-	return db.Exec(
-		`UPDATE archives SET notes = ? WHERE uuid = ? AND target_uuid = ?`,
-		notes, id, target,
-	)
+	return db.exclusively(func() error {
+		err := db.exec(
+			`UPDATE archives SET notes = ? WHERE uuid = ? AND target_uuid = ?`,
+			notes, id, target,
+		)
+		if err != nil {
+			return err
+		}
+		return db.reindexArchive(id)
+	})
 }
 
 func (db *DB) GetArchivesNeedingPurge() ([]*Archive, error) {
 	filter := &ArchiveFilter{
-		WithOutStatus: []string{"purged", "valid"},
+		WithOutStatus: []string{"purged", "valid", "pending-purge"},
+		WithOutHolds:  true,
 	}
 	return db.GetAllArchives(filter)
 }
 
+// ArchiveExpiry works out when an archive taken at the given time
+// expires, if it is to be retained for the given number of days.
+func ArchiveExpiry(taken time.Time, keepDays int) time.Time {
+	return taken.Add(time.Duration(keepDays*24) * time.Hour)
+}
+
+// Expired determines whether or not the archive has outlived its
+// retention, as of the given time.  This is the same check that
+// GetExpiredArchives makes, in SQL, on behalf of the archive expiry
+// upkeep.  Archives under legal hold never expire.
+func (a *Archive) Expired(at time.Time) bool {
+	return a.Status == "valid" && !a.Held && a.ExpiresAt <= at.Unix()
+}
+
 func (db *DB) GetExpiredArchives() ([]*Archive, error) {
 	now := time.Now()
 	filter := &ArchiveFilter{
 		ExpiresBefore: &now,
 		WithStatus:    []string{"valid"},
+		WithOutHolds:  true,
 	}
 	return db.GetAllArchives(filter)
 }
 
+// GetArchiveAsOf finds the archive that holds the state of a target
+// system as it was at the given time: the newest valid archive of that
+// target taken at or before then.  Every SHIELD archive is a complete
+// backup, so this one archive is all a restore will need.  If there is
+// no such archive, GetArchiveAsOf returns nil.
+func (db *DB) GetArchiveAsOf(target string, at time.Time) (*Archive, error) {
+	l, err := db.GetAllArchives(&ArchiveFilter{
+		ForTarget:  target,
+		Before:     &at,
+		WithStatus: []string{"valid"},
+		Limit:      1,
+	})
+	if err != nil || len(l) == 0 {
+		return nil, err
+	}
+	return l[0], nil
+}
+
+// UnencryptedArchive is the encryption type of archives that were
+// imported into SHIELD as they were, without encrypting them.  There
+// is nothing in the vault for these legacy archives, and restoring (or
+// downloading) them skips the decryption step altogether.
+const UnencryptedArchive = "none"
+
+// Unencrypted returns true if the archive was imported without being
+// encrypted by SHIELD.
+func (a *Archive) Unencrypted() bool {
+	return a.EncryptionType == UnencryptedArchive
+}
+
+// ImportArchive records an archive that was not taken by a SHIELD
+// backup job, i.e. a dump made by some other tool, that is already in
+// cloud storage, against a target system.  Imported archives are
+// labeled like any other, and with imported=yes, so that they are easy
+// to tell apart from the archives SHIELD took itself.
+func (db *DB) ImportArchive(archive *Archive) (*Archive, error) {
+	if archive.StoreKey == "" {
+		return nil, fmt.Errorf("cannot import an archive without a store_key")
+	}
+	if archive.UUID == "" {
+		archive.UUID = RandomID()
+	}
+
+	var imported *Archive
+	err := db.exclusively(func() error {
+		/* validate the target */
+		if err := db.targetShouldExist(archive.TargetUUID); err != nil {
+			return fmt.Errorf("unable to import archive: %s", err)
+		}
+
+		/* validate the store */
+		if err := db.storeShouldExist(archive.StoreUUID); err != nil {
+			return fmt.Errorf("unable to import archive: %s", err)
+		}
+
+		/* two archives sharing one blob would purge it out from under each other */
+		if used, err := db.storeKeyInUse(archive.StoreUUID, archive.StoreKey); err != nil {
+			return fmt.Errorf("unable to import archive: %s", err)
+		} else if used {
+			return fmt.Errorf("unable to import archive: store key [%s] is already in use by another archive", archive.StoreKey)
+		}
+
+		err := db.exec(`
+		   INSERT INTO archives
+		     (uuid, tenant_uuid, target_uuid, store_uuid, store_key,
+		      taken_at, expires_at, notes, status, purge_reason, job,
+		      compression, encryption_type, size)
+		   VALUES
+		     (?, ?, ?, ?, ?,
+		      ?, ?, ?, 'valid', '', '',
+		      ?, ?, ?)`,
+			archive.UUID, archive.TenantUUID, archive.TargetUUID, archive.StoreUUID, archive.StoreKey,
+			archive.TakenAt, archive.ExpiresAt, archive.Notes,
+			archive.Compression, archive.EncryptionType, archive.Size)
+		if err != nil {
+			return err
+		}
+
+		imported, err = db.getArchive(archive.UUID)
+		if err != nil {
+			return err
+		}
+
+		err = db.labelArchive(archive.UUID, map[string]string{
+			"target":   imported.TargetName,
+			"plugin":   imported.TargetPlugin,
+			"store":    imported.StoreName,
+			"imported": "yes",
+		})
+		if err != nil {
+			return err
+		}
+
+		imported, err = db.getArchive(archive.UUID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	db.sendCreateObjectEvent(imported, "tenant:"+imported.TenantUUID)
+	return imported, nil
+}
+
+// StoreKeyInUse returns true if an archive that has not yet been
+// purged already lives at the given key, in the given store.
+func (db *DB) StoreKeyInUse(store, key string) (bool, error) {
+	var used bool
+	err := db.exclusively(func() error {
+		var err error
+		used, err = db.storeKeyInUse(store, key)
+		return err
+	})
+	return used, err
+}
+
+func (db *DB) storeKeyInUse(store, key string) (bool, error) {
+	return db.exists(`
+	   SELECT uuid FROM archives
+	    WHERE store_uuid = ? AND store_key = ?
+	      AND status NOT IN ('purged', 'manually purged')`, store, key)
+}
+
 func (db *DB) InvalidateArchive(id string) error {
 	return db.Exec(`UPDATE archives SET status = 'invalid' WHERE uuid = ?`, id)
 }
@@ -345,6 +543,9 @@ func (db *DB) PurgeArchive(id string) error {
 	if a.Status == "valid" {
 		return fmt.Errorf("invalid attempt to purge a 'valid' archive detected")
 	}
+	if a.Held {
+		return fmt.Errorf("invalid attempt to purge an archive under legal hold detected")
+	}
 
 	err = db.Exec(`UPDATE archives SET purge_reason = status WHERE uuid = ?`, id)
 	if err != nil {
@@ -355,12 +556,110 @@ func (db *DB) PurgeArchive(id string) error {
 }
 
 func (db *DB) ExpireArchive(id string) error {
-	return db.Exec(`UPDATE archives SET status = 'expired' WHERE uuid = ?`, id)
+	return db.Exec(`UPDATE archives SET status = 'expired' WHERE uuid = ? AND held = 0`, id)
+}
+
+// ManuallyPurgeArchive deletes an archive by hand, moving it to the
+// recycle bin until purgeAfter; see RecycleArchive.
+func (db *DB) ManuallyPurgeArchive(id string, purgeAfter time.Time) error {
+	return db.RecycleArchive(id, "manually purged", purgeAfter)
+}
+
+// RecycleArchive moves a valid archive into the recycle bin, marking
+// it as 'pending-purge' for the given reason ('expired' or 'manually
+// purged').  Until purgeAfter, the archive can be brought back with
+// UndeleteArchive; after that, EndPurgeGrace gives it over to the
+// purge upkeep, and it is removed from cloud storage for good.
+// Archives under legal hold cannot be recycled.
+func (db *DB) RecycleArchive(id, reason string, purgeAfter time.Time) error {
+	return db.exclusively(func() error {
+		archive, err := db.getArchive(id)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve archive [%s]: %s", id, err)
+		}
+		if archive == nil {
+			return fmt.Errorf("unable to retrieve archive [%s]: not found in database.", id)
+		}
+		if archive.Held {
+			return fmt.Errorf("archive [%s] is under legal hold, and cannot be purged", id)
+		}
+		if archive.Status != "valid" {
+			return fmt.Errorf("unable to purge archive [%s]: archive is already %s", id, archive.Status)
+		}
+
+		err = db.exec(`
+		   UPDATE archives
+		      SET status = 'pending-purge', purge_reason = ?, purge_after = ?
+		    WHERE uuid = ?`, reason, purgeAfter.Unix(), id)
+		if err != nil {
+			return err
+		}
+
+		archive, err = db.getArchive(id)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve archive [%s]: %s", id, err)
+		}
+		db.sendUpdateObjectEvent(archive, "tenant:"+archive.TenantUUID)
+		return nil
+	})
+}
+
+// UndeleteArchive takes an archive back out of the recycle bin,
+// making it valid once more.  If expiresAt is non-zero, it becomes
+// the archive's new expiry; otherwise the original expiry stands.
+func (db *DB) UndeleteArchive(id string, expiresAt int64) error {
+	return db.exclusively(func() error {
+		archive, err := db.getArchive(id)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve archive [%s]: %s", id, err)
+		}
+		if archive == nil {
+			return fmt.Errorf("unable to retrieve archive [%s]: not found in database.", id)
+		}
+		if archive.Status != "pending-purge" {
+			return fmt.Errorf("unable to undelete archive [%s]: archive is %s, not pending-purge", id, archive.Status)
+		}
+
+		if expiresAt == 0 {
+			expiresAt = archive.ExpiresAt
+		}
+		err = db.exec(`
+		   UPDATE archives
+		      SET status = 'valid', purge_reason = '', purge_after = 0, expires_at = ?
+		    WHERE uuid = ?`, expiresAt, id)
+		if err != nil {
+			return err
+		}
+
+		archive, err = db.getArchive(id)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve archive [%s]: %s", id, err)
+		}
+		db.sendUpdateObjectEvent(archive, "tenant:"+archive.TenantUUID)
+		return nil
+	})
+}
+
+// GetArchivesPastGrace finds all archives in the recycle bin whose
+// grace period is over, as of the given time.
+func (db *DB) GetArchivesPastGrace(at time.Time) ([]*Archive, error) {
+	filter := &ArchiveFilter{
+		WithStatus:   []string{"pending-purge"},
+		WithOutHolds: true,
+		PurgeableBy:  &at,
+	}
+	return db.GetAllArchives(filter)
 }
 
-func (db *DB) ManuallyPurgeArchive(id string) error {
+// EndPurgeGrace takes an archive out of the recycle bin for good,
+// restoring the status it was recycled for ('expired' or 'manually
+// purged'), so that it will be purged from cloud storage.
+func (db *DB) EndPurgeGrace(id string) error {
 	return db.exclusively(func() error {
-		err := db.exec(`UPDATE archives SET status = 'manually purged', expires_at = ? WHERE uuid = ?`, time.Now().Unix(), id)
+		err := db.exec(`
+		   UPDATE archives
+		      SET status = purge_reason, purge_reason = ''
+		    WHERE uuid = ? AND status = 'pending-purge' AND held = 0`, id)
 		if err != nil {
 			return err
 		}
@@ -369,21 +668,111 @@ func (db *DB) ManuallyPurgeArchive(id string) error {
 		if err != nil {
 			return fmt.Errorf("unable to retrieve archive [%s]: %s", id, err)
 		}
+		if archive != nil {
+			db.sendUpdateObjectEvent(archive, "tenant:"+archive.TenantUUID)
+		}
+		return nil
+	})
+}
+
+// HoldArchive places a valid archive under legal hold, on behalf of
+// the given actor.  Held archives are never expired or purged, be it
+// by retention upkeep or by hand.  If until is non-zero, the hold
+// lapses at that time (see ReleaseLapsedHolds); otherwise, it lasts
+// until it is explicitly released.
+func (db *DB) HoldArchive(id, reason, actor string, until int64) error {
+	return db.exclusively(func() error {
+		archive, err := db.getArchive(id)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve archive [%s]: %s", id, err)
+		}
+		if archive == nil {
+			return fmt.Errorf("unable to retrieve archive [%s]: not found in database.", id)
+		}
+		if archive.Status != "valid" {
+			return fmt.Errorf("unable to hold archive [%s]: archive is %s", id, archive.Status)
+		}
+
+		err = db.exec(`
+		   UPDATE archives
+		      SET held = 1, hold_reason = ?, held_by = ?, held_at = ?, hold_until = ?
+		    WHERE uuid = ?`, reason, actor, time.Now().Unix(), until, id)
+		if err != nil {
+			return err
+		}
+
+		archive, err = db.getArchive(id)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve archive [%s]: %s", id, err)
+		}
 		db.sendUpdateObjectEvent(archive, "tenant:"+archive.TenantUUID)
 		return nil
 	})
 }
 
+// ReleaseArchive lifts the legal hold on an archive, leaving it
+// subject to its retention policy once again.
+func (db *DB) ReleaseArchive(id string) error {
+	return db.exclusively(func() error {
+		err := db.exec(`
+		   UPDATE archives
+		      SET held = 0, hold_reason = '', held_by = '', held_at = 0, hold_until = 0
+		    WHERE uuid = ?`, id)
+		if err != nil {
+			return err
+		}
+
+		archive, err := db.getArchive(id)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve archive [%s]: %s", id, err)
+		}
+		if archive != nil {
+			db.sendUpdateObjectEvent(archive, "tenant:"+archive.TenantUUID)
+		}
+		return nil
+	})
+}
+
+// GetLapsedHolds finds all archives whose legal hold was placed with
+// a release date that has since passed.
+func (db *DB) GetLapsedHolds(at time.Time) ([]*Archive, error) {
+	filter := &ArchiveFilter{
+		HoldsLapsedBy: &at,
+	}
+	return db.GetAllArchives(filter)
+}
+
 func (db *DB) DeleteArchive(id string) (bool, error) {
-	return true, db.Exec(`DELETE FROM archives WHERE uuid = ?`, id)
+	return true, db.exclusively(func() error {
+		if err := db.exec(`DELETE FROM archive_labels WHERE archive_uuid = ?`, id); err != nil {
+			return err
+		}
+		if err := db.exec(`DELETE FROM archive_terms WHERE archive_uuid = ?`, id); err != nil {
+			return err
+		}
+		return db.exec(`DELETE FROM archives WHERE uuid = ?`, id)
+	})
 }
 
 func (db *DB) CleanupArchives(age int) error {
-	return db.Exec(`
-       DELETE FROM archives
-             WHERE status IN ('purged', 'manually purged')
-               AND expires_at < ?`,
-		(int)(time.Now().Unix())-age)
+	cutoff := (int)(time.Now().Unix()) - age
+	return db.exclusively(func() error {
+		for _, table := range []string{"archive_labels", "archive_terms"} {
+			err := db.exec(`
+			   DELETE FROM `+table+`
+			         WHERE archive_uuid IN (SELECT uuid FROM archives
+			                                 WHERE status IN ('purged', 'manually purged')
+			                                   AND expires_at < ?)`, cutoff)
+			if err != nil {
+				return err
+			}
+		}
+
+		return db.exec(`
+		   DELETE FROM archives
+		         WHERE status IN ('purged', 'manually purged')
+		           AND expires_at < ?`, cutoff)
+	})
 }
 
 func (db *DB) ArchiveStorageFootprint(filter *ArchiveFilter) (int64, error) {
@@ -480,5 +869,65 @@ func (db *DB) CleanArchives() error {
                          FROM archives a
                     LEFT JOIN tenants  t
                            ON t.uuid = a.tenant_uuid
-                        WHERE t.uuid IS NULL)`)
+                        WHERE t.uuid IS NULL
+                          AND a.held = 0)`)
+}
+
+// A RetentionSimulation shows which of a job's valid archives would
+// be kept, and which would be expired (and later purged), were the
+// job to retain its archives for KeepDays days instead.
+type RetentionSimulation struct {
+	KeepDays int `json:"keep_days"`
+	KeepN    int `json:"keep_n"`
+
+	Keep       []*Archive `json:"keep"`
+	Purge      []*Archive `json:"purge"`
+	KeepBytes  int64      `json:"keep_bytes"`
+	PurgeBytes int64      `json:"purge_bytes"`
+}
+
+// SimulateRetention works out what would happen to the job's valid
+// archives, as of the given time, if they had been taken under a
+// retention of keepDays days.  The expiry of each archive in the
+// simulation is recalculated accordingly; nothing is changed in the
+// database.
+func (db *DB) SimulateRetention(job *Job, keepDays int, at time.Time) (*RetentionSimulation, error) {
+	archives, err := db.GetAllArchives(&ArchiveFilter{
+		ForTarget:  job.Target.UUID,
+		ForStore:   job.Store.UUID,
+		WithStatus: []string{"valid"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sim := &RetentionSimulation{
+		KeepDays: keepDays,
+		KeepN:    -1,
+		Keep:     []*Archive{},
+		Purge:    []*Archive{},
+	}
+	if spec, err := timespec.Parse(job.Schedule); err == nil {
+		if err := db.ResolveExclusions(spec); err != nil {
+			return nil, err
+		}
+		sim.KeepN = spec.KeepNAt(keepDays, at)
+	}
+
+	for _, archive := range archives {
+		/* other jobs can share the same target and store */
+		if archive.Job != job.Name {
+			continue
+		}
+
+		archive.ExpiresAt = ArchiveExpiry(time.Unix(archive.TakenAt, 0), keepDays).Unix()
+		if archive.Expired(at) {
+			sim.Purge = append(sim.Purge, archive)
+			sim.PurgeBytes += archive.Size
+		} else {
+			sim.Keep = append(sim.Keep, archive)
+			sim.KeepBytes += archive.Size
+		}
+	}
+	return sim, nil
 }
@@ -96,6 +96,313 @@ var _ = Describe("Archive Management", func() {
 		})
 	})
 
+	Describe("Labels and search", func() {
+		find := func(filter *ArchiveFilter) []string {
+			l, err := db.GetAllArchives(filter)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			ids := []string{}
+			for _, a := range l {
+				ids = append(ids, a.UUID)
+			}
+			return ids
+		}
+
+		It("can label archives", func() {
+			Ω(db.LabelArchive(ARCHIVE_UUID, map[string]string{"env": "prod", "team": "dba"})).Should(Succeed())
+			Ω(db.LabelArchive(ARCHIVE_UUID, map[string]string{"env": "staging"})).Should(Succeed())
+
+			a, err := db.GetArchive(ARCHIVE_UUID)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(a.Labels).Should(Equal(map[string]string{"env": "staging", "team": "dba"}))
+
+			Ω(db.UnlabelArchive(ARCHIVE_UUID, []string{"team"})).Should(Succeed())
+			a, err = db.GetArchive(ARCHIVE_UUID)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(a.Labels).Should(Equal(map[string]string{"env": "staging"}))
+		})
+
+		It("rejects bad label names", func() {
+			Ω(db.LabelArchive(ARCHIVE_UUID, map[string]string{"no spaces": "x"})).ShouldNot(Succeed())
+			Ω(db.LabelArchive(ARCHIVE_UUID, map[string]string{"": "x"})).ShouldNot(Succeed())
+		})
+
+		It("can filter archives by label", func() {
+			Ω(db.LabelArchive(ARCHIVE_UUID, map[string]string{"env": "prod", "team": "dba"})).Should(Succeed())
+
+			Ω(find(&ArchiveFilter{WithLabels: map[string]string{"env": "prod"}})).Should(Equal([]string{ARCHIVE_UUID}))
+			Ω(find(&ArchiveFilter{WithLabels: map[string]string{"env": "prod", "team": "dba"}})).Should(Equal([]string{ARCHIVE_UUID}))
+			Ω(find(&ArchiveFilter{WithLabels: map[string]string{"env": "prod", "team": "ops"}})).Should(BeEmpty())
+			Ω(find(&ArchiveFilter{WithLabels: map[string]string{"env": "staging"}})).Should(BeEmpty())
+		})
+
+		It("can search archive notes and labels", func() {
+			a, err := db.GetArchive(ARCHIVE_UUID)
+			Ω(err).ShouldNot(HaveOccurred())
+			a.Notes = "Taken right before the migration to v2"
+			Ω(db.UpdateArchive(a)).Should(Succeed())
+			Ω(db.LabelArchive(ARCHIVE_UUID, map[string]string{"env": "Production"})).Should(Succeed())
+
+			Ω(find(&ArchiveFilter{Search: "before migration"})).Should(Equal([]string{ARCHIVE_UUID}))
+			Ω(find(&ArchiveFilter{Search: "BEFORE MIGR"})).Should(Equal([]string{ARCHIVE_UUID}))
+			Ω(find(&ArchiveFilter{Search: "production"})).Should(Equal([]string{ARCHIVE_UUID}))
+			Ω(find(&ArchiveFilter{Search: "before rollback"})).Should(BeEmpty())
+			Ω(find(&ArchiveFilter{Search: "fore"})).Should(BeEmpty())
+
+			a.Notes = "after the migration"
+			Ω(db.UpdateArchive(a)).Should(Succeed())
+			Ω(find(&ArchiveFilter{Search: "before"})).Should(BeEmpty())
+			Ω(find(&ArchiveFilter{Search: "after", WithLabels: map[string]string{"env": "Production"}})).Should(Equal([]string{ARCHIVE_UUID}))
+		})
+
+		It("breaks text up into search terms", func() {
+			Ω(SearchTerms("Before the pg_dump; before MIGRATION #12345")).Should(Equal(
+				[]string{"before", "the", "pg", "dump", "migration", "12345"}))
+			Ω(SearchTerms("  ")).Should(BeEmpty())
+		})
+	})
+
+	Describe("The recycle bin", func() {
+		It("keeps deleted archives around until their grace period ends", func() {
+			grace := time.Now().Add(time.Hour)
+			Ω(db.ManuallyPurgeArchive(ARCHIVE_UUID, grace)).Should(Succeed())
+			shouldHaveArchiveStatus(ARCHIVE_UUID, "pending-purge")
+			shouldHavePurgeReason(ARCHIVE_UUID, "manually purged")
+
+			l, err := db.GetArchivesNeedingPurge()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(l).Should(BeEmpty())
+
+			l, err = db.GetArchivesPastGrace(time.Now())
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(l).Should(BeEmpty())
+
+			l, err = db.GetArchivesPastGrace(grace)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(len(l)).Should(Equal(1))
+
+			Ω(db.EndPurgeGrace(ARCHIVE_UUID)).Should(Succeed())
+			shouldHaveArchiveStatus(ARCHIVE_UUID, "manually purged")
+
+			l, err = db.GetArchivesNeedingPurge()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(len(l)).Should(Equal(1))
+
+			Ω(db.PurgeArchive(ARCHIVE_UUID)).Should(Succeed())
+			shouldHaveArchiveStatus(ARCHIVE_UUID, "purged")
+			shouldHavePurgeReason(ARCHIVE_UUID, "manually purged")
+		})
+
+		It("remembers why an archive was recycled", func() {
+			Ω(db.RecycleArchive(ARCHIVE_UUID, "expired", time.Now())).Should(Succeed())
+			Ω(db.EndPurgeGrace(ARCHIVE_UUID)).Should(Succeed())
+			shouldHaveArchiveStatus(ARCHIVE_UUID, "expired")
+		})
+
+		It("can undelete archives during their grace period", func() {
+			Ω(db.ManuallyPurgeArchive(ARCHIVE_UUID, time.Now().Add(time.Hour))).Should(Succeed())
+			Ω(db.UndeleteArchive(ARCHIVE_UUID, 0)).Should(Succeed())
+			shouldHaveArchiveStatus(ARCHIVE_UUID, "valid")
+			shouldHavePurgeReason(ARCHIVE_UUID, "")
+
+			a, err := db.GetArchive(ARCHIVE_UUID)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(a.ExpiresAt).Should(BeEquivalentTo(0))
+			Ω(a.PurgeAfter).Should(BeEquivalentTo(0))
+
+			Ω(db.RecycleArchive(ARCHIVE_UUID, "expired", time.Now())).Should(Succeed())
+			Ω(db.UndeleteArchive(ARCHIVE_UUID, 86400)).Should(Succeed())
+			a, err = db.GetArchive(ARCHIVE_UUID)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(a.ExpiresAt).Should(BeEquivalentTo(86400))
+		})
+
+		It("cannot undelete archives once their grace period is over", func() {
+			Ω(db.ManuallyPurgeArchive(ARCHIVE_UUID, time.Now())).Should(Succeed())
+			Ω(db.EndPurgeGrace(ARCHIVE_UUID)).Should(Succeed())
+			Ω(db.UndeleteArchive(ARCHIVE_UUID, 0)).ShouldNot(Succeed())
+		})
+
+		It("only recycles valid archives", func() {
+			Ω(db.InvalidateArchive(ARCHIVE_UUID)).Should(Succeed())
+			Ω(db.ManuallyPurgeArchive(ARCHIVE_UUID, time.Now())).ShouldNot(Succeed())
+		})
+	})
+
+	Describe("Legal holds", func() {
+		BeforeEach(func() {
+			err := db.HoldArchive(ARCHIVE_UUID, "litigation", "legal@example.com", 0)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("records who placed the hold, and why", func() {
+			a, err := db.GetArchive(ARCHIVE_UUID)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(a.Held).Should(BeTrue())
+			Ω(a.HoldReason).Should(Equal("litigation"))
+			Ω(a.HeldBy).Should(Equal("legal@example.com"))
+			Ω(a.HeldAt).ShouldNot(BeZero())
+			Ω(a.HoldUntil).Should(BeZero())
+		})
+
+		It("keeps held archives from expiring", func() {
+			l, err := db.GetExpiredArchives()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(l).Should(BeEmpty())
+
+			Ω(db.ExpireArchive(ARCHIVE_UUID)).Should(Succeed())
+			shouldHaveArchiveStatus(ARCHIVE_UUID, "valid")
+
+			a, err := db.GetArchive(ARCHIVE_UUID)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(a.Expired(time.Now())).Should(BeFalse())
+		})
+
+		It("keeps held archives from being purged", func() {
+			Ω(db.ManuallyPurgeArchive(ARCHIVE_UUID, time.Now())).ShouldNot(Succeed())
+			shouldHaveArchiveStatus(ARCHIVE_UUID, "valid")
+
+			Ω(db.Exec(`UPDATE archives SET status = 'invalid'`)).Should(Succeed())
+			l, err := db.GetArchivesNeedingPurge()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(l).Should(BeEmpty())
+			Ω(db.PurgeArchive(ARCHIVE_UUID)).ShouldNot(Succeed())
+		})
+
+		It("lets released archives expire again", func() {
+			Ω(db.ReleaseArchive(ARCHIVE_UUID)).Should(Succeed())
+
+			l, err := db.GetExpiredArchives()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(len(l)).Should(Equal(1))
+			Ω(l[0].Held).Should(BeFalse())
+			Ω(l[0].HoldReason).Should(Equal(""))
+		})
+
+		It("only holds valid archives", func() {
+			Ω(db.ReleaseArchive(ARCHIVE_UUID)).Should(Succeed())
+			Ω(db.ExpireArchive(ARCHIVE_UUID)).Should(Succeed())
+			Ω(db.HoldArchive(ARCHIVE_UUID, "too late", "legal@example.com", 0)).ShouldNot(Succeed())
+		})
+
+		It("finds holds whose release date has passed", func() {
+			l, err := db.GetLapsedHolds(time.Now())
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(l).Should(BeEmpty())
+
+			until := time.Now().Add(time.Hour)
+			Ω(db.HoldArchive(ARCHIVE_UUID, "audit", "legal@example.com", until.Unix())).Should(Succeed())
+
+			l, err = db.GetLapsedHolds(time.Now())
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(l).Should(BeEmpty())
+
+			l, err = db.GetLapsedHolds(until.Add(time.Minute))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(len(l)).Should(Equal(1))
+			Ω(l[0].UUID).Should(Equal(ARCHIVE_UUID))
+		})
+	})
+
+	Describe("Importing archives", func() {
+		It("records and labels archives made outside of SHIELD", func() {
+			a, err := db.ImportArchive(&Archive{
+				TenantUUID:     TENANT_UUID,
+				TargetUUID:     TARGET_UUID,
+				StoreUUID:      STORE_UUID,
+				StoreKey:       "dumps/2019/01/01.sql.gz",
+				TakenAt:        1546300800,
+				ExpiresAt:      time.Now().Add(time.Hour).Unix(),
+				Notes:          "nightly cron dump",
+				Compression:    "gzip",
+				EncryptionType: UnencryptedArchive,
+				Size:           1024,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(a).ShouldNot(BeNil())
+			Ω(a.UUID).ShouldNot(BeEmpty())
+			Ω(a.Status).Should(Equal("valid"))
+			Ω(a.StoreKey).Should(Equal("dumps/2019/01/01.sql.gz"))
+			Ω(a.Compression).Should(Equal("gzip"))
+			Ω(a.Unencrypted()).Should(BeTrue())
+			Ω(a.Labels).Should(Equal(map[string]string{
+				"target":   "target_name",
+				"plugin":   "target_plugin",
+				"store":    "store_name",
+				"imported": "yes",
+			}))
+
+			l, err := db.GetAllArchives(&ArchiveFilter{Search: "cron"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(len(l)).Should(Equal(1))
+			Ω(l[0].UUID).Should(Equal(a.UUID))
+		})
+
+		It("requires a store key", func() {
+			_, err := db.ImportArchive(&Archive{
+				TenantUUID: TENANT_UUID,
+				TargetUUID: TARGET_UUID,
+				StoreUUID:  STORE_UUID,
+			})
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("requires the target to exist", func() {
+			_, err := db.ImportArchive(&Archive{
+				TenantUUID: TENANT_UUID,
+				TargetUUID: RandomID(),
+				StoreUUID:  STORE_UUID,
+				StoreKey:   "some/key",
+			})
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("refuses a store key that another archive is still using", func() {
+			used, err := db.StoreKeyInUse(STORE_UUID, "dumps/shared.sql")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(used).Should(BeFalse())
+
+			a, err := db.ImportArchive(&Archive{
+				TenantUUID: TENANT_UUID,
+				TargetUUID: TARGET_UUID,
+				StoreUUID:  STORE_UUID,
+				StoreKey:   "dumps/shared.sql",
+				ExpiresAt:  time.Now().Add(time.Hour).Unix(),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			used, err = db.StoreKeyInUse(STORE_UUID, "dumps/shared.sql")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(used).Should(BeTrue())
+
+			_, err = db.ImportArchive(&Archive{
+				TenantUUID: TENANT_UUID,
+				TargetUUID: TARGET_UUID,
+				StoreUUID:  STORE_UUID,
+				StoreKey:   "dumps/shared.sql",
+				ExpiresAt:  time.Now().Add(time.Hour).Unix(),
+			})
+			Ω(err).Should(HaveOccurred())
+
+			/* the same key, in another store, is another blob */
+			used, err = db.StoreKeyInUse(RandomID(), "dumps/shared.sql")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(used).Should(BeFalse())
+
+			/* once purged, the key is free to be imported again */
+			Ω(db.ExpireArchive(a.UUID)).Should(Succeed())
+			Ω(db.PurgeArchive(a.UUID)).Should(Succeed())
+			_, err = db.ImportArchive(&Archive{
+				TenantUUID: TENANT_UUID,
+				TargetUUID: TARGET_UUID,
+				StoreUUID:  STORE_UUID,
+				StoreKey:   "dumps/shared.sql",
+				ExpiresAt:  time.Now().Add(time.Hour).Unix(),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
 	Describe("Archive Retrieval", func() {
 		TARGET2_UUID := RandomID()
 		STORE2_UUID := RandomID()
@@ -160,6 +467,7 @@ var _ = Describe("Archive Management", func() {
 					StoreName:      "store_name",
 					StoreEndpoint:  "{}",
 					StorePlugin:    "store_plugin",
+					Labels:         map[string]string{},
 				}))
 			})
 			It("Should return error nil/nil if no records exist", func() {
@@ -169,6 +477,28 @@ var _ = Describe("Archive Management", func() {
 			})
 		})
 
+		Describe("As of a point in time", func() {
+			It("finds the newest valid archive taken at or before then", func() {
+				/* ARCHIVE_UUID (valid) was taken at 0; the purged, invalid
+				   and expired ones between 10 and 20 must be skipped */
+				a, err := db.GetArchiveAsOf(TARGET_UUID, time.Unix(25, 0))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(a).ShouldNot(BeNil())
+				Ω(a.UUID).Should(Equal(ARCHIVE_UUID))
+
+				a, err = db.GetArchiveAsOf(TARGET2_UUID, time.Unix(20, 0))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(a).ShouldNot(BeNil())
+				Ω(a.UUID).Should(Equal(ARCHIVE_TARGET2))
+			})
+
+			It("returns nil if there are no archives from before then", func() {
+				a, err := db.GetArchiveAsOf(TARGET2_UUID, time.Unix(19, 0))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(a).Should(BeNil())
+			})
+		})
+
 		Describe("Of multiple archives", func() {
 			It("When filtering by Status", func() {
 				filter := ArchiveFilter{
@@ -353,4 +683,71 @@ var _ = Describe("Archive Management", func() {
 
 		})
 	})
+
+	Describe("Retention simulation", func() {
+		now := time.Unix(100*86400, 0)
+		var job *Job
+
+		BeforeEach(func() {
+			err := db.Exec(`DELETE FROM archives`)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			/* one archive a day, for the last 30 days; each
+			   one is 1k bigger than the one before it. */
+			for i := 1; i <= 30; i++ {
+				taken := now.Add(time.Duration(-i*24) * time.Hour).Unix()
+				err = db.Exec(`INSERT INTO archives (uuid, target_uuid, store_uuid, store_key, taken_at, expires_at, status, job, size, tenant_uuid)
+				                 VALUES (?, ?, ?, "key", ?, ?, "valid", "nightly", ?, ?)`,
+					RandomID(), TARGET_UUID, STORE_UUID, taken, taken+30*86400, i*1024, TENANT_UUID)
+				Ω(err).ShouldNot(HaveOccurred())
+			}
+
+			/* archives from other jobs, or that are no longer valid, don't count */
+			err = db.Exec(`INSERT INTO archives (uuid, target_uuid, store_uuid, store_key, taken_at, expires_at, status, job, size, tenant_uuid)
+			                 VALUES (?, ?, ?, "key", 0, 0, "valid", "hourly", 1, ?)`,
+				RandomID(), TARGET_UUID, STORE_UUID, TENANT_UUID)
+			Ω(err).ShouldNot(HaveOccurred())
+			err = db.Exec(`INSERT INTO archives (uuid, target_uuid, store_uuid, store_key, taken_at, expires_at, status, job, size, tenant_uuid)
+			                 VALUES (?, ?, ?, "key", 0, 0, "purged", "nightly", 1, ?)`,
+				RandomID(), TARGET_UUID, STORE_UUID, TENANT_UUID)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			job = &Job{Name: "nightly", Schedule: "daily 3am", KeepDays: 30}
+			job.Target.UUID = TARGET_UUID
+			job.Store.UUID = STORE_UUID
+		})
+
+		It("keeps everything under the current retention", func() {
+			sim, err := db.SimulateRetention(job, 31, now)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(sim.KeepN).Should(Equal(31))
+			Ω(len(sim.Keep)).Should(Equal(30))
+			Ω(sim.Purge).Should(BeEmpty())
+			Ω(sim.KeepBytes).Should(BeEquivalentTo(465 * 1024))
+			Ω(sim.PurgeBytes).Should(BeEquivalentTo(0))
+		})
+
+		It("expires archives that outlive a shorter retention", func() {
+			sim, err := db.SimulateRetention(job, 7, now)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(len(sim.Keep)).Should(Equal(6))
+			Ω(len(sim.Purge)).Should(Equal(24))
+			Ω(sim.KeepBytes).Should(BeEquivalentTo(21 * 1024))
+			Ω(sim.PurgeBytes).Should(BeEquivalentTo(444 * 1024))
+
+			for _, archive := range sim.Keep {
+				Ω(archive.ExpiresAt).Should(Equal(archive.TakenAt + 7*86400))
+				Ω(archive.Expired(now)).Should(BeFalse())
+			}
+		})
+
+		It("does not change anything", func() {
+			_, err := db.SimulateRetention(job, 1, now)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			expired, err := db.CountArchives(&ArchiveFilter{ExpiresBefore: &now, WithStatus: []string{"valid"}})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(expired).Should(Equal(1))
+		})
+	})
 })
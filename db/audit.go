@@ -0,0 +1,411 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pborman/uuid"
+)
+
+// An AuditEntry records a single mutating API request: who made it,
+// from where, what it acted upon, and what changed as a result.
+//
+// Entries are chained together, each one carrying the Hash of the
+// entry before it (as PrevHash) and a Hash over its own contents
+// and that PrevHash, so that altering or removing any entry after
+// the fact is evident when the chain is verified.
+type AuditEntry struct {
+	Seq         int64           `json:"seq"`
+	UUID        string          `json:"uuid"`
+	At          int64           `json:"at"`
+	Actor       string          `json:"actor"`
+	ActorUUID   string          `json:"actor_uuid,omitempty"`
+	SessionUUID string          `json:"session_uuid,omitempty"`
+	TokenUUID   string          `json:"token_uuid,omitempty"`
+	IP          string          `json:"ip"`
+	Method      string          `json:"method"`
+	Route       string          `json:"route"`
+	Path        string          `json:"path"`
+	Status      int             `json:"status"`
+	TenantUUID  string          `json:"tenant_uuid,omitempty"`
+	ObjectType  string          `json:"object_type,omitempty"`
+	ObjectUUID  string          `json:"object_uuid,omitempty"`
+	Changes     json.RawMessage `json:"changes,omitempty"`
+	PrevHash    string          `json:"prev_hash"`
+	Hash        string          `json:"hash"`
+}
+
+type AuditFilter struct {
+	ForActor  string
+	ForTenant string
+	ForType   string
+	ForObject string
+	ForMethod string
+	Since     int64
+	Until     int64
+	Before    int64
+	After     int64
+	Limit     int
+	Oldest    bool
+}
+
+// AuditVerification is the outcome of walking the audit log hash
+// chain; if it is not OK, BrokenAt is the sequence number of the first
+// entry that doesn't check out, and Problem says why.
+type AuditVerification struct {
+	OK       bool   `json:"ok"`
+	Entries  int64  `json:"entries"`
+	Head     string `json:"head"`
+	BrokenAt int64  `json:"broken_at,omitempty"`
+	Problem  string `json:"problem,omitempty"`
+}
+
+// field names that are never written to the audit log verbatim,
+// since they may hold passwords, keys, or plugin credentials
+var redactedAuditFields = []string{"password", "secret", "key", "token", "config"}
+
+func redactAuditField(field string) bool {
+	field = strings.ToLower(field)
+	for _, s := range redactedAuditFields {
+		if strings.Contains(field, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactAudit strips the values of any sensitive fields nested inside
+// of a value that is about to be written to the audit log.
+func redactAudit(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for field, value := range v {
+			if redactAuditField(field) && value != nil {
+				m[field] = "(redacted)"
+			} else {
+				m[field] = redactAudit(value)
+			}
+		}
+		return m
+
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i := range v {
+			l[i] = redactAudit(v[i])
+		}
+		return l
+	}
+	return v
+}
+
+// AuditDiff compares the before and after forms of some object (as
+// they would be rendered to JSON), and returns the fields that changed,
+// as a JSON object mapping each field name to a [before, after] pair.
+// Sensitive fields (at any depth) are noted as having changed, without
+// their values.
+// Either before or after can be nil, for things created or deleted.
+func AuditDiff(before, after interface{}) (json.RawMessage, error) {
+	fields := func(v interface{}) (map[string]interface{}, error) {
+		m := make(map[string]interface{})
+		if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+			return m, nil
+		}
+
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &m); err != nil {
+			return nil, fmt.Errorf("unable to audit changes to a %T: %s", v, err)
+		}
+		return m, nil
+	}
+
+	a, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	b, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string][]interface{})
+	for _, m := range []map[string]interface{}{a, b} {
+		for field := range m {
+			was, wasok := a[field]
+			now, nowok := b[field]
+			if wasok == nowok && reflect.DeepEqual(was, now) {
+				continue
+			}
+			if redactAuditField(field) {
+				was, now = "(redacted)", "(redacted)"
+				if !wasok {
+					was = nil
+				}
+				if !nowok {
+					now = nil
+				}
+			}
+			changes[field] = []interface{}{redactAudit(was), redactAudit(now)}
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(changes)
+}
+
+func (e *AuditEntry) hash() string {
+	h := sha256.New()
+	for _, s := range []string{
+		e.PrevHash, fmt.Sprintf("%d", e.Seq), e.UUID, fmt.Sprintf("%d", e.At),
+		e.Actor, e.ActorUUID, e.SessionUUID, e.TokenUUID, e.IP,
+		e.Method, e.Route, e.Path, fmt.Sprintf("%d", e.Status),
+		e.TenantUUID, e.ObjectType, e.ObjectUUID, string(e.Changes),
+	} {
+		/* length-prefix every field, so that no two
+		   different entries can serialize the same way */
+		fmt.Fprintf(h, "%d:%s;", len(s), s)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// AppendAudit adds an entry to the end of the audit log, filling in its
+// sequence number, UUID, and timestamp (if not already set), and chaining
+// it to the entry before it.
+func (db *DB) AppendAudit(e *AuditEntry) error {
+	if e.UUID == "" {
+		e.UUID = uuid.NewRandom().String()
+	}
+	if e.At == 0 {
+		e.At = time.Now().Unix()
+	}
+
+	return db.exclusively(func() error {
+		r, err := db.query(`SELECT seq, hash FROM audit_log ORDER BY seq DESC LIMIT 1`)
+		if err != nil {
+			return err
+		}
+		e.Seq, e.PrevHash = 0, ""
+		if r.Next() {
+			if err := r.Scan(&e.Seq, &e.PrevHash); err != nil {
+				r.Close()
+				return err
+			}
+		}
+		r.Close()
+
+		e.Seq++
+		e.Hash = e.hash()
+		return db.exec(`
+		   INSERT INTO audit_log
+		     (seq, uuid, at, actor, actor_uuid, session_uuid, token_uuid, ip,
+		      method, route, path, status,
+		      tenant_uuid, object_type, object_uuid, changes,
+		      prev_hash, hash)
+		   VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			e.Seq, e.UUID, e.At, e.Actor, e.ActorUUID, e.SessionUUID, e.TokenUUID, e.IP,
+			e.Method, e.Route, e.Path, e.Status,
+			e.TenantUUID, e.ObjectType, e.ObjectUUID, string(e.Changes),
+			e.PrevHash, e.Hash)
+	})
+}
+
+func (f *AuditFilter) Query() (string, []interface{}) {
+	wheres := []string{"1 = 1"}
+	var args []interface{}
+
+	if f.ForActor != "" {
+		wheres = append(wheres, "(a.actor = ? OR a.actor_uuid = ?)")
+		args = append(args, f.ForActor, f.ForActor)
+	}
+
+	if f.ForTenant != "" {
+		wheres = append(wheres, "a.tenant_uuid = ?")
+		args = append(args, f.ForTenant)
+	}
+
+	if f.ForType != "" {
+		wheres = append(wheres, "a.object_type = ?")
+		args = append(args, f.ForType)
+	}
+
+	if f.ForObject != "" {
+		wheres = append(wheres, "a.object_uuid = ?")
+		args = append(args, f.ForObject)
+	}
+
+	if f.ForMethod != "" {
+		wheres = append(wheres, "a.method = ?")
+		args = append(args, strings.ToUpper(f.ForMethod))
+	}
+
+	if f.Since > 0 {
+		wheres = append(wheres, "a.at >= ?")
+		args = append(args, f.Since)
+	}
+
+	if f.Until > 0 {
+		wheres = append(wheres, "a.at < ?")
+		args = append(args, f.Until)
+	}
+
+	if f.Before > 0 {
+		wheres = append(wheres, "a.seq < ?")
+		args = append(args, f.Before)
+	}
+
+	if f.After > 0 {
+		wheres = append(wheres, "a.seq > ?")
+		args = append(args, f.After)
+	}
+
+	order := "DESC"
+	if f.Oldest {
+		order = "ASC"
+	}
+
+	limit := ""
+	if f.Limit > 0 {
+		limit = " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+	return `
+	    SELECT a.seq, a.uuid, a.at, a.actor, a.actor_uuid, a.session_uuid, a.token_uuid, a.ip,
+	           a.method, a.route, a.path, a.status,
+	           a.tenant_uuid, a.object_type, a.object_uuid, a.changes,
+	           a.prev_hash, a.hash
+
+	      FROM audit_log a
+
+	     WHERE ` + strings.Join(wheres, " AND ") + `
+	  ORDER BY a.seq ` + order + limit, args
+}
+
+func (r *queryResult) scanAuditEntry() (*AuditEntry, error) {
+	var changes string
+	e := &AuditEntry{}
+	if err := r.Scan(
+		&e.Seq, &e.UUID, &e.At, &e.Actor, &e.ActorUUID, &e.SessionUUID, &e.TokenUUID, &e.IP,
+		&e.Method, &e.Route, &e.Path, &e.Status,
+		&e.TenantUUID, &e.ObjectType, &e.ObjectUUID, &changes,
+		&e.PrevHash, &e.Hash); err != nil {
+		return nil, err
+	}
+	if changes != "" {
+		e.Changes = json.RawMessage(changes)
+	}
+	return e, nil
+}
+
+// GetAuditLog returns the audit log entries matching the filter, most
+// recent first (unless the filter asks for the oldest first).
+func (db *DB) GetAuditLog(filter *AuditFilter) ([]*AuditEntry, error) {
+	l := []*AuditEntry{}
+	err := db.WalkAuditLog(filter, func(e *AuditEntry) error {
+		l = append(l, e)
+		return nil
+	})
+	return l, err
+}
+
+// WalkAuditLog calls fn for each of the audit log entries matching the
+// filter, in the same order as GetAuditLog, without having to hold them
+// all in memory at once (i.e. for exports.)  If fn returns an error,
+// the walk stops and that error is returned.
+func (db *DB) WalkAuditLog(filter *AuditFilter, fn func(*AuditEntry) error) error {
+	db.exclusive.Lock()
+	defer db.exclusive.Unlock()
+
+	if filter == nil {
+		filter = &AuditFilter{}
+	}
+
+	query, args := filter.Query()
+	r, err := db.query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for r.Next() {
+		e, err := r.scanAuditEntry()
+		if err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportAuditLog writes the audit log entries matching the filter to
+// out, oldest first, as JSON lines (one entry per line).  Entries are
+// retrieved a few hundred at a time, so that a slow reader on the other
+// end of out doesn't hold up everything else that needs the database.
+func (db *DB) ExportAuditLog(filter *AuditFilter, out io.Writer) error {
+	f := AuditFilter{}
+	if filter != nil {
+		f = *filter
+	}
+	f.Oldest = true
+	f.Limit = 500
+
+	enc := json.NewEncoder(out)
+	for {
+		l, err := db.GetAuditLog(&f)
+		if err != nil {
+			return err
+		}
+		if len(l) == 0 {
+			return nil
+		}
+
+		for _, e := range l {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		f.After = l[len(l)-1].Seq
+	}
+}
+
+// VerifyAuditLog walks the entire audit log, from the first entry to
+// the last, checking that each entry follows on from the one before it
+// and that its contents still match its hash.
+func (db *DB) VerifyAuditLog() (AuditVerification, error) {
+	v := AuditVerification{OK: true}
+
+	err := db.WalkAuditLog(&AuditFilter{Oldest: true}, func(e *AuditEntry) error {
+		switch {
+		case e.Seq != v.Entries+1:
+			v.Problem = fmt.Sprintf("expected entry #%d, but found entry #%d", v.Entries+1, e.Seq)
+		case e.PrevHash != v.Head:
+			v.Problem = fmt.Sprintf("entry #%d does not follow on from the entry before it", e.Seq)
+		case e.Hash != e.hash():
+			v.Problem = fmt.Sprintf("entry #%d does not match its hash", e.Seq)
+		default:
+			v.Entries = e.Seq
+			v.Head = e.Hash
+			return nil
+		}
+
+		v.OK = false
+		v.BrokenAt = e.Seq
+		return io.EOF
+	})
+	if err == io.EOF {
+		err = nil
+	}
+	return v, err
+}
@@ -0,0 +1,206 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	// sql drivers
+	_ "github.com/mattn/go-sqlite3"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Audit Log", func() {
+	var db *DB
+
+	audit := func(actor, method, route, tenant string) *AuditEntry {
+		e := &AuditEntry{
+			Actor:      actor,
+			IP:         "10.0.0.1",
+			Method:     method,
+			Route:      method + " " + route,
+			Path:       route,
+			Status:     200,
+			TenantUUID: tenant,
+		}
+		Ω(db.AppendAudit(e)).Should(Succeed())
+		return e
+	}
+
+	BeforeEach(func() {
+		var err error
+		db, err = Database()
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	It("chains each entry to the one before it", func() {
+		first := audit("admin@local", "POST", "/v2/tenants", "")
+		Ω(first.Seq).Should(Equal(int64(1)))
+		Ω(first.UUID).ShouldNot(BeEmpty())
+		Ω(first.PrevHash).Should(BeEmpty())
+		Ω(first.Hash).ShouldNot(BeEmpty())
+
+		second := audit("admin@local", "DELETE", "/v2/tenants/t1", "t1")
+		Ω(second.Seq).Should(Equal(int64(2)))
+		Ω(second.PrevHash).Should(Equal(first.Hash))
+
+		v, err := db.VerifyAuditLog()
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(v.OK).Should(BeTrue())
+		Ω(v.Entries).Should(Equal(int64(2)))
+		Ω(v.Head).Should(Equal(second.Hash))
+	})
+
+	It("verifies an empty audit log", func() {
+		v, err := db.VerifyAuditLog()
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(v.OK).Should(BeTrue())
+		Ω(v.Entries).Should(Equal(int64(0)))
+	})
+
+	It("refuses to change or remove entries", func() {
+		audit("admin@local", "POST", "/v2/tenants", "")
+		Ω(db.Exec(`UPDATE audit_log SET actor = 'someone-else'`)).ShouldNot(Succeed())
+		Ω(db.Exec(`DELETE FROM audit_log`)).ShouldNot(Succeed())
+
+		l, err := db.GetAuditLog(nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(l).Should(HaveLen(1))
+		Ω(l[0].Actor).Should(Equal("admin@local"))
+	})
+
+	It("detects tampering with the audit log", func() {
+		audit("admin@local", "POST", "/v2/tenants", "")
+		audit("admin@local", "PATCH", "/v2/tenants/t1", "t1")
+		audit("admin@local", "DELETE", "/v2/tenants/t1", "t1")
+
+		Ω(db.Exec(`DROP TRIGGER audit_log_no_update`)).Should(Succeed())
+		Ω(db.Exec(`UPDATE audit_log SET actor = 'someone-else' WHERE seq = 2`)).Should(Succeed())
+
+		v, err := db.VerifyAuditLog()
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(v.OK).Should(BeFalse())
+		Ω(v.BrokenAt).Should(Equal(int64(2)))
+		Ω(v.Entries).Should(Equal(int64(1)))
+	})
+
+	It("detects entries removed from the audit log", func() {
+		audit("admin@local", "POST", "/v2/tenants", "")
+		audit("admin@local", "PATCH", "/v2/tenants/t1", "t1")
+		audit("admin@local", "DELETE", "/v2/tenants/t1", "t1")
+
+		Ω(db.Exec(`DROP TRIGGER audit_log_no_delete`)).Should(Succeed())
+		Ω(db.Exec(`DELETE FROM audit_log WHERE seq = 2`)).Should(Succeed())
+
+		v, err := db.VerifyAuditLog()
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(v.OK).Should(BeFalse())
+		Ω(v.BrokenAt).Should(Equal(int64(3)))
+	})
+
+	It("filters the audit log", func() {
+		audit("admin@local", "POST", "/v2/tenants", "")
+		audit("jhunt@local", "PATCH", "/v2/tenants/t1", "t1")
+		audit("admin@local", "DELETE", "/v2/tenants/t1", "t1")
+
+		l, err := db.GetAuditLog(&AuditFilter{ForActor: "admin@local"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(l).Should(HaveLen(2))
+		Ω(l[0].Method).Should(Equal("DELETE"))
+		Ω(l[1].Method).Should(Equal("POST"))
+
+		l, err = db.GetAuditLog(&AuditFilter{ForTenant: "t1", ForMethod: "patch"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(l).Should(HaveLen(1))
+		Ω(l[0].Actor).Should(Equal("jhunt@local"))
+
+		l, err = db.GetAuditLog(&AuditFilter{Before: 3, Limit: 1})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(l).Should(HaveLen(1))
+		Ω(l[0].Seq).Should(Equal(int64(2)))
+	})
+
+	It("exports the audit log as JSON lines, oldest first", func() {
+		audit("admin@local", "POST", "/v2/tenants", "")
+		audit("admin@local", "DELETE", "/v2/tenants/t1", "t1")
+
+		var out bytes.Buffer
+		Ω(db.ExportAuditLog(nil, &out)).Should(Succeed())
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		Ω(lines).Should(HaveLen(2))
+
+		var e AuditEntry
+		Ω(json.Unmarshal([]byte(lines[0]), &e)).Should(Succeed())
+		Ω(e.Seq).Should(Equal(int64(1)))
+		Ω(json.Unmarshal([]byte(lines[1]), &e)).Should(Succeed())
+		Ω(e.Seq).Should(Equal(int64(2)))
+	})
+
+	Describe("change tracking", func() {
+		type thing struct {
+			Name     string `json:"name"`
+			Summary  string `json:"summary"`
+			Password string `json:"password"`
+		}
+
+		diff := func(before, after interface{}) map[string][]interface{} {
+			b, err := AuditDiff(before, after)
+			Ω(err).ShouldNot(HaveOccurred())
+			if b == nil {
+				return nil
+			}
+
+			m := make(map[string][]interface{})
+			Ω(json.Unmarshal(b, &m)).Should(Succeed())
+			return m
+		}
+
+		It("records only the fields that changed", func() {
+			Ω(diff(
+				thing{Name: "a", Summary: "same"},
+				thing{Name: "b", Summary: "same"},
+			)).Should(Equal(map[string][]interface{}{
+				"name": {"a", "b"},
+			}))
+		})
+
+		It("records nothing when nothing changed", func() {
+			Ω(diff(thing{Name: "a"}, thing{Name: "a"})).Should(BeNil())
+		})
+
+		It("handles things being created and deleted", func() {
+			var none *thing
+			Ω(diff(none, &thing{Name: "a"})["name"]).Should(Equal([]interface{}{nil, "a"}))
+			Ω(diff(&thing{Name: "a"}, nil)["name"]).Should(Equal([]interface{}{"a", nil}))
+		})
+
+		It("redacts sensitive fields", func() {
+			Ω(diff(
+				thing{Password: "hunter2"},
+				thing{Password: "sekrit"},
+			)).Should(Equal(map[string][]interface{}{
+				"password": {"(redacted)", "(redacted)"},
+			}))
+		})
+
+		It("redacts sensitive fields nested inside of other fields", func() {
+			type outer struct {
+				Target map[string]interface{} `json:"target"`
+			}
+			Ω(diff(nil, outer{
+				Target: map[string]interface{}{
+					"name":   "db",
+					"config": map[string]interface{}{"password": "hunter2"},
+				},
+			})).Should(Equal(map[string][]interface{}{
+				"target": {nil, map[string]interface{}{
+					"name":   "db",
+					"config": "(redacted)",
+				}},
+			}))
+		})
+	})
+})
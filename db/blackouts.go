@@ -0,0 +1,283 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shieldproject/shield/timespec"
+)
+
+// A Blackout is a period of time during which scheduled backups are
+// not run.  Blackouts are either recurring (a timespec Schedule that
+// says when each blackout starts, and a Duration, in minutes, that
+// says how long it lasts), or a one-off, explicit range of time,
+// from StartsAt up to (but not including) EndsAt.
+//
+// Blackouts defined on the global tenant apply to every job; those
+// defined on a tenant apply to every job in that tenant, unless they
+// are also tied to a single target, in which case they only apply to
+// jobs that back up that target.
+type Blackout struct {
+	UUID       string `json:"uuid"        mbus:"uuid"`
+	TenantUUID string `json:"tenant_uuid" mbus:"tenant_uuid"`
+	TargetUUID string `json:"target_uuid" mbus:"target_uuid"`
+	Name       string `json:"name"        mbus:"name"`
+	Summary    string `json:"summary"     mbus:"summary"`
+
+	Schedule string `json:"schedule" mbus:"schedule"`
+	Duration int    `json:"duration" mbus:"duration"`
+
+	StartsAt int64 `json:"starts_at" mbus:"starts_at"`
+	EndsAt   int64 `json:"ends_at"   mbus:"ends_at"`
+
+	CatchUp bool `json:"catch_up" mbus:"catch_up"`
+}
+
+// Validate checks that the blackout is either a recurring blackout
+// or an explicit range of time, but not both, and that its schedule
+// (if any) is a valid timespec.
+func (b *Blackout) Validate() error {
+	if b.Schedule != "" {
+		if b.StartsAt != 0 || b.EndsAt != 0 {
+			return fmt.Errorf("a blackout cannot have both a recurring schedule and an explicit start / end time")
+		}
+		if _, err := timespec.Parse(b.Schedule); err != nil {
+			return fmt.Errorf("invalid blackout schedule '%s': %s", b.Schedule, err)
+		}
+		if b.Duration <= 0 {
+			return fmt.Errorf("recurring blackouts must last for at least a minute")
+		}
+		return nil
+	}
+
+	if b.Duration != 0 {
+		return fmt.Errorf("only recurring blackouts (with a schedule) can have a duration")
+	}
+	if b.StartsAt <= 0 || b.EndsAt <= 0 {
+		return fmt.Errorf("a blackout must have either a recurring schedule, or an explicit start and end time")
+	}
+	if b.EndsAt <= b.StartsAt {
+		return fmt.Errorf("a blackout must end after it starts")
+	}
+	return nil
+}
+
+// Active determines whether or not the blackout is in effect at the
+// given time, and if so, when it will end.
+func (b *Blackout) Active(at time.Time) (bool, time.Time, error) {
+	if b.Schedule == "" {
+		if at.Unix() >= b.StartsAt && at.Unix() < b.EndsAt {
+			return true, time.Unix(b.EndsAt, 0), nil
+		}
+		return false, time.Time{}, nil
+	}
+
+	spec, err := timespec.Parse(b.Schedule)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	/* a recurring blackout is active if one of its occurrences
+	   started within the last Duration minutes. */
+	length := time.Duration(b.Duration) * time.Minute
+	start, err := spec.Next(at.Add(-1 * length))
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	if start.After(at) {
+		return false, time.Time{}, nil
+	}
+	return true, start.Add(length), nil
+}
+
+// Covers determines whether or not the blackout applies to the given
+// job, based on its tenant and target.
+func (b *Blackout) Covers(job *Job) bool {
+	if b.TenantUUID == GlobalTenantUUID {
+		return true
+	}
+	if b.TenantUUID != job.TenantUUID {
+		return false
+	}
+	return b.TargetUUID == "" || b.TargetUUID == job.TargetUUID
+}
+
+type BlackoutFilter struct {
+	UUID       string
+	SearchName string
+	ForTenant  string
+	ForTarget  string
+	ExactMatch bool
+}
+
+func (f *BlackoutFilter) Query() (string, []interface{}) {
+	wheres := []string{}
+	args := []interface{}{}
+
+	if f.UUID != "" {
+		if f.ExactMatch {
+			wheres = append(wheres, "b.uuid = ?")
+			args = append(args, f.UUID)
+		} else {
+			wheres = append(wheres, "b.uuid LIKE ? ESCAPE '/'")
+			args = append(args, PatternPrefix(f.UUID))
+		}
+	}
+
+	if f.SearchName != "" {
+		if f.ExactMatch {
+			wheres = append(wheres, "b.name = ?")
+			args = append(args, f.SearchName)
+		} else {
+			wheres = append(wheres, "b.name LIKE ?")
+			args = append(args, Pattern(f.SearchName))
+		}
+	}
+
+	if len(wheres) == 0 {
+		wheres = []string{"1"}
+	} else if len(wheres) > 1 {
+		wheres = []string{strings.Join(wheres, " OR ")}
+	}
+
+	if f.ForTenant != "" {
+		wheres = append(wheres, "b.tenant_uuid = ?")
+		args = append(args, f.ForTenant)
+	}
+	if f.ForTarget != "" {
+		wheres = append(wheres, "b.target_uuid = ?")
+		args = append(args, f.ForTarget)
+	}
+
+	return `
+	   SELECT b.uuid, b.tenant_uuid, b.target_uuid, b.name, b.summary,
+	          b.schedule, b.duration, b.starts_at, b.ends_at, b.catch_up
+	     FROM blackouts b
+	    WHERE ` + strings.Join(wheres, " AND ") + `
+	 ORDER BY b.name, b.uuid ASC`, args
+}
+
+func (db *DB) GetAllBlackouts(filter *BlackoutFilter) ([]*Blackout, error) {
+	db.exclusive.Lock()
+	defer db.exclusive.Unlock()
+
+	if filter == nil {
+		filter = &BlackoutFilter{}
+	}
+
+	l := []*Blackout{}
+	query, args := filter.Query()
+	r, err := db.query(query, args...)
+	if err != nil {
+		return l, err
+	}
+	defer r.Close()
+
+	for r.Next() {
+		b := &Blackout{}
+		if err = r.Scan(&b.UUID, &b.TenantUUID, &b.TargetUUID, &b.Name, &b.Summary,
+			&b.Schedule, &b.Duration, &b.StartsAt, &b.EndsAt, &b.CatchUp); err != nil {
+			return l, err
+		}
+		l = append(l, b)
+	}
+
+	return l, nil
+}
+
+func (db *DB) GetBlackout(id string) (*Blackout, error) {
+	l, err := db.GetAllBlackouts(&BlackoutFilter{UUID: id, ExactMatch: true})
+	if err != nil || len(l) == 0 {
+		return nil, err
+	}
+	return l[0], nil
+}
+
+func (db *DB) CreateBlackout(blackout *Blackout) (*Blackout, error) {
+	if err := blackout.Validate(); err != nil {
+		return nil, err
+	}
+
+	blackout.UUID = RandomID()
+	err := db.exclusively(func() error {
+		/* validate the tenant */
+		if err := db.tenantShouldExist(blackout.TenantUUID); err != nil {
+			return fmt.Errorf("unable to create blackout: %s", err)
+		}
+		if blackout.TargetUUID != "" {
+			/* validate the target */
+			if err := db.targetShouldExist(blackout.TargetUUID); err != nil {
+				return fmt.Errorf("unable to create blackout: %s", err)
+			}
+		}
+
+		return db.exec(`
+		    INSERT INTO blackouts (uuid, tenant_uuid, target_uuid, name, summary,
+		                           schedule, duration, starts_at, ends_at, catch_up)
+		                   VALUES (?, ?, ?, ?, ?,
+		                           ?, ?, ?, ?, ?)`,
+			blackout.UUID, blackout.TenantUUID, blackout.TargetUUID, blackout.Name, blackout.Summary,
+			blackout.Schedule, blackout.Duration, blackout.StartsAt, blackout.EndsAt, blackout.CatchUp)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	db.sendCreateObjectEvent(blackout, blackoutQueue(blackout))
+	return blackout, nil
+}
+
+func (db *DB) UpdateBlackout(blackout *Blackout) error {
+	if err := blackout.Validate(); err != nil {
+		return err
+	}
+
+	err := db.Exec(`
+	  UPDATE blackouts
+	     SET name        = ?,
+	         summary     = ?,
+	         target_uuid = ?,
+	         schedule    = ?,
+	         duration    = ?,
+	         starts_at   = ?,
+	         ends_at     = ?,
+	         catch_up    = ?
+	   WHERE uuid = ?`,
+		blackout.Name, blackout.Summary, blackout.TargetUUID,
+		blackout.Schedule, blackout.Duration, blackout.StartsAt, blackout.EndsAt, blackout.CatchUp,
+		blackout.UUID)
+	if err != nil {
+		return err
+	}
+
+	db.sendUpdateObjectEvent(blackout, blackoutQueue(blackout))
+	return nil
+}
+
+func (db *DB) DeleteBlackout(id string) (bool, error) {
+	blackout, err := db.GetBlackout(id)
+	if err != nil {
+		return false, err
+	}
+
+	if blackout == nil {
+		/* already deleted */
+		return true, nil
+	}
+
+	err = db.Exec(`DELETE FROM blackouts WHERE uuid = ?`, id)
+	if err != nil {
+		return false, err
+	}
+
+	db.sendDeleteObjectEvent(blackout, blackoutQueue(blackout))
+	return true, nil
+}
+
+func blackoutQueue(blackout *Blackout) string {
+	if blackout.TenantUUID == GlobalTenantUUID {
+		return "*"
+	}
+	return "tenant:" + blackout.TenantUUID
+}
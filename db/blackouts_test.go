@@ -0,0 +1,193 @@
+package db
+
+import (
+	"time"
+
+	// sql drivers
+	_ "github.com/mattn/go-sqlite3"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Blackouts", func() {
+	Context("Validation", func() {
+		It("accepts recurring blackouts", func() {
+			b := &Blackout{Schedule: "daily 1am", Duration: 120}
+			Ω(b.Validate()).Should(Succeed())
+		})
+
+		It("accepts explicit ranges", func() {
+			b := &Blackout{StartsAt: T0.Unix(), EndsAt: T0.Unix() + 3600}
+			Ω(b.Validate()).Should(Succeed())
+		})
+
+		It("rejects blackouts that are both recurring and explicit", func() {
+			b := &Blackout{Schedule: "daily 1am", Duration: 120, StartsAt: T0.Unix(), EndsAt: T0.Unix() + 3600}
+			Ω(b.Validate()).ShouldNot(Succeed())
+		})
+
+		It("rejects recurring blackouts without a duration", func() {
+			b := &Blackout{Schedule: "daily 1am"}
+			Ω(b.Validate()).ShouldNot(Succeed())
+		})
+
+		It("rejects recurring blackouts with a bad schedule", func() {
+			b := &Blackout{Schedule: "whenever", Duration: 120}
+			Ω(b.Validate()).ShouldNot(Succeed())
+		})
+
+		It("rejects ranges that end before they start", func() {
+			b := &Blackout{StartsAt: T0.Unix(), EndsAt: T0.Unix() - 3600}
+			Ω(b.Validate()).ShouldNot(Succeed())
+		})
+
+		It("rejects blackouts with no schedule or range", func() {
+			Ω((&Blackout{}).Validate()).ShouldNot(Succeed())
+		})
+	})
+
+	Context("Evaluation", func() {
+		active := func(b *Blackout, t time.Time) (bool, time.Time) {
+			ok, until, err := b.Active(t)
+			Ω(err).ShouldNot(HaveOccurred())
+			return ok, until
+		}
+
+		It("handles explicit ranges", func() {
+			b := &Blackout{StartsAt: at(-60).Unix(), EndsAt: at(60).Unix()}
+
+			ok, until := active(b, at(0))
+			Ω(ok).Should(BeTrue())
+			Ω(until).Should(BeTemporally("==", at(60)))
+
+			ok, _ = active(b, at(60))
+			Ω(ok).Should(BeFalse())
+			ok, _ = active(b, at(-61))
+			Ω(ok).Should(BeFalse())
+		})
+
+		It("handles recurring daily blackouts", func() {
+			/* T0 is 02:14 */
+			b := &Blackout{Schedule: "daily 1am", Duration: 120}
+
+			ok, until := active(b, T0)
+			Ω(ok).Should(BeTrue())
+			Ω(until).Should(BeTemporally("==", time.Date(1997, 8, 29, 3, 0, 0, 0, time.UTC)))
+
+			ok, _ = active(b, T0.Add(time.Hour))
+			Ω(ok).Should(BeFalse())
+			ok, _ = active(b, T0.Add(-90*time.Minute))
+			Ω(ok).Should(BeFalse())
+		})
+
+		It("handles recurring blackouts that last for days", func() {
+			/* T0 is a Friday */
+			b := &Blackout{Schedule: "thursdays at 18:00", Duration: 3 * 1440}
+
+			ok, until := active(b, T0)
+			Ω(ok).Should(BeTrue())
+			Ω(until).Should(BeTemporally("==", time.Date(1997, 8, 31, 18, 0, 0, 0, time.UTC)))
+
+			ok, _ = active(b, T0.Add(-3*24*time.Hour))
+			Ω(ok).Should(BeFalse())
+		})
+
+		It("applies global blackouts to every job", func() {
+			b := &Blackout{TenantUUID: GlobalTenantUUID}
+			Ω(b.Covers(&Job{TenantUUID: "t1", TargetUUID: "x"})).Should(BeTrue())
+		})
+
+		It("applies tenant blackouts to that tenant's jobs", func() {
+			b := &Blackout{TenantUUID: "t1"}
+			Ω(b.Covers(&Job{TenantUUID: "t1", TargetUUID: "x"})).Should(BeTrue())
+			Ω(b.Covers(&Job{TenantUUID: "t2", TargetUUID: "x"})).Should(BeFalse())
+		})
+
+		It("applies target blackouts only to jobs for that target", func() {
+			b := &Blackout{TenantUUID: "t1", TargetUUID: "x"}
+			Ω(b.Covers(&Job{TenantUUID: "t1", TargetUUID: "x"})).Should(BeTrue())
+			Ω(b.Covers(&Job{TenantUUID: "t1", TargetUUID: "y"})).Should(BeFalse())
+		})
+	})
+
+	Context("Management", func() {
+		var (
+			db         *DB
+			SomeTenant *Tenant
+		)
+
+		BeforeEach(func() {
+			var err error
+			SomeTenant = &Tenant{UUID: RandomID()}
+			db, err = Database(
+				`INSERT INTO tenants (uuid, name)
+				   VALUES ("` + SomeTenant.UUID + `", "Some Tenant")`,
+			)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(db).ShouldNot(BeNil())
+		})
+
+		It("can create, retrieve, update and delete blackouts", func() {
+			global, err := db.CreateBlackout(&Blackout{
+				TenantUUID: GlobalTenantUUID,
+				Name:       "Month-End Close",
+				Schedule:   "monthly at 18:00 on 28th",
+				Duration:   4 * 1440,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(global.UUID).ShouldNot(BeEmpty())
+
+			_, err = db.CreateBlackout(&Blackout{
+				TenantUUID: SomeTenant.UUID,
+				Name:       "Data Center Move",
+				StartsAt:   at(0).Unix(),
+				EndsAt:     at(86400).Unix(),
+				CatchUp:    true,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			all, err := db.GetAllBlackouts(nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(len(all)).Should(Equal(2))
+
+			mine, err := db.GetAllBlackouts(&BlackoutFilter{ForTenant: SomeTenant.UUID})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(len(mine)).Should(Equal(1))
+			Ω(mine[0].Name).Should(Equal("Data Center Move"))
+			Ω(mine[0].CatchUp).Should(BeTrue())
+
+			global.Duration = 3 * 1440
+			Ω(db.UpdateBlackout(global)).Should(Succeed())
+			b, err := db.GetBlackout(global.UUID)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(b).ShouldNot(BeNil())
+			Ω(b.Duration).Should(Equal(3 * 1440))
+
+			ok, err := db.DeleteBlackout(global.UUID)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(ok).Should(BeTrue())
+			b, err = db.GetBlackout(global.UUID)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(b).Should(BeNil())
+		})
+
+		It("refuses to create invalid blackouts", func() {
+			_, err := db.CreateBlackout(&Blackout{
+				TenantUUID: SomeTenant.UUID,
+				Name:       "Broken",
+			})
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("refuses to create blackouts for tenants that do not exist", func() {
+			_, err := db.CreateBlackout(&Blackout{
+				TenantUUID: RandomID(),
+				Name:       "Orphaned",
+				Schedule:   "daily 1am",
+				Duration:   60,
+			})
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+})
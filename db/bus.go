@@ -46,6 +46,16 @@ func datauuid(thing interface{}) string {
 	case *Archive:
 		return fmt.Sprintf("archive [%s]", thing.(*Archive).UUID)
 
+	case Blackout:
+		return fmt.Sprintf("blackout [%s]", thing.(Blackout).UUID)
+	case *Blackout:
+		return fmt.Sprintf("blackout [%s]", thing.(*Blackout).UUID)
+
+	case Calendar:
+		return fmt.Sprintf("calendar [%s]", thing.(Calendar).UUID)
+	case *Calendar:
+		return fmt.Sprintf("calendar [%s]", thing.(*Calendar).UUID)
+
 	default:
 		panic("SHIELD was unable to determine the type of thing, in order to craft a message bus event for it.  This is most certainly a bug in SHIELD itself.")
 	}
@@ -74,6 +84,12 @@ func datatype(thing interface{}) string {
 	case Archive, *Archive:
 		return "archive"
 
+	case Blackout, *Blackout:
+		return "blackout"
+
+	case Calendar, *Calendar:
+		return "calendar"
+
 	default:
 		panic("SHIELD was unable to determine the type of thing, in order to craft a message bus event for it.  This is most certainly a bug in SHIELD itself.")
 	}
@@ -148,3 +164,20 @@ func (db *DB) sendTenantBanishEvent(user, tenant string) {
 		}, "user:"+user, "tenant:"+tenant)
 	}
 }
+
+func (db *DB) sendLoginLockoutEvent(l *LoginLockout) {
+	if db.bus != nil {
+		log.Infof("sending %s to [admins] for %s [%s]", bus.LoginLockoutEvent, l.Kind, l.Name)
+		db.bus.Send(bus.LoginLockoutEvent, "", l, "admins")
+	}
+}
+
+func (db *DB) sendLoginUnlockEvent(kind, name string) {
+	if db.bus != nil {
+		log.Infof("sending %s to [admins] for %s [%s]", bus.LoginUnlockEvent, kind, name)
+		db.bus.Send(bus.LoginUnlockEvent, "", map[string]interface{}{
+			"kind": kind,
+			"name": name,
+		}, "admins")
+	}
+}
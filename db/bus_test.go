@@ -46,6 +46,8 @@ var _ = Describe("MessageBus Database Integration", func() {
 		check("tenant", "foo", Tenant{UUID: "foo"}, &Tenant{UUID: "foo"})
 		check("task", "foo", Task{UUID: "foo"}, &Task{UUID: "foo"})
 		check("archive", "foo", Archive{UUID: "foo"}, &Archive{UUID: "foo"})
+		check("blackout", "foo", Blackout{UUID: "foo"}, &Blackout{UUID: "foo"})
+		check("calendar", "foo", Calendar{UUID: "foo"}, &Calendar{UUID: "foo"})
 	})
 
 	Context("with a (local) messagebus", func() {
@@ -0,0 +1,308 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shieldproject/shield/timespec"
+)
+
+// A Calendar is a named list of dates (i.e. public holidays, or
+// month-end close) that schedules can exclude, by way of an except
+// clause like "daily at 2am except dates in the us-holidays calendar".
+// Calendars are shared by every tenant.
+//
+// Dates are kept as YYYY-MM-DD strings, in the local time of
+// whatever schedule happens to be excluding them.
+type Calendar struct {
+	UUID    string   `json:"uuid"    mbus:"uuid"`
+	Name    string   `json:"name"    mbus:"name"`
+	Summary string   `json:"summary" mbus:"summary"`
+	Dates   []string `json:"dates"   mbus:"dates"`
+}
+
+var calendarName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// Validate checks that the calendar name can be used in a timespec,
+// and that all of its dates are real dates.  Dates are sorted, and
+// any duplicates removed, along the way.
+func (c *Calendar) Validate() error {
+	if !calendarName.MatchString(c.Name) {
+		return fmt.Errorf("invalid calendar name '%s' (names may only contain letters, numbers, dots, dashes and underscores)", c.Name)
+	}
+
+	seen := make(map[string]bool)
+	dates := make([]string, 0, len(c.Dates))
+	for _, date := range c.Dates {
+		date = strings.TrimSpace(date)
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return fmt.Errorf("invalid calendar date '%s' (dates must be in YYYY-MM-DD format)", date)
+		}
+		if !seen[date] {
+			seen[date] = true
+			dates = append(dates, date)
+		}
+	}
+	sort.Strings(dates)
+	c.Dates = dates
+	return nil
+}
+
+type CalendarFilter struct {
+	UUID       string
+	SearchName string
+	ExactMatch bool
+}
+
+func (f *CalendarFilter) Query() (string, []interface{}) {
+	wheres := []string{}
+	args := []interface{}{}
+
+	if f.UUID != "" {
+		if f.ExactMatch {
+			wheres = append(wheres, "c.uuid = ?")
+			args = append(args, f.UUID)
+		} else {
+			wheres = append(wheres, "c.uuid LIKE ? ESCAPE '/'")
+			args = append(args, PatternPrefix(f.UUID))
+		}
+	}
+
+	if f.SearchName != "" {
+		if f.ExactMatch {
+			wheres = append(wheres, "c.name = ?")
+			args = append(args, f.SearchName)
+		} else {
+			wheres = append(wheres, "c.name LIKE ?")
+			args = append(args, Pattern(f.SearchName))
+		}
+	}
+
+	if len(wheres) == 0 {
+		wheres = []string{"1"}
+	} else if len(wheres) > 1 {
+		wheres = []string{strings.Join(wheres, " OR ")}
+	}
+
+	return `
+	   SELECT c.uuid, c.name, c.summary
+	     FROM calendars c
+	    WHERE ` + strings.Join(wheres, " AND ") + `
+	 ORDER BY c.name, c.uuid ASC`, args
+}
+
+func (db *DB) GetAllCalendars(filter *CalendarFilter) ([]*Calendar, error) {
+	db.exclusive.Lock()
+	defer db.exclusive.Unlock()
+
+	if filter == nil {
+		filter = &CalendarFilter{}
+	}
+
+	l := []*Calendar{}
+	query, args := filter.Query()
+	r, err := db.query(query, args...)
+	if err != nil {
+		return l, err
+	}
+
+	for r.Next() {
+		c := &Calendar{Dates: []string{}}
+		if err = r.Scan(&c.UUID, &c.Name, &c.Summary); err != nil {
+			r.Close()
+			return l, err
+		}
+		l = append(l, c)
+	}
+	r.Close()
+
+	for _, c := range l {
+		dates, err := db.query(`
+		   SELECT date FROM calendar_dates
+		    WHERE calendar_uuid = ?
+		 ORDER BY date ASC`, c.UUID)
+		if err != nil {
+			return l, err
+		}
+		for dates.Next() {
+			var date string
+			if err = dates.Scan(&date); err != nil {
+				dates.Close()
+				return l, err
+			}
+			c.Dates = append(c.Dates, date)
+		}
+		dates.Close()
+	}
+
+	return l, nil
+}
+
+func (db *DB) GetCalendar(id string) (*Calendar, error) {
+	l, err := db.GetAllCalendars(&CalendarFilter{UUID: id, ExactMatch: true})
+	if err != nil || len(l) == 0 {
+		return nil, err
+	}
+	return l[0], nil
+}
+
+func (db *DB) GetCalendarByName(name string) (*Calendar, error) {
+	l, err := db.GetAllCalendars(&CalendarFilter{SearchName: name, ExactMatch: true})
+	if err != nil || len(l) == 0 {
+		return nil, err
+	}
+	return l[0], nil
+}
+
+func (db *DB) insertCalendarDates(calendar *Calendar) error {
+	for _, date := range calendar.Dates {
+		err := db.exec(`INSERT INTO calendar_dates (calendar_uuid, date) VALUES (?, ?)`,
+			calendar.UUID, date)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *DB) CreateCalendar(calendar *Calendar) (*Calendar, error) {
+	if err := calendar.Validate(); err != nil {
+		return nil, err
+	}
+
+	calendar.UUID = RandomID()
+	err := db.exclusively(func() error {
+		if n, err := db.count(`SELECT uuid FROM calendars WHERE name = ?`, calendar.Name); err != nil {
+			return err
+		} else if n > 0 {
+			return fmt.Errorf("a calendar named '%s' already exists", calendar.Name)
+		}
+
+		err := db.exec(`
+		    INSERT INTO calendars (uuid, name, summary)
+		                   VALUES (?, ?, ?)`,
+			calendar.UUID, calendar.Name, calendar.Summary)
+		if err != nil {
+			return err
+		}
+		return db.insertCalendarDates(calendar)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	db.sendCreateObjectEvent(calendar, "*")
+	return calendar, nil
+}
+
+func (db *DB) UpdateCalendar(calendar *Calendar) error {
+	if err := calendar.Validate(); err != nil {
+		return err
+	}
+
+	err := db.exclusively(func() error {
+		if n, err := db.count(`SELECT uuid FROM calendars WHERE name = ? AND uuid != ?`, calendar.Name, calendar.UUID); err != nil {
+			return err
+		} else if n > 0 {
+			return fmt.Errorf("a calendar named '%s' already exists", calendar.Name)
+		}
+
+		err := db.exec(`
+		  UPDATE calendars
+		     SET name    = ?,
+		         summary = ?
+		   WHERE uuid = ?`,
+			calendar.Name, calendar.Summary, calendar.UUID)
+		if err != nil {
+			return err
+		}
+
+		err = db.exec(`DELETE FROM calendar_dates WHERE calendar_uuid = ?`, calendar.UUID)
+		if err != nil {
+			return err
+		}
+		return db.insertCalendarDates(calendar)
+	})
+	if err != nil {
+		return err
+	}
+
+	db.sendUpdateObjectEvent(calendar, "*")
+	return nil
+}
+
+func (db *DB) DeleteCalendar(id string) (bool, error) {
+	calendar, err := db.GetCalendar(id)
+	if err != nil {
+		return false, err
+	}
+
+	if calendar == nil {
+		/* already deleted */
+		return true, nil
+	}
+
+	err = db.exclusively(func() error {
+		if err := db.exec(`DELETE FROM calendar_dates WHERE calendar_uuid = ?`, id); err != nil {
+			return err
+		}
+		return db.exec(`DELETE FROM calendars WHERE uuid = ?`, id)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	db.sendDeleteObjectEvent(calendar, "*")
+	return true, nil
+}
+
+// ResolveExclusions looks up the dates of every calendar named in the
+// except clause of a timespec, and fills in the spec's ExceptDates.
+// It is an error for a spec to name a calendar that does not exist.
+func (db *DB) ResolveExclusions(spec *timespec.Spec) error {
+	if len(spec.ExceptCalendars) == 0 {
+		return nil
+	}
+
+	spec.ExceptDates = make(map[string]bool)
+	for _, name := range spec.ExceptCalendars {
+		calendar, err := db.GetCalendarByName(name)
+		if err != nil {
+			return err
+		}
+		if calendar == nil {
+			return fmt.Errorf("no such exclusion calendar '%s'", name)
+		}
+		for _, date := range calendar.Dates {
+			spec.ExceptDates[date] = true
+		}
+	}
+	return nil
+}
+
+// JobsExcludingCalendar returns all of the jobs whose schedules skip
+// the dates in the named calendar.
+func (db *DB) JobsExcludingCalendar(name string) ([]*Job, error) {
+	jobs, err := db.GetAllJobs(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	l := []*Job{}
+	for _, job := range jobs {
+		spec, err := timespec.Parse(job.Schedule)
+		if err != nil {
+			continue
+		}
+		for _, other := range spec.ExceptCalendars {
+			if other == name {
+				l = append(l, job)
+				break
+			}
+		}
+	}
+	return l, nil
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"time"
+
+	// sql drivers
+	_ "github.com/mattn/go-sqlite3"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/shieldproject/shield/timespec"
+)
+
+var _ = Describe("Calendars", func() {
+	Context("Validation", func() {
+		It("accepts calendars with good names and dates", func() {
+			c := &Calendar{Name: "us-holidays", Dates: []string{"1997-12-25", "1997-07-04", "1997-12-25"}}
+			Ω(c.Validate()).Should(Succeed())
+			Ω(c.Dates).Should(Equal([]string{"1997-07-04", "1997-12-25"}))
+		})
+
+		It("rejects names that cannot be used in a timespec", func() {
+			Ω((&Calendar{Name: "us holidays"}).Validate()).ShouldNot(Succeed())
+			Ω((&Calendar{Name: ""}).Validate()).ShouldNot(Succeed())
+		})
+
+		It("rejects bad dates", func() {
+			Ω((&Calendar{Name: "x", Dates: []string{"12/25/1997"}}).Validate()).ShouldNot(Succeed())
+			Ω((&Calendar{Name: "x", Dates: []string{"1997-02-30"}}).Validate()).ShouldNot(Succeed())
+		})
+	})
+
+	Context("Storage and resolution", func() {
+		var db *DB
+
+		BeforeEach(func() {
+			var err error
+			db, err = Database()
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("stores calendars and their dates", func() {
+			c, err := db.CreateCalendar(&Calendar{
+				Name:    "us-holidays",
+				Summary: "US Federal Holidays",
+				Dates:   []string{"1997-09-01", "1997-07-04"},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			got, err := db.GetCalendar(c.UUID)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(got).ShouldNot(BeNil())
+			Ω(got.Name).Should(Equal("us-holidays"))
+			Ω(got.Dates).Should(Equal([]string{"1997-07-04", "1997-09-01"}))
+
+			got.Dates = []string{"1997-11-27"}
+			Ω(db.UpdateCalendar(got)).Should(Succeed())
+			got, err = db.GetCalendarByName("us-holidays")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(got.Dates).Should(Equal([]string{"1997-11-27"}))
+
+			_, err = db.CreateCalendar(&Calendar{Name: "us-holidays"})
+			Ω(err).Should(HaveOccurred())
+
+			Ω(db.DeleteCalendar(c.UUID)).Should(BeTrue())
+			got, err = db.GetCalendar(c.UUID)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(got).Should(BeNil())
+			Ω(db.Count(`SELECT * FROM calendar_dates`)).Should(BeEquivalentTo(0))
+		})
+
+		It("resolves calendars named in a timespec", func() {
+			_, err := db.CreateCalendar(&Calendar{
+				Name:  "us-holidays",
+				Dates: []string{"1997-09-01"},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			spec, err := timespec.Parse("daily at 2:14am except dates in the us-holidays calendar")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(db.ResolveExclusions(spec)).Should(Succeed())
+
+			/* T0 is Friday, August 29th 1997; Labor Day is the Monday after */
+			next, err := spec.Next(T0.Add(48 * time.Hour))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(next).Should(BeTemporally("==", time.Date(1997, 9, 2, 2, 14, 0, 0, time.UTC)))
+
+			spec, err = timespec.Parse("daily at 2am except dates in the eu-holidays calendar")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(db.ResolveExclusions(spec)).ShouldNot(Succeed())
+		})
+	})
+})
@@ -126,6 +126,12 @@ func (db *DB) exportArchives(out *json.Encoder, vault *vault.Client) error {
 		Job            string `json:"jobs"`
 		EncryptionType string `json:"encryption_type"`
 		Compression    string `json:"compression"`
+		Held           bool   `json:"held"`
+		HoldReason     string `json:"hold_reason"`
+		HeldBy         string `json:"held_by"`
+		HeldAt         int64  `json:"held_at"`
+		HoldUntil      int64  `json:"hold_until"`
+		PurgeAfter     int64  `json:"purge_after"`
 		EncryptionKey  string `json:"encryption_key"`
 		EncryptionIV   string `json:"encryption_iv"`
 	}
@@ -133,7 +139,9 @@ func (db *DB) exportArchives(out *json.Encoder, vault *vault.Client) error {
 	r, err := db.query(`
 	  SELECT uuid, tenant_uuid, target_uuid, store_uuid,
 	         store_key, taken_at, expires_at, notes, purge_reason,
-	         status, size, job,compression
+	         status, size, job,compression,
+	         held, hold_reason, held_by, held_at, hold_until,
+	         purge_after
 	    FROM archives`)
 	if err != nil {
 		return err
@@ -146,7 +154,9 @@ func (db *DB) exportArchives(out *json.Encoder, vault *vault.Client) error {
 		if err = r.Scan(
 			&v.UUID, &v.TenantUUID, &v.TargetUUID, &v.StoreUUID,
 			&v.StoreKey, &v.TakenAt, &v.ExpiresAt, &v.Notes, &v.PurgeReason,
-			&v.Status, &v.Size, &v.Job, &v.Compression); err != nil {
+			&v.Status, &v.Size, &v.Job, &v.Compression,
+			&v.Held, &v.HoldReason, &v.HeldBy, &v.HeldAt, &v.HoldUntil,
+			&v.PurgeAfter); err != nil {
 
 			return err
 		}
@@ -164,6 +174,132 @@ func (db *DB) exportArchives(out *json.Encoder, vault *vault.Client) error {
 	return nil
 }
 
+func (db *DB) exportArchiveLabels(out *json.Encoder) error {
+	db.exportHeader(out, "archive_labels")
+
+	type label struct {
+		ArchiveUUID string `json:"archive_uuid"`
+		Name        string `json:"name"`
+		Value       string `json:"value"`
+	}
+
+	r, err := db.query(`
+	  SELECT archive_uuid, name, value
+	    FROM archive_labels`)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for r.Next() {
+		v := label{}
+
+		if err = r.Scan(&v.ArchiveUUID, &v.Name, &v.Value); err != nil {
+			return err
+		}
+
+		out.Encode(&v)
+	}
+	return nil
+}
+
+func (db *DB) exportBlackouts(out *json.Encoder) error {
+	db.exportHeader(out, "blackouts")
+
+	type blackout struct {
+		UUID       string `json:"uuid"`
+		TenantUUID string `json:"tenant_uuid"`
+		TargetUUID string `json:"target_uuid"`
+		Name       string `json:"name"`
+		Summary    string `json:"summary"`
+		Schedule   string `json:"schedule"`
+		Duration   int    `json:"duration"`
+		StartsAt   int64  `json:"starts_at"`
+		EndsAt     int64  `json:"ends_at"`
+		CatchUp    bool   `json:"catch_up"`
+	}
+
+	r, err := db.query(`
+	  SELECT uuid, tenant_uuid, target_uuid, name, summary,
+	         schedule, duration, starts_at, ends_at, catch_up
+	    FROM blackouts`)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for r.Next() {
+		v := blackout{}
+
+		if err = r.Scan(
+			&v.UUID, &v.TenantUUID, &v.TargetUUID, &v.Name, &v.Summary,
+			&v.Schedule, &v.Duration, &v.StartsAt, &v.EndsAt, &v.CatchUp); err != nil {
+
+			return err
+		}
+
+		out.Encode(&v)
+	}
+	return nil
+}
+
+func (db *DB) exportCalendars(out *json.Encoder) error {
+	db.exportHeader(out, "calendars")
+
+	type calendar struct {
+		UUID    string `json:"uuid"`
+		Name    string `json:"name"`
+		Summary string `json:"summary"`
+	}
+
+	r, err := db.query(`
+	  SELECT uuid, name, summary
+	    FROM calendars`)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for r.Next() {
+		v := calendar{}
+
+		if err = r.Scan(&v.UUID, &v.Name, &v.Summary); err != nil {
+			return err
+		}
+
+		out.Encode(&v)
+	}
+	return nil
+}
+
+func (db *DB) exportCalendarDates(out *json.Encoder) error {
+	db.exportHeader(out, "calendar_dates")
+
+	type date struct {
+		CalendarUUID string `json:"calendar_uuid"`
+		Date         string `json:"date"`
+	}
+
+	r, err := db.query(`
+	  SELECT calendar_uuid, date
+	    FROM calendar_dates`)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for r.Next() {
+		v := date{}
+
+		if err = r.Scan(&v.CalendarUUID, &v.Date); err != nil {
+			return err
+		}
+
+		out.Encode(&v)
+	}
+	return nil
+}
+
 func (db *DB) exportFixups(out *json.Encoder) error {
 	db.exportHeader(out, "fixups")
 
@@ -216,12 +352,23 @@ func (db *DB) exportJobs(out *json.Encoder) error {
 		FixedKey   bool   `json:"fixed_key"`
 		Healthy    bool   `json:"healthy"`
 		Retries    int    `json:"retries"`
+
+		MaxRuntime  int    `json:"max_runtime"`
+		WindowStart string `json:"window_start"`
+		WindowEnd   string `json:"window_end"`
+		FinishBy    string `json:"finish_by"`
+		MissedRun   int64  `json:"missed_run"`
+
+		Jitter       int  `json:"jitter"`
+		HashedJitter bool `json:"hashed_jitter"`
 	}
 
 	r, err := db.query(`
 	  SELECT uuid, target_uuid, store_uuid, tenant_uuid,
 	         name, summary, schedule, keep_n, keep_days,
-	         next_run, priority, paused, fixed_key, healthy, retries
+	         next_run, priority, paused, fixed_key, healthy, retries,
+	         max_runtime, window_start, window_end, finish_by, missed_run,
+	         jitter, hashed_jitter
 	    FROM jobs`)
 	if err != nil {
 		return err
@@ -234,7 +381,9 @@ func (db *DB) exportJobs(out *json.Encoder) error {
 		if err = r.Scan(
 			&v.UUID, &v.TargetUUID, &v.StoreUUID, &v.TenantUUID,
 			&v.Name, &v.Summary, &v.Schedule, &v.KeepN, &v.KeepDays,
-			&v.NextRun, &v.Priority, &v.Paused, &v.FixedKey, &v.Healthy, &v.Retries); err != nil {
+			&v.NextRun, &v.Priority, &v.Paused, &v.FixedKey, &v.Healthy, &v.Retries,
+			&v.MaxRuntime, &v.WindowStart, &v.WindowEnd, &v.FinishBy, &v.MissedRun,
+			&v.Jitter, &v.HashedJitter); err != nil {
 
 			return err
 		}
@@ -251,10 +400,11 @@ func (db *DB) exportMemberships(out *json.Encoder) error {
 		TenantUUID string `json:"tenant_uuid"`
 		UserUUID   string `json:"user_uuid"`
 		Role       string `json:"role"`
+		External   bool   `json:"external"`
 	}
 
 	r, err := db.query(`
-	  SELECT user_uuid, tenant_uuid, role
+	  SELECT user_uuid, tenant_uuid, role, external
 	    FROM memberships`)
 	if err != nil {
 		return err
@@ -265,7 +415,39 @@ func (db *DB) exportMemberships(out *json.Encoder) error {
 		v := membership{}
 
 		if err = r.Scan(
-			&v.UserUUID, &v.TenantUUID, &v.Role); err != nil {
+			&v.UserUUID, &v.TenantUUID, &v.Role, &v.External); err != nil {
+
+			return err
+		}
+
+		out.Encode(&v)
+	}
+	return nil
+}
+
+func (db *DB) exportRoles(out *json.Encoder) error {
+	db.exportHeader(out, "roles")
+
+	type role struct {
+		TenantUUID  string `json:"tenant_uuid"`
+		Name        string `json:"name"`
+		Summary     string `json:"summary"`
+		Permissions string `json:"permissions"`
+	}
+
+	r, err := db.query(`
+	  SELECT tenant_uuid, name, summary, permissions
+	    FROM roles`)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for r.Next() {
+		v := role{}
+
+		if err = r.Scan(
+			&v.TenantUUID, &v.Name, &v.Summary, &v.Permissions); err != nil {
 
 			return err
 		}
@@ -449,10 +631,24 @@ func (db *DB) exportTenants(out *json.Encoder) error {
 		DailyIncrease *int   `json:"daily_increase"`
 		StorageUsed   *int   `json:"storage_used"`
 		ArchiveCount  *int   `json:"archive_count"`
+		Share         int    `json:"scheduler_share"`
+		Spread        int    `json:"spread_window"`
+		Approval      bool   `json:"require_approval"`
+		Timeout       int    `json:"approval_timeout"`
+		QuotaStorage  int64  `json:"quota_storage"`
+		QuotaArchives int    `json:"quota_archives"`
+		QuotaJobs     int    `json:"quota_jobs"`
+		QuotaTargets  int    `json:"quota_targets"`
+		QuotaTasks    int    `json:"quota_tasks"`
+		Enforce       bool   `json:"enforce_quotas"`
 	}
 
 	r, err := db.query(`
-	  SELECT uuid, name, daily_increase, storage_used, archive_count
+	  SELECT uuid, name, daily_increase, storage_used, archive_count,
+	         scheduler_share, spread_window,
+	         require_approval, approval_timeout,
+	         quota_storage, quota_archives, quota_jobs, quota_targets, quota_tasks,
+	         enforce_quotas
 	    FROM tenants`)
 	if err != nil {
 		return err
@@ -463,7 +659,10 @@ func (db *DB) exportTenants(out *json.Encoder) error {
 		v := tenant{}
 
 		if err = r.Scan(
-			&v.UUID, &v.Name, &v.DailyIncrease, &v.StorageUsed, &v.ArchiveCount); err != nil {
+			&v.UUID, &v.Name, &v.DailyIncrease, &v.StorageUsed, &v.ArchiveCount,
+			&v.Share, &v.Spread, &v.Approval, &v.Timeout,
+			&v.QuotaStorage, &v.QuotaArchives, &v.QuotaJobs, &v.QuotaTargets, &v.QuotaTasks,
+			&v.Enforce); err != nil {
 
 			return err
 		}
@@ -484,11 +683,15 @@ func (db *DB) exportUsers(out *json.Encoder) error {
 		PasswordHash  string `json:"password_hash"`
 		SystemRole    string `json:"system_role"`
 		DefaultTenant string `json:"default_tenant"`
+		MFASecret     string `json:"mfa_secret"`
+		MFAEnabled    bool   `json:"mfa_enabled"`
+		MFALastStep   int64  `json:"mfa_last_step"`
 	}
 
 	r, err := db.query(`
 	  SELECT uuid, name, account, backend,
-	         pwhash, sysrole, default_tenant
+	         pwhash, sysrole, default_tenant,
+	         mfa_secret, mfa_enabled, mfa_last_step
 	    FROM users`)
 	if err != nil {
 		return err
@@ -500,7 +703,8 @@ func (db *DB) exportUsers(out *json.Encoder) error {
 
 		if err = r.Scan(
 			&v.UUID, &v.Name, &v.Account, &v.Backend,
-			&v.PasswordHash, &v.SystemRole, &v.DefaultTenant); err != nil {
+			&v.PasswordHash, &v.SystemRole, &v.DefaultTenant,
+			&v.MFASecret, &v.MFAEnabled, &v.MFALastStep); err != nil {
 
 			return err
 		}
@@ -510,6 +714,35 @@ func (db *DB) exportUsers(out *json.Encoder) error {
 	return nil
 }
 
+func (db *DB) exportRecoveryCodes(out *json.Encoder) error {
+	db.exportHeader(out, "mfa_recovery_codes")
+
+	type code struct {
+		UserUUID string `json:"user_uuid"`
+		Hash     string `json:"hash"`
+		UsedAt   *int64 `json:"used_at"`
+	}
+
+	r, err := db.query(`
+	  SELECT user_uuid, hash, used_at
+	    FROM mfa_recovery_codes`)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for r.Next() {
+		v := code{}
+
+		if err = r.Scan(&v.UserUUID, &v.Hash, &v.UsedAt); err != nil {
+			return err
+		}
+
+		out.Encode(&v)
+	}
+	return nil
+}
+
 func (db *DB) exportSessions(out *json.Encoder) error {
 	db.exportHeader(out, "sessions")
 
@@ -524,11 +757,17 @@ func (db *DB) exportSessions(out *json.Encoder) error {
 		UserAgent      string `json:"user_agent"`
 		UserAccount    string `json:"user_account"`
 		CurrentSession bool   `json:"current_session"`
+		ScopeTenant    string `json:"scope_tenant"`
+		ScopeRole      string `json:"scope_role"`
+		ScopeOps       string `json:"scope_ops"`
+		ScopeAllow     string `json:"scope_allow"`
+		ExpiresAt      int64  `json:"expires_at"`
 	}
 
 	r, err := db.query(`
         SELECT uuid, user_uuid, created_at, last_seen,
-                token, name, ip_addr, user_agent
+                token, name, ip_addr, user_agent,
+                scope_tenant, scope_role, scope_ops, scope_allow, expires_at
             FROM sessions`)
 	if err != nil {
 		return err
@@ -542,7 +781,8 @@ func (db *DB) exportSessions(out *json.Encoder) error {
 			last  *int64
 			token sql.NullString
 		)
-		if err := r.Scan(&s.UUID, &s.UserUUID, &s.CreatedAt, &last, &token, &s.Name, &s.IP, &s.UserAgent); err != nil {
+		if err := r.Scan(&s.UUID, &s.UserUUID, &s.CreatedAt, &last, &token, &s.Name, &s.IP, &s.UserAgent,
+			&s.ScopeTenant, &s.ScopeRole, &s.ScopeOps, &s.ScopeAllow, &s.ExpiresAt); err != nil {
 			return err
 		}
 		if last != nil {
@@ -606,11 +846,31 @@ func (db *DB) Export(out *json.Encoder, vault *vault.Client, task_uuid string) {
 			db.exportErrors(out, err)
 		}
 
+		err = db.exportBlackouts(out)
+		if err != nil {
+			db.exportErrors(out, err)
+		}
+
+		err = db.exportCalendars(out)
+		if err != nil {
+			db.exportErrors(out, err)
+		}
+
+		err = db.exportCalendarDates(out)
+		if err != nil {
+			db.exportErrors(out, err)
+		}
+
 		err = db.exportArchives(out, vault)
 		if err != nil {
 			db.exportErrors(out, err)
 		}
 
+		err = db.exportArchiveLabels(out)
+		if err != nil {
+			db.exportErrors(out, err)
+		}
+
 		// we might get some additional information out
 		// of exportTasks, based on our current task_uuid
 		// and the running tasks.
@@ -627,11 +887,21 @@ func (db *DB) Export(out *json.Encoder, vault *vault.Client, task_uuid string) {
 			db.exportErrors(out, err)
 		}
 
+		err = db.exportRecoveryCodes(out)
+		if err != nil {
+			db.exportErrors(out, err)
+		}
+
 		err = db.exportMemberships(out)
 		if err != nil {
 			db.exportErrors(out, err)
 		}
 
+		err = db.exportRoles(out)
+		if err != nil {
+			db.exportErrors(out, err)
+		}
+
 		err = db.exportSessions(out)
 		if err != nil {
 			db.exportErrors(out, err)
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	// sql drivers
+	_ "github.com/mattn/go-sqlite3"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/shieldproject/shield/lib/totp"
+)
+
+var _ = Describe("Export and Import", func() {
+	var (
+		db   *DB
+		user *User
+	)
+
+	BeforeEach(func() {
+		var err error
+		db, err = Database()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		user, err = db.CreateUser(&User{Name: "CI", Account: "ci", Backend: "local"})
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	/* export everything, and import it into a brand new database */
+	restore := func() *DB {
+		var buf bytes.Buffer
+		db.Export(json.NewEncoder(&buf), nil, "")
+		Ω(buf.String()).ShouldNot(ContainSubstring(`"error"`))
+
+		restored, err := Database()
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(restored.Import(json.NewDecoder(&buf), nil, "", "")).Should(Succeed())
+		return restored
+	}
+
+	It("keeps track of which tenant roles were granted by hand", func() {
+		tenant, err := db.CreateTenant(&Tenant{Name: "acme"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(db.AddUserToTenant(user.UUID, tenant.UUID, "operator")).Should(Succeed())
+
+		restored := restore()
+		Ω(restored.ClearExternalMembershipsFor(user)).Should(Succeed())
+		l, err := restored.GetMembershipsForUser(user.UUID)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(l).Should(HaveLen(1))
+
+		added, err := restored.AddExternalUserToTenant(user.UUID, tenant.UUID, "admin")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(added).Should(BeFalse())
+	})
+
+	It("keeps the scope and expiry of auth tokens", func() {
+		expires := time.Now().Add(24 * time.Hour)
+		scope := TokenScope{
+			Tenant:    RandomID(),
+			Role:      "operator",
+			Ops:       []string{"run-job", "tasks"},
+			Allow:     []string{"10.8.0.0/16"},
+			ExpiresAt: expires.Unix(),
+		}
+		t, _, err := db.GenerateAuthToken("ci", user, scope)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		session, err := restore().GetSession(t.Session)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(session).ShouldNot(BeNil())
+		Ω(session.Scope).Should(Equal(scope))
+		Ω(session.Scope.Unlimited()).Should(BeFalse())
+		Ω(session.Scope.Allows("192.0.2.7")).Should(BeFalse())
+		Ω(session.Scope.Expired(expires.Add(time.Minute))).Should(BeTrue())
+	})
+
+	It("keeps local users' multi-factor authentication", func() {
+		secret, err := totp.NewSecret()
+		Ω(err).ShouldNot(HaveOccurred())
+		code := func(offset int64) string {
+			c, err := totp.Code(secret, totp.Step(time.Now())+offset)
+			Ω(err).ShouldNot(HaveOccurred())
+			return c
+		}
+
+		Ω(db.StartMFA(user, secret)).Should(Succeed())
+		user, err = db.GetUserByID(user.UUID)
+		Ω(err).ShouldNot(HaveOccurred())
+		recovery, ok, err := db.ConfirmMFA(user, code(-1))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(ok).Should(BeTrue())
+
+		ok, err = db.CheckMFA(user, recovery[0])
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(ok).Should(BeTrue())
+
+		restored := restore()
+		u, err := restored.GetUserByID(user.UUID)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(u).ShouldNot(BeNil())
+		Ω(u.MFAEnabled).Should(BeTrue())
+		Ω(restored.CountRecoveryCodes(u)).Should(Equal(MFARecoveryCodes - 1))
+
+		/* used codes stay used */
+		ok, err = restored.CheckMFA(u, code(-1))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(ok).Should(BeFalse())
+		ok, err = restored.CheckMFA(u, recovery[0])
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(ok).Should(BeFalse())
+
+		ok, err = restored.CheckMFA(u, code(0))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(ok).Should(BeTrue())
+		ok, err = restored.CheckMFA(u, recovery[1])
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(ok).Should(BeTrue())
+	})
+})
@@ -79,6 +79,12 @@ func (db *DB) importArchives(n uint, in *json.Decoder, vlt *vault.Client) error
 		Job            string `json:"jobs"`
 		EncryptionType string `json:"encryption_type"`
 		Compression    string `json:"compression"`
+		Held           bool   `json:"held"`
+		HoldReason     string `json:"hold_reason"`
+		HeldBy         string `json:"held_by"`
+		HeldAt         int64  `json:"held_at"`
+		HoldUntil      int64  `json:"hold_until"`
+		PurgeAfter     int64  `json:"purge_after"`
 		EncryptionKey  string `json:"encryption_key"`
 		EncryptionIV   string `json:"encryption_iv"`
 		Error          string `json:"error"`
@@ -99,14 +105,20 @@ func (db *DB) importArchives(n uint, in *json.Decoder, vlt *vault.Client) error
 		  INSERT INTO archives
 		    (uuid, tenant_uuid, target_uuid, store_uuid,
 		     store_key, taken_at, expires_at, notes, purge_reason,
-		     status, size, job, encryption_type, compression)
+		     status, size, job, encryption_type, compression,
+		     held, hold_reason, held_by, held_at, hold_until,
+		     purge_after)
 		  VALUES
 		    (?, ?, ?, ?,
 		     ?, ?, ?, ?, ?,
-		     ?, ?, ?, ?, ?)`,
+		     ?, ?, ?, ?, ?,
+		     ?, ?, ?, ?, ?,
+		     ?)`,
 			v.UUID, v.TenantUUID, v.TargetUUID, v.StoreUUID,
 			v.StoreKey, v.TakenAt, v.ExpiresAt, v.Notes, v.PurgeReason,
-			v.Status, v.Size, v.Job, v.EncryptionType, v.Compression)
+			v.Status, v.Size, v.Job, v.EncryptionType, v.Compression,
+			v.Held, v.HoldReason, v.HeldBy, v.HeldAt, v.HoldUntil,
+			v.PurgeAfter)
 		if err != nil {
 			return err
 		}
@@ -123,6 +135,143 @@ func (db *DB) importArchives(n uint, in *json.Decoder, vlt *vault.Client) error
 	return nil
 }
 
+func (db *DB) importArchiveLabels(n uint, in *json.Decoder) error {
+	type label struct {
+		ArchiveUUID string `json:"archive_uuid"`
+		Name        string `json:"name"`
+		Value       string `json:"value"`
+		Error       string `json:"error"`
+	}
+
+	for ; n > 0; n-- {
+		var v label
+		if err := in.Decode(&v); err != nil {
+			return err
+		}
+
+		if v.Error != "" {
+			return fmt.Errorf(v.Error)
+		}
+
+		log.Infof("IMPORT: inserting label %s for archive %s...", v.Name, v.ArchiveUUID)
+		err := db.exec(`
+		  INSERT INTO archive_labels
+		    (archive_uuid, name, value)
+		  VALUES
+		    (?, ?, ?)`,
+			v.ArchiveUUID, v.Name, v.Value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *DB) importBlackouts(n uint, in *json.Decoder) error {
+	type blackout struct {
+		UUID       string `json:"uuid"`
+		TenantUUID string `json:"tenant_uuid"`
+		TargetUUID string `json:"target_uuid"`
+		Name       string `json:"name"`
+		Summary    string `json:"summary"`
+		Schedule   string `json:"schedule"`
+		Duration   int    `json:"duration"`
+		StartsAt   int64  `json:"starts_at"`
+		EndsAt     int64  `json:"ends_at"`
+		CatchUp    bool   `json:"catch_up"`
+		Error      string `json:"error"`
+	}
+
+	for ; n > 0; n-- {
+		var v blackout
+		if err := in.Decode(&v); err != nil {
+			return err
+		}
+
+		if v.Error != "" {
+			return fmt.Errorf(v.Error)
+		}
+
+		log.Infof("IMPORT: inserting blackout %s...", v.UUID)
+		err := db.exec(`
+		  INSERT INTO blackouts
+		    (uuid, tenant_uuid, target_uuid, name, summary,
+		     schedule, duration, starts_at, ends_at, catch_up)
+		  VALUES
+		    (?, ?, ?, ?, ?,
+		     ?, ?, ?, ?, ?)`,
+			v.UUID, v.TenantUUID, v.TargetUUID, v.Name, v.Summary,
+			v.Schedule, v.Duration, v.StartsAt, v.EndsAt, v.CatchUp)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *DB) importCalendars(n uint, in *json.Decoder) error {
+	type calendar struct {
+		UUID    string `json:"uuid"`
+		Name    string `json:"name"`
+		Summary string `json:"summary"`
+		Error   string `json:"error"`
+	}
+
+	for ; n > 0; n-- {
+		var v calendar
+		if err := in.Decode(&v); err != nil {
+			return err
+		}
+
+		if v.Error != "" {
+			return fmt.Errorf(v.Error)
+		}
+
+		log.Infof("IMPORT: inserting calendar %s...", v.UUID)
+		err := db.exec(`
+		  INSERT INTO calendars
+		    (uuid, name, summary)
+		  VALUES
+		    (?, ?, ?)`,
+			v.UUID, v.Name, v.Summary)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *DB) importCalendarDates(n uint, in *json.Decoder) error {
+	type date struct {
+		CalendarUUID string `json:"calendar_uuid"`
+		Date         string `json:"date"`
+		Error        string `json:"error"`
+	}
+
+	for ; n > 0; n-- {
+		var v date
+		if err := in.Decode(&v); err != nil {
+			return err
+		}
+
+		if v.Error != "" {
+			return fmt.Errorf(v.Error)
+		}
+
+		log.Infof("IMPORT: inserting calendar date %s for calendar %s...", v.Date, v.CalendarUUID)
+		err := db.exec(`
+		  INSERT INTO calendar_dates
+		    (calendar_uuid, date)
+		  VALUES
+		    (?, ?)`,
+			v.CalendarUUID, v.Date)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (db *DB) importFixups(n uint, in *json.Decoder) error {
 	type fixup struct {
 		ID        string `json:"id"`
@@ -175,6 +324,15 @@ func (db *DB) importJobs(n uint, in *json.Decoder) error {
 		Healthy    bool   `json:"healthy"`
 		Error      string `json:"error"`
 		Retries    int    `json:"retries"`
+
+		MaxRuntime  int    `json:"max_runtime"`
+		WindowStart string `json:"window_start"`
+		WindowEnd   string `json:"window_end"`
+		FinishBy    string `json:"finish_by"`
+		MissedRun   int64  `json:"missed_run"`
+
+		Jitter       int  `json:"jitter"`
+		HashedJitter bool `json:"hashed_jitter"`
 	}
 
 	for ; n > 0; n-- {
@@ -192,14 +350,20 @@ func (db *DB) importJobs(n uint, in *json.Decoder) error {
 		  INSERT INTO jobs
 		    (uuid, target_uuid, store_uuid, tenant_uuid,
 		     name, summary, schedule, keep_n, keep_days,
-		     next_run, priority, paused, fixed_key, healthy, retries)
+		     next_run, priority, paused, fixed_key, healthy, retries,
+		     max_runtime, window_start, window_end, finish_by, missed_run,
+		     jitter, hashed_jitter)
 		  VALUES
 		    (?, ?, ?, ?,
 		     ?, ?, ?, ?, ?,
-		     ?, ?, ?, ?, ?, ?)`,
+		     ?, ?, ?, ?, ?, ?,
+		     ?, ?, ?, ?, ?,
+		     ?, ?)`,
 			v.UUID, v.TargetUUID, v.StoreUUID, v.TenantUUID,
 			v.Name, v.Summary, v.Schedule, v.KeepN, v.KeepDays,
-			v.NextRun, v.Priority, v.Paused, v.FixedKey, v.Healthy, v.Retries)
+			v.NextRun, v.Priority, v.Paused, v.FixedKey, v.Healthy, v.Retries,
+			v.MaxRuntime, v.WindowStart, v.WindowEnd, v.FinishBy, v.MissedRun,
+			v.Jitter, v.HashedJitter)
 		if err != nil {
 			return err
 		}
@@ -212,6 +376,7 @@ func (db *DB) importMemberships(n uint, in *json.Decoder) error {
 		TenantUUID string `json:"tenant_uuid"`
 		UserUUID   string `json:"user_uuid"`
 		Role       string `json:"role"`
+		External   *bool  `json:"external"`
 		Error      string `json:"error"`
 	}
 
@@ -225,12 +390,58 @@ func (db *DB) importMemberships(n uint, in *json.Decoder) error {
 			return fmt.Errorf(v.Error)
 		}
 
+		/* older exports don't say; there, auth providers could only
+		   have assigned built-in roles, to users that aren't local. */
+		if v.External == nil {
+			external := false
+			if IsBuiltinRole(v.Role) {
+				var err error
+				external, err = db.exists(`SELECT uuid FROM users WHERE uuid = ? AND backend != 'local'`, v.UserUUID)
+				if err != nil {
+					return err
+				}
+			}
+			v.External = &external
+		}
+
 		err := db.exec(`
 		  INSERT INTO memberships
-		    (user_uuid, tenant_uuid, role)
+		    (user_uuid, tenant_uuid, role, external)
 		  VALUES
-		    (?, ?, ?)`,
-			v.UserUUID, v.TenantUUID, v.Role)
+		    (?, ?, ?, ?)`,
+			v.UserUUID, v.TenantUUID, v.Role, *v.External)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *DB) importRoles(n uint, in *json.Decoder) error {
+	type role struct {
+		TenantUUID  string `json:"tenant_uuid"`
+		Name        string `json:"name"`
+		Summary     string `json:"summary"`
+		Permissions string `json:"permissions"`
+		Error       string `json:"error"`
+	}
+
+	for ; n > 0; n-- {
+		var v role
+		if err := in.Decode(&v); err != nil {
+			return err
+		}
+
+		if v.Error != "" {
+			return fmt.Errorf(v.Error)
+		}
+
+		err := db.exec(`
+		  INSERT INTO roles
+		    (tenant_uuid, name, summary, permissions)
+		  VALUES
+		    (?, ?, ?, ?)`,
+			v.TenantUUID, v.Name, v.Summary, v.Permissions)
 		if err != nil {
 			return err
 		}
@@ -379,7 +590,7 @@ func (db *DB) importTasks(n uint, in *json.Decoder) error {
 			at := time.Now().Unix()
 			v.StoppedAt = &at
 		}
-		if v.Status == "done" || v.Status == "failed" || v.Status == "canceled" {
+		if v.Status == "done" || v.Status == "failed" || v.Status == "canceled" || v.Status == "overrun" {
 			log.Infof("IMPORT: inserting task %s... ", v.UUID)
 			err := db.exec(`
             INSERT INTO tasks
@@ -422,6 +633,16 @@ func (db *DB) importTenants(n uint, in *json.Decoder) error {
 		DailyIncrease *int   `json:"daily_increase"`
 		StorageUsed   *int   `json:"storage_used"`
 		ArchiveCount  *int   `json:"archive_count"`
+		Share         int    `json:"scheduler_share"`
+		Spread        int    `json:"spread_window"`
+		Approval      bool   `json:"require_approval"`
+		Timeout       int    `json:"approval_timeout"`
+		QuotaStorage  int64  `json:"quota_storage"`
+		QuotaArchives int    `json:"quota_archives"`
+		QuotaJobs     int    `json:"quota_jobs"`
+		QuotaTargets  int    `json:"quota_targets"`
+		QuotaTasks    int    `json:"quota_tasks"`
+		Enforce       bool   `json:"enforce_quotas"`
 		Error         string `json:"error"`
 	}
 
@@ -435,16 +656,35 @@ func (db *DB) importTenants(n uint, in *json.Decoder) error {
 			return fmt.Errorf(v.Error)
 		}
 
+		if v.Share < 1 {
+			v.Share = 1 /* older exports predate fair-share scheduling */
+		}
+		if v.Timeout < 1 {
+			v.Timeout = DefaultApprovalTimeout /* ... and approvals, too */
+		}
+
 		log.Infof("IMPORT: inserting tenant %s...", v.UUID)
 		err := db.exec(`
 		  INSERT INTO tenants
 		    (uuid, name,
-		     daily_increase, storage_used, archive_count)
+		     daily_increase, storage_used, archive_count,
+		     scheduler_share, spread_window,
+		     require_approval, approval_timeout,
+		     quota_storage, quota_archives, quota_jobs, quota_targets, quota_tasks,
+		     enforce_quotas)
 		  VALUES
 		    (?, ?,
-		     ?, ?, ?)`,
+		     ?, ?, ?,
+		     ?, ?,
+		     ?, ?,
+		     ?, ?, ?, ?, ?,
+		     ?)`,
 			v.UUID, v.Name,
-			v.DailyIncrease, v.StorageUsed, v.ArchiveCount)
+			v.DailyIncrease, v.StorageUsed, v.ArchiveCount,
+			v.Share, v.Spread,
+			v.Approval, v.Timeout,
+			v.QuotaStorage, v.QuotaArchives, v.QuotaJobs, v.QuotaTargets, v.QuotaTasks,
+			v.Enforce)
 		if err != nil {
 			return err
 		}
@@ -461,6 +701,9 @@ func (db *DB) importUsers(n uint, in *json.Decoder) error {
 		PasswordHash  string `json:"password_hash"`
 		SystemRole    string `json:"system_role"`
 		DefaultTenant string `json:"default_tenant"`
+		MFASecret     string `json:"mfa_secret"`
+		MFAEnabled    bool   `json:"mfa_enabled"`
+		MFALastStep   int64  `json:"mfa_last_step"`
 		Error         string `json:"error"`
 	}
 
@@ -478,12 +721,46 @@ func (db *DB) importUsers(n uint, in *json.Decoder) error {
 		err := db.exec(`
 		  INSERT INTO users
 		    (uuid, name, account, backend,
-		     pwhash, sysrole, default_tenant)
+		     pwhash, sysrole, default_tenant,
+		     mfa_secret, mfa_enabled, mfa_last_step)
 		  VALUES
 		    (?, ?, ?, ?,
+		     ?, ?, ?,
 		     ?, ?, ?)`,
 			v.UUID, v.Name, v.Account, v.Backend,
-			v.PasswordHash, v.SystemRole, v.DefaultTenant)
+			v.PasswordHash, v.SystemRole, v.DefaultTenant,
+			v.MFASecret, v.MFAEnabled, v.MFALastStep)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *DB) importRecoveryCodes(n uint, in *json.Decoder) error {
+	type code struct {
+		UserUUID string `json:"user_uuid"`
+		Hash     string `json:"hash"`
+		UsedAt   *int64 `json:"used_at"`
+		Error    string `json:"error"`
+	}
+
+	for ; n > 0; n-- {
+		var v code
+		if err := in.Decode(&v); err != nil {
+			return err
+		}
+
+		if v.Error != "" {
+			return fmt.Errorf(v.Error)
+		}
+
+		err := db.exec(`
+		  INSERT INTO mfa_recovery_codes
+		    (user_uuid, hash, used_at)
+		  VALUES
+		    (?, ?, ?)`,
+			v.UserUUID, v.Hash, v.UsedAt)
 		if err != nil {
 			return err
 		}
@@ -503,6 +780,11 @@ func (db *DB) importSessions(n uint, in *json.Decoder) error {
 		UserAgent      string `json:"user_agent"`
 		UserAccount    string `json:"user_account"`
 		CurrentSession bool   `json:"current_session"`
+		ScopeTenant    string `json:"scope_tenant"`
+		ScopeRole      string `json:"scope_role"`
+		ScopeOps       string `json:"scope_ops"`
+		ScopeAllow     string `json:"scope_allow"`
+		ExpiresAt      int64  `json:"expires_at"`
 		Error          string `json:"error"`
 	}
 
@@ -520,12 +802,15 @@ func (db *DB) importSessions(n uint, in *json.Decoder) error {
 		err := db.exec(`
           INSERT INTO sessions
             (uuid, user_uuid, created_at, last_seen,
-             token, name, ip_addr, user_agent)
+             token, name, ip_addr, user_agent,
+             scope_tenant, scope_role, scope_ops, scope_allow, expires_at)
           VALUES
              (?, ?, ?, ?,
-              ?, ?, ?, ?)`,
+              ?, ?, ?, ?,
+              ?, ?, ?, ?, ?)`,
 			v.UUID, v.UserUUID, v.CreatedAt, v.LastSeen,
-			v.Token, v.Name, v.IP, v.UserAgent)
+			v.Token, v.Name, v.IP, v.UserAgent,
+			v.ScopeTenant, v.ScopeRole, v.ScopeOps, v.ScopeAllow, v.ExpiresAt)
 		if err != nil {
 			return err
 		}
@@ -646,11 +931,11 @@ func (db *DB) Import(in *json.Decoder, vault *vault.Client, restoreKey, uuid str
 	}
 
 	err = db.transactionally(func() error {
-		err = db.clear("agents", "archives", "fixups", "jobs", "memberships", "stores")
+		err = db.clear("agents", "approvals", "archives", "fixups", "jobs", "memberships", "roles", "stores")
 		if err != nil {
 			return err
 		}
-		err = db.clear("targets", "tasks", "tenants", "users")
+		err = db.clear("targets", "tasks", "tenants", "users", "mfa_recovery_codes")
 		if err != nil {
 			return err
 		}
@@ -658,6 +943,14 @@ func (db *DB) Import(in *json.Decoder, vault *vault.Client, restoreKey, uuid str
 		if err != nil {
 			return err
 		}
+		err = db.clear("blackouts", "calendars", "calendar_dates")
+		if err != nil {
+			return err
+		}
+		err = db.clear("archive_labels", "archive_terms")
+		if err != nil {
+			return err
+		}
 
 		for in.More() {
 			if err := in.Decode(&h); err != nil {
@@ -678,6 +971,26 @@ func (db *DB) Import(in *json.Decoder, vault *vault.Client, restoreKey, uuid str
 					return err
 				}
 
+			case "archive_labels":
+				if err := db.importArchiveLabels(h.N, in); err != nil {
+					return err
+				}
+
+			case "blackouts":
+				if err := db.importBlackouts(h.N, in); err != nil {
+					return err
+				}
+
+			case "calendars":
+				if err := db.importCalendars(h.N, in); err != nil {
+					return err
+				}
+
+			case "calendar_dates":
+				if err := db.importCalendarDates(h.N, in); err != nil {
+					return err
+				}
+
 			case "fixups":
 				if err := db.importFixups(h.N, in); err != nil {
 					return err
@@ -693,6 +1006,11 @@ func (db *DB) Import(in *json.Decoder, vault *vault.Client, restoreKey, uuid str
 					return err
 				}
 
+			case "roles":
+				if err := db.importRoles(h.N, in); err != nil {
+					return err
+				}
+
 			case "stores":
 				if err := db.importStores(h.N, in); err != nil {
 					return err
@@ -718,6 +1036,11 @@ func (db *DB) Import(in *json.Decoder, vault *vault.Client, restoreKey, uuid str
 					return err
 				}
 
+			case "mfa_recovery_codes":
+				if err := db.importRecoveryCodes(h.N, in); err != nil {
+					return err
+				}
+
 			case "sessions":
 				if err := db.importSessions(h.N, in); err != nil {
 					return err
@@ -741,7 +1064,8 @@ func (db *DB) Import(in *json.Decoder, vault *vault.Client, restoreKey, uuid str
 			}
 		}
 
-		return nil
+		/* the search index is rebuilt, rather than exported */
+		return db.reindexAllArchives()
 	})
 	if err != nil {
 		return err
@@ -3,6 +3,7 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -24,6 +25,16 @@ type Job struct {
 	Paused   bool   `json:"paused"    mbus:"paused"`
 	FixedKey bool   `json:"fixed_key" mbus:"fixed_key"`
 
+	MaxRuntime  int    `json:"max_runtime"  mbus:"max_runtime"`
+	WindowStart string `json:"window_start" mbus:"window_start"`
+	WindowEnd   string `json:"window_end"   mbus:"window_end"`
+	FinishBy    string `json:"finish_by"    mbus:"finish_by"`
+
+	Jitter       int  `json:"jitter"        mbus:"jitter"`
+	HashedJitter bool `json:"hashed_jitter" mbus:"hashed_jitter"`
+
+	MissedRun int64 `json:"missed_run" mbus:"missed_run"`
+
 	Target struct {
 		UUID        string `json:"uuid"`
 		Name        string `json:"name"`
@@ -63,6 +74,7 @@ type JobFilter struct {
 	SkipUnpaused bool
 
 	Overdue bool
+	Missed  bool
 
 	SearchName string
 
@@ -127,6 +139,9 @@ func (f *JobFilter) Query() (string, []interface{}) {
 		wheres = append(wheres, "j.next_run <= ?")
 		args = append(args, time.Now().Unix())
 	}
+	if f.Missed {
+		wheres = append(wheres, "j.missed_run > 0")
+	}
 
 	return `
 	   WITH recent_tasks AS (
@@ -138,6 +153,8 @@ func (f *JobFilter) Query() (string, []interface{}) {
 
 	   SELECT j.uuid, j.name, j.summary, j.paused, j.schedule,
 	          j.tenant_uuid, j.fixed_key, j.healthy, j.keep_n, j.keep_days, j.retries,
+	          j.max_runtime, j.window_start, j.window_end, j.finish_by, j.missed_run,
+	          j.jitter, j.hashed_jitter,
 	          s.uuid, s.name, s.plugin, s.endpoint, s.summary, s.healthy,
 	          t.uuid, t.name, t.plugin, t.endpoint, t.agent, t.compression,
 	          k.started_at, k.status
@@ -177,6 +194,8 @@ func (db *DB) GetAllJobs(filter *JobFilter) ([]*Job, error) {
 		if err = r.Scan(
 			&j.UUID, &j.Name, &j.Summary, &j.Paused, &j.Schedule,
 			&j.TenantUUID, &j.FixedKey, &j.Healthy, &j.KeepN, &j.KeepDays, &j.Retries,
+			&j.MaxRuntime, &j.WindowStart, &j.WindowEnd, &j.FinishBy, &j.MissedRun,
+			&j.Jitter, &j.HashedJitter,
 			&j.Store.UUID, &j.Store.Name, &j.Store.Plugin, &j.Store.Endpoint, &j.Store.Summary, &j.Store.Healthy,
 			&j.Target.UUID, &j.Target.Name, &j.Target.Plugin, &j.Target.Endpoint,
 			&j.Agent, &j.Target.Compression, &last, &status); err != nil {
@@ -387,13 +406,19 @@ func (db *DB) CreateJob(job *Job) (*Job, error) {
 		return db.exec(`
 		   INSERT INTO jobs (uuid, tenant_uuid,
 		                     name, summary, schedule, keep_n, keep_days, paused,
-		                     target_uuid, store_uuid, fixed_key, healthy, retries)
+		                     target_uuid, store_uuid, fixed_key, healthy, retries,
+		                     max_runtime, window_start, window_end, finish_by,
+		                     jitter, hashed_jitter)
 		             VALUES (?, ?,
 		                     ?, ?, ?, ?, ?, ?,
-		                     ?, ?, ?, ?, ?)`,
+		                     ?, ?, ?, ?, ?,
+		                     ?, ?, ?, ?,
+		                     ?, ?)`,
 			job.UUID, job.TenantUUID,
 			job.Name, job.Summary, job.Schedule, job.KeepN, job.KeepDays, job.Paused,
-			job.TargetUUID, job.StoreUUID, job.FixedKey, job.Healthy, job.Retries)
+			job.TargetUUID, job.StoreUUID, job.FixedKey, job.Healthy, job.Retries,
+			job.MaxRuntime, job.WindowStart, job.WindowEnd, job.FinishBy,
+			job.Jitter, job.HashedJitter)
 	})
 	if err != nil {
 		return nil, err
@@ -437,10 +462,18 @@ func (db *DB) UpdateJob(job *Job) error {
 		          target_uuid    = ?,
 		          store_uuid     = ?,
 		          fixed_key      = ?,
-				  retries        = ?
+				  retries        = ?,
+		          max_runtime    = ?,
+		          window_start   = ?,
+		          window_end     = ?,
+		          finish_by      = ?,
+		          jitter         = ?,
+		          hashed_jitter  = ?
 		    WHERE uuid = ?`,
 			job.Name, job.Summary, job.Schedule, job.KeepN, job.KeepDays,
 			job.TargetUUID, job.StoreUUID, job.FixedKey, job.Retries,
+			job.MaxRuntime, job.WindowStart, job.WindowEnd, job.FinishBy,
+			job.Jitter, job.HashedJitter,
 			job.UUID)
 	})
 	if err != nil {
@@ -516,6 +549,183 @@ func (db *DB) RescheduleJob(j *Job, t time.Time) error {
 	return db.Exec(`UPDATE jobs SET next_run = ? WHERE uuid = ?`, t.Unix(), j.UUID)
 }
 
+// JobTimespec parses the job's schedule, and applies its jitter, or
+// its tenant's spread, to the resulting Spec.
+//
+// When the tenant has a spread window, all of the tenant's jobs that
+// run on the same schedule are spread evenly across that window, in
+// UUID order, and any per-job jitter is ignored.  Otherwise, jobs
+// with a hashed jitter get a stable offset within their jitter
+// window, and the rest get a random delay, different for every run.
+func (db *DB) JobTimespec(job *Job) (*timespec.Spec, error) {
+	specs, err := db.JobTimespecs([]*Job{job})
+	if err != nil {
+		return nil, err
+	}
+	return specs[job.UUID], nil
+}
+
+// JobTimespecs works out the Spec (see JobTimespec) for each of the
+// given jobs, keyed by job UUID.  Each tenant, and each spreading
+// tenant's set of jobs, is only looked up once, no matter how many of
+// its jobs are in the list.
+func (db *DB) JobTimespecs(jobs []*Job) (map[string]*timespec.Spec, error) {
+	specs := make(map[string]*timespec.Spec)
+	cache := db.timespecs()
+	for _, job := range jobs {
+		spec, err := cache.of(job)
+		if err != nil {
+			return nil, err
+		}
+		specs[job.UUID] = spec
+	}
+	return specs, nil
+}
+
+// ScheduleJob works out the next run of the job after the given
+// time, jitter and spread included, and reschedules the job for then.
+func (db *DB) ScheduleJob(job *Job, from time.Time) error {
+	return db.ScheduleJobs([]*Job{job}, from)
+}
+
+// ScheduleJobs reschedules each of the given jobs, as ScheduleJob
+// does, looking up each tenant's spread only once.  It carries on
+// past jobs that cannot be scheduled, and returns the first error.
+func (db *DB) ScheduleJobs(jobs []*Job, from time.Time) error {
+	var failed error
+	cache := db.timespecs()
+	for _, job := range jobs {
+		spec, err := cache.of(job)
+		if err == nil {
+			var next time.Time
+			if next, err = spec.Next(from); err == nil {
+				err = db.RescheduleJob(job, next)
+			}
+		}
+		if err != nil && failed == nil {
+			failed = fmt.Errorf("job %s [%s]: %s", job.Name, job.UUID, err)
+		}
+	}
+	return failed
+}
+
+// jobTimespecs remembers tenants, and how their jobs are grouped by
+// schedule, across the working out of several jobs' Specs.
+type jobTimespecs struct {
+	db      *DB
+	tenants map[string]*Tenant
+	peers   map[string]map[string][]string /* tenant -> schedule -> sorted job uuids */
+}
+
+func (db *DB) timespecs() *jobTimespecs {
+	return &jobTimespecs{
+		db:      db,
+		tenants: make(map[string]*Tenant),
+		peers:   make(map[string]map[string][]string),
+	}
+}
+
+func (c *jobTimespecs) of(job *Job) (*timespec.Spec, error) {
+	spec, err := timespec.Parse(job.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.db.ResolveExclusions(spec); err != nil {
+		return nil, err
+	}
+
+	tenant, seen := c.tenants[job.TenantUUID]
+	if !seen {
+		tenant, err = c.db.GetTenant(job.TenantUUID)
+		if err != nil {
+			return nil, err
+		}
+		if tenant != nil && tenant.Spread > 0 {
+			c.peers[job.TenantUUID], err = c.db.jobsBySchedule(job.TenantUUID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		c.tenants[job.TenantUUID] = tenant
+	}
+
+	if tenant != nil && tenant.Spread > 0 {
+		/* the job may not be in the database yet, or may be
+		   there under a schedule it is about to give up. */
+		l := c.peers[job.TenantUUID][spec.String()]
+		i, n := sort.SearchStrings(l, job.UUID), len(l)
+		if i == n || l[i] != job.UUID {
+			n++
+		}
+		spec.Offset = timespec.SpreadOffset(i, n, time.Duration(tenant.Spread)*time.Minute)
+		return spec, nil
+	}
+
+	if job.Jitter > 0 {
+		window := time.Duration(job.Jitter) * time.Minute
+		if job.HashedJitter {
+			spec.Offset = timespec.HashedOffset(job.UUID, window)
+		} else {
+			spec.Jitter = window
+		}
+	}
+	return spec, nil
+}
+
+// jobsBySchedule groups the UUIDs of all of a tenant's jobs by their
+// (normalized) schedule, sorted, for spreading them out.
+func (db *DB) jobsBySchedule(tenant string) (map[string][]string, error) {
+	jobs, err := db.GetAllJobs(&JobFilter{ForTenant: tenant})
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string][]string)
+	for _, job := range jobs {
+		if s, err := timespec.Parse(job.Schedule); err == nil {
+			m[s.String()] = append(m[s.String()], job.UUID)
+		}
+	}
+	for _, l := range m {
+		sort.Strings(l)
+	}
+	return m, nil
+}
+
+// RespreadJobs reschedules all of a tenant's jobs, if that tenant
+// spreads its jobs out, so that they move into the slots left for
+// them after a job is added, removed, or changes schedule.
+func (db *DB) RespreadJobs(tenant string, from time.Time) error {
+	t, err := db.GetTenant(tenant)
+	if err != nil {
+		return err
+	}
+	if t == nil || t.Spread <= 0 {
+		return nil
+	}
+
+	jobs, err := db.GetAllJobs(&JobFilter{ForTenant: tenant})
+	if err != nil {
+		return err
+	}
+	return db.ScheduleJobs(jobs, from)
+}
+
+// MissJob records that a scheduled run of the given job was skipped
+// because of a blackout, so that it can be caught up later.  Only the
+// earliest missed run is remembered.
+func (db *DB) MissJob(id string, at time.Time) error {
+	/* note: this update does not require a message bus notification */
+	return db.Exec(`UPDATE jobs SET missed_run = ? WHERE uuid = ? AND missed_run = 0`, at.Unix(), id)
+}
+
+// CatchUpJob clears the record of a missed run, once the job has
+// been caught up (or no longer needs to be).
+func (db *DB) CatchUpJob(id string) error {
+	/* note: this update does not require a message bus notification */
+	return db.Exec(`UPDATE jobs SET missed_run = 0 WHERE uuid = ?`, id)
+}
+
 func (j *Job) Reschedule() error {
 	var err error
 	if j.Spec == nil {
@@ -532,6 +742,12 @@ func (j *Job) Reschedule() error {
 	return nil
 }
 
+// Window returns the execution window (and finish-by deadline)
+// that this job's backup tasks must run within.
+func (j *Job) Window() (*timespec.Window, error) {
+	return timespec.ParseWindow(j.WindowStart, j.WindowEnd, j.FinishBy)
+}
+
 func (j *Job) Runnable() bool {
 	return !j.Paused && j.NextRun <= time.Now().Unix()
 }
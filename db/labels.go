@@ -0,0 +1,243 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Archives can be labeled with key/value pairs, like env=prod, to make
+// them easier to find later on.  Every archive taken by a backup job is
+// labeled with the name of the job, its target system (and plugin),
+// and its cloud storage system; operators can add their own labels, or
+// change these, through the API.
+//
+// Labels and notes are broken up into search terms, and kept in the
+// archive_terms table, which is indexed by term, so that searching
+// through many thousands of archives does not have to scan them all.
+
+var labelName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// ValidateLabels checks that all of the given label names are usable
+// in a --label name=value filter.
+func ValidateLabels(labels map[string]string) error {
+	for name := range labels {
+		if !labelName.MatchString(name) {
+			return fmt.Errorf("invalid label name '%s' (names may only contain letters, numbers, dots, dashes and underscores)", name)
+		}
+	}
+	return nil
+}
+
+// SearchTerms breaks a piece of text up into the (lower-cased) words
+// that the archive search index is made of, without duplicates.
+func SearchTerms(s string) []string {
+	seen := make(map[string]bool)
+	terms := []string{}
+	for _, term := range strings.FieldsFunc(strings.ToLower(s), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsNumber(c)
+	}) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// termsMatching returns the SQL (and its arguments) for finding the
+// UUIDs of all archives with a search term that starts with prefix.
+// The upper bound is the prefix followed by the highest code point
+// there is, which keeps the query a range scan of the term index.
+func termsMatching(prefix string) (string, []interface{}) {
+	return `SELECT archive_uuid FROM archive_terms WHERE term >= ? AND term < ?`,
+		[]interface{}{prefix, prefix + string(unicode.MaxRune)}
+}
+
+// The caller is responsible for holding the database lock.
+func (db *DB) reindexArchive(id string) error {
+	r, err := db.query(`
+	   SELECT notes FROM archives WHERE uuid = ?
+	   UNION ALL
+	   SELECT name || ' ' || value FROM archive_labels WHERE archive_uuid = ?`, id, id)
+	if err != nil {
+		return err
+	}
+
+	var text []string
+	for r.Next() {
+		var s string
+		if err = r.Scan(&s); err != nil {
+			r.Close()
+			return err
+		}
+		text = append(text, s)
+	}
+	r.Close()
+
+	err = db.exec(`DELETE FROM archive_terms WHERE archive_uuid = ?`, id)
+	if err != nil {
+		return err
+	}
+	for _, term := range SearchTerms(strings.Join(text, " ")) {
+		err = db.exec(`INSERT INTO archive_terms (term, archive_uuid) VALUES (?, ?)`, term, id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reindexAllArchives rebuilds the search index for every archive.
+// The caller is responsible for holding the database lock.
+func (db *DB) reindexAllArchives() error {
+	r, err := db.query(`SELECT uuid FROM archives`)
+	if err != nil {
+		return err
+	}
+
+	var ids []string
+	for r.Next() {
+		var id string
+		if err = r.Scan(&id); err != nil {
+			r.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	r.Close()
+
+	for _, id := range ids {
+		if err := db.reindexArchive(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The caller is responsible for holding the database lock.
+func (db *DB) labelArchive(id string, labels map[string]string) error {
+	for name, value := range labels {
+		err := db.exec(`
+		   INSERT OR REPLACE INTO archive_labels (archive_uuid, name, value)
+		                                  VALUES (?, ?, ?)`, id, name, value)
+		if err != nil {
+			return err
+		}
+	}
+	return db.reindexArchive(id)
+}
+
+// LabelArchive sets the given labels on an archive, overwriting the
+// values of any labels it already has by the same names.  All other
+// labels are left as they are.
+func (db *DB) LabelArchive(id string, labels map[string]string) error {
+	if err := ValidateLabels(labels); err != nil {
+		return err
+	}
+
+	return db.exclusively(func() error {
+		archive, err := db.getArchive(id)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve archive [%s]: %s", id, err)
+		}
+		if archive == nil {
+			return fmt.Errorf("unable to retrieve archive [%s]: not found in database.", id)
+		}
+
+		if err = db.labelArchive(id, labels); err != nil {
+			return err
+		}
+
+		archive, err = db.getArchive(id)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve archive [%s]: %s", id, err)
+		}
+		db.sendUpdateObjectEvent(archive, "tenant:"+archive.TenantUUID)
+		return nil
+	})
+}
+
+// UnlabelArchive removes the named labels from an archive.
+func (db *DB) UnlabelArchive(id string, names []string) error {
+	return db.exclusively(func() error {
+		for _, name := range names {
+			err := db.exec(`DELETE FROM archive_labels WHERE archive_uuid = ? AND name = ?`, id, name)
+			if err != nil {
+				return err
+			}
+		}
+		if err := db.reindexArchive(id); err != nil {
+			return err
+		}
+
+		archive, err := db.getArchive(id)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve archive [%s]: %s", id, err)
+		}
+		if archive != nil {
+			db.sendUpdateObjectEvent(archive, "tenant:"+archive.TenantUUID)
+		}
+		return nil
+	})
+}
+
+// loadArchiveLabels fills in the labels of each of the given archives,
+// a few hundred archives at a time.
+// The caller is responsible for holding the database lock.
+func (db *DB) loadArchiveLabels(l []*Archive) error {
+	const batch = 500
+
+	byUUID := make(map[string]*Archive)
+	for _, a := range l {
+		a.Labels = make(map[string]string)
+		byUUID[a.UUID] = a
+	}
+
+	for i := 0; i < len(l); i += batch {
+		j := i + batch
+		if j > len(l) {
+			j = len(l)
+		}
+
+		var params []string
+		var args []interface{}
+		for _, a := range l[i:j] {
+			params = append(params, "?")
+			args = append(args, a.UUID)
+		}
+
+		r, err := db.query(`
+		   SELECT archive_uuid, name, value
+		     FROM archive_labels
+		    WHERE archive_uuid IN (`+strings.Join(params, ", ")+`)`, args...)
+		if err != nil {
+			return err
+		}
+		for r.Next() {
+			var id, name, value string
+			if err = r.Scan(&id, &name, &value); err != nil {
+				r.Close()
+				return err
+			}
+			if a, ok := byUUID[id]; ok {
+				a.Labels[name] = value
+			}
+		}
+		r.Close()
+	}
+	return nil
+}
+
+// labelNames returns the names of the given labels, in order, so that
+// queries built from them come out the same way every time.
+func labelNames(labels map[string]string) []string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
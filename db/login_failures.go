@@ -0,0 +1,181 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	AccountLockout = "account"
+	IPLockout      = "ip"
+)
+
+// A LoginLockout tracks the failed login attempts for either an
+// account (named account@backend), or a remote IP address.
+type LoginLockout struct {
+	Kind         string `json:"kind"`
+	Name         string `json:"name"`
+	Failures     int    `json:"failures"`
+	LastFailedAt int64  `json:"last_failed_at"`
+	LockedUntil  int64  `json:"locked_until"`
+}
+
+// A LockoutPolicy governs how many failed logins are tolerated before
+// locking out further attempts, and for how long.  Each failure past
+// the Threshold doubles the lockout, starting at Backoff seconds, up
+// to Max seconds.  Failures are forgotten once Max seconds have gone
+// by without another one.  A Threshold of zero disables lockouts.
+type LockoutPolicy struct {
+	Threshold int
+	Backoff   int64
+	Max       int64
+}
+
+// AccountLockoutName returns the name that login failures for an
+// account are tracked under.
+func AccountLockoutName(account, backend string) string {
+	return account + "@" + backend
+}
+
+func (l *LoginLockout) Locked() bool {
+	return l != nil && l.LockedUntil > time.Now().Unix()
+}
+
+func (p LockoutPolicy) lockout(failures int) int64 {
+	if p.Threshold <= 0 || failures < p.Threshold {
+		return 0
+	}
+
+	n := failures - p.Threshold
+	if n > 30 {
+		return p.Max
+	}
+	if d := p.Backoff << uint(n); d < p.Max {
+		return d
+	}
+	return p.Max
+}
+
+// GetLoginLockout returns the login failure tracking for an account or
+// IP address, or nil if there have been no (recent) failures.
+func (db *DB) GetLoginLockout(kind, name string) (*LoginLockout, error) {
+	db.exclusive.Lock()
+	defer db.exclusive.Unlock()
+
+	return db.getLoginLockout(kind, name)
+}
+
+func (db *DB) getLoginLockout(kind, name string) (*LoginLockout, error) {
+	r, err := db.query(`
+	    SELECT kind, name, failures, last_failed_at, locked_until
+	      FROM login_failures
+	     WHERE kind = ? AND name = ?`, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	if !r.Next() {
+		return nil, nil
+	}
+
+	l := &LoginLockout{}
+	if err := r.Scan(&l.Kind, &l.Name, &l.Failures, &l.LastFailedAt, &l.LockedUntil); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// GetLoginLockouts returns all of the accounts and IP addresses that
+// are currently locked out.
+func (db *DB) GetLoginLockouts() ([]*LoginLockout, error) {
+	db.exclusive.Lock()
+	defer db.exclusive.Unlock()
+
+	r, err := db.query(`
+	    SELECT kind, name, failures, last_failed_at, locked_until
+	      FROM login_failures
+	     WHERE locked_until > ?
+	  ORDER BY kind, name`, time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	l := make([]*LoginLockout, 0)
+	for r.Next() {
+		lock := &LoginLockout{}
+		if err := r.Scan(&lock.Kind, &lock.Name, &lock.Failures, &lock.LastFailedAt, &lock.LockedUntil); err != nil {
+			return nil, err
+		}
+		l = append(l, lock)
+	}
+	return l, nil
+}
+
+// RecordLoginFailure counts a failed login against an account or IP
+// address, locking it out if the policy says so.  When that happens,
+// a login-lockout event goes out to the admins queue.
+func (db *DB) RecordLoginFailure(kind, name string, policy LockoutPolicy) (*LoginLockout, error) {
+	if kind != AccountLockout && kind != IPLockout {
+		return nil, fmt.Errorf("invalid login lockout kind '%s'", kind)
+	}
+
+	var (
+		lock   *LoginLockout
+		locked bool
+	)
+	err := db.exclusively(func() error {
+		l, err := db.getLoginLockout(kind, name)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().Unix()
+		if l == nil {
+			l = &LoginLockout{Kind: kind, Name: name}
+		} else if !l.Locked() && now-l.LastFailedAt > policy.Max {
+			/* it's been a while; start over */
+			l.Failures = 0
+		}
+
+		l.Failures++
+		l.LastFailedAt = now
+		if d := policy.lockout(l.Failures); d > 0 {
+			l.LockedUntil = now + d
+			locked = true
+		}
+
+		lock = l
+		return db.exec(`
+		   INSERT OR REPLACE INTO login_failures
+		     (kind, name, failures, last_failed_at, locked_until)
+		   VALUES (?, ?, ?, ?, ?)`,
+			l.Kind, l.Name, l.Failures, l.LastFailedAt, l.LockedUntil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if locked {
+		db.sendLoginLockoutEvent(lock)
+	}
+	return lock, nil
+}
+
+// ClearLoginFailures forgets about any failed logins for an account or
+// IP address, i.e. after a successful login.
+func (db *DB) ClearLoginFailures(kind, name string) error {
+	return db.Exec(`DELETE FROM login_failures WHERE kind = ? AND name = ?`, kind, name)
+}
+
+// UnlockLogin lifts the lockout on an account or IP address, forgetting
+// its failed logins, and sends a login-unlock event to the admins queue.
+func (db *DB) UnlockLogin(kind, name string) error {
+	if err := db.ClearLoginFailures(kind, name); err != nil {
+		return err
+	}
+
+	db.sendLoginUnlockEvent(kind, name)
+	return nil
+}
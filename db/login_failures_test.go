@@ -0,0 +1,123 @@
+package db
+
+import (
+	"time"
+
+	// sql drivers
+	_ "github.com/mattn/go-sqlite3"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Login Lockouts", func() {
+	var (
+		db     *DB
+		policy LockoutPolicy
+	)
+
+	fail := func(kind, name string) *LoginLockout {
+		l, err := db.RecordLoginFailure(kind, name, policy)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(l).ShouldNot(BeNil())
+		return l
+	}
+
+	BeforeEach(func() {
+		var err error
+		db, err = Database()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		policy = LockoutPolicy{Threshold: 3, Backoff: 60, Max: 300}
+	})
+
+	It("tolerates failures up to the threshold", func() {
+		Ω(fail(AccountLockout, "admin@local").Locked()).Should(BeFalse())
+		Ω(fail(AccountLockout, "admin@local").Locked()).Should(BeFalse())
+
+		l, err := db.GetLoginLockout(AccountLockout, "admin@local")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(l.Failures).Should(Equal(2))
+		Ω(l.Locked()).Should(BeFalse())
+	})
+
+	It("locks out with exponential backoff, up to the maximum", func() {
+		fail(AccountLockout, "admin@local")
+		fail(AccountLockout, "admin@local")
+
+		now := time.Now().Unix()
+		l := fail(AccountLockout, "admin@local")
+		Ω(l.Locked()).Should(BeTrue())
+		Ω(l.LockedUntil - now).Should(BeNumerically("~", 60, 1))
+
+		l = fail(AccountLockout, "admin@local")
+		Ω(l.LockedUntil - now).Should(BeNumerically("~", 120, 1))
+
+		l = fail(AccountLockout, "admin@local")
+		Ω(l.LockedUntil - now).Should(BeNumerically("~", 240, 1))
+
+		l = fail(AccountLockout, "admin@local")
+		Ω(l.LockedUntil - now).Should(BeNumerically("~", 300, 1))
+	})
+
+	It("tracks accounts and IP addresses separately", func() {
+		for i := 0; i < 3; i++ {
+			fail(IPLockout, "10.0.0.1")
+		}
+
+		l, err := db.GetLoginLockout(IPLockout, "10.0.0.1")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(l.Locked()).Should(BeTrue())
+
+		l, err = db.GetLoginLockout(AccountLockout, "10.0.0.1")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(l).Should(BeNil())
+	})
+
+	It("never locks out when the threshold is zero", func() {
+		policy.Threshold = 0
+		for i := 0; i < 10; i++ {
+			Ω(fail(AccountLockout, "admin@local").Locked()).Should(BeFalse())
+		}
+	})
+
+	It("forgets old failures", func() {
+		fail(AccountLockout, "admin@local")
+		fail(AccountLockout, "admin@local")
+		Ω(db.Exec(`UPDATE login_failures SET last_failed_at = ?`, time.Now().Unix()-301)).Should(Succeed())
+
+		l := fail(AccountLockout, "admin@local")
+		Ω(l.Failures).Should(Equal(1))
+		Ω(l.Locked()).Should(BeFalse())
+	})
+
+	It("lists only the current lockouts", func() {
+		for i := 0; i < 3; i++ {
+			fail(AccountLockout, "admin@local")
+		}
+		fail(IPLockout, "10.0.0.1")
+
+		l, err := db.GetLoginLockouts()
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(l).Should(HaveLen(1))
+		Ω(l[0].Kind).Should(Equal(AccountLockout))
+		Ω(l[0].Name).Should(Equal("admin@local"))
+	})
+
+	It("lifts lockouts when unlocked", func() {
+		for i := 0; i < 3; i++ {
+			fail(AccountLockout, "admin@local")
+		}
+		Ω(db.UnlockLogin(AccountLockout, "admin@local")).Should(Succeed())
+
+		l, err := db.GetLoginLockout(AccountLockout, "admin@local")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(l.Locked()).Should(BeFalse())
+		Ω(l).Should(BeNil())
+	})
+
+	It("refuses to track unknown kinds of things", func() {
+		_, err := db.RecordLoginFailure("planet", "mars", policy)
+		Ω(err).Should(HaveOccurred())
+	})
+})
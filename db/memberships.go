@@ -40,11 +40,31 @@ func (db *DB) ClearMembershipsFor(user *User) error {
 	return db.Exec(`DELETE FROM memberships WHERE user_uuid = ?`, user.UUID)
 }
 
+// ClearExternalMembershipsFor removes the tenant memberships that an
+// auth provider assigned to the user, leaving those granted by hand.
+func (db *DB) ClearExternalMembershipsFor(user *User) error {
+	return db.Exec(`DELETE FROM memberships WHERE user_uuid = ? AND external = 1`, user.UUID)
+}
+
 func (db *DB) AddUserToTenant(user, tenant_id, role string) error {
+	_, err := db.addUserToTenant(user, tenant_id, role, false)
+	return err
+}
+
+// AddExternalUserToTenant is AddUserToTenant, for memberships assigned
+// by an auth provider, from its group (or team) mappings.  These never
+// take the place of a membership that was granted by hand; if there is
+// one, it is kept, and AddExternalUserToTenant returns false.
+func (db *DB) AddExternalUserToTenant(user, tenant_id, role string) (bool, error) {
+	return db.addUserToTenant(user, tenant_id, role, true)
+}
+
+func (db *DB) addUserToTenant(user, tenant_id, role string, external bool) (bool, error) {
 	tenant, err := db.GetTenant(tenant_id)
 	if err != nil {
-		return fmt.Errorf("unable to create tenant membership: %s", err)
+		return false, fmt.Errorf("unable to create tenant membership: %s", err)
 	}
+	added := true
 	err = db.exclusively(func() error {
 		/* validate the user */
 		if err := db.userShouldExist(user); err != nil {
@@ -58,27 +78,42 @@ func (db *DB) AddUserToTenant(user, tenant_id, role string) error {
 		if err != nil {
 			return err
 		}
+		if exists && external {
+			byHand, err := db.exists(`
+			    SELECT m.role
+			      FROM memberships m
+			     WHERE m.user_uuid = ?
+			       AND m.tenant_uuid = ?
+			       AND m.external = 0`, user, tenant.UUID)
+			if err != nil {
+				return err
+			}
+			if byHand {
+				added = false
+				return nil
+			}
+		}
 		if exists {
 			return db.exec(`
 			    UPDATE memberships
-			       SET role = ?
+			       SET role = ?, external = ?
 			     WHERE user_uuid = ?
 			       AND tenant_uuid = ?`,
-				role, user, tenant.UUID)
+				role, external, user, tenant.UUID)
 
 		} else {
 			return db.exec(`
-			    INSERT INTO memberships (user_uuid, tenant_uuid, role)
-			                     VALUES (?, ?, ?)`,
-				user, tenant.UUID, role)
+			    INSERT INTO memberships (user_uuid, tenant_uuid, role, external)
+			                     VALUES (?, ?, ?, ?)`,
+				user, tenant.UUID, role, external)
 		}
 	})
-	if err != nil {
-		return err
+	if err != nil || !added {
+		return false, err
 	}
 
 	db.sendTenantInviteEvent(user, tenant, role)
-	return nil
+	return true, nil
 }
 
 func (db *DB) RemoveUserFromTenant(user, tenant string) error {
@@ -0,0 +1,245 @@
+package db
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shieldproject/shield/lib/totp"
+)
+
+// How many single-use recovery codes a user gets, each time they
+// (re-)generate them, for logging in without their authenticator.
+const MFARecoveryCodes = 10
+
+// StartMFA begins (or restarts) TOTP enrolment for a local user, with
+// a new secret.  Until the enrolment is confirmed with a code from the
+// secret (see ConfirmMFA), multi-factor authentication stays off.
+func (db *DB) StartMFA(user *User, secret string) error {
+	if !user.IsLocal() {
+		return fmt.Errorf("%s is not a local user account", user.Account)
+	}
+
+	err := db.exclusively(func() error {
+		err := db.exec(`
+		   UPDATE users
+		      SET mfa_secret = ?, mfa_enabled = 0, mfa_last_step = 0
+		    WHERE uuid = ?`, secret, user.UUID)
+		if err != nil {
+			return err
+		}
+
+		return db.exec(`DELETE FROM mfa_recovery_codes WHERE user_uuid = ?`, user.UUID)
+	})
+	if err != nil {
+		return err
+	}
+
+	user.mfaSecret = secret
+	user.mfaLastStep = 0
+	user.MFAEnabled = false
+	return nil
+}
+
+// ConfirmMFA finishes TOTP enrolment, if the given code is good for
+// the secret from StartMFA.  Confirmed enrolments get a fresh set of
+// recovery codes, which are returned (and never stored in the clear.)
+func (db *DB) ConfirmMFA(user *User, code string) ([]string, bool, error) {
+	if user.mfaSecret == "" {
+		return nil, false, fmt.Errorf("%s has not started multi-factor authentication enrolment", user.Account)
+	}
+
+	step, ok := totp.Verify(user.mfaSecret, code, time.Now(), 0)
+	if !ok {
+		return nil, false, nil
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, false, err
+	}
+
+	err = db.exclusively(func() error {
+		err := db.exec(`
+		   UPDATE users
+		      SET mfa_enabled = 1, mfa_last_step = ?
+		    WHERE uuid = ? AND mfa_secret = ?`, step, user.UUID, user.mfaSecret)
+		if err != nil {
+			return err
+		}
+
+		return db.replaceRecoveryCodes(user, hashes)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	user.MFAEnabled = true
+	user.mfaLastStep = step
+	return codes, true, nil
+}
+
+// CheckMFA checks a second factor for a user with multi-factor
+// authentication enabled: either a current code from their
+// authenticator, or one of their unused recovery codes.  Either
+// kind of code is only ever accepted once.
+func (db *DB) CheckMFA(user *User, code string) (bool, error) {
+	ok := false
+	err := db.exclusively(func() error {
+		/* re-read the secret and the last step used, to make sure
+		   that two logins can't race each other with the same code */
+		r, err := db.query(`
+		    SELECT mfa_secret, mfa_enabled, mfa_last_step
+		      FROM users
+		     WHERE uuid = ?`, user.UUID)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		if !r.Next() {
+			return nil
+		}
+
+		var (
+			secret  string
+			enabled bool
+			last    int64
+		)
+		if err := r.Scan(&secret, &enabled, &last); err != nil {
+			return err
+		}
+		r.Close()
+
+		if !enabled {
+			return nil
+		}
+
+		if step, good := totp.Verify(secret, code, time.Now(), last); good {
+			ok = true
+			return db.exec(`
+			   UPDATE users
+			      SET mfa_last_step = ?
+			    WHERE uuid = ?`, step, user.UUID)
+		}
+
+		hash := recoveryHash(code)
+		unused, err := db.exists(`
+		    SELECT hash FROM mfa_recovery_codes
+		     WHERE user_uuid = ? AND hash = ? AND used_at IS NULL`, user.UUID, hash)
+		if err != nil || !unused {
+			return err
+		}
+
+		ok = true
+		return db.exec(`
+		   UPDATE mfa_recovery_codes
+		      SET used_at = ?
+		    WHERE user_uuid = ? AND hash = ?`, time.Now().Unix(), user.UUID, hash)
+	})
+	return ok && err == nil, err
+}
+
+// RegenerateRecoveryCodes throws away all of a user's recovery codes,
+// used or not, and gives them a new set, which is returned.
+func (db *DB) RegenerateRecoveryCodes(user *User) ([]string, error) {
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.exclusively(func() error {
+		return db.replaceRecoveryCodes(user, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// CountRecoveryCodes returns how many unused recovery codes a user
+// has left.
+func (db *DB) CountRecoveryCodes(user *User) (int, error) {
+	db.exclusive.Lock()
+	defer db.exclusive.Unlock()
+
+	n, err := db.count(`
+	    SELECT hash FROM mfa_recovery_codes
+	     WHERE user_uuid = ? AND used_at IS NULL`, user.UUID)
+	return int(n), err
+}
+
+// DisableMFA turns off multi-factor authentication for a user, and
+// forgets their secret and recovery codes.
+func (db *DB) DisableMFA(user *User) error {
+	err := db.exclusively(func() error {
+		err := db.exec(`
+		   UPDATE users
+		      SET mfa_secret = '', mfa_enabled = 0, mfa_last_step = 0
+		    WHERE uuid = ?`, user.UUID)
+		if err != nil {
+			return err
+		}
+
+		return db.exec(`DELETE FROM mfa_recovery_codes WHERE user_uuid = ?`, user.UUID)
+	})
+	if err != nil {
+		return err
+	}
+
+	user.mfaSecret = ""
+	user.mfaLastStep = 0
+	user.MFAEnabled = false
+	return nil
+}
+
+func (db *DB) replaceRecoveryCodes(user *User, hashes []string) error {
+	err := db.exec(`DELETE FROM mfa_recovery_codes WHERE user_uuid = ?`, user.UUID)
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range hashes {
+		err = db.exec(`
+		   INSERT INTO mfa_recovery_codes (user_uuid, hash)
+		                           VALUES (?, ?)`, user.UUID, hash)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Recovery codes are ten characters of base32 (50 bits), which is
+// plenty for a single-use code, and lets us get away with a plain
+// hash (unlike passwords, which need bcrypt.)
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, MFARecoveryCodes)
+	hashes := make([]string, MFARecoveryCodes)
+
+	for i := range codes {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("unable to generate recovery codes: %s", err)
+		}
+		s := strings.ToLower(recoveryEncoding.EncodeToString(b))[:10]
+		codes[i] = s[:5] + "-" + s[5:]
+		hashes[i] = recoveryHash(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+func recoveryHash(code string) string {
+	code = strings.ToLower(code)
+	code = strings.Replace(code, "-", "", -1)
+	code = strings.Replace(code, " ", "", -1)
+
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
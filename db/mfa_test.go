@@ -0,0 +1,145 @@
+package db
+
+import (
+	"strings"
+	"time"
+
+	// sql drivers
+	_ "github.com/mattn/go-sqlite3"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/shieldproject/shield/lib/totp"
+)
+
+var _ = Describe("Multi-Factor Authentication", func() {
+	var (
+		db     *DB
+		user   *User
+		secret string
+	)
+
+	code := func(offset int64) string {
+		c, err := totp.Code(secret, totp.Step(time.Now())+offset)
+		Ω(err).ShouldNot(HaveOccurred())
+		return c
+	}
+
+	reload := func() *User {
+		u, err := db.GetUserByID(user.UUID)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(u).ShouldNot(BeNil())
+		return u
+	}
+
+	BeforeEach(func() {
+		var err error
+		db, err = Database()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		user, err = db.CreateUser(&User{Name: "Admin", Account: "admin", Backend: "local"})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		secret, err = totp.NewSecret()
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	It("only enables MFA once enrolment is confirmed", func() {
+		Ω(db.StartMFA(user, secret)).Should(Succeed())
+		Ω(reload().MFAEnabled).Should(BeFalse())
+
+		codes, ok, err := db.ConfirmMFA(reload(), "000000")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(ok).Should(BeFalse())
+		Ω(codes).Should(BeEmpty())
+		Ω(reload().MFAEnabled).Should(BeFalse())
+
+		codes, ok, err = db.ConfirmMFA(reload(), code(0))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(ok).Should(BeTrue())
+		Ω(codes).Should(HaveLen(MFARecoveryCodes))
+		Ω(reload().MFAEnabled).Should(BeTrue())
+	})
+
+	It("refuses to enrol non-local users", func() {
+		u, err := db.CreateUser(&User{Name: "Someone", Account: "someone", Backend: "github"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(db.StartMFA(u, secret)).ShouldNot(Succeed())
+	})
+
+	Context("with MFA enabled", func() {
+		var recovery []string
+
+		BeforeEach(func() {
+			Ω(db.StartMFA(user, secret)).Should(Succeed())
+
+			var (
+				ok  bool
+				err error
+			)
+			recovery, ok, err = db.ConfirmMFA(reload(), code(-1))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(ok).Should(BeTrue())
+		})
+
+		It("accepts current codes, but only once", func() {
+			ok, err := db.CheckMFA(user, code(0))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(ok).Should(BeTrue())
+
+			ok, err = db.CheckMFA(user, code(0))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(ok).Should(BeFalse())
+		})
+
+		It("refuses codes older than the last one used", func() {
+			ok, err := db.CheckMFA(user, code(-1))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(ok).Should(BeFalse())
+		})
+
+		It("refuses bogus codes", func() {
+			ok, err := db.CheckMFA(user, "123")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(ok).Should(BeFalse())
+		})
+
+		It("accepts each recovery code once", func() {
+			Ω(db.CountRecoveryCodes(user)).Should(Equal(MFARecoveryCodes))
+
+			ok, err := db.CheckMFA(user, strings.ToUpper(recovery[0]))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(ok).Should(BeTrue())
+			Ω(db.CountRecoveryCodes(user)).Should(Equal(MFARecoveryCodes - 1))
+
+			ok, err = db.CheckMFA(user, recovery[0])
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(ok).Should(BeFalse())
+		})
+
+		It("invalidates old recovery codes when regenerating them", func() {
+			fresh, err := db.RegenerateRecoveryCodes(user)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(fresh).Should(HaveLen(MFARecoveryCodes))
+
+			ok, err := db.CheckMFA(user, recovery[0])
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(ok).Should(BeFalse())
+
+			ok, err = db.CheckMFA(user, fresh[0])
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(ok).Should(BeTrue())
+		})
+
+		It("forgets everything when MFA is disabled", func() {
+			Ω(db.DisableMFA(user)).Should(Succeed())
+			Ω(reload().MFAEnabled).Should(BeFalse())
+			Ω(db.CountRecoveryCodes(user)).Should(Equal(0))
+
+			ok, err := db.CheckMFA(user, code(1))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(ok).Should(BeFalse())
+		})
+	})
+})
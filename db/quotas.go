@@ -0,0 +1,77 @@
+package db
+
+import (
+	"fmt"
+)
+
+// The resources that a tenant's quotas can limit.
+const (
+	QuotaStorage  = "storage"
+	QuotaArchives = "archives"
+	QuotaJobs     = "jobs"
+	QuotaTargets  = "targets"
+	QuotaTasks    = "tasks"
+)
+
+// A QuotaUsage reports how much of a single resource a tenant is
+// using, against its quota for that resource.  A Limit of zero means
+// that the tenant can use as much as it likes.
+//
+// Storage is measured in bytes, and tasks in how many are running
+// right now; everything else is a simple count.
+type QuotaUsage struct {
+	Resource string `json:"resource"`
+	Limit    int64  `json:"limit"`
+	Used     int64  `json:"used"`
+	Reached  bool   `json:"reached"`
+}
+
+func (q *QuotaUsage) String() string {
+	if q.Resource == QuotaStorage {
+		return fmt.Sprintf("%s (%d of %d bytes used)", q.Resource, q.Used, q.Limit)
+	}
+	return fmt.Sprintf("%s (%d of %d)", q.Resource, q.Used, q.Limit)
+}
+
+// GetTenantQuotas works out how much of each resource the tenant is
+// using, relative to its quotas.  Storage and archive usage come from
+// the tenant's own (periodically recalculated) statistics.
+func (db *DB) GetTenantQuotas(tenant *Tenant) ([]*QuotaUsage, error) {
+	l := []*QuotaUsage{
+		{Resource: QuotaStorage, Limit: tenant.QuotaStorage, Used: tenant.StorageUsed},
+		{Resource: QuotaArchives, Limit: int64(tenant.QuotaArchives), Used: int64(tenant.ArchiveCount)},
+		{Resource: QuotaJobs, Limit: int64(tenant.QuotaJobs)},
+		{Resource: QuotaTargets, Limit: int64(tenant.QuotaTargets)},
+		{Resource: QuotaTasks, Limit: int64(tenant.QuotaTasks)},
+	}
+
+	err := db.exclusively(func() error {
+		for _, q := range l[2:] {
+			var (
+				n   uint
+				err error
+			)
+			switch q.Resource {
+			case QuotaJobs:
+				n, err = db.count(`SELECT uuid FROM jobs WHERE tenant_uuid = ?`, tenant.UUID)
+			case QuotaTargets:
+				n, err = db.count(`SELECT uuid FROM targets WHERE tenant_uuid = ?`, tenant.UUID)
+			case QuotaTasks:
+				n, err = db.count(`SELECT uuid FROM tasks WHERE tenant_uuid = ? AND status = ?`, tenant.UUID, RunningStatus)
+			}
+			if err != nil {
+				return err
+			}
+			q.Used = int64(n)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, q := range l {
+		q.Reached = q.Limit > 0 && q.Used >= q.Limit
+	}
+	return l, nil
+}
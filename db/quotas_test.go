@@ -0,0 +1,114 @@
+package db
+
+import (
+	// sql drivers
+	_ "github.com/mattn/go-sqlite3"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tenant Quotas", func() {
+	var (
+		db     *DB
+		tenant *Tenant
+	)
+
+	const (
+		TenantUUID = "3f950780-120f-4e00-b46b-28f35e5882df"
+		OtherUUID  = "b1d6eeeb-1235-4c93-8800-f5c44ee50f1b"
+	)
+
+	BeforeEach(func() {
+		var err error
+		db, err = Database(
+			`INSERT INTO targets (uuid, name, summary, plugin, endpoint, agent, tenant_uuid)
+			   VALUES ("target-1", "one", "", "fs", "{}", "127.0.0.1:5444", "`+TenantUUID+`")`,
+			`INSERT INTO targets (uuid, name, summary, plugin, endpoint, agent, tenant_uuid)
+			   VALUES ("target-2", "two", "", "fs", "{}", "127.0.0.1:5444", "`+TenantUUID+`")`,
+			`INSERT INTO targets (uuid, name, summary, plugin, endpoint, agent, tenant_uuid)
+			   VALUES ("target-3", "three", "", "fs", "{}", "127.0.0.1:5444", "`+OtherUUID+`")`,
+			`INSERT INTO jobs (uuid, name, summary, paused, target_uuid, store_uuid, keep_n, keep_days, retries, schedule, tenant_uuid)
+			   VALUES ("job-1", "Some Job", "", 0, "target-1", "store-1", 4, 4, 4, "daily 3am", "`+TenantUUID+`")`,
+			`INSERT INTO tasks (uuid, owner, op, requested_at, status, tenant_uuid)
+			   VALUES ("task-1", "system", "backup", 0, "running", "`+TenantUUID+`")`,
+			`INSERT INTO tasks (uuid, owner, op, requested_at, status, tenant_uuid)
+			   VALUES ("task-2", "system", "backup", 0, "done", "`+TenantUUID+`")`,
+			`INSERT INTO tasks (uuid, owner, op, requested_at, status, tenant_uuid)
+			   VALUES ("task-3", "system", "backup", 0, "running", "`+OtherUUID+`")`,
+		)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		tenant, err = db.CreateTenant(&Tenant{
+			UUID:          TenantUUID,
+			Name:          "acme",
+			QuotaStorage:  1024,
+			QuotaArchives: 10,
+			QuotaTargets:  2,
+			QuotaTasks:    4,
+			EnforceQuotas: true,
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	quota := func(l []*QuotaUsage, resource string) *QuotaUsage {
+		for _, q := range l {
+			if q.Resource == resource {
+				return q
+			}
+		}
+		Fail("no quota for " + resource)
+		return nil
+	}
+
+	It("stores tenant quotas", func() {
+		t, err := db.GetTenant(TenantUUID)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(t.QuotaStorage).Should(Equal(int64(1024)))
+		Ω(t.QuotaArchives).Should(Equal(10))
+		Ω(t.QuotaJobs).Should(Equal(0))
+		Ω(t.QuotaTargets).Should(Equal(2))
+		Ω(t.QuotaTasks).Should(Equal(4))
+		Ω(t.EnforceQuotas).Should(BeTrue())
+
+		t.QuotaJobs = 5
+		t.EnforceQuotas = false
+		_, err = db.UpdateTenant(t)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		t, err = db.GetTenant(TenantUUID)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(t.QuotaJobs).Should(Equal(5))
+		Ω(t.EnforceQuotas).Should(BeFalse())
+	})
+
+	It("counts what the tenant is using against its quotas", func() {
+		tenant.StorageUsed = 512
+		tenant.ArchiveCount = 3
+
+		l, err := db.GetTenantQuotas(tenant)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(l).Should(HaveLen(5))
+
+		Ω(quota(l, QuotaStorage).Used).Should(Equal(int64(512)))
+		Ω(quota(l, QuotaArchives).Used).Should(Equal(int64(3)))
+		Ω(quota(l, QuotaJobs).Used).Should(Equal(int64(1)))
+		Ω(quota(l, QuotaTargets).Used).Should(Equal(int64(2)))
+		Ω(quota(l, QuotaTasks).Used).Should(Equal(int64(1)))
+	})
+
+	It("knows when a quota has been reached", func() {
+		tenant.StorageUsed = 2048
+
+		l, err := db.GetTenantQuotas(tenant)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(quota(l, QuotaStorage).Reached).Should(BeTrue())
+		Ω(quota(l, QuotaArchives).Reached).Should(BeFalse())
+		Ω(quota(l, QuotaTargets).Reached).Should(BeTrue())
+		Ω(quota(l, QuotaTasks).Reached).Should(BeFalse())
+
+		By("never considering unlimited quotas reached")
+		Ω(quota(l, QuotaJobs).Limit).Should(Equal(int64(0)))
+		Ω(quota(l, QuotaJobs).Reached).Should(BeFalse())
+	})
+})
@@ -0,0 +1,153 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// A Role is a custom tenant role: a named set of permissions that
+// members of a single tenant can be assigned, in place of one of the
+// built-in admin, engineer, or operator roles.
+//
+// The built-in roles are not stored in the database at all; they are
+// only ever Roles (with Builtin set) on their way out of the API.
+type Role struct {
+	TenantUUID  string   `json:"tenant_uuid,omitempty"`
+	Name        string   `json:"name"`
+	Summary     string   `json:"summary"`
+	Permissions []string `json:"permissions"`
+	Builtin     bool     `json:"builtin"`
+}
+
+var roleName = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// IsBuiltinRole returns true if the named role is one of the built-in
+// tenant roles, which every tenant has.
+func IsBuiltinRole(name string) bool {
+	return name == "admin" || name == "engineer" || name == "operator"
+}
+
+// Validate checks that the role has a usable name, and sorts its
+// permissions (removing any duplicates along the way).  Whether or
+// not those permissions mean anything is for the caller to decide.
+func (r *Role) Validate() error {
+	if !roleName.MatchString(r.Name) {
+		return fmt.Errorf("invalid role name '%s' (names must start with a lowercase letter, and may only contain lowercase letters, numbers, and dashes)", r.Name)
+	}
+
+	seen := make(map[string]bool)
+	perms := make([]string, 0, len(r.Permissions))
+	for _, p := range r.Permissions {
+		if !seen[p] {
+			seen[p] = true
+			perms = append(perms, p)
+		}
+	}
+	sort.Strings(perms)
+	r.Permissions = perms
+	return nil
+}
+
+func (db *DB) GetRolesForTenant(tenant string) ([]*Role, error) {
+	l := make([]*Role, 0)
+	return l, db.exclusively(func() error {
+		r, err := db.query(`
+		    SELECT tenant_uuid, name, summary, permissions
+		      FROM roles
+		     WHERE tenant_uuid = ?
+		  ORDER BY name ASC`, tenant)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		for r.Next() {
+			var (
+				role  Role
+				perms string
+			)
+			if err := r.Scan(&role.TenantUUID, &role.Name, &role.Summary, &perms); err != nil {
+				return err
+			}
+			role.Permissions = splitList(perms)
+			if role.Permissions == nil {
+				role.Permissions = []string{}
+			}
+			l = append(l, &role)
+		}
+		return nil
+	})
+}
+
+func (db *DB) GetRole(tenant, name string) (*Role, error) {
+	l, err := db.GetRolesForTenant(tenant)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range l {
+		if role.Name == name {
+			return role, nil
+		}
+	}
+	return nil, nil
+}
+
+func (db *DB) CreateRole(role *Role) (*Role, error) {
+	if err := role.Validate(); err != nil {
+		return nil, err
+	}
+
+	err := db.exclusively(func() error {
+		if err := db.tenantShouldExist(role.TenantUUID); err != nil {
+			return err
+		}
+		if ok, err := db.exists(`SELECT name FROM roles WHERE tenant_uuid = ? AND name = ?`, role.TenantUUID, role.Name); err != nil {
+			return err
+		} else if ok {
+			return fmt.Errorf("a role named '%s' already exists", role.Name)
+		}
+
+		return db.exec(`
+		    INSERT INTO roles (tenant_uuid, name, summary, permissions)
+		               VALUES (?, ?, ?, ?)`,
+			role.TenantUUID, role.Name, role.Summary, joinList(role.Permissions))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+func (db *DB) UpdateRole(role *Role) error {
+	if err := role.Validate(); err != nil {
+		return err
+	}
+
+	return db.Exec(`
+	    UPDATE roles
+	       SET summary     = ?,
+	           permissions = ?
+	     WHERE tenant_uuid = ?
+	       AND name        = ?`,
+		role.Summary, joinList(role.Permissions), role.TenantUUID, role.Name)
+}
+
+// DeleteRole removes a custom role from a tenant, so long as no one is
+// still assigned it.
+func (db *DB) DeleteRole(tenant, name string) error {
+	return db.exclusively(func() error {
+		n, err := db.count(`
+		    SELECT user_uuid FROM memberships
+		     WHERE tenant_uuid = ?
+		       AND role        = ?`, tenant, name)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return fmt.Errorf("role '%s' is still assigned to %d tenant member(s)", name, n)
+		}
+
+		return db.exec(`DELETE FROM roles WHERE tenant_uuid = ? AND name = ?`, tenant, name)
+	})
+}
@@ -0,0 +1,144 @@
+package db
+
+import (
+	// sql drivers
+	_ "github.com/mattn/go-sqlite3"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Custom Tenant Roles", func() {
+	var (
+		db     *DB
+		tenant *Tenant
+	)
+
+	BeforeEach(func() {
+		var err error
+		db, err = Database(
+			`INSERT INTO users (uuid, name, account, backend, sysrole, pwhash)
+			   VALUES ('a0e5ecd5-4f5e-4ed2-8a4f-b0cd4b2b21d3', 'Jim', 'jhunt', 'local', '', '')`,
+		)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		tenant, err = db.CreateTenant(&Tenant{Name: "acme"})
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	It("creates, updates, and retrieves roles", func() {
+		role, err := db.CreateRole(&Role{
+			TenantUUID:  tenant.UUID,
+			Name:        "restorer",
+			Summary:     "Restores, and nothing else",
+			Permissions: []string{"view", "restore", "view"},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(role.Permissions).Should(Equal([]string{"restore", "view"}))
+
+		role, err = db.GetRole(tenant.UUID, "restorer")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(role).ShouldNot(BeNil())
+		Ω(role.Summary).Should(Equal("Restores, and nothing else"))
+		Ω(role.Permissions).Should(Equal([]string{"restore", "view"}))
+
+		role.Permissions = []string{"view"}
+		Ω(db.UpdateRole(role)).Should(Succeed())
+
+		l, err := db.GetRolesForTenant(tenant.UUID)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(l).Should(HaveLen(1))
+		Ω(l[0].Permissions).Should(Equal([]string{"view"}))
+
+		l, err = db.GetRolesForTenant("some-other-tenant")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(l).Should(BeEmpty())
+	})
+
+	It("rejects bad role names", func() {
+		for _, name := range []string{"", "Restorer", "1st-line", "db restorer"} {
+			_, err := db.CreateRole(&Role{TenantUUID: tenant.UUID, Name: name})
+			Ω(err).Should(HaveOccurred())
+		}
+	})
+
+	It("rejects duplicate roles, and roles for unknown tenants", func() {
+		_, err := db.CreateRole(&Role{TenantUUID: tenant.UUID, Name: "auditor"})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, err = db.CreateRole(&Role{TenantUUID: tenant.UUID, Name: "auditor"})
+		Ω(err).Should(HaveOccurred())
+
+		_, err = db.CreateRole(&Role{TenantUUID: "some-other-tenant", Name: "auditor"})
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("refuses to delete roles that are still assigned", func() {
+		_, err := db.CreateRole(&Role{TenantUUID: tenant.UUID, Name: "auditor"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(db.AddUserToTenant("a0e5ecd5-4f5e-4ed2-8a4f-b0cd4b2b21d3", tenant.UUID, "auditor")).Should(Succeed())
+
+		Ω(db.DeleteRole(tenant.UUID, "auditor")).ShouldNot(Succeed())
+
+		Ω(db.RemoveUserFromTenant("a0e5ecd5-4f5e-4ed2-8a4f-b0cd4b2b21d3", tenant.UUID)).Should(Succeed())
+		Ω(db.DeleteRole(tenant.UUID, "auditor")).Should(Succeed())
+
+		role, err := db.GetRole(tenant.UUID, "auditor")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(role).Should(BeNil())
+	})
+
+	It("keeps roles granted by hand when auth providers reassign roles", func() {
+		user := &User{UUID: "a0e5ecd5-4f5e-4ed2-8a4f-b0cd4b2b21d3"}
+		other, err := db.CreateTenant(&Tenant{Name: "initech"})
+		Ω(err).ShouldNot(HaveOccurred())
+		_, err = db.CreateRole(&Role{TenantUUID: tenant.UUID, Name: "auditor"})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		role := func(tenant *Tenant) string {
+			l, err := db.GetMembershipsForUser(user.UUID)
+			Ω(err).ShouldNot(HaveOccurred())
+			for _, m := range l {
+				if m.TenantUUID == tenant.UUID {
+					return m.Role
+				}
+			}
+			return ""
+		}
+
+		Ω(db.AddUserToTenant(user.UUID, tenant.UUID, "auditor")).Should(Succeed())
+
+		/* the provider's mappings never take the place of a hand grant... */
+		added, err := db.AddExternalUserToTenant(user.UUID, tenant.UUID, "admin")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(added).Should(BeFalse())
+		added, err = db.AddExternalUserToTenant(user.UUID, other.UUID, "engineer")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(added).Should(BeTrue())
+		Ω(role(tenant)).Should(Equal("auditor"))
+		Ω(role(other)).Should(Equal("engineer"))
+
+		/* ...and are the only thing cleared out at the next login */
+		Ω(db.ClearExternalMembershipsFor(user)).Should(Succeed())
+		Ω(role(tenant)).Should(Equal("auditor"))
+		Ω(role(other)).Should(Equal(""))
+
+		/* granting a role by hand takes over from the provider */
+		_, err = db.AddExternalUserToTenant(user.UUID, other.UUID, "engineer")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(db.AddUserToTenant(user.UUID, other.UUID, "operator")).Should(Succeed())
+		Ω(db.ClearExternalMembershipsFor(user)).Should(Succeed())
+		Ω(role(other)).Should(Equal("operator"))
+	})
+
+	It("deletes custom roles along with their tenant", func() {
+		_, err := db.CreateRole(&Role{TenantUUID: tenant.UUID, Name: "auditor"})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Ω(db.DeleteTenant(tenant, false)).Should(Succeed())
+
+		l, err := db.GetRolesForTenant(tenant.UUID)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(l).Should(BeEmpty())
+	})
+})
@@ -0,0 +1,154 @@
+package db
+
+import (
+	"sort"
+	"time"
+)
+
+// MaxScheduledRuns caps how many runs of any single job ExpandSchedule
+// will work out, so that a minutely job previewed across a month
+// doesn't drown out everything else.
+const MaxScheduledRuns = 1000
+
+// A ScheduledRun is a single, future run of a backup job, worked out
+// from the job's schedule (including any offset and exclusions).  Jobs
+// with random jitter may start up to Jitter minutes after At.
+//
+// Duration is how long the run is expected to take, in seconds, based
+// on the job's recent history; it is zero for jobs that have never
+// completed a backup.  If the run falls within a blackout, Blackout
+// names it; such runs will be skipped, rather than run.
+type ScheduledRun struct {
+	At         int64  `json:"at"`
+	Duration   int64  `json:"duration"`
+	Jitter     int    `json:"jitter"`
+	Blackout   string `json:"blackout"`
+	TenantUUID string `json:"tenant_uuid"`
+	Agent      string `json:"agent"`
+
+	Job struct {
+		UUID string `json:"uuid"`
+		Name string `json:"name"`
+	} `json:"job"`
+
+	Target struct {
+		UUID string `json:"uuid"`
+		Name string `json:"name"`
+	} `json:"target"`
+
+	Store struct {
+		UUID string `json:"uuid"`
+		Name string `json:"name"`
+	} `json:"store"`
+}
+
+// ExpectedRuntime works out how long a run of the job ought to take,
+// in seconds, by averaging its last ten successful backups.
+func (db *DB) ExpectedRuntime(job *Job) (int64, error) {
+	db.exclusive.Lock()
+	defer db.exclusive.Unlock()
+
+	r, err := db.query(`
+	   SELECT started_at, stopped_at
+	     FROM tasks
+	    WHERE job_uuid = ? AND op = ? AND status = ?
+	      AND started_at > 0 AND stopped_at >= started_at
+	 ORDER BY stopped_at DESC
+	    LIMIT 10`, job.UUID, BackupOperation, DoneStatus)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	var total, n int64
+	for r.Next() {
+		var started, stopped int64
+		if err = r.Scan(&started, &stopped); err != nil {
+			return 0, err
+		}
+		total += stopped - started
+		n++
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	return total / n, nil
+}
+
+// ExpandSchedule works out every run of each of the given jobs, from
+// (but not including) the given start time, up to the given end time.
+// Paused jobs never run, and are left out.  Runs are returned in the
+// order they will happen.
+func (db *DB) ExpandSchedule(jobs []*Job, from, to time.Time) ([]*ScheduledRun, error) {
+	blackouts, err := db.GetAllBlackouts(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	active := []*Job{}
+	for _, job := range jobs {
+		if !job.Paused {
+			active = append(active, job)
+		}
+	}
+	specs, err := db.JobTimespecs(active)
+	if err != nil {
+		return nil, err
+	}
+
+	l := []*ScheduledRun{}
+	for _, job := range active {
+		spec := specs[job.UUID]
+		/* random jitter is different every time; report
+		   it, rather than picking a delay at random. */
+		jitter := int(spec.Jitter / time.Minute)
+		spec.Jitter = 0
+
+		duration, err := db.ExpectedRuntime(job)
+		if err != nil {
+			return nil, err
+		}
+
+		t := from
+		for i := 0; i < MaxScheduledRuns; i++ {
+			t, err = spec.Next(t)
+			if err != nil {
+				return nil, err
+			}
+			if t.After(to) {
+				break
+			}
+
+			run := &ScheduledRun{
+				At:         t.Unix(),
+				Duration:   duration,
+				Jitter:     jitter,
+				TenantUUID: job.TenantUUID,
+				Agent:      job.Agent,
+			}
+			run.Job.UUID = job.UUID
+			run.Job.Name = job.Name
+			run.Target.UUID = job.Target.UUID
+			run.Target.Name = job.Target.Name
+			run.Store.UUID = job.Store.UUID
+			run.Store.Name = job.Store.Name
+
+			for _, blackout := range blackouts {
+				if !blackout.Covers(job) {
+					continue
+				}
+				if active, _, err := blackout.Active(t); err == nil && active {
+					run.Blackout = blackout.Name
+					break
+				}
+			}
+
+			l = append(l, run)
+		}
+	}
+
+	sort.SliceStable(l, func(i, j int) bool {
+		return l[i].At < l[j].At
+	})
+	return l, nil
+}
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"time"
+
+	// sql drivers
+	_ "github.com/mattn/go-sqlite3"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Schedule Previews", func() {
+	var (
+		db *DB
+
+		SomeTenant = &Tenant{UUID: RandomID()}
+		SomeTarget = &Target{UUID: RandomID()}
+		SomeStore  = &Store{UUID: RandomID()}
+		Nightly    = RandomID()
+		Hourly     = RandomID()
+		Paused     = RandomID()
+	)
+
+	BeforeEach(func() {
+		var err error
+		db, err = Database(
+			`INSERT INTO tenants (uuid, name) VALUES ("`+SomeTenant.UUID+`", "Some Tenant")`,
+
+			`INSERT INTO targets (uuid, tenant_uuid, name, summary, plugin, endpoint, agent)
+			   VALUES ("`+SomeTarget.UUID+`", "`+SomeTenant.UUID+`", "Some Target", "", "plugin", '{}', "127.0.0.1:5444")`,
+
+			`INSERT INTO stores (uuid, tenant_uuid, name, summary, plugin, endpoint, agent)
+			   VALUES ("`+SomeStore.UUID+`", "`+SomeTenant.UUID+`", "Some Store", "", "plugin", '{}', "127.0.0.1:5444")`,
+
+			`INSERT INTO jobs (uuid, tenant_uuid, name, summary, paused, target_uuid, store_uuid, schedule, keep_days, retries)
+			   VALUES ("`+Nightly+`", "`+SomeTenant.UUID+`", "Nightly", "", 0,
+			           "`+SomeTarget.UUID+`", "`+SomeStore.UUID+`", "daily at 3am", 7, 0)`,
+
+			`INSERT INTO jobs (uuid, tenant_uuid, name, summary, paused, target_uuid, store_uuid, schedule, keep_days, retries)
+			   VALUES ("`+Hourly+`", "`+SomeTenant.UUID+`", "Hourly", "", 0,
+			           "`+SomeTarget.UUID+`", "`+SomeStore.UUID+`", "hourly at :30", 1, 0)`,
+
+			`INSERT INTO jobs (uuid, tenant_uuid, name, summary, paused, target_uuid, store_uuid, schedule, keep_days, retries)
+			   VALUES ("`+Paused+`", "`+SomeTenant.UUID+`", "Paused", "", 1,
+			           "`+SomeTarget.UUID+`", "`+SomeStore.UUID+`", "daily at 4am", 7, 0)`,
+
+			`INSERT INTO tasks (uuid, owner, op, status, requested_at, started_at, stopped_at, job_uuid, tenant_uuid)
+			   VALUES ("`+RandomID()+`", "system", "backup", "done", 0, 1000, 1600, "`+Nightly+`", "`+SomeTenant.UUID+`")`,
+			`INSERT INTO tasks (uuid, owner, op, status, requested_at, started_at, stopped_at, job_uuid, tenant_uuid)
+			   VALUES ("`+RandomID()+`", "system", "backup", "done", 0, 2000, 2800, "`+Nightly+`", "`+SomeTenant.UUID+`")`,
+			`INSERT INTO tasks (uuid, owner, op, status, requested_at, started_at, stopped_at, job_uuid, tenant_uuid)
+			   VALUES ("`+RandomID()+`", "system", "backup", "failed", 0, 3000, 9000, "`+Nightly+`", "`+SomeTenant.UUID+`")`,
+		)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(db).ShouldNot(BeNil())
+	})
+
+	expand := func(hours int) []*ScheduledRun {
+		jobs, err := db.GetAllJobs(&JobFilter{ForTenant: SomeTenant.UUID})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		runs, err := db.ExpandSchedule(jobs, T0, T0.Add(time.Duration(hours)*time.Hour))
+		Ω(err).ShouldNot(HaveOccurred())
+		return runs
+	}
+
+	It("works out expected runtimes from successful backups", func() {
+		job, err := db.GetJob(Nightly)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(db.ExpectedRuntime(job)).Should(BeEquivalentTo(700))
+
+		job, err = db.GetJob(Hourly)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(db.ExpectedRuntime(job)).Should(BeEquivalentTo(0))
+	})
+
+	It("expands every unpaused job into runs, in order", func() {
+		/* T0 is 02:14; the next four hours hold
+		   02:30, 03:00, 03:30, 04:30 and 05:30 */
+		runs := expand(4)
+		Ω(len(runs)).Should(Equal(5))
+
+		Ω(runs[0].At).Should(Equal(time.Date(1997, 8, 29, 2, 30, 0, 0, time.UTC).Unix()))
+		Ω(runs[0].Job.Name).Should(Equal("Hourly"))
+
+		Ω(runs[1].At).Should(Equal(time.Date(1997, 8, 29, 3, 0, 0, 0, time.UTC).Unix()))
+		Ω(runs[1].Job.Name).Should(Equal("Nightly"))
+		Ω(runs[1].Duration).Should(BeEquivalentTo(700))
+		Ω(runs[1].Target.Name).Should(Equal("Some Target"))
+		Ω(runs[1].Store.Name).Should(Equal("Some Store"))
+		Ω(runs[1].Agent).Should(Equal("127.0.0.1:5444"))
+
+		for _, run := range runs {
+			Ω(run.Job.UUID).ShouldNot(Equal(Paused))
+		}
+	})
+
+	It("flags runs that fall within a blackout", func() {
+		_, err := db.CreateBlackout(&Blackout{
+			TenantUUID: SomeTenant.UUID,
+			Name:       "Maintenance",
+			Schedule:   "daily at 2:45am",
+			Duration:   30,
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		runs := expand(2)
+		Ω(len(runs)).Should(Equal(3))
+		Ω(runs[0].Blackout).Should(Equal(""))
+		Ω(runs[1].Blackout).Should(Equal("Maintenance"))
+		Ω(runs[2].Blackout).Should(Equal(""))
+	})
+})
@@ -22,6 +22,22 @@ var Schemas = map[int]Schema{
 	11: v11Schema{},
 	12: v12Schema{},
 	13: v13Schema{},
+	14: v14Schema{},
+	15: v15Schema{},
+	16: v16Schema{},
+	17: v17Schema{},
+	18: v18Schema{},
+	19: v19Schema{},
+	20: v20Schema{},
+	21: v21Schema{},
+	22: v22Schema{},
+	23: v23Schema{},
+	24: v24Schema{},
+	25: v25Schema{},
+	26: v26Schema{},
+	27: v27Schema{},
+	28: v28Schema{},
+	29: v29Schema{},
 }
 
 type Schema interface {
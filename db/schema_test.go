@@ -70,7 +70,7 @@ var _ = Describe("Database Schema", func() {
 
 				var v int
 				Ω(r.Scan(&v)).Should(Succeed())
-				Ω(v).Should(Equal(13))
+				Ω(v).Should(Equal(29))
 			})
 
 			It("creates the correct tables", func() {
@@ -87,6 +87,11 @@ var _ = Describe("Database Schema", func() {
 				tableExists("jobs")
 				tableExists("archives")
 				tableExists("tasks")
+				tableExists("blackouts")
+				tableExists("calendars")
+				tableExists("calendar_dates")
+				tableExists("archive_labels")
+				tableExists("archive_terms")
 			})
 		})
 	})
@@ -0,0 +1,19 @@
+package db
+
+type v14Schema struct{}
+
+func (s v14Schema) Deploy(db *DB) error {
+	var err error
+
+	err = db.Exec(`ALTER TABLE tenants ADD COLUMN scheduler_share INTEGER NOT NULL DEFAULT 1`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`UPDATE schema_info set version = 14`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package db
+
+type v15Schema struct{}
+
+func (s v15Schema) Deploy(db *DB) error {
+	var err error
+
+	err = db.Exec(`ALTER TABLE jobs ADD COLUMN max_runtime INTEGER NOT NULL DEFAULT 0`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`ALTER TABLE jobs ADD COLUMN window_start TEXT NOT NULL DEFAULT ''`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`ALTER TABLE jobs ADD COLUMN window_end TEXT NOT NULL DEFAULT ''`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`ALTER TABLE jobs ADD COLUMN finish_by TEXT NOT NULL DEFAULT ''`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`UPDATE schema_info set version = 15`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package db
+
+type v16Schema struct{}
+
+func (s v16Schema) Deploy(db *DB) error {
+	var err error
+
+	err = db.Exec(`CREATE TABLE blackouts (
+	                 uuid         UUID PRIMARY KEY,
+	                 tenant_uuid  UUID NOT NULL,
+	                 target_uuid  UUID NOT NULL DEFAULT '',
+	                 name         TEXT NOT NULL,
+	                 summary      TEXT NOT NULL DEFAULT '',
+	                 schedule     TEXT NOT NULL DEFAULT '',
+	                 duration     INTEGER NOT NULL DEFAULT 0,
+	                 starts_at    INTEGER NOT NULL DEFAULT 0,
+	                 ends_at      INTEGER NOT NULL DEFAULT 0,
+	                 catch_up     BOOLEAN NOT NULL DEFAULT 0
+	               )`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`ALTER TABLE jobs ADD COLUMN missed_run INTEGER NOT NULL DEFAULT 0`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`UPDATE schema_info set version = 16`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
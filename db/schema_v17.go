@@ -0,0 +1,29 @@
+package db
+
+type v17Schema struct{}
+
+func (s v17Schema) Deploy(db *DB) error {
+	var err error
+
+	err = db.Exec(`ALTER TABLE jobs ADD COLUMN jitter INTEGER NOT NULL DEFAULT 0`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`ALTER TABLE jobs ADD COLUMN hashed_jitter BOOLEAN NOT NULL DEFAULT 0`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`ALTER TABLE tenants ADD COLUMN spread_window INTEGER NOT NULL DEFAULT 0`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`UPDATE schema_info set version = 17`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
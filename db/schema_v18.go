@@ -0,0 +1,33 @@
+package db
+
+type v18Schema struct{}
+
+func (s v18Schema) Deploy(db *DB) error {
+	var err error
+
+	err = db.Exec(`CREATE TABLE calendars (
+	                 uuid     UUID PRIMARY KEY,
+	                 name     TEXT NOT NULL UNIQUE,
+	                 summary  TEXT NOT NULL DEFAULT ''
+	               )`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`CREATE TABLE calendar_dates (
+	                 calendar_uuid  UUID NOT NULL,
+	                 date           TEXT NOT NULL,
+
+	                 PRIMARY KEY (calendar_uuid, date)
+	               )`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`UPDATE schema_info set version = 18`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
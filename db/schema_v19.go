@@ -0,0 +1,39 @@
+package db
+
+type v19Schema struct{}
+
+func (s v19Schema) Deploy(db *DB) error {
+	var err error
+
+	err = db.Exec(`ALTER TABLE archives ADD COLUMN held INTEGER NOT NULL DEFAULT 0`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`ALTER TABLE archives ADD COLUMN hold_reason TEXT NOT NULL DEFAULT ''`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`ALTER TABLE archives ADD COLUMN held_by TEXT NOT NULL DEFAULT ''`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`ALTER TABLE archives ADD COLUMN held_at INTEGER NOT NULL DEFAULT 0`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`ALTER TABLE archives ADD COLUMN hold_until INTEGER NOT NULL DEFAULT 0`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`UPDATE schema_info set version = 19`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,19 @@
+package db
+
+type v20Schema struct{}
+
+func (s v20Schema) Deploy(db *DB) error {
+	var err error
+
+	err = db.Exec(`ALTER TABLE archives ADD COLUMN purge_after INTEGER NOT NULL DEFAULT 0`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`UPDATE schema_info set version = 20`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
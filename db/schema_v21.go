@@ -0,0 +1,74 @@
+package db
+
+type v21Schema struct{}
+
+func (s v21Schema) Deploy(db *DB) error {
+	var err error
+
+	err = db.Exec(`CREATE TABLE archive_labels (
+	                 archive_uuid  UUID NOT NULL,
+	                 name          TEXT NOT NULL,
+	                 value         TEXT NOT NULL DEFAULT '',
+
+	                 PRIMARY KEY (archive_uuid, name)
+	               )`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`CREATE INDEX archive_labels_by_value ON archive_labels (name, value)`)
+	if err != nil {
+		return err
+	}
+
+	/* the search index is keyed on term first, so that
+	   searches can find their archives without a table scan */
+	err = db.Exec(`CREATE TABLE archive_terms (
+	                 term          TEXT NOT NULL,
+	                 archive_uuid  UUID NOT NULL,
+
+	                 PRIMARY KEY (term, archive_uuid)
+	               )`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`CREATE INDEX archive_terms_by_archive ON archive_terms (archive_uuid)`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`CREATE INDEX archives_by_tenant ON archives (tenant_uuid, taken_at)`)
+	if err != nil {
+		return err
+	}
+
+	/* label existing archives the way new ones will be */
+	for _, query := range []string{
+		`SELECT a.uuid, 'job', a.job
+		   FROM archives a WHERE a.job != ''`,
+		`SELECT a.uuid, 'target', t.name
+		   FROM archives a INNER JOIN targets t ON t.uuid = a.target_uuid`,
+		`SELECT a.uuid, 'plugin', t.plugin
+		   FROM archives a INNER JOIN targets t ON t.uuid = a.target_uuid`,
+		`SELECT a.uuid, 'store', s.name
+		   FROM archives a INNER JOIN stores s ON s.uuid = a.store_uuid`,
+	} {
+		err = db.Exec(`INSERT INTO archive_labels (archive_uuid, name, value) ` + query)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = db.exclusively(db.reindexAllArchives)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`UPDATE schema_info set version = 21`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package db
+
+type v22Schema struct{}
+
+func (s v22Schema) Deploy(db *DB) error {
+	var err error
+
+	/* auth tokens live in the sessions table; these columns
+	   limit what a token can be used for, and until when. */
+	for _, col := range []string{
+		`scope_tenant  TEXT    NOT NULL DEFAULT ''`,
+		`scope_role    TEXT    NOT NULL DEFAULT ''`,
+		`scope_ops     TEXT    NOT NULL DEFAULT ''`,
+		`scope_allow   TEXT    NOT NULL DEFAULT ''`,
+		`expires_at    INTEGER NOT NULL DEFAULT 0`,
+	} {
+		err = db.Exec(`ALTER TABLE sessions ADD COLUMN ` + col)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = db.Exec(`UPDATE schema_info set version = 22`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
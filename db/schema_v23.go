@@ -0,0 +1,41 @@
+package db
+
+type v23Schema struct{}
+
+func (s v23Schema) Deploy(db *DB) error {
+	var err error
+
+	/* local users can enrol in TOTP multi-factor authentication;
+	   mfa_secret is set when enrolment starts, but mfa_enabled is
+	   only set once the user has proven they can generate codes.
+	   mfa_last_step is the time step of the last code accepted,
+	   so that codes cannot be replayed. */
+	for _, col := range []string{
+		`mfa_secret     TEXT    NOT NULL DEFAULT ''`,
+		`mfa_enabled    BOOLEAN NOT NULL DEFAULT 0`,
+		`mfa_last_step  INTEGER NOT NULL DEFAULT 0`,
+	} {
+		err = db.Exec(`ALTER TABLE users ADD COLUMN ` + col)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = db.Exec(`CREATE TABLE mfa_recovery_codes (
+	                 user_uuid  UUID    NOT NULL,
+	                 hash       TEXT    NOT NULL,
+	                 used_at    INTEGER DEFAULT NULL,
+
+	                 PRIMARY KEY (user_uuid, hash)
+	               )`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`UPDATE schema_info set version = 23`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
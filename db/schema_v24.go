@@ -0,0 +1,30 @@
+package db
+
+type v24Schema struct{}
+
+func (s v24Schema) Deploy(db *DB) error {
+	var err error
+
+	/* failed logins are tracked per account (whether or not it
+	   exists), and per remote IP address; once enough failures
+	   pile up, further attempts are refused until locked_until. */
+	err = db.Exec(`CREATE TABLE login_failures (
+	                 kind            TEXT    NOT NULL,
+	                 name            TEXT    NOT NULL,
+	                 failures        INTEGER NOT NULL DEFAULT 0,
+	                 last_failed_at  INTEGER NOT NULL DEFAULT 0,
+	                 locked_until    INTEGER NOT NULL DEFAULT 0,
+
+	                 PRIMARY KEY (kind, name)
+	               )`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`UPDATE schema_info set version = 24`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package db
+
+type v25Schema struct{}
+
+func (s v25Schema) Deploy(db *DB) error {
+	var err error
+
+	/* the audit log records every mutating API request; each
+	   entry carries the hash of the one before it, so that any
+	   tampering with the history breaks the chain. */
+	err = db.Exec(`CREATE TABLE audit_log (
+	                 seq          INTEGER PRIMARY KEY,
+	                 uuid         UUID    NOT NULL,
+	                 at           INTEGER NOT NULL,
+	                 actor        TEXT    NOT NULL DEFAULT '',
+	                 actor_uuid   TEXT    NOT NULL DEFAULT '',
+	                 session_uuid TEXT    NOT NULL DEFAULT '',
+	                 token_uuid   TEXT    NOT NULL DEFAULT '',
+	                 ip           TEXT    NOT NULL DEFAULT '',
+	                 method       TEXT    NOT NULL,
+	                 route        TEXT    NOT NULL,
+	                 path         TEXT    NOT NULL,
+	                 status       INTEGER NOT NULL DEFAULT 0,
+	                 tenant_uuid  TEXT    NOT NULL DEFAULT '',
+	                 object_type  TEXT    NOT NULL DEFAULT '',
+	                 object_uuid  TEXT    NOT NULL DEFAULT '',
+	                 changes      TEXT    NOT NULL DEFAULT '',
+	                 prev_hash    TEXT    NOT NULL DEFAULT '',
+	                 hash         TEXT    NOT NULL
+	               )`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`CREATE INDEX audit_log_at ON audit_log (at)`)
+	if err != nil {
+		return err
+	}
+
+	/* entries can only ever be appended, never changed or removed */
+	err = db.Exec(`CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+	               BEGIN
+	                 SELECT RAISE(ABORT, 'the audit log is append-only');
+	               END`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+	               BEGIN
+	                 SELECT RAISE(ABORT, 'the audit log is append-only');
+	               END`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`UPDATE schema_info set version = 25`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package db
+
+type v26Schema struct{}
+
+func (s v26Schema) Deploy(db *DB) error {
+	var err error
+
+	/* custom tenant roles are named sets of permissions, defined
+	   per tenant, that members can be assigned in place of (or in
+	   addition to) the built-in admin, engineer, and operator. */
+	err = db.Exec(`CREATE TABLE roles (
+	                 tenant_uuid  UUID NOT NULL,
+	                 name         TEXT NOT NULL,
+	                 summary      TEXT NOT NULL DEFAULT '',
+	                 permissions  TEXT NOT NULL DEFAULT '',
+
+	                 PRIMARY KEY (tenant_uuid, name)
+	               )`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`UPDATE schema_info set version = 26`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
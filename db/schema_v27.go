@@ -0,0 +1,50 @@
+package db
+
+type v27Schema struct{}
+
+func (s v27Schema) Deploy(db *DB) error {
+	var err error
+
+	/* tenants can require that restores, archive purges, and target
+	   deletions be approved by a second person before they happen;
+	   requests for approval that go unanswered for approval_timeout
+	   minutes expire. */
+	err = db.Exec(`ALTER TABLE tenants ADD COLUMN require_approval BOOLEAN NOT NULL DEFAULT 0`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`ALTER TABLE tenants ADD COLUMN approval_timeout INTEGER NOT NULL DEFAULT 1440`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`CREATE TABLE approvals (
+	                 uuid            UUID    PRIMARY KEY,
+	                 tenant_uuid     UUID    NOT NULL,
+	                 operation       TEXT    NOT NULL,
+	                 archive_uuid    UUID    NOT NULL DEFAULT '',
+	                 target_uuid     UUID    NOT NULL DEFAULT '',
+	                 summary         TEXT    NOT NULL DEFAULT '',
+
+	                 requested_by    TEXT    NOT NULL,
+	                 requester_uuid  UUID    NOT NULL,
+	                 requested_at    INTEGER NOT NULL,
+	                 expires_at      INTEGER NOT NULL,
+
+	                 status          TEXT    NOT NULL DEFAULT 'pending',
+	                 decided_by      TEXT    NOT NULL DEFAULT '',
+	                 decided_at      INTEGER NOT NULL DEFAULT 0,
+	                 task_uuid       UUID    NOT NULL DEFAULT ''
+	               )`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`UPDATE schema_info set version = 27`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
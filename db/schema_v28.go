@@ -0,0 +1,32 @@
+package db
+
+type v28Schema struct{}
+
+func (s v28Schema) Deploy(db *DB) error {
+	var err error
+
+	/* tenants can be given quotas on how much storage they use, how
+	   many archives, jobs, and targets they have, and how many tasks
+	   they can run at once; a quota of 0 means "unlimited".  unless
+	   enforce_quotas is set, going over quota only draws a warning. */
+	for _, column := range []string{
+		`quota_storage  INTEGER NOT NULL DEFAULT 0`,
+		`quota_archives INTEGER NOT NULL DEFAULT 0`,
+		`quota_jobs     INTEGER NOT NULL DEFAULT 0`,
+		`quota_targets  INTEGER NOT NULL DEFAULT 0`,
+		`quota_tasks    INTEGER NOT NULL DEFAULT 0`,
+		`enforce_quotas BOOLEAN NOT NULL DEFAULT 0`,
+	} {
+		err = db.Exec(`ALTER TABLE tenants ADD COLUMN ` + column)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = db.Exec(`UPDATE schema_info set version = 28`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
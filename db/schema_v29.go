@@ -0,0 +1,32 @@
+package db
+
+type v29Schema struct{}
+
+func (s v29Schema) Deploy(db *DB) error {
+	var err error
+
+	/* tenant memberships assigned by an auth provider (from group or
+	   team mappings) are replaced every time the user logs in; those
+	   granted by hand are left alone.  until now, providers could only
+	   ever assign the built-in roles, and they wiped everything else,
+	   so those are the ones to mark as external. */
+	err = db.Exec(`ALTER TABLE memberships ADD COLUMN external BOOLEAN NOT NULL DEFAULT 0`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`
+	   UPDATE memberships SET external = 1
+	    WHERE role IN ('admin', 'engineer', 'operator')
+	      AND user_uuid IN (SELECT uuid FROM users WHERE backend != 'local')`)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`UPDATE schema_info set version = 29`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -19,6 +19,8 @@ type Session struct {
 	UserAgent      string `json:"user_agent"`
 	UserAccount    string `json:"user_account"`
 	CurrentSession bool   `json:"current_session"`
+
+	Scope TokenScope `json:"-"`
 }
 
 type SessionFilter struct {
@@ -124,7 +126,8 @@ func (db *DB) GetSession(id string) (*Session, error) {
 
 	r, err := db.query(`
 	         SELECT s.uuid, s.user_uuid, s.created_at, s.last_seen, s.token,
-	                s.name, s.ip_addr, s.user_agent, u.account, u.backend
+	                s.name, s.ip_addr, s.user_agent, u.account, u.backend,
+	                s.scope_tenant, s.scope_role, s.scope_ops, s.scope_allow, s.expires_at
 
 	           FROM sessions s
 	     INNER JOIN users u   ON u.uuid = s.user_uuid
@@ -141,14 +144,18 @@ func (db *DB) GetSession(id string) (*Session, error) {
 
 	s := &Session{}
 	var (
-		backend string
-		last    *int64
-		token   sql.NullString
+		backend    string
+		last       *int64
+		token      sql.NullString
+		ops, allow string
 	)
 	if err := r.Scan(&s.UUID, &s.UserUUID, &s.CreatedAt, &last, &token,
-		&s.Name, &s.IP, &s.UserAgent, &s.UserAccount, &backend); err != nil {
+		&s.Name, &s.IP, &s.UserAgent, &s.UserAccount, &backend,
+		&s.Scope.Tenant, &s.Scope.Role, &ops, &allow, &s.Scope.ExpiresAt); err != nil {
 		return nil, err
 	}
+	s.Scope.Ops = splitList(ops)
+	s.Scope.Allow = splitList(allow)
 	s.UserAccount = s.UserAccount + "@" + backend
 	if token.Valid {
 		s.Token = token.String
@@ -165,7 +172,8 @@ func (db *DB) GetUserForSession(id string) (*User, error) {
 	defer db.exclusive.Unlock()
 	r, err := db.query(`
 	        SELECT u.uuid, u.name, u.account, u.backend, u.sysrole,
-	               u.pwhash, u.default_tenant
+	               u.pwhash, u.default_tenant, u.mfa_secret, u.mfa_enabled,
+	               u.mfa_last_step
 
 	          FROM sessions s
 	    INNER JOIN users u ON u.uuid = s.user_uuid
@@ -182,7 +190,7 @@ func (db *DB) GetUserForSession(id string) (*User, error) {
 	u := &User{}
 	var pwhash sql.NullString
 	if err := r.Scan(&u.UUID, &u.Name, &u.Account, &u.Backend, &u.SysRole,
-		&pwhash, &u.DefaultTenant); err != nil {
+		&pwhash, &u.DefaultTenant, &u.mfaSecret, &u.MFAEnabled, &u.mfaLastStep); err != nil {
 		return nil, err
 	}
 	if pwhash.Valid {
@@ -311,6 +311,11 @@ func (db *DB) DeleteTarget(id string) (bool, error) {
 		return false, err
 	}
 
+	err = db.Exec(`DELETE FROM blackouts WHERE target_uuid = ?`, id)
+	if err != nil {
+		return false, err
+	}
+
 	db.sendDeleteObjectEvent(target, "tenant:"+target.TenantUUID)
 	return true, nil
 }
@@ -15,15 +15,20 @@ const (
 	RestoreOperation        = "restore"
 	ShieldRestoreOperation  = "shield-restore"
 	PurgeOperation          = "purge"
+	HoldOperation           = "hold"
+	ReleaseOperation        = "release"
 	TestStoreOperation      = "test-store"
 	AgentStatusOperation    = "agent-status"
 	AnalyzeStorageOperation = "analyze-storage"
+	DownloadOperation       = "download"
+	ImportOperation         = "import"
 
 	PendingStatus   = "pending"
 	ScheduledStatus = "scheduled"
 	RunningStatus   = "running"
 	CanceledStatus  = "canceled"
 	FailedStatus    = "failed"
+	OverrunStatus   = "overrun"
 	DoneStatus      = "done"
 )
 
@@ -515,21 +520,159 @@ func (db *DB) CreateRestoreTask(owner string, archive *Archive, target *Target)
 }
 
 func (db *DB) CreatePurgeTask(owner string, archive *Archive) (*Task, error) {
+	return db.createArchiveTask(owner, PurgeOperation, archive)
+}
+
+// CreateHoldTask schedules a task to place the archive under a hold
+// in its cloud storage provider, for store plugins that support it.
+func (db *DB) CreateHoldTask(owner string, archive *Archive) (*Task, error) {
+	return db.createArchiveTask(owner, HoldOperation, archive)
+}
+
+// CreateReleaseTask schedules a task to lift a hold placed on the
+// archive in its cloud storage provider by CreateHoldTask.
+func (db *DB) CreateReleaseTask(owner string, archive *Archive) (*Task, error) {
+	return db.createArchiveTask(owner, ReleaseOperation, archive)
+}
+
+// CreateDownloadTask records the download of an archive, through the
+// agent that stored it.  Downloads are streamed straight back to the
+// requester, rather than going through the scheduler, so the task
+// starts out running.  The compression recorded against the task is
+// what the agent should decompress the archive with ("none" to leave
+// the archive compressed.)
+func (db *DB) CreateDownloadTask(owner string, archive *Archive, compression string) (*Task, error) {
 	id := RandomID()
 	err := db.exclusively(func() error {
 		/* validate the archive */
 		if err := db.archiveShouldExist(archive.UUID); err != nil {
-			return fmt.Errorf("unable to create purge task: %s", err)
+			return fmt.Errorf("unable to create download task: %s", err)
 		}
 
 		/* validate the tenant */
 		if err := db.tenantShouldExist(archive.TenantUUID); err != nil {
-			return fmt.Errorf("unable to create purge task: %s", err)
+			return fmt.Errorf("unable to create download task: %s", err)
 		}
 
 		/* validate the store */
 		if err := db.storeShouldExist(archive.StoreUUID); err != nil {
-			return fmt.Errorf("unable to create purge task: %s", err)
+			return fmt.Errorf("unable to create download task: %s", err)
+		}
+
+		now := time.Now().Unix()
+		return db.exec(
+			`INSERT INTO tasks
+                (uuid, owner, op, archive_uuid, status, log, requested_at, started_at,
+                 store_uuid, store_plugin, store_endpoint,
+                 target_plugin, target_endpoint,
+                 restore_key, compression, agent, attempts, tenant_uuid)
+              VALUES
+                (?, ?, ?, ?, ?, ?, ?, ?,
+                 ?, ?, ?,
+                 ?, ?,
+                 ?, ?, ?, ?, ?)`,
+			id, owner, DownloadOperation, archive.UUID, RunningStatus, "", now, now,
+			archive.StoreUUID, archive.StorePlugin, archive.StoreEndpoint,
+			"", "",
+			archive.StoreKey, compression, archive.StoreAgent, 0, archive.TenantUUID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := db.GetTask(id)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		return nil, fmt.Errorf("failed to retrieve newly-inserted task [%s]: not found in database.", id)
+	}
+
+	db.sendCreateObjectEvent(task, "tenant:"+archive.TenantUUID)
+	return task, nil
+}
+
+// CreateImportTask records the import of an externally produced
+// backup into the given store, by way of the store's agent.  The data
+// comes either from the request that started the import (an upload),
+// or from the store itself, if key is given.  Like downloads, imports
+// are carried out right away, so the task starts out running.
+//
+// The new archive is not created until the import succeeds; as with
+// backups, its UUID is recorded in the task ahead of time.
+func (db *DB) CreateImportTask(owner string, target *Target, store *Store, key, compression string) (*Task, error) {
+	endpoint, err := store.ConfigJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	id := RandomID()
+	archive := RandomID()
+	err = db.exclusively(func() error {
+		/* validate the tenant */
+		if err := db.tenantShouldExist(target.TenantUUID); err != nil {
+			return fmt.Errorf("unable to create import task: %s", err)
+		}
+
+		/* validate the target */
+		if err := db.targetShouldExist(target.UUID); err != nil {
+			return fmt.Errorf("unable to create import task: %s", err)
+		}
+
+		/* validate the store */
+		if err := db.storeShouldExist(store.UUID); err != nil {
+			return fmt.Errorf("unable to create import task: %s", err)
+		}
+
+		now := time.Now().Unix()
+		return db.exec(
+			`INSERT INTO tasks
+                (uuid, owner, op, status, log, requested_at, started_at,
+                 archive_uuid, store_uuid, store_plugin, store_endpoint,
+                 target_uuid, target_plugin, target_endpoint,
+                 restore_key, compression, agent, attempts, tenant_uuid)
+              VALUES
+                (?, ?, ?, ?, ?, ?, ?,
+                 ?, ?, ?, ?,
+                 ?, ?, ?,
+                 ?, ?, ?, ?, ?)`,
+			id, owner, ImportOperation, RunningStatus, "", now, now,
+			archive, store.UUID, store.Plugin, endpoint,
+			target.UUID, "", "",
+			key, compression, store.Agent, 0, target.TenantUUID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := db.GetTask(id)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		return nil, fmt.Errorf("failed to retrieve newly-inserted task [%s]: not found in database.", id)
+	}
+
+	db.sendCreateObjectEvent(task, "tenant:"+target.TenantUUID)
+	return task, nil
+}
+
+func (db *DB) createArchiveTask(owner, op string, archive *Archive) (*Task, error) {
+	id := RandomID()
+	err := db.exclusively(func() error {
+		/* validate the archive */
+		if err := db.archiveShouldExist(archive.UUID); err != nil {
+			return fmt.Errorf("unable to create %s task: %s", op, err)
+		}
+
+		/* validate the tenant */
+		if err := db.tenantShouldExist(archive.TenantUUID); err != nil {
+			return fmt.Errorf("unable to create %s task: %s", op, err)
+		}
+
+		/* validate the store */
+		if err := db.storeShouldExist(archive.StoreUUID); err != nil {
+			return fmt.Errorf("unable to create %s task: %s", op, err)
 		}
 
 		return db.exec(
@@ -543,7 +686,7 @@ func (db *DB) CreatePurgeTask(owner string, archive *Archive) (*Task, error) {
                  ?, ?, ?,
                  ?, ?,
                  ?, ?, ?, ?)`,
-			id, owner, PurgeOperation, archive.UUID, PendingStatus, "", time.Now().Unix(),
+			id, owner, op, archive.UUID, PendingStatus, "", time.Now().Unix(),
 			archive.StoreUUID, archive.StorePlugin, archive.StoreEndpoint,
 			"", "",
 			archive.StoreKey, archive.StoreAgent, 0, archive.TenantUUID)
@@ -800,6 +943,61 @@ func (db *DB) FailTask(id string, at time.Time) error {
 	return nil
 }
 
+// OverrunTask marks a task as having been terminated by the scheduler
+// for running past its job's maximum run time or backup window.  As
+// with FailTask, the job and target are marked unhealthy.
+func (db *DB) OverrunTask(id string, at time.Time) error {
+	//updateTaskStatus grabs the lock
+	err := db.updateTaskStatus(id, OverrunStatus, effectively(at), 0)
+	if err != nil {
+		return err
+	}
+	task, err := db.GetTask(id)
+	if err != nil {
+		return err
+	}
+	if task.JobUUID != "" {
+		err = db.UpdateJobHealth(task.JobUUID, false)
+		if err != nil {
+			return err
+		}
+		err = db.UpdateTargetHealth(task.TargetUUID, false)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TaskDeadline records the time by which a running task must finish,
+// after which the scheduler will terminate it.
+func (db *DB) TaskDeadline(id string, at time.Time) error {
+	return db.Exec(`UPDATE tasks SET timeout_at = ? WHERE uuid = ?`,
+		at.Unix(), id)
+}
+
+// DeferTask returns a scheduled task to the pending state, so that it
+// will be picked up again by the core on a later pass.
+func (db *DB) DeferTask(id string) error {
+	err := db.Exec(
+		`UPDATE tasks SET status = ? WHERE uuid = ?`,
+		PendingStatus, id)
+	if err != nil {
+		return err
+	}
+
+	task, err := db.GetTask(id)
+	if err != nil {
+		return err
+	}
+	if task == nil {
+		return fmt.Errorf("task '%s' not found", id)
+	}
+
+	db.sendTaskStatusUpdateEvent(task, "tenant:"+task.TenantUUID)
+	return nil
+}
+
 func (db *DB) CompleteTask(id string, at time.Time) error {
 	//updateTaskStatus grabs the lock
 	err := db.updateTaskStatus(id, DoneStatus, effectively(at), 1)
@@ -874,7 +1072,7 @@ func (db *DB) createTaskArchive(id, archive_id, key string, at time.Time, encryp
 		UUID:           archive_id,
 		StoreKey:       key,
 		TakenAt:        effectively(at),
-		ExpiresAt:      at.Add(time.Duration(n*24) * time.Hour).Unix(),
+		ExpiresAt:      ArchiveExpiry(at, n).Unix(),
 		EncryptionType: encryptionType,
 		Compression:    compression,
 		Size:           archive_size,
@@ -884,6 +1082,21 @@ func (db *DB) createTaskArchive(id, archive_id, key string, at time.Time, encryp
 		log.Errorf("failed to insert archive with UUID %s into database: %s", archive.UUID, err)
 		return "", err
 	}
+
+	// label the archive with what we know about the job that made it
+	err = db.labelArchive(archive.UUID, map[string]string{
+		"job":    archive.Job,
+		"target": archive.TargetName,
+		"plugin": archive.TargetPlugin,
+		"store":  archive.StoreName,
+	})
+	if err != nil {
+		log.Errorf("failed to label archive %s: %s", archive.UUID, err)
+		return "", err
+	}
+	if archive, err = db.getArchive(archive_id); err != nil {
+		return "", err
+	}
 	db.sendCreateObjectEvent(archive, "tenant:"+archive.TenantUUID)
 
 	// and finally, associate task -> archive
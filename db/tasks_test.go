@@ -133,6 +133,37 @@ var _ = Describe("Task Management", func() {
 		shouldExist(`SELECT * FROM tasks WHERE agent = ?`, "127.0.0.1:9938")
 	})
 
+	It("Can create a new download task, already running", func() {
+		task, err := db.CreateDownloadTask("owner-name", SomeArchive, "none")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(task).ShouldNot(BeNil())
+
+		shouldExist(`SELECT * FROM tasks WHERE uuid = ?`, task.UUID)
+		shouldExist(`SELECT * FROM tasks WHERE op = ?`, DownloadOperation)
+		shouldExist(`SELECT * FROM tasks WHERE archive_uuid = ?`, SomeArchive.UUID)
+		shouldExist(`SELECT * FROM tasks WHERE restore_key = ?`, "key")
+		shouldExist(`SELECT * FROM tasks WHERE compression = ?`, "none")
+		shouldExist(`SELECT * FROM tasks WHERE status = ?`, RunningStatus)
+		shouldExist(`SELECT * FROM tasks WHERE started_at IS NOT NULL`)
+		shouldExist(`SELECT * FROM tasks WHERE stopped_at IS NULL`)
+		shouldExist(`SELECT * FROM tasks WHERE agent = ?`, "127.0.0.1:9938")
+	})
+
+	It("Can create a new import task, already running", func() {
+		task, err := db.CreateImportTask("owner-name", SomeTarget, SomeStore, "", "gzip")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(task).ShouldNot(BeNil())
+
+		Expect(task.Op).Should(Equal(ImportOperation))
+		Expect(task.Status).Should(Equal(RunningStatus))
+		Expect(task.ArchiveUUID).ShouldNot(BeEmpty())
+		Expect(task.TargetUUID).Should(Equal(SomeTarget.UUID))
+		Expect(task.StoreUUID).Should(Equal(SomeStore.UUID))
+		Expect(task.Compression).Should(Equal("gzip"))
+		Expect(task.Agent).Should(Equal("127.0.0.1:9938"))
+		shouldExist(`SELECT * FROM tasks WHERE uuid = ? AND started_at IS NOT NULL`, task.UUID)
+	})
+
 	It("Can create a new restore task", func() {
 		task, err := db.CreateRestoreTask("owner-name", SomeArchive, SomeTarget)
 		Ω(err).ShouldNot(HaveOccurred())
@@ -257,6 +288,27 @@ var _ = Describe("Task Management", func() {
 		shouldExist(`SELECT * FROM archives WHERE taken_at IS NOT NULL`)
 		shouldExist(`SELECT * FROM archives WHERE expires_at IS NOT NULL`)
 	})
+	It("Labels archives with the job that made them", func() {
+		task, err := db.CreateBackupTask("bob", SomeJob)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		archive_id, err := db.CreateTaskArchive(task.UUID, RandomID(), "SOME-KEY", time.Now(), "aes-256-ctr", "gz", 0, task.TenantUUID)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		archive, err := db.GetArchive(archive_id)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(archive.Labels).Should(Equal(map[string]string{
+			"job":    "Some Job",
+			"target": "Some Target",
+			"plugin": "plugin",
+			"store":  "Some Store",
+		}))
+
+		l, err := db.GetAllArchives(&ArchiveFilter{Search: "some job"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(len(l)).Should(Equal(1))
+		Ω(l[0].UUID).Should(Equal(archive_id))
+	})
 	It("Fails to associate archives with a task, when no restore key is present", func() {
 		task, err := db.CreateBackupTask("bob", SomeJob)
 		Expect(err).ShouldNot(HaveOccurred())
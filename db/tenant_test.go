@@ -167,6 +167,138 @@ var _ = Describe("tenant Management", func() {
 		Ω(num).Should(Equal(1))
 	})
 
+	It("gives tenants a scheduler share of 1 by default", func() {
+		tenant, err := db.GetTenant(Tenant2.UUID)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(tenant).ShouldNot(BeNil())
+		Ω(tenant.Share).Should(Equal(1))
+
+		tenant, err = db.CreateTenant(&Tenant{Name: "tenant4"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(tenant.Share).Should(Equal(1))
+	})
+
+	It("can update a tenant's scheduler share", func() {
+		tenant, err := db.GetTenant(Tenant2.UUID)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		tenant.Share = 3
+		_, err = db.UpdateTenant(tenant)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		tenant, err = db.GetTenant(Tenant2.UUID)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(tenant.Share).Should(Equal(3))
+	})
+
+	It("updates storage statistics without undoing changes to the tenant's settings", func() {
+		stale, err := db.GetTenant(Tenant2.UUID)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		/* an admin turns on two-person approval while the
+		   storage analysis is still working on the tenant */
+		tenant, err := db.GetTenant(Tenant2.UUID)
+		Ω(err).ShouldNot(HaveOccurred())
+		tenant.RequireApproval = true
+		tenant.QuotaJobs = 10
+		_, err = db.UpdateTenant(tenant)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		stale.DailyIncrease = 1024
+		stale.StorageUsed = 4096
+		stale.ArchiveCount = 4
+		Ω(db.UpdateTenantStats(stale)).Should(Succeed())
+
+		tenant, err = db.GetTenant(Tenant2.UUID)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(tenant.RequireApproval).Should(BeTrue())
+		Ω(tenant.QuotaJobs).Should(Equal(10))
+		Ω(tenant.DailyIncrease).Should(Equal(int64(1024)))
+		Ω(tenant.StorageUsed).Should(Equal(int64(4096)))
+		Ω(tenant.ArchiveCount).Should(Equal(4))
+	})
+
+	It("spreads jobs on the same schedule across the tenant's spread window", func() {
+		other := "00000000-581a-415e-abc0-0234bc70c7a9"
+		err := db.Exec(`INSERT INTO jobs (uuid, name, summary, paused, target_uuid, store_uuid, keep_n, keep_days, retries, schedule, tenant_uuid, jitter)
+		                   VALUES (?, "Other Job", "", 0, ?, ?, 4, 4, 4, "daily at 3:00", ?, 10)`,
+			other, SomeTarget.UUID, SomeStore.UUID, Tenant2.UUID)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		tenant, err := db.GetTenant(Tenant2.UUID)
+		Ω(err).ShouldNot(HaveOccurred())
+		tenant.Spread = 60
+		_, err = db.UpdateTenant(tenant)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		otherJob, err := db.GetJob(other)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		spec, err := db.JobTimespec(otherJob)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(spec.Offset).Should(Equal(time.Duration(0)))
+		Ω(spec.Jitter).Should(Equal(time.Duration(0)))
+
+		spec, err = db.JobTimespec(SomeJob)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(spec.Offset).Should(Equal(30 * time.Minute))
+	})
+
+	It("reschedules a tenant's spread jobs as jobs come and go", func() {
+		other := "00000000-581a-415e-abc0-0234bc70c7a9"
+		err := db.Exec(`INSERT INTO jobs (uuid, name, summary, paused, target_uuid, store_uuid, keep_n, keep_days, retries, schedule, tenant_uuid)
+		                   VALUES (?, "Other Job", "", 0, ?, ?, 4, 4, 4, "daily at 3:00", ?)`,
+			other, SomeTarget.UUID, SomeStore.UUID, Tenant2.UUID)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		tenant, err := db.GetTenant(Tenant2.UUID)
+		Ω(err).ShouldNot(HaveOccurred())
+		tenant.Spread = 60
+		_, err = db.UpdateTenant(tenant)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		jobs, err := db.GetAllJobs(&JobFilter{ForTenant: Tenant2.UUID})
+		Ω(err).ShouldNot(HaveOccurred())
+		specs, err := db.JobTimespecs(jobs)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(specs).Should(HaveLen(2))
+		Ω(specs[other].Offset).Should(Equal(time.Duration(0)))
+		Ω(specs[SomeJob.UUID].Offset).Should(Equal(30 * time.Minute))
+
+		from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)
+		Ω(db.RespreadJobs(Tenant2.UUID, from)).Should(Succeed())
+		n, err := db.Count(`SELECT uuid FROM jobs WHERE uuid = ? AND next_run = ?`,
+			SomeJob.UUID, from.Add(3*time.Hour+30*time.Minute).Unix())
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(n).Should(Equal(uint(1)))
+
+		_, err = db.DeleteJob(other)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(db.RespreadJobs(Tenant2.UUID, from)).Should(Succeed())
+		n, err = db.Count(`SELECT uuid FROM jobs WHERE uuid = ? AND next_run = ?`,
+			SomeJob.UUID, from.Add(3*time.Hour).Unix())
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(n).Should(Equal(uint(1)))
+	})
+
+	It("applies per-job jitter when the tenant does not spread its jobs", func() {
+		SomeJob.Jitter = 15
+		spec, err := db.JobTimespec(SomeJob)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(spec.Offset).Should(Equal(time.Duration(0)))
+		Ω(spec.Jitter).Should(Equal(15 * time.Minute))
+
+		SomeJob.HashedJitter = true
+		spec, err = db.JobTimespec(SomeJob)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(spec.Jitter).Should(Equal(time.Duration(0)))
+		Ω(spec.Offset).Should(BeNumerically("<", 15*time.Minute))
+
+		again, err := db.JobTimespec(SomeJob)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(again.Offset).Should(Equal(spec.Offset))
+	})
+
 	It("Will fail non recursive with jobs", func() {
 		err := db.DeleteTenant(Tenant2, false)
 		Expect(err).Should(HaveOccurred())
@@ -6,14 +6,30 @@ import (
 )
 
 type Tenant struct {
-	UUID          string  `json:"uuid"              mbus:"uuid"`
-	Name          string  `json:"name"              mbus:"name"`
-	Members       []*User `json:"members,omitempty"`
-	DailyIncrease int64   `json:"daily_increase"    mbus:"daily_increase"`
-	StorageUsed   int64   `json:"storage_used"      mbus:"storage_used"`
-	ArchiveCount  int     `json:"archive_count"     mbus:"archive_count"`
+	UUID            string  `json:"uuid"              mbus:"uuid"`
+	Name            string  `json:"name"              mbus:"name"`
+	Members         []*User `json:"members,omitempty"`
+	DailyIncrease   int64   `json:"daily_increase"    mbus:"daily_increase"`
+	StorageUsed     int64   `json:"storage_used"      mbus:"storage_used"`
+	ArchiveCount    int     `json:"archive_count"     mbus:"archive_count"`
+	Share           int     `json:"share"             mbus:"share"`
+	Spread          int     `json:"spread"            mbus:"spread"`
+	RequireApproval bool    `json:"require_approval"  mbus:"require_approval"`
+	ApprovalTimeout int     `json:"approval_timeout"  mbus:"approval_timeout"`
+
+	QuotaStorage  int64         `json:"quota_storage"   mbus:"quota_storage"`
+	QuotaArchives int           `json:"quota_archives"  mbus:"quota_archives"`
+	QuotaJobs     int           `json:"quota_jobs"      mbus:"quota_jobs"`
+	QuotaTargets  int           `json:"quota_targets"   mbus:"quota_targets"`
+	QuotaTasks    int           `json:"quota_tasks"     mbus:"quota_tasks"`
+	EnforceQuotas bool          `json:"enforce_quotas"  mbus:"enforce_quotas"`
+	Quotas        []*QuotaUsage `json:"quotas,omitempty"`
 }
 
+// DefaultApprovalTimeout is how long (in minutes) requests for approval
+// stay open, for tenants that haven't said otherwise.
+const DefaultApprovalTimeout = 1440
+
 type TenantFilter struct {
 	Name       string
 	ExactMatch bool
@@ -58,7 +74,11 @@ func (f *TenantFilter) Query() (string, []interface{}) {
 	}
 
 	return `
-	    SELECT t.uuid, t.name, t.daily_increase, t.storage_used, t.archive_count
+	    SELECT t.uuid, t.name, t.daily_increase, t.storage_used, t.archive_count,
+	           t.scheduler_share, t.spread_window,
+	           t.require_approval, t.approval_timeout,
+	           t.quota_storage, t.quota_archives, t.quota_jobs, t.quota_targets, t.quota_tasks,
+	           t.enforce_quotas
 	      FROM tenants t
 	     WHERE ` + strings.Join(wheres, " AND ") + `
 	` + limit, args
@@ -86,7 +106,10 @@ func (db *DB) GetAllTenants(filter *TenantFilter) ([]*Tenant, error) {
 			daily, used *int64
 			archives    *int
 		)
-		if err := r.Scan(&tenant.UUID, &tenant.Name, &daily, &used, &archives); err != nil {
+		if err := r.Scan(&tenant.UUID, &tenant.Name, &daily, &used, &archives, &tenant.Share, &tenant.Spread,
+			&tenant.RequireApproval, &tenant.ApprovalTimeout,
+			&tenant.QuotaStorage, &tenant.QuotaArchives, &tenant.QuotaJobs, &tenant.QuotaTargets, &tenant.QuotaTasks,
+			&tenant.EnforceQuotas); err != nil {
 			return l, err
 		}
 		if daily != nil {
@@ -110,7 +133,11 @@ func (db *DB) GetTenant(id string) (*Tenant, error) {
 	defer db.exclusive.Unlock()
 	r, err := db.query(`
 	     SELECT t.uuid, t.name,
-	            t.daily_increase, t.storage_used, t.archive_count
+	            t.daily_increase, t.storage_used, t.archive_count,
+	            t.scheduler_share, t.spread_window,
+	            t.require_approval, t.approval_timeout,
+	            t.quota_storage, t.quota_archives, t.quota_jobs, t.quota_targets, t.quota_tasks,
+	            t.enforce_quotas
 
 	       FROM tenants t
 
@@ -130,7 +157,10 @@ func (db *DB) GetTenant(id string) (*Tenant, error) {
 		archives    *int
 	)
 	if err := r.Scan(&tenant.UUID, &tenant.Name,
-		&daily, &used, &archives); err != nil {
+		&daily, &used, &archives, &tenant.Share, &tenant.Spread,
+		&tenant.RequireApproval, &tenant.ApprovalTimeout,
+		&tenant.QuotaStorage, &tenant.QuotaArchives, &tenant.QuotaJobs, &tenant.QuotaTargets, &tenant.QuotaTasks,
+		&tenant.EnforceQuotas); err != nil {
 		return tenant, err
 	}
 	if daily != nil {
@@ -168,7 +198,19 @@ func (db *DB) CreateTenant(tenant *Tenant) (*Tenant, error) {
 	if tenant.UUID == "" {
 		tenant.UUID = RandomID()
 	}
-	err := db.Exec(`INSERT INTO tenants (uuid, name) VALUES (?, ?)`, tenant.UUID, tenant.Name)
+	if tenant.Share < 1 {
+		tenant.Share = 1
+	}
+	if tenant.ApprovalTimeout < 1 {
+		tenant.ApprovalTimeout = DefaultApprovalTimeout
+	}
+	err := db.Exec(`
+	   INSERT INTO tenants (uuid, name, scheduler_share, spread_window, require_approval, approval_timeout,
+	                        quota_storage, quota_archives, quota_jobs, quota_targets, quota_tasks, enforce_quotas)
+	                VALUES (?, ?, ?, ?, ?, ?,
+	                        ?, ?, ?, ?, ?, ?)`,
+		tenant.UUID, tenant.Name, tenant.Share, tenant.Spread, tenant.RequireApproval, tenant.ApprovalTimeout,
+		tenant.QuotaStorage, tenant.QuotaArchives, tenant.QuotaJobs, tenant.QuotaTargets, tenant.QuotaTasks, tenant.EnforceQuotas)
 	if err != nil {
 		return nil, err
 	}
@@ -176,15 +218,26 @@ func (db *DB) CreateTenant(tenant *Tenant) (*Tenant, error) {
 	return tenant, nil
 }
 
+// UpdateTenant saves the tenant's settings.  Its storage statistics
+// are left alone; see UpdateTenantStats.
 func (db *DB) UpdateTenant(tenant *Tenant) (*Tenant, error) {
 	err := db.Exec(`
 	   UPDATE tenants
 	      SET name = ?,
-	          daily_increase = ?,
-	          archive_count  = ?,
-	          storage_used   = ?
+	          scheduler_share = ?,
+	          spread_window   = ?,
+	          require_approval = ?,
+	          approval_timeout = ?,
+	          quota_storage  = ?,
+	          quota_archives = ?,
+	          quota_jobs     = ?,
+	          quota_targets  = ?,
+	          quota_tasks    = ?,
+	          enforce_quotas = ?
 	    WHERE uuid = ?`,
-		tenant.Name, tenant.DailyIncrease, tenant.ArchiveCount, tenant.StorageUsed,
+		tenant.Name,
+		tenant.Share, tenant.Spread, tenant.RequireApproval, tenant.ApprovalTimeout,
+		tenant.QuotaStorage, tenant.QuotaArchives, tenant.QuotaJobs, tenant.QuotaTargets, tenant.QuotaTasks, tenant.EnforceQuotas,
 		tenant.UUID)
 	if err != nil {
 		return nil, err
@@ -194,6 +247,34 @@ func (db *DB) UpdateTenant(tenant *Tenant) (*Tenant, error) {
 	return tenant, nil
 }
 
+// UpdateTenantStats saves the tenant's storage statistics (and only
+// those), so that the daily storage analysis never undoes changes made
+// to the tenant's settings while it was running.
+func (db *DB) UpdateTenantStats(tenant *Tenant) error {
+	err := db.Exec(`
+	   UPDATE tenants
+	      SET daily_increase = ?,
+	          archive_count  = ?,
+	          storage_used   = ?
+	    WHERE uuid = ?`,
+		tenant.DailyIncrease, tenant.ArchiveCount, tenant.StorageUsed,
+		tenant.UUID)
+	if err != nil {
+		return err
+	}
+
+	update, err := db.GetTenant(tenant.UUID)
+	if err != nil {
+		return err
+	}
+	if update == nil {
+		return fmt.Errorf("unable to retrieve tenant %s after update", tenant.UUID)
+	}
+
+	db.sendUpdateObjectEvent(update, "tenant:"+tenant.UUID)
+	return nil
+}
+
 func (db *DB) GetTenantRole(org string, team string) (string, string, error) {
 	db.exclusive.Lock()
 	defer db.exclusive.Unlock()
@@ -290,6 +371,31 @@ func (db *DB) DeleteTenant(tenant *Tenant, recurse bool) error {
 			return fmt.Errorf("unable to delete tenant: tenant has outstanding tasks")
 		}
 	}
+
+	/* blackouts have nothing depending on them, so they always go */
+	err := db.Exec(`
+	   DELETE FROM blackouts
+	         WHERE tenant_uuid = ?`, tenant.UUID)
+	if err != nil {
+		return fmt.Errorf("unable to delete tenant blackouts: %s", err)
+	}
+
+	/* custom roles mean nothing outside of the tenant, either;
+	   nor do its requests for approval (the audit log has those) */
+	err = db.Exec(`
+	   DELETE FROM roles
+	         WHERE tenant_uuid = ?`, tenant.UUID)
+	if err != nil {
+		return fmt.Errorf("unable to delete tenant roles: %s", err)
+	}
+
+	err = db.Exec(`
+	   DELETE FROM approvals
+	         WHERE tenant_uuid = ?`, tenant.UUID)
+	if err != nil {
+		return fmt.Errorf("unable to delete tenant approval requests: %s", err)
+	}
+
 	db.sendDeleteObjectEvent(tenant, "tenant:"+tenant.UUID)
 	return db.Exec(`
 	   DELETE FROM tenants
@@ -2,6 +2,7 @@ package db
 
 import (
 	"fmt"
+	"net"
 	"strings"
 	"time"
 )
@@ -12,6 +13,94 @@ type AuthToken struct {
 	Name      string `json:"name"`
 	CreatedAt int64  `json:"created_at"`
 	LastSeen  *int64 `json:"last_seen"`
+
+	TokenScope
+}
+
+// A TokenScope limits what an auth token can be used for: which
+// tenant, with at most what role, for which operations, from where,
+// and until when.  The zero value places no limits at all; the token
+// carries the full power of the user who issued it.
+type TokenScope struct {
+	Tenant    string   `json:"tenant,omitempty"`
+	Role      string   `json:"role,omitempty"`
+	Ops       []string `json:"ops,omitempty"`
+	Allow     []string `json:"allow,omitempty"`
+	ExpiresAt int64    `json:"expires_at,omitempty"`
+}
+
+func (s TokenScope) Unlimited() bool {
+	return s.Tenant == "" && s.Role == "" && len(s.Ops) == 0 && len(s.Allow) == 0 && s.ExpiresAt == 0
+}
+
+func (s TokenScope) Expired(at time.Time) bool {
+	return s.ExpiresAt != 0 && s.ExpiresAt <= at.Unix()
+}
+
+// Allows reports whether the token may be used from the given remote
+// address, which may carry a port.  Allow entries are either single
+// IP addresses or CIDR ranges; with none, any address is allowed.
+func (s TokenScope) Allows(addr string) bool {
+	if len(s.Allow) == 0 {
+		return true
+	}
+
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+
+	for _, allow := range s.Allow {
+		if strings.Contains(allow, "/") {
+			if _, network, err := net.ParseCIDR(allow); err == nil && network.Contains(ip) {
+				return true
+			}
+		} else if ip.Equal(net.ParseIP(allow)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the scope makes sense on its own.  Custom roles
+// belong to a single tenant, so tokens can only be limited to one when
+// they are also limited to that tenant; whether the tenant actually has
+// such a role is checked when the token is generated.
+func (s TokenScope) Validate() error {
+	if s.Role != "" && !IsBuiltinRole(s.Role) {
+		if !roleName.MatchString(s.Role) {
+			return fmt.Errorf("invalid token role '%s'", s.Role)
+		}
+		if s.Tenant == "" {
+			return fmt.Errorf("token role '%s' is not a built-in role, and can only be used with a tenant", s.Role)
+		}
+	}
+
+	for _, allow := range s.Allow {
+		if strings.Contains(allow, "/") {
+			if _, _, err := net.ParseCIDR(allow); err != nil {
+				return fmt.Errorf("invalid CIDR range '%s' in token allow list", allow)
+			}
+		} else if net.ParseIP(allow) == nil {
+			return fmt.Errorf("invalid IP address '%s' in token allow list", allow)
+		}
+	}
+
+	return nil
+}
+
+func joinList(l []string) string {
+	return strings.Join(l, ",")
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
 }
 
 type AuthTokenFilter struct {
@@ -37,7 +126,8 @@ func (t AuthTokenFilter) Query() (string, []interface{}) {
 	}
 
 	return `
-		SELECT s.token, s.uuid, s.created_at, s.last_seen, s.name
+		SELECT s.token, s.uuid, s.created_at, s.last_seen, s.name,
+		       s.scope_tenant, s.scope_role, s.scope_ops, s.scope_allow, s.expires_at
 
 		FROM sessions s INNER JOIN users u ON s.user_uuid = u.uuid
 
@@ -63,9 +153,13 @@ func (db *DB) GetAllAuthTokens(filter *AuthTokenFilter) ([]*AuthToken, error) {
 
 	for r.Next() {
 		t := &AuthToken{}
-		if err = r.Scan(&t.UUID, &t.Session, &t.CreatedAt, &t.LastSeen, &t.Name); err != nil {
+		var ops, allow string
+		if err = r.Scan(&t.UUID, &t.Session, &t.CreatedAt, &t.LastSeen, &t.Name,
+			&t.Tenant, &t.Role, &ops, &allow, &t.ExpiresAt); err != nil {
 			return l, err
 		}
+		t.Ops = splitList(ops)
+		t.Allow = splitList(allow)
 		l = append(l, t)
 	}
 
@@ -83,17 +177,30 @@ func (db *DB) GetAuthToken(id string) (*AuthToken, error) {
 	return r[0], nil
 }
 
-func (db *DB) GenerateAuthToken(name string, user *User) (*AuthToken, string, error) {
+func (db *DB) GenerateAuthToken(name string, user *User, scope TokenScope) (*AuthToken, string, error) {
 	if user == nil {
 		return nil, "", fmt.Errorf("cannot generate a token without a user")
 	}
+	if err := scope.Validate(); err != nil {
+		return nil, "", err
+	}
+	if scope.Role != "" && !IsBuiltinRole(scope.Role) {
+		if role, err := db.GetRole(scope.Tenant, scope.Role); err != nil {
+			return nil, "", err
+		} else if role == nil {
+			return nil, "", fmt.Errorf("invalid token role '%s': tenant [%s] has no such role", scope.Role, scope.Tenant)
+		}
+	}
 
 	id := RandomID()
 	token := RandomID()
 	err := db.Exec(`
-	   INSERT INTO sessions (uuid, user_uuid, created_at, token, name)
-	                 VALUES (?,    ?,         ?,          ?,     ?)`,
-		id, user.UUID, time.Now().Unix(), token, name)
+	   INSERT INTO sessions (uuid, user_uuid, created_at, token, name,
+	                         scope_tenant, scope_role, scope_ops, scope_allow, expires_at)
+	                 VALUES (?,    ?,         ?,          ?,     ?,
+	                         ?,            ?,          ?,         ?,           ?)`,
+		id, user.UUID, time.Now().Unix(), token, name,
+		scope.Tenant, scope.Role, joinList(scope.Ops), joinList(scope.Allow), scope.ExpiresAt)
 	if err != nil {
 		return nil, "", err
 	}
@@ -0,0 +1,154 @@
+package db
+
+import (
+	"net/http"
+	"time"
+
+	// sql drivers
+	_ "github.com/mattn/go-sqlite3"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/shieldproject/shield/route"
+)
+
+var _ = Describe("Auth Tokens", func() {
+	Context("Scope Validation", func() {
+		It("accepts unlimited tokens", func() {
+			Ω(TokenScope{}.Validate()).Should(Succeed())
+		})
+
+		It("accepts tenant roles", func() {
+			for _, role := range []string{"admin", "engineer", "operator"} {
+				Ω(TokenScope{Role: role}.Validate()).Should(Succeed())
+			}
+		})
+
+		It("rejects unknown roles", func() {
+			Ω(TokenScope{Role: "Manager"}.Validate()).ShouldNot(Succeed())
+		})
+
+		It("accepts custom roles only for tokens limited to a tenant", func() {
+			Ω(TokenScope{Role: "restorer"}.Validate()).ShouldNot(Succeed())
+			Ω(TokenScope{Tenant: RandomID(), Role: "restorer"}.Validate()).Should(Succeed())
+		})
+
+		It("accepts IP addresses and CIDR ranges", func() {
+			Ω(TokenScope{Allow: []string{"10.0.0.1", "192.168.0.0/16", "fd00::/8"}}.Validate()).Should(Succeed())
+		})
+
+		It("rejects malformed addresses and ranges", func() {
+			Ω(TokenScope{Allow: []string{"10.0.0"}}.Validate()).ShouldNot(Succeed())
+			Ω(TokenScope{Allow: []string{"10.0.0.0/33"}}.Validate()).ShouldNot(Succeed())
+		})
+	})
+
+	Context("Scope Evaluation", func() {
+		It("never expires tokens without an expiry", func() {
+			Ω(TokenScope{}.Expired(at(86400 * 365))).Should(BeFalse())
+		})
+
+		It("expires tokens once their expiry has passed", func() {
+			scope := TokenScope{ExpiresAt: at(60).Unix()}
+			Ω(scope.Expired(at(0))).Should(BeFalse())
+			Ω(scope.Expired(at(60))).Should(BeTrue())
+			Ω(scope.Expired(at(61))).Should(BeTrue())
+		})
+
+		It("allows any address without an allow list", func() {
+			Ω(TokenScope{}.Allows("203.0.113.9:41234")).Should(BeTrue())
+		})
+
+		It("allows only the addresses on the allow list", func() {
+			scope := TokenScope{Allow: []string{"10.8.0.0/16", "192.168.1.10"}}
+			Ω(scope.Allows("10.8.3.4")).Should(BeTrue())
+			Ω(scope.Allows("10.8.3.4:41234")).Should(BeTrue())
+			Ω(scope.Allows("192.168.1.10")).Should(BeTrue())
+			Ω(scope.Allows("192.168.1.11")).Should(BeFalse())
+			Ω(scope.Allows("10.9.0.1")).Should(BeFalse())
+			Ω(scope.Allows("not-an-ip")).Should(BeFalse())
+		})
+
+		It("does not allow a forged X-Forwarded-For to get around the allow list", func() {
+			from := func(remote, xff string, trusted ...string) string {
+				req, err := http.NewRequest("GET", "/v2/tenants", nil)
+				Ω(err).ShouldNot(HaveOccurred())
+				req.RemoteAddr = remote
+				req.Header.Set("X-Forwarded-For", xff)
+
+				proxies, err := route.ParseTrustedProxies(trusted)
+				Ω(err).ShouldNot(HaveOccurred())
+				return route.NewRequest(nil, req, false).ClientIP(proxies)
+			}
+
+			scope := TokenScope{Allow: []string{"10.8.0.0/16"}}
+			Ω(scope.Allows(from("203.0.113.9:41234", "10.8.3.4"))).Should(BeFalse())
+			Ω(scope.Allows(from("203.0.113.9:41234", "10.8.3.4", "192.168.0.0/16"))).Should(BeFalse())
+			Ω(scope.Allows(from("192.168.7.1:41234", "203.0.113.9, 10.8.3.4", "192.168.0.0/16"))).Should(BeTrue())
+			Ω(scope.Allows(from("192.168.7.1:41234", "10.8.3.4, 203.0.113.9", "192.168.0.0/16"))).Should(BeFalse())
+		})
+	})
+
+	Context("Issuing tokens", func() {
+		var (
+			db   *DB
+			user *User
+		)
+
+		BeforeEach(func() {
+			var err error
+			db, err = Database()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			user, err = db.CreateUser(&User{Name: "CI", Account: "ci", Backend: "local"})
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("issues unlimited tokens", func() {
+			t, id, err := db.GenerateAuthToken("everything", user, TokenScope{})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(id).ShouldNot(Equal(""))
+			Ω(t.TokenScope.Unlimited()).Should(BeTrue())
+		})
+
+		It("remembers the scope of a token", func() {
+			scope := TokenScope{
+				Tenant:    RandomID(),
+				Role:      "operator",
+				Ops:       []string{"run-job", "tasks"},
+				Allow:     []string{"10.8.0.0/16"},
+				ExpiresAt: time.Now().Add(24 * time.Hour).Unix(),
+			}
+			t, _, err := db.GenerateAuthToken("ci", user, scope)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(t.TokenScope).Should(Equal(scope))
+
+			session, err := db.GetSession(t.Session)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(session).ShouldNot(BeNil())
+			Ω(session.Scope).Should(Equal(scope))
+		})
+
+		It("refuses to issue tokens with an invalid scope", func() {
+			_, _, err := db.GenerateAuthToken("ci", user, TokenScope{Role: "root"})
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("issues tokens limited to the custom roles of their tenant", func() {
+			tenant, err := db.CreateTenant(&Tenant{Name: "acme"})
+			Ω(err).ShouldNot(HaveOccurred())
+			_, err = db.CreateRole(&Role{TenantUUID: tenant.UUID, Name: "restorer", Permissions: []string{"restore"}})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			t, _, err := db.GenerateAuthToken("ci", user, TokenScope{Tenant: tenant.UUID, Role: "restorer"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(t.Role).Should(Equal("restorer"))
+
+			_, _, err = db.GenerateAuthToken("ci", user, TokenScope{Tenant: tenant.UUID, Role: "auditor"})
+			Ω(err).Should(HaveOccurred())
+			_, _, err = db.GenerateAuthToken("ci", user, TokenScope{Tenant: RandomID(), Role: "restorer"})
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+})
@@ -23,7 +23,11 @@ type User struct {
 
 	DefaultTenant string `json:"default_tenant"`
 
-	pwhash string
+	MFAEnabled bool `json:"mfa_enabled"`
+
+	pwhash      string
+	mfaSecret   string
+	mfaLastStep int64
 }
 
 func (u *User) IsLocal() bool {
@@ -107,7 +111,7 @@ func (f *UserFilter) Query() (string, []interface{}) {
 
 	return `
 	    SELECT u.uuid, u.name, u.account, u.backend, sysrole, pwhash,
-	           u.default_tenant
+	           u.default_tenant, u.mfa_secret, u.mfa_enabled, u.mfa_last_step
 	      FROM users u
 	     WHERE ` + strings.Join(wheres, " AND ") + `
 	` + limit, args
@@ -131,7 +135,8 @@ func (db *DB) GetAllUsers(filter *UserFilter) ([]*User, error) {
 
 	for r.Next() {
 		u := &User{}
-		if err = r.Scan(&u.UUID, &u.Name, &u.Account, &u.Backend, &u.SysRole, &u.pwhash, &u.DefaultTenant); err != nil {
+		if err = r.Scan(&u.UUID, &u.Name, &u.Account, &u.Backend, &u.SysRole, &u.pwhash, &u.DefaultTenant,
+			&u.mfaSecret, &u.MFAEnabled, &u.mfaLastStep); err != nil {
 			return l, err
 		}
 		l = append(l, u)
@@ -145,7 +150,7 @@ func (db *DB) GetUserByID(id string) (*User, error) {
 	defer db.exclusive.Unlock()
 	r, err := db.query(`
 	    SELECT u.uuid, u.name, u.account, u.backend, u.sysrole, u.pwhash,
-	           u.default_tenant
+	           u.default_tenant, u.mfa_secret, u.mfa_enabled, u.mfa_last_step
 	      FROM users u
 	     WHERE u.uuid = ?`, id)
 	if err != nil {
@@ -158,7 +163,8 @@ func (db *DB) GetUserByID(id string) (*User, error) {
 	}
 
 	u := &User{}
-	if err = r.Scan(&u.UUID, &u.Name, &u.Account, &u.Backend, &u.SysRole, &u.pwhash, &u.DefaultTenant); err != nil {
+	if err = r.Scan(&u.UUID, &u.Name, &u.Account, &u.Backend, &u.SysRole, &u.pwhash, &u.DefaultTenant,
+		&u.mfaSecret, &u.MFAEnabled, &u.mfaLastStep); err != nil {
 		return nil, err
 	}
 	return u, nil
@@ -170,7 +176,7 @@ func (db *DB) GetUser(account string, backend string) (*User, error) {
 
 	r, err := db.query(`
 	    SELECT u.uuid, u.name, u.account, u.backend, u.sysrole, u.pwhash,
-	           u.default_tenant
+	           u.default_tenant, u.mfa_secret, u.mfa_enabled, u.mfa_last_step
 	      FROM users u
 	     WHERE u.account = ? AND backend = ?`, account, backend)
 	if err != nil {
@@ -183,7 +189,8 @@ func (db *DB) GetUser(account string, backend string) (*User, error) {
 	}
 
 	u := &User{}
-	if err = r.Scan(&u.UUID, &u.Name, &u.Account, &u.Backend, &u.SysRole, &u.pwhash, &u.DefaultTenant); err != nil {
+	if err = r.Scan(&u.UUID, &u.Name, &u.Account, &u.Backend, &u.SysRole, &u.pwhash, &u.DefaultTenant,
+		&u.mfaSecret, &u.MFAEnabled, &u.mfaLastStep); err != nil {
 		return nil, err
 	}
 	return u, nil
@@ -217,9 +224,18 @@ func (db *DB) UpdateUserSettings(user *User) error {
 }
 
 func (db *DB) DeleteUser(user *User) error {
-	return db.Exec(`
-		DELETE FROM users
-		      WHERE uuid = ?`, user.UUID)
+	return db.exclusively(func() error {
+		err := db.exec(`
+		    DELETE FROM mfa_recovery_codes
+		          WHERE user_uuid = ?`, user.UUID)
+		if err != nil {
+			return err
+		}
+
+		return db.exec(`
+			DELETE FROM users
+			      WHERE uuid = ?`, user.UUID)
+	})
 }
 
 func (db *DB) userShouldExist(uuid string) error {
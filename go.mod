@@ -8,6 +8,7 @@ require (
 	github.com/ErikDubbelboer/gspt v0.0.0-20180711091504-e39e726e09cc
 	github.com/cloudfoundry-community/vaultkv v0.3.0
 	github.com/fsouza/go-dockerclient v1.9.7
+	github.com/go-ldap/ldap/v3 v3.4.5
 	github.com/go-sql-driver/mysql v1.5.0
 	github.com/google/go-github v0.0.0-20150605201353-af17a5fa8537
 	github.com/gorilla/websocket v1.4.2
@@ -21,6 +22,7 @@ require (
 	github.com/jhunt/go-table v0.0.0-20181127194439-fcc252a20f4c
 	github.com/jmoiron/sqlx v0.0.0-20160615151803-bdae0c3219c3
 	github.com/kurin/blazer v0.5.1
+	github.com/lestrrat-go/jwx v1.2.26
 	github.com/mattn/go-isatty v0.0.17
 	github.com/mattn/go-shellwords v1.0.12
 	github.com/mattn/go-sqlite3 v1.14.15
@@ -36,6 +38,7 @@ require (
 	golang.org/x/oauth2 v0.8.0
 	google.golang.org/api v0.126.0
 	gopkg.in/yaml.v2 v2.4.0
+	rsc.io/qr v0.2.0
 )
 
 require (
@@ -43,6 +46,7 @@ require (
 	cloud.google.com/go/compute/metadata v0.2.3 // indirect
 	github.com/AdaLogics/go-fuzz-headers v0.0.0-20230106234847-43070de90fa1 // indirect
 	github.com/Azure/go-ansiterm v0.0.0-20230124172434-306776ec8161 // indirect
+	github.com/Azure/go-ntlmssp v0.0.0-20221128193559-754e69321358 // indirect
 	github.com/Microsoft/go-winio v0.6.1 // indirect
 	github.com/Microsoft/hcsshim v0.9.10 // indirect
 	github.com/armon/go-metrics v0.3.10 // indirect
@@ -57,6 +61,7 @@ require (
 	github.com/docker/go-units v0.5.0 // indirect
 	github.com/fatih/color v1.13.0 // indirect
 	github.com/fsnotify/fsnotify v1.6.0 // indirect
+	github.com/go-asn1-ber/asn1-ber v1.5.4 // indirect
 	github.com/go-logr/logr v1.2.4 // indirect
 	github.com/goccy/go-json v0.10.2 // indirect
 	github.com/gogo/protobuf v1.3.2 // indirect
@@ -83,7 +88,6 @@ require (
 	github.com/lestrrat-go/blackmagic v1.0.1 // indirect
 	github.com/lestrrat-go/httpcc v1.0.1 // indirect
 	github.com/lestrrat-go/iter v1.0.2 // indirect
-	github.com/lestrrat-go/jwx v1.2.26 // indirect
 	github.com/lestrrat-go/option v1.0.1 // indirect
 	github.com/lib/pq v1.1.1 // indirect
 	github.com/mattn/go-colorable v0.1.12 // indirect
@@ -0,0 +1,97 @@
+package oidc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Claims are what an identity provider asserts about a user, from an
+// ID token or the userinfo endpoint.  Claims can be looked up by name,
+// or by a dotted path into nested claims, i.e. "realm_access.roles".
+type Claims map[string]interface{}
+
+func (c Claims) Lookup(name string) (interface{}, bool) {
+	if v, ok := c[name]; ok {
+		return v, true
+	}
+
+	var v interface{} = map[string]interface{}(c)
+	for _, part := range strings.Split(name, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// String returns the value of a single-valued claim, or the empty
+// string if there is no such claim.
+func (c Claims) String(name string) string {
+	v, ok := c.Lookup(name)
+	if !ok || v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// Strings returns the values of a multi-valued claim (like groups);
+// single-valued claims are treated as lists of one.
+func (c Claims) Strings(name string) []string {
+	v, ok := c.Lookup(name)
+	if !ok || v == nil {
+		return nil
+	}
+
+	if l, ok := v.([]interface{}); ok {
+		ss := make([]string, 0, len(l))
+		for _, x := range l {
+			if s, ok := x.(string); ok {
+				ss = append(ss, s)
+			} else {
+				ss = append(ss, fmt.Sprintf("%v", x))
+			}
+		}
+		return ss
+	}
+	return []string{c.String(name)}
+}
+
+// Has reports whether the claim is, or (for multi-valued claims)
+// includes, the given value.
+func (c Claims) Has(name, value string) bool {
+	for _, s := range c.Strings(name) {
+		if s == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Claims) Time(name string) (time.Time, bool) {
+	v, ok := c.Lookup(name)
+	if !ok {
+		return time.Time{}, false
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(f), 0), true
+}
+
+// Merge fills in any claims that are missing, from another set.
+func (c Claims) Merge(other Claims) {
+	for name, v := range other {
+		if _, ok := c[name]; !ok {
+			c[name] = v
+		}
+	}
+}
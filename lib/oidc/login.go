@@ -0,0 +1,92 @@
+package oidc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A Login is a login that has been sent off to the identity provider,
+// and not yet finished.  It carries the state parameter that names it,
+// and the secrets needed to finish it: the PKCE code verifier and the
+// nonce.  Rather than keep track of every login ever started, these are
+// handed to the browser to hold onto, sealed (see Seal), so that they
+// can neither be tampered with nor used after they expire.
+type Login struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	Expires  int64  `json:"expires"`
+}
+
+// NewLogin starts a new login, good for ttl, returning it along with
+// the (S256) PKCE code challenge to send to the identity provider.
+func NewLogin(ttl time.Duration) (*Login, string, error) {
+	state, err := Random(16)
+	if err != nil {
+		return nil, "", err
+	}
+	nonce, err := Random(16)
+	if err != nil {
+		return nil, "", err
+	}
+	verifier, challenge, err := NewVerifier()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &Login{
+		State:    state,
+		Nonce:    nonce,
+		Verifier: verifier,
+		Expires:  time.Now().Add(ttl).Unix(),
+	}, challenge, nil
+}
+
+// Seal signs the login with the given key (HMAC-SHA256), producing a
+// cookie-safe string that only OpenLogin, with the same key, can turn
+// back into a Login.
+func (l Login) Seal(key []byte) (string, error) {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	return payload + "." + sign(key, payload), nil
+}
+
+// OpenLogin verifies and unpacks a login sealed by Seal, so long as it
+// has not expired by the given time.
+func OpenLogin(sealed string, key []byte, at time.Time) (*Login, error) {
+	parts := strings.Split(sealed, ".")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("malformed login")
+	}
+	if !hmac.Equal([]byte(sign(key, parts[0])), []byte(parts[1])) {
+		return nil, fmt.Errorf("login signature verification failed")
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("malformed login: %s", err)
+	}
+	var l Login
+	if err := json.Unmarshal(b, &l); err != nil {
+		return nil, fmt.Errorf("malformed login: %s", err)
+	}
+	if at.Unix() >= l.Expires {
+		return nil, fmt.Errorf("login has timed out")
+	}
+	return &l, nil
+}
+
+func sign(key []byte, payload string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
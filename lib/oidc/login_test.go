@@ -0,0 +1,61 @@
+package oidc_test
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/shieldproject/shield/lib/oidc"
+)
+
+var _ = Describe("Logins in progress", func() {
+	key := []byte("a key that only SHIELD knows....")
+
+	It("seals and opens logins", func() {
+		login, challenge, err := NewLogin(10 * time.Minute)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(challenge).ShouldNot(BeEmpty())
+		Ω(challenge).ShouldNot(Equal(login.Verifier))
+
+		sealed, err := login.Seal(key)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		opened, err := OpenLogin(sealed, key, time.Now())
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(*opened).Should(Equal(*login))
+	})
+
+	It("refuses logins that have been tampered with", func() {
+		login, _, err := NewLogin(10 * time.Minute)
+		Ω(err).ShouldNot(HaveOccurred())
+		sealed, err := login.Seal(key)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, err = OpenLogin(sealed, []byte("some other key"), time.Now())
+		Ω(err).Should(HaveOccurred())
+
+		login.State = "st4te"
+		forged, err := login.Seal([]byte("some other key"))
+		Ω(err).ShouldNot(HaveOccurred())
+		parts := strings.Split(sealed, ".")
+		_, err = OpenLogin(strings.Split(forged, ".")[0]+"."+parts[1], key, time.Now())
+		Ω(err).Should(HaveOccurred())
+
+		_, err = OpenLogin(parts[0], key, time.Now())
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("refuses logins that have timed out", func() {
+		login, _, err := NewLogin(10 * time.Minute)
+		Ω(err).ShouldNot(HaveOccurred())
+		sealed, err := login.Seal(key)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, err = OpenLogin(sealed, key, time.Now().Add(9*time.Minute))
+		Ω(err).ShouldNot(HaveOccurred())
+		_, err = OpenLogin(sealed, key, time.Now().Add(11*time.Minute))
+		Ω(err).Should(HaveOccurred())
+	})
+})
@@ -0,0 +1,54 @@
+package oidc
+
+import (
+	"fmt"
+)
+
+// A Mapping assigns a role on a SHIELD tenant, according to the first
+// of its Rights that matches the claims of the user logging in.
+type Mapping struct {
+	Tenant string  `json:"tenant"`
+	Rights []Right `json:"rights"`
+}
+
+// A Right matches users in a group, or with a claim that has (or, for
+// multi-valued claims, includes) a given value.  A Right with neither
+// a group nor a claim matches everyone.
+type Right struct {
+	Group string `json:"group"`
+	Claim string `json:"claim"`
+	Value string `json:"value"`
+	Role  string `json:"role"`
+}
+
+func (m Mapping) Validate() error {
+	for _, right := range m.Rights {
+		if right.Group != "" && right.Claim != "" {
+			return fmt.Errorf("mapping for tenant '%s' has a right with both a `group' and a `claim'", m.Tenant)
+		}
+		if right.Claim != "" && right.Value == "" {
+			return fmt.Errorf("mapping for tenant '%s' has a `claim' right with no `value'", m.Tenant)
+		}
+	}
+	return nil
+}
+
+// Role returns the role granted by the first matching right, if any
+// do match.  Group membership is read from the named groups claim.
+func (m Mapping) Role(claims Claims, groups string) (string, bool) {
+	for _, right := range m.Rights {
+		switch {
+		case right.Claim != "":
+			if !claims.Has(right.Claim, right.Value) {
+				continue
+			}
+
+		case right.Group != "":
+			if !claims.Has(groups, right.Group) {
+				continue
+			}
+		}
+		return right.Role, true
+	}
+	return "", false
+}
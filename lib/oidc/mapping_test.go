@@ -0,0 +1,67 @@
+package oidc_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/shieldproject/shield/lib/oidc"
+)
+
+var _ = Describe("Claim to role mapping", func() {
+	claims := func(s string) Claims {
+		c := Claims{}
+		Ω(json.Unmarshal([]byte(s), &c)).Should(Succeed())
+		return c
+	}
+
+	mapping := Mapping{
+		Tenant: "acme",
+		Rights: []Right{
+			{Group: "shield-admins", Role: "admin"},
+			{Claim: "realm_access.roles", Value: "backup-engineer", Role: "engineer"},
+			{Claim: "email_verified", Value: "true", Role: "operator"},
+		},
+	}
+
+	It("assigns the role of the first matching right", func() {
+		role, ok := mapping.Role(claims(`{"groups":["staff","shield-admins"],"realm_access":{"roles":["backup-engineer"]}}`), "groups")
+		Ω(ok).Should(BeTrue())
+		Ω(role).Should(Equal("admin"))
+
+		role, ok = mapping.Role(claims(`{"groups":["staff"],"realm_access":{"roles":["backup-engineer"]}}`), "groups")
+		Ω(ok).Should(BeTrue())
+		Ω(role).Should(Equal("engineer"))
+
+		role, ok = mapping.Role(claims(`{"email_verified":true}`), "groups")
+		Ω(ok).Should(BeTrue())
+		Ω(role).Should(Equal("operator"))
+	})
+
+	It("reads groups from the configured claim", func() {
+		role, ok := mapping.Role(claims(`{"roles":"shield-admins"}`), "roles")
+		Ω(ok).Should(BeTrue())
+		Ω(role).Should(Equal("admin"))
+
+		_, ok = mapping.Role(claims(`{"roles":"shield-admins"}`), "groups")
+		Ω(ok).Should(BeFalse())
+	})
+
+	It("assigns nothing when no rights match", func() {
+		_, ok := mapping.Role(claims(`{"groups":["staff"],"email_verified":false}`), "groups")
+		Ω(ok).Should(BeFalse())
+	})
+
+	It("matches everyone with a right that has neither a group nor a claim", func() {
+		role, ok := Mapping{Tenant: "acme", Rights: []Right{{Role: "operator"}}}.Role(Claims{}, "groups")
+		Ω(ok).Should(BeTrue())
+		Ω(role).Should(Equal("operator"))
+	})
+
+	It("refuses rights that are ambiguous or incomplete", func() {
+		Ω(mapping.Validate()).Should(Succeed())
+		Ω(Mapping{Rights: []Right{{Group: "g", Claim: "c", Value: "v"}}}.Validate()).ShouldNot(Succeed())
+		Ω(Mapping{Rights: []Right{{Claim: "c"}}}.Validate()).ShouldNot(Succeed())
+	})
+})
@@ -0,0 +1,334 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jws"
+)
+
+const DefaultScopes = "openid profile email"
+
+// How far apart our clock and the identity provider's can be, when
+// checking the issue and expiry times of ID tokens.
+const Leeway = 2 * time.Minute
+
+type Client struct {
+	Issuer string
+	ID     string
+	Secret string
+
+	http *http.Client
+
+	lock   sync.Mutex
+	config *Configuration
+	keys   jwk.Set
+}
+
+// Configuration is the part of an identity provider's discovery
+// document (its /.well-known/openid-configuration) that we use.
+type Configuration struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type Tokens struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+}
+
+func NewClient(issuer, id, secret string, verifytls bool) *Client {
+	return &Client{
+		Issuer: strings.TrimSuffix(issuer, "/"),
+		ID:     id,
+		Secret: secret,
+
+		http: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					InsecureSkipVerify: !verifytls,
+				},
+			},
+		},
+	}
+}
+
+// Discover retrieves the identity provider's discovery document, the
+// first time it is needed, so that SHIELD can boot even if the
+// identity provider happens to be unreachable at the time.
+func (c *Client) Discover() (*Configuration, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.config != nil {
+		return c.config, nil
+	}
+
+	uri := c.Issuer + "/.well-known/openid-configuration"
+	config := &Configuration{}
+	if err := c.getJSON(uri, "", config); err != nil {
+		return nil, err
+	}
+	if config.Issuer == "" || config.AuthorizationEndpoint == "" || config.TokenEndpoint == "" || config.JWKSURI == "" {
+		return nil, fmt.Errorf("GET %s: incomplete discovery document (missing issuer, authorization_endpoint, token_endpoint, or jwks_uri)", uri)
+	}
+	if strings.TrimSuffix(config.Issuer, "/") != c.Issuer {
+		return nil, fmt.Errorf("GET %s: discovery document is for issuer '%s', not '%s'", uri, config.Issuer, c.Issuer)
+	}
+
+	c.config = config
+	return c.config, nil
+}
+
+func (c *Client) AuthorizationURL(redirect, scope, state, nonce, challenge string) (string, error) {
+	config, err := c.Discover()
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(config.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization_endpoint '%s': %s", config.AuthorizationEndpoint, err)
+	}
+	q := u.Query()
+
+	q.Set("response_type", "code")
+	q.Set("client_id", c.ID)
+	q.Set("redirect_uri", redirect)
+	q.Set("scope", scope)
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", challenge)
+	q.Set("code_challenge_method", "S256")
+
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// Exchange trades the authorization code that the identity provider
+// redirected back with for tokens, proving (via the PKCE verifier)
+// that we are the ones who started the login in the first place.
+func (c *Client) Exchange(code, redirect, verifier string) (*Tokens, error) {
+	config, err := c.Discover()
+	if err != nil {
+		return nil, err
+	}
+
+	u := url.Values{}
+	u.Set("grant_type", "authorization_code")
+	u.Set("code", code)
+	u.Set("redirect_uri", redirect)
+	u.Set("code_verifier", verifier)
+	u.Set("client_id", c.ID)
+	if c.Secret != "" {
+		u.Set("client_secret", c.Secret)
+	}
+
+	res, err := c.http.PostForm(config.TokenEndpoint, u)
+	if err != nil {
+		return nil, fmt.Errorf("POST %s failed: %s", config.TokenEndpoint, err)
+	}
+	defer res.Body.Close()
+
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("POST %s: failed to read response body: %s", config.TokenEndpoint, err)
+	}
+
+	var data struct {
+		Tokens
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	if err = json.Unmarshal(b, &data); err != nil {
+		return nil, fmt.Errorf("POST %s: failed to unmarshal JSON [%s]: %s", config.TokenEndpoint, string(b), err)
+	}
+	if data.Error != "" {
+		return nil, fmt.Errorf("POST %s: %s (%s)", config.TokenEndpoint, data.Error, data.Description)
+	}
+	if data.IDToken == "" {
+		return nil, fmt.Errorf("POST %s: no id_token found in response body (is the `openid' scope being requested?)", config.TokenEndpoint)
+	}
+
+	return &data.Tokens, nil
+}
+
+// VerifyIDToken checks the signature of an ID token against the
+// identity provider's published keys, and that it was issued by the
+// identity provider, to us, for the login we started (per the nonce),
+// and has not expired.  The token's claims are returned.
+func (c *Client) VerifyIDToken(token, nonce string) (Claims, error) {
+	config, err := c.Discover()
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := jws.ParseString(token)
+	if err != nil {
+		return nil, fmt.Errorf("malformed id token: %s", err)
+	}
+	if len(msg.Signatures()) != 1 {
+		return nil, fmt.Errorf("malformed id token: expected 1 signature, found %d", len(msg.Signatures()))
+	}
+	headers := msg.Signatures()[0].ProtectedHeaders()
+
+	alg := headers.Algorithm()
+	switch alg {
+	case jwa.RS256, jwa.RS384, jwa.RS512, jwa.PS256, jwa.PS384, jwa.PS512, jwa.ES256, jwa.ES384, jwa.ES512:
+	default:
+		return nil, fmt.Errorf("id token is signed with unsupported algorithm '%s'", alg)
+	}
+
+	key, err := c.key(config, headers.KeyID())
+	if err != nil {
+		return nil, err
+	}
+	payload, err := jws.Verify([]byte(token), alg, key)
+	if err != nil {
+		return nil, fmt.Errorf("id token signature verification failed: %s", err)
+	}
+
+	claims := Claims{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("malformed id token claims: %s", err)
+	}
+
+	if iss := claims.String("iss"); iss != config.Issuer {
+		return nil, fmt.Errorf("id token was issued by '%s', not '%s'", iss, config.Issuer)
+	}
+	if !claims.Has("aud", c.ID) {
+		return nil, fmt.Errorf("id token was not issued to client '%s'", c.ID)
+	}
+	if azp := claims.String("azp"); azp != "" && azp != c.ID {
+		return nil, fmt.Errorf("id token was issued to authorized party '%s', not '%s'", azp, c.ID)
+	}
+	if claims.String("nonce") != nonce {
+		return nil, fmt.Errorf("id token nonce does not match the one we sent")
+	}
+	if claims.String("sub") == "" {
+		return nil, fmt.Errorf("id token has no subject")
+	}
+
+	now := time.Now()
+	if exp, ok := claims.Time("exp"); !ok || now.After(exp.Add(Leeway)) {
+		return nil, fmt.Errorf("id token has expired")
+	}
+	if iat, ok := claims.Time("iat"); ok && now.Add(Leeway).Before(iat) {
+		return nil, fmt.Errorf("id token was issued in the future")
+	}
+
+	return claims, nil
+}
+
+// UserInfo retrieves the claims about the authenticated user that the
+// identity provider makes available from its userinfo endpoint.
+func (c *Client) UserInfo(token string) (Claims, error) {
+	config, err := c.Discover()
+	if err != nil {
+		return nil, err
+	}
+	if config.UserinfoEndpoint == "" {
+		return Claims{}, nil
+	}
+
+	claims := Claims{}
+	if err := c.getJSON(config.UserinfoEndpoint, token, &claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (c *Client) key(config *Configuration, kid string) (jwk.Key, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	/* refetch the keys if we don't know the one that the token was
+	   signed with; identity providers rotate their signing keys. */
+	for attempt := 0; attempt < 2; attempt++ {
+		if c.keys == nil || attempt > 0 {
+			keys, err := jwk.Fetch(context.Background(), config.JWKSURI, jwk.WithHTTPClient(c.http))
+			if err != nil {
+				return nil, fmt.Errorf("GET %s failed: %s", config.JWKSURI, err)
+			}
+			c.keys = keys
+		}
+
+		if kid == "" && c.keys.Len() == 1 {
+			key, _ := c.keys.Get(0)
+			return key, nil
+		}
+		if key, ok := c.keys.LookupKeyID(kid); ok {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("id token was signed with unknown key '%s'", kid)
+}
+
+func (c *Client) getJSON(uri, token string, out interface{}) error {
+	req, err := http.NewRequest("GET", uri, nil)
+	if err != nil {
+		return fmt.Errorf("GET %s failed to create request: %s", uri, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("GET %s failed: %s", uri, err)
+	}
+	defer res.Body.Close()
+
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("GET %s: failed to read response body: %s", uri, err)
+	}
+	if res.StatusCode != 200 {
+		return fmt.Errorf("GET %s: received a %s response [%s]", uri, res.Status, string(b))
+	}
+	if err = json.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("GET %s: failed to unmarshal JSON [%s]: %s", uri, string(b), err)
+	}
+	return nil
+}
+
+// NewVerifier generates a PKCE code verifier (RFC 7636), and the
+// (S256) code challenge that goes with it.
+func NewVerifier() (string, string, error) {
+	verifier, err := Random(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// Random generates a URL-safe random string, from n random bytes, for
+// use as a state parameter or a nonce.
+func Random(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("unable to generate random data: %s", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
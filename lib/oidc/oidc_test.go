@@ -0,0 +1,182 @@
+package oidc_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jws"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/shieldproject/shield/lib/oidc"
+)
+
+var _ = Describe("OpenID Connect Client", func() {
+	var (
+		idp       *httptest.Server
+		discovery map[string]string
+		signer    jwk.Key
+		client    *Client
+	)
+
+	BeforeEach(func() {
+		priv, err := rsa.GenerateKey(rand.Reader, 2048)
+		Ω(err).ShouldNot(HaveOccurred())
+		signer, err = jwk.New(priv)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(signer.Set(jwk.KeyIDKey, "k1")).Should(Succeed())
+
+		pub, err := jwk.PublicKeyOf(signer)
+		Ω(err).ShouldNot(HaveOccurred())
+		keys := jwk.NewSet()
+		keys.Add(pub)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(discovery)
+		})
+		mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(keys)
+		})
+		idp = httptest.NewServer(mux)
+
+		discovery = map[string]string{
+			"issuer":                 idp.URL,
+			"authorization_endpoint": idp.URL + "/authorize",
+			"token_endpoint":         idp.URL + "/token",
+			"jwks_uri":               idp.URL + "/keys",
+		}
+		client = NewClient(idp.URL, "shield", "", true)
+	})
+
+	AfterEach(func() {
+		idp.Close()
+	})
+
+	/* an id token, as issued by our fake identity provider,
+	   with whatever claims the test needs changed */
+	token := func(changes map[string]interface{}) string {
+		claims := map[string]interface{}{
+			"iss":   idp.URL,
+			"sub":   "8f3e0c1a",
+			"aud":   "shield",
+			"nonce": "n0nce",
+			"iat":   time.Now().Unix(),
+			"exp":   time.Now().Add(5 * time.Minute).Unix(),
+		}
+		for k, v := range changes {
+			if v == nil {
+				delete(claims, k)
+			} else {
+				claims[k] = v
+			}
+		}
+
+		b, err := json.Marshal(claims)
+		Ω(err).ShouldNot(HaveOccurred())
+		signed, err := jws.Sign(b, jwa.RS256, signer)
+		Ω(err).ShouldNot(HaveOccurred())
+		return string(signed)
+	}
+
+	Context("Discovery", func() {
+		It("finds the identity provider's endpoints", func() {
+			config, err := client.Discover()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(config.AuthorizationEndpoint).Should(Equal(idp.URL + "/authorize"))
+			Ω(config.TokenEndpoint).Should(Equal(idp.URL + "/token"))
+
+			uri, err := client.AuthorizationURL("https://shield/auth/sso/redir", DefaultScopes, "st4te", "n0nce", "ch4llenge")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(uri).Should(HavePrefix(idp.URL + "/authorize?"))
+			Ω(uri).Should(ContainSubstring("code_challenge_method=S256"))
+		})
+
+		It("refuses a discovery document for some other issuer", func() {
+			discovery["issuer"] = "https://evil.example.com"
+			_, err := client.Discover()
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("refuses an incomplete discovery document", func() {
+			delete(discovery, "jwks_uri")
+			_, err := client.Discover()
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Context("ID token verification", func() {
+		It("accepts a good id token, and returns its claims", func() {
+			claims, err := client.VerifyIDToken(token(map[string]interface{}{"aud": []string{"other", "shield"}}), "n0nce")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(claims.String("sub")).Should(Equal("8f3e0c1a"))
+		})
+
+		It("rejects id tokens issued to some other client", func() {
+			_, err := client.VerifyIDToken(token(map[string]interface{}{"aud": "not-shield"}), "n0nce")
+			Ω(err).Should(HaveOccurred())
+
+			_, err = client.VerifyIDToken(token(map[string]interface{}{"azp": "not-shield"}), "n0nce")
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("rejects id tokens from some other issuer", func() {
+			_, err := client.VerifyIDToken(token(map[string]interface{}{"iss": "https://evil.example.com"}), "n0nce")
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("rejects id tokens for some other login", func() {
+			_, err := client.VerifyIDToken(token(nil), "some-other-nonce")
+			Ω(err).Should(HaveOccurred())
+
+			_, err = client.VerifyIDToken(token(map[string]interface{}{"nonce": nil}), "n0nce")
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("rejects expired id tokens, allowing for some clock skew", func() {
+			_, err := client.VerifyIDToken(token(map[string]interface{}{"exp": time.Now().Add(-Leeway / 2).Unix()}), "n0nce")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, err = client.VerifyIDToken(token(map[string]interface{}{"exp": time.Now().Add(-2 * Leeway).Unix()}), "n0nce")
+			Ω(err).Should(HaveOccurred())
+
+			_, err = client.VerifyIDToken(token(map[string]interface{}{"exp": nil}), "n0nce")
+			Ω(err).Should(HaveOccurred())
+
+			_, err = client.VerifyIDToken(token(map[string]interface{}{"iat": time.Now().Add(2 * Leeway).Unix()}), "n0nce")
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("rejects id tokens without a subject", func() {
+			_, err := client.VerifyIDToken(token(map[string]interface{}{"sub": nil}), "n0nce")
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("rejects id tokens that were not signed by the identity provider", func() {
+			priv, err := rsa.GenerateKey(rand.Reader, 2048)
+			Ω(err).ShouldNot(HaveOccurred())
+			signer, err = jwk.New(priv)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(signer.Set(jwk.KeyIDKey, "k1")).Should(Succeed())
+
+			_, err = client.VerifyIDToken(token(nil), "n0nce")
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("rejects unsigned id tokens", func() {
+			signed := strings.Split(token(nil), ".")
+			unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"k1"}`)) + "." + signed[1] + "."
+			_, err := client.VerifyIDToken(unsigned, "n0nce")
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+})
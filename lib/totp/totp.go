@@ -0,0 +1,115 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Every authenticator app out there supports (and defaults to)
+	// six-digit, SHA-1 codes that change every thirty seconds, so
+	// that's what we use, per RFC 6238.
+	Digits = 6
+	Period = 30
+
+	// How many periods either side of now we accept codes for, to
+	// allow for clock drift and for people who type slowly.
+	Skew = 1
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewSecret generates a new, random, base32-encoded shared secret.
+func NewSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("unable to generate TOTP secret: %s", err)
+	}
+	return encoding.EncodeToString(b), nil
+}
+
+// URI returns the otpauth:// provisioning URI for a secret, which
+// authenticator apps can import (usually by scanning it as a QR code.)
+func URI(issuer, account, secret string) string {
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprintf("%d", Digits))
+	q.Set("period", fmt.Sprintf("%d", Period))
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: q.Encode(),
+	}
+	return u.String()
+}
+
+// Step returns the time step (the number of periods since the epoch)
+// that a given time falls into.
+func Step(t time.Time) int64 {
+	return t.Unix() / Period
+}
+
+// Code computes the code for a secret, at a given time step.
+func Code(secret string, step int64) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %s", err)
+	}
+
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	/* dynamic truncation, per RFC 4226 */
+	off := sum[len(sum)-1] & 0x0f
+	n := binary.BigEndian.Uint32(sum[off:off+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", Digits, n%mod), nil
+}
+
+// Verify checks a code against a secret, allowing for Skew periods of
+// clock drift either way.  Codes for time steps at or before last (the
+// step of the last code accepted) are refused, so that no code can be
+// used twice.  If the code is good, the time step that it was good for
+// is returned, for the caller to remember as the new last step.
+func Verify(secret, code string, t time.Time, last int64) (int64, bool) {
+	code = strings.Replace(code, " ", "", -1)
+	if len(code) != Digits {
+		return 0, false
+	}
+
+	now := Step(t)
+	for step := now - Skew; step <= now+Skew; step++ {
+		if step <= last {
+			continue
+		}
+
+		want, err := Code(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(want), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,114 @@
+package totp_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/shieldproject/shield/lib/totp"
+)
+
+var _ = Describe("TOTP", func() {
+	/* the SHA-1 seed from RFC 6238, Appendix B ("12345678901234567890") */
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	at := func(epoch int64) time.Time {
+		return time.Unix(epoch, 0)
+	}
+
+	It("generates the RFC 6238 test vectors", func() {
+		/* the RFC gives eight-digit codes; ours are
+		   the last six digits of the same values. */
+		for epoch, want := range map[int64]string{
+			59:          "287082", /* 94287082 */
+			1111111109:  "081804", /* 07081804 */
+			1111111111:  "050471", /* 14050471 */
+			1234567890:  "005924", /* 89005924 */
+			2000000000:  "279037", /* 69279037 */
+			20000000000: "353130", /* 65353130 */
+		} {
+			code, err := Code(secret, Step(at(epoch)))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(code).Should(Equal(want), "code for T=%d", epoch)
+		}
+	})
+
+	It("accepts lower-case secrets, and refuses malformed ones", func() {
+		code, err := Code("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", Step(at(59)))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(code).Should(Equal("287082"))
+
+		_, err = Code("not base32!", 1)
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("generates distinct, usable secrets", func() {
+		a, err := NewSecret()
+		Ω(err).ShouldNot(HaveOccurred())
+		b, err := NewSecret()
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(a).ShouldNot(Equal(b))
+
+		_, err = Code(a, 1)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	Context("verifying codes", func() {
+		now := at(1234567890)
+
+		It("accepts codes up to Skew periods either side of now", func() {
+			for offset := int64(-Skew); offset <= Skew; offset++ {
+				code, err := Code(secret, Step(now)+offset)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				step, ok := Verify(secret, code, now, 0)
+				Ω(ok).Should(BeTrue(), "code at offset %d", offset)
+				Ω(step).Should(Equal(Step(now) + offset))
+			}
+		})
+
+		It("refuses codes from outside of the skew window", func() {
+			for _, offset := range []int64{-Skew - 1, Skew + 1} {
+				code, err := Code(secret, Step(now)+offset)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				_, ok := Verify(secret, code, now, 0)
+				Ω(ok).Should(BeFalse(), "code at offset %d", offset)
+			}
+		})
+
+		It("refuses a code whose time step was already used", func() {
+			code, err := Code(secret, Step(now))
+			Ω(err).ShouldNot(HaveOccurred())
+
+			last, ok := Verify(secret, code, now, 0)
+			Ω(ok).Should(BeTrue())
+
+			_, ok = Verify(secret, code, now, last)
+			Ω(ok).Should(BeFalse())
+
+			/* nor any code older than that */
+			older, err := Code(secret, Step(now)-1)
+			Ω(err).ShouldNot(HaveOccurred())
+			_, ok = Verify(secret, older, now, last)
+			Ω(ok).Should(BeFalse())
+
+			/* but the next one is fine */
+			newer, err := Code(secret, Step(now)+1)
+			Ω(err).ShouldNot(HaveOccurred())
+			_, ok = Verify(secret, newer, now, last)
+			Ω(ok).Should(BeTrue())
+		})
+
+		It("ignores spaces, and refuses codes of the wrong length", func() {
+			_, ok := Verify(secret, "005 924", now, 0)
+			Ω(ok).Should(BeTrue())
+
+			_, ok = Verify(secret, "5924", now, 0)
+			Ω(ok).Should(BeFalse())
+			_, ok = Verify(secret, "89005924", now, 0)
+			Ω(ok).Should(BeFalse())
+		})
+	})
+})
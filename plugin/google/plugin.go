@@ -198,6 +198,39 @@ func (p GooglePlugin) Purge(endpoint plugin.ShieldEndpoint, file string) error {
 	return nil
 }
 
+func (p GooglePlugin) Hold(endpoint plugin.ShieldEndpoint, file string) error {
+	return p.setTemporaryHold(endpoint, file, true)
+}
+
+func (p GooglePlugin) Release(endpoint plugin.ShieldEndpoint, file string) error {
+	return p.setTemporaryHold(endpoint, file, false)
+}
+
+// GCS temporary holds keep an object from being deleted (or replaced)
+// until the hold is lifted, regardless of any bucket retention policy
+// or lifecycle rules.
+func (p GooglePlugin) setTemporaryHold(endpoint plugin.ShieldEndpoint, file string, held bool) error {
+	gcs, err := getGoogleConnInfo(endpoint)
+	if err != nil {
+		return err
+	}
+
+	client, err := gcs.Connect()
+	if err != nil {
+		return err
+	}
+
+	object := &storage.Object{
+		TemporaryHold:   held,
+		ForceSendFields: []string{"TemporaryHold"},
+	}
+	if _, err := client.Objects.Patch(gcs.Bucket, file, object).Do(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func getGoogleConnInfo(e plugin.ShieldEndpoint) (GoogleConnectionInfo, error) {
 	jsonKey, err := e.StringValueDefault("json_key", DefaultJsonKey)
 	if err != nil {
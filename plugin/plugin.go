@@ -40,6 +40,8 @@ type Opt struct {
 	Store    struct{} `cli:"store"`
 	Retrieve struct{} `cli:"retrieve"`
 	Purge    struct{} `cli:"purge"`
+	Hold     struct{} `cli:"hold"`
+	Release  struct{} `cli:"release"`
 }
 
 type Plugin interface {
@@ -52,6 +54,16 @@ type Plugin interface {
 	Meta() PluginInfo
 }
 
+// A Holder is a store plugin that can place (and release) a legal
+// hold on a backup archive, using whatever the backing storage
+// provides for that purpose, so that the archive cannot be deleted
+// out from under SHIELD.  Implementing Holder is optional; the hold
+// and release commands of plugins that do not are unsupported.
+type Holder interface {
+	Hold(ShieldEndpoint, string) error
+	Release(ShieldEndpoint, string) error
+}
+
 type Field struct {
 	Mode     string   `json:"mode"`
 	Name     string   `json:"name"`
@@ -133,6 +145,8 @@ COMMANDS
   store    -e JSON [--text]    Store a backup archive
   retrieve -e JSON -k KEY      Stream a backup archive from storage
   purge    -e JSON -k KEY      Delete a backup archive from storage
+  hold     -e JSON -k KEY      Place a legal hold on a backup archive
+  release  -e JSON -k KEY      Release a legal hold on a backup archive
 `)
 		if info.Example != "" {
 			fmt.Fprintf(os.Stderr, "\nEXAMPLE ENDPOINT CONFIGURATION\n%s\n", info.Example)
@@ -206,6 +220,16 @@ STORAGE COMMANDS
 
     Removes a backup archive from the backing storage, using the
     STORAGE-HANDLE given by a previous 'store' command.
+
+  hold --key STORAGE-HANDLE --endpoint STORE-ENDPOINT-JSON
+
+    Places a legal hold on a backup archive in the backing storage,
+    so that it cannot be deleted until the hold is released.  Not
+    all storage systems support holds.
+
+  release --key STORAGE-HANDLE --endpoint STORE-ENDPOINT-JSON
+
+    Releases a legal hold placed by a previous 'hold' command.
 `)
 		os.Exit(0)
 	}
@@ -394,6 +418,24 @@ func dispatch(p Plugin, mode string, opt Opt) error {
 		}
 		err = p.Purge(endpoint, opt.Key)
 
+	case "hold", "release":
+		holder, ok := p.(Holder)
+		if !ok {
+			return UnsupportedActionError{Action: mode}
+		}
+		endpoint, err = getEndpoint(opt.Endpoint)
+		if err != nil {
+			return err
+		}
+		if opt.Key == "" {
+			return MissingRestoreKeyError{}
+		}
+		if mode == "hold" {
+			err = holder.Hold(endpoint, opt.Key)
+		} else {
+			err = holder.Release(endpoint, opt.Key)
+		}
+
 	default:
 		return UnsupportedActionError{Action: mode}
 	}
@@ -23,3 +23,10 @@ func newMatch(pat string) matcher {
 		return m, m != nil
 	}
 }
+
+// Matches reports whether the request would be dispatched to a route
+// with the given pattern, i.e. "GET /v2/tenants/:uuid/tasks".
+func (r *Request) Matches(pat string) bool {
+	_, ok := newMatch(pat)(r.Req)
+	return ok
+}
@@ -4,8 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
-	"regexp"
+	"strings"
 	"time"
 
 	"github.com/jhunt/go-log"
@@ -20,9 +21,26 @@ type Request struct {
 	Req  *http.Request
 	Args []string
 
-	w     http.ResponseWriter
-	debug bool
-	bt    []string
+	// Route is the pattern of the route that matched this request,
+	// i.e. "PUT /v2/tenants/:uuid/jobs/:uuid".
+	Route string
+
+	w      http.ResponseWriter
+	debug  bool
+	bt     []string
+	status int
+	audit  Audit
+}
+
+// Audit is what a handler notes down about what its request did,
+// for the benefit of an audit log.
+type Audit struct {
+	Actor  string
+	Type   string
+	UUID   string
+	Before interface{}
+	After  interface{}
+	Skip   bool
 }
 
 // NewRequest initializes and returns a new request object. Setting debug to
@@ -39,15 +57,68 @@ func (r *Request) String() string {
 	return fmt.Sprintf("%s %s", r.Req.Method, r.Req.URL.Path)
 }
 
-func (r *Request) RemoteIP() string {
-	ip := ""
-	if xff := r.Req.Header.Get("X-Forwarded-For"); xff != "" {
-		ip = regexp.MustCompile("[, ].*$").ReplaceAllString(xff, "")
-	}
-	if ip == "" {
+// ClientIP returns the IP address (without any port) of the client
+// that made the request.  The X-Forwarded-For header is only believed
+// if the request came from one of the trusted proxies, in which case
+// it is read from right to left, past any other trusted proxies, to
+// the first address that none of them can vouch for.
+func (r *Request) ClientIP(trusted []*net.IPNet) string {
+	ip := hostIP(r.Req.RemoteAddr)
+	if ip == nil {
 		return r.Req.RemoteAddr
 	}
-	return ip
+
+	hops := strings.Split(r.Req.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0 && isTrusted(ip, trusted); i-- {
+		hop := hostIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		ip = hop
+	}
+	return ip.String()
+}
+
+// ParseTrustedProxies parses a list of IP addresses and CIDR ranges,
+// for use with ClientIP.
+func ParseTrustedProxies(l []string) ([]*net.IPNet, error) {
+	nets := []*net.IPNet{}
+	for _, s := range l {
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address '%s'", s)
+			}
+			if ip.To4() != nil {
+				s += "/32"
+			} else {
+				s += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR range '%s'", s)
+		}
+		nets = append(nets, network)
+	}
+	return nets, nil
+}
+
+func hostIP(addr string) net.IP {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return net.ParseIP(addr)
+}
+
+func isTrusted(ip net.IP, trusted []*net.IPNet) bool {
+	for _, network := range trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
 }
 
 func (r *Request) UserAgent() string {
@@ -58,6 +129,40 @@ func (r *Request) Done() bool {
 	return len(r.bt) > 0
 }
 
+// Status returns the HTTP status code that the request was answered
+// with, or 0 if it hasn't been answered yet.
+func (r *Request) Status() int {
+	return r.status
+}
+
+// Audit notes what kind of thing (and which one) the request acted
+// upon, and what it looked like before and after.  Either of before
+// and after can be nil, for things being created or deleted.
+func (r *Request) Audit(typ, uuid string, before, after interface{}) {
+	r.audit.Type = typ
+	r.audit.UUID = uuid
+	r.audit.Before = before
+	r.audit.After = after
+}
+
+// AuditAs notes who the request was made by, when that can't be
+// worked out from its session (i.e. for logins.)
+func (r *Request) AuditAs(actor string) {
+	r.audit.Actor = actor
+}
+
+// Unaudited notes that the request, though it may look like it, did
+// not really change anything, and need not be audited.
+func (r *Request) Unaudited() {
+	r.audit.Skip = true
+}
+
+// Audited returns everything that has been noted down about the
+// request, via Audit(), AuditAs(), and Unaudited().
+func (r *Request) Audited() Audit {
+	return r.audit
+}
+
 func (r *Request) respond(code int, fn, typ, msg string) {
 	/* have we already responded for this request? */
 	if r.Done() {
@@ -68,6 +173,7 @@ func (r *Request) respond(code int, fn, typ, msg string) {
 	/* respond ... */
 	r.w.Header().Set("Content-Type", typ)
 	r.w.WriteHeader(code)
+	r.status = code
 	fmt.Fprintf(r.w, "%s\n", msg)
 
 	/* track that OK() or Fail() called us... */
@@ -88,6 +194,7 @@ func (r *Request) Redirect(code int, to string) {
 	/* respond ... */
 	r.w.Header().Set("Location", to)
 	r.w.WriteHeader(code)
+	r.status = code
 
 	/* track that we finished via Redirect() */
 	r.bt = append(r.bt, "Redirect")
@@ -100,6 +207,16 @@ func (r *Request) Success(msg string, args ...interface{}) {
 }
 
 func (r *Request) OK(resp interface{}) {
+	r.respondJSON(200, "OK", resp)
+}
+
+// Accepted responds to a request that has been accepted, but not yet
+// acted upon (i.e. because someone else has to approve it first.)
+func (r *Request) Accepted(resp interface{}) {
+	r.respondJSON(202, "Accepted", resp)
+}
+
+func (r *Request) respondJSON(code int, fn string, resp interface{}) {
 	b, err := json.Marshal(resp)
 	if err != nil {
 		log.Errorf("%s errored, trying to marshal a JSON error response: %s", r, err)
@@ -107,7 +224,7 @@ func (r *Request) OK(resp interface{}) {
 		return
 	}
 
-	r.respond(200, "OK", "application/json", string(b))
+	r.respond(code, fn, "application/json", string(b))
 }
 
 func (r *Request) Fail(e Error) {
@@ -138,6 +255,7 @@ func (r *Request) JSONEncoder() *json.Encoder {
 	/* respond ... */
 	r.w.Header().Set("Content-Type", "application/json")
 	r.w.WriteHeader(200)
+	r.status = 200
 
 	/* track that OK() or Fail() called us... */
 	r.bt = append(r.bt, "StreamJSON")
@@ -145,6 +263,30 @@ func (r *Request) JSONEncoder() *json.Encoder {
 	return json.NewEncoder(r.w)
 }
 
+// Stream starts a successful response of the given content type, with
+// any extra headers, and returns a writer for the body, so that large
+// responses can be sent without buffering them in memory first.
+func (r *Request) Stream(typ string, headers map[string]string) io.Writer {
+	/* have we already responded for this request? */
+	if r.Done() {
+		log.Errorf("%s handler bug: called Stream() having already called [%v]", r, r.bt)
+		return nil
+	}
+
+	/* respond ... */
+	r.w.Header().Set("Content-Type", typ)
+	for k, v := range headers {
+		r.w.Header().Set(k, v)
+	}
+	r.w.WriteHeader(200)
+	r.status = 200
+
+	/* track that we are streaming... */
+	r.bt = append(r.bt, "Stream")
+
+	return r.w
+}
+
 // Payload unmarshals the JSON body of this request into the given interface.
 // Returns true if successful and false otherwise.
 func (r *Request) Payload(v interface{}) bool {
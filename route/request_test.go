@@ -0,0 +1,77 @@
+package route_test
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/shieldproject/shield/route"
+)
+
+var _ = Describe("Client IP Addresses", func() {
+	request := func(remote, xff string) *Request {
+		req, err := http.NewRequest("POST", "/v2/auth/login", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		req.RemoteAddr = remote
+		if xff != "" {
+			req.Header.Set("X-Forwarded-For", xff)
+		}
+		return NewRequest(nil, req, false)
+	}
+
+	trust := func(l ...string) []*net.IPNet {
+		nets, err := ParseTrustedProxies(l)
+		Ω(err).ShouldNot(HaveOccurred())
+		return nets
+	}
+
+	It("leaves the port off of the peer address", func() {
+		Ω(request("192.0.2.7:40001", "").ClientIP(nil)).Should(Equal("192.0.2.7"))
+		Ω(request("192.0.2.7:40002", "").ClientIP(nil)).Should(Equal("192.0.2.7"))
+		Ω(request("[2001:db8::7]:40003", "").ClientIP(nil)).Should(Equal("2001:db8::7"))
+	})
+
+	It("gives the same address for every connection from one host", func() {
+		/* IP lockouts are keyed on this; a client that
+		   reconnects must not get a clean slate. */
+		for port := 40001; port < 40010; port++ {
+			Ω(request(fmt.Sprintf("192.0.2.7:%d", port), "").ClientIP(nil)).Should(Equal("192.0.2.7"))
+			Ω(request(fmt.Sprintf("192.0.2.7:%d", port), fmt.Sprintf("198.51.100.%d", port%256)).ClientIP(nil)).Should(Equal("192.0.2.7"))
+		}
+	})
+
+	It("ignores X-Forwarded-For from peers that are not trusted proxies", func() {
+		Ω(request("192.0.2.7:40001", "10.0.0.1").ClientIP(nil)).Should(Equal("192.0.2.7"))
+		Ω(request("192.0.2.7:40001", "10.0.0.1").ClientIP(trust("10.0.0.0/8"))).Should(Equal("192.0.2.7"))
+	})
+
+	It("believes X-Forwarded-For from trusted proxies", func() {
+		proxies := trust("10.0.0.0/8", "172.16.0.1")
+		Ω(request("10.1.2.3:8443", "192.0.2.7").ClientIP(proxies)).Should(Equal("192.0.2.7"))
+		Ω(request("172.16.0.1:8443", "192.0.2.7:40001").ClientIP(proxies)).Should(Equal("192.0.2.7"))
+
+		/* chained proxies */
+		Ω(request("10.1.2.3:8443", "192.0.2.7, 172.16.0.1").ClientIP(proxies)).Should(Equal("192.0.2.7"))
+
+		/* without a header, the proxy is the client */
+		Ω(request("10.1.2.3:8443", "").ClientIP(proxies)).Should(Equal("10.1.2.3"))
+	})
+
+	It("does not let clients forge their way past a trusted proxy", func() {
+		/* the client sent "X-Forwarded-For: 10.9.9.9" itself,
+		   and the proxy appended the real client address. */
+		proxies := trust("10.0.0.0/8")
+		Ω(request("10.1.2.3:8443", "10.9.9.9, 192.0.2.7").ClientIP(proxies)).Should(Equal("192.0.2.7"))
+		Ω(request("10.1.2.3:8443", "not-an-ip, 192.0.2.7").ClientIP(proxies)).Should(Equal("192.0.2.7"))
+	})
+
+	It("rejects invalid trusted proxies", func() {
+		_, err := ParseTrustedProxies([]string{"10.0.0.0/33"})
+		Ω(err).Should(HaveOccurred())
+		_, err = ParseTrustedProxies([]string{"proxy.example.com"})
+		Ω(err).Should(HaveOccurred())
+	})
+})
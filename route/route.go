@@ -9,17 +9,24 @@ import (
 type Handler func(r *Request)
 
 type route struct {
+	pattern string
 	matcher matcher
 	handler Handler
 }
 
 type Router struct {
-	Debug  bool
+	Debug bool
+
+	// Around, if set, is called to run each matched handler, so that
+	// things can be done before and after every request (i.e. auditing).
+	Around func(r *Request, handler Handler)
+
 	routes []route
 }
 
 func (r *Router) Dispatch(match string, handler Handler) {
 	r.routes = append(r.routes, route{
+		pattern: match,
 		matcher: newMatch(match),
 		handler: handler,
 	})
@@ -33,7 +40,12 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			w.Header().Set("Content-Type", "application/json")
 
 			request.Args = args
-			rt.handler(request)
+			request.Route = rt.pattern
+			if r.Around != nil {
+				r.Around(request, rt.handler)
+			} else {
+				rt.handler(request)
+			}
 			if !request.Done() {
 				log.Errorf("%s handler bug: failed to call either OK() or Fail()", request)
 				request.Fail(Oops(nil, "an unknown error has occurred"))
@@ -0,0 +1,30 @@
+package timespec
+
+import (
+	"hash/fnv"
+	"time"
+)
+
+// HashedOffset derives an offset within the given window from a key
+// (usually a job UUID), so that the same key always gets the same
+// offset.  Offsets are whole seconds.
+func HashedOffset(key string, window time.Duration) time.Duration {
+	secs := int64(window / time.Second)
+	if secs <= 0 {
+		return 0
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return time.Duration(h.Sum64()%uint64(secs)) * time.Second
+}
+
+// SpreadOffset returns the offset of the i'th of n things, spread
+// evenly across the given window, so that the first starts at the
+// beginning of the window and the rest follow at regular intervals.
+func SpreadOffset(i, n int, window time.Duration) time.Duration {
+	if n <= 0 || i < 0 || window <= 0 {
+		return 0
+	}
+	return time.Duration(int64(window) / int64(n) * int64(i)).Truncate(time.Second)
+}
@@ -0,0 +1,73 @@
+package timespec_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/shieldproject/shield/timespec"
+)
+
+var _ = Describe("Jitter and Offsets", func() {
+	at := func(day, hour, minute, second int) time.Time {
+		return time.Date(2018, time.March, day, hour, minute, second, 0, time.UTC)
+	}
+
+	spec := func(s string) *Spec {
+		sp, err := Parse(s)
+		Ω(err).ShouldNot(HaveOccurred())
+		return sp
+	}
+
+	It("delays each occurrence by the offset", func() {
+		s := spec("daily 2am")
+		s.Offset = 7*time.Minute + 30*time.Second
+
+		next, err := s.Next(at(1, 1, 0, 0))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(next).Should(Equal(at(1, 2, 7, 30)))
+	})
+
+	It("moves on to the next occurrence once the delayed one has passed", func() {
+		s := spec("daily 2am")
+		s.Offset = 7*time.Minute + 30*time.Second
+
+		next, err := s.Next(at(1, 2, 5, 0))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(next).Should(Equal(at(1, 2, 7, 30)))
+
+		next, err = s.Next(at(1, 2, 7, 30))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(next).Should(Equal(at(2, 2, 7, 30)))
+	})
+
+	It("keeps jittered occurrences within the jitter window", func() {
+		s := spec("daily 2am")
+		s.Jitter = 10 * time.Minute
+
+		for i := 0; i < 100; i++ {
+			next, err := s.Next(at(1, 1, 0, 0))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(next).Should(BeTemporally(">=", at(1, 2, 0, 0)))
+			Ω(next).Should(BeTemporally("<", at(1, 2, 10, 0)))
+		}
+	})
+
+	It("hashes keys to stable offsets within the window", func() {
+		a := HashedOffset("a-job", 15*time.Minute)
+		Ω(HashedOffset("a-job", 15*time.Minute)).Should(Equal(a))
+		Ω(a).Should(BeNumerically(">=", 0))
+		Ω(a).Should(BeNumerically("<", 15*time.Minute))
+		Ω(a % time.Second).Should(BeZero())
+
+		Ω(HashedOffset("a-job", 0)).Should(BeZero())
+	})
+
+	It("spreads things evenly across a window", func() {
+		Ω(SpreadOffset(0, 4, time.Hour)).Should(Equal(time.Duration(0)))
+		Ω(SpreadOffset(1, 4, time.Hour)).Should(Equal(15 * time.Minute))
+		Ω(SpreadOffset(3, 4, time.Hour)).Should(Equal(45 * time.Minute))
+		Ω(SpreadOffset(0, 0, time.Hour)).Should(Equal(time.Duration(0)))
+	})
+})
@@ -1,6 +1,68 @@
 package timespec
 
+import (
+	"time"
+)
+
+// KeepN works out how many backups the spec will have taken over
+// the given number of days, counting back from now.  See KeepNAt.
 func (s *Spec) KeepN(days int) int {
+	return s.KeepNAt(days, time.Now())
+}
+
+// KeepNAt works out how many backups the spec will have taken in the
+// given number of days, up to (and including) the given day.  Days
+// ruled out by the spec's except clause are not counted; excluded
+// calendar dates are only known once the spec's exclusions have been
+// resolved (into ExceptDates).
+func (s *Spec) KeepNAt(days int, at time.Time) int {
+	n := s.keepN(days)
+	if n <= 0 || (len(s.ExceptDays) == 0 && len(s.ExceptDates) == 0) {
+		return n
+	}
+
+	/* scale back by the share of the days we
+	   would have run on that were excluded */
+	y, m, d := at.Date()
+	running, kept := 0, 0
+	for i := 0; i < days; i++ {
+		day := time.Date(y, m, d-i, 12, 0, 0, 0, at.Location())
+		if !s.mightRunOn(day.Weekday()) {
+			continue
+		}
+		running++
+		if !s.Excludes(day) {
+			kept++
+		}
+	}
+	if running == 0 {
+		return n
+	}
+	return n * kept / running
+}
+
+// mightRunOn reports whether or not the spec ever runs on the given day
+// of the week, before taking its except clause into account.
+func (s *Spec) mightRunOn(wd time.Weekday) bool {
+	days := s.Days
+	if len(days) == 0 && !s.Bounded && s.Interval == Weekly {
+		days = []time.Weekday{s.DayOfWeek}
+	}
+	if len(days) == 0 {
+		return true
+	}
+	return (&Spec{Days: days}).runsOn(wd)
+}
+
+func (s *Spec) keepN(days int) int {
+	if s.Bounded {
+		n := days * len(s.boundedRuns())
+		if len(s.Days) > 0 {
+			return n * len(s.Days) / 7
+		}
+		return n
+	}
+
 	switch s.Interval {
 	default:
 		return -1
@@ -10,11 +72,17 @@ func (s *Spec) KeepN(days int) int {
 		if s.Cardinality == 0 {
 			return days * 24
 		} else {
-			return days * 24 / int(s.Cardinality)
+			return int(float32(days*24) / s.Cardinality)
 		}
 	case Daily:
+		if s.Cardinality > 1 {
+			return days / int(s.Cardinality)
+		}
 		return days
 	case Weekly:
+		if len(s.Days) > 0 {
+			return days * len(s.Days) / 7
+		}
 		return days / 7
 	case Monthly:
 		return days / 30
@@ -10,7 +10,11 @@ import (
 	numval  uint
 	time    int
 	wday    time.Weekday
+	wdays   []time.Weekday
 	spec   *Spec
+	except *exclusion
+	bounds *bounds
+	name    string
 	truth   bool
 }
 
@@ -18,10 +22,14 @@ import (
 %type  <time>   time_in_HHMM
 %type  <numval> month_day minutes
 %type  <wday>   day_name
+%type  <wdays>  day_list day_set
 %type  <spec>   spec minutely_spec hourly_spec daily_spec weekly_spec monthly_spec
 %type  <truth>  am_or_pm
+%type  <except> exclusion exclusions
+%type  <bounds> between
 
 %token <numval> NUMBER ORDINAL
+%token <name>   NAME
 
 %token HOURLY
 %token DAILY
@@ -47,20 +55,49 @@ import (
 %token THURSDAY
 %token FRIDAY
 %token SATURDAY
+%token WEEKDAYS
+%token WEEKENDS
+%token LAST
+%token OF
+%token THE
+%token MONTH
+%token EXCEPT
+%token DATES
+%token IN
+%token AND
+%token CALENDAR
+%token BETWEEN
 
 %%
 
 timespec : spec {
                    yylex.(*yyLex).spec = $1
                 }
+         | spec EXCEPT exclusions {
+                   yylex.(*yyLex).spec = $1.except($3)
+                }
          ;
 
+exclusions : exclusion
+           | exclusions AND exclusion  { $$ = $1.and($3) }
+           ;
+
+exclusion : day_name                           { $$ = &exclusion{days: []time.Weekday{$1}} }
+          | day_set                            { $$ = &exclusion{days: $1} }
+          | DATES IN     NAME                  { $$ = &exclusion{calendars: []string{$3}} }
+          | DATES IN     NAME CALENDAR         { $$ = &exclusion{calendars: []string{$3}} }
+          | DATES IN THE NAME                  { $$ = &exclusion{calendars: []string{$4}} }
+          | DATES IN THE NAME CALENDAR         { $$ = &exclusion{calendars: []string{$4}} }
+          ;
+
 spec : minutely_spec | hourly_spec | daily_spec | weekly_spec | monthly_spec
      ;
 
 minutely_spec : EVERY NUMBER MINUTE FROM time_in_HHMM { $$ = minutely($5, int($2)) }
               | EVERY        MINUTE                   { $$ = minutely( 0, 1) }
               | EVERY NUMBER MINUTE                   { $$ = minutely( 0, int($2)) }
+              | EVERY        MINUTE between           { $$ = minutely( 0, 1).between($3) }
+              | EVERY NUMBER MINUTE between           { $$ = minutely( 0, int($2)).between($4) }
               ;
 
 hourly_spec : HOURLY     AT time_in_MM             { $$ = hourly($3, 0) }
@@ -70,12 +107,27 @@ hourly_spec : HOURLY     AT time_in_MM             { $$ = hourly($3, 0) }
             | EVERY HOUR AT time_in_MM             { $$ = hourly($4, 0) }
             | EVERY HOUR time_in_MM                { $$ = hourly($3, 0) }
             | EVERY NUMBER HOUR FROM time_in_HHMM  { $$ = hourly($5, float32($2)) }
+            | HOURLY                between        { $$ = hourly( 0, 0).between($2) }
+            | HOURLY     AT time_in_MM between     { $$ = hourly($3, 0).between($4) }
+            | HOURLY        time_in_MM between     { $$ = hourly($2, 0).between($3) }
+            | EVERY HOUR            between        { $$ = hourly( 0, 0).between($3) }
+            | EVERY HOUR AT time_in_MM between     { $$ = hourly($4, 0).between($5) }
+            | EVERY QUARTER HOUR    between        { $$ = hourly( 0, 0.25).between($4) }
+            | EVERY HALF HOUR       between        { $$ = hourly( 0, 0.5).between($4) }
+            | EVERY NUMBER HOUR     between        { $$ = hourly( 0, float32($2)).between($4) }
             ;
 
-daily_spec : DAILY    AT time_in_HHMM   { $$ = daily($3) }
-           | DAILY       time_in_HHMM   { $$ = daily($2) }
-           | EVERY DAY AT time_in_HHMM  { $$ = daily($4) }
-           | EVERY DAY    time_in_HHMM  { $$ = daily($3) }
+between : BETWEEN time_in_HHMM AND time_in_HHMM                 { $$ = &bounds{start: $2, end: $4} }
+        | BETWEEN time_in_HHMM AND time_in_HHMM ON day_name     { $$ = &bounds{start: $2, end: $4, days: []time.Weekday{$6}} }
+        | BETWEEN time_in_HHMM AND time_in_HHMM ON day_set      { $$ = &bounds{start: $2, end: $4, days: $6} }
+        ;
+
+daily_spec : DAILY    AT time_in_HHMM          { $$ = daily($3) }
+           | DAILY       time_in_HHMM          { $$ = daily($2) }
+           | EVERY DAY AT time_in_HHMM         { $$ = daily($4) }
+           | EVERY DAY    time_in_HHMM         { $$ = daily($3) }
+           | EVERY NUMBER DAY AT time_in_HHMM  { $$ = ndays($5, $2) }
+           | EVERY NUMBER DAY    time_in_HHMM  { $$ = ndays($4, $2) }
            ;
 
 anyhour: | 'h' | 'H' | 'x' | 'X' | '*' ;
@@ -104,8 +156,23 @@ weekly_spec : WEEKLY AT time_in_HHMM ON day_name { $$ = weekly($3, $5) }
             | WEEKLY    time_in_HHMM    day_name { $$ = weekly($2, $3) }
             | day_name AT time_in_HHMM           { $$ = weekly($3, $1) }
             | day_name    time_in_HHMM           { $$ = weekly($2, $1) }
+            | EVERY day_name AT time_in_HHMM     { $$ = weekly($4, $2) }
+            | EVERY day_name    time_in_HHMM     { $$ = weekly($3, $2) }
+            | day_set AT time_in_HHMM            { $$ = weekdays($3, $1) }
+            | day_set    time_in_HHMM            { $$ = weekdays($2, $1) }
+            | EVERY day_set AT time_in_HHMM      { $$ = weekdays($4, $2) }
+            | EVERY day_set    time_in_HHMM      { $$ = weekdays($3, $2) }
             ;
 
+day_set : WEEKDAYS  { $$ = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday} }
+        | WEEKENDS  { $$ = []time.Weekday{time.Saturday, time.Sunday} }
+        | day_list
+        ;
+
+day_list : day_name ',' day_name  { $$ = []time.Weekday{$1, $3} }
+         | day_list ',' day_name  { $$ = append($1, $3) }
+         ;
+
 am_or_pm: AM { $$ = true  }
         | PM { $$ = false }
         ;
@@ -125,8 +192,21 @@ monthly_spec : MONTHLY AT time_in_HHMM ON month_day { $$ = mday($3, $5) }
              | MONTHLY    time_in_HHMM    month_day { $$ = mday($2, $3) }
              | ORDINAL day_name AT time_in_HHMM     { $$ = mweek($4, $2, $1) }
              | ORDINAL day_name    time_in_HHMM     { $$ = mweek($3, $2, $1) }
+             | LAST day_name AT time_in_HHMM        { $$ = mlast($4, $2) }
+             | LAST day_name    time_in_HHMM        { $$ = mlast($3, $2) }
+             | LAST DAY of_month AT time_in_HHMM    { $$ = mlastday($5) }
+             | LAST DAY of_month    time_in_HHMM    { $$ = mlastday($4) }
+             | MONTHLY AT time_in_HHMM ON LAST DAY  { $$ = mlastday($3) }
+             | MONTHLY    time_in_HHMM ON LAST DAY  { $$ = mlastday($2) }
+             | MONTHLY AT time_in_HHMM    LAST DAY  { $$ = mlastday($3) }
+             | MONTHLY    time_in_HHMM    LAST DAY  { $$ = mlastday($2) }
              ;
 
+of_month :
+         | OF MONTH
+         | OF THE MONTH
+         ;
+
 month_day: ORDINAL
          | NUMBER
          ;
@@ -11,6 +11,8 @@ type tokens struct {
 	whitespace *regexp.Regexp
 	number     *regexp.Regexp
 	ordinal    *regexp.Regexp
+	the        *regexp.Regexp
+	name       *regexp.Regexp
 }
 
 type keywordMatcher struct {
@@ -23,6 +25,7 @@ type yyLex struct {
 	keywords []keywordMatcher
 	buf      []byte
 	spec     *Spec
+	wantName bool
 }
 
 func (l *yyLex) eat(m [][]byte) {
@@ -51,6 +54,9 @@ func (l *yyLex) init() {
 	l.keywords = append(l.keywords, keywordMatcher{token: DAILY, match: regexp.MustCompile(`(?i:^daily)`)})
 	l.keywords = append(l.keywords, keywordMatcher{token: WEEKLY, match: regexp.MustCompile(`(?i:^weekly)`)})
 	l.keywords = append(l.keywords, keywordMatcher{token: MONTHLY, match: regexp.MustCompile(`(?i:^monthly)`)})
+	l.keywords = append(l.keywords, keywordMatcher{token: MONTH, match: regexp.MustCompile(`(?i:^month)`)})
+	l.keywords = append(l.keywords, keywordMatcher{token: WEEKDAYS, match: regexp.MustCompile(`(?i:^weekdays?)`)})
+	l.keywords = append(l.keywords, keywordMatcher{token: WEEKENDS, match: regexp.MustCompile(`(?i:^weekends?)`)})
 	l.keywords = append(l.keywords, keywordMatcher{token: FROM, match: regexp.MustCompile(`(?i:^from)`)})
 	l.keywords = append(l.keywords, keywordMatcher{token: AT, match: regexp.MustCompile(`(?i:^at)`)})
 	l.keywords = append(l.keywords, keywordMatcher{token: ON, match: regexp.MustCompile(`(?i:^on)`)})
@@ -64,6 +70,15 @@ func (l *yyLex) init() {
 	l.keywords = append(l.keywords, keywordMatcher{token: QUARTER, match: regexp.MustCompile(`(?i:^quarter)`)})
 	l.keywords = append(l.keywords, keywordMatcher{token: AFTER, match: regexp.MustCompile(`(?i:^(past|after))`)})
 	l.keywords = append(l.keywords, keywordMatcher{token: TIL, match: regexp.MustCompile(`(?i:^(un)?til)`)})
+	l.keywords = append(l.keywords, keywordMatcher{token: BETWEEN, match: regexp.MustCompile(`(?i:^between)`)})
+	l.keywords = append(l.keywords, keywordMatcher{token: LAST, match: regexp.MustCompile(`(?i:^last)`)})
+	l.keywords = append(l.keywords, keywordMatcher{token: OF, match: regexp.MustCompile(`(?i:^of)`)})
+	l.keywords = append(l.keywords, keywordMatcher{token: THE, match: regexp.MustCompile(`(?i:^the)`)})
+	l.keywords = append(l.keywords, keywordMatcher{token: EXCEPT, match: regexp.MustCompile(`(?i:^except)`)})
+	l.keywords = append(l.keywords, keywordMatcher{token: DATES, match: regexp.MustCompile(`(?i:^dates?)`)})
+	l.keywords = append(l.keywords, keywordMatcher{token: IN, match: regexp.MustCompile(`(?i:^in)`)})
+	l.keywords = append(l.keywords, keywordMatcher{token: AND, match: regexp.MustCompile(`(?i:^and)`)})
+	l.keywords = append(l.keywords, keywordMatcher{token: CALENDAR, match: regexp.MustCompile(`(?i:^calendar)`)})
 	l.keywords = append(l.keywords, keywordMatcher{token: SUNDAY, match: regexp.MustCompile(`(?i:^sun(days?)?)`)})
 	l.keywords = append(l.keywords, keywordMatcher{token: MONDAY, match: regexp.MustCompile(`(?i:^mon(days?)?)`)})
 	l.keywords = append(l.keywords, keywordMatcher{token: TUESDAY, match: regexp.MustCompile(`(?i:^tue(s(days?)?)?)`)})
@@ -75,6 +90,8 @@ func (l *yyLex) init() {
 	l.tokens.whitespace = regexp.MustCompile(`^\s+`)
 	l.tokens.number = regexp.MustCompile(`^\d+`)
 	l.tokens.ordinal = regexp.MustCompile(`(?i:^(\d+)(st|rd|nd|th))`)
+	l.tokens.the = regexp.MustCompile(`(?i:^the\s)`)
+	l.tokens.name = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*`)
 }
 
 func LexerForString(s string) *yyLex {
@@ -101,10 +118,27 @@ func (l *yyLex) Lex(lval *yySymType) int {
 		l.eat(m)
 	}
 
+	/* calendar names (in "except dates in the X calendar") can
+	   look like anything, including keywords, so the word after
+	   IN (and an optional THE) is always lexed as a NAME. */
+	if l.wantName {
+		if m = l.tokens.the.FindSubmatch(l.buf); m != nil {
+			l.eat(m)
+			return THE
+		}
+		l.wantName = false
+		if m = l.tokens.name.FindSubmatch(l.buf); m != nil {
+			l.eat(m)
+			lval.name = string(m[0])
+			return NAME
+		}
+	}
+
 	for _, keyword := range l.keywords {
 		m = keyword.match.FindSubmatch(l.buf)
 		if m != nil {
 			l.eat(m)
+			l.wantName = keyword.token == IN
 			return keyword.token
 		}
 	}
@@ -2,6 +2,8 @@ package timespec
 
 import (
 	"fmt"
+	"math/rand"
+	"strings"
 	"time"
 )
 
@@ -27,6 +29,43 @@ type Spec struct {
 	DayOfMonth  int
 	Week        int
 	Cardinality float32
+
+	// Days lists the days of the week that a Weekly spec runs on,
+	// for schedules like "weekdays at 1am" that run on more than
+	// one day.  Single-day schedules use DayOfWeek instead.  Bounded
+	// specs use Days to limit which days their window applies to.
+	Days []time.Weekday
+
+	// Bounded Minutely and Hourly specs only run within a window of
+	// each day, for "every 30 minutes between 8am and 6pm", starting
+	// at BoundStart and running up to (and including) BoundEnd, both
+	// in minutes past midnight.  Windows that end before they start
+	// run on past midnight, into the next day.
+	Bounded    bool
+	BoundStart int
+	BoundEnd   int
+
+	// LastWeek and LastDayOfMonth count Monthly specs from the end
+	// of the month, for "last friday" and "last day of the month".
+	LastWeek       bool
+	LastDayOfMonth bool
+
+	// ExceptDays and ExceptCalendars come from the "except" clause,
+	// i.e. "daily at 2am except sundays and dates in the us-holidays
+	// calendar".  Occurrences that fall on an excluded day of the
+	// week, or on one of the ExceptDates, are skipped.  The timespec
+	// language only names the calendars; it is up to the caller to
+	// look up their dates and fill in ExceptDates (keyed YYYY-MM-DD).
+	ExceptDays      []time.Weekday
+	ExceptCalendars []string
+	ExceptDates     map[string]bool
+
+	// Offset shifts every occurrence of the schedule later by a fixed
+	// amount, and Jitter delays each occurrence by a further random
+	// amount, up to (but not including) the given duration.  Neither
+	// is part of the timespec language; they are set by the caller.
+	Offset time.Duration
+	Jitter time.Duration
 }
 
 func roundM(t time.Time) time.Time {
@@ -38,6 +77,40 @@ func offsetM(t time.Time, m int) time.Time {
 func nthWeek(t time.Time) int {
 	return int(t.Day()/7) + 1
 }
+func lastWeek(t time.Time) bool {
+	return t.AddDate(0, 0, 7).Month() != t.Month()
+}
+func lastDay(t time.Time) bool {
+	return t.AddDate(0, 0, 1).Month() != t.Month()
+}
+func epochDay(t time.Time) int64 {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400
+}
+
+func days(l []time.Weekday) string {
+	names := make([]string, len(l))
+	for i, d := range l {
+		names[i] = weekday(d)
+	}
+	switch s := strings.Join(names, ", "); s {
+	case "monday, tuesday, wednesday, thursday, friday":
+		return "weekdays"
+	case "sunday, saturday":
+		return "weekends"
+	default:
+		return s
+	}
+}
+
+func (s *Spec) runsOn(d time.Weekday) bool {
+	for _, day := range s.Days {
+		if day == d {
+			return true
+		}
+	}
+	return false
+}
 
 func ord(n int) string {
 	switch {
@@ -75,8 +148,30 @@ func weekday(d time.Weekday) string {
 }
 
 func (s *Spec) String() string {
+	str := s.str()
+	if len(s.ExceptDays) == 0 && len(s.ExceptCalendars) == 0 {
+		return str
+	}
+
+	l := []string{}
+	if len(s.ExceptDays) == 1 {
+		l = append(l, weekday(s.ExceptDays[0])+"s")
+	} else if len(s.ExceptDays) > 1 {
+		l = append(l, days(s.ExceptDays))
+	}
+	for _, name := range s.ExceptCalendars {
+		l = append(l, fmt.Sprintf("dates in the %s calendar", name))
+	}
+	return fmt.Sprintf("%s except %s", str, strings.Join(l, " and "))
+}
+
+func (s *Spec) str() string {
 	t := fmt.Sprintf("%d:%02d", s.TimeOfDay/60, s.TimeOfDay%60)
 
+	if s.Bounded {
+		return s.boundedStr()
+	}
+
 	if s.Interval == Minutely {
 		if s.Cardinality == 1.0 {
 			return "every minute"
@@ -110,12 +205,24 @@ func (s *Spec) String() string {
 		return fmt.Sprintf("every %d hours from %s", int(s.Cardinality), t)
 	}
 
-	if s.Interval == Daily {
+	if s.Interval == Daily && s.Cardinality > 1 {
+		return fmt.Sprintf("every %d days at %s", int(s.Cardinality), t)
+
+	} else if s.Interval == Daily {
 		return fmt.Sprintf("daily at %s", t)
 
+	} else if s.Interval == Weekly && len(s.Days) > 0 {
+		return fmt.Sprintf("%s at %s", days(s.Days), t)
+
 	} else if s.Interval == Weekly {
 		return fmt.Sprintf("%ss at %s", weekday(s.DayOfWeek), t)
 
+	} else if s.Interval == Monthly && s.LastWeek {
+		return fmt.Sprintf("last %s at %s", weekday(s.DayOfWeek), t)
+
+	} else if s.Interval == Monthly && s.LastDayOfMonth {
+		return fmt.Sprintf("last day of the month at %s", t)
+
 	} else if s.Interval == Monthly && s.Week != 0 {
 		return fmt.Sprintf("%d%s %s at %s", s.Week, ord(s.Week), weekday(s.DayOfWeek), t)
 
@@ -126,13 +233,62 @@ func (s *Spec) String() string {
 	return "<unknown interval>"
 }
 
+// Next returns the first occurrence of the schedule after t, taking
+// into account any Offset and Jitter.
 func (s *Spec) Next(t time.Time) (time.Time, error) {
+	if s.Offset <= 0 && s.Jitter <= 0 {
+		return s.nextIncluded(t)
+	}
+
+	/* find the first undelayed occurrence that, once delayed
+	   by the offset, still falls after t */
+	next, err := s.nextIncluded(t.Add(-1 * s.Offset))
+	if err != nil {
+		return t, err
+	}
+	next = next.Add(s.Offset)
+	if s.Jitter > 0 {
+		next = next.Add(time.Duration(rand.Int63n(int64(s.Jitter))))
+	}
+	return next, nil
+}
+
+// Excludes determines whether or not the given instant falls on a
+// day that the spec's except clause rules out.
+func (s *Spec) Excludes(t time.Time) bool {
+	for _, d := range s.ExceptDays {
+		if t.Weekday() == d {
+			return true
+		}
+	}
+	return s.ExceptDates[t.Format("2006-01-02")]
+}
+
+func (s *Spec) nextIncluded(t time.Time) (time.Time, error) {
+	for i := 0; i < 1000; i++ {
+		next, err := s.next(t)
+		if err != nil || !s.Excludes(next) {
+			return next, err
+		}
+
+		/* skip the rest of the excluded day */
+		y, m, d := next.Date()
+		t = time.Date(y, m, d+1, 0, 0, 0, 0, next.Location()).Add(-1 * time.Minute)
+	}
+	return t, fmt.Errorf("Cannot find an occurrence of '%s' that is not excluded", s)
+}
+
+func (s *Spec) next(t time.Time) (time.Time, error) {
 	t = roundM(t)
 	midnight := offsetM(t, -1*(t.Hour()*60+t.Minute()))
 
+	if s.Bounded {
+		return s.nextBounded(t, midnight)
+	}
+
 	if s.Interval == Minutely {
 		target := offsetM(midnight, s.TimeOfDay)
-		for i := 0; i < 1440; i++ { //Incrementing 1440 minutes in the worst case
+		for i := 0; i <= 1440; i++ { //Incrementing 1440 minutes in the worst case
 			if target.After(t) {
 				return target, nil
 			}
@@ -175,13 +331,33 @@ func (s *Spec) Next(t time.Time) (time.Time, error) {
 		return offsetM(target, 60), nil
 	}
 
-	if s.Interval == Daily {
+	if s.Interval == Daily && s.Cardinality > 1 {
+		/* every N days counts from the Unix epoch, so that
+		   the schedule doesn't drift as it gets re-evaluated */
+		n := int64(s.Cardinality)
+		target := offsetM(midnight, s.TimeOfDay)
+		for epochDay(target)%n != 0 || !target.After(t) {
+			target = offsetM(target, 1440)
+		}
+		return target, nil
+
+	} else if s.Interval == Daily {
 		target := offsetM(midnight, s.TimeOfDay)
 		if target.After(t) {
 			return target, nil
 		}
 		return offsetM(target, 1440), nil
 
+	} else if s.Interval == Weekly && len(s.Days) > 0 {
+		target := offsetM(midnight, s.TimeOfDay)
+		for i := 0; i < 8; i++ {
+			if s.runsOn(target.Weekday()) && target.After(t) {
+				return target, nil
+			}
+			target = offsetM(target, 1440)
+		}
+		return t, fmt.Errorf("Cannot calculate the next day of the week from %v", s.Days)
+
 	} else if s.Interval == Weekly {
 		target := offsetM(midnight, s.TimeOfDay)
 		for target.Weekday() != s.DayOfWeek {
@@ -192,6 +368,23 @@ func (s *Spec) Next(t time.Time) (time.Time, error) {
 		}
 		return target, nil
 
+	} else if s.Interval == Monthly && s.LastWeek {
+		target := offsetM(midnight, s.TimeOfDay)
+		for target.Weekday() != s.DayOfWeek || !target.After(t) {
+			target = offsetM(target, 1440)
+		}
+		for !lastWeek(target) {
+			target = offsetM(target, 1440*7)
+		}
+		return target, nil
+
+	} else if s.Interval == Monthly && s.LastDayOfMonth {
+		target := offsetM(midnight, s.TimeOfDay)
+		for !lastDay(target) || !target.After(t) {
+			target = offsetM(target, 1440)
+		}
+		return target, nil
+
 	} else if s.Interval == Monthly && s.Week != 0 {
 		if s.Week < 1 || s.Week > 5 {
 			return t, fmt.Errorf("Cannot calculate the %dth week in a month", s.Week)
@@ -218,3 +411,77 @@ func (s *Spec) Next(t time.Time) (time.Time, error) {
 
 	return t, fmt.Errorf("unhandled Interval for Spec")
 }
+
+func (s *Spec) boundedStr() string {
+	var every string
+	switch {
+	case s.Interval == Minutely && s.Cardinality == 1.0:
+		every = "every minute"
+	case s.Interval == Minutely:
+		every = fmt.Sprintf("every %d minutes", int(s.Cardinality))
+	case s.Cardinality == 0:
+		every = fmt.Sprintf("hourly at %d after", s.TimeOfHour)
+	case s.Cardinality == 0.25:
+		every = "every quarter hour"
+	case s.Cardinality == 0.5:
+		every = "every half hour"
+	default:
+		every = fmt.Sprintf("every %d hours", int(s.Cardinality))
+	}
+
+	str := fmt.Sprintf("%s between %s and %s", every, clock(s.BoundStart), clock(s.BoundEnd))
+	if len(s.Days) == 1 {
+		return fmt.Sprintf("%s on %ss", str, weekday(s.Days[0]))
+	} else if len(s.Days) > 1 {
+		return fmt.Sprintf("%s on %s", str, days(s.Days))
+	}
+	return str
+}
+
+// boundedRuns lists when a Bounded spec runs, in minutes past
+// midnight, on each day that it runs.  Runs in a window that wraps
+// past midnight come after 1440.
+func (s *Spec) boundedRuns() []int {
+	end := s.BoundEnd
+	if end < s.BoundStart {
+		end += 1440
+	}
+
+	first, every := s.BoundStart, int(s.Cardinality)
+	if s.Interval == Hourly {
+		every = int(60 * s.Cardinality)
+		if s.Cardinality == 0 {
+			/* hourly at :MM runs on the first :MM in the window */
+			every = 60
+			first += (s.TimeOfHour - s.BoundStart%60 + 60) % 60
+		}
+	}
+
+	l := []int{}
+	if every < 1 {
+		return l
+	}
+	for m := first; m <= end; m += every {
+		l = append(l, m)
+	}
+	return l
+}
+
+func (s *Spec) nextBounded(t, midnight time.Time) (time.Time, error) {
+	runs := s.boundedRuns()
+
+	/* start from yesterday, in case yesterday's window
+	   runs on past midnight, into today. */
+	for d := -1; d < 8; d++ {
+		day := offsetM(midnight, d*1440)
+		if len(s.Days) > 0 && !s.runsOn(day.Weekday()) {
+			continue
+		}
+		for _, m := range runs {
+			if target := offsetM(day, m); target.After(t) {
+				return target, nil
+			}
+		}
+	}
+	return t, fmt.Errorf("Cannot calculate the next run between %s and %s", clock(s.BoundStart), clock(s.BoundEnd))
+}
@@ -117,6 +117,72 @@ var _ = Describe("Timespec", func() {
 
 			Ω(spec.String()).Should(Equal("monthly at 2:05 on 14th"))
 		})
+
+		It("can stringify every-N-days specs", func() {
+			spec := &Spec{
+				Interval:    Daily,
+				Cardinality: 3,
+				TimeOfDay:   inMinutes(2, 00),
+			}
+
+			Ω(spec.String()).Should(Equal("every 3 days at 2:00"))
+		})
+
+		It("can stringify weekday set specs", func() {
+			spec := &Spec{
+				Interval:  Weekly,
+				Days:      []time.Weekday{time.Monday, time.Wednesday, time.Friday},
+				TimeOfDay: inMinutes(3, 00),
+			}
+			Ω(spec.String()).Should(Equal("monday, wednesday, friday at 3:00"))
+
+			spec.Days = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+			Ω(spec.String()).Should(Equal("weekdays at 3:00"))
+
+			spec.Days = []time.Weekday{time.Sunday, time.Saturday}
+			Ω(spec.String()).Should(Equal("weekends at 3:00"))
+		})
+
+		It("can stringify monthly (last weekday) specs", func() {
+			spec := &Spec{
+				Interval:  Monthly,
+				DayOfWeek: time.Friday,
+				LastWeek:  true,
+				TimeOfDay: inMinutes(23, 00),
+			}
+
+			Ω(spec.String()).Should(Equal("last friday at 23:00"))
+		})
+
+		It("can stringify monthly (last day) specs", func() {
+			spec := &Spec{
+				Interval:       Monthly,
+				LastDayOfMonth: true,
+				TimeOfDay:      inMinutes(23, 00),
+			}
+
+			Ω(spec.String()).Should(Equal("last day of the month at 23:00"))
+		})
+
+		It("stringifies new specs to something that parses back the same", func() {
+			for _, in := range []string{
+				"every 3 days at 2am",
+				"weekdays at 1am",
+				"weekends at 6pm",
+				"mon, wed, fri at 3am",
+				"last friday at 11pm",
+				"last day of the month at 11pm",
+				"daily at 2am except sundays",
+				"daily at 2am except sat, sun",
+				"hourly at 15 except weekends and dates in the us-holidays calendar",
+			} {
+				a, err := Parse(in)
+				Ω(err).ShouldNot(HaveOccurred())
+				b, err := Parse(a.String())
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(b).Should(Equal(a))
+			}
+		})
 	})
 
 	Describe("Determining the next timestamp from a spec object", func() {
@@ -601,6 +667,309 @@ var _ = Describe("Timespec", func() {
 		})
 	})
 
+	Describe("Determining the next timestamp from an extended spec object", func() {
+		// August 6th, 1991 (a Tuesday), at about 11:15 in the morning
+		tz := time.Now().Location()
+		now := time.Date(1991, 8, 6, 11, 15, 42, 100203, tz)
+
+		Context("with an every-N-days spec", func() {
+			It("counts days from the epoch", func() {
+				/* Aug 6th 1991 is day 7887 since the epoch,
+				   which is evenly divisible by 3 */
+				spec := &Spec{
+					Interval:    Daily,
+					Cardinality: 3,
+					TimeOfDay:   inMinutes(16, 00),
+				}
+				Ω(spec.Next(now)).Should(Equal(
+					time.Date(1991, 8, 6, 16, 00, 00, 00, tz)))
+
+				spec.TimeOfDay = inMinutes(2, 00)
+				Ω(spec.Next(now)).Should(Equal(
+					time.Date(1991, 8, 9, 2, 00, 00, 00, tz)))
+			})
+		})
+
+		Context("with a weekday set spec", func() {
+			It("handles the next timestamp being later that day", func() {
+				spec := &Spec{
+					Interval:  Weekly,
+					Days:      []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+					TimeOfDay: inMinutes(23, 00),
+				}
+
+				Ω(spec.Next(now)).Should(Equal(
+					time.Date(1991, 8, 6, 23, 00, 00, 00, tz)))
+			})
+
+			It("handles the next timestamp being later in the week", func() {
+				spec := &Spec{
+					Interval:  Weekly,
+					Days:      []time.Weekday{time.Monday, time.Wednesday, time.Friday},
+					TimeOfDay: inMinutes(3, 00),
+				}
+
+				Ω(spec.Next(now)).Should(Equal(
+					time.Date(1991, 8, 7, 3, 00, 00, 00, tz)))
+			})
+
+			It("handles the next timestamp being next week", func() {
+				spec := &Spec{
+					Interval:  Weekly,
+					Days:      []time.Weekday{time.Sunday, time.Tuesday},
+					TimeOfDay: inMinutes(1, 00),
+				}
+
+				Ω(spec.Next(now)).Should(Equal(
+					time.Date(1991, 8, 11, 1, 00, 00, 00, tz)))
+			})
+		})
+
+		Context("with a monthly (last weekday) spec", func() {
+			It("handles the next timestamp being later in the same month", func() {
+				spec := &Spec{
+					Interval:  Monthly,
+					DayOfWeek: time.Friday,
+					LastWeek:  true,
+					TimeOfDay: inMinutes(23, 00),
+				}
+
+				Ω(spec.Next(now)).Should(Equal(
+					time.Date(1991, 8, 30, 23, 00, 00, 00, tz)))
+			})
+
+			It("handles having just missed this month", func() {
+				spec := &Spec{
+					Interval:  Monthly,
+					DayOfWeek: time.Sunday,
+					LastWeek:  true,
+					TimeOfDay: inMinutes(23, 00),
+				}
+
+				Ω(spec.Next(time.Date(1991, 8, 26, 0, 00, 00, 00, tz))).Should(Equal(
+					time.Date(1991, 9, 29, 23, 00, 00, 00, tz)))
+			})
+		})
+
+		Context("with a monthly (last day) spec", func() {
+			It("handles the next timestamp being later in the same month", func() {
+				spec := &Spec{
+					Interval:       Monthly,
+					LastDayOfMonth: true,
+					TimeOfDay:      inMinutes(1, 00),
+				}
+
+				Ω(spec.Next(now)).Should(Equal(
+					time.Date(1991, 8, 31, 1, 00, 00, 00, tz)))
+			})
+
+			It("handles having just missed this month", func() {
+				spec := &Spec{
+					Interval:       Monthly,
+					LastDayOfMonth: true,
+					TimeOfDay:      inMinutes(1, 00),
+				}
+
+				Ω(spec.Next(time.Date(1991, 8, 31, 12, 00, 00, 00, tz))).Should(Equal(
+					time.Date(1991, 9, 30, 1, 00, 00, 00, tz)))
+			})
+		})
+	})
+
+	Describe("Determining the next timestamp from a bounded spec", func() {
+		// August 6th, 1991 (a Tuesday), at about 11:15 in the morning
+		tz := time.Now().Location()
+		now := time.Date(1991, 8, 6, 11, 15, 42, 100203, tz)
+
+		It("runs within the window", func() {
+			spec := &Spec{
+				Interval:    Minutely,
+				Cardinality: 30,
+				Bounded:     true,
+				BoundStart:  inMinutes(8, 00),
+				BoundEnd:    inMinutes(18, 00),
+			}
+			Ω(spec.Next(now)).Should(Equal(
+				time.Date(1991, 8, 6, 11, 30, 00, 00, tz)))
+			Ω(spec.Next(time.Date(1991, 8, 6, 17, 45, 00, 00, tz))).Should(Equal(
+				time.Date(1991, 8, 6, 18, 00, 00, 00, tz)))
+		})
+
+		It("skips ahead to the next window once the day's window is over", func() {
+			spec := &Spec{
+				Interval:    Hourly,
+				Cardinality: 2,
+				Bounded:     true,
+				BoundStart:  inMinutes(8, 00),
+				BoundEnd:    inMinutes(18, 00),
+			}
+			Ω(spec.Next(time.Date(1991, 8, 6, 18, 00, 00, 00, tz))).Should(Equal(
+				time.Date(1991, 8, 7, 8, 00, 00, 00, tz)))
+		})
+
+		It("runs hourly specs on the first matching minute in the window", func() {
+			spec := &Spec{
+				Interval:   Hourly,
+				TimeOfHour: 15,
+				Bounded:    true,
+				BoundStart: inMinutes(8, 30),
+				BoundEnd:   inMinutes(18, 00),
+			}
+			Ω(spec.Next(time.Date(1991, 8, 6, 7, 00, 00, 00, tz))).Should(Equal(
+				time.Date(1991, 8, 6, 9, 15, 00, 00, tz)))
+			Ω(spec.Next(time.Date(1991, 8, 6, 17, 15, 00, 00, tz))).Should(Equal(
+				time.Date(1991, 8, 7, 9, 15, 00, 00, tz)))
+		})
+
+		It("only runs on the given days", func() {
+			/* Aug 9th 1991 is a Friday */
+			spec := &Spec{
+				Interval:    Minutely,
+				Cardinality: 30,
+				Bounded:     true,
+				BoundStart:  inMinutes(8, 00),
+				BoundEnd:    inMinutes(18, 00),
+				Days:        []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+			}
+			Ω(spec.Next(time.Date(1991, 8, 9, 18, 00, 00, 00, tz))).Should(Equal(
+				time.Date(1991, 8, 12, 8, 00, 00, 00, tz)))
+		})
+
+		It("handles windows that run past midnight", func() {
+			spec := &Spec{
+				Interval:    Hourly,
+				Cardinality: 2,
+				Bounded:     true,
+				BoundStart:  inMinutes(22, 00),
+				BoundEnd:    inMinutes(4, 00),
+			}
+			Ω(spec.Next(now)).Should(Equal(
+				time.Date(1991, 8, 6, 22, 00, 00, 00, tz)))
+			Ω(spec.Next(time.Date(1991, 8, 7, 1, 00, 00, 00, tz))).Should(Equal(
+				time.Date(1991, 8, 7, 2, 00, 00, 00, tz)))
+			Ω(spec.Next(time.Date(1991, 8, 7, 4, 00, 00, 00, tz))).Should(Equal(
+				time.Date(1991, 8, 7, 22, 00, 00, 00, tz)))
+		})
+	})
+
+	Describe("Determining the next timestamp from a spec with exclusions", func() {
+		// August 6th, 1991 (a Tuesday), at about 11:15 in the morning
+		tz := time.Now().Location()
+		now := time.Date(1991, 8, 6, 11, 15, 42, 100203, tz)
+
+		It("skips excluded days of the week", func() {
+			spec := &Spec{
+				Interval:   Daily,
+				TimeOfDay:  inMinutes(2, 00),
+				ExceptDays: []time.Weekday{time.Wednesday, time.Thursday},
+			}
+
+			Ω(spec.Next(now)).Should(Equal(
+				time.Date(1991, 8, 9, 2, 00, 00, 00, tz)))
+		})
+
+		It("skips excluded dates", func() {
+			spec := &Spec{
+				Interval:    Daily,
+				TimeOfDay:   inMinutes(2, 00),
+				ExceptDates: map[string]bool{"1991-08-07": true},
+			}
+
+			Ω(spec.Next(now)).Should(Equal(
+				time.Date(1991, 8, 8, 2, 00, 00, 00, tz)))
+		})
+
+		It("skips the whole of an excluded day, for sub-daily specs", func() {
+			spec := &Spec{
+				Interval:    Minutely,
+				Cardinality: 1,
+				ExceptDates: map[string]bool{"1991-08-06": true},
+			}
+
+			Ω(spec.Next(now)).Should(Equal(
+				time.Date(1991, 8, 7, 0, 00, 00, 00, tz)))
+		})
+
+		It("applies offsets after skipping excluded days", func() {
+			spec := &Spec{
+				Interval:   Daily,
+				TimeOfDay:  inMinutes(23, 30),
+				ExceptDays: []time.Weekday{time.Tuesday},
+				Offset:     time.Hour,
+			}
+
+			Ω(spec.Next(now)).Should(Equal(
+				time.Date(1991, 8, 8, 0, 30, 00, 00, tz)))
+		})
+
+		It("throws errors when every occurrence is excluded", func() {
+			spec := &Spec{
+				Interval:   Weekly,
+				DayOfWeek:  time.Sunday,
+				TimeOfDay:  inMinutes(2, 00),
+				ExceptDays: []time.Weekday{time.Sunday},
+			}
+
+			_, err := spec.Next(now)
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Keeping N backups", func() {
+		It("accounts for every-N-days and weekday set specs", func() {
+			Ω((&Spec{Interval: Daily}).KeepN(30)).Should(Equal(30))
+			Ω((&Spec{Interval: Daily, Cardinality: 3}).KeepN(30)).Should(Equal(10))
+			Ω((&Spec{Interval: Weekly, DayOfWeek: time.Monday}).KeepN(28)).Should(Equal(4))
+			Ω((&Spec{Interval: Weekly, Days: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}}).KeepN(28)).Should(Equal(20))
+			Ω((&Spec{Interval: Monthly, LastWeek: true}).KeepN(90)).Should(Equal(3))
+			Ω((&Spec{Interval: Hourly, Cardinality: 0.25}).KeepN(1)).Should(Equal(96))
+		})
+
+		It("only counts runs within the window, for bounded specs", func() {
+			spec := &Spec{Interval: Minutely, Cardinality: 30, Bounded: true, BoundStart: inMinutes(8, 00), BoundEnd: inMinutes(18, 00)}
+			Ω(spec.KeepN(1)).Should(Equal(21))
+
+			spec.Days = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+			Ω(spec.KeepN(7)).Should(Equal(105))
+
+			spec = &Spec{Interval: Hourly, TimeOfHour: 15, Bounded: true, BoundStart: inMinutes(8, 00), BoundEnd: inMinutes(18, 00)}
+			Ω(spec.KeepN(2)).Should(Equal(20))
+
+			spec = &Spec{Interval: Hourly, Cardinality: 2, Bounded: true, BoundStart: inMinutes(22, 00), BoundEnd: inMinutes(4, 00)}
+			Ω(spec.KeepN(1)).Should(Equal(4))
+		})
+
+		It("does not count days ruled out by an except clause", func() {
+			spec, err := Parse("daily 3am except saturday,sunday")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(spec.KeepN(28)).Should(Equal(20))
+			Ω(spec.KeepN(7)).Should(Equal(5))
+
+			spec, err = Parse("hourly at 15 except weekends")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(spec.KeepN(14)).Should(Equal(240))
+
+			spec, err = Parse("mondays,wednesdays,fridays 3am except fridays")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(spec.KeepN(28)).Should(Equal(8))
+		})
+
+		It("does not count excluded calendar dates, once they are resolved", func() {
+			at := time.Date(2026, time.December, 31, 9, 0, 0, 0, time.UTC)
+
+			spec, err := Parse("daily 3am except dates in the holidays calendar")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(spec.KeepNAt(30, at)).Should(Equal(30))
+
+			spec.ExceptDates = map[string]bool{
+				"2026-12-24": true,
+				"2026-12-25": true,
+				"2026-11-01": true, /* outside the window */
+			}
+			Ω(spec.KeepNAt(30, at)).Should(Equal(28))
+		})
+	})
+
 	Describe("spec parser", func() {
 		Context("for hourly specs", func() {
 			specOK := func(spec string, m int) {
@@ -857,5 +1226,202 @@ var _ = Describe("Timespec", func() {
 				specOK("monthly 11:01pm on 19st", 19, 23, 01)
 			})
 		})
+
+		Context("for every-N-days specs", func() {
+			It("just works", func() {
+				s, err := Parse("every 3 days at 2am")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(s.Interval).Should(Equal(Daily))
+				Ω(s.Cardinality).Should(Equal(float32(3)))
+				Ω(s.TimeOfDay).Should(Equal(inMinutes(2, 00)))
+
+				s, err = Parse("every 1 day 2am")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(s.Interval).Should(Equal(Daily))
+				Ω(s.Cardinality).Should(Equal(float32(0)))
+			})
+
+			It("rejects every 0 days", func() {
+				_, err := Parse("every 0 days at 2am")
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+
+		Context("for weekday set specs", func() {
+			specOK := func(spec string, days []time.Weekday, h int, m int) {
+				s, err := Parse(spec)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(s).ShouldNot(BeNil())
+				Ω(s.Interval).Should(Equal(Weekly))
+				Ω(s.TimeOfDay).Should(Equal(h*60 + m))
+				Ω(s.Days).Should(Equal(days))
+			}
+			weekdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+			It("handles weekdays and weekends", func() {
+				specOK("weekdays at 1am", weekdays, 1, 00)
+				specOK("every weekday at 1am", weekdays, 1, 00)
+				specOK("Weekdays 1am", weekdays, 1, 00)
+				specOK("weekends at 6:30pm", []time.Weekday{time.Sunday, time.Saturday}, 18, 30)
+			})
+
+			It("handles lists of days", func() {
+				specOK("mon, wed, fri at 3am", []time.Weekday{time.Monday, time.Wednesday, time.Friday}, 3, 00)
+				specOK("fridays,mondays 3am", []time.Weekday{time.Monday, time.Friday}, 3, 00)
+				specOK("every tue, thu at 14:00", []time.Weekday{time.Tuesday, time.Thursday}, 14, 00)
+			})
+
+			It("collapses degenerate lists", func() {
+				s, err := Parse("mon, mon at 3am")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(s.Days).Should(BeEmpty())
+				Ω(s.DayOfWeek).Should(Equal(time.Monday))
+
+				s, err = Parse("sun, mon, tue, wed, thu, fri, sat at 3am")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(s.Interval).Should(Equal(Daily))
+			})
+		})
+
+		Context("for monthly specs (last-of-month flavor)", func() {
+			It("handles the last weekday of the month", func() {
+				s, err := Parse("last friday at 11pm")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(s.Interval).Should(Equal(Monthly))
+				Ω(s.LastWeek).Should(BeTrue())
+				Ω(s.DayOfWeek).Should(Equal(time.Friday))
+				Ω(s.TimeOfDay).Should(Equal(inMinutes(23, 00)))
+			})
+
+			It("handles the last day of the month", func() {
+				for _, spec := range []string{
+					"last day of the month at 11pm",
+					"last day of month 11pm",
+					"last day at 11pm",
+					"monthly at 11pm on last day",
+					"monthly 11pm last day",
+				} {
+					s, err := Parse(spec)
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(s.Interval).Should(Equal(Monthly))
+					Ω(s.LastDayOfMonth).Should(BeTrue())
+					Ω(s.LastWeek).Should(BeFalse())
+					Ω(s.TimeOfDay).Should(Equal(inMinutes(23, 00)))
+				}
+			})
+		})
+
+		Context("for bounded specs", func() {
+			It("handles minutely and hourly specs with a window", func() {
+				s, err := Parse("every 30 minutes between 8am and 6pm")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(s.Interval).Should(Equal(Minutely))
+				Ω(s.Cardinality).Should(Equal(float32(30)))
+				Ω(s.Bounded).Should(BeTrue())
+				Ω(s.BoundStart).Should(Equal(inMinutes(8, 00)))
+				Ω(s.BoundEnd).Should(Equal(inMinutes(18, 00)))
+				Ω(s.Days).Should(BeEmpty())
+
+				s, err = Parse("every 2 hours between 22:00 and 4:00")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(s.Interval).Should(Equal(Hourly))
+				Ω(s.Cardinality).Should(Equal(float32(2)))
+				Ω(s.BoundStart).Should(Equal(inMinutes(22, 00)))
+				Ω(s.BoundEnd).Should(Equal(inMinutes(4, 00)))
+
+				s, err = Parse("hourly at :15 between 9am and 5pm")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(s.Interval).Should(Equal(Hourly))
+				Ω(s.TimeOfHour).Should(Equal(15))
+				Ω(s.Bounded).Should(BeTrue())
+
+				s, err = Parse("every half hour between 9am and 5pm")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(s.Cardinality).Should(Equal(float32(0.5)))
+			})
+
+			It("handles windows limited to certain days", func() {
+				s, err := Parse("every 30 minutes between 8am and 6pm on weekdays")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(s.Days).Should(Equal([]time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}))
+
+				s, err = Parse("hourly between 8am and 6pm on saturdays")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(s.Days).Should(Equal([]time.Weekday{time.Saturday}))
+
+				s, err = Parse("every 15 minutes between 8am and 6pm on weekdays except dates in the us-holidays calendar")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(s.Bounded).Should(BeTrue())
+				Ω(s.ExceptCalendars).Should(Equal([]string{"us-holidays"}))
+			})
+
+			It("round-trips through String()", func() {
+				for _, spec := range []string{
+					"every 30 minutes between 08:00 and 18:00 on weekdays",
+					"every minute between 12:00 and 13:00",
+					"every 2 hours between 22:00 and 04:00",
+					"every quarter hour between 09:00 and 17:00 on mondays",
+					"hourly at 15 after between 09:00 and 17:00 on monday, wednesday, friday",
+				} {
+					s, err := Parse(spec)
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(s.String()).Should(Equal(spec))
+				}
+			})
+
+			It("rejects empty windows", func() {
+				_, err := Parse("every 30 minutes between 8am and 8am")
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+
+		Context("for specs with exclusions", func() {
+			It("handles excluded days of the week", func() {
+				s, err := Parse("daily at 2am except sundays")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(s.Interval).Should(Equal(Daily))
+				Ω(s.ExceptDays).Should(Equal([]time.Weekday{time.Sunday}))
+				Ω(s.ExceptCalendars).Should(BeEmpty())
+
+				s, err = Parse("hourly at 15 except weekends")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(s.ExceptDays).Should(Equal([]time.Weekday{time.Sunday, time.Saturday}))
+
+				s, err = Parse("daily 4am except fri, mon and wednesdays")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(s.ExceptDays).Should(Equal([]time.Weekday{time.Monday, time.Wednesday, time.Friday}))
+			})
+
+			It("handles excluded calendars", func() {
+				for _, spec := range []string{
+					"daily at 2am except dates in the us-holidays calendar",
+					"daily at 2am except dates in us-holidays",
+					"daily at 2am Except Date In The us-holidays Calendar",
+				} {
+					s, err := Parse(spec)
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(s.ExceptDays).Should(BeEmpty())
+					Ω(s.ExceptCalendars).Should(Equal([]string{"us-holidays"}))
+				}
+			})
+
+			It("treats calendar names as names, even if they look like keywords", func() {
+				s, err := Parse("daily at 2am except dates in the monthly-close calendar and dates in daily")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(s.ExceptCalendars).Should(Equal([]string{"monthly-close", "daily"}))
+			})
+
+			It("handles both at once", func() {
+				s, err := Parse("daily at 2am except sundays and dates in the us-holidays calendar")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(s.ExceptDays).Should(Equal([]time.Weekday{time.Sunday}))
+				Ω(s.ExceptCalendars).Should(Equal([]string{"us-holidays"}))
+			})
+
+			It("rejects schedules that exclude every day", func() {
+				_, err := Parse("daily at 2am except weekdays and weekends")
+				Ω(err).Should(HaveOccurred())
+			})
+		})
 	})
 })
@@ -109,3 +109,135 @@ func mweek(minutes int, weekday time.Weekday, week uint) *Spec {
 		Week:      int(week),
 	}
 }
+func ndays(minutes int, n uint) *Spec {
+	if n < 1 {
+		return &Spec{
+			Error: fmt.Errorf("Schedules cannot be created to run every 0 days"),
+		}
+	}
+	if n == 1 {
+		return daily(minutes)
+	}
+	return &Spec{
+		Interval:    Daily,
+		TimeOfDay:   minutes,
+		Cardinality: float32(n),
+	}
+}
+func weekdays(minutes int, days []time.Weekday) *Spec {
+	var set [7]bool
+	for _, d := range days {
+		set[d] = true
+	}
+
+	l := make([]time.Weekday, 0, 7)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if set[d] {
+			l = append(l, d)
+		}
+	}
+
+	switch len(l) {
+	case 1:
+		return weekly(minutes, l[0])
+	case 7:
+		return daily(minutes)
+	}
+	return &Spec{
+		Interval:  Weekly,
+		TimeOfDay: minutes,
+		Days:      l,
+	}
+}
+func mlast(minutes int, weekday time.Weekday) *Spec {
+	return &Spec{
+		Interval:  Monthly,
+		TimeOfDay: minutes,
+		DayOfWeek: weekday,
+		LastWeek:  true,
+	}
+}
+func mlastday(minutes int) *Spec {
+	return &Spec{
+		Interval:       Monthly,
+		TimeOfDay:      minutes,
+		LastDayOfMonth: true,
+	}
+}
+
+type exclusion struct {
+	days      []time.Weekday
+	calendars []string
+}
+
+func (e *exclusion) and(other *exclusion) *exclusion {
+	return &exclusion{
+		days:      append(e.days, other.days...),
+		calendars: append(e.calendars, other.calendars...),
+	}
+}
+
+func (s *Spec) except(e *exclusion) *Spec {
+	if s.Error != nil {
+		return s
+	}
+
+	var set [7]bool
+	for _, d := range e.days {
+		set[d] = true
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if set[d] {
+			s.ExceptDays = append(s.ExceptDays, d)
+		}
+	}
+	if len(s.ExceptDays) == 7 {
+		return &Spec{
+			Error: fmt.Errorf("Schedules cannot exclude every day of the week"),
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, name := range e.calendars {
+		if !seen[name] {
+			seen[name] = true
+			s.ExceptCalendars = append(s.ExceptCalendars, name)
+		}
+	}
+	return s
+}
+
+type bounds struct {
+	start int
+	end   int
+	days  []time.Weekday
+}
+
+func (s *Spec) between(b *bounds) *Spec {
+	if s.Error != nil {
+		return s
+	}
+	if b.start == b.end {
+		return &Spec{
+			Error: fmt.Errorf("Schedules cannot run between a time and itself"),
+		}
+	}
+
+	s.Bounded = true
+	s.BoundStart = b.start
+	s.BoundEnd = b.end
+
+	var set [7]bool
+	for _, d := range b.days {
+		set[d] = true
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if set[d] {
+			s.Days = append(s.Days, d)
+		}
+	}
+	if len(s.Days) == 7 {
+		s.Days = nil
+	}
+	return s
+}
@@ -0,0 +1,147 @@
+package timespec
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Window constrains when a task may run.  Tasks may only be started
+// while the window is open (between Start and End, which may wrap
+// around midnight), and must be finished by the next FinishBy after
+// they start.  All values are minutes past midnight, local time;
+// negative values mean "unconstrained".
+type Window struct {
+	Start    int
+	End      int
+	FinishBy int
+}
+
+var timeOfDay = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?\s*(am|pm)?$`)
+
+func parseTimeOfDay(s string) (int, error) {
+	m := timeOfDay.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if m == nil || (m[2] == "" && m[3] == "") {
+		return -1, fmt.Errorf("'%s' is not a valid time of day (try something like '22:00' or '10pm')", s)
+	}
+
+	hours, _ := strconv.Atoi(m[1])
+	minutes := 0
+	if m[2] != "" {
+		minutes, _ = strconv.Atoi(m[2])
+	}
+	if minutes > 59 {
+		return -1, fmt.Errorf("'%s' is not a valid time of day", s)
+	}
+
+	if m[3] != "" {
+		if hours < 1 || hours > 12 {
+			return -1, fmt.Errorf("'%s' is not a valid time of day", s)
+		}
+		return hhmm12(uint(hours), uint(minutes), m[3] == "am"), nil
+	}
+
+	if hours > 23 {
+		return -1, fmt.Errorf("'%s' is not a valid time of day", s)
+	}
+	return hhmm24(uint(hours), uint(minutes)), nil
+}
+
+func ParseWindow(start, end, finishBy string) (*Window, error) {
+	var err error
+	w := &Window{Start: -1, End: -1, FinishBy: -1}
+
+	if (start == "") != (end == "") {
+		return nil, fmt.Errorf("backup windows need both a start and an end time")
+	}
+	if start != "" {
+		if w.Start, err = parseTimeOfDay(start); err != nil {
+			return nil, err
+		}
+		if w.End, err = parseTimeOfDay(end); err != nil {
+			return nil, err
+		}
+		if w.Start == w.End {
+			return nil, fmt.Errorf("backup window cannot start and end at the same time")
+		}
+	}
+
+	if finishBy != "" {
+		if w.FinishBy, err = parseTimeOfDay(finishBy); err != nil {
+			return nil, err
+		}
+		if w.Start >= 0 && w.contains(w.FinishBy) {
+			return nil, fmt.Errorf("backup window must close (at %s) before the finish-by time (%s)", clock(w.End), clock(w.FinishBy))
+		}
+	}
+
+	return w, nil
+}
+
+func clock(m int) string {
+	return fmt.Sprintf("%02d:%02d", m/60, m%60)
+}
+
+func minuteOfDay(t time.Time) int {
+	return t.Hour()*60 + t.Minute()
+}
+
+func (w *Window) contains(m int) bool {
+	if w.Start <= w.End {
+		return m >= w.Start && m < w.End
+	}
+	return m >= w.Start || m < w.End
+}
+
+// Open returns true if a task may be started at time t.
+func (w *Window) Open(t time.Time) bool {
+	if w == nil || w.Start < 0 {
+		return true
+	}
+	return w.contains(minuteOfDay(t))
+}
+
+// Opens returns the next time, at or after t, that the window is open.
+func (w *Window) Opens(t time.Time) time.Time {
+	if w.Open(t) {
+		return t
+	}
+	t = roundM(t)
+	midnight := offsetM(t, -1*minuteOfDay(t))
+	target := offsetM(midnight, w.Start)
+	if !target.After(t) {
+		target = offsetM(target, 1440)
+	}
+	return target
+}
+
+// Deadline returns the time by which a task started at t must be
+// finished, or the zero time if the window has no finish-by time.
+func (w *Window) Deadline(t time.Time) time.Time {
+	if w == nil || w.FinishBy < 0 {
+		return time.Time{}
+	}
+	t = roundM(t)
+	midnight := offsetM(t, -1*minuteOfDay(t))
+	target := offsetM(midnight, w.FinishBy)
+	if !target.After(t) {
+		target = offsetM(target, 1440)
+	}
+	return target
+}
+
+func (w *Window) String() string {
+	l := []string{}
+	if w.Start >= 0 {
+		l = append(l, fmt.Sprintf("start between %s and %s", clock(w.Start), clock(w.End)))
+	}
+	if w.FinishBy >= 0 {
+		l = append(l, fmt.Sprintf("finish by %s", clock(w.FinishBy)))
+	}
+	if len(l) == 0 {
+		return "anytime"
+	}
+	return strings.Join(l, ", ")
+}
@@ -0,0 +1,94 @@
+package timespec_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/shieldproject/shield/timespec"
+)
+
+var _ = Describe("Backup Windows", func() {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2018, time.March, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	Describe("Parsing", func() {
+		It("parses 24-hour and 12-hour times of day", func() {
+			w, err := ParseWindow("22:00", "4am", "6:30am")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(w.Start).Should(Equal(22 * 60))
+			Ω(w.End).Should(Equal(4 * 60))
+			Ω(w.FinishBy).Should(Equal(6*60 + 30))
+			Ω(w.String()).Should(Equal("start between 22:00 and 04:00, finish by 06:30"))
+		})
+
+		It("treats empty values as unconstrained", func() {
+			w, err := ParseWindow("", "", "")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(w.Start).Should(Equal(-1))
+			Ω(w.FinishBy).Should(Equal(-1))
+			Ω(w.String()).Should(Equal("anytime"))
+		})
+
+		It("rejects malformed windows", func() {
+			_, err := ParseWindow("22:00", "", "")
+			Ω(err).Should(HaveOccurred())
+
+			_, err = ParseWindow("25:00", "04:00", "")
+			Ω(err).Should(HaveOccurred())
+
+			_, err = ParseWindow("13pm", "04:00", "")
+			Ω(err).Should(HaveOccurred())
+
+			_, err = ParseWindow("22", "04:00", "")
+			Ω(err).Should(HaveOccurred())
+
+			_, err = ParseWindow("04:00", "04:00", "")
+			Ω(err).Should(HaveOccurred())
+
+			_, err = ParseWindow("22:00", "04:00", "02:00")
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Evaluation", func() {
+		It("handles windows that wrap around midnight", func() {
+			w, err := ParseWindow("22:00", "04:00", "06:00")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(w.Open(at(1, 21, 59))).Should(BeFalse())
+			Ω(w.Open(at(1, 22, 0))).Should(BeTrue())
+			Ω(w.Open(at(1, 23, 30))).Should(BeTrue())
+			Ω(w.Open(at(2, 3, 59))).Should(BeTrue())
+			Ω(w.Open(at(2, 4, 0))).Should(BeFalse())
+			Ω(w.Open(at(2, 12, 0))).Should(BeFalse())
+
+			Ω(w.Opens(at(2, 12, 0))).Should(Equal(at(2, 22, 0)))
+			Ω(w.Opens(at(2, 23, 0))).Should(Equal(at(2, 23, 0)))
+
+			Ω(w.Deadline(at(1, 23, 0))).Should(Equal(at(2, 6, 0)))
+			Ω(w.Deadline(at(2, 1, 0))).Should(Equal(at(2, 6, 0)))
+		})
+
+		It("handles windows within a single day", func() {
+			w, err := ParseWindow("1am", "3am", "")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(w.Open(at(1, 0, 59))).Should(BeFalse())
+			Ω(w.Open(at(1, 1, 0))).Should(BeTrue())
+			Ω(w.Open(at(1, 3, 0))).Should(BeFalse())
+			Ω(w.Opens(at(1, 3, 0))).Should(Equal(at(2, 1, 0)))
+			Ω(w.Deadline(at(1, 1, 0)).IsZero()).Should(BeTrue())
+		})
+
+		It("is always open without a start / end", func() {
+			w, err := ParseWindow("", "", "6am")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(w.Open(at(1, 12, 0))).Should(BeTrue())
+			Ω(w.Deadline(at(1, 12, 0))).Should(Equal(at(2, 6, 0)))
+		})
+	})
+})
@@ -6,6 +6,7 @@ package timespec
 import __yyfmt__ "fmt"
 
 //line lang.y:2
+
 import (
 	"time"
 )
@@ -16,36 +17,53 @@ type yySymType struct {
 	numval uint
 	time   int
 	wday   time.Weekday
+	wdays  []time.Weekday
 	spec   *Spec
+	except *exclusion
+	bounds *bounds
+	name   string
 	truth  bool
 }
 
 const NUMBER = 57346
 const ORDINAL = 57347
-const HOURLY = 57348
-const DAILY = 57349
-const WEEKLY = 57350
-const MONTHLY = 57351
-const FROM = 57352
-const AT = 57353
-const ON = 57354
-const AM = 57355
-const PM = 57356
-const HALF = 57357
-const EVERY = 57358
-const DAY = 57359
-const MINUTE = 57360
-const HOUR = 57361
-const QUARTER = 57362
-const AFTER = 57363
-const TIL = 57364
-const SUNDAY = 57365
-const MONDAY = 57366
-const TUESDAY = 57367
-const WEDNESDAY = 57368
-const THURSDAY = 57369
-const FRIDAY = 57370
-const SATURDAY = 57371
+const NAME = 57348
+const HOURLY = 57349
+const DAILY = 57350
+const WEEKLY = 57351
+const MONTHLY = 57352
+const FROM = 57353
+const AT = 57354
+const ON = 57355
+const AM = 57356
+const PM = 57357
+const HALF = 57358
+const EVERY = 57359
+const DAY = 57360
+const MINUTE = 57361
+const HOUR = 57362
+const QUARTER = 57363
+const AFTER = 57364
+const TIL = 57365
+const SUNDAY = 57366
+const MONDAY = 57367
+const TUESDAY = 57368
+const WEDNESDAY = 57369
+const THURSDAY = 57370
+const FRIDAY = 57371
+const SATURDAY = 57372
+const WEEKDAYS = 57373
+const WEEKENDS = 57374
+const LAST = 57375
+const OF = 57376
+const THE = 57377
+const MONTH = 57378
+const EXCEPT = 57379
+const DATES = 57380
+const IN = 57381
+const AND = 57382
+const CALENDAR = 57383
+const BETWEEN = 57384
 
 var yyToknames = [...]string{
 	"$end",
@@ -53,6 +71,7 @@ var yyToknames = [...]string{
 	"$unk",
 	"NUMBER",
 	"ORDINAL",
+	"NAME",
 	"HOURLY",
 	"DAILY",
 	"WEEKLY",
@@ -77,6 +96,18 @@ var yyToknames = [...]string{
 	"THURSDAY",
 	"FRIDAY",
 	"SATURDAY",
+	"WEEKDAYS",
+	"WEEKENDS",
+	"LAST",
+	"OF",
+	"THE",
+	"MONTH",
+	"EXCEPT",
+	"DATES",
+	"IN",
+	"AND",
+	"CALENDAR",
+	"BETWEEN",
 	"'h'",
 	"'H'",
 	"'x'",
@@ -84,139 +115,188 @@ var yyToknames = [...]string{
 	"'*'",
 	"':'",
 	"' '",
+	"','",
 }
+
 var yyStatenames = [...]string{}
 
 const yyEofCode = 1
 const yyErrCode = 2
 const yyInitialStackSize = 16
 
-//line lang.y:134
+//line lang.y:214
 
 //line yacctab:1
-var yyExca = [...]int{
+var yyExca = [...]int8{
 	-1, 1,
 	1, -1,
 	-2, 0,
-	-1, 31,
-	1, 31,
-	-2, 29,
+	-1, 40,
+	22, 53,
+	23, 53,
+	-2, 55,
 }
 
 const yyPrivate = 57344
 
-const yyLast = 169
-
-var yyAct = [...]int{
-
-	64, 74, 12, 31, 66, 67, 59, 53, 41, 52,
-	54, 42, 29, 42, 39, 66, 67, 49, 77, 38,
-	44, 46, 48, 82, 60, 61, 63, 65, 81, 33,
-	34, 35, 36, 37, 50, 51, 57, 42, 100, 55,
-	80, 58, 66, 67, 56, 79, 86, 70, 42, 62,
-	42, 85, 68, 31, 71, 47, 72, 45, 78, 30,
-	28, 42, 76, 75, 39, 84, 87, 83, 43, 38,
-	91, 89, 90, 1, 92, 93, 76, 75, 42, 33,
-	34, 35, 36, 37, 73, 40, 94, 99, 95, 96,
-	7, 101, 31, 102, 97, 98, 76, 75, 6, 5,
-	4, 103, 3, 39, 2, 32, 0, 0, 38, 0,
-	0, 0, 14, 9, 10, 11, 13, 0, 33, 34,
-	35, 36, 37, 8, 0, 0, 88, 0, 0, 0,
-	15, 16, 17, 18, 19, 20, 21, 15, 16, 17,
-	18, 19, 20, 21, 69, 15, 16, 17, 18, 19,
-	20, 21, 22, 0, 0, 15, 16, 17, 18, 19,
-	20, 21, 0, 25, 0, 27, 23, 26, 24,
+const yyLast = 302
+
+var yyAct = [...]uint8{
+	68, 12, 69, 13, 106, 38, 51, 94, 57, 34,
+	65, 35, 88, 67, 42, 37, 62, 63, 54, 56,
+	59, 61, 127, 177, 172, 125, 96, 97, 135, 96,
+	97, 117, 118, 40, 27, 74, 171, 121, 79, 52,
+	81, 83, 85, 87, 115, 49, 176, 82, 78, 91,
+	48, 52, 86, 42, 76, 100, 42, 92, 102, 55,
+	98, 164, 101, 93, 95, 103, 116, 104, 42, 111,
+	113, 119, 43, 44, 45, 46, 47, 120, 122, 154,
+	124, 126, 128, 152, 151, 57, 75, 130, 169, 131,
+	168, 132, 133, 129, 109, 108, 146, 57, 174, 139,
+	140, 173, 42, 137, 109, 108, 89, 90, 155, 142,
+	144, 52, 40, 141, 73, 71, 72, 147, 136, 148,
+	77, 150, 134, 167, 49, 114, 156, 39, 157, 48,
+	158, 153, 52, 143, 1, 161, 109, 108, 66, 165,
+	149, 159, 162, 160, 163, 105, 166, 109, 108, 40,
+	42, 43, 44, 45, 46, 47, 170, 36, 96, 97,
+	52, 49, 52, 52, 7, 107, 48, 6, 123, 5,
+	112, 110, 175, 4, 52, 178, 145, 179, 3, 2,
+	26, 15, 84, 9, 10, 11, 14, 42, 43, 44,
+	45, 46, 47, 8, 41, 0, 0, 0, 0, 0,
+	17, 18, 19, 20, 21, 22, 23, 24, 25, 16,
+	17, 18, 19, 20, 21, 22, 23, 24, 25, 28,
+	52, 52, 52, 0, 70, 0, 0, 0, 80, 60,
+	58, 31, 0, 33, 29, 32, 30, 0, 0, 17,
+	18, 19, 20, 21, 22, 23, 24, 25, 17, 18,
+	19, 20, 21, 22, 23, 24, 25, 138, 52, 52,
+	0, 0, 0, 0, 99, 0, 53, 50, 17, 18,
+	19, 20, 21, 22, 23, 17, 18, 19, 20, 21,
+	22, 23, 64, 0, 0, 0, 0, 0, 17, 18,
+	19, 20, 21, 22, 23, 17, 18, 19, 20, 21,
+	22, 23,
 }
-var yyPact = [...]int{
-
-	107, -1000, -1000, -1000, -1000, -1000, -1000, -1000, 148, 49,
-	74, 57, 46, 44, 122, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, 16, -1000, -10, -12, -1, 33, 88, -1000,
-	-29, -1000, 3, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	9, -1000, -9, 9, 132, 9, -1000, 9, 72, 7,
-	35, 30, 18, 13, 88, -1000, 9, -1000, -1000, 47,
-	-1000, -1000, -1000, 42, -1000, 29, -1000, -1000, 114, 122,
-	-1000, -1000, 58, 92, -1000, -1000, -1000, 9, -1000, 9,
-	9, 88, 88, -1000, -1000, -1000, 2, -1000, 122, -1000,
-	-1000, 92, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	29, -1000, -1000, -1000,
+
+var yyPact = [...]int16{
+	176, -1000, -3, -1000, -1000, -1000, -1000, -1000, 215, 145,
+	255, 254, 47, 218, 217, 271, 264, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, -40, 186, 96, -28,
+	66, 34, 108, 216, 35, 170, 29, -28, -1000, -36,
+	-1000, 84, 107, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	107, -1000, 15, 107, 251, 107, -1000, 271, 107, -1000,
+	107, 132, 159, 158, 10, 271, -9, -1000, -42, -1000,
+	-7, 60, 26, 156, -1000, 14, 11, 29, -1000, -1000,
+	107, -1000, 107, -1000, 107, -1000, -28, -1000, 118, -1000,
+	-1000, -12, -1000, 114, -1000, 144, -1000, -1000, 244, 271,
+	-1000, -1000, -1000, -1000, 100, 143, -1000, 78, -1000, -1000,
+	107, -1000, 107, -1000, 128, 48, -1000, 186, 73, 107,
+	-1000, 107, -1000, 107, -1000, 29, -1000, 29, -1000, -28,
+	-1000, -1000, -1000, -1000, -1000, 107, 12, -1000, 271, -1000,
+	-1000, 90, -1000, 72, -1000, 70, -1000, -1000, -1000, 107,
+	-1000, -1000, 0, -1000, -17, 95, -1000, -1000, -1000, -1000,
+	-1000, -1000, 85, -1000, 144, -1000, -1000, 28, -1000, -1000,
+	-1000, -1000, -1000, -18, 224, -1000, -1000, -1000, -42, -1000,
 }
-var yyPgo = [...]int{
 
-	0, 12, 8, 1, 105, 2, 104, 102, 100, 99,
-	98, 90, 0, 73, 59,
+var yyPgo = [...]uint8{
+	0, 15, 6, 4, 194, 0, 180, 2, 179, 178,
+	173, 169, 167, 164, 7, 13, 138, 5, 134, 127,
+	125,
 }
-var yyR1 = [...]int{
-
-	0, 13, 6, 6, 6, 6, 6, 7, 7, 7,
-	8, 8, 8, 8, 8, 8, 8, 9, 9, 9,
-	9, 14, 14, 14, 14, 14, 14, 4, 4, 4,
-	1, 1, 1, 1, 2, 2, 2, 2, 2, 10,
-	10, 10, 10, 10, 10, 12, 12, 5, 5, 5,
-	5, 5, 5, 5, 11, 11, 11, 11, 11, 11,
-	3, 3,
+
+var yyR1 = [...]int8{
+	0, 18, 18, 16, 16, 15, 15, 15, 15, 15,
+	15, 8, 8, 8, 8, 8, 9, 9, 9, 9,
+	9, 10, 10, 10, 10, 10, 10, 10, 10, 10,
+	10, 10, 10, 10, 10, 10, 17, 17, 17, 11,
+	11, 11, 11, 11, 11, 19, 19, 19, 19, 19,
+	19, 4, 4, 4, 1, 1, 1, 1, 2, 2,
+	2, 2, 2, 12, 12, 12, 12, 12, 12, 12,
+	12, 12, 12, 12, 12, 7, 7, 7, 6, 6,
+	14, 14, 5, 5, 5, 5, 5, 5, 5, 13,
+	13, 13, 13, 13, 13, 13, 13, 13, 13, 13,
+	13, 13, 13, 20, 20, 20, 3, 3,
 }
-var yyR2 = [...]int{
-
-	0, 1, 1, 1, 1, 1, 1, 5, 2, 3,
-	3, 2, 5, 5, 4, 3, 5, 3, 2, 4,
-	3, 0, 1, 1, 1, 1, 1, 1, 1, 1,
-	3, 1, 2, 2, 3, 4, 5, 2, 3, 5,
-	4, 4, 3, 3, 2, 1, 1, 1, 1, 1,
-	1, 1, 1, 1, 5, 4, 4, 3, 4, 3,
-	1, 1,
+
+var yyR2 = [...]int8{
+	0, 1, 3, 1, 3, 1, 1, 3, 4, 4,
+	5, 1, 1, 1, 1, 1, 5, 2, 3, 3,
+	4, 3, 2, 5, 5, 4, 3, 5, 2, 4,
+	3, 3, 5, 4, 4, 4, 4, 6, 6, 3,
+	2, 4, 3, 5, 4, 0, 1, 1, 1, 1,
+	1, 1, 1, 1, 3, 1, 2, 2, 3, 4,
+	5, 2, 3, 5, 4, 4, 3, 3, 2, 4,
+	3, 3, 2, 4, 3, 1, 1, 1, 3, 3,
+	1, 1, 1, 1, 1, 1, 1, 1, 1, 5,
+	4, 4, 3, 4, 3, 4, 3, 5, 4, 6,
+	5, 5, 4, 0, 2, 3, 1, 1,
 }
-var yyChk = [...]int{
-
-	-1000, -13, -6, -7, -8, -9, -10, -11, 16, 6,
-	7, 8, -5, 9, 5, 23, 24, 25, 26, 27,
-	28, 29, 4, 18, 20, 15, 19, 17, 11, -1,
-	-14, 4, -4, 30, 31, 32, 33, 34, 20, 15,
-	11, -2, 4, 11, -2, 11, -2, 11, -2, -5,
-	18, 19, 19, 19, 11, -1, 11, -2, -1, 35,
-	21, 22, -2, 35, -12, 36, 13, 14, -2, 12,
-	-5, -2, -2, 12, -3, 5, 4, 11, -2, 10,
-	10, 10, 10, -1, -2, 4, 4, -12, 12, -5,
-	-5, 12, -3, -3, -2, -2, -2, -1, -1, -12,
-	36, -5, -3, -12,
+
+var yyChk = [...]int16{
+	-1000, -18, -8, -9, -10, -11, -12, -13, 17, 7,
+	8, 9, -5, -7, 10, 5, 33, 24, 25, 26,
+	27, 28, 29, 30, 31, 32, -6, 37, 4, 19,
+	21, 16, 20, 18, -5, -7, 12, -1, -17, -19,
+	4, -4, 42, 43, 44, 45, 46, 47, 21, 16,
+	12, -2, 4, 12, -2, 12, -2, 50, 12, -2,
+	12, -2, -5, -5, 18, 50, -16, -15, -5, -7,
+	38, 19, 20, 18, -17, 20, 20, 12, -1, -17,
+	12, -2, 12, -2, 12, -2, -1, -17, 48, 22,
+	23, -2, -2, 48, -14, 49, 14, 15, -2, 13,
+	-5, -2, -5, -2, -2, 13, -3, 33, 5, 4,
+	12, -2, 12, -2, -20, 34, -5, 40, 39, 11,
+	-17, 11, -17, 12, -2, 11, -17, 11, -17, -1,
+	-2, -2, -2, -17, 4, 40, 4, -14, 13, -5,
+	-5, 13, -3, 33, -3, 33, 18, -2, -2, 12,
+	-2, 36, 35, -15, 6, 35, -2, -2, -2, -1,
+	-1, -17, -2, -14, 49, -5, -3, 33, 18, 18,
+	-2, 36, 41, 6, 13, -14, 18, 41, -5, -7,
 }
-var yyDef = [...]int{
-
-	0, -2, 1, 2, 3, 4, 5, 6, 0, 21,
-	0, 0, 0, 0, 0, 47, 48, 49, 50, 51,
-	52, 53, 0, 8, 0, 0, 21, 0, 21, 11,
-	0, -2, 0, 22, 23, 24, 25, 26, 27, 28,
-	0, 18, 0, 0, 0, 0, 44, 0, 0, 0,
-	9, 0, 0, 0, 21, 15, 0, 20, 10, 0,
-	32, 33, 17, 0, 37, 0, 45, 46, 0, 0,
-	42, 43, 0, 0, 57, 60, 61, 0, 59, 0,
-	0, 21, 21, 14, 19, 30, 34, 38, 0, 41,
-	40, 0, 56, 55, 58, 7, 16, 12, 13, 35,
-	0, 39, 54, 36,
+
+var yyDef = [...]int8{
+	0, -2, 1, 11, 12, 13, 14, 15, 0, 45,
+	0, 0, 0, 0, 0, 0, 0, 82, 83, 84,
+	85, 86, 87, 88, 75, 76, 77, 0, 0, 17,
+	0, 0, 45, 0, 0, 0, 45, 22, 28, 0,
+	-2, 0, 0, 46, 47, 48, 49, 50, 51, 52,
+	0, 40, 0, 0, 0, 0, 68, 0, 0, 72,
+	0, 0, 0, 0, 103, 0, 2, 3, 5, 6,
+	0, 18, 0, 0, 19, 0, 0, 45, 26, 31,
+	0, 42, 0, 70, 0, 74, 21, 30, 0, 56,
+	57, 0, 39, 0, 61, 0, 80, 81, 0, 0,
+	66, 67, 78, 71, 0, 0, 92, 0, 106, 107,
+	0, 94, 0, 96, 0, 0, 79, 0, 0, 0,
+	20, 0, 35, 0, 44, 45, 33, 45, 34, 25,
+	41, 69, 73, 29, 54, 0, 58, 62, 0, 65,
+	64, 0, 91, 0, 90, 0, 102, 93, 95, 0,
+	98, 104, 0, 4, 7, 0, 16, 27, 43, 23,
+	24, 32, 36, 59, 0, 63, 89, 0, 101, 100,
+	97, 105, 8, 9, 0, 60, 99, 10, 37, 38,
 }
-var yyTok1 = [...]int{
 
+var yyTok1 = [...]int8{
 	1, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 36, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 34, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 3, 3, 3, 3, 3, 3, 35, 3,
+	3, 3, 49, 3, 3, 3, 3, 3, 3, 3,
+	3, 3, 47, 3, 50, 3, 3, 3, 3, 3,
+	3, 3, 3, 3, 3, 3, 3, 3, 48, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 31, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 3, 3, 3, 3, 3, 3, 33, 3,
+	3, 3, 44, 3, 3, 3, 3, 3, 3, 3,
+	3, 3, 3, 3, 3, 3, 3, 3, 46, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 3, 3, 30, 3, 3, 3, 3, 3,
+	3, 3, 3, 3, 43, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	32,
+	45,
 }
-var yyTok2 = [...]int{
 
+var yyTok2 = [...]int8{
 	2, 3, 4, 5, 6, 7, 8, 9, 10, 11,
 	12, 13, 14, 15, 16, 17, 18, 19, 20, 21,
-	22, 23, 24, 25, 26, 27, 28, 29,
+	22, 23, 24, 25, 26, 27, 28, 29, 30, 31,
+	32, 33, 34, 35, 36, 37, 38, 39, 40, 41,
+	42,
 }
-var yyTok3 = [...]int{
+
+var yyTok3 = [...]int8{
 	0,
 }
 
@@ -298,9 +378,9 @@ func yyErrorMessage(state, lookAhead int) string {
 	expected := make([]int, 0, 4)
 
 	// Look for shiftable tokens.
-	base := yyPact[state]
+	base := int(yyPact[state])
 	for tok := TOKSTART; tok-1 < len(yyToknames); tok++ {
-		if n := base + tok; n >= 0 && n < yyLast && yyChk[yyAct[n]] == tok {
+		if n := base + tok; n >= 0 && n < yyLast && int(yyChk[int(yyAct[n])]) == tok {
 			if len(expected) == cap(expected) {
 				return res
 			}
@@ -310,13 +390,13 @@ func yyErrorMessage(state, lookAhead int) string {
 
 	if yyDef[state] == -2 {
 		i := 0
-		for yyExca[i] != -1 || yyExca[i+1] != state {
+		for yyExca[i] != -1 || int(yyExca[i+1]) != state {
 			i += 2
 		}
 
 		// Look for tokens that we accept or reduce.
 		for i += 2; yyExca[i] >= 0; i += 2 {
-			tok := yyExca[i]
+			tok := int(yyExca[i])
 			if tok < TOKSTART || yyExca[i+1] == 0 {
 				continue
 			}
@@ -347,30 +427,30 @@ func yylex1(lex yyLexer, lval *yySymType) (char, token int) {
 	token = 0
 	char = lex.Lex(lval)
 	if char <= 0 {
-		token = yyTok1[0]
+		token = int(yyTok1[0])
 		goto out
 	}
 	if char < len(yyTok1) {
-		token = yyTok1[char]
+		token = int(yyTok1[char])
 		goto out
 	}
 	if char >= yyPrivate {
 		if char < yyPrivate+len(yyTok2) {
-			token = yyTok2[char-yyPrivate]
+			token = int(yyTok2[char-yyPrivate])
 			goto out
 		}
 	}
 	for i := 0; i < len(yyTok3); i += 2 {
-		token = yyTok3[i+0]
+		token = int(yyTok3[i+0])
 		if token == char {
-			token = yyTok3[i+1]
+			token = int(yyTok3[i+1])
 			goto out
 		}
 	}
 
 out:
 	if token == 0 {
-		token = yyTok2[1] /* unknown char */
+		token = int(yyTok2[1]) /* unknown char */
 	}
 	if yyDebug >= 3 {
 		__yyfmt__.Printf("lex %s(%d)\n", yyTokname(token), uint(char))
@@ -425,7 +505,7 @@ yystack:
 	yyS[yyp].yys = yystate
 
 yynewstate:
-	yyn = yyPact[yystate]
+	yyn = int(yyPact[yystate])
 	if yyn <= yyFlag {
 		goto yydefault /* simple state */
 	}
@@ -436,8 +516,8 @@ yynewstate:
 	if yyn < 0 || yyn >= yyLast {
 		goto yydefault
 	}
-	yyn = yyAct[yyn]
-	if yyChk[yyn] == yytoken { /* valid shift */
+	yyn = int(yyAct[yyn])
+	if int(yyChk[yyn]) == yytoken { /* valid shift */
 		yyrcvr.char = -1
 		yytoken = -1
 		yyVAL = yyrcvr.lval
@@ -450,7 +530,7 @@ yynewstate:
 
 yydefault:
 	/* default state action */
-	yyn = yyDef[yystate]
+	yyn = int(yyDef[yystate])
 	if yyn == -2 {
 		if yyrcvr.char < 0 {
 			yyrcvr.char, yytoken = yylex1(yylex, &yyrcvr.lval)
@@ -459,18 +539,18 @@ yydefault:
 		/* look through exception table */
 		xi := 0
 		for {
-			if yyExca[xi+0] == -1 && yyExca[xi+1] == yystate {
+			if yyExca[xi+0] == -1 && int(yyExca[xi+1]) == yystate {
 				break
 			}
 			xi += 2
 		}
 		for xi += 2; ; xi += 2 {
-			yyn = yyExca[xi+0]
+			yyn = int(yyExca[xi+0])
 			if yyn < 0 || yyn == yytoken {
 				break
 			}
 		}
-		yyn = yyExca[xi+1]
+		yyn = int(yyExca[xi+1])
 		if yyn < 0 {
 			goto ret0
 		}
@@ -492,10 +572,10 @@ yydefault:
 
 			/* find a state where "error" is a legal shift action */
 			for yyp >= 0 {
-				yyn = yyPact[yyS[yyp].yys] + yyErrCode
+				yyn = int(yyPact[yyS[yyp].yys]) + yyErrCode
 				if yyn >= 0 && yyn < yyLast {
-					yystate = yyAct[yyn] /* simulate a shift of "error" */
-					if yyChk[yystate] == yyErrCode {
+					yystate = int(yyAct[yyn]) /* simulate a shift of "error" */
+					if int(yyChk[yystate]) == yyErrCode {
 						goto yystack
 					}
 				}
@@ -531,7 +611,7 @@ yydefault:
 	yypt := yyp
 	_ = yypt // guard against "declared and not used"
 
-	yyp -= yyR2[yyn]
+	yyp -= int(yyR2[yyn])
 	// yyp is now the index of $0. Perform the default action. Iff the
 	// reduced production is ε, $1 is possibly out of range.
 	if yyp+1 >= len(yyS) {
@@ -542,16 +622,16 @@ yydefault:
 	yyVAL = yyS[yyp+1]
 
 	/* consult goto table to find next state */
-	yyn = yyR1[yyn]
-	yyg := yyPgo[yyn]
+	yyn = int(yyR1[yyn])
+	yyg := int(yyPgo[yyn])
 	yyj := yyg + yyS[yyp].yys + 1
 
 	if yyj >= yyLast {
-		yystate = yyAct[yyg]
+		yystate = int(yyAct[yyg])
 	} else {
-		yystate = yyAct[yyj]
-		if yyChk[yystate] != -yyn {
-			yystate = yyAct[yyg]
+		yystate = int(yyAct[yyj])
+		if int(yyChk[yystate]) != -yyn {
+			yystate = int(yyAct[yyg])
 		}
 	}
 	// dummy call; replaced with literal code
@@ -559,292 +639,538 @@ yydefault:
 
 	case 1:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line lang.y:53
+//line lang.y:73
 		{
 			yylex.(*yyLex).spec = yyDollar[1].spec
 		}
+	case 2:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line lang.y:76
+		{
+			yylex.(*yyLex).spec = yyDollar[1].spec.except(yyDollar[3].except)
+		}
+	case 4:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line lang.y:82
+		{
+			yyVAL.except = yyDollar[1].except.and(yyDollar[3].except)
+		}
+	case 5:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line lang.y:85
+		{
+			yyVAL.except = &exclusion{days: []time.Weekday{yyDollar[1].wday}}
+		}
+	case 6:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line lang.y:86
+		{
+			yyVAL.except = &exclusion{days: yyDollar[1].wdays}
+		}
 	case 7:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line lang.y:87
+		{
+			yyVAL.except = &exclusion{calendars: []string{yyDollar[3].name}}
+		}
+	case 8:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line lang.y:88
+		{
+			yyVAL.except = &exclusion{calendars: []string{yyDollar[3].name}}
+		}
+	case 9:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line lang.y:89
+		{
+			yyVAL.except = &exclusion{calendars: []string{yyDollar[4].name}}
+		}
+	case 10:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line lang.y:61
+//line lang.y:90
+		{
+			yyVAL.except = &exclusion{calendars: []string{yyDollar[4].name}}
+		}
+	case 16:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line lang.y:96
 		{
 			yyVAL.spec = minutely(yyDollar[5].time, int(yyDollar[2].numval))
 		}
-	case 8:
+	case 17:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line lang.y:62
+//line lang.y:97
 		{
 			yyVAL.spec = minutely(0, 1)
 		}
-	case 9:
+	case 18:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line lang.y:63
+//line lang.y:98
 		{
 			yyVAL.spec = minutely(0, int(yyDollar[2].numval))
 		}
-	case 10:
+	case 19:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line lang.y:99
+		{
+			yyVAL.spec = minutely(0, 1).between(yyDollar[3].bounds)
+		}
+	case 20:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line lang.y:100
+		{
+			yyVAL.spec = minutely(0, int(yyDollar[2].numval)).between(yyDollar[4].bounds)
+		}
+	case 21:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line lang.y:66
+//line lang.y:103
 		{
 			yyVAL.spec = hourly(yyDollar[3].time, 0)
 		}
-	case 11:
+	case 22:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line lang.y:67
+//line lang.y:104
 		{
 			yyVAL.spec = hourly(yyDollar[2].time, 0)
 		}
-	case 12:
+	case 23:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line lang.y:68
+//line lang.y:105
 		{
 			yyVAL.spec = hourly(yyDollar[5].time, 0.25)
 		}
-	case 13:
+	case 24:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line lang.y:69
+//line lang.y:106
 		{
 			yyVAL.spec = hourly(yyDollar[5].time, 0.5)
 		}
-	case 14:
+	case 25:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line lang.y:70
+//line lang.y:107
 		{
 			yyVAL.spec = hourly(yyDollar[4].time, 0)
 		}
-	case 15:
+	case 26:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line lang.y:71
+//line lang.y:108
 		{
 			yyVAL.spec = hourly(yyDollar[3].time, 0)
 		}
-	case 16:
+	case 27:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line lang.y:72
+//line lang.y:109
 		{
 			yyVAL.spec = hourly(yyDollar[5].time, float32(yyDollar[2].numval))
 		}
-	case 17:
+	case 28:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line lang.y:110
+		{
+			yyVAL.spec = hourly(0, 0).between(yyDollar[2].bounds)
+		}
+	case 29:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line lang.y:111
+		{
+			yyVAL.spec = hourly(yyDollar[3].time, 0).between(yyDollar[4].bounds)
+		}
+	case 30:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line lang.y:75
+//line lang.y:112
+		{
+			yyVAL.spec = hourly(yyDollar[2].time, 0).between(yyDollar[3].bounds)
+		}
+	case 31:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line lang.y:113
+		{
+			yyVAL.spec = hourly(0, 0).between(yyDollar[3].bounds)
+		}
+	case 32:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line lang.y:114
+		{
+			yyVAL.spec = hourly(yyDollar[4].time, 0).between(yyDollar[5].bounds)
+		}
+	case 33:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line lang.y:115
+		{
+			yyVAL.spec = hourly(0, 0.25).between(yyDollar[4].bounds)
+		}
+	case 34:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line lang.y:116
+		{
+			yyVAL.spec = hourly(0, 0.5).between(yyDollar[4].bounds)
+		}
+	case 35:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line lang.y:117
+		{
+			yyVAL.spec = hourly(0, float32(yyDollar[2].numval)).between(yyDollar[4].bounds)
+		}
+	case 36:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line lang.y:120
+		{
+			yyVAL.bounds = &bounds{start: yyDollar[2].time, end: yyDollar[4].time}
+		}
+	case 37:
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line lang.y:121
+		{
+			yyVAL.bounds = &bounds{start: yyDollar[2].time, end: yyDollar[4].time, days: []time.Weekday{yyDollar[6].wday}}
+		}
+	case 38:
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line lang.y:122
+		{
+			yyVAL.bounds = &bounds{start: yyDollar[2].time, end: yyDollar[4].time, days: yyDollar[6].wdays}
+		}
+	case 39:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line lang.y:125
 		{
 			yyVAL.spec = daily(yyDollar[3].time)
 		}
-	case 18:
+	case 40:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line lang.y:76
+//line lang.y:126
 		{
 			yyVAL.spec = daily(yyDollar[2].time)
 		}
-	case 19:
+	case 41:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line lang.y:77
+//line lang.y:127
 		{
 			yyVAL.spec = daily(yyDollar[4].time)
 		}
-	case 20:
+	case 42:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line lang.y:78
+//line lang.y:128
 		{
 			yyVAL.spec = daily(yyDollar[3].time)
 		}
-	case 27:
+	case 43:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line lang.y:129
+		{
+			yyVAL.spec = ndays(yyDollar[5].time, yyDollar[2].numval)
+		}
+	case 44:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line lang.y:130
+		{
+			yyVAL.spec = ndays(yyDollar[4].time, yyDollar[2].numval)
+		}
+	case 51:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line lang.y:83
+//line lang.y:135
 		{
 			yyVAL.numval = 15
 		}
-	case 28:
+	case 52:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line lang.y:84
+//line lang.y:136
 		{
 			yyVAL.numval = 30
 		}
-	case 29:
+	case 53:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line lang.y:85
+//line lang.y:137
 		{
 			yyVAL.numval = yyDollar[1].numval
 		}
-	case 30:
+	case 54:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line lang.y:88
+//line lang.y:140
 		{
 			yyVAL.time = hhmm24(0, yyDollar[3].numval)
 		}
-	case 31:
+	case 55:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line lang.y:89
+//line lang.y:141
 		{
 			yyVAL.time = hhmm24(0, yyDollar[1].numval)
 		}
-	case 32:
+	case 56:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line lang.y:90
+//line lang.y:142
 		{
 			yyVAL.time = hhmm24(0, yyDollar[1].numval)
 		}
-	case 33:
+	case 57:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line lang.y:91
+//line lang.y:143
 		{
 			yyVAL.time = hhmm24(0, 60-yyDollar[1].numval)
 		}
-	case 34:
+	case 58:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line lang.y:94
+//line lang.y:146
 		{
 			yyVAL.time = hhmm24(yyDollar[1].numval, yyDollar[3].numval)
 		}
-	case 35:
+	case 59:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line lang.y:95
+//line lang.y:147
 		{
 			yyVAL.time = hhmm12(yyDollar[1].numval, yyDollar[3].numval, yyDollar[4].truth)
 		}
-	case 36:
+	case 60:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line lang.y:96
+//line lang.y:148
 		{
 			yyVAL.time = hhmm12(yyDollar[1].numval, yyDollar[3].numval, yyDollar[5].truth)
 		}
-	case 37:
+	case 61:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line lang.y:97
+//line lang.y:149
 		{
 			yyVAL.time = hhmm12(yyDollar[1].numval, 0, yyDollar[2].truth)
 		}
-	case 38:
+	case 62:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line lang.y:98
+//line lang.y:150
 		{
 			yyVAL.time = hhmm12(yyDollar[1].numval, 0, yyDollar[3].truth)
 		}
-	case 39:
+	case 63:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line lang.y:101
+//line lang.y:153
 		{
 			yyVAL.spec = weekly(yyDollar[3].time, yyDollar[5].wday)
 		}
-	case 40:
+	case 64:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line lang.y:102
+//line lang.y:154
 		{
 			yyVAL.spec = weekly(yyDollar[2].time, yyDollar[4].wday)
 		}
-	case 41:
+	case 65:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line lang.y:103
+//line lang.y:155
 		{
 			yyVAL.spec = weekly(yyDollar[3].time, yyDollar[4].wday)
 		}
-	case 42:
+	case 66:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line lang.y:104
+//line lang.y:156
 		{
 			yyVAL.spec = weekly(yyDollar[2].time, yyDollar[3].wday)
 		}
-	case 43:
+	case 67:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line lang.y:105
+//line lang.y:157
 		{
 			yyVAL.spec = weekly(yyDollar[3].time, yyDollar[1].wday)
 		}
-	case 44:
+	case 68:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line lang.y:106
+//line lang.y:158
 		{
 			yyVAL.spec = weekly(yyDollar[2].time, yyDollar[1].wday)
 		}
-	case 45:
+	case 69:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line lang.y:159
+		{
+			yyVAL.spec = weekly(yyDollar[4].time, yyDollar[2].wday)
+		}
+	case 70:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line lang.y:160
+		{
+			yyVAL.spec = weekly(yyDollar[3].time, yyDollar[2].wday)
+		}
+	case 71:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line lang.y:161
+		{
+			yyVAL.spec = weekdays(yyDollar[3].time, yyDollar[1].wdays)
+		}
+	case 72:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line lang.y:162
+		{
+			yyVAL.spec = weekdays(yyDollar[2].time, yyDollar[1].wdays)
+		}
+	case 73:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line lang.y:163
+		{
+			yyVAL.spec = weekdays(yyDollar[4].time, yyDollar[2].wdays)
+		}
+	case 74:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line lang.y:164
+		{
+			yyVAL.spec = weekdays(yyDollar[3].time, yyDollar[2].wdays)
+		}
+	case 75:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line lang.y:109
+//line lang.y:167
+		{
+			yyVAL.wdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+		}
+	case 76:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line lang.y:168
+		{
+			yyVAL.wdays = []time.Weekday{time.Saturday, time.Sunday}
+		}
+	case 78:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line lang.y:172
+		{
+			yyVAL.wdays = []time.Weekday{yyDollar[1].wday, yyDollar[3].wday}
+		}
+	case 79:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line lang.y:173
+		{
+			yyVAL.wdays = append(yyDollar[1].wdays, yyDollar[3].wday)
+		}
+	case 80:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line lang.y:176
 		{
 			yyVAL.truth = true
 		}
-	case 46:
+	case 81:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line lang.y:110
+//line lang.y:177
 		{
 			yyVAL.truth = false
 		}
-	case 47:
+	case 82:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line lang.y:113
+//line lang.y:180
 		{
 			yyVAL.wday = time.Sunday
 		}
-	case 48:
+	case 83:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line lang.y:114
+//line lang.y:181
 		{
 			yyVAL.wday = time.Monday
 		}
-	case 49:
+	case 84:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line lang.y:115
+//line lang.y:182
 		{
 			yyVAL.wday = time.Tuesday
 		}
-	case 50:
+	case 85:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line lang.y:116
+//line lang.y:183
 		{
 			yyVAL.wday = time.Wednesday
 		}
-	case 51:
+	case 86:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line lang.y:117
+//line lang.y:184
 		{
 			yyVAL.wday = time.Thursday
 		}
-	case 52:
+	case 87:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line lang.y:118
+//line lang.y:185
 		{
 			yyVAL.wday = time.Friday
 		}
-	case 53:
+	case 88:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line lang.y:119
+//line lang.y:186
 		{
 			yyVAL.wday = time.Saturday
 		}
-	case 54:
+	case 89:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line lang.y:122
+//line lang.y:189
 		{
 			yyVAL.spec = mday(yyDollar[3].time, yyDollar[5].numval)
 		}
-	case 55:
+	case 90:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line lang.y:123
+//line lang.y:190
 		{
 			yyVAL.spec = mday(yyDollar[2].time, yyDollar[4].numval)
 		}
-	case 56:
+	case 91:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line lang.y:124
+//line lang.y:191
 		{
 			yyVAL.spec = mday(yyDollar[3].time, yyDollar[4].numval)
 		}
-	case 57:
+	case 92:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line lang.y:125
+//line lang.y:192
 		{
 			yyVAL.spec = mday(yyDollar[2].time, yyDollar[3].numval)
 		}
-	case 58:
+	case 93:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line lang.y:126
+//line lang.y:193
 		{
 			yyVAL.spec = mweek(yyDollar[4].time, yyDollar[2].wday, yyDollar[1].numval)
 		}
-	case 59:
+	case 94:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line lang.y:127
+//line lang.y:194
 		{
 			yyVAL.spec = mweek(yyDollar[3].time, yyDollar[2].wday, yyDollar[1].numval)
 		}
+	case 95:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line lang.y:195
+		{
+			yyVAL.spec = mlast(yyDollar[4].time, yyDollar[2].wday)
+		}
+	case 96:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line lang.y:196
+		{
+			yyVAL.spec = mlast(yyDollar[3].time, yyDollar[2].wday)
+		}
+	case 97:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line lang.y:197
+		{
+			yyVAL.spec = mlastday(yyDollar[5].time)
+		}
+	case 98:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line lang.y:198
+		{
+			yyVAL.spec = mlastday(yyDollar[4].time)
+		}
+	case 99:
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line lang.y:199
+		{
+			yyVAL.spec = mlastday(yyDollar[3].time)
+		}
+	case 100:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line lang.y:200
+		{
+			yyVAL.spec = mlastday(yyDollar[2].time)
+		}
+	case 101:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line lang.y:201
+		{
+			yyVAL.spec = mlastday(yyDollar[3].time)
+		}
+	case 102:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line lang.y:202
+		{
+			yyVAL.spec = mlastday(yyDollar[2].time)
+		}
 	}
 	goto yystack /* stack new state and value */
 }
@@ -252,6 +252,25 @@
       }) /* }}} */
       /* }}} */
 
+       /* "Unlock user" links (href="unlock-user:...") */
+       .on('click', 'a[href^="unlock-user:"]', function (event) { /* {{{ */
+        event.preventDefault();
+        var uuid = $(event.target).closest('a[href^="unlock-user:"]').attr('href')
+                                  .replace(/^unlock-user:/, '');
+        var name = $(event.target).extract('user-name');
+
+        api({
+          type: 'POST',
+          url:  '/v2/auth/local/users/'+uuid+'/unlock',
+          success: function () {
+            banner('Unlocked User "'+name+'"');
+            goto('#!/admin/users');
+          },
+          error: function () {
+            banner('unable to unlock', 'error');
+          }
+        });
+      }) /* }}} */
        /* "Delete user" links (href="delete-user:...") */
        .on('click', 'a[href^="delete-user:"], button[rel^="delete-user:"]', function (event) { /* {{{ */
         event.preventDefault();
@@ -423,7 +442,11 @@
           api({
             type: 'POST',
             url:  '/v2/tenants/'+AEGIS.current.uuid+'/archives/'+uuid+'/restore',
-            success: function() {
+            success: function(data, status, xhr) {
+              if (xhr.status == 202) {
+                banner("restore operation is awaiting approval by a second person");
+                return;
+              }
               banner("restore operation started");
               redraw(false);
             },
@@ -434,6 +457,45 @@
         });
       }) /* }}} */
 
+      /* "Approve" / "Reject" links (href="approve:..." / href="reject:...") */
+      .on('click', 'a[href^="approve:"], a[href^="reject:"]', function (event) { /* {{{ */
+        event.preventDefault();
+        var uuid   = $(event.target).extract('approval-uuid');
+        var action = $(event.target).closest('a').attr('href').replace(/:.*$/, '');
+
+        var decide = function () {
+          api({
+            type: 'POST',
+            url:  '/v2/tenants/'+AEGIS.current.uuid+'/approvals/'+uuid+'/'+action,
+            success: function (approval) {
+              banner('Request '+approval.status+': '+approval.summary);
+              goto('#!/approvals');
+            },
+            error: function (xhr) {
+              banner('unable to '+action+' request: '+(xhr.responseJSON ? xhr.responseJSON.error : 'an unknown error has occurred'), 'error');
+              goto('#!/approvals');
+            }
+          });
+        };
+
+        if (action == 'reject') {
+          decide();
+          return;
+        }
+        api({
+          type: 'GET',
+          url:  '/v2/tenants/'+AEGIS.current.uuid+'/approvals/'+uuid,
+          error: "Failed retrieving the approval request from the SHIELD API.",
+          success: function (approval) {
+            modal($.template('approve-are-you-sure', { approval: approval }))
+              .on('click', '[rel=yes]', function (event) {
+                event.preventDefault();
+                decide();
+              });
+          }
+        });
+      }) /* }}} */
+
       /* SHIELD Unlock Form */
       .on('submit', 'form#unlock-shield', function (event) { /* {{{ */
         event.preventDefault();
@@ -693,7 +755,12 @@
             $form.submitting(false);
             $(event.target).removeClass('submitting');
           },
-          success: function () {
+          success: function (approval, status, xhr) {
+            if (xhr.status == 202) {
+              banner("restore operation is awaiting approval by a second person");
+              goto('#!/approvals');
+              return;
+            }
             goto('#!/systems/system:uuid:'+data.target.uuid);
           }
         });
@@ -65,12 +65,14 @@ function dispatch(page) {
           })
           .on("submit", "form", function (event) {
             event.preventDefault()
-            progress('local SHIELD authentication');
 
             var $form = $(event.target);
             var data = $form.serializeObject();
+            progress(data.provider ? $form.find('[name=provider] option:selected').text()
+                                   : 'local SHIELD authentication');
             $form.reset();
 
+            var mfa = false;
             api({
               type: "POST",
               url:  "/v2/auth/login",
@@ -80,10 +82,23 @@ function dispatch(page) {
                 document.location.href = "/"
               },
               error: function (xhr) {
-                $(event.target).error(xhr.responseJSON);
+                /* accounts with multi-factor authentication need a second
+                   go-round, with the code from their authenticator app */
+                var e = xhr.responseJSON || {};
+                if (data.code || $.inArray('code', e.missing || []) >= 0) {
+                  mfa = true;
+                  $form.find('[name=provider]').val(data.provider);
+                  $form.find('[name=username]').val(data.username);
+                  $form.find('[name=password]').val(data.password);
+                  $form.find('.ctl.mfa').show().find('input').focus();
+                }
+                $(event.target).error(e);
               },
               complete: function () {
                 $('#viewport').find('#logging-in').remove();
+                if (mfa) {
+                  return;
+                }
                 //using the systems page as our landing page when a user logs in
                 document.location.href = "/#!/systems"
               }
@@ -514,6 +529,22 @@ function dispatch(page) {
     break; /* #!/stores/delete */
     // }}}
 
+  case '#!/approvals': /* {{{ */
+    if (!AEGIS.current) {
+      $('#main').template('you-have-no-tenants');
+      break;
+    }
+    $('#main').template('loading');
+    api({
+      type: 'GET',
+      url:  '/v2/tenants/'+AEGIS.current.uuid+'/approvals?limit=100',
+      error: "Failed retrieving the list of approval requests from the SHIELD API.",
+      success: function (data) {
+        $('#main').template('approvals', { approvals: data });
+      }
+    });
+    break; /* #!/approvals */
+    // }}}
   case '#!/tenants/edit': /* {{{ */
     if (!AEGIS.current) {
         $('#main').template('you-have-no-tenants');